@@ -22,6 +22,11 @@ var ErrInputNotSet = errors.New("variable is not allocated")
 // ErrUnsatisfiedConstraint can be generated when solving a R1CS
 var ErrUnsatisfiedConstraint = errors.New("constraint is not satisfied")
 
+// ErrInputOverflow can be generated when solving the R1CS: the absolute value of an assignment is not
+// smaller than the modulus of the scalar field (the values are reduced modulo the field, so a negative
+// value -x is p-x)
+var ErrInputOverflow = errors.New("value overflows the scalar field")
+
 // note: this types are shared between frontend and backend packages and are here to avoid import cycles
 // probably need a better naming / home for them
 
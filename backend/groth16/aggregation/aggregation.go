@@ -0,0 +1,292 @@
+// Copyright 2020 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package aggregation aggregates Groth16 proofs of the same circuit on BN256 into a single proof, whose
+// size and verification cost are logarithmic in the number of proofs (apart from the public inputs)
+//
+// the protocol follows SnarkPack (https://eprint.iacr.org/2021/529): the prover commits to the proofs
+// with pairing-based commitments, proves that the random linear combination of the Groth16 equations
+// is computed from the committed proofs with two inner product arguments (TIPP for the pairings
+// e(Ar, Bs), MIPP for Krs), and opens the final commitment keys with KZG.
+//
+// the keys of the aggregation are powers of two secrets a and b, which don't depend on the circuit;
+// KeysFromPowersOfTau derives them from two Powers-of-Tau ceremonies
+package aggregation
+
+import (
+	"crypto/sha256"
+	"errors"
+	"math/big"
+	"math/bits"
+
+	"github.com/consensys/gnark/backend/groth16/mpcsetup"
+	curve "github.com/consensys/gurvy/bn256"
+	"github.com/consensys/gurvy/bn256/fr"
+)
+
+var (
+	errInvalidProof  = errors.New("aggregation: invalid aggregated proof")
+	errTooManyProofs = errors.New("aggregation: the keys are too short for the number of proofs")
+	errNoProof       = errors.New("aggregation: no proof to aggregate")
+	errCommitment    = errors.New("aggregation: circuits with a commitment are not supported")
+	errZeroChallenge = errors.New("aggregation: zero challenge")
+	errNotBN256      = errors.New("aggregation: only BN256 proofs are supported")
+)
+
+// ProvingKey is used to aggregate up to N proofs, N a power of 2
+type ProvingKey struct {
+	G1 struct {
+		A, B []curve.G1Affine // [aⁱ]1, [bⁱ]1 for i < 2N
+	}
+	G2 struct {
+		A, B []curve.G2Affine // [aⁱ]2, [bⁱ]2 for i < N
+	}
+}
+
+// VerifyingKey is used to verify aggregated proofs
+type VerifyingKey struct {
+	G1 struct {
+		A, B curve.G1Affine // [a]1, [b]1
+	}
+	G2 struct {
+		A, B curve.G2Affine // [a]2, [b]2
+	}
+}
+
+// Setup returns keys to aggregate up to n proofs, from secrets a and b sampled by a single party
+//
+// anyone knowing a or b can forge aggregated proofs: for production use, see KeysFromPowersOfTau
+func Setup(n int) (*ProvingKey, *VerifyingKey, error) {
+	N := nextPowerOfTwo(n)
+	var a, b fr.Element
+	if _, err := a.SetRandom(); err != nil {
+		return nil, nil, err
+	}
+	if _, err := b.SetRandom(); err != nil {
+		return nil, nil, err
+	}
+
+	_, _, g1, g2 := curve.Generators()
+	aPowers, bPowers := powers(a, 2*N), powers(b, 2*N)
+	for i := range aPowers {
+		aPowers[i].FromMont()
+		bPowers[i].FromMont()
+	}
+
+	var pk ProvingKey
+	pk.G1.A = curve.BatchScalarMultiplicationG1(&g1, aPowers)
+	pk.G1.B = curve.BatchScalarMultiplicationG1(&g1, bPowers)
+	pk.G2.A = curve.BatchScalarMultiplicationG2(&g2, aPowers[:N])
+	pk.G2.B = curve.BatchScalarMultiplicationG2(&g2, bPowers[:N])
+	return &pk, pk.verifyingKey(), nil
+}
+
+// KeysFromPowersOfTau returns keys from the output of two independent Powers-of-Tau ceremonies
+// (see mpcsetup), whose τ are a and b; ceremonies for 2^p constraints aggregate up to 2^(p-1) proofs
+//
+// the aggregation is sound as long as one contributor of each ceremony is honest. The ceremonies
+// must be verified, and distinct
+func KeysFromPowersOfTau(srsA, srsB *mpcsetup.Phase1) (*ProvingKey, *VerifyingKey, error) {
+	pa, pb := &srsA.Parameters, &srsB.Parameters
+	N := len(pa.G2.Tau) / 2
+	if len(pb.G2.Tau)/2 < N {
+		N = len(pb.G2.Tau) / 2
+	}
+	if N == 0 {
+		return nil, nil, errors.New("aggregation: the ceremonies are too short")
+	}
+	if pa.G1.Tau[1].Equal(&pb.G1.Tau[1]) {
+		return nil, nil, errors.New("aggregation: the ceremonies must be distinct")
+	}
+
+	var pk ProvingKey
+	pk.G1.A = append([]curve.G1Affine{}, pa.G1.Tau[:2*N]...)
+	pk.G1.B = append([]curve.G1Affine{}, pb.G1.Tau[:2*N]...)
+	pk.G2.A = append([]curve.G2Affine{}, pa.G2.Tau[:N]...)
+	pk.G2.B = append([]curve.G2Affine{}, pb.G2.Tau[:N]...)
+	return &pk, pk.verifyingKey(), nil
+}
+
+func (pk *ProvingKey) verifyingKey() *VerifyingKey {
+	var vk VerifyingKey
+	vk.G1.A, vk.G1.B = pk.G1.A[1], pk.G1.B[1]
+	vk.G2.A, vk.G2.B = pk.G2.A[1], pk.G2.B[1]
+	return &vk
+}
+
+// Proof is the aggregation of n Groth16 proofs (Ar, Bs, Krs), padded to a power of 2 with copies of
+// the last proof
+type Proof struct {
+	// commitments to the proofs: (T, U) = (e(Ar, v₁)·e(w₁, Bs), e(Ar, v₂)·e(w₂, Bs)) and (e(Krs, v₁), e(Krs, v₂)),
+	// for the keys v₁ = [aⁱ]2, v₂ = [bⁱ]2, w₁ = [aⁿ⁺ⁱ]1, w₂ = [bⁿ⁺ⁱ]1
+	ComAB, ComC [2]curve.GT
+
+	// Π e(Arᵢ, Bsᵢ)^rⁱ and Σ rⁱ.Krsᵢ
+	ZAB curve.GT
+	ZC  curve.G1Affine
+
+	// the rounds of the inner product arguments, which halve the vectors
+	Rounds []Round
+
+	// the vectors and the keys reduced to a single element
+	A, C   curve.G1Affine
+	B      curve.G2Affine
+	V1, V2 curve.G2Affine
+	W1, W2 curve.G1Affine
+
+	// KZG openings of the final keys
+	OpeningV1, OpeningV2 curve.G2Affine
+	OpeningW1, OpeningW2 curve.G1Affine
+}
+
+// Round holds the cross terms of a round of the inner product arguments, for the left and right halves
+type Round struct {
+	ComABL, ComABR [2]curve.GT
+	ComCL, ComCR   [2]curve.GT
+	ZABL, ZABR     curve.GT
+	ZCL, ZCR       curve.G1Affine
+}
+
+// transcript derives the challenges of the Fiat-Shamir transform
+type transcript struct {
+	state []byte
+}
+
+// challenge hashes the state and the data, and returns the new state reduced modulo r
+func (t *transcript) challenge(data ...[]byte) (fr.Element, error) {
+	h := sha256.New()
+	h.Write(t.state)
+	for _, d := range data {
+		h.Write(d)
+	}
+	t.state = h.Sum(nil)
+
+	var x fr.Element
+	x.SetBytes(t.state)
+	if x.IsZero() {
+		return x, errZeroChallenge
+	}
+	return x, nil
+}
+
+// inputsTranscript returns a transcript initialized with the number of proofs and their public inputs
+func inputsTranscript(inputs [][]fr.Element) *transcript {
+	h := sha256.New()
+	h.Write([]byte("gnark-aggregation"))
+	var n [8]byte
+	big.NewInt(int64(len(inputs))).FillBytes(n[:])
+	h.Write(n[:])
+	for _, input := range inputs {
+		for _, x := range input {
+			b := x.Bytes()
+			h.Write(b[:])
+		}
+	}
+	return &transcript{state: h.Sum(nil)}
+}
+
+func gtBytes(elements ...curve.GT) []byte {
+	var res []byte
+	for i := range elements {
+		b := elements[i].Bytes()
+		res = append(res, b[:]...)
+	}
+	return res
+}
+
+func g1Bytes(points ...curve.G1Affine) []byte {
+	var res []byte
+	for i := range points {
+		b := points[i].Bytes()
+		res = append(res, b[:]...)
+	}
+	return res
+}
+
+func g2Bytes(points ...curve.G2Affine) []byte {
+	var res []byte
+	for i := range points {
+		b := points[i].Bytes()
+		res = append(res, b[:]...)
+	}
+	return res
+}
+
+// bytes returns the serialization of a round, hashed for its challenge
+func (r *Round) bytes() []byte {
+	res := gtBytes(r.ComABL[0], r.ComABL[1], r.ComABR[0], r.ComABR[1], r.ComCL[0], r.ComCL[1], r.ComCR[0], r.ComCR[1], r.ZABL, r.ZABR)
+	return append(res, g1Bytes(r.ZCL, r.ZCR)...)
+}
+
+// powers returns [1, x, x², ..., x^(n-1)]
+func powers(x fr.Element, n int) []fr.Element {
+	res := make([]fr.Element, n)
+	if n == 0 {
+		return res
+	}
+	res[0].SetOne()
+	for i := 1; i < n; i++ {
+		res[i].Mul(&res[i-1], &x)
+	}
+	return res
+}
+
+// keyPolynomial returns the coefficients of Π_j (1 + y_j X^(2^(m-1-j))), j < m == len(y): after the
+// rounds of challenges y_j, the key kᵢ = [sⁱ] is reduced to [f(s)]
+func keyPolynomial(y []fr.Element) []fr.Element {
+	res := []fr.Element{fr.One()}
+	for j := len(y) - 1; j >= 0; j-- {
+		// res has degree < len(res) == 2^(m-1-j), so multiplying by 1 + y_j X^len(res) concatenates
+		high := make([]fr.Element, len(res))
+		for i := range res {
+			high[i].Mul(&res[i], &y[j])
+		}
+		res = append(res, high...)
+	}
+	return res
+}
+
+// evalKeyPolynomial evaluates keyPolynomial(y) at z
+func evalKeyPolynomial(y []fr.Element, z fr.Element) fr.Element {
+	res, one := fr.One(), fr.One()
+	zPow := z // z^(2^(m-1-j)), starting from j == m-1
+	var t fr.Element
+	for j := len(y) - 1; j >= 0; j-- {
+		t.Mul(&y[j], &zPow).Add(&t, &one)
+		res.Mul(&res, &t)
+		zPow.Square(&zPow)
+	}
+	return res
+}
+
+// wChallenges returns the challenges of the keyPolynomial of the keys w: the key wᵢ is multiplied by
+// r⁻ⁱ before the rounds, and the round j multiplies the right half by x_j, so y_j = x_j.r^-(2^(m-1-j))
+func wChallenges(x []fr.Element, r fr.Element) []fr.Element {
+	var rInv fr.Element
+	rInv.Inverse(&r)
+	y := make([]fr.Element, len(x))
+	rPow := rInv
+	for j := len(x) - 1; j >= 0; j-- {
+		y[j].Mul(&x[j], &rPow)
+		rPow.Square(&rPow)
+	}
+	return y
+}
+
+func nextPowerOfTwo(n int) int {
+	if n <= 1 {
+		return 1
+	}
+	return 1 << bits.Len(uint(n-1))
+}
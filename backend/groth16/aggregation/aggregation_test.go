@@ -0,0 +1,120 @@
+// Copyright 2020 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aggregation
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/groth16/mpcsetup"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gurvy"
+	"github.com/stretchr/testify/require"
+)
+
+type cubicCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+// x**3 + 3x + 5 == y
+func (circuit *cubicCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	x3 := cs.Mul(circuit.X, circuit.X, circuit.X)
+	cs.AssertIsEqual(circuit.Y, cs.Add(x3, cs.Mul(circuit.X, 3), 5))
+	return nil
+}
+
+// prove returns n proofs of the cubic circuit, for x = 1, 2, ..., and their witnesses
+func prove(t *testing.T, n int) (groth16.VerifyingKey, []groth16.Proof, []interface{}) {
+	assert := require.New(t)
+
+	var circuit cubicCircuit
+	r1cs, err := frontend.Compile(gurvy.BN256, &circuit)
+	assert.NoError(err)
+	pk, vk, err := groth16.Setup(r1cs)
+	assert.NoError(err)
+
+	proofs := make([]groth16.Proof, n)
+	solutions := make([]interface{}, n)
+	for i := range proofs {
+		x := i + 1
+		var witness cubicCircuit
+		witness.X.Assign(x)
+		witness.Y.Assign(x*x*x + 3*x + 5)
+		proofs[i], err = groth16.Prove(r1cs, pk, &witness)
+		assert.NoError(err)
+		solutions[i] = &witness
+	}
+	return vk, proofs, solutions
+}
+
+func TestAggregate(t *testing.T) {
+	assert := require.New(t)
+
+	vk, proofs, solutions := prove(t, 3)
+	pk, avk, err := Setup(4)
+	assert.NoError(err)
+
+	for _, n := range []int{1, 2, 3} {
+		proof, err := Aggregate(pk, vk, proofs[:n], solutions[:n])
+		assert.NoError(err)
+		assert.NoError(Verify(avk, vk, proof, solutions[:n]), "%d proofs", n)
+	}
+
+	proof, err := Aggregate(pk, vk, proofs, solutions)
+	assert.NoError(err)
+
+	// wrong public inputs
+	var bad cubicCircuit
+	bad.Y.Assign(42)
+	assert.Error(Verify(avk, vk, proof, []interface{}{solutions[0], &bad, solutions[2]}))
+
+	// the proofs of another number of solutions
+	assert.Error(Verify(avk, vk, proof, solutions[:2]))
+
+	// a wrong opening of the final keys
+	tampered := *proof
+	tampered.OpeningW1 = proof.OpeningW2
+	assert.Error(Verify(avk, vk, &tampered, solutions))
+
+	// a proof swapped with another
+	swapped := []groth16.Proof{proofs[1], proofs[0], proofs[2]}
+	proof, err = Aggregate(pk, vk, swapped, solutions)
+	assert.NoError(err)
+	assert.Error(Verify(avk, vk, proof, solutions))
+
+	// the keys are too short
+	_, err = Aggregate(pk, vk, append(proofs, proofs[:2]...), append(solutions, solutions[:2]...))
+	assert.Error(err)
+}
+
+func TestKeysFromPowersOfTau(t *testing.T) {
+	assert := require.New(t)
+
+	srsA, srsB := mpcsetup.NewPhase1(3), mpcsetup.NewPhase1(3)
+	assert.NoError(srsA.Contribute())
+	assert.NoError(srsB.Contribute())
+	pk, avk, err := KeysFromPowersOfTau(srsA, srsB)
+	assert.NoError(err)
+	assert.Equal(4, len(pk.G2.A))
+
+	vk, proofs, solutions := prove(t, 4)
+	proof, err := Aggregate(pk, vk, proofs, solutions)
+	assert.NoError(err)
+	assert.NoError(Verify(avk, vk, proof, solutions))
+
+	_, _, err = KeysFromPowersOfTau(srsA, srsA)
+	assert.Error(err)
+}
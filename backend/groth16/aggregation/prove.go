@@ -0,0 +1,321 @@
+// Copyright 2020 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aggregation
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	groth16_bn256 "github.com/consensys/gnark/internal/backend/bn256/groth16"
+	"github.com/consensys/gnark/internal/utils"
+	curve "github.com/consensys/gurvy/bn256"
+	"github.com/consensys/gurvy/bn256/fr"
+)
+
+// Aggregate aggregates the proofs of a circuit, proofs[i] with the public inputs of solutions[i] (see
+// groth16.Verify)
+//
+// the proofs don't need to be valid: an invalid proof makes the verification of the aggregated proof
+// fail
+func Aggregate(pk *ProvingKey, vk groth16.VerifyingKey, proofs []groth16.Proof, solutions []interface{}) (*Proof, error) {
+	_proofs, inputs, err := parse(vk, proofs, solutions)
+	if err != nil {
+		return nil, err
+	}
+	n := len(_proofs)
+	if 2*n > len(pk.G1.A) {
+		return nil, errTooManyProofs
+	}
+
+	var res Proof
+	A := make([]curve.G1Affine, n)
+	B := make([]curve.G2Affine, n)
+	C := make([]curve.G1Affine, n)
+	for i, proof := range _proofs {
+		A[i], B[i], C[i] = proof.Ar, proof.Bs, proof.Krs
+	}
+
+	// commitment keys
+	v1 := append([]curve.G2Affine{}, pk.G2.A[:n]...)
+	v2 := append([]curve.G2Affine{}, pk.G2.B[:n]...)
+	w1 := append([]curve.G1Affine{}, pk.G1.A[n:2*n]...)
+	w2 := append([]curve.G1Affine{}, pk.G1.B[n:2*n]...)
+
+	if res.ComAB, err = commitAB(A, B, v1, v2, w1, w2); err != nil {
+		return nil, err
+	}
+	if res.ComC, err = commitC(C, v1, v2); err != nil {
+		return nil, err
+	}
+
+	t := inputsTranscript(inputs)
+	r, err := t.challenge(gtBytes(res.ComAB[0], res.ComAB[1], res.ComC[0], res.ComC[1]))
+	if err != nil {
+		return nil, err
+	}
+
+	// B'ᵢ = rⁱ.Bᵢ and w'ᵢ = r⁻ⁱ.wᵢ, so that the commitment to (A, B') under (v, w') is ComAB
+	var rInv fr.Element
+	rInv.Inverse(&r)
+	s := powers(r, n)
+	scaleG2(B, s)
+	rInvPowers := powers(rInv, n)
+	scaleG1(w1, rInvPowers)
+	scaleG1(w2, rInvPowers)
+
+	if res.ZAB, err = curve.Pair(A, B); err != nil {
+		return nil, err
+	}
+	res.ZC.MultiExp(C, regular(s))
+
+	// rounds: the vectors of size 2k are split in halves L and R, the prover sends the cross terms,
+	// then the vectors are folded with the challenge x: A, C, w = L + x.R, and B, v, s = L + x⁻¹.R
+	var challenges []fr.Element
+	for len(A) > 1 {
+		k := len(A) / 2
+		var round Round
+		if round.ComABL, err = commitAB(A[k:], B[:k], v1[:k], v2[:k], w1[k:], w2[k:]); err != nil {
+			return nil, err
+		}
+		if round.ComABR, err = commitAB(A[:k], B[k:], v1[k:], v2[k:], w1[:k], w2[:k]); err != nil {
+			return nil, err
+		}
+		if round.ComCL, err = commitC(C[k:], v1[:k], v2[:k]); err != nil {
+			return nil, err
+		}
+		if round.ComCR, err = commitC(C[:k], v1[k:], v2[k:]); err != nil {
+			return nil, err
+		}
+		if round.ZABL, err = curve.Pair(A[k:], B[:k]); err != nil {
+			return nil, err
+		}
+		if round.ZABR, err = curve.Pair(A[:k], B[k:]); err != nil {
+			return nil, err
+		}
+		round.ZCL.MultiExp(C[k:], regular(s[:k]))
+		round.ZCR.MultiExp(C[:k], regular(s[k:]))
+		res.Rounds = append(res.Rounds, round)
+
+		x, err := t.challenge(round.bytes())
+		if err != nil {
+			return nil, err
+		}
+		challenges = append(challenges, x)
+		var xInv fr.Element
+		xInv.Inverse(&x)
+
+		A = foldG1(A, x)
+		C = foldG1(C, x)
+		w1 = foldG1(w1, x)
+		w2 = foldG1(w2, x)
+		B = foldG2(B, xInv)
+		v1 = foldG2(v1, xInv)
+		v2 = foldG2(v2, xInv)
+		for i := 0; i < k; i++ {
+			var t fr.Element
+			t.Mul(&s[k+i], &xInv)
+			s[i].Add(&s[i], &t)
+		}
+		s = s[:k]
+	}
+	res.A, res.B, res.C = A[0], B[0], C[0]
+	res.V1, res.V2, res.W1, res.W2 = v1[0], v2[0], w1[0], w2[0]
+
+	// KZG openings of the final keys at z: v = [fv(s)]2 and w = [sⁿ.fw(s)]1, s ∈ {a, b}
+	z, err := t.challenge(g2Bytes(res.V1, res.V2), g1Bytes(res.W1, res.W2))
+	if err != nil {
+		return nil, err
+	}
+	fv := keyPolynomial(inverses(challenges))
+	fw := append(make([]fr.Element, n), keyPolynomial(wChallenges(challenges, r))...)
+	qv, qw := regular(quotient(fv, z)), regular(quotient(fw, z))
+	if len(qv) > 0 {
+		// n == 1: fv == 1 and the openings are the point at infinity
+		res.OpeningV1.MultiExp(pk.G2.A[:len(qv)], qv)
+		res.OpeningV2.MultiExp(pk.G2.B[:len(qv)], qv)
+	}
+	res.OpeningW1.MultiExp(pk.G1.A[:len(qw)], qw)
+	res.OpeningW2.MultiExp(pk.G1.B[:len(qw)], qw)
+
+	return &res, nil
+}
+
+// parse returns the typed proofs and their public inputs, padded to a power of 2 with copies of the
+// last proof
+func parse(vk groth16.VerifyingKey, proofs []groth16.Proof, solutions []interface{}) ([]*groth16_bn256.Proof, [][]fr.Element, error) {
+	if len(proofs) != len(solutions) {
+		return nil, nil, errors.New("aggregation: the number of proofs and of solutions don't match")
+	}
+	_, inputs, err := parseInputs(vk, solutions)
+	if err != nil {
+		return nil, nil, err
+	}
+	_proofs := make([]*groth16_bn256.Proof, len(inputs))
+	for i := range _proofs {
+		var ok bool
+		if _proofs[i], ok = proofs[min(i, len(proofs)-1)].(*groth16_bn256.Proof); !ok {
+			return nil, nil, errNotBN256
+		}
+	}
+	return _proofs, inputs, nil
+}
+
+// parseInputs returns the typed verifying key, and the public inputs of the solutions (in regular
+// form), padded to a power of 2 with copies of the last ones
+func parseInputs(vk groth16.VerifyingKey, solutions []interface{}) (*groth16_bn256.VerifyingKey, [][]fr.Element, error) {
+	if len(solutions) == 0 {
+		return nil, nil, errNoProof
+	}
+	_vk, ok := vk.(*groth16_bn256.VerifyingKey)
+	if !ok {
+		return nil, nil, errNotBN256
+	}
+	if _vk.Commitment != nil {
+		return nil, nil, errCommitment
+	}
+
+	inputs := make([][]fr.Element, nextPowerOfTwo(len(solutions)))
+	for i := range solutions {
+		solution, err := frontend.ParseWitness(solutions[i])
+		if err != nil {
+			return nil, nil, err
+		}
+		if inputs[i], err = groth16_bn256.ParsePublicInput(_vk.PublicInputs, solution); err != nil {
+			return nil, nil, err
+		}
+	}
+	for i := len(solutions); i < len(inputs); i++ {
+		inputs[i] = inputs[len(solutions)-1]
+	}
+	return _vk, inputs, nil
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// commitAB returns (e(A, v₁)·e(w₁, B), e(A, v₂)·e(w₂, B))
+func commitAB(A []curve.G1Affine, B []curve.G2Affine, v1, v2 []curve.G2Affine, w1, w2 []curve.G1Affine) (res [2]curve.GT, err error) {
+	P := append(append(make([]curve.G1Affine, 0, 2*len(A)), A...), w1...)
+	Q := append(append(make([]curve.G2Affine, 0, 2*len(A)), v1...), B...)
+	if res[0], err = curve.Pair(P, Q); err != nil {
+		return
+	}
+	copy(P[len(A):], w2)
+	copy(Q, v2)
+	res[1], err = curve.Pair(P, Q)
+	return
+}
+
+// commitC returns (e(C, v₁), e(C, v₂))
+func commitC(C []curve.G1Affine, v1, v2 []curve.G2Affine) (res [2]curve.GT, err error) {
+	if res[0], err = curve.Pair(C, v1); err != nil {
+		return
+	}
+	res[1], err = curve.Pair(C, v2)
+	return
+}
+
+// foldG1 returns L + x.R, where L and R are the halves of P
+func foldG1(P []curve.G1Affine, x fr.Element) []curve.G1Affine {
+	k := len(P) / 2
+	var b big.Int
+	x.ToBigIntRegular(&b)
+	utils.Parallelize(k, func(start, end int) {
+		var l, r curve.G1Jac
+		for i := start; i < end; i++ {
+			r.FromAffine(&P[k+i])
+			r.ScalarMultiplication(&r, &b)
+			l.FromAffine(&P[i])
+			l.AddAssign(&r)
+			P[i].FromJacobian(&l)
+		}
+	})
+	return P[:k]
+}
+
+// foldG2 returns L + x.R, where L and R are the halves of P
+func foldG2(P []curve.G2Affine, x fr.Element) []curve.G2Affine {
+	k := len(P) / 2
+	var b big.Int
+	x.ToBigIntRegular(&b)
+	utils.Parallelize(k, func(start, end int) {
+		var l, r curve.G2Jac
+		for i := start; i < end; i++ {
+			r.FromAffine(&P[k+i])
+			r.ScalarMultiplication(&r, &b)
+			l.FromAffine(&P[i])
+			l.AddAssign(&r)
+			P[i].FromJacobian(&l)
+		}
+	})
+	return P[:k]
+}
+
+// scaleG1 multiplies P[i] by scalars[i]
+func scaleG1(P []curve.G1Affine, scalars []fr.Element) {
+	utils.Parallelize(len(P), func(start, end int) {
+		var b big.Int
+		for i := start; i < end; i++ {
+			P[i].ScalarMultiplication(&P[i], scalars[i].ToBigIntRegular(&b))
+		}
+	})
+}
+
+// scaleG2 multiplies P[i] by scalars[i]
+func scaleG2(P []curve.G2Affine, scalars []fr.Element) {
+	utils.Parallelize(len(P), func(start, end int) {
+		var b big.Int
+		for i := start; i < end; i++ {
+			P[i].ScalarMultiplication(&P[i], scalars[i].ToBigIntRegular(&b))
+		}
+	})
+}
+
+// quotient returns the coefficients of (f(X) - f(z)) / (X - z)
+func quotient(f []fr.Element, z fr.Element) []fr.Element {
+	if len(f) < 2 {
+		return nil
+	}
+	q := make([]fr.Element, len(f)-1)
+	q[len(q)-1] = f[len(f)-1]
+	for i := len(q) - 2; i >= 0; i-- {
+		q[i].Mul(&q[i+1], &z).Add(&q[i], &f[i+1])
+	}
+	return q
+}
+
+// regular returns a copy of the scalars in regular form, for a multi exponentiation
+func regular(scalars []fr.Element) []fr.Element {
+	res := make([]fr.Element, len(scalars))
+	for i := range scalars {
+		res[i] = scalars[i]
+		res[i].FromMont()
+	}
+	return res
+}
+
+func inverses(x []fr.Element) []fr.Element {
+	res := make([]fr.Element, len(x))
+	for i := range x {
+		res[i].Inverse(&x[i])
+	}
+	return res
+}
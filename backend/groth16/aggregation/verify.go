@@ -0,0 +1,235 @@
+// Copyright 2020 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aggregation
+
+import (
+	"math/big"
+
+	"github.com/consensys/gnark/backend/groth16"
+	curve "github.com/consensys/gurvy/bn256"
+	"github.com/consensys/gurvy/bn256/fr"
+)
+
+// Verify verifies an aggregated proof of len(solutions) proofs, the i-th with the public inputs of
+// solutions[i]
+//
+// the aggregated proof is valid if and only if (except with negligible probability) all the proofs
+// are valid for vk
+func Verify(avk *VerifyingKey, vk groth16.VerifyingKey, proof *Proof, solutions []interface{}) error {
+	_vk, inputs, err := parseInputs(vk, solutions)
+	if err != nil {
+		return err
+	}
+	n := len(inputs)
+	m := len(proof.Rounds)
+	if 1<<m != n {
+		return errInvalidProof
+	}
+
+	// replays the transcript
+	t := inputsTranscript(inputs)
+	r, err := t.challenge(gtBytes(proof.ComAB[0], proof.ComAB[1], proof.ComC[0], proof.ComC[1]))
+	if err != nil {
+		return err
+	}
+	challenges := make([]fr.Element, m)
+	for j := range proof.Rounds {
+		if challenges[j], err = t.challenge(proof.Rounds[j].bytes()); err != nil {
+			return err
+		}
+	}
+	z, err := t.challenge(g2Bytes(proof.V1, proof.V2), g1Bytes(proof.W1, proof.W2))
+	if err != nil {
+		return err
+	}
+
+	// the Groth16 equations combined with rⁱ:
+	// ZAB . e(Σrⁱ.kSumᵢ, -[γ]2) . e(ZC, -[δ]2) == e(α, β)^Σrⁱ
+	s := powers(r, n)
+	var sum fr.Element
+	for i := range s {
+		sum.Add(&sum, &s[i])
+	}
+	scalars := make([]fr.Element, len(_vk.G1.K))
+	for i := range inputs {
+		for j := range inputs[i] {
+			var x fr.Element
+			x.Set(&inputs[i][j]).ToMont()
+			x.Mul(&x, &s[i])
+			scalars[j].Add(&scalars[j], &x)
+		}
+	}
+	var kSum curve.G1Affine
+	kSum.MultiExp(_vk.G1.K, regular(scalars))
+	right, err := curve.Pair([]curve.G1Affine{kSum, proof.ZC}, []curve.G2Affine{_vk.G2.GammaNeg, _vk.G2.DeltaNeg})
+	if err != nil {
+		return err
+	}
+	right.Mul(&right, &proof.ZAB)
+	var e curve.GT
+	e.Exp(&_vk.E, *sum.ToBigIntRegular(new(big.Int)))
+	if !e.Equal(&right) {
+		return errInvalidProof
+	}
+
+	// folds the commitments and the inner products with the cross terms of the rounds
+	comAB, comC, zAB, zC := proof.ComAB, proof.ComC, proof.ZAB, proof.ZC
+	for j := range proof.Rounds {
+		round := &proof.Rounds[j]
+		var x, xInv big.Int
+		var _xInv fr.Element
+		challenges[j].ToBigIntRegular(&x)
+		_xInv.Inverse(&challenges[j]).ToBigIntRegular(&xInv)
+		for k := 0; k < 2; k++ {
+			foldGT(&comAB[k], &round.ComABL[k], &round.ComABR[k], &x, &xInv)
+			foldGT(&comC[k], &round.ComCL[k], &round.ComCR[k], &x, &xInv)
+		}
+		foldGT(&zAB, &round.ZABL, &round.ZABR, &x, &xInv)
+
+		var zl, zr, acc curve.G1Jac
+		zl.FromAffine(&round.ZCL)
+		zl.ScalarMultiplication(&zl, &x)
+		zr.FromAffine(&round.ZCR)
+		zr.ScalarMultiplication(&zr, &xInv)
+		acc.FromAffine(&zC)
+		acc.AddAssign(&zl).AddAssign(&zr)
+		zC.FromJacobian(&acc)
+	}
+
+	// the final commitments and inner products of the vectors of size 1
+	for k, key := range [2]struct {
+		v curve.G2Affine
+		w curve.G1Affine
+	}{{proof.V1, proof.W1}, {proof.V2, proof.W2}} {
+		ab, err := curve.Pair([]curve.G1Affine{proof.A, key.w}, []curve.G2Affine{key.v, proof.B})
+		if err != nil {
+			return err
+		}
+		c, err := curve.Pair([]curve.G1Affine{proof.C}, []curve.G2Affine{key.v})
+		if err != nil {
+			return err
+		}
+		if !ab.Equal(&comAB[k]) || !c.Equal(&comC[k]) {
+			return errInvalidProof
+		}
+	}
+	ab, err := curve.Pair([]curve.G1Affine{proof.A}, []curve.G2Affine{proof.B})
+	if err != nil {
+		return err
+	}
+	if !ab.Equal(&zAB) {
+		return errInvalidProof
+	}
+	xInv := inverses(challenges)
+	sFinal := evalKeyPolynomial(xInv, r)
+	var c curve.G1Affine
+	c.ScalarMultiplication(&proof.C, sFinal.ToBigIntRegular(new(big.Int)))
+	if !c.Equal(&zC) {
+		return errInvalidProof
+	}
+
+	// the final keys are the evaluations of the key polynomials: v = [fv(s)]2 and w = [sⁿ.fw(s)]1
+	fv := evalKeyPolynomial(xInv, z)
+	fw := evalKeyPolynomial(wChallenges(challenges, r), z)
+	var zn fr.Element
+	zn.Exp(z, big.NewInt(int64(n)))
+	fw.Mul(&fw, &zn)
+
+	if err := checkOpeningG2(&proof.V1, &proof.OpeningV1, &avk.G1.A, z, fv); err != nil {
+		return err
+	}
+	if err := checkOpeningG2(&proof.V2, &proof.OpeningV2, &avk.G1.B, z, fv); err != nil {
+		return err
+	}
+	if err := checkOpeningG1(&proof.W1, &proof.OpeningW1, &avk.G2.A, z, fw); err != nil {
+		return err
+	}
+	return checkOpeningG1(&proof.W2, &proof.OpeningW2, &avk.G2.B, z, fw)
+}
+
+// foldGT sets c to c . l^x . r^(1/x)
+func foldGT(c, l, r *curve.GT, x, xInv *big.Int) {
+	var t curve.GT
+	t.Exp(l, *x)
+	c.Mul(c, &t)
+	t.Exp(r, *xInv)
+	c.Mul(c, &t)
+}
+
+// checkOpeningG2 checks that v == [f(s)]2 with f(z) == y, from the opening [(f(s) - y) / (s - z)]2 and
+// [s]1: e([1]1, v - [y]2) == e([s - z]1, opening)
+func checkOpeningG2(v, opening *curve.G2Affine, s *curve.G1Affine, z, y fr.Element) error {
+	_, g2, g1, _ := curve.Generators()
+	var b big.Int
+
+	var vy, t curve.G2Jac
+	t.ScalarMultiplication(&g2, y.ToBigIntRegular(&b))
+	vy.FromAffine(v)
+	vy.SubAssign(&t)
+
+	var sz, u curve.G1Jac
+	u.FromAffine(&g1)
+	u.ScalarMultiplication(&u, z.ToBigIntRegular(&b))
+	sz.FromAffine(s)
+	sz.SubAssign(&u)
+
+	var _vy curve.G2Affine
+	var _sz curve.G1Affine
+	_vy.FromJacobian(&vy)
+	_sz.FromJacobian(&sz)
+	_sz.Neg(&_sz)
+
+	ok, err := curve.PairingCheck([]curve.G1Affine{g1, _sz}, []curve.G2Affine{_vy, *opening})
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errInvalidProof
+	}
+	return nil
+}
+
+// checkOpeningG1 checks that w == [f(s)]1 with f(z) == y, from the opening [(f(s) - y) / (s - z)]1 and
+// [s]2: e(w - [y]1, [1]2) == e(opening, [s - z]2)
+func checkOpeningG1(w, opening *curve.G1Affine, s *curve.G2Affine, z, y fr.Element) error {
+	g1, _, _, g2 := curve.Generators()
+	var b big.Int
+
+	var wy, t curve.G1Jac
+	t.ScalarMultiplication(&g1, y.ToBigIntRegular(&b))
+	wy.FromAffine(w)
+	wy.SubAssign(&t)
+
+	var sz, u curve.G2Jac
+	u.FromAffine(&g2)
+	u.ScalarMultiplication(&u, z.ToBigIntRegular(&b))
+	sz.FromAffine(s)
+	sz.SubAssign(&u)
+
+	var _wy, _opening curve.G1Affine
+	var _sz curve.G2Affine
+	_wy.FromJacobian(&wy)
+	_sz.FromJacobian(&sz)
+	_opening.Neg(opening)
+
+	ok, err := curve.PairingCheck([]curve.G1Affine{_wy, _opening}, []curve.G2Affine{g2, _sz})
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errInvalidProof
+	}
+	return nil
+}
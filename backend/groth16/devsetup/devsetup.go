@@ -0,0 +1,115 @@
+// Copyright 2020 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build devsetup
+// +build devsetup
+
+package devsetup
+
+import (
+	"errors"
+
+	"github.com/consensys/gurvy"
+
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/r1cs"
+	backend_bls377 "github.com/consensys/gnark/internal/backend/bls377"
+	groth16_bls377 "github.com/consensys/gnark/internal/backend/bls377/groth16"
+	backend_bls381 "github.com/consensys/gnark/internal/backend/bls381"
+	groth16_bls381 "github.com/consensys/gnark/internal/backend/bls381/groth16"
+	backend_bn256 "github.com/consensys/gnark/internal/backend/bn256"
+	groth16_bn256 "github.com/consensys/gnark/internal/backend/bn256/groth16"
+	backend_bw761 "github.com/consensys/gnark/internal/backend/bw761"
+	groth16_bw761 "github.com/consensys/gnark/internal/backend/bw761/groth16"
+)
+
+// Trapdoor holds the secrets sampled by UnsafeSetup
+//
+// anyone knowing them can forge proofs; it must never leave a development environment
+//
+// it's underlying implementation is curve specific (see gnark/internal/backend)
+type Trapdoor interface {
+	GetCurveID() gurvy.ID
+}
+
+// UnsafeSetup runs groth16.Setup with provided R1CS and returns the secrets sampled during the setup
+//
+// the keys are updated with UpdateKeys when the circuit changes
+func UnsafeSetup(r1cs r1cs.R1CS, opts ...backend.Option) (groth16.ProvingKey, groth16.VerifyingKey, Trapdoor, error) {
+
+	switch _r1cs := r1cs.(type) {
+	case *backend_bls377.R1CS:
+		var pk groth16_bls377.ProvingKey
+		var vk groth16_bls377.VerifyingKey
+		trapdoor, err := groth16_bls377.UnsafeSetup(_r1cs, &pk, &vk, opts...)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return &pk, &vk, trapdoor, nil
+	case *backend_bls381.R1CS:
+		var pk groth16_bls381.ProvingKey
+		var vk groth16_bls381.VerifyingKey
+		trapdoor, err := groth16_bls381.UnsafeSetup(_r1cs, &pk, &vk, opts...)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return &pk, &vk, trapdoor, nil
+	case *backend_bn256.R1CS:
+		var pk groth16_bn256.ProvingKey
+		var vk groth16_bn256.VerifyingKey
+		trapdoor, err := groth16_bn256.UnsafeSetup(_r1cs, &pk, &vk, opts...)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return &pk, &vk, trapdoor, nil
+	case *backend_bw761.R1CS:
+		var pk groth16_bw761.ProvingKey
+		var vk groth16_bw761.VerifyingKey
+		trapdoor, err := groth16_bw761.UnsafeSetup(_r1cs, &pk, &vk, opts...)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return &pk, &vk, trapdoor, nil
+	default:
+		panic("unrecognized R1CS curve type")
+	}
+}
+
+// UpdateKeys updates pk and vk (output of UnsafeSetup on oldR1CS) such that they can be used with newR1CS
+//
+// only the keys entries of the wires affected by the change are recomputed, which is much faster than a
+// new setup when, for example, a gadget is appended to the circuit. The public and secret inputs of the circuit must
+// not change, and the number of constraints must fit in the fft domain of the keys; otherwise an error is returned
+//
+// the update needs the trapdoor, i.e. the full toxic waste of the setup: it can't be done from public
+// artifacts without allowing anyone to forge proofs. Keys updated this way must not be used in
+// production, and the trapdoor must be destroyed once the development loop is over.
+func UpdateKeys(oldR1CS, newR1CS r1cs.R1CS, pk groth16.ProvingKey, vk groth16.VerifyingKey, trapdoor Trapdoor) error {
+	if oldR1CS.GetCurveID() != newR1CS.GetCurveID() || trapdoor.GetCurveID() != newR1CS.GetCurveID() {
+		return errors.New("can't update keys: curve mismatch")
+	}
+	switch _r1cs := newR1CS.(type) {
+	case *backend_bls377.R1CS:
+		return groth16_bls377.UpdateKeys(oldR1CS.(*backend_bls377.R1CS), _r1cs, pk.(*groth16_bls377.ProvingKey), vk.(*groth16_bls377.VerifyingKey), trapdoor.(*groth16_bls377.Trapdoor))
+	case *backend_bls381.R1CS:
+		return groth16_bls381.UpdateKeys(oldR1CS.(*backend_bls381.R1CS), _r1cs, pk.(*groth16_bls381.ProvingKey), vk.(*groth16_bls381.VerifyingKey), trapdoor.(*groth16_bls381.Trapdoor))
+	case *backend_bn256.R1CS:
+		return groth16_bn256.UpdateKeys(oldR1CS.(*backend_bn256.R1CS), _r1cs, pk.(*groth16_bn256.ProvingKey), vk.(*groth16_bn256.VerifyingKey), trapdoor.(*groth16_bn256.Trapdoor))
+	case *backend_bw761.R1CS:
+		return groth16_bw761.UpdateKeys(oldR1CS.(*backend_bw761.R1CS), _r1cs, pk.(*groth16_bw761.ProvingKey), vk.(*groth16_bw761.VerifyingKey), trapdoor.(*groth16_bw761.Trapdoor))
+	default:
+		panic("unrecognized R1CS curve type")
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2020 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build devsetup
+// +build devsetup
+
+package devsetup
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gurvy"
+)
+
+type squareCircuit struct {
+	nbConstraints int
+	X             frontend.Variable
+	Y             frontend.Variable `gnark:",public"`
+}
+
+func (circuit *squareCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	for i := 0; i < circuit.nbConstraints; i++ {
+		circuit.X = cs.Mul(circuit.X, circuit.X)
+	}
+	cs.AssertIsEqual(circuit.X, circuit.Y)
+	return nil
+}
+
+func TestUpdateKeys(t *testing.T) {
+	oldR1CS, err := frontend.Compile(gurvy.BN256, &squareCircuit{nbConstraints: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	newR1CS, err := frontend.Compile(gurvy.BN256, &squareCircuit{nbConstraints: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pk, vk, trapdoor, err := UnsafeSetup(oldR1CS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := UpdateKeys(oldR1CS, newR1CS, pk, vk, trapdoor); err != nil {
+		t.Fatal(err)
+	}
+	good := map[string]interface{}{"X": 2, "Y": 256}
+	proof, err := groth16.Prove(newR1CS, pk, good)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := groth16.Verify(proof, vk, good); err != nil {
+		t.Fatal(err)
+	}
+
+	otherR1CS, err := frontend.Compile(gurvy.BLS381, &squareCircuit{nbConstraints: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := UpdateKeys(oldR1CS, otherR1CS, pk, vk, trapdoor); err == nil {
+		t.Fatal("expected error when the curves don't match")
+	}
+}
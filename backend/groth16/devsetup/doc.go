@@ -0,0 +1,20 @@
+// Copyright 2020 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package devsetup runs Groth16 setups that keep their toxic waste, such that the keys can be updated
+// when the circuit changes instead of running a new setup
+//
+// anyone knowing the trapdoor returned by UnsafeSetup can forge proofs: the package is only compiled
+// with the devsetup build tag (go build -tags devsetup), and must never be part of a production binary
+package devsetup
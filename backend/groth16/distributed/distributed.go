@@ -0,0 +1,432 @@
+// Copyright 2020 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package distributed computes a Groth16 proof with its multi exponentiations split across machines
+//
+// a coordinator solves the circuit and computes the FFTs, then splits the multi exponentiations of the
+// proof in independent tasks; workers holding the proving key (in memory or in a file, of which they
+// only read the points of their tasks) compute the tasks, and the coordinator merges their results:
+//
+//	// coordinator
+//	c, err := distributed.NewCoordinator(r1cs, pk, &witness, 1<<22)
+//	for _, task := range c.Tasks() {
+//		task.WriteTo(conn) // to a worker
+//	}
+//
+//	// worker
+//	w, err := distributed.NewWorkerFromFile(gurvy.BN256, pkFile)
+//	task := w.NewTask()
+//	task.ReadFrom(conn)
+//	res, err := w.Compute(task)
+//	res.WriteTo(conn) // to the coordinator
+//
+//	// coordinator
+//	res := c.NewResult()
+//	res.ReadFrom(conn)
+//	err = c.AddResult(res)
+//	...
+//	proof, err := c.Proof()
+//
+// the results aren't checked by the coordinator: the proof should be verified if the workers aren't
+// trusted.
+package distributed
+
+import (
+	"errors"
+	"io"
+
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/r1cs"
+	"github.com/consensys/gnark/frontend"
+	backend_bls377 "github.com/consensys/gnark/internal/backend/bls377"
+	groth16_bls377 "github.com/consensys/gnark/internal/backend/bls377/groth16"
+	backend_bls381 "github.com/consensys/gnark/internal/backend/bls381"
+	groth16_bls381 "github.com/consensys/gnark/internal/backend/bls381/groth16"
+	backend_bn256 "github.com/consensys/gnark/internal/backend/bn256"
+	groth16_bn256 "github.com/consensys/gnark/internal/backend/bn256/groth16"
+	backend_bw761 "github.com/consensys/gnark/internal/backend/bw761"
+	groth16_bw761 "github.com/consensys/gnark/internal/backend/bw761/groth16"
+	"github.com/consensys/gurvy"
+)
+
+var errCurve = errors.New("the task or the result isn't of the curve of the proof")
+
+// Task is a chunk of a multi exponentiation of a proof, sent by the coordinator to a worker
+//
+// its underlying implementation is curve specific (see gnark/internal/backend)
+type Task interface {
+	io.WriterTo
+	io.ReaderFrom
+}
+
+// Result is the result of a Task, sent by a worker to the coordinator
+//
+// its underlying implementation is curve specific (see gnark/internal/backend)
+type Result interface {
+	io.WriterTo
+	io.ReaderFrom
+}
+
+// Coordinator holds a proof whose multi exponentiations are computed by workers
+type Coordinator struct {
+	tasks     []Task
+	newResult func() Result
+	addResult func(Result) error
+	proof     func() (groth16.Proof, error)
+}
+
+// NewCoordinator solves the circuit, computes the FFTs of the proof, and splits its multi exponentiations
+// in tasks of at most chunkSize points; opts are the options of the solver and of the FFTs
+// (backend.WithSeed, backend.WithOutOfCoreFFT, ...)
+func NewCoordinator(r1cs r1cs.R1CS, pk groth16.ProvingKey, solution interface{}, chunkSize int, opts ...backend.Option) (*Coordinator, error) {
+	_solution, err := frontend.ParseWitness(solution)
+	if err != nil {
+		return nil, err
+	}
+
+	switch _r1cs := r1cs.(type) {
+	case *backend_bls377.R1CS:
+		d, err := groth16_bls377.NewDistributedProof(_r1cs, pk.(*groth16_bls377.ProvingKey), _solution, chunkSize, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return newCoordinatorBLS377(d), nil
+	case *backend_bls381.R1CS:
+		d, err := groth16_bls381.NewDistributedProof(_r1cs, pk.(*groth16_bls381.ProvingKey), _solution, chunkSize, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return newCoordinatorBLS381(d), nil
+	case *backend_bn256.R1CS:
+		d, err := groth16_bn256.NewDistributedProof(_r1cs, pk.(*groth16_bn256.ProvingKey), _solution, chunkSize, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return newCoordinatorBN256(d), nil
+	case *backend_bw761.R1CS:
+		d, err := groth16_bw761.NewDistributedProof(_r1cs, pk.(*groth16_bw761.ProvingKey), _solution, chunkSize, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return newCoordinatorBW761(d), nil
+	default:
+		panic("unrecognized R1CS curve type")
+	}
+}
+
+// NewCoordinatorFromFile is NewCoordinator with a proving key read from pkFile (written by
+// ProvingKey.WriteTo or WriteRawTo); the coordinator only reads the points which aren't in the tasks
+func NewCoordinatorFromFile(r1cs r1cs.R1CS, pkFile io.ReaderAt, solution interface{}, chunkSize int, opts ...backend.Option) (*Coordinator, error) {
+	_solution, err := frontend.ParseWitness(solution)
+	if err != nil {
+		return nil, err
+	}
+
+	switch _r1cs := r1cs.(type) {
+	case *backend_bls377.R1CS:
+		d, err := groth16_bls377.NewDistributedProofFromFile(_r1cs, pkFile, _solution, chunkSize, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return newCoordinatorBLS377(d), nil
+	case *backend_bls381.R1CS:
+		d, err := groth16_bls381.NewDistributedProofFromFile(_r1cs, pkFile, _solution, chunkSize, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return newCoordinatorBLS381(d), nil
+	case *backend_bn256.R1CS:
+		d, err := groth16_bn256.NewDistributedProofFromFile(_r1cs, pkFile, _solution, chunkSize, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return newCoordinatorBN256(d), nil
+	case *backend_bw761.R1CS:
+		d, err := groth16_bw761.NewDistributedProofFromFile(_r1cs, pkFile, _solution, chunkSize, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return newCoordinatorBW761(d), nil
+	default:
+		panic("unrecognized R1CS curve type")
+	}
+}
+
+// Tasks returns the tasks of the proof, to be sent to the workers; they must not be modified
+func (c *Coordinator) Tasks() []Task {
+	return c.tasks
+}
+
+// NewResult returns an empty result, to read the result of a worker
+func (c *Coordinator) NewResult() Result {
+	return c.newResult()
+}
+
+// AddResult adds the result of a task to the proof; the result of a task added twice replaces the first one
+func (c *Coordinator) AddResult(res Result) error {
+	return c.addResult(res)
+}
+
+// Proof merges the results of the tasks in the proof; it fails if a result is missing
+func (c *Coordinator) Proof() (groth16.Proof, error) {
+	return c.proof()
+}
+
+// Worker computes the tasks of the proofs of a proving key
+type Worker struct {
+	newTask func() Task
+	compute func(Task, ...backend.Option) (Result, error)
+}
+
+// NewWorker returns a worker computing the tasks with the points of pk
+func NewWorker(pk groth16.ProvingKey) *Worker {
+	switch _pk := pk.(type) {
+	case *groth16_bls377.ProvingKey:
+		return newWorkerBLS377(groth16_bls377.NewMSMWorker(_pk))
+	case *groth16_bls381.ProvingKey:
+		return newWorkerBLS381(groth16_bls381.NewMSMWorker(_pk))
+	case *groth16_bn256.ProvingKey:
+		return newWorkerBN256(groth16_bn256.NewMSMWorker(_pk))
+	case *groth16_bw761.ProvingKey:
+		return newWorkerBW761(groth16_bw761.NewMSMWorker(_pk))
+	default:
+		panic("unrecognized proving key curve type")
+	}
+}
+
+// NewWorkerFromFile returns a worker computing the tasks with the points of the proving key of curveID
+// in pkFile (written by ProvingKey.WriteTo or WriteRawTo); only the points of a task are read, by
+// chunks of backend.WithKeyChunkSize points
+func NewWorkerFromFile(curveID gurvy.ID, pkFile io.ReaderAt) (*Worker, error) {
+	switch curveID {
+	case gurvy.BLS377:
+		w, err := groth16_bls377.NewMSMWorkerFromFile(pkFile)
+		if err != nil {
+			return nil, err
+		}
+		return newWorkerBLS377(w), nil
+	case gurvy.BLS381:
+		w, err := groth16_bls381.NewMSMWorkerFromFile(pkFile)
+		if err != nil {
+			return nil, err
+		}
+		return newWorkerBLS381(w), nil
+	case gurvy.BN256:
+		w, err := groth16_bn256.NewMSMWorkerFromFile(pkFile)
+		if err != nil {
+			return nil, err
+		}
+		return newWorkerBN256(w), nil
+	case gurvy.BW761:
+		w, err := groth16_bw761.NewMSMWorkerFromFile(pkFile)
+		if err != nil {
+			return nil, err
+		}
+		return newWorkerBW761(w), nil
+	default:
+		panic("not implemented")
+	}
+}
+
+// NewTask returns an empty task, to read a task of the coordinator
+func (w *Worker) NewTask() Task {
+	return w.newTask()
+}
+
+// Compute returns the result of the task; the multi exponentiation runs on the accelerator of the
+// options if any (see backend.WithAccelerator)
+func (w *Worker) Compute(task Task, opts ...backend.Option) (Result, error) {
+	return w.compute(task, opts...)
+}
+
+func newCoordinatorBLS377(d *groth16_bls377.DistributedProof) *Coordinator {
+	tasks := d.Tasks()
+	c := &Coordinator{tasks: make([]Task, len(tasks))}
+	for i := range tasks {
+		c.tasks[i] = &tasks[i]
+	}
+	c.newResult = func() Result {
+		return new(groth16_bls377.MSMResult)
+	}
+	c.addResult = func(res Result) error {
+		_res, ok := res.(*groth16_bls377.MSMResult)
+		if !ok {
+			return errCurve
+		}
+		return d.AddResult(*_res)
+	}
+	c.proof = func() (groth16.Proof, error) {
+		proof, err := d.Proof()
+		if err != nil {
+			return nil, err
+		}
+		return proof, nil
+	}
+	return c
+}
+
+func newWorkerBLS377(w *groth16_bls377.MSMWorker) *Worker {
+	return &Worker{
+		newTask: func() Task {
+			return new(groth16_bls377.MSMTask)
+		},
+		compute: func(task Task, opts ...backend.Option) (Result, error) {
+			_task, ok := task.(*groth16_bls377.MSMTask)
+			if !ok {
+				return nil, errCurve
+			}
+			res, err := w.Compute(_task, opts...)
+			if err != nil {
+				return nil, err
+			}
+			return &res, nil
+		},
+	}
+}
+
+func newCoordinatorBLS381(d *groth16_bls381.DistributedProof) *Coordinator {
+	tasks := d.Tasks()
+	c := &Coordinator{tasks: make([]Task, len(tasks))}
+	for i := range tasks {
+		c.tasks[i] = &tasks[i]
+	}
+	c.newResult = func() Result {
+		return new(groth16_bls381.MSMResult)
+	}
+	c.addResult = func(res Result) error {
+		_res, ok := res.(*groth16_bls381.MSMResult)
+		if !ok {
+			return errCurve
+		}
+		return d.AddResult(*_res)
+	}
+	c.proof = func() (groth16.Proof, error) {
+		proof, err := d.Proof()
+		if err != nil {
+			return nil, err
+		}
+		return proof, nil
+	}
+	return c
+}
+
+func newWorkerBLS381(w *groth16_bls381.MSMWorker) *Worker {
+	return &Worker{
+		newTask: func() Task {
+			return new(groth16_bls381.MSMTask)
+		},
+		compute: func(task Task, opts ...backend.Option) (Result, error) {
+			_task, ok := task.(*groth16_bls381.MSMTask)
+			if !ok {
+				return nil, errCurve
+			}
+			res, err := w.Compute(_task, opts...)
+			if err != nil {
+				return nil, err
+			}
+			return &res, nil
+		},
+	}
+}
+
+func newCoordinatorBN256(d *groth16_bn256.DistributedProof) *Coordinator {
+	tasks := d.Tasks()
+	c := &Coordinator{tasks: make([]Task, len(tasks))}
+	for i := range tasks {
+		c.tasks[i] = &tasks[i]
+	}
+	c.newResult = func() Result {
+		return new(groth16_bn256.MSMResult)
+	}
+	c.addResult = func(res Result) error {
+		_res, ok := res.(*groth16_bn256.MSMResult)
+		if !ok {
+			return errCurve
+		}
+		return d.AddResult(*_res)
+	}
+	c.proof = func() (groth16.Proof, error) {
+		proof, err := d.Proof()
+		if err != nil {
+			return nil, err
+		}
+		return proof, nil
+	}
+	return c
+}
+
+func newWorkerBN256(w *groth16_bn256.MSMWorker) *Worker {
+	return &Worker{
+		newTask: func() Task {
+			return new(groth16_bn256.MSMTask)
+		},
+		compute: func(task Task, opts ...backend.Option) (Result, error) {
+			_task, ok := task.(*groth16_bn256.MSMTask)
+			if !ok {
+				return nil, errCurve
+			}
+			res, err := w.Compute(_task, opts...)
+			if err != nil {
+				return nil, err
+			}
+			return &res, nil
+		},
+	}
+}
+
+func newCoordinatorBW761(d *groth16_bw761.DistributedProof) *Coordinator {
+	tasks := d.Tasks()
+	c := &Coordinator{tasks: make([]Task, len(tasks))}
+	for i := range tasks {
+		c.tasks[i] = &tasks[i]
+	}
+	c.newResult = func() Result {
+		return new(groth16_bw761.MSMResult)
+	}
+	c.addResult = func(res Result) error {
+		_res, ok := res.(*groth16_bw761.MSMResult)
+		if !ok {
+			return errCurve
+		}
+		return d.AddResult(*_res)
+	}
+	c.proof = func() (groth16.Proof, error) {
+		proof, err := d.Proof()
+		if err != nil {
+			return nil, err
+		}
+		return proof, nil
+	}
+	return c
+}
+
+func newWorkerBW761(w *groth16_bw761.MSMWorker) *Worker {
+	return &Worker{
+		newTask: func() Task {
+			return new(groth16_bw761.MSMTask)
+		},
+		compute: func(task Task, opts ...backend.Option) (Result, error) {
+			_task, ok := task.(*groth16_bw761.MSMTask)
+			if !ok {
+				return nil, errCurve
+			}
+			res, err := w.Compute(_task, opts...)
+			if err != nil {
+				return nil, err
+			}
+			return &res, nil
+		},
+	}
+}
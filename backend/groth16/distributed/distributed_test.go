@@ -0,0 +1,148 @@
+// Copyright 2020 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package distributed
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gurvy"
+)
+
+type cubicCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+// x**3 + x + 5 == y
+func (circuit *cubicCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	x3 := cs.Mul(circuit.X, circuit.X, circuit.X)
+	cs.AssertIsEqual(circuit.Y, cs.Add(x3, circuit.X, 5))
+	return nil
+}
+
+func TestDistributed(t *testing.T) {
+	for _, curveID := range []gurvy.ID{gurvy.BN256, gurvy.BLS381} {
+		r1cs, err := frontend.Compile(curveID, &cubicCircuit{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		pk, vk, err := groth16.Setup(r1cs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var key bytes.Buffer
+		if _, err := pk.WriteTo(&key); err != nil {
+			t.Fatal(err)
+		}
+		solution := map[string]interface{}{"X": 3, "Y": 35}
+
+		for _, fromFile := range []bool{false, true} {
+			var c *Coordinator
+			var w *Worker
+			if fromFile {
+				if c, err = NewCoordinatorFromFile(r1cs, bytes.NewReader(key.Bytes()), solution, 2); err != nil {
+					t.Fatal(err)
+				}
+				if w, err = NewWorkerFromFile(curveID, bytes.NewReader(key.Bytes())); err != nil {
+					t.Fatal(err)
+				}
+			} else {
+				if c, err = NewCoordinator(r1cs, pk, solution, 2); err != nil {
+					t.Fatal(err)
+				}
+				w = NewWorker(pk)
+			}
+
+			// the tasks and the results go through their encodings, as between machines
+			for _, task := range c.Tasks() {
+				var buf bytes.Buffer
+				if _, err := task.WriteTo(&buf); err != nil {
+					t.Fatal(err)
+				}
+				received := w.NewTask()
+				if _, err := received.ReadFrom(&buf); err != nil {
+					t.Fatal(err)
+				}
+				res, err := w.Compute(received)
+				if err != nil {
+					t.Fatal(err)
+				}
+				buf.Reset()
+				if _, err := res.WriteTo(&buf); err != nil {
+					t.Fatal(err)
+				}
+				merged := c.NewResult()
+				if _, err := merged.ReadFrom(&buf); err != nil {
+					t.Fatal(err)
+				}
+				if err := c.AddResult(merged); err != nil {
+					t.Fatal(err)
+				}
+			}
+			proof, err := c.Proof()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := groth16.Verify(proof, vk, map[string]interface{}{"Y": 35}); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if _, err := NewCoordinator(r1cs, pk, map[string]interface{}{"X": 3, "Y": 42}, 2); err == nil {
+			t.Fatal("expected error with a wrong solution")
+		}
+		if _, err := NewCoordinator(r1cs, pk, solution, 2, backend.WithMaxWorkers(0)); err == nil {
+			t.Fatal("expected error with invalid options")
+		}
+	}
+}
+
+func TestDistributedCurves(t *testing.T) {
+	var workers []*Worker
+	var coordinators []*Coordinator
+	for _, curveID := range []gurvy.ID{gurvy.BN256, gurvy.BLS381} {
+		r1cs, err := frontend.Compile(curveID, &cubicCircuit{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		pk, _, err := groth16.Setup(r1cs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, err := NewCoordinator(r1cs, pk, map[string]interface{}{"X": 3, "Y": 35}, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		coordinators = append(coordinators, c)
+		workers = append(workers, NewWorker(pk))
+	}
+
+	// the worker and the coordinator of another curve reject the task and the result
+	task := coordinators[0].Tasks()[0]
+	if _, err := workers[1].Compute(task); err == nil {
+		t.Fatal("expected error with a task of another curve")
+	}
+	res, err := workers[0].Compute(task)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := coordinators[1].AddResult(res); err == nil {
+		t.Fatal("expected error with a result of another curve")
+	}
+}
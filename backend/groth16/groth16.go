@@ -16,10 +16,12 @@
 package groth16
 
 import (
+	"errors"
 	"io"
 
 	"github.com/consensys/gurvy"
 
+	"github.com/consensys/gnark/backend"
 	"github.com/consensys/gnark/frontend"
 	backend_bls377 "github.com/consensys/gnark/internal/backend/bls377"
 	backend_bls381 "github.com/consensys/gnark/internal/backend/bls381"
@@ -61,23 +63,101 @@ type VerifyingKey interface {
 	io.WriterTo
 	io.ReaderFrom
 	IsDifferent(interface{}) bool
+
+	// Precompute computes the lines of the Miller loops of the fixed points of the key, to speed up
+	// Verify and BatchVerify for a verifier checking many proofs against the same key
+	Precompute()
+}
+
+// ErrCommitment is returned by the exports of a VerifyingKey to the formats of other verifiers, which don't
+// verify the commitment of a circuit (see frontend.ConstraintSystem.Commit)
+var ErrCommitment = errors.New("the verifying key has a commitment, which this format doesn't support")
+
+// Accelerators of the prover, one per curve (see backend.WithAccelerator)
+type (
+	AcceleratorBLS377 = groth16_bls377.Accelerator
+	AcceleratorBLS381 = groth16_bls381.Accelerator
+	AcceleratorBN256  = groth16_bn256.Accelerator
+	AcceleratorBW761  = groth16_bw761.Accelerator
+)
+
+// HasCommitment returns true if the circuit of vk has a commitment (see frontend.ConstraintSystem.Commit)
+func HasCommitment(vk VerifyingKey) bool {
+	switch _vk := vk.(type) {
+	case *groth16_bls377.VerifyingKey:
+		return _vk.Commitment != nil
+	case *groth16_bls381.VerifyingKey:
+		return _vk.Commitment != nil
+	case *groth16_bn256.VerifyingKey:
+		return _vk.Commitment != nil
+	case *groth16_bw761.VerifyingKey:
+		return _vk.Commitment != nil
+	default:
+		return false
+	}
 }
 
 // Verify runs the groth16.Verify algorithm on provided proof with given solution
-func Verify(proof Proof, vk VerifyingKey, solution interface{}) error {
+//
+// see backend.ConstantTime for a verification without early exits on the checks of the proof
+func Verify(proof Proof, vk VerifyingKey, solution interface{}, opts ...backend.Option) error {
 	_solution, err := frontend.ParseWitness(solution)
 	if err != nil {
 		return err
 	}
 	switch _proof := proof.(type) {
 	case *groth16_bls377.Proof:
-		return groth16_bls377.Verify(_proof, vk.(*groth16_bls377.VerifyingKey), _solution)
+		return groth16_bls377.Verify(_proof, vk.(*groth16_bls377.VerifyingKey), _solution, opts...)
 	case *groth16_bls381.Proof:
-		return groth16_bls381.Verify(_proof, vk.(*groth16_bls381.VerifyingKey), _solution)
+		return groth16_bls381.Verify(_proof, vk.(*groth16_bls381.VerifyingKey), _solution, opts...)
 	case *groth16_bn256.Proof:
-		return groth16_bn256.Verify(_proof, vk.(*groth16_bn256.VerifyingKey), _solution)
+		return groth16_bn256.Verify(_proof, vk.(*groth16_bn256.VerifyingKey), _solution, opts...)
 	case *groth16_bw761.Proof:
-		return groth16_bw761.Verify(_proof, vk.(*groth16_bw761.VerifyingKey), _solution)
+		return groth16_bw761.Verify(_proof, vk.(*groth16_bw761.VerifyingKey), _solution, opts...)
+	default:
+		panic("unrecognized R1CS curve type")
+	}
+}
+
+// BatchVerify verifies proofs of the circuit of vk with their public witnesses (see Verify), proofs[i]
+// with publicWitnesses[i], combining the pairing checks: it is much faster than verifying the proofs one
+// by one, but the error doesn't tell which proof is invalid
+func BatchVerify(vk VerifyingKey, proofs []Proof, publicWitnesses []interface{}) error {
+	if len(proofs) != len(publicWitnesses) {
+		return errors.New("the number of proofs and of public witnesses don't match")
+	}
+	_solutions := make([]map[string]interface{}, len(publicWitnesses))
+	for i := 0; i < len(publicWitnesses); i++ {
+		var err error
+		if _solutions[i], err = frontend.ParseWitness(publicWitnesses[i]); err != nil {
+			return err
+		}
+	}
+	switch _vk := vk.(type) {
+	case *groth16_bls377.VerifyingKey:
+		_proofs := make([]*groth16_bls377.Proof, len(proofs))
+		for i := 0; i < len(proofs); i++ {
+			_proofs[i] = proofs[i].(*groth16_bls377.Proof)
+		}
+		return groth16_bls377.BatchVerify(_vk, _proofs, _solutions)
+	case *groth16_bls381.VerifyingKey:
+		_proofs := make([]*groth16_bls381.Proof, len(proofs))
+		for i := 0; i < len(proofs); i++ {
+			_proofs[i] = proofs[i].(*groth16_bls381.Proof)
+		}
+		return groth16_bls381.BatchVerify(_vk, _proofs, _solutions)
+	case *groth16_bn256.VerifyingKey:
+		_proofs := make([]*groth16_bn256.Proof, len(proofs))
+		for i := 0; i < len(proofs); i++ {
+			_proofs[i] = proofs[i].(*groth16_bn256.Proof)
+		}
+		return groth16_bn256.BatchVerify(_vk, _proofs, _solutions)
+	case *groth16_bw761.VerifyingKey:
+		_proofs := make([]*groth16_bw761.Proof, len(proofs))
+		for i := 0; i < len(proofs); i++ {
+			_proofs[i] = proofs[i].(*groth16_bw761.Proof)
+		}
+		return groth16_bw761.BatchVerify(_vk, _proofs, _solutions)
 	default:
 		panic("unrecognized R1CS curve type")
 	}
@@ -86,7 +166,20 @@ func Verify(proof Proof, vk VerifyingKey, solution interface{}) error {
 // Prove generates the proof of knoweldge of a r1cs with solution.
 // if force flag is set, Prove ignores R1CS solving error (ie invalid solution) and executes
 // the FFTs and MultiExponentiations to compute an (invalid) Proof object
+//
+// see ProveWithOptions to pass backend options to the prover
 func Prove(r1cs r1cs.R1CS, pk ProvingKey, solution interface{}, force ...bool) (Proof, error) {
+	if len(force) > 0 && force[0] {
+		return ProveWithOptions(r1cs, pk, solution, backend.IgnoreSolverError())
+	}
+	return ProveWithOptions(r1cs, pk, solution)
+}
+
+// ProveWithOptions is Prove with backend options, backend.IgnoreSolverError() replacing the force flag
+//
+// see backend.WithOutOfCoreFFT to bound the memory used by the FFTs, and backend.WithContext and
+// backend.WithProgress to cancel and monitor the prover
+func ProveWithOptions(r1cs r1cs.R1CS, pk ProvingKey, solution interface{}, opts ...backend.Option) (Proof, error) {
 
 	_solution, err := frontend.ParseWitness(solution)
 
@@ -94,54 +187,128 @@ func Prove(r1cs r1cs.R1CS, pk ProvingKey, solution interface{}, force ...bool) (
 		return nil, err
 	}
 
-	_force := false
-	if len(force) > 0 {
-		_force = force[0]
+	switch _r1cs := r1cs.(type) {
+	case *backend_bls377.R1CS:
+		return groth16_bls377.Prove(_r1cs, pk.(*groth16_bls377.ProvingKey), _solution, false, opts...)
+	case *backend_bls381.R1CS:
+		return groth16_bls381.Prove(_r1cs, pk.(*groth16_bls381.ProvingKey), _solution, false, opts...)
+	case *backend_bn256.R1CS:
+		return groth16_bn256.Prove(_r1cs, pk.(*groth16_bn256.ProvingKey), _solution, false, opts...)
+	case *backend_bw761.R1CS:
+		return groth16_bw761.Prove(_r1cs, pk.(*groth16_bw761.ProvingKey), _solution, false, opts...)
+	default:
+		panic("unrecognized R1CS curve type")
+	}
+}
+
+// ProveFromFile is Prove with a proving key read by chunks from pk, as written by ProvingKey.WriteTo or
+// WriteRawTo, for circuits whose proving key doesn't fit in memory
+//
+// see backend.WithKeyChunkSize, and backend.WithOutOfCoreFFT to also bound the memory used by the FFTs
+func ProveFromFile(r1cs r1cs.R1CS, pk io.ReaderAt, solution interface{}, opts ...backend.Option) (Proof, error) {
+	_solution, err := frontend.ParseWitness(solution)
+	if err != nil {
+		return nil, err
 	}
 
 	switch _r1cs := r1cs.(type) {
 	case *backend_bls377.R1CS:
-		return groth16_bls377.Prove(_r1cs, pk.(*groth16_bls377.ProvingKey), _solution, _force)
+		return groth16_bls377.ProveFromFile(_r1cs, pk, _solution, opts...)
 	case *backend_bls381.R1CS:
-		return groth16_bls381.Prove(_r1cs, pk.(*groth16_bls381.ProvingKey), _solution, _force)
+		return groth16_bls381.ProveFromFile(_r1cs, pk, _solution, opts...)
 	case *backend_bn256.R1CS:
-		return groth16_bn256.Prove(_r1cs, pk.(*groth16_bn256.ProvingKey), _solution, _force)
+		return groth16_bn256.ProveFromFile(_r1cs, pk, _solution, opts...)
 	case *backend_bw761.R1CS:
-		return groth16_bw761.Prove(_r1cs, pk.(*groth16_bw761.ProvingKey), _solution, _force)
+		return groth16_bw761.ProveFromFile(_r1cs, pk, _solution, opts...)
 	default:
 		panic("unrecognized R1CS curve type")
 	}
 }
 
+// ProveBatch generates the proofs of knowledge of a r1cs with each of the solutions (see Prove)
+//
+// the solver, the FFTs and the MultiExponentiations of consecutive instances run concurrently, which
+// keeps the CPUs busy during the sequential parts of each phase
+func ProveBatch(r1cs r1cs.R1CS, pk ProvingKey, solutions []interface{}, opts ...backend.Option) ([]Proof, error) {
+	_solutions := make([]map[string]interface{}, len(solutions))
+	for i := 0; i < len(solutions); i++ {
+		var err error
+		if _solutions[i], err = frontend.ParseWitness(solutions[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	proofs := make([]Proof, len(solutions))
+	switch _r1cs := r1cs.(type) {
+	case *backend_bls377.R1CS:
+		_proofs, err := groth16_bls377.ProveBatch(_r1cs, pk.(*groth16_bls377.ProvingKey), _solutions, opts...)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < len(_proofs); i++ {
+			proofs[i] = _proofs[i]
+		}
+	case *backend_bls381.R1CS:
+		_proofs, err := groth16_bls381.ProveBatch(_r1cs, pk.(*groth16_bls381.ProvingKey), _solutions, opts...)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < len(_proofs); i++ {
+			proofs[i] = _proofs[i]
+		}
+	case *backend_bn256.R1CS:
+		_proofs, err := groth16_bn256.ProveBatch(_r1cs, pk.(*groth16_bn256.ProvingKey), _solutions, opts...)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < len(_proofs); i++ {
+			proofs[i] = _proofs[i]
+		}
+	case *backend_bw761.R1CS:
+		_proofs, err := groth16_bw761.ProveBatch(_r1cs, pk.(*groth16_bw761.ProvingKey), _solutions, opts...)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < len(_proofs); i++ {
+			proofs[i] = _proofs[i]
+		}
+	default:
+		panic("unrecognized R1CS curve type")
+	}
+	return proofs, nil
+}
+
 // Setup runs groth16.Setup with provided R1CS
-func Setup(r1cs r1cs.R1CS) (ProvingKey, VerifyingKey, error) {
+//
+// the computation is parallelized; see backend.WithProgress to monitor it
+func Setup(r1cs r1cs.R1CS, opts ...backend.Option) (ProvingKey, VerifyingKey, error) {
 
 	switch _r1cs := r1cs.(type) {
 	case *backend_bls377.R1CS:
 		var pk groth16_bls377.ProvingKey
 		var vk groth16_bls377.VerifyingKey
-		if err := groth16_bls377.Setup(_r1cs, &pk, &vk); err != nil {
+		if err := groth16_bls377.Setup(_r1cs, &pk, &vk, opts...); err != nil {
 			return nil, nil, err
 		}
 		return &pk, &vk, nil
 	case *backend_bls381.R1CS:
 		var pk groth16_bls381.ProvingKey
 		var vk groth16_bls381.VerifyingKey
-		if err := groth16_bls381.Setup(_r1cs, &pk, &vk); err != nil {
+		if err := groth16_bls381.Setup(_r1cs, &pk, &vk, opts...); err != nil {
 			return nil, nil, err
 		}
 		return &pk, &vk, nil
 	case *backend_bn256.R1CS:
 		var pk groth16_bn256.ProvingKey
 		var vk groth16_bn256.VerifyingKey
-		if err := groth16_bn256.Setup(_r1cs, &pk, &vk); err != nil {
+		if err := groth16_bn256.Setup(_r1cs, &pk, &vk, opts...); err != nil {
 			return nil, nil, err
 		}
 		return &pk, &vk, nil
 	case *backend_bw761.R1CS:
 		var pk groth16_bw761.ProvingKey
 		var vk groth16_bw761.VerifyingKey
-		if err := groth16_bw761.Setup(_r1cs, &pk, &vk); err != nil {
+		if err := groth16_bw761.Setup(_r1cs, &pk, &vk, opts...); err != nil {
 			return nil, nil, err
 		}
 		return &pk, &vk, nil
@@ -183,6 +350,43 @@ func DummySetup(r1cs r1cs.R1CS) (ProvingKey, error) {
 	}
 }
 
+// WriteMmapProvingKey writes pk in the memory layout of its points, to be loaded by MmapProvingKey on a
+// machine with the same byte order
+func WriteMmapProvingKey(pk ProvingKey, w io.Writer) (int64, error) {
+	switch _pk := pk.(type) {
+	case *groth16_bls377.ProvingKey:
+		return _pk.WriteMmapTo(w)
+	case *groth16_bls381.ProvingKey:
+		return _pk.WriteMmapTo(w)
+	case *groth16_bn256.ProvingKey:
+		return _pk.WriteMmapTo(w)
+	case *groth16_bw761.ProvingKey:
+		return _pk.WriteMmapTo(w)
+	default:
+		panic("unrecognized ProvingKey curve type")
+	}
+}
+
+// MmapProvingKey maps in memory a proving key written by WriteMmapProvingKey: the points aren't
+// decoded, and the pages of the file are read when the prover needs them, so that a process can keep
+// the keys of many circuits at a low memory cost
+//
+// the key must not be used after Close
+func MmapProvingKey(curveID gurvy.ID, path string) (ProvingKey, io.Closer, error) {
+	switch curveID {
+	case gurvy.BN256:
+		return groth16_bn256.MmapProvingKey(path)
+	case gurvy.BLS377:
+		return groth16_bls377.MmapProvingKey(path)
+	case gurvy.BLS381:
+		return groth16_bls381.MmapProvingKey(path)
+	case gurvy.BW761:
+		return groth16_bw761.MmapProvingKey(path)
+	default:
+		panic("not implemented")
+	}
+}
+
 // NewProvingKey instantiates a curve-typed ProvingKey and returns an interface object
 // This function exists for serialization purposes
 func NewProvingKey(curveID gurvy.ID) ProvingKey {
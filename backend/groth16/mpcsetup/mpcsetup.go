@@ -0,0 +1,374 @@
+// Copyright 2020 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package mpcsetup implements a multi-party computation of the Groth16 keys on BN256, such that the
+// toxic waste is unknown as long as one contributor is honest
+//
+// the ceremony has two phases:
+//
+//   - Phase 1 (powers of tau) doesn't depend on the circuit; its output has the layout of the
+//     Powers-of-Tau ceremonies (.ptau files), and can be imported from one of them (see ReadPtau)
+//   - Phase 2 is specific to a circuit: InitPhase2 evaluates the circuit on the output of Phase 1,
+//     then each contributor updates δ. The last contribution should be ContributeWithBeacon
+//
+// each contribution is verified against the previous one (VerifyPhase1, VerifyPhase2), then
+// ExtractKeys returns the keys used by the groth16 package
+package mpcsetup
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+	"math/bits"
+
+	"github.com/consensys/gnark/internal/backend/bn256/fft"
+	"github.com/consensys/gnark/internal/utils"
+	curve "github.com/consensys/gurvy/bn256"
+	"github.com/consensys/gurvy/bn256/fp"
+	"github.com/consensys/gurvy/bn256/fr"
+)
+
+// errors returned by the verifications of the contributions
+var (
+	ErrInvalidPublicKey    = errors.New("invalid proof of knowledge of the contribution")
+	ErrInvalidContribution = errors.New("parameters don't match the proof of knowledge of the contribution")
+	ErrInvalidParameters   = errors.New("malformed parameters")
+	ErrInvalidHash         = errors.New("hash of the contribution doesn't match its parameters")
+)
+
+// PublicKey is the proof of knowledge of the secret x of a contribution
+//
+// the contributor samples s and publishes [s]1, [s·x]1 and [x]R, where R in G2 is derived from
+// [s]1, [s·x]1 and the hash of the previous contribution; the update of the parameters by x is
+// then checked with pairings against [x]R
+type PublicKey struct {
+	SG  curve.G1Affine // [s]1
+	SXG curve.G1Affine // [s·x]1
+	XR  curve.G2Affine // [x]R
+}
+
+// newPublicKey returns the proof of knowledge of x; dst separates the secrets of a contribution
+func newPublicKey(x fr.Element, challenge []byte, dst byte) (PublicKey, error) {
+	var s fr.Element
+	if _, err := s.SetRandom(); err != nil {
+		return PublicKey{}, err
+	}
+	return newPublicKeyFromSecret(s, x, challenge, dst)
+}
+
+// newPublicKeyFromSecret returns the proof of knowledge of x for a given s
+func newPublicKeyFromSecret(s, x fr.Element, challenge []byte, dst byte) (PublicKey, error) {
+	var pk PublicKey
+	_, _, g1, _ := curve.Generators()
+
+	var sx fr.Element
+	sx.Mul(&s, &x)
+
+	var b big.Int
+	pk.SG.ScalarMultiplication(&g1, s.ToBigIntRegular(&b))
+	pk.SXG.ScalarMultiplication(&g1, sx.ToBigIntRegular(&b))
+
+	R, err := genR(pk.SG, pk.SXG, challenge, dst)
+	if err != nil {
+		return pk, err
+	}
+	pk.XR.ScalarMultiplication(&R, x.ToBigIntRegular(&b))
+	return pk, nil
+}
+
+// verify returns the point R of the proof of knowledge, if the proof is valid
+func (pk *PublicKey) verify(challenge []byte, dst byte) (curve.G2Affine, error) {
+	if !pk.SG.IsInSubGroup() || !pk.SXG.IsInSubGroup() || !pk.XR.IsInSubGroup() || pk.SG.X.IsZero() {
+		return curve.G2Affine{}, ErrInvalidPublicKey
+	}
+	R, err := genR(pk.SG, pk.SXG, challenge, dst)
+	if err != nil {
+		return R, err
+	}
+	if !sameRatio(pk.SXG, pk.SG, pk.XR, R) {
+		return R, ErrInvalidPublicKey
+	}
+	return R, nil
+}
+
+// genR hashes [s]1, [s·x]1 and the challenge to G2
+func genR(sG1, sxG1 curve.G1Affine, challenge []byte, dst byte) (curve.G2Affine, error) {
+	var buf bytes.Buffer
+	buf.Grow(len(challenge) + curve.SizeOfG1AffineUncompressed*2)
+	b1, b2 := sG1.RawBytes(), sxG1.RawBytes()
+	buf.Write(b1[:])
+	buf.Write(b2[:])
+	buf.Write(challenge)
+
+	// HashToCurveG2Svdw doesn't always return a point of the curve, nor of the subgroup of order r:
+	// the message is extended with a counter until it does, then the point is multiplied by the
+	// cofactor 2p - r of G2 with a double-and-add (the GLV scalar multiplication is only correct
+	// in the subgroup)
+	for counter := byte(0); ; counter++ {
+		R, err := curve.HashToCurveG2Svdw(append(buf.Bytes(), counter), []byte{dst})
+		if err != nil {
+			return R, err
+		}
+		if !R.IsOnCurve() {
+			continue
+		}
+		var acc, base curve.G2Jac
+		base.FromAffine(&R)
+		acc.FromAffine(&curve.G2Affine{}) // infinity
+		for i := g2Cofactor.BitLen() - 1; i >= 0; i-- {
+			acc.DoubleAssign()
+			if g2Cofactor.Bit(i) == 1 {
+				acc.AddAssign(&base)
+			}
+		}
+		if acc.Z.IsZero() {
+			continue
+		}
+		return *R.FromJacobian(&acc), nil
+	}
+}
+
+var g2Cofactor = new(big.Int).Sub(new(big.Int).Lsh(fp.Modulus(), 1), fr.Modulus())
+
+// sameRatio returns true if a1/b1 == a2/b2 in the exponent, that is e(a1, b2) == e(b1, a2)
+func sameRatio(a1, b1 curve.G1Affine, a2, b2 curve.G2Affine) bool {
+	var nb1 curve.G1Affine
+	nb1.Neg(&b1)
+	ok, err := curve.PairingCheck([]curve.G1Affine{a1, nb1}, []curve.G2Affine{b2, a2})
+	return err == nil && ok
+}
+
+// randomScalars returns n random scalars, for the random linear combinations of the verifications
+func randomScalars(n int) ([]fr.Element, error) {
+	res := make([]fr.Element, n)
+	for i := range res {
+		if _, err := res[i].SetRandom(); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// linearCombinationG1 returns Σ r_i A[i] and Σ r_i A[i+1] for random r_i; if A[i+1]/A[i] is the
+// same x for all i, the second is the first multiplied by x (with overwhelming probability otherwise
+// not)
+func linearCombinationG1(A []curve.G1Affine) (L0, L1 curve.G1Affine, err error) {
+	return mergeG1(A[:len(A)-1], A[1:])
+}
+
+// linearCombinationG2 is linearCombinationG1 in G2
+func linearCombinationG2(A []curve.G2Affine) (L0, L1 curve.G2Affine, err error) {
+	r, err := randomScalars(len(A) - 1)
+	if err != nil {
+		return
+	}
+	L0.MultiExp(A[:len(A)-1], r)
+	L1.MultiExp(A[1:], r)
+	return
+}
+
+// mergeG1 returns Σ r_i A[i] and Σ r_i B[i] for random r_i, to check that B[i]/A[i] is the same x
+// for all i with a single pairing
+func mergeG1(A, B []curve.G1Affine) (LA, LB curve.G1Affine, err error) {
+	r, err := randomScalars(len(A))
+	if err != nil {
+		return
+	}
+	LA.MultiExp(A, r)
+	LB.MultiExp(B, r)
+	return
+}
+
+// scaleG1 multiplies A[i] by scalars[i]
+func scaleG1(A []curve.G1Affine, scalars []fr.Element) {
+	utils.Parallelize(len(A), func(start, end int) {
+		var s big.Int
+		for i := start; i < end; i++ {
+			A[i].ScalarMultiplication(&A[i], scalars[i].ToBigIntRegular(&s))
+		}
+	})
+}
+
+// scaleG1By multiplies the points of A by x
+func scaleG1By(A []curve.G1Affine, x fr.Element) {
+	var s big.Int
+	x.ToBigIntRegular(&s)
+	utils.Parallelize(len(A), func(start, end int) {
+		for i := start; i < end; i++ {
+			A[i].ScalarMultiplication(&A[i], &s)
+		}
+	})
+}
+
+// scaleG2 multiplies A[i] by scalars[i]
+func scaleG2(A []curve.G2Affine, scalars []fr.Element) {
+	utils.Parallelize(len(A), func(start, end int) {
+		var s big.Int
+		for i := start; i < end; i++ {
+			A[i].ScalarMultiplication(&A[i], scalars[i].ToBigIntRegular(&s))
+		}
+	})
+}
+
+// powers returns [1, x, x², ..., x^(n-1)] multiplied by c
+func powers(c, x fr.Element, n int) []fr.Element {
+	res := make([]fr.Element, n)
+	if n == 0 {
+		return res
+	}
+	res[0] = c
+	for i := 1; i < n; i++ {
+		res[i].Mul(&res[i-1], &x)
+	}
+	return res
+}
+
+// hashPoints returns the sha256 of the points, in this order
+func hashPoints(g1 [][]curve.G1Affine, g2 [][]curve.G2Affine) []byte {
+	h := sha256.New()
+	for _, points := range g1 {
+		for i := range points {
+			b := points[i].Bytes()
+			h.Write(b[:])
+		}
+	}
+	for _, points := range g2 {
+		for i := range points {
+			b := points[i].Bytes()
+			h.Write(b[:])
+		}
+	}
+	return h.Sum(nil)
+}
+
+// lagrangeG1 returns [L_0(τ)]1, ..., [L_{n-1}(τ)]1 on the domain of size n == len(powers) from
+// the powers [τ⁰]1, ..., [τ^(n-1)]1
+//
+// L_i(X) = 1/n Σ_j (ω^-i X)^j, so the bases are the inverse FFT of the powers
+func lagrangeG1(powers []curve.G1Affine, domain *fft.Domain) []curve.G1Affine {
+	n := len(powers)
+	a := make([]curve.G1Jac, n)
+	for i := range powers {
+		a[i].FromAffine(&powers[i])
+	}
+	bitReverseG1Jac(a)
+
+	var s big.Int
+	for _, twiddles := range inverseTwiddles(domain, n) {
+		m := 2 * len(twiddles)
+		utils.Parallelize(n/2, func(start, end int) {
+			var t curve.G1Jac
+			var w big.Int
+			for i := start; i < end; i++ {
+				k, j := (i/len(twiddles))*m, i%len(twiddles)
+				t.ScalarMultiplication(&a[k+j+m/2], twiddles[j].ToBigIntRegular(&w))
+				a[k+j+m/2] = a[k+j]
+				a[k+j+m/2].SubAssign(&t)
+				a[k+j].AddAssign(&t)
+			}
+		})
+	}
+
+	res := make([]curve.G1Affine, n)
+	domain.CardinalityInv.ToBigIntRegular(&s)
+	utils.Parallelize(n, func(start, end int) {
+		for i := start; i < end; i++ {
+			a[i].ScalarMultiplication(&a[i], &s)
+			res[i].FromJacobian(&a[i])
+		}
+	})
+	return res
+}
+
+// lagrangeG2 is lagrangeG1 in G2
+func lagrangeG2(powers []curve.G2Affine, domain *fft.Domain) []curve.G2Affine {
+	n := len(powers)
+	a := make([]curve.G2Jac, n)
+	for i := range powers {
+		a[i].FromAffine(&powers[i])
+	}
+	bitReverseG2Jac(a)
+
+	var s big.Int
+	for _, twiddles := range inverseTwiddles(domain, n) {
+		m := 2 * len(twiddles)
+		utils.Parallelize(n/2, func(start, end int) {
+			var t curve.G2Jac
+			var w big.Int
+			for i := start; i < end; i++ {
+				k, j := (i/len(twiddles))*m, i%len(twiddles)
+				t.ScalarMultiplication(&a[k+j+m/2], twiddles[j].ToBigIntRegular(&w))
+				a[k+j+m/2] = a[k+j]
+				a[k+j+m/2].SubAssign(&t)
+				a[k+j].AddAssign(&t)
+			}
+		})
+	}
+
+	res := make([]curve.G2Affine, n)
+	domain.CardinalityInv.ToBigIntRegular(&s)
+	utils.Parallelize(n, func(start, end int) {
+		for i := start; i < end; i++ {
+			a[i].ScalarMultiplication(&a[i], &s)
+			res[i].FromJacobian(&a[i])
+		}
+	})
+	return res
+}
+
+// inverseTwiddles returns the twiddles of each stage of an iterative inverse FFT of size n: the
+// stage merging blocks of size m/2 uses ω_m^-j for j < m/2, where ω_m has order m
+func inverseTwiddles(domain *fft.Domain, n int) [][]fr.Element {
+	var res [][]fr.Element
+	for m := 2; m <= n; m <<= 1 {
+		var w fr.Element
+		w.Exp(domain.GeneratorInv, big.NewInt(int64(n/m)))
+		res = append(res, powers(fr.One(), w, m/2))
+	}
+	return res
+}
+
+func bitReverseG1Jac(a []curve.G1Jac) {
+	n := uint(len(a))
+	nn := uint(bits.UintSize - bits.TrailingZeros(n))
+	for i := uint(0); i < n; i++ {
+		irev := bits.Reverse(i) >> nn
+		if irev > i {
+			a[i], a[irev] = a[irev], a[i]
+		}
+	}
+}
+
+func bitReverseG2Jac(a []curve.G2Jac) {
+	n := uint(len(a))
+	nn := uint(bits.UintSize - bits.TrailingZeros(n))
+	for i := uint(0); i < n; i++ {
+		irev := bits.Reverse(i) >> nn
+		if irev > i {
+			a[i], a[irev] = a[irev], a[i]
+		}
+	}
+}
+
+func bitReverseG1(a []curve.G1Affine) {
+	n := uint(len(a))
+	nn := uint(bits.UintSize - bits.TrailingZeros(n))
+	for i := uint(0); i < n; i++ {
+		irev := bits.Reverse(i) >> nn
+		if irev > i {
+			a[i], a[irev] = a[irev], a[i]
+		}
+	}
+}
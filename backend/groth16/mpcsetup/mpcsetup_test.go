@@ -0,0 +1,147 @@
+// Copyright 2020 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mpcsetup
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gurvy"
+	"github.com/stretchr/testify/require"
+)
+
+type cubicCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+// x**3 + 3x + 5 == y
+func (circuit *cubicCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	x3 := cs.Mul(circuit.X, circuit.X, circuit.X)
+	cs.AssertIsEqual(circuit.Y, cs.Add(x3, cs.Mul(circuit.X, 3), 5))
+	return nil
+}
+
+func (c *Phase1) clone() *Phase1 {
+	r := *c
+	r.Parameters.G1.Tau = append(r.Parameters.G1.Tau[:0:0], c.Parameters.G1.Tau...)
+	r.Parameters.G1.AlphaTau = append(r.Parameters.G1.AlphaTau[:0:0], c.Parameters.G1.AlphaTau...)
+	r.Parameters.G1.BetaTau = append(r.Parameters.G1.BetaTau[:0:0], c.Parameters.G1.BetaTau...)
+	r.Parameters.G2.Tau = append(r.Parameters.G2.Tau[:0:0], c.Parameters.G2.Tau...)
+	return &r
+}
+
+func (c *Phase2) clone() *Phase2 {
+	r := *c
+	r.Parameters.G1.L = append(r.Parameters.G1.L[:0:0], c.Parameters.G1.L...)
+	r.Parameters.G1.Z = append(r.Parameters.G1.Z[:0:0], c.Parameters.G1.Z...)
+	return &r
+}
+
+func TestCeremony(t *testing.T) {
+	assert := require.New(t)
+
+	var circuit cubicCircuit
+	r1cs, err := frontend.Compile(gurvy.BN256, &circuit)
+	assert.NoError(err)
+
+	// phase 1
+	phase1 := []*Phase1{NewPhase1(3)}
+	for i := 0; i < 2; i++ {
+		next := phase1[len(phase1)-1].clone()
+		assert.NoError(next.Contribute())
+		phase1 = append(phase1, next)
+	}
+	assert.NoError(VerifyPhase1(phase1...))
+
+	// phase 2
+	srs1 := phase1[len(phase1)-1]
+	init, evals, err := InitPhase2(r1cs, srs1)
+	assert.NoError(err)
+	phase2 := []*Phase2{init}
+	for i := 0; i < 2; i++ {
+		next := phase2[len(phase2)-1].clone()
+		assert.NoError(next.Contribute())
+		phase2 = append(phase2, next)
+	}
+	final := phase2[len(phase2)-1].clone()
+	assert.NoError(final.ContributeWithBeacon([]byte("beacon"), 4))
+	phase2 = append(phase2, final)
+	assert.NoError(VerifyPhase2(phase2...))
+
+	// the beacon contribution can be replayed
+	replay := phase2[len(phase2)-2].clone()
+	assert.NoError(replay.ContributeWithBeacon([]byte("beacon"), 4))
+	assert.Equal(final.Hash, replay.Hash)
+
+	// the keys prove and verify
+	pk, vk, err := ExtractKeys(r1cs, final, evals)
+	assert.NoError(err)
+
+	var witness cubicCircuit
+	witness.X.Assign(3)
+	witness.Y.Assign(41)
+	proof, err := groth16.Prove(r1cs, pk, &witness)
+	assert.NoError(err)
+	assert.NoError(groth16.Verify(proof, vk, &witness))
+
+	var bad cubicCircuit
+	bad.X.Assign(3)
+	bad.Y.Assign(42)
+	_, err = groth16.Prove(r1cs, pk, &bad)
+	assert.Error(err)
+}
+
+func TestVerifyTamperedContribution(t *testing.T) {
+	assert := require.New(t)
+
+	srs1 := NewPhase1(2)
+	next := srs1.clone()
+	assert.NoError(next.Contribute())
+	assert.NoError(VerifyPhase1(srs1, next))
+
+	// a point not updated by the contribution
+	tampered := next.clone()
+	tampered.Parameters.G1.Tau[2] = srs1.Parameters.G1.Tau[2]
+	tampered.Hash = tampered.hash()
+	assert.Equal(ErrInvalidContribution, VerifyPhase1(srs1, tampered))
+
+	// the hash doesn't match
+	tampered = next.clone()
+	tampered.Parameters.G1.AlphaTau[1] = next.Parameters.G1.AlphaTau[0]
+	assert.Equal(ErrInvalidHash, VerifyPhase1(srs1, tampered))
+
+	var circuit cubicCircuit
+	r1cs, err := frontend.Compile(gurvy.BN256, &circuit)
+	assert.NoError(err)
+	init, _, err := InitPhase2(r1cs, next)
+	assert.NoError(err)
+	next2 := init.clone()
+	assert.NoError(next2.Contribute())
+	assert.NoError(VerifyPhase2(init, next2))
+
+	// δ is updated, but not L
+	tampered2 := next2.clone()
+	tampered2.Parameters.G1.L = init.Parameters.G1.L
+	tampered2.Hash = tampered2.hash()
+	assert.Equal(ErrInvalidContribution, VerifyPhase2(init, tampered2))
+
+	// the proof of knowledge is for another challenge
+	tampered2 = next2.clone()
+	tampered2.PublicKey = next.PublicKeys.Tau
+	tampered2.Hash = tampered2.hash()
+	assert.Error(VerifyPhase2(init, tampered2))
+}
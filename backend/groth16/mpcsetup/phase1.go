@@ -0,0 +1,233 @@
+// Copyright 2020 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mpcsetup
+
+import (
+	"bytes"
+	"math/big"
+
+	curve "github.com/consensys/gurvy/bn256"
+	"github.com/consensys/gurvy/bn256/fr"
+)
+
+// domain separation tags of the proofs of knowledge
+const (
+	dstTau   byte = 1
+	dstAlpha byte = 2
+	dstBeta  byte = 3
+	dstDelta byte = 4
+)
+
+// Phase1 is the state of the powers of tau ceremony after a contribution, for circuits of up to
+// N = 2^power constraints
+type Phase1 struct {
+	Parameters struct {
+		G1 struct {
+			Tau      []curve.G1Affine // [τ⁰]1, [τ¹]1, ..., [τ^(2N-2)]1
+			AlphaTau []curve.G1Affine // [ατ⁰]1, [ατ¹]1, ..., [ατ^(N-1)]1
+			BetaTau  []curve.G1Affine // [βτ⁰]1, [βτ¹]1, ..., [βτ^(N-1)]1
+		}
+		G2 struct {
+			Tau  []curve.G2Affine // [τ⁰]2, [τ¹]2, ..., [τ^(N-1)]2
+			Beta curve.G2Affine   // [β]2
+		}
+	}
+
+	// proofs of knowledge of the contribution; zero for the initial state
+	PublicKeys struct {
+		Tau, Alpha, Beta PublicKey
+	}
+
+	// hash of the parameters and of the public keys, challenge of the next contribution
+	Hash []byte
+}
+
+// NewPhase1 returns the initial state of the powers of tau ceremony (τ = α = β = 1), for circuits of
+// up to 2^power constraints
+func NewPhase1(power int) *Phase1 {
+	N := 1 << power
+	_, _, g1, g2 := curve.Generators()
+
+	var c Phase1
+	c.Parameters.G1.Tau = make([]curve.G1Affine, 2*N-1)
+	c.Parameters.G1.AlphaTau = make([]curve.G1Affine, N)
+	c.Parameters.G1.BetaTau = make([]curve.G1Affine, N)
+	c.Parameters.G2.Tau = make([]curve.G2Affine, N)
+	for i := range c.Parameters.G1.Tau {
+		c.Parameters.G1.Tau[i] = g1
+	}
+	for i := 0; i < N; i++ {
+		c.Parameters.G1.AlphaTau[i] = g1
+		c.Parameters.G1.BetaTau[i] = g1
+		c.Parameters.G2.Tau[i] = g2
+	}
+	c.Parameters.G2.Beta = g2
+
+	c.Hash = c.hash()
+	return &c
+}
+
+// Contribute updates the parameters with random secrets τ, α and β, which aren't kept
+func (c *Phase1) Contribute() error {
+	var tau, alpha, beta fr.Element
+	for _, x := range []*fr.Element{&tau, &alpha, &beta} {
+		if _, err := x.SetRandom(); err != nil {
+			return err
+		}
+	}
+	return c.contribute(tau, alpha, beta)
+}
+
+func (c *Phase1) contribute(tau, alpha, beta fr.Element) error {
+	var err error
+	challenge := c.Hash
+	if c.PublicKeys.Tau, err = newPublicKey(tau, challenge, dstTau); err != nil {
+		return err
+	}
+	if c.PublicKeys.Alpha, err = newPublicKey(alpha, challenge, dstAlpha); err != nil {
+		return err
+	}
+	if c.PublicKeys.Beta, err = newPublicKey(beta, challenge, dstBeta); err != nil {
+		return err
+	}
+
+	p := &c.Parameters
+	N := len(p.G1.AlphaTau)
+	taus := powers(fr.One(), tau, len(p.G1.Tau))
+	scaleG1(p.G1.Tau, taus)
+	scaleG2(p.G2.Tau, taus[:N])
+	scaleG1(p.G1.AlphaTau, powers(alpha, tau, N))
+	scaleG1(p.G1.BetaTau, powers(beta, tau, N))
+	var b big.Int
+	p.G2.Beta.ScalarMultiplication(&p.G2.Beta, beta.ToBigIntRegular(&b))
+
+	c.Hash = c.hash()
+	return nil
+}
+
+// VerifyPhase1 verifies a chain of contributions, each one against the previous one; the first one
+// is trusted (typically NewPhase1, or the output of a previous ceremony)
+func VerifyPhase1(contributions ...*Phase1) error {
+	for i := 1; i < len(contributions); i++ {
+		if err := verifyPhase1(contributions[i-1], contributions[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func verifyPhase1(prev, next *Phase1) error {
+	if !bytes.Equal(next.Hash, next.hash()) {
+		return ErrInvalidHash
+	}
+	if err := next.checkShape(len(prev.Parameters.G1.AlphaTau)); err != nil {
+		return err
+	}
+
+	// proofs of knowledge of τ, α and β
+	rTau, err := next.PublicKeys.Tau.verify(prev.Hash, dstTau)
+	if err != nil {
+		return err
+	}
+	rAlpha, err := next.PublicKeys.Alpha.verify(prev.Hash, dstAlpha)
+	if err != nil {
+		return err
+	}
+	rBeta, err := next.PublicKeys.Beta.verify(prev.Hash, dstBeta)
+	if err != nil {
+		return err
+	}
+
+	// the parameters are updated by the secrets of the public keys
+	pp, np := &prev.Parameters, &next.Parameters
+	if !sameRatio(np.G1.Tau[1], pp.G1.Tau[1], next.PublicKeys.Tau.XR, rTau) ||
+		!sameRatio(np.G1.AlphaTau[0], pp.G1.AlphaTau[0], next.PublicKeys.Alpha.XR, rAlpha) ||
+		!sameRatio(np.G1.BetaTau[0], pp.G1.BetaTau[0], next.PublicKeys.Beta.XR, rBeta) {
+		return ErrInvalidContribution
+	}
+
+	return next.checkPowers()
+}
+
+// checkShape returns an error if the parameters don't have the length of a ceremony for N
+// constraints, or if they aren't points of the groups
+func (c *Phase1) checkShape(N int) error {
+	p := &c.Parameters
+	if N < 2 || len(p.G1.Tau) != 2*N-1 || len(p.G1.AlphaTau) != N || len(p.G1.BetaTau) != N || len(p.G2.Tau) != N {
+		return ErrInvalidParameters
+	}
+	_, _, g1, g2 := curve.Generators()
+	if !p.G1.Tau[0].Equal(&g1) || !p.G2.Tau[0].Equal(&g2) {
+		return ErrInvalidParameters
+	}
+	for _, points := range [][]curve.G1Affine{p.G1.Tau, p.G1.AlphaTau, p.G1.BetaTau} {
+		for i := range points {
+			if !points[i].IsInSubGroup() {
+				return ErrInvalidParameters
+			}
+		}
+	}
+	for i := range p.G2.Tau {
+		if !p.G2.Tau[i].IsInSubGroup() {
+			return ErrInvalidParameters
+		}
+	}
+	if !p.G2.Beta.IsInSubGroup() {
+		return ErrInvalidParameters
+	}
+	return nil
+}
+
+// checkPowers returns an error if the parameters aren't the successive powers of the same τ, or if
+// [β]1 and [β]2 don't match
+func (c *Phase1) checkPowers() error {
+	p := &c.Parameters
+	_, _, g1, g2 := curve.Generators()
+
+	if !sameRatio(p.G1.BetaTau[0], g1, p.G2.Beta, g2) {
+		return ErrInvalidContribution
+	}
+
+	// [τ^(i+1)]1 / [τ^i]1 == [τ]2 / [τ⁰]2
+	for _, points := range [][]curve.G1Affine{p.G1.Tau, p.G1.AlphaTau, p.G1.BetaTau} {
+		L0, L1, err := linearCombinationG1(points)
+		if err != nil {
+			return err
+		}
+		if !sameRatio(L1, L0, p.G2.Tau[1], p.G2.Tau[0]) {
+			return ErrInvalidContribution
+		}
+	}
+
+	// [τ^(i+1)]2 / [τ^i]2 == [τ]1 / [τ⁰]1
+	L0, L1, err := linearCombinationG2(p.G2.Tau)
+	if err != nil {
+		return err
+	}
+	if !sameRatio(p.G1.Tau[1], p.G1.Tau[0], L1, L0) {
+		return ErrInvalidContribution
+	}
+	return nil
+}
+
+// hash returns the hash of the parameters and of the public keys
+func (c *Phase1) hash() []byte {
+	p := &c.Parameters
+	k := &c.PublicKeys
+	return hashPoints(
+		[][]curve.G1Affine{p.G1.Tau, p.G1.AlphaTau, p.G1.BetaTau, {k.Tau.SG, k.Tau.SXG, k.Alpha.SG, k.Alpha.SXG, k.Beta.SG, k.Beta.SXG}},
+		[][]curve.G2Affine{p.G2.Tau, {p.G2.Beta, k.Tau.XR, k.Alpha.XR, k.Beta.XR}},
+	)
+}
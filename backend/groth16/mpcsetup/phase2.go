@@ -0,0 +1,402 @@
+// Copyright 2020 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mpcsetup
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+	"sync"
+
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/r1cs"
+	"github.com/consensys/gnark/backend/r1cs/r1c"
+	backend_bn256 "github.com/consensys/gnark/internal/backend/bn256"
+	"github.com/consensys/gnark/internal/backend/bn256/fft"
+	groth16_bn256 "github.com/consensys/gnark/internal/backend/bn256/groth16"
+	curve "github.com/consensys/gurvy/bn256"
+	"github.com/consensys/gurvy/bn256/fr"
+)
+
+var (
+	errUnsupportedR1CS = errors.New("mpcsetup: only BN256 circuits are supported")
+	errCommitment      = errors.New("mpcsetup: circuits with a commitment are not supported")
+	errPhase1Size      = errors.New("mpcsetup: the powers of tau are too short for the number of constraints of the circuit")
+	errKeysSize        = errors.New("mpcsetup: the parameters don't match the circuit")
+)
+
+// Phase2 is the state of the circuit-specific ceremony after a contribution
+//
+// the contributions update δ; γ is 1
+type Phase2 struct {
+	Parameters struct {
+		G1 struct {
+			Delta curve.G1Affine   // [δ]1
+			L     []curve.G1Affine // [(βA_i(τ) + αB_i(τ) + C_i(τ))/δ]1 of the private wires
+			Z     []curve.G1Affine // [τ^i(τⁿ-1)/δ]1, in bit-reversed order (see groth16 ProvingKey)
+		}
+		G2 struct {
+			Delta curve.G2Affine // [δ]2
+		}
+	}
+
+	// proof of knowledge of the contribution; zero for the output of InitPhase2
+	PublicKey PublicKey
+
+	// hash of the parameters and of the public key, challenge of the next contribution
+	Hash []byte
+}
+
+// Phase2Evaluations holds the parts of the keys which don't depend on δ
+//
+// they are computed by InitPhase2 from the circuit and the output of Phase 1, so anyone can
+// recompute them
+type Phase2Evaluations struct {
+	G1 struct {
+		Alpha, Beta curve.G1Affine   // [α]1, [β]1
+		A, B        []curve.G1Affine // [A_i(τ)]1, [B_i(τ)]1 of the wires
+		VKK         []curve.G1Affine // [βA_i(τ) + αB_i(τ) + C_i(τ)]1 of the public wires
+	}
+	G2 struct {
+		Beta curve.G2Affine   // [β]2
+		B    []curve.G2Affine // [B_i(τ)]2 of the wires
+	}
+}
+
+// InitPhase2 evaluates the circuit on the output of Phase 1 (which must be verified), and returns
+// the initial state of the circuit-specific ceremony (δ = 1)
+func InitPhase2(_r1cs r1cs.R1CS, srs1 *Phase1) (*Phase2, *Phase2Evaluations, error) {
+	r1cs, ok := _r1cs.(*backend_bn256.R1CS)
+	if !ok {
+		return nil, nil, errUnsupportedR1CS
+	}
+	if r1cs.Commitment != nil {
+		return nil, nil, errCommitment
+	}
+	domain := fft.NewDomain(r1cs.NbConstraints)
+	n := int(domain.Cardinality)
+	p1 := &srs1.Parameters
+	if len(p1.G1.AlphaTau) < n {
+		return nil, nil, errPhase1Size
+	}
+
+	// the Lagrange bases evaluated at τ, multiplied by 1, α and β
+	var lagrange, alphaLagrange, betaLagrange []curve.G1Affine
+	var lagrange2 []curve.G2Affine
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		lagrange = lagrangeG1(p1.G1.Tau[:n], domain)
+		wg.Done()
+	}()
+	go func() {
+		alphaLagrange = lagrangeG1(p1.G1.AlphaTau[:n], domain)
+		wg.Done()
+	}()
+	go func() {
+		betaLagrange = lagrangeG1(p1.G1.BetaTau[:n], domain)
+		wg.Done()
+	}()
+	lagrange2 = lagrangeG2(p1.G2.Tau[:n], domain)
+	wg.Wait()
+
+	// A_i(τ) = Σ_j L[j][i] * Lagrange_j(τ), where j is the constraint index and i the wire index
+	// (similarly for B with R); K_i = βA_i(τ) + αB_i(τ) + C_i(τ)
+	nbWires := int(r1cs.NbWires)
+	A := make([]curve.G1Jac, nbWires)
+	B := make([]curve.G1Jac, nbWires)
+	B2 := make([]curve.G2Jac, nbWires)
+	K := make([]curve.G1Jac, nbWires)
+	wg.Add(3)
+	go func() {
+		for i, c := range r1cs.Constraints {
+			for _, t := range c.L {
+				addTermG1(r1cs, &A[t.VariableID()], t, &lagrange[i])
+			}
+		}
+		wg.Done()
+	}()
+	go func() {
+		for i, c := range r1cs.Constraints {
+			for _, t := range c.R {
+				addTermG1(r1cs, &B[t.VariableID()], t, &lagrange[i])
+			}
+		}
+		wg.Done()
+	}()
+	go func() {
+		for i, c := range r1cs.Constraints {
+			for _, t := range c.R {
+				addTermG2(r1cs, &B2[t.VariableID()], t, &lagrange2[i])
+			}
+		}
+		wg.Done()
+	}()
+	for i, c := range r1cs.Constraints {
+		for _, t := range c.L {
+			addTermG1(r1cs, &K[t.VariableID()], t, &betaLagrange[i])
+		}
+		for _, t := range c.R {
+			addTermG1(r1cs, &K[t.VariableID()], t, &alphaLagrange[i])
+		}
+		for _, t := range c.O {
+			addTermG1(r1cs, &K[t.VariableID()], t, &lagrange[i])
+		}
+	}
+	wg.Wait()
+
+	nbPrivateWires := int(r1cs.NbWires - r1cs.NbPublicWires)
+	var evals Phase2Evaluations
+	evals.G1.Alpha = p1.G1.AlphaTau[0]
+	evals.G1.Beta = p1.G1.BetaTau[0]
+	evals.G2.Beta = p1.G2.Beta
+	evals.G1.A = toAffineG1(A)
+	evals.G1.B = toAffineG1(B)
+	evals.G2.B = make([]curve.G2Affine, nbWires)
+	for i := range B2 {
+		evals.G2.B[i].FromJacobian(&B2[i])
+	}
+	kAffine := toAffineG1(K)
+	evals.G1.VKK = kAffine[nbPrivateWires:]
+
+	var c Phase2
+	_, _, g1, g2 := curve.Generators()
+	c.Parameters.G1.Delta = g1
+	c.Parameters.G2.Delta = g2
+	c.Parameters.G1.L = kAffine[:nbPrivateWires:nbPrivateWires]
+
+	// Z[i] = [τ^(n+i)]1 - [τ^i]1; h has degree n-2, so the last point isn't used by the prover and
+	// is the point at infinity
+	c.Parameters.G1.Z = make([]curve.G1Affine, n)
+	var z curve.G1Jac
+	for i := 0; i < n-1; i++ {
+		z.FromAffine(&p1.G1.Tau[i+n])
+		var tau curve.G1Jac
+		tau.FromAffine(&p1.G1.Tau[i])
+		z.SubAssign(&tau)
+		c.Parameters.G1.Z[i].FromJacobian(&z)
+	}
+	bitReverseG1(c.Parameters.G1.Z)
+
+	c.Hash = c.hash()
+	return &c, &evals, nil
+}
+
+func toAffineG1(points []curve.G1Jac) []curve.G1Affine {
+	res := make([]curve.G1Affine, len(points))
+	curve.BatchJacobianToAffineG1Affine(points, res)
+	return res
+}
+
+// addTermG1 adds the coefficient of t times p to res
+func addTermG1(r1cs *backend_bn256.R1CS, res *curve.G1Jac, t r1c.Term, p *curve.G1Affine) {
+	switch t.CoeffValue() {
+	case 0:
+	case 1:
+		res.AddMixed(p)
+	case -1:
+		var q curve.G1Affine
+		res.AddMixed(q.Neg(p))
+	default:
+		var coeff fr.Element
+		var b big.Int
+		r1cs.AddTerm(&coeff, t, fr.One())
+		var q curve.G1Affine
+		res.AddMixed(q.ScalarMultiplication(p, coeff.ToBigIntRegular(&b)))
+	}
+}
+
+// addTermG2 adds the coefficient of t times p to res
+func addTermG2(r1cs *backend_bn256.R1CS, res *curve.G2Jac, t r1c.Term, p *curve.G2Affine) {
+	switch t.CoeffValue() {
+	case 0:
+	case 1:
+		res.AddMixed(p)
+	case -1:
+		var q curve.G2Affine
+		res.AddMixed(q.Neg(p))
+	default:
+		var coeff fr.Element
+		var b big.Int
+		r1cs.AddTerm(&coeff, t, fr.One())
+		var q curve.G2Affine
+		res.AddMixed(q.ScalarMultiplication(p, coeff.ToBigIntRegular(&b)))
+	}
+}
+
+// Contribute updates the parameters with a random secret δ, which isn't kept
+func (c *Phase2) Contribute() error {
+	var delta fr.Element
+	if _, err := delta.SetRandom(); err != nil {
+		return err
+	}
+	pk, err := newPublicKey(delta, c.Hash, dstDelta)
+	if err != nil {
+		return err
+	}
+	c.contribute(delta, pk)
+	return nil
+}
+
+// ContributeWithBeacon updates the parameters with a secret derived from a public random beacon
+// (a future block hash, ...), which is hashed 2^iterations times with the previous contribution
+//
+// it is the last contribution of a ceremony: it doesn't depend on the contributors, so none of them
+// could choose their contribution knowing the final keys. The contribution is deterministic, so
+// anyone can check it by replaying it on a copy of the previous contribution and comparing the
+// hashes
+func (c *Phase2) ContributeWithBeacon(beacon []byte, iterations int) error {
+	h := sha256.Sum256(append(append([]byte{}, c.Hash...), beacon...))
+	for i := 0; i < 1<<iterations; i++ {
+		h = sha256.Sum256(h[:])
+	}
+
+	var delta, s fr.Element
+	delta.SetBytes(h[:])
+	sh := sha256.Sum256(append(h[:], dstDelta))
+	s.SetBytes(sh[:])
+	if delta.IsZero() || s.IsZero() {
+		return errors.New("mpcsetup: invalid beacon")
+	}
+
+	pk, err := newPublicKeyFromSecret(s, delta, c.Hash, dstDelta)
+	if err != nil {
+		return err
+	}
+	c.contribute(delta, pk)
+	return nil
+}
+
+func (c *Phase2) contribute(delta fr.Element, pk PublicKey) {
+	c.PublicKey = pk
+
+	var deltaInv fr.Element
+	deltaInv.Inverse(&delta)
+
+	p := &c.Parameters
+	var b big.Int
+	delta.ToBigIntRegular(&b)
+	p.G1.Delta.ScalarMultiplication(&p.G1.Delta, &b)
+	p.G2.Delta.ScalarMultiplication(&p.G2.Delta, &b)
+	scaleG1By(p.G1.L, deltaInv)
+	scaleG1By(p.G1.Z, deltaInv)
+
+	c.Hash = c.hash()
+}
+
+// VerifyPhase2 verifies a chain of contributions, each one against the previous one; the first one
+// is the output of InitPhase2, which anyone can recompute from the circuit and the output of Phase 1
+func VerifyPhase2(contributions ...*Phase2) error {
+	for i := 1; i < len(contributions); i++ {
+		if err := verifyPhase2(contributions[i-1], contributions[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func verifyPhase2(prev, next *Phase2) error {
+	if !bytes.Equal(next.Hash, next.hash()) {
+		return ErrInvalidHash
+	}
+	pp, np := &prev.Parameters, &next.Parameters
+	if len(np.G1.L) != len(pp.G1.L) || len(np.G1.Z) != len(pp.G1.Z) {
+		return ErrInvalidParameters
+	}
+	if !np.G1.Delta.IsInSubGroup() || !np.G2.Delta.IsInSubGroup() {
+		return ErrInvalidParameters
+	}
+
+	R, err := next.PublicKey.verify(prev.Hash, dstDelta)
+	if err != nil {
+		return err
+	}
+
+	// [δ]1 and [δ]2 are updated by the secret of the public key
+	if !sameRatio(np.G1.Delta, pp.G1.Delta, next.PublicKey.XR, R) ||
+		!sameRatio(np.G1.Delta, pp.G1.Delta, np.G2.Delta, pp.G2.Delta) {
+		return ErrInvalidContribution
+	}
+
+	// L and Z are divided by the same secret
+	prevLZ := append(append([]curve.G1Affine{}, pp.G1.L...), pp.G1.Z...)
+	nextLZ := append(append([]curve.G1Affine{}, np.G1.L...), np.G1.Z...)
+	if len(prevLZ) == 0 {
+		return nil
+	}
+	LPrev, LNext, err := mergeG1(prevLZ, nextLZ)
+	if err != nil {
+		return err
+	}
+	if !sameRatio(LPrev, LNext, np.G2.Delta, pp.G2.Delta) {
+		return ErrInvalidContribution
+	}
+	return nil
+}
+
+// hash returns the hash of the parameters and of the public key
+func (c *Phase2) hash() []byte {
+	p := &c.Parameters
+	return hashPoints(
+		[][]curve.G1Affine{{p.G1.Delta, c.PublicKey.SG, c.PublicKey.SXG}, p.G1.L, p.G1.Z},
+		[][]curve.G2Affine{{p.G2.Delta, c.PublicKey.XR}},
+	)
+}
+
+// ExtractKeys returns the Groth16 keys of the circuit, from the last contribution of the ceremony and
+// the evaluations returned by InitPhase2
+func ExtractKeys(_r1cs r1cs.R1CS, srs2 *Phase2, evals *Phase2Evaluations) (groth16.ProvingKey, groth16.VerifyingKey, error) {
+	r1cs, ok := _r1cs.(*backend_bn256.R1CS)
+	if !ok {
+		return nil, nil, errUnsupportedR1CS
+	}
+	domain := fft.NewDomain(r1cs.NbConstraints)
+	nbPrivateWires := int(r1cs.NbWires - r1cs.NbPublicWires)
+	if len(srs2.Parameters.G1.Z) != int(domain.Cardinality) || len(srs2.Parameters.G1.L) != nbPrivateWires ||
+		len(evals.G1.A) != int(r1cs.NbWires) || len(evals.G1.VKK) != int(r1cs.NbPublicWires) {
+		return nil, nil, errKeysSize
+	}
+
+	var pk groth16_bn256.ProvingKey
+	var vk groth16_bn256.VerifyingKey
+
+	pk.Domain = *domain
+	pk.G1.Alpha = evals.G1.Alpha
+	pk.G1.Beta = evals.G1.Beta
+	pk.G1.Delta = srs2.Parameters.G1.Delta
+	pk.G1.A = evals.G1.A
+	pk.G1.B = evals.G1.B
+	pk.G1.K = srs2.Parameters.G1.L
+	pk.G1.Z = srs2.Parameters.G1.Z
+	pk.G2.Beta = evals.G2.Beta
+	pk.G2.Delta = srs2.Parameters.G2.Delta
+	pk.G2.B = evals.G2.B
+
+	_, _, _, g2 := curve.Generators()
+	vk.PublicInputs = r1cs.PublicWires
+	vk.G1.Alpha = evals.G1.Alpha
+	vk.G1.K = evals.G1.VKK
+	vk.G2.Beta = evals.G2.Beta
+	vk.G2.GammaNeg.Neg(&g2)
+	vk.G2.DeltaNeg.Neg(&srs2.Parameters.G2.Delta)
+
+	var err error
+	vk.E, err = curve.Pair([]curve.G1Affine{evals.G1.Alpha}, []curve.G2Affine{evals.G2.Beta})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &pk, &vk, nil
+}
@@ -0,0 +1,209 @@
+// Copyright 2020 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mpcsetup
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+
+	curve "github.com/consensys/gurvy/bn256"
+	"github.com/consensys/gurvy/bn256/fp"
+)
+
+// sections of a .ptau file
+const (
+	ptauHeader     = 1
+	ptauTauG1      = 2
+	ptauTauG2      = 3
+	ptauAlphaTauG1 = 4
+	ptauBetaTauG1  = 5
+	ptauBetaG2     = 6
+)
+
+// ErrInvalidPtau is returned by ReadPtau for a malformed .ptau file
+var ErrInvalidPtau = errors.New("invalid ptau file")
+
+// ReadPtau reads the output of a Powers-of-Tau ceremony in the .ptau format of snarkjs (the perpetual
+// powers of tau ceremony, ...), and returns the Phase 1 for circuits of up to 2^power constraints
+//
+// the ceremony must be on BN254 (bn128 in snarkjs), and of a power greater or equal to power. The
+// contributions recorded in the file are not verified (snarkjs powersoftau verify does it); the
+// returned Phase1 is the first of a chain given to VerifyPhase1, and can be passed to InitPhase2
+func ReadPtau(r io.Reader, power int) (*Phase1, error) {
+	var header struct {
+		Magic     [4]byte
+		Version   uint32
+		NbSection uint32
+	}
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+		return nil, err
+	}
+	if string(header.Magic[:]) != "ptau" {
+		return nil, fmt.Errorf("%w: wrong magic number", ErrInvalidPtau)
+	}
+
+	N := 1 << power
+	var c Phase1
+	p := &c.Parameters
+	var filePower uint32
+	read := make(map[uint32]bool)
+
+	for i := uint32(0); i < header.NbSection; i++ {
+		var section struct {
+			Type uint32
+			Size uint64
+		}
+		if err := binary.Read(r, binary.LittleEndian, &section); err != nil {
+			return nil, err
+		}
+		sr := io.LimitReader(r, int64(section.Size))
+
+		if section.Type != ptauHeader && section.Type <= ptauBetaG2 && !read[ptauHeader] {
+			return nil, fmt.Errorf("%w: the header must be the first section", ErrInvalidPtau)
+		}
+
+		var err error
+		switch section.Type {
+		case ptauHeader:
+			filePower, err = readPtauHeader(sr)
+			if err == nil && int(filePower) < power {
+				err = fmt.Errorf("ptau file of power %d, %d is needed", filePower, power)
+			}
+		case ptauTauG1:
+			p.G1.Tau, err = readPtauG1(sr, 2*N-1)
+		case ptauTauG2:
+			p.G2.Tau, err = readPtauG2(sr, N)
+		case ptauAlphaTauG1:
+			p.G1.AlphaTau, err = readPtauG1(sr, N)
+		case ptauBetaTauG1:
+			p.G1.BetaTau, err = readPtauG1(sr, N)
+		case ptauBetaG2:
+			var beta []curve.G2Affine
+			beta, err = readPtauG2(sr, 1)
+			if err == nil {
+				p.G2.Beta = beta[0]
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		read[section.Type] = true
+
+		// skips the end of the section, and the sections not needed by Phase 1 (contributions,
+		// Lagrange bases, ...)
+		if _, err := io.Copy(ioutil.Discard, sr); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, s := range []uint32{ptauHeader, ptauTauG1, ptauTauG2, ptauAlphaTauG1, ptauBetaTauG1, ptauBetaG2} {
+		if !read[s] {
+			return nil, fmt.Errorf("%w: missing section %d", ErrInvalidPtau, s)
+		}
+	}
+
+	c.Hash = c.hash()
+	return &c, nil
+}
+
+// readPtauHeader reads the header section, and returns the power of the ceremony
+func readPtauHeader(r io.Reader) (uint32, error) {
+	var n8 uint32
+	if err := binary.Read(r, binary.LittleEndian, &n8); err != nil {
+		return 0, err
+	}
+	if n8 != fp.Bytes {
+		return 0, fmt.Errorf("%w: the curve is not BN254", ErrInvalidPtau)
+	}
+	q := make([]byte, n8)
+	if _, err := io.ReadFull(r, q); err != nil {
+		return 0, err
+	}
+	if new(big.Int).SetBytes(reverse(q)).Cmp(fp.Modulus()) != 0 {
+		return 0, fmt.Errorf("%w: the curve is not BN254", ErrInvalidPtau)
+	}
+	var power uint32
+	if err := binary.Read(r, binary.LittleEndian, &power); err != nil {
+		return 0, err
+	}
+	return power, nil
+}
+
+// readPtauG1 reads the first n points of a section of points of G1
+func readPtauG1(r io.Reader, n int) ([]curve.G1Affine, error) {
+	res := make([]curve.G1Affine, n)
+	var buf [2 * fp.Bytes]byte
+	for i := range res {
+		if _, err := io.ReadFull(r, buf[:]); err != nil {
+			return nil, err
+		}
+		if err := readPtauFp(&res[i].X, buf[:fp.Bytes]); err != nil {
+			return nil, err
+		}
+		if err := readPtauFp(&res[i].Y, buf[fp.Bytes:]); err != nil {
+			return nil, err
+		}
+		if !res[i].IsOnCurve() {
+			return nil, fmt.Errorf("%w: point not on the curve", ErrInvalidPtau)
+		}
+	}
+	return res, nil
+}
+
+// readPtauG2 reads the first n points of a section of points of G2
+func readPtauG2(r io.Reader, n int) ([]curve.G2Affine, error) {
+	res := make([]curve.G2Affine, n)
+	var buf [4 * fp.Bytes]byte
+	const size = fp.Bytes
+	for i := range res {
+		if _, err := io.ReadFull(r, buf[:]); err != nil {
+			return nil, err
+		}
+		for j, e := range []*fp.Element{&res[i].X.A0, &res[i].X.A1, &res[i].Y.A0, &res[i].Y.A1} {
+			if err := readPtauFp(e, buf[j*size:(j+1)*size]); err != nil {
+				return nil, err
+			}
+		}
+		if !res[i].IsOnCurve() {
+			return nil, fmt.Errorf("%w: point not on the curve", ErrInvalidPtau)
+		}
+	}
+	return res, nil
+}
+
+// readPtauFp sets e from its little-endian encoding in Montgomery form, which is the memory layout
+// of fp.Element
+func readPtauFp(e *fp.Element, b []byte) error {
+	for i := 0; i < fp.Limbs; i++ {
+		e[i] = binary.LittleEndian.Uint64(b[i*8:])
+	}
+	if new(big.Int).SetBytes(reverse(b)).Cmp(fp.Modulus()) >= 0 {
+		return fmt.Errorf("%w: coordinate not reduced", ErrInvalidPtau)
+	}
+	return nil
+}
+
+// reverse returns a reversed copy of b
+func reverse(b []byte) []byte {
+	res := make([]byte, len(b))
+	for i := range b {
+		res[len(b)-1-i] = b[i]
+	}
+	return res
+}
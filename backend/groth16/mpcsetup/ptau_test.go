@@ -0,0 +1,126 @@
+// Copyright 2020 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mpcsetup
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gurvy"
+	curve "github.com/consensys/gurvy/bn256"
+	"github.com/consensys/gurvy/bn256/fp"
+	"github.com/stretchr/testify/require"
+)
+
+// writePtau writes c in the .ptau format of snarkjs, with an empty contributions section
+func writePtau(c *Phase1, power uint32) []byte {
+	var buf bytes.Buffer
+	le := binary.LittleEndian
+	writeFp := func(w *bytes.Buffer, e *fp.Element) {
+		for _, limb := range e {
+			binary.Write(w, le, limb)
+		}
+	}
+	section := func(typ uint32, content []byte) {
+		binary.Write(&buf, le, typ)
+		binary.Write(&buf, le, uint64(len(content)))
+		buf.Write(content)
+	}
+	g1 := func(points []curve.G1Affine) []byte {
+		var w bytes.Buffer
+		for i := range points {
+			writeFp(&w, &points[i].X)
+			writeFp(&w, &points[i].Y)
+		}
+		return w.Bytes()
+	}
+	g2 := func(points []curve.G2Affine) []byte {
+		var w bytes.Buffer
+		for i := range points {
+			writeFp(&w, &points[i].X.A0)
+			writeFp(&w, &points[i].X.A1)
+			writeFp(&w, &points[i].Y.A0)
+			writeFp(&w, &points[i].Y.A1)
+		}
+		return w.Bytes()
+	}
+
+	buf.WriteString("ptau")
+	binary.Write(&buf, le, uint32(1))
+	binary.Write(&buf, le, uint32(7))
+
+	var header bytes.Buffer
+	binary.Write(&header, le, uint32(fp.Bytes))
+	header.Write(reverse(fp.Modulus().FillBytes(make([]byte, fp.Bytes))))
+	binary.Write(&header, le, power)
+	binary.Write(&header, le, power) // ceremony power
+	section(ptauHeader, header.Bytes())
+
+	p := &c.Parameters
+	section(ptauTauG1, g1(p.G1.Tau))
+	section(ptauTauG2, g2(p.G2.Tau))
+	section(ptauAlphaTauG1, g1(p.G1.AlphaTau))
+	section(ptauBetaTauG1, g1(p.G1.BetaTau))
+	section(ptauBetaG2, g2([]curve.G2Affine{p.G2.Beta}))
+	section(7, []byte{0, 0, 0, 0})
+
+	return buf.Bytes()
+}
+
+func TestReadPtau(t *testing.T) {
+	assert := require.New(t)
+
+	ceremony := NewPhase1(4)
+	assert.NoError(ceremony.Contribute())
+	file := writePtau(ceremony, 4)
+
+	// a ceremony of power 4 is used for circuits of up to 2^3 constraints
+	srs1, err := ReadPtau(bytes.NewReader(file), 3)
+	assert.NoError(err)
+	p, q := &srs1.Parameters, &ceremony.Parameters
+	assert.Equal(q.G1.Tau[:15], p.G1.Tau)
+	assert.Equal(q.G1.AlphaTau[:8], p.G1.AlphaTau)
+	assert.Equal(q.G1.BetaTau[:8], p.G1.BetaTau)
+	assert.Equal(q.G2.Tau[:8], p.G2.Tau)
+	assert.Equal(q.G2.Beta, p.G2.Beta)
+
+	// the ceremony goes on from the file
+	next := srs1.clone()
+	assert.NoError(next.Contribute())
+	assert.NoError(VerifyPhase1(srs1, next))
+
+	var circuit cubicCircuit
+	r1cs, err := frontend.Compile(gurvy.BN256, &circuit)
+	assert.NoError(err)
+	_, _, err = InitPhase2(r1cs, next)
+	assert.NoError(err)
+
+	// the file is too short
+	_, err = ReadPtau(bytes.NewReader(file), 5)
+	assert.Error(err)
+
+	// not a ptau file
+	_, err = ReadPtau(bytes.NewReader(append([]byte("zkey"), file[4:]...)), 3)
+	assert.True(errors.Is(err, ErrInvalidPtau))
+
+	// [β]2 not on the curve (the last section is the contributions, of 12+4 bytes)
+	corrupted := append([]byte{}, file...)
+	corrupted[len(file)-16-2*fp.Bytes] ^= 1
+	_, err = ReadPtau(bytes.NewReader(corrupted), 3)
+	assert.Error(err)
+}
@@ -0,0 +1,117 @@
+// Copyright 2020 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package prover computes concurrent Groth16 proofs of a circuit with shared resources
+//
+// a service proving the same circuit for concurrent requests calls Pool.Prove from each request:
+//
+//	pool, err := prover.NewPool(r1cs, pk, 4, backend.WithMaxWorkers(32))
+//	...
+//	proof, err := pool.Prove(&witness, backend.WithContext(ctx))
+//
+// instead of N groth16.Prove calls each allocating the memory of a proof and using all the CPUs,
+// at most 4 proofs run at a time with 8 CPUs each, and their memory is reused by the next proofs.
+package prover
+
+import (
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/r1cs"
+	"github.com/consensys/gnark/frontend"
+	backend_bls377 "github.com/consensys/gnark/internal/backend/bls377"
+	groth16_bls377 "github.com/consensys/gnark/internal/backend/bls377/groth16"
+	backend_bls381 "github.com/consensys/gnark/internal/backend/bls381"
+	groth16_bls381 "github.com/consensys/gnark/internal/backend/bls381/groth16"
+	backend_bn256 "github.com/consensys/gnark/internal/backend/bn256"
+	groth16_bn256 "github.com/consensys/gnark/internal/backend/bn256/groth16"
+	backend_bw761 "github.com/consensys/gnark/internal/backend/bw761"
+	groth16_bw761 "github.com/consensys/gnark/internal/backend/bw761/groth16"
+)
+
+// Pool computes the proofs of a circuit with a proving key, at most nbProvers at a time
+//
+// the provers share the proving key, each of them uses 1/nbProvers of the CPUs of
+// backend.WithMaxWorkers, and the memory of a proof is reused by the next proofs
+type Pool struct {
+	prove func(solution map[string]interface{}, opts ...backend.Option) (groth16.Proof, error)
+}
+
+// NewPool returns a pool of nbProvers provers of r1cs with pk; opts are the options of all the proofs
+func NewPool(r1cs r1cs.R1CS, pk groth16.ProvingKey, nbProvers int, opts ...backend.Option) (*Pool, error) {
+	switch _r1cs := r1cs.(type) {
+	case *backend_bls377.R1CS:
+		p, err := groth16_bls377.NewProverPool(_r1cs, pk.(*groth16_bls377.ProvingKey), nbProvers, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return &Pool{prove: func(solution map[string]interface{}, opts ...backend.Option) (groth16.Proof, error) {
+			proof, err := p.Prove(solution, opts...)
+			if err != nil {
+				return nil, err
+			}
+			return proof, nil
+		}}, nil
+	case *backend_bls381.R1CS:
+		p, err := groth16_bls381.NewProverPool(_r1cs, pk.(*groth16_bls381.ProvingKey), nbProvers, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return &Pool{prove: func(solution map[string]interface{}, opts ...backend.Option) (groth16.Proof, error) {
+			proof, err := p.Prove(solution, opts...)
+			if err != nil {
+				return nil, err
+			}
+			return proof, nil
+		}}, nil
+	case *backend_bn256.R1CS:
+		p, err := groth16_bn256.NewProverPool(_r1cs, pk.(*groth16_bn256.ProvingKey), nbProvers, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return &Pool{prove: func(solution map[string]interface{}, opts ...backend.Option) (groth16.Proof, error) {
+			proof, err := p.Prove(solution, opts...)
+			if err != nil {
+				return nil, err
+			}
+			return proof, nil
+		}}, nil
+	case *backend_bw761.R1CS:
+		p, err := groth16_bw761.NewProverPool(_r1cs, pk.(*groth16_bw761.ProvingKey), nbProvers, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return &Pool{prove: func(solution map[string]interface{}, opts ...backend.Option) (groth16.Proof, error) {
+			proof, err := p.Prove(solution, opts...)
+			if err != nil {
+				return nil, err
+			}
+			return proof, nil
+		}}, nil
+	default:
+		panic("unrecognized R1CS curve type")
+	}
+}
+
+// Prove computes a proof of solution (see groth16.ProveWithOptions); it waits for a free prover if
+// nbProvers proofs are in progress, and may be called concurrently
+//
+// opts are added to the options of the pool, backend.WithContext for example (which also cancels the
+// wait); backend.WithMaxWorkers is set by the pool
+func (p *Pool) Prove(solution interface{}, opts ...backend.Option) (groth16.Proof, error) {
+	_solution, err := frontend.ParseWitness(solution)
+	if err != nil {
+		return nil, err
+	}
+	return p.prove(_solution, opts...)
+}
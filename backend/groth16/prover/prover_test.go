@@ -0,0 +1,115 @@
+// Copyright 2020 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prover
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gurvy"
+)
+
+type cubicCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+// x**3 + x + 5 == y
+func (circuit *cubicCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	x3 := cs.Mul(circuit.X, circuit.X, circuit.X)
+	cs.AssertIsEqual(circuit.Y, cs.Add(x3, circuit.X, 5))
+	return nil
+}
+
+func TestPool(t *testing.T) {
+	for _, curveID := range []gurvy.ID{gurvy.BN256, gurvy.BLS381} {
+		r1cs, err := frontend.Compile(curveID, &cubicCircuit{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		pk, vk, err := groth16.Setup(r1cs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pool, err := NewPool(r1cs, pk, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// more concurrent proofs than provers, with different solutions: the memory reused by a proof
+		// doesn't leak into the next ones
+		const nbProofs = 8
+		var wg sync.WaitGroup
+		solutions := make([]map[string]interface{}, nbProofs)
+		proofs := make([]groth16.Proof, nbProofs)
+		errs := make([]error, nbProofs)
+		for i := 0; i < nbProofs; i++ {
+			x := i + 1
+			solutions[i] = map[string]interface{}{"X": x, "Y": x*x*x + x + 5}
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				proofs[i], errs[i] = pool.Prove(solutions[i])
+			}(i)
+		}
+		wg.Wait()
+		for i := range proofs {
+			if errs[i] != nil {
+				t.Fatal(errs[i])
+			}
+			if err := groth16.Verify(proofs[i], vk, solutions[i]); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if _, err := pool.Prove(map[string]interface{}{"X": 3, "Y": 42}); err == nil {
+			t.Fatal("expected error with a wrong solution")
+		}
+		proof, err := pool.Prove(map[string]interface{}{"X": 3, "Y": 35})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := groth16.Verify(proof, vk, map[string]interface{}{"Y": 35}); err != nil {
+			t.Fatal(err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := pool.Prove(map[string]interface{}{"X": 3, "Y": 35}, backend.WithContext(ctx)); err != context.Canceled {
+			t.Fatal("expected context.Canceled, got", err)
+		}
+	}
+}
+
+func TestPoolInvalid(t *testing.T) {
+	r1cs, err := frontend.Compile(gurvy.BN256, &cubicCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, _, err := groth16.Setup(r1cs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewPool(r1cs, pk, 0); err == nil {
+		t.Fatal("expected error with no prover")
+	}
+	if _, err := NewPool(r1cs, pk, 1, backend.WithMaxWorkers(0)); err == nil {
+		t.Fatal("expected error with invalid options")
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2020 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package groth16
+
+import (
+	"errors"
+
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/r1cs"
+	groth16_bls377 "github.com/consensys/gnark/internal/backend/bls377/groth16"
+	groth16_bls381 "github.com/consensys/gnark/internal/backend/bls381/groth16"
+	groth16_bn256 "github.com/consensys/gnark/internal/backend/bn256/groth16"
+	groth16_bw761 "github.com/consensys/gnark/internal/backend/bw761/groth16"
+	"github.com/consensys/gurvy"
+)
+
+func init() {
+	backend.Register(backend.GROTH16, system{})
+}
+
+var (
+	errSystemR1CS  = errors.New("groth16: the constraint system isn't a R1CS")
+	errSystemTypes = errors.New("groth16: the keys and proofs aren't Groth16 objects of the curve of the circuit")
+	errSystemCurve = errors.New("groth16: unsupported curve")
+)
+
+// system is the backend.System of Groth16
+type system struct{}
+
+func (system) Setup(cs backend.ConstraintSystem, opts ...backend.Option) (backend.ProvingKey, backend.VerifyingKey, error) {
+	_r1cs, ok := cs.(r1cs.R1CS)
+	if !ok {
+		return nil, nil, errSystemR1CS
+	}
+	pk, vk, err := Setup(_r1cs, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pk, vk, nil
+}
+
+func (system) Prove(cs backend.ConstraintSystem, pk backend.ProvingKey, solution interface{}, opts ...backend.Option) (backend.Proof, error) {
+	_r1cs, ok := cs.(r1cs.R1CS)
+	if !ok {
+		return nil, errSystemR1CS
+	}
+	if curveOf(pk, kindProvingKey) != _r1cs.GetCurveID() {
+		return nil, errSystemTypes
+	}
+	proof, err := ProveWithOptions(_r1cs, pk.(ProvingKey), solution, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return proof, nil
+}
+
+func (system) Verify(proof backend.Proof, vk backend.VerifyingKey, solution interface{}, opts ...backend.Option) error {
+	curveID := curveOf(vk, kindVerifyingKey)
+	if curveID == gurvy.UNKNOWN || curveOf(proof, kindProof) != curveID {
+		return errSystemTypes
+	}
+	return Verify(proof.(Proof), vk.(VerifyingKey), solution, opts...)
+}
+
+func (system) NewProvingKey(curveID gurvy.ID) (backend.ProvingKey, error) {
+	if !supported(curveID) {
+		return nil, errSystemCurve
+	}
+	return NewProvingKey(curveID), nil
+}
+
+func (system) NewVerifyingKey(curveID gurvy.ID) (backend.VerifyingKey, error) {
+	if !supported(curveID) {
+		return nil, errSystemCurve
+	}
+	return NewVerifyingKey(curveID), nil
+}
+
+func (system) NewProof(curveID gurvy.ID) (backend.Proof, error) {
+	if !supported(curveID) {
+		return nil, errSystemCurve
+	}
+	return NewProof(curveID), nil
+}
+
+func supported(curveID gurvy.ID) bool {
+	switch curveID {
+	case gurvy.BN256, gurvy.BLS377, gurvy.BLS381, gurvy.BW761:
+		return true
+	default:
+		return false
+	}
+}
+
+type kind int
+
+const (
+	kindProvingKey kind = iota
+	kindVerifyingKey
+	kindProof
+)
+
+// curveOf returns the curve of v if it is a Groth16 object of kind k, gurvy.UNKNOWN otherwise
+//
+// the interfaces ProvingKey and VerifyingKey have the same methods, so the concrete types are checked
+func curveOf(v interface{}, k kind) gurvy.ID {
+	switch v.(type) {
+	case *groth16_bn256.ProvingKey, *groth16_bls377.ProvingKey, *groth16_bls381.ProvingKey, *groth16_bw761.ProvingKey:
+		if k != kindProvingKey {
+			return gurvy.UNKNOWN
+		}
+	case *groth16_bn256.VerifyingKey, *groth16_bls377.VerifyingKey, *groth16_bls381.VerifyingKey, *groth16_bw761.VerifyingKey:
+		if k != kindVerifyingKey {
+			return gurvy.UNKNOWN
+		}
+	case *groth16_bn256.Proof, *groth16_bls377.Proof, *groth16_bls381.Proof, *groth16_bw761.Proof:
+		if k != kindProof {
+			return gurvy.UNKNOWN
+		}
+	default:
+		return gurvy.UNKNOWN
+	}
+	return v.(interface{ GetCurveID() gurvy.ID }).GetCurveID()
+}
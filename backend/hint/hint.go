@@ -0,0 +1,240 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package hint defines the functions computing wires at solving time, outside of the constraints
+//
+// A hint is created in a circuit with ConstraintSystem.NewHint: its result is a new wire, which is
+// computed by the solver from the values of its inputs, and which the circuit must then constrain (a
+// hint is not checked by the proof). For example, the inverse of x is the hint
+//
+//	func inverse(curveID gurvy.ID, inputs []*big.Int, result *big.Int) error
+//
+// constrained by cs.AssertIsEqual(cs.Mul(x, inv), 1).
+//
+// The compiled R1CS refers to a hint by the ID of its function: a program solving a R1CS it didn't
+// compile (for example, read from a file) must Register the hints of the circuit first.
+package hint
+
+import (
+	"crypto/sha256"
+	"errors"
+	"hash/fnv"
+	"math/big"
+	"reflect"
+	"runtime"
+	"sync"
+
+	"github.com/consensys/gurvy"
+	fr_bls377 "github.com/consensys/gurvy/bls377/fr"
+	fr_bls381 "github.com/consensys/gurvy/bls381/fr"
+	fr_bn256 "github.com/consensys/gurvy/bn256/fr"
+	fr_bw761 "github.com/consensys/gurvy/bw761/fr"
+)
+
+// ErrUnknownHint is returned by the solver when the function of a hint is not registered
+var ErrUnknownHint = errors.New("hint function is not registered")
+
+// Function computes the result of a hint from the values of its inputs, reduced modulo the scalar field
+// of curveID; the result is reduced modulo the field by the solver
+type Function func(curveID gurvy.ID, inputs []*big.Int, result *big.Int) error
+
+// MultiFunction computes the results of a hint with several outputs (see r1c.Hint) from the values of
+// its inputs; len(results) is the number of outputs, and the results are reduced modulo the field by
+// the solver
+type MultiFunction func(curveID gurvy.ID, inputs []*big.Int, results []*big.Int) error
+
+// ID identifies a hint function in a compiled R1CS
+type ID uint32
+
+// UUID returns the ID of a hint function, a hash of its name (package path and function name)
+func UUID(f Function) ID {
+	return uuid(f)
+}
+
+// UUIDMulti returns the ID of a hint function with several outputs, as UUID
+func UUIDMulti(f MultiFunction) ID {
+	return uuid(f)
+}
+
+func uuid(f interface{}) ID {
+	name := runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	return ID(h.Sum32())
+}
+
+var (
+	registry      = make(map[ID]Function)
+	multiRegistry = make(map[ID]MultiFunction)
+	lock          sync.RWMutex
+)
+
+// Register adds a hint function to the functions known by the solver, and returns its ID
+//
+// the hints of the package are registered by default
+func Register(f Function) ID {
+	id := UUID(f)
+	lock.Lock()
+	registry[id] = f
+	lock.Unlock()
+	return id
+}
+
+// Lookup returns the registered hint function with a given ID
+func Lookup(id ID) (Function, bool) {
+	lock.RLock()
+	f, ok := registry[id]
+	lock.RUnlock()
+	return f, ok
+}
+
+// RegisterMulti adds a hint function with several outputs to the functions known by the solver, and
+// returns its ID
+func RegisterMulti(f MultiFunction) ID {
+	id := UUIDMulti(f)
+	lock.Lock()
+	multiRegistry[id] = f
+	lock.Unlock()
+	return id
+}
+
+// LookupMulti returns the registered hint function with several outputs with a given ID
+func LookupMulti(id ID) (MultiFunction, bool) {
+	lock.RLock()
+	f, ok := multiRegistry[id]
+	lock.RUnlock()
+	return f, ok
+}
+
+func init() {
+	Register(IsZero)
+	Register(IthBit)
+	Register(InvZero)
+	Register(Commitment)
+	RegisterMulti(BatchInvert)
+}
+
+// IsZero sets result to 1 if inputs[0] is 0, and to 0 otherwise
+func IsZero(curveID gurvy.ID, inputs []*big.Int, result *big.Int) error {
+	if len(inputs) != 1 {
+		return errors.New("IsZero expects one input")
+	}
+	if inputs[0].Sign() == 0 {
+		result.SetUint64(1)
+	} else {
+		result.SetUint64(0)
+	}
+	return nil
+}
+
+// IthBit sets result to the bit inputs[1] of inputs[0]
+func IthBit(curveID gurvy.ID, inputs []*big.Int, result *big.Int) error {
+	if len(inputs) != 2 {
+		return errors.New("IthBit expects two inputs")
+	}
+	if !inputs[1].IsUint64() {
+		result.SetUint64(0)
+		return nil
+	}
+	result.SetUint64(uint64(inputs[0].Bit(int(inputs[1].Uint64()))))
+	return nil
+}
+
+// InvZero sets result to the inverse of inputs[0] in the scalar field of curveID, or to 0 if inputs[0]
+// is 0
+func InvZero(curveID gurvy.ID, inputs []*big.Int, result *big.Int) error {
+	if len(inputs) != 1 {
+		return errors.New("InvZero expects one input")
+	}
+	modulus, err := scalarField(curveID)
+	if err != nil {
+		return err
+	}
+	if inputs[0].Sign() == 0 {
+		result.SetUint64(0)
+		return nil
+	}
+	result.ModInverse(inputs[0], modulus)
+	return nil
+}
+
+// BatchInvert sets results[i] to the inverse of inputs[i] in the scalar field of curveID, or to 0 if
+// inputs[i] is 0, with a single field inversion (Montgomery's trick)
+func BatchInvert(curveID gurvy.ID, inputs []*big.Int, results []*big.Int) error {
+	if len(results) != len(inputs) {
+		return errors.New("BatchInvert expects as many results as inputs")
+	}
+	modulus, err := scalarField(curveID)
+	if err != nil {
+		return err
+	}
+
+	// results[i] is the product of the non zero inputs before i
+	acc := big.NewInt(1)
+	for i, input := range inputs {
+		if input.Sign() == 0 {
+			results[i].SetUint64(0)
+			continue
+		}
+		results[i].Set(acc)
+		acc.Mul(acc, input).Mod(acc, modulus)
+	}
+
+	// acc is the inverse of the product of the non zero inputs after i
+	acc.ModInverse(acc, modulus)
+	for i := len(inputs) - 1; i >= 0; i-- {
+		if inputs[i].Sign() == 0 {
+			continue
+		}
+		results[i].Mul(results[i], acc).Mod(results[i], modulus)
+		acc.Mul(acc, inputs[i]).Mod(acc, modulus)
+	}
+	return nil
+}
+
+// Commitment sets result to the hash (sha256) of the inputs, reduced modulo the scalar field of curveID
+//
+// it is the commitment of a circuit when the R1CS is solved without a backend enforcing it (see
+// r1c.Commitment)
+func Commitment(curveID gurvy.ID, inputs []*big.Int, result *big.Int) error {
+	modulus, err := scalarField(curveID)
+	if err != nil {
+		return err
+	}
+	h := sha256.New()
+	buf := make([]byte, (modulus.BitLen()+7)/8)
+	for _, input := range inputs {
+		h.Write(input.FillBytes(buf))
+	}
+	result.SetBytes(h.Sum(nil)).Mod(result, modulus)
+	return nil
+}
+
+// scalarField returns the modulus of the scalar field of curveID
+func scalarField(curveID gurvy.ID) (*big.Int, error) {
+	switch curveID {
+	case gurvy.BN256:
+		return fr_bn256.Modulus(), nil
+	case gurvy.BLS377:
+		return fr_bls377.Modulus(), nil
+	case gurvy.BLS381:
+		return fr_bls381.Modulus(), nil
+	case gurvy.BW761:
+		return fr_bw761.Modulus(), nil
+	default:
+		return nil, errors.New("unsupported curve")
+	}
+}
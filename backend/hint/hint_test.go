@@ -0,0 +1,99 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hint
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gurvy"
+	fr_bls377 "github.com/consensys/gurvy/bls377/fr"
+	"github.com/stretchr/testify/require"
+)
+
+func double(curveID gurvy.ID, inputs []*big.Int, result *big.Int) error {
+	result.Lsh(inputs[0], 1)
+	return nil
+}
+
+func TestRegister(t *testing.T) {
+	assert := require.New(t)
+
+	assert.Equal(UUID(IsZero), UUID(IsZero))
+	assert.NotEqual(UUID(IsZero), UUID(IthBit))
+
+	_, ok := Lookup(UUID(double))
+	assert.False(ok)
+	id := Register(double)
+	assert.Equal(UUID(double), id)
+	f, ok := Lookup(id)
+	assert.True(ok)
+
+	var result big.Int
+	assert.NoError(f(gurvy.BN256, []*big.Int{big.NewInt(21)}, &result))
+	assert.Equal(int64(42), result.Int64())
+
+	_, ok = Lookup(UUID(IthBit))
+	assert.True(ok, "the hints of the package are registered")
+}
+
+func TestBuiltins(t *testing.T) {
+	assert := require.New(t)
+	var result big.Int
+
+	assert.NoError(IsZero(gurvy.BN256, []*big.Int{big.NewInt(0)}, &result))
+	assert.Equal(int64(1), result.Int64())
+	assert.NoError(IsZero(gurvy.BN256, []*big.Int{big.NewInt(7)}, &result))
+	assert.Equal(int64(0), result.Int64())
+
+	assert.NoError(IthBit(gurvy.BN256, []*big.Int{big.NewInt(6), big.NewInt(2)}, &result))
+	assert.Equal(int64(1), result.Int64())
+	assert.NoError(IthBit(gurvy.BN256, []*big.Int{big.NewInt(6), big.NewInt(0)}, &result))
+	assert.Equal(int64(0), result.Int64())
+
+	assert.Error(IthBit(gurvy.BN256, []*big.Int{big.NewInt(6)}, &result))
+
+	assert.NoError(InvZero(gurvy.BLS377, []*big.Int{big.NewInt(0)}, &result))
+	assert.Equal(int64(0), result.Int64())
+	assert.NoError(InvZero(gurvy.BLS377, []*big.Int{big.NewInt(3)}, &result))
+	result.Mul(&result, big.NewInt(3)).Mod(&result, fr_bls377.Modulus())
+	assert.Equal(int64(1), result.Int64())
+	assert.Error(InvZero(gurvy.UNKNOWN, []*big.Int{big.NewInt(3)}, &result))
+}
+
+func TestBatchInvert(t *testing.T) {
+	assert := require.New(t)
+
+	inputs := []*big.Int{big.NewInt(3), big.NewInt(0), big.NewInt(5), big.NewInt(7)}
+	results := make([]*big.Int, len(inputs))
+	for i := range results {
+		results[i] = new(big.Int)
+	}
+	assert.NoError(BatchInvert(gurvy.BLS377, inputs, results))
+	for i := range inputs {
+		if inputs[i].Sign() == 0 {
+			assert.Equal(0, results[i].Sign(), "the inverse of 0 is 0")
+			continue
+		}
+		results[i].Mul(results[i], inputs[i]).Mod(results[i], fr_bls377.Modulus())
+		assert.Equal(int64(1), results[i].Int64())
+	}
+
+	assert.Error(BatchInvert(gurvy.BLS377, inputs, results[:2]))
+	_, ok := LookupMulti(UUIDMulti(BatchInvert))
+	assert.True(ok, "the hints of the package are registered")
+}
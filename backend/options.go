@@ -0,0 +1,236 @@
+// Copyright 2020 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"context"
+	"errors"
+	"io"
+	"runtime"
+)
+
+// ProgressFunc is called by long running operations (like groth16.Setup) to report their progress
+//
+// step identifies the current stage of the operation; done and total are expressed in a
+// step specific unit (constraints, curve points, ...). For a given step, the last call has done == total.
+type ProgressFunc func(step string, done, total int)
+
+// Config holds the options of the backend operations
+//
+// see the documentation of the With... functions for the operations honoring each option
+type Config struct {
+	Context           context.Context // cancels the operation (see WithContext)
+	Progress          ProgressFunc
+	IgnoreSolverError bool
+	ConstantTime      bool
+	MaxWorkers        int // max number of CPUs used by the operation (see WithMaxWorkers)
+
+	// out-of-core fft (see WithOutOfCoreFFT)
+	FFTDir         string
+	FFTMaxElements int
+
+	// multi exponentiations and ffts on an external device (see WithAccelerator)
+	Accelerator interface{}
+
+	KeyChunkSize int // number of points of the proving key decoded at once (see WithKeyChunkSize)
+
+	Seed []byte // derives the randomness of the prover (see WithSeed)
+
+	// trace of the R1CS solver (see WithSolverTrace)
+	SolverTrace       io.Writer
+	SolverTraceFormat TraceFormat
+}
+
+// Option updates a Config
+type Option func(*Config) error
+
+// NewConfig returns a Config with default values, updated with provided options
+func NewConfig(opts ...Option) (Config, error) {
+	config := Config{
+		Context:      context.Background(),
+		Progress:     func(string, int, int) {},
+		MaxWorkers:   runtime.NumCPU(),
+		KeyChunkSize: 1 << 16,
+	}
+	for _, opt := range opts {
+		if err := opt(&config); err != nil {
+			return config, err
+		}
+	}
+	return config, nil
+}
+
+// WithProgress sets a callback to monitor the progress of the operation
+//
+// the callback isn't called concurrently. The steps of the provers are the solver, the FFTs and the
+// multi exponentiations; groth16.ProveBatch only reports the number of proofs computed.
+//
+// honored by: groth16.Setup, groth16.ProveWithOptions, groth16.ProveFromFile, groth16.ProveBatch
+func WithProgress(f ProgressFunc) Option {
+	return func(config *Config) error {
+		if f == nil {
+			return errors.New("progress callback can't be nil")
+		}
+		config.Progress = f
+		return nil
+	}
+}
+
+// WithContext cancels the operation when ctx is done; the operation then returns ctx.Err()
+//
+// the context is checked between the steps of the operation (and between the chunks of the proving
+// key with groth16.ProveFromFile): a multi exponentiation or a FFT in progress isn't interrupted.
+//
+// honored by: groth16.ProveWithOptions, groth16.ProveFromFile, groth16.ProveBatch
+func WithContext(ctx context.Context) Option {
+	return func(config *Config) error {
+		if ctx == nil {
+			return errors.New("context can't be nil")
+		}
+		config.Context = ctx
+		return nil
+	}
+}
+
+// IgnoreSolverError ignores the R1CS solving errors; the prover then computes an (invalid) proof
+//
+// honored by: groth16.ProveWithOptions
+func IgnoreSolverError() Option {
+	return func(config *Config) error {
+		config.IgnoreSolverError = true
+		return nil
+	}
+}
+
+// WithMaxWorkers caps the number of CPUs used by the parallel parts of the operation (runtime.NumCPU() by default)
+//
+// Go has no goroutine priorities; capping the workers of a background operation (a prover for example)
+// leaves the remaining CPUs to latency sensitive tasks of the same process (a verifier for example).
+// The batch scalar multiplications of groth16.Setup, done in gurvy, are not capped.
+//
+// honored by: groth16.Setup, groth16.ProveWithOptions, groth16.ProveBatch
+func WithMaxWorkers(n int) Option {
+	return func(config *Config) error {
+		if n < 1 {
+			return errors.New("max workers must be strictly positive")
+		}
+		config.MaxWorkers = n
+		return nil
+	}
+}
+
+// WithOutOfCoreFFT bounds the memory used by the prover FFTs: when the fft domain has more than
+// maxElements elements, the polynomials are stored in temporary files in dir (os.TempDir() if empty)
+// and transformed by blocks of at most maxElements elements.
+//
+// maxElements must be larger than sqrt(domain cardinality).
+//
+// honored by: groth16.ProveWithOptions
+func WithOutOfCoreFFT(dir string, maxElements int) Option {
+	return func(config *Config) error {
+		if maxElements <= 0 {
+			return errors.New("out-of-core fft: maxElements must be strictly positive")
+		}
+		config.FFTDir = dir
+		config.FFTMaxElements = maxElements
+		return nil
+	}
+}
+
+// WithAccelerator delegates the multi exponentiations and the FFTs of the prover to acc, a plugin
+// driving a GPU for example; by default, they run on the CPU
+//
+// acc implements the Accelerator interface of the curve of the circuit (groth16.AcceleratorBN256, ...),
+// which is checked by the prover. With an accelerator, WithOutOfCoreFFT is ignored.
+//
+// honored by: groth16.ProveWithOptions, groth16.ProveBatch
+func WithAccelerator(acc interface{}) Option {
+	return func(config *Config) error {
+		if acc == nil {
+			return errors.New("accelerator can't be nil")
+		}
+		config.Accelerator = acc
+		return nil
+	}
+}
+
+// WithKeyChunkSize sets the number of points of the proving key decoded at once by the provers reading
+// the key from a file (1 << 16 by default), which bounds the memory used by the key
+//
+// honored by: groth16.ProveFromFile
+func WithKeyChunkSize(n int) Option {
+	return func(config *Config) error {
+		if n < 1 {
+			return errors.New("key chunk size must be strictly positive")
+		}
+		config.KeyChunkSize = n
+		return nil
+	}
+}
+
+// WithSeed derives the randomizers of the proof from seed and from the solved wires, instead of
+// sampling them: the same seed and solution give byte-identical proofs, for tests and reproducibility
+// checks
+//
+// the proofs are zero-knowledge only if seed is secret and random: don't use this option in production.
+//
+// honored by: groth16.ProveWithOptions, groth16.ProveFromFile, groth16.ProveBatch
+func WithSeed(seed []byte) Option {
+	return func(config *Config) error {
+		if len(seed) == 0 {
+			return errors.New("seed can't be empty")
+		}
+		config.Seed = append([]byte(nil), seed...)
+		return nil
+	}
+}
+
+// WithSolverTrace writes the trace of the R1CS solver to w: the value of each wire and the status of each
+// constraint (see SolverTrace), in the given format. The trace is written whether the solver succeeds or
+// not, before the prover returns the error of the solver; a write error is returned by the prover.
+//
+// the traces of the proofs of groth16.ProveBatch are written one after the other, in the order of the
+// solutions.
+//
+// honored by: groth16.ProveWithOptions, groth16.ProveFromFile, groth16.ProveBatch
+func WithSolverTrace(w io.Writer, format TraceFormat) Option {
+	return func(config *Config) error {
+		if w == nil {
+			return errors.New("solver trace writer can't be nil")
+		}
+		if format != TraceBinary && format != TraceJSON {
+			return errors.New("unknown solver trace format")
+		}
+		config.SolverTrace = w
+		config.SolverTraceFormat = format
+		return nil
+	}
+}
+
+// ConstantTime makes the verifier evaluate all the checks of the proof before returning (the subgroup
+// checks, the proof of knowledge of the commitment and the pairing equation), whichever fails first,
+// and compare the result of the pairings with the verifying key in constant time.
+//
+// it doesn't make the verification constant time, and doesn't close timing side channels: the subgroup
+// checks, the multi exponentiation of the public inputs and the pairings use variable time curve
+// arithmetic, and an invalid public input or an error of a Miller loop still returns early.
+//
+// honored by: groth16.Verify
+func ConstantTime() Option {
+	return func(config *Config) error {
+		config.ConstantTime = true
+		return nil
+	}
+}
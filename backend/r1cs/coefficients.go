@@ -0,0 +1,68 @@
+// Copyright 2020 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package r1cs
+
+import "math/big"
+
+// CoeffArena stores big.Int coefficients back to back in a single slice of limbs
+//
+// a R1CS with millions of constraints has (at most) millions of unique coefficients; storing
+// them as []big.Int means one heap allocation per coefficient, which the GC has to scan.
+// Coefficients are referenced by index (see r1c.Term.CoeffID()), so we don't need
+// individual big.Int objects: the limbs of coefficient i are Limbs[Offsets[i]:Offsets[i+1]]
+type CoeffArena struct {
+	Limbs   []big.Word // little endian limbs of the absolute values of the coefficients
+	Offsets []uint64   // len(Offsets) == Len() + 1, Offsets[0] == 0
+	Neg     []bool     // Neg[i] is set if coefficient i is negative
+}
+
+// NewCoeffArena returns an empty CoeffArena with capacity for nbCoeffs coefficients of nbLimbs limbs
+func NewCoeffArena(nbCoeffs, nbLimbs int) CoeffArena {
+	arena := CoeffArena{
+		Limbs:   make([]big.Word, 0, nbCoeffs*nbLimbs),
+		Offsets: make([]uint64, 1, nbCoeffs+1),
+		Neg:     make([]bool, 0, nbCoeffs),
+	}
+	return arena
+}
+
+// Len returns the number of coefficients stored in the arena
+func (arena *CoeffArena) Len() int {
+	return len(arena.Neg)
+}
+
+// Append copies b at the end of the arena and returns its index
+func (arena *CoeffArena) Append(b *big.Int) int {
+	if len(arena.Offsets) == 0 {
+		arena.Offsets = append(arena.Offsets, 0)
+	}
+	id := len(arena.Neg)
+	arena.Limbs = append(arena.Limbs, b.Bits()...)
+	arena.Offsets = append(arena.Offsets, uint64(len(arena.Limbs)))
+	arena.Neg = append(arena.Neg, b.Sign() < 0)
+	return id
+}
+
+// Get sets res to the i-th coefficient and returns res
+//
+// res doesn't share memory with the arena and can be modified
+func (arena *CoeffArena) Get(i int, res *big.Int) *big.Int {
+	limbs := arena.Limbs[arena.Offsets[i]:arena.Offsets[i+1]]
+	res.SetBits(append(res.Bits()[:0], limbs...)) // reuses res memory if possible
+	if arena.Neg[i] {
+		res.Neg(res)
+	}
+	return res
+}
@@ -0,0 +1,57 @@
+// Copyright 2020 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package r1cs
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestCoeffArena(t *testing.T) {
+	var arena CoeffArena
+
+	var big1 big.Int
+	big1.SetString("21888242871839275222246405745257275088548364400416034343698204186575808495617", 10)
+	inputs := []*big.Int{
+		big.NewInt(0),
+		big.NewInt(1),
+		big.NewInt(-42),
+		&big1,
+		new(big.Int).Neg(&big1),
+	}
+
+	for i, b := range inputs {
+		if id := arena.Append(b); id != i {
+			t.Fatal("unexpected coefficient id", id, i)
+		}
+	}
+	if arena.Len() != len(inputs) {
+		t.Fatal("unexpected arena length")
+	}
+
+	var res big.Int
+	for i, b := range inputs {
+		if arena.Get(i, &res).Cmp(b) != 0 {
+			t.Fatal("coefficient", i, "expected", b.String(), "got", res.String())
+		}
+	}
+
+	// modifying a coefficient we got shouldn't modify the arena
+	arena.Get(3, &res)
+	res.Add(&res, big.NewInt(1))
+	if arena.Get(3, &res).Cmp(&big1) != 0 {
+		t.Fatal("arena was modified through Get result")
+	}
+}
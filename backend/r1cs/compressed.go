@@ -0,0 +1,66 @@
+// Copyright 2020 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package r1cs
+
+import (
+	"errors"
+	"io"
+
+	"github.com/consensys/gnark/internal/backend/ioutils"
+	"github.com/consensys/gurvy"
+)
+
+// Sections selects the parts of a compressed R1CS to decompress (see ReadCompressed)
+//
+// the header (number of wires and constraints, names of the inputs) is always loaded
+type Sections int
+
+const (
+	// SectionConstraints and SectionCoefficients are needed to solve the R1CS, to run a setup or a prover
+	SectionConstraints  Sections = ioutils.LoadConstraints
+	SectionCoefficients Sections = ioutils.LoadCoefficients
+
+	// SectionDebug holds the logs (see frontend.Println) and the debug info used in the solver errors
+	SectionDebug Sections = ioutils.LoadDebug
+
+	// SectionsAll loads the full R1CS
+	SectionsAll = SectionConstraints | SectionCoefficients | SectionDebug
+)
+
+type compressedR1CS interface {
+	WriteCompressedTo(w io.Writer) (int64, error)
+	ReadCompressedFrom(r io.ReaderAt, load int) error
+}
+
+// WriteCompressed writes r1cs in w, split in sections compressed with zstd
+//
+// compared to r1cs.WriteTo, the output is smaller, and ReadCompressed can decompress only the sections
+// needed by the caller
+func WriteCompressed(w io.Writer, r1cs R1CS) (int64, error) {
+	_r1cs, ok := r1cs.(compressedR1CS)
+	if !ok {
+		return 0, errors.New("compressed format is only supported for curve typed R1CS")
+	}
+	return _r1cs.WriteCompressedTo(w)
+}
+
+// ReadCompressed reads a R1CS written by WriteCompressed, decompressing only the provided sections
+func ReadCompressed(curveID gurvy.ID, r io.ReaderAt, sections Sections) (R1CS, error) {
+	res := New(curveID)
+	if err := res.(compressedR1CS).ReadCompressedFrom(r, int(sections)); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
@@ -0,0 +1,526 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package debug steps through the solving of a compiled R1CS, constraint by constraint
+//
+// The Debugger solves the constraints in the order of the solver (the computational constraints, then
+// the assertions), and stops on a failed constraint instead of returning an error: the values of the
+// wires solved so far can be inspected by name (the inputs by their names, the internal wires as $id),
+// and breakpoints set on namespaces (the gadget called by Define, eddsa.Verify for example, or a namespace
+// of the frontend, as in dot):
+//
+//	d, _ := debug.New(r1cs, witness)
+//	d.Break("eddsa.Verify")
+//	for {
+//		if err := d.Continue(); err != nil {
+//			d.Dump(os.Stdout)
+//			break
+//		}
+//		if d.Done() {
+//			break
+//		}
+//		fmt.Println(d.Constraint(d.Next())) // at a breakpoint
+//	}
+//
+// Run is an interactive session on a Debugger (gnark debug).
+package debug
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/hint"
+	"github.com/consensys/gnark/backend/r1cs"
+	"github.com/consensys/gnark/backend/r1cs/internal/compiled"
+	"github.com/consensys/gnark/backend/r1cs/r1c"
+)
+
+var (
+	// ErrDone is returned by Step when all the constraints are solved
+	ErrDone = errors.New("debug: all the constraints are solved")
+	// ErrUnknownWire is returned by Value for a name which is not a wire of the R1CS
+	ErrUnknownWire = errors.New("debug: unknown wire")
+	// ErrNotSolved is returned by Value for a wire which is not solved yet
+	ErrNotSolved = errors.New("debug: wire not solved yet")
+
+	errCurve = errors.New("debug: unsupported R1CS")
+)
+
+// Failure is a constraint a·b == c which is not satisfied
+type Failure struct {
+	Constraint int
+	Namespace  string
+	Location   string // file:line in Define, when the R1CS has the debug information
+	A, B, C    big.Int
+}
+
+func (f *Failure) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "constraint %d (%s", f.Constraint, f.Namespace)
+	if f.Location != "" {
+		sb.WriteString(", " + f.Location)
+	}
+	fmt.Fprintf(&sb, ") is not satisfied: %s · %s != %s", f.A.String(), f.B.String(), f.C.String())
+	return sb.String()
+}
+
+// Unwrap returns backend.ErrUnsatisfiedConstraint
+func (f *Failure) Unwrap() error {
+	return backend.ErrUnsatisfiedConstraint
+}
+
+// Debugger solves a R1CS step by step
+type Debugger struct {
+	c           *compiled.R1CS
+	namespaces  []string // of each constraint
+	values      []big.Int
+	solved      []bool
+	hints       map[int]*r1c.Hint // of the wires computed by a hint
+	next        int               // next constraint to solve
+	failure     *Failure
+	breakpoints map[string]bool
+}
+
+// New returns a Debugger on a typed R1CS, with the inputs of the assignment set (the values are
+// converted with backend.FromInterface) and no constraint solved
+func New(_r1cs r1cs.R1CS, assignment map[string]interface{}) (*Debugger, error) {
+	c, err := compiled.New(_r1cs)
+	if err != nil {
+		return nil, errCurve
+	}
+	d := &Debugger{
+		c:           c,
+		namespaces:  c.Graph().Namespaces,
+		values:      make([]big.Int, c.NbWires),
+		solved:      make([]bool, c.NbWires),
+		hints:       make(map[int]*r1c.Hint, len(c.Hints)),
+		breakpoints: make(map[string]bool),
+	}
+	for i := range c.Hints {
+		for _, w := range c.Hints[i].Outputs() {
+			d.hints[w] = &c.Hints[i]
+		}
+	}
+	for w := c.NbInternal(); w < c.NbWires; w++ {
+		if c.IsOne(w) {
+			d.values[w].SetInt64(1)
+			d.solved[w] = true
+			continue
+		}
+		name := c.Name(w)
+		val, ok := assignment[name]
+		if !ok {
+			return nil, fmt.Errorf("%q: %w", name, backend.ErrInputNotSet)
+		}
+		v := backend.FromInterface(val)
+		d.values[w].Mod(&v, c.Modulus)
+		d.solved[w] = true
+	}
+	return d, nil
+}
+
+// Next returns the index of the next constraint to solve (the failed constraint after a failure), or
+// the number of constraints when they are all solved
+func (d *Debugger) Next() int {
+	return d.next
+}
+
+// Done returns true if all the constraints are solved
+func (d *Debugger) Done() bool {
+	return d.next == len(d.c.Constraints)
+}
+
+// Failure returns the failed constraint, or nil
+func (d *Debugger) Failure() *Failure {
+	return d.failure
+}
+
+// Namespace returns the namespace of a constraint
+func (d *Debugger) Namespace(constraint int) string {
+	return d.namespaces[constraint]
+}
+
+// Namespaces returns the namespaces of the R1CS, sorted
+func (d *Debugger) Namespaces() []string {
+	seen := make(map[string]bool)
+	var res []string
+	for _, ns := range d.namespaces {
+		if !seen[ns] {
+			seen[ns] = true
+			res = append(res, ns)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// Break sets a breakpoint on a namespace: Continue stops before the constraints of the namespace (or of
+// its sub namespaces: a breakpoint on eddsa stops in eddsa.Verify, and on merkle in merkle/level3)
+func (d *Debugger) Break(namespace string) {
+	d.breakpoints[namespace] = true
+}
+
+// Clear removes a breakpoint
+func (d *Debugger) Clear(namespace string) {
+	delete(d.breakpoints, namespace)
+}
+
+// Breakpoints returns the namespaces with a breakpoint, sorted
+func (d *Debugger) Breakpoints() []string {
+	res := make([]string, 0, len(d.breakpoints))
+	for ns := range d.breakpoints {
+		res = append(res, ns)
+	}
+	sort.Strings(res)
+	return res
+}
+
+func (d *Debugger) isBreakpoint(namespace string) bool {
+	for bp := range d.breakpoints {
+		if namespace == bp || strings.HasPrefix(namespace, bp+".") || strings.HasPrefix(namespace, bp+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Step solves the next constraint (or checks it, for an assertion), and returns a *Failure if it is not
+// satisfied; after a failure, the Debugger doesn't move anymore
+func (d *Debugger) Step() error {
+	if d.failure != nil {
+		return d.failure
+	}
+	if d.Done() {
+		return ErrDone
+	}
+	i := d.next
+	r := &d.c.Constraints[i]
+	for _, w := range d.c.Wires(r) {
+		if h, ok := d.hints[w]; ok {
+			if err := d.solveHint(h); err != nil {
+				return fmt.Errorf("constraint %d: %w", i, err)
+			}
+		}
+	}
+	if i < d.c.NbCOConstraints {
+		if err := d.solve(r); err != nil {
+			return fmt.Errorf("constraint %d: %w", i, err)
+		}
+	}
+	var a, b, c, ab big.Int
+	d.eval(&a, r.L)
+	d.eval(&b, r.R)
+	d.eval(&c, r.O)
+	ab.Mul(&a, &b).Mod(&ab, d.c.Modulus)
+	if ab.Cmp(&c) != 0 {
+		d.failure = &Failure{Constraint: i, Namespace: d.namespaces[i], Location: d.c.Location(i)}
+		d.failure.A.Set(&a)
+		d.failure.B.Set(&b)
+		d.failure.C.Set(&c)
+		return d.failure
+	}
+	d.next++
+	return nil
+}
+
+// Continue solves the constraints until the first constraint of a namespace with a breakpoint (after
+// the next constraint, so that Continue moves on from a breakpoint), a failure, or the end
+func (d *Debugger) Continue() error {
+	for {
+		if err := d.Step(); err != nil {
+			return err
+		}
+		if d.Done() {
+			return nil
+		}
+		ns := d.namespaces[d.next]
+		if d.isBreakpoint(ns) && ns != d.namespaces[d.next-1] {
+			return nil
+		}
+	}
+}
+
+// Value returns the value of a wire, by name for an input, or $id for an internal wire
+func (d *Debugger) Value(name string) (*big.Int, error) {
+	w, err := d.wire(name)
+	if err != nil {
+		return nil, err
+	}
+	if !d.solved[w] {
+		return nil, fmt.Errorf("%s: %w", name, ErrNotSolved)
+	}
+	return new(big.Int).Set(&d.values[w]), nil
+}
+
+// wire returns the id of a wire from its name
+func (d *Debugger) wire(name string) (int, error) {
+	if strings.HasPrefix(name, "$") {
+		if w, err := strconv.Atoi(name[1:]); err == nil && w >= 0 && w < d.c.NbInternal() {
+			return w, nil
+		}
+	} else {
+		for w := d.c.NbInternal(); w < d.c.NbWires; w++ {
+			if d.c.Name(w) == name {
+				return w, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("%s: %w", name, ErrUnknownWire)
+}
+
+// name returns the name of a wire, $id for an internal wire
+func (d *Debugger) name(w int) string {
+	if name := d.c.Name(w); name != "" {
+		return name
+	}
+	return "$" + strconv.Itoa(w)
+}
+
+// maxTerms is the number of terms of a linear expression written by Constraint
+const maxTerms = 8
+
+// Constraint returns a constraint as "L · R == O", with the wires by name (and the first terms of the
+// long linear expressions)
+func (d *Debugger) Constraint(constraint int) string {
+	r := &d.c.Constraints[constraint]
+	var half big.Int
+	half.Rsh(d.c.Modulus, 1)
+	linExp := func(l r1c.LinearExpression) string {
+		var parts []string
+		for _, t := range l {
+			coeff := d.c.Coeff(t)
+			if coeff.Cmp(&half) > 0 {
+				coeff.Sub(&coeff, d.c.Modulus)
+			}
+			w := t.VariableID()
+			switch {
+			case coeff.Sign() == 0:
+			case d.c.IsOne(w):
+				parts = append(parts, coeff.String())
+			case coeff.IsInt64() && coeff.Int64() == 1:
+				parts = append(parts, d.name(w))
+			case coeff.IsInt64() && coeff.Int64() == -1:
+				parts = append(parts, "-"+d.name(w))
+			default:
+				parts = append(parts, coeff.String()+"·"+d.name(w))
+			}
+		}
+		if len(parts) == 0 {
+			return "0"
+		}
+		if len(parts) > maxTerms {
+			parts = append(parts[:maxTerms-1], fmt.Sprintf("... (%d terms)", len(parts)))
+		}
+		res := strings.Join(parts, " + ")
+		if len(parts) > 1 {
+			res = "(" + res + ")"
+		}
+		return strings.Replace(res, "+ -", "- ", -1)
+	}
+	return linExp(r.L) + " · " + linExp(r.R) + " == " + linExp(r.O)
+}
+
+// Dump writes the state of the Debugger: the next (or failed) constraint, its namespace and location,
+// the values of its wires, and the values of the inputs
+func (d *Debugger) Dump(w io.Writer) error {
+	var sb strings.Builder
+	switch {
+	case d.failure != nil:
+		fmt.Fprintf(&sb, "%s\n", d.failure.Error())
+	case d.Done():
+		sb.WriteString("all the constraints are solved\n")
+	default:
+		fmt.Fprintf(&sb, "next: constraint %d (%s", d.next, d.namespaces[d.next])
+		if loc := d.c.Location(d.next); loc != "" {
+			sb.WriteString(", " + loc)
+		}
+		sb.WriteString(")\n")
+	}
+	value := func(w int) string {
+		if !d.solved[w] {
+			return "?"
+		}
+		return d.values[w].String()
+	}
+	if !d.Done() {
+		fmt.Fprintf(&sb, "  %s\n", d.Constraint(d.next))
+		for _, w := range uniqueWires(d.c.Wires(&d.c.Constraints[d.next])) {
+			fmt.Fprintf(&sb, "  %s = %s\n", d.name(w), value(w))
+		}
+	}
+	sb.WriteString("inputs:\n")
+	for w := d.c.NbInternal(); w < d.c.NbWires; w++ {
+		if !d.c.IsOne(w) {
+			fmt.Fprintf(&sb, "  %s = %s\n", d.name(w), value(w))
+		}
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func uniqueWires(wires []int) []int {
+	seen := make(map[int]bool)
+	res := wires[:0]
+	for _, w := range wires {
+		if !seen[w] {
+			seen[w] = true
+			res = append(res, w)
+		}
+	}
+	return res
+}
+
+// eval sets res to the value of a linear expression, with the wires solved so far
+func (d *Debugger) eval(res *big.Int, l r1c.LinearExpression) {
+	res.SetInt64(0)
+	for _, t := range l {
+		coeff := d.c.Coeff(t)
+		coeff.Mul(&coeff, &d.values[t.VariableID()])
+		res.Add(res, &coeff)
+	}
+	res.Mod(res, d.c.Modulus)
+}
+
+// solveHint computes the wires of a hint, and the hints of its inputs, as the solver of the backends
+func (d *Debugger) solveHint(h *r1c.Hint) error {
+	if d.solved[h.Wire] {
+		return nil
+	}
+	var f hint.Function
+	var fMulti hint.MultiFunction
+	var ok bool
+	if len(h.Wires) != 0 {
+		fMulti, ok = hint.LookupMulti(h.ID)
+	} else {
+		f, ok = hint.Lookup(h.ID)
+	}
+	if !ok {
+		return fmt.Errorf("%w: %d", hint.ErrUnknownHint, h.ID)
+	}
+	inputs := make([]*big.Int, len(h.Inputs))
+	for i, l := range h.Inputs {
+		for _, t := range l {
+			if input, ok := d.hints[t.VariableID()]; ok {
+				if err := d.solveHint(input); err != nil {
+					return err
+				}
+			}
+		}
+		inputs[i] = new(big.Int)
+		d.eval(inputs[i], l)
+	}
+	if fMulti != nil {
+		results := make([]*big.Int, len(h.Wires))
+		for i := range results {
+			results[i] = new(big.Int)
+		}
+		if err := fMulti(d.c.CurveID, inputs, results); err != nil {
+			return fmt.Errorf("hint %d: %w", h.ID, err)
+		}
+		for i, w := range h.Wires {
+			d.values[w].Mod(results[i], d.c.Modulus)
+			d.solved[w] = true
+		}
+		return nil
+	}
+	var res big.Int
+	if err := f(d.c.CurveID, inputs, &res); err != nil {
+		return fmt.Errorf("hint %d: %w", h.ID, err)
+	}
+	d.values[h.Wire].Mod(&res, d.c.Modulus)
+	d.solved[h.Wire] = true
+	return nil
+}
+
+// solve computes the wires of a computational constraint, as the solver of the backends
+func (d *Debugger) solve(r *r1c.R1C) error {
+	p := d.c.Modulus
+	switch r.Solver {
+	case r1c.SingleOutput:
+		// a·b == c, with a term of a, b or c to compute
+		var values [3]big.Int
+		loc := -1
+		var toCompute r1c.Term
+		for i, l := range []r1c.LinearExpression{r.L, r.R, r.O} {
+			for _, t := range l {
+				if !d.solved[t.VariableID()] {
+					if loc != -1 {
+						return errors.New("more than one wire to solve")
+					}
+					loc, toCompute = i, t
+					continue
+				}
+				coeff := d.c.Coeff(t)
+				coeff.Mul(&coeff, &d.values[t.VariableID()])
+				values[i].Add(&values[i], &coeff)
+			}
+			values[i].Mod(&values[i], p)
+		}
+		if loc == -1 {
+			return nil
+		}
+		a, b, c := &values[0], &values[1], &values[2]
+		w := toCompute.VariableID()
+		var res big.Int
+		switch loc {
+		case 0, 1:
+			// (a + k·w)·b == c or a·(b + k·w) == c
+			other, sum := b, a
+			if loc == 1 {
+				other, sum = a, b
+			}
+			if other.Sign() != 0 {
+				res.ModInverse(other, p).Mul(&res, c).Sub(&res, sum)
+			}
+		case 2:
+			// a·b == c + k·w
+			res.Mul(a, b).Sub(&res, c)
+		}
+		if coeff := d.c.Coeff(toCompute); coeff.Sign() != 0 {
+			var inv big.Int
+			res.Mul(&res, inv.ModInverse(&coeff, p))
+		}
+		d.values[w].Mod(&res, p)
+		d.solved[w] = true
+
+	case r1c.BinaryDec:
+		// the bits of O, with the coefficients of the bits in L the powers of 2
+		var n big.Int
+		d.eval(&n, r.O)
+		nbBits := len(r.L)
+		for _, t := range r.L {
+			coeff := d.c.Coeff(t)
+			bit := coeff.BitLen() - 1
+			if bit < 0 || new(big.Int).Lsh(big.NewInt(1), uint(bit)).Cmp(&coeff) != 0 {
+				// the coefficient is not a power of 2, it is reduced modulo p: the last bit
+				bit = nbBits - 1
+			}
+			w := t.VariableID()
+			d.values[w].SetUint64(uint64(n.Bit(bit)))
+			d.solved[w] = true
+		}
+
+	default:
+		return errors.New("unimplemented solving method")
+	}
+	return nil
+}
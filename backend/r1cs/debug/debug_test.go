@@ -0,0 +1,140 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/internal/backend/circuits"
+	"github.com/consensys/gurvy"
+	"github.com/stretchr/testify/require"
+)
+
+type rangeCircuit struct {
+	X, Y frontend.Variable
+	Z    frontend.Variable `gnark:",public"`
+}
+
+func checkRange(cs *frontend.ConstraintSystem, v frontend.Variable) {
+	cs.AssertIsLessOrEqual(v, 100)
+}
+
+func (circuit *rangeCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	checkRange(cs, circuit.X)
+	cs.AssertIsEqual(circuit.Z, cs.Mul(circuit.X, circuit.Y))
+	return nil
+}
+
+func newDebugger(t *testing.T, x, y, z int) *Debugger {
+	_r1cs, err := frontend.Compile(gurvy.BN256, &rangeCircuit{})
+	require.NoError(t, err)
+	d, err := New(_r1cs, map[string]interface{}{"X": x, "Y": y, "Z": z})
+	require.NoError(t, err)
+	return d
+}
+
+func TestDebugger(t *testing.T) {
+	assert := require.New(t)
+	d := newDebugger(t, 42, 3, 126)
+	assert.Equal([]string{"circuit", "debug.checkRange"}, d.Namespaces())
+
+	v, err := d.Value("X")
+	assert.NoError(err)
+	assert.Equal("42", v.String())
+	_, err = d.Value("$0")
+	assert.True(errors.Is(err, ErrNotSolved), "%v", err)
+	_, err = d.Value("W")
+	assert.True(errors.Is(err, ErrUnknownWire), "%v", err)
+
+	assert.NoError(d.Step())
+	assert.Equal(1, d.Next())
+	_, err = d.Value("$0")
+	assert.NoError(err)
+
+	// stops at the first constraint of the namespace
+	d.Break("debug")
+	assert.NoError(d.Continue())
+	assert.False(d.Done())
+	assert.Equal("debug.checkRange", d.Namespace(d.Next()))
+	assert.NotEqual("debug.checkRange", d.Namespace(d.Next()-1))
+
+	d.Clear("debug")
+	assert.NoError(d.Continue())
+	assert.True(d.Done())
+	assert.Nil(d.Failure())
+	assert.Equal(ErrDone, d.Step())
+}
+
+func TestFailure(t *testing.T) {
+	assert := require.New(t)
+	d := newDebugger(t, 101, 3, 303)
+	err := d.Continue()
+	assert.True(errors.Is(err, backend.ErrUnsatisfiedConstraint), "%v", err)
+	failure := d.Failure()
+	assert.NotNil(failure)
+	assert.Equal(failure.Constraint, d.Next())
+	assert.Equal("debug.checkRange", failure.Namespace)
+	assert.Contains(failure.Location, "debug_test.go")
+
+	// the debugger doesn't move after a failure
+	assert.Equal(failure, d.Step())
+	assert.Equal(failure.Constraint, d.Next())
+
+	var dump bytes.Buffer
+	assert.NoError(d.Dump(&dump))
+	assert.Contains(dump.String(), "is not satisfied")
+	assert.Contains(dump.String(), "inputs:\n  X = 101\n  Y = 3\n  Z = 303\n")
+}
+
+// the debugger agrees with the solver of the backends
+func TestCircuits(t *testing.T) {
+	for name, circuit := range circuits.Circuits {
+		for _, curveID := range []gurvy.ID{gurvy.BN256, gurvy.BLS377, gurvy.BLS381, gurvy.BW761} {
+			_r1cs := circuit.R1CS.ToR1CS(curveID)
+			for _, witness := range []frontend.Circuit{circuit.Good, circuit.Bad} {
+				assignment, err := frontend.ParseWitness(witness)
+				require.NoError(t, err)
+				d, err := New(_r1cs, assignment)
+				require.NoError(t, err)
+				for d.Continue() == nil && !d.Done() {
+				}
+				expected := _r1cs.IsSolved(assignment)
+				require.Equal(t, expected == nil, d.Failure() == nil, "%s on %s: %v != %v", name, curveID, expected, d.Failure())
+			}
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	assert := require.New(t)
+	d := newDebugger(t, 101, 3, 303)
+	commands := strings.Join([]string{"namespaces", "break debug.checkRange", "print X $0", "c", "p X", "c", "step"}, "\n")
+	var out bytes.Buffer
+	err := Run(d, strings.NewReader(commands), &out)
+	assert.True(errors.Is(err, backend.ErrUnsatisfiedConstraint), "%v", err)
+	assert.Contains(out.String(), "debug.checkRange\n")
+	assert.Contains(out.String(), "X = 101\n")
+	assert.Contains(out.String(), "$0: debug: wire not solved yet\n")
+	assert.Contains(out.String(), "(debug.checkRange): ")
+	assert.Contains(out.String(), "inputs:\n  X = 101\n")
+	assert.Contains(out.String(), d.Failure().Error()+"\n")
+}
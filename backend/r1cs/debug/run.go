@@ -0,0 +1,152 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const help = `commands:
+  s, step [n]        solve the next n constraints (default 1)
+  c, continue        solve the constraints until a breakpoint, a failure or the end
+  b, break ns        stop before the constraints of the namespace ns
+  clear ns           remove the breakpoint on ns
+  p, print wire...   print the values of wires (inputs by name, internal wires as $id)
+  l, list            print the next constraint
+  ns, namespaces     print the namespaces and the breakpoints
+  dump               print the next constraint, the values of its wires and of the inputs
+  q, quit            quit
+`
+
+// Run reads commands from in (one per line, see help), and writes their results to out, until quit or
+// the end of in; the state of the Debugger is dumped when a constraint fails
+//
+// Run returns the failure of the R1CS, if any, or an error of in or out
+func Run(d *Debugger, in io.Reader, out io.Writer) error {
+	w := bufio.NewWriter(out)
+	defer w.Flush()
+
+	// the result of a step or a continue
+	report := func(err error) {
+		switch {
+		case err == nil && d.Done():
+			fmt.Fprintln(w, "all the constraints are solved")
+		case err == nil:
+			fmt.Fprintf(w, "constraint %d (%s): %s\n", d.Next(), d.Namespace(d.Next()), d.Constraint(d.Next()))
+		case errors.Is(err, ErrDone):
+			fmt.Fprintln(w, err)
+		default:
+			var failure *Failure
+			if errors.As(err, &failure) {
+				d.Dump(w)
+			} else {
+				fmt.Fprintln(w, err)
+			}
+		}
+	}
+
+	fmt.Fprintf(w, "%d constraints, type help for the commands\n", len(d.c.Constraints))
+	report(nil)
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(w, "(debug) ")
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		if !scanner.Scan() {
+			break
+		}
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		command, args := fields[0], fields[1:]
+		switch command {
+		case "s", "step":
+			n := 1
+			if len(args) > 0 {
+				var err error
+				if n, err = strconv.Atoi(args[0]); err != nil || n < 1 {
+					fmt.Fprintf(w, "invalid number of steps %q\n", args[0])
+					continue
+				}
+			}
+			var err error
+			for i := 0; i < n && err == nil && !d.Done(); i++ {
+				err = d.Step()
+			}
+			report(err)
+		case "c", "continue":
+			report(d.Continue())
+		case "b", "break", "clear":
+			if len(args) != 1 {
+				fmt.Fprintf(w, "usage: %s namespace\n", command)
+				continue
+			}
+			if command == "clear" {
+				d.Clear(args[0])
+			} else {
+				d.Break(args[0])
+			}
+		case "p", "print":
+			for _, name := range args {
+				if v, err := d.Value(name); err != nil {
+					fmt.Fprintln(w, err)
+				} else {
+					fmt.Fprintf(w, "%s = %s\n", name, v.String())
+				}
+			}
+		case "l", "list":
+			report(nil)
+		case "ns", "namespaces":
+			for _, ns := range d.Namespaces() {
+				if d.breakpoints[ns] {
+					fmt.Fprintf(w, "%s (breakpoint)\n", ns)
+				} else {
+					fmt.Fprintln(w, ns)
+				}
+			}
+		case "dump":
+			d.Dump(w)
+		case "q", "quit":
+			w.WriteString("\n")
+			return d.result()
+		case "h", "help":
+			w.WriteString(help)
+		default:
+			fmt.Fprintf(w, "unknown command %q, type help for the commands\n", command)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	w.WriteString("\n")
+	return d.result()
+}
+
+// result returns the failure of the Debugger, if any
+func (d *Debugger) result() error {
+	if d.failure != nil {
+		return d.failure
+	}
+	return nil
+}
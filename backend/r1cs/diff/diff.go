@@ -0,0 +1,329 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package diff compares two versions of a compiled circuit, at the constraint level
+//
+// The wire ids of the internal wires change with any modification of a circuit, so constraints are
+// compared on a canonical form: inputs by name, internal wires numbered in the constraint ($0, $1...),
+// and coefficients reduced (p-1 is -1). The constraints are grouped by namespace (the gadget called
+// by Define, see dot), and compared as multisets:
+//
+//	report, _ := diff.Compare(oldR1CS, newR1CS)
+//	fmt.Print(report)
+//
+// prints
+//
+//	constraints: 3 -> 4
+//	public inputs: +Z
+//	namespace circuit: +1 -0
+//	  + Z == X
+//
+// A change of the public inputs (added, removed or reordered) changes the verifying key and the public
+// witness of the verifiers.
+package diff
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/consensys/gnark/backend/r1cs"
+	"github.com/consensys/gnark/backend/r1cs/internal/compiled"
+	"github.com/consensys/gnark/backend/r1cs/r1c"
+	"github.com/consensys/gurvy"
+)
+
+var errCurve = errors.New("diff: unsupported R1CS")
+
+// Report is the difference between two compiled circuits
+type Report struct {
+	Curves          [2]gurvy.ID
+	NbConstraints   [2]int
+	AddedPublic     []string
+	RemovedPublic   []string
+	PublicReordered bool // the remaining public inputs are in a different order
+	AddedSecret     []string
+	RemovedSecret   []string
+	Namespaces      []Namespace // the namespaces with added or removed constraints, by name
+}
+
+// Namespace lists the constraints added to and removed from a namespace
+type Namespace struct {
+	Name           string
+	Added, Removed []Constraint
+}
+
+// Constraint is the canonical form of a constraint, and its number of occurrences
+type Constraint struct {
+	Constraint string
+	Count      int
+}
+
+// Equal returns true if the circuits have the same inputs and the same constraints
+func (r *Report) Equal() bool {
+	return r.Curves[0] == r.Curves[1] && len(r.AddedPublic) == 0 && len(r.RemovedPublic) == 0 && !r.PublicReordered &&
+		len(r.AddedSecret) == 0 && len(r.RemovedSecret) == 0 && len(r.Namespaces) == 0
+}
+
+func (r *Report) String() string {
+	var sb strings.Builder
+	if r.Curves[0] != r.Curves[1] {
+		fmt.Fprintf(&sb, "curve: %s -> %s\n", r.Curves[0], r.Curves[1])
+	}
+	fmt.Fprintf(&sb, "constraints: %d -> %d\n", r.NbConstraints[0], r.NbConstraints[1])
+	inputs := func(visibility string, added, removed []string, reordered bool) {
+		if len(added) == 0 && len(removed) == 0 && !reordered {
+			return
+		}
+		sb.WriteString(visibility + " inputs:")
+		for _, name := range added {
+			sb.WriteString(" +" + name)
+		}
+		for _, name := range removed {
+			sb.WriteString(" -" + name)
+		}
+		if reordered {
+			sb.WriteString(" (reordered)")
+		}
+		sb.WriteByte('\n')
+	}
+	inputs("public", r.AddedPublic, r.RemovedPublic, r.PublicReordered)
+	inputs("secret", r.AddedSecret, r.RemovedSecret, false)
+	for _, ns := range r.Namespaces {
+		count := func(constraints []Constraint) (n int) {
+			for _, c := range constraints {
+				n += c.Count
+			}
+			return
+		}
+		fmt.Fprintf(&sb, "namespace %s: +%d -%d\n", ns.Name, count(ns.Added), count(ns.Removed))
+		lines := func(prefix string, constraints []Constraint) {
+			for _, c := range constraints {
+				if c.Count > 1 {
+					fmt.Fprintf(&sb, "  %s %d× %s\n", prefix, c.Count, c.Constraint)
+				} else {
+					fmt.Fprintf(&sb, "  %s %s\n", prefix, c.Constraint)
+				}
+			}
+		}
+		lines("+", ns.Added)
+		lines("-", ns.Removed)
+	}
+	return sb.String()
+}
+
+// Compare returns the difference between an old and a new version of a circuit
+func Compare(old, new r1cs.R1CS) (*Report, error) {
+	c0, err := compiled.New(old)
+	if err != nil {
+		return nil, errCurve
+	}
+	c1, err := compiled.New(new)
+	if err != nil {
+		return nil, errCurve
+	}
+
+	r := &Report{
+		Curves:        [2]gurvy.ID{c0.CurveID, c1.CurveID},
+		NbConstraints: [2]int{len(c0.Constraints), len(c1.Constraints)},
+	}
+	var kept0, kept1 []string
+	r.AddedPublic, r.RemovedPublic, kept0, kept1 = compareNames(c0.PublicWires, c1.PublicWires)
+	for i := range kept0 {
+		if kept0[i] != kept1[i] {
+			r.PublicReordered = true
+		}
+	}
+	r.AddedSecret, r.RemovedSecret, _, _ = compareNames(c0.SecretWires, c1.SecretWires)
+
+	// number of occurrences of each canonical constraint, by namespace
+	count := func(c *compiled.R1CS) map[string]map[string]int {
+		res := make(map[string]map[string]int)
+		namespaces := c.Graph().Namespaces
+		for i := range c.Constraints {
+			ns := namespaces[i]
+			if res[ns] == nil {
+				res[ns] = make(map[string]int)
+			}
+			res[ns][canonical(c, &c.Constraints[i], i)]++
+		}
+		return res
+	}
+	counts0, counts1 := count(c0), count(c1)
+
+	var names []string
+	for name := range counts0 {
+		names = append(names, name)
+	}
+	for name := range counts1 {
+		if _, ok := counts0[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		ns := Namespace{Name: name}
+		ns.Added = difference(counts1[name], counts0[name])
+		ns.Removed = difference(counts0[name], counts1[name])
+		if len(ns.Added) != 0 || len(ns.Removed) != 0 {
+			r.Namespaces = append(r.Namespaces, ns)
+		}
+	}
+	return r, nil
+}
+
+// compareNames returns the names added to and removed from old, and the names of both, in their order
+func compareNames(old, new []string) (added, removed, kept0, kept1 []string) {
+	in := func(names []string) map[string]bool {
+		res := make(map[string]bool, len(names))
+		for _, name := range names {
+			res[name] = true
+		}
+		return res
+	}
+	inOld, inNew := in(old), in(new)
+	for _, name := range new {
+		if !inOld[name] {
+			added = append(added, name)
+		} else {
+			kept1 = append(kept1, name)
+		}
+	}
+	for _, name := range old {
+		if !inNew[name] {
+			removed = append(removed, name)
+		} else {
+			kept0 = append(kept0, name)
+		}
+	}
+	return
+}
+
+// difference returns the constraints occurring more often in a than in b, sorted
+func difference(a, b map[string]int) []Constraint {
+	var res []Constraint
+	for c, n := range a {
+		if n > b[c] {
+			res = append(res, Constraint{c, n - b[c]})
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Constraint < res[j].Constraint
+	})
+	return res
+}
+
+// canonical returns the canonical form of a constraint, "a · b == c"
+func canonical(c *compiled.R1CS, r *r1c.R1C, i int) string {
+	type term struct {
+		wire  int
+		name  string // "" for an internal wire
+		coeff big.Int
+	}
+	var half big.Int
+	half.Rsh(c.Modulus, 1)
+	format := func(coeff *big.Int) string {
+		if coeff.Cmp(&half) > 0 {
+			return new(big.Int).Sub(coeff, c.Modulus).String()
+		}
+		return coeff.String()
+	}
+
+	local := make(map[int]int) // internal wire -> number in the constraint
+	linExp := func(l r1c.LinearExpression) string {
+		terms := make([]term, 0, len(l))
+		for _, t := range l {
+			w := t.VariableID()
+			name := c.Name(w)
+			if c.IsOne(w) {
+				name = ""
+			}
+			terms = append(terms, term{wire: w, name: name, coeff: c.Coeff(t)})
+		}
+		// inputs by name, then the constant, then the internal wires by coefficient
+		rank := func(t term) int {
+			switch {
+			case t.name != "":
+				return 0
+			case c.IsOne(t.wire):
+				return 1
+			}
+			return 2
+		}
+		sort.SliceStable(terms, func(i, j int) bool {
+			if ri, rj := rank(terms[i]), rank(terms[j]); ri != rj {
+				return ri < rj
+			}
+			if terms[i].name != terms[j].name {
+				return terms[i].name < terms[j].name
+			}
+			return terms[i].coeff.Cmp(&terms[j].coeff) < 0
+		})
+
+		var parts []string
+		for _, t := range terms {
+			if t.coeff.Sign() == 0 {
+				continue
+			}
+			coeff := format(&t.coeff)
+			var v string
+			switch {
+			case t.name != "":
+				v = t.name
+			case c.IsOne(t.wire):
+				parts = append(parts, coeff)
+				continue
+			default:
+				n, ok := local[t.wire]
+				if !ok {
+					n = len(local)
+					local[t.wire] = n
+				}
+				v = fmt.Sprintf("$%d", n)
+			}
+			switch coeff {
+			case "1":
+				parts = append(parts, v)
+			case "-1":
+				parts = append(parts, "-"+v)
+			default:
+				parts = append(parts, coeff+"·"+v)
+			}
+		}
+		if len(parts) == 0 {
+			return "0"
+		}
+		res := strings.Join(parts, " + ")
+		if len(parts) > 1 {
+			res = "(" + res + ")"
+		}
+		return strings.Replace(res, "+ -", "- ", -1)
+	}
+
+	a, b, o := linExp(r.L), linExp(r.R), linExp(r.O)
+	var res string
+	if b == "1" {
+		res = a + " == " + o
+	} else {
+		res = a + " · " + b + " == " + o
+	}
+	if i < c.NbCOConstraints && r.Solver == r1c.BinaryDec {
+		res += " (bits)"
+	}
+	return res
+}
@@ -0,0 +1,131 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diff
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark/backend/r1cs"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gurvy"
+	"github.com/stretchr/testify/require"
+)
+
+type cubicCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (circuit *cubicCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	x3 := cs.Mul(circuit.X, circuit.X, circuit.X)
+	cs.AssertIsEqual(circuit.Y, cs.Add(x3, circuit.X, 5))
+	return nil
+}
+
+// the cubic circuit, with a new public input and a range check
+type cubicCircuitV2 struct {
+	X frontend.Variable
+	Z frontend.Variable `gnark:",public"`
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (circuit *cubicCircuitV2) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	x3 := cs.Mul(circuit.X, circuit.X, circuit.X)
+	cs.AssertIsEqual(circuit.Y, cs.Add(x3, circuit.X, 5))
+	checkX(cs, circuit.X, circuit.Z)
+	return nil
+}
+
+func checkX(cs *frontend.ConstraintSystem, x, z frontend.Variable) {
+	cs.AssertIsEqual(z, cs.Mul(x, 2))
+	cs.AssertIsBoolean(cs.Sub(x, 3))
+}
+
+func compile(t *testing.T, circuit frontend.Circuit) r1cs.R1CS {
+	_r1cs, err := frontend.Compile(gurvy.BN256, circuit)
+	require.NoError(t, err)
+	return _r1cs
+}
+
+func TestEqual(t *testing.T) {
+	report, err := Compare(compile(t, &cubicCircuit{}), compile(t, &cubicCircuit{}))
+	require.NoError(t, err)
+	require.True(t, report.Equal())
+	require.Equal(t, "constraints: 3 -> 3\n", report.String())
+}
+
+func TestCompare(t *testing.T) {
+	report, err := Compare(compile(t, &cubicCircuit{}), compile(t, &cubicCircuitV2{}))
+	require.NoError(t, err)
+	require.False(t, report.Equal())
+	require.Equal(t, []string{"Z"}, report.AddedPublic)
+	require.False(t, report.PublicReordered)
+
+	// AssertIsEqual has no debug information, it is in the namespace of Define
+	expected := `constraints: 3 -> 5
+public inputs: +Z
+namespace circuit: +1 -0
+  + Z == 2·X
+namespace diff.checkX: +1 -0
+  + (X - 3) · (-X + 4) == 0
+`
+	require.Equal(t, expected, report.String())
+
+	// and back
+	report, err = Compare(compile(t, &cubicCircuitV2{}), compile(t, &cubicCircuit{}))
+	require.NoError(t, err)
+	require.Equal(t, []string{"Z"}, report.RemovedPublic)
+	require.Len(t, report.Namespaces, 2)
+	require.Empty(t, report.Namespaces[0].Added)
+	require.Equal(t, []Constraint{{"Z == 2·X", 1}}, report.Namespaces[0].Removed)
+}
+
+type pairCircuit struct {
+	A frontend.Variable `gnark:",public"`
+	B frontend.Variable `gnark:",public"`
+}
+
+func (circuit *pairCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	cs.AssertIsEqual(circuit.A, cs.Mul(circuit.B, circuit.B))
+	return nil
+}
+
+type swappedCircuit struct {
+	B frontend.Variable `gnark:",public"`
+	A frontend.Variable `gnark:",public"`
+}
+
+func (circuit *swappedCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	cs.AssertIsEqual(circuit.A, cs.Mul(circuit.B, circuit.B))
+	return nil
+}
+
+// the same constraints, but another public witness
+func TestReordered(t *testing.T) {
+	report, err := Compare(compile(t, &pairCircuit{}), compile(t, &swappedCircuit{}))
+	require.NoError(t, err)
+	require.True(t, report.PublicReordered)
+	require.Empty(t, report.Namespaces)
+	require.False(t, report.Equal())
+}
+
+func TestUntyped(t *testing.T) {
+	untyped, err := frontend.Compile(gurvy.UNKNOWN, &cubicCircuit{})
+	require.NoError(t, err)
+	_, err = Compare(untyped, compile(t, &cubicCircuit{}))
+	require.Equal(t, errCurve, err)
+}
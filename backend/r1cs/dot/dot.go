@@ -0,0 +1,242 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package dot exports the dependency graph of a compiled R1CS in the DOT format of graphviz
+//
+// By default, the graph is collapsed by namespace: a node per group of inputs (the inputs of a
+// struct field, like Signature_R_X and Signature_R_Y, are in the group Signature_R) and a node per
+// gadget (the constraints of the function called by Define, eddsa.Verify for example, from the debug
+// information of the R1CS), with an edge per dependency, labelled with the number of wires:
+//
+//	r1cs, _ := frontend.Compile(gurvy.BN256, &circuit)
+//	f, _ := os.Create("circuit.dot")
+//	dot.Write(f, r1cs)
+//	// dot -Tsvg circuit.dot > circuit.svg
+//
+// WithConstraints draws a node per constraint instead, grouped in a cluster per gadget.
+package dot
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/consensys/gnark/backend/r1cs"
+	"github.com/consensys/gnark/backend/r1cs/internal/compiled"
+)
+
+var errCurve = errors.New("dot: unsupported R1CS")
+
+// Option configures Write
+type Option func(*config)
+
+type config struct {
+	constraints bool
+}
+
+// WithConstraints draws a node per input and per constraint, instead of a node per namespace
+func WithConstraints() Option {
+	return func(c *config) {
+		c.constraints = true
+	}
+}
+
+// Write writes the dependency graph of the R1CS to w, in the DOT format
+func Write(w io.Writer, _r1cs r1cs.R1CS, opts ...Option) error {
+	var config config
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	c, err := compiled.New(_r1cs)
+	if err != nil {
+		return errCurve
+	}
+
+	g := graph{c, c.Graph()}
+	bw := bufio.NewWriter(w)
+	if config.constraints {
+		g.writeConstraints(bw)
+	} else {
+		g.writeNamespaces(bw)
+	}
+	return bw.Flush()
+}
+
+type graph struct {
+	c *compiled.R1CS
+	*compiled.Graph
+}
+
+// inputGroup returns the group of an input: the name of its parent struct field
+func inputGroup(name string) string {
+	if i := strings.LastIndexByte(name, '_'); i > 0 {
+		return name[:i]
+	}
+	return name
+}
+
+func (g graph) writeNamespaces(w *bufio.Writer) {
+	c := g.c
+	w.WriteString("digraph circuit {\n\trankdir=LR;\n")
+
+	// nodes
+	type group struct {
+		name     string
+		public   bool
+		nbInputs int
+	}
+	groupIDs := make(map[string]int)
+	var groups []group
+	inputNode := make(map[int]string)
+	for wire := c.NbInternal(); wire < c.NbWires; wire++ {
+		if c.IsOne(wire) {
+			continue
+		}
+		key := inputGroup(c.Name(wire))
+		if c.IsPublic(wire) {
+			key = "public " + key
+		}
+		id, ok := groupIDs[key]
+		if !ok {
+			id = len(groups)
+			groupIDs[key] = id
+			groups = append(groups, group{name: inputGroup(c.Name(wire)), public: c.IsPublic(wire)})
+		}
+		groups[id].nbInputs++
+		inputNode[wire] = "in" + strconv.Itoa(id)
+	}
+	for id, gr := range groups {
+		visibility, style := "secret", ""
+		if gr.public {
+			visibility, style = "public", ", style=bold"
+		}
+		label := fmt.Sprintf("%s\n%s, %d input", gr.name, visibility, gr.nbInputs)
+		if gr.nbInputs > 1 {
+			label += "s"
+		}
+		fmt.Fprintf(w, "\tin%d [shape=box, label=%s%s];\n", id, strconv.Quote(label), style)
+	}
+
+	nsIDs := make(map[string]int)
+	var namespaces []string
+	nbConstraints := make(map[string]int)
+	for _, ns := range g.Namespaces {
+		if _, ok := nsIDs[ns]; !ok {
+			nsIDs[ns] = len(namespaces)
+			namespaces = append(namespaces, ns)
+		}
+		nbConstraints[ns]++
+	}
+	for id, ns := range namespaces {
+		label := fmt.Sprintf("%s\n%d constraint", ns, nbConstraints[ns])
+		if nbConstraints[ns] > 1 {
+			label += "s"
+		}
+		fmt.Fprintf(w, "\tns%d [label=%s];\n", id, strconv.Quote(label))
+	}
+
+	// edges, with the number of distinct wires
+	edges := make(map[[2]string]map[int]bool)
+	for i, reads := range g.Reads {
+		to := "ns" + strconv.Itoa(nsIDs[g.Namespaces[i]])
+		for _, wire := range reads {
+			var from string
+			if wire >= c.NbInternal() {
+				from = inputNode[wire]
+			} else if p := g.Producer[wire]; p != -1 {
+				from = "ns" + strconv.Itoa(nsIDs[g.Namespaces[p]])
+			}
+			if from == "" || from == to {
+				continue
+			}
+			edge := [2]string{from, to}
+			if edges[edge] == nil {
+				edges[edge] = make(map[int]bool)
+			}
+			edges[edge][wire] = true
+		}
+	}
+	keys := make([][2]string, 0, len(edges))
+	for edge := range edges {
+		keys = append(keys, edge)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+	for _, edge := range keys {
+		fmt.Fprintf(w, "\t%s -> %s [label=\"%d\"];\n", edge[0], edge[1], len(edges[edge]))
+	}
+	w.WriteString("}\n")
+}
+
+func (g graph) writeConstraints(w *bufio.Writer) {
+	c := g.c
+	w.WriteString("digraph circuit {\n\trankdir=LR;\n")
+
+	for wire := c.NbInternal(); wire < c.NbWires; wire++ {
+		if c.IsOne(wire) {
+			continue
+		}
+		style := ""
+		if c.IsPublic(wire) {
+			style = ", style=bold"
+		}
+		fmt.Fprintf(w, "\tw%d [shape=box, label=%s%s];\n", wire, strconv.Quote(c.Name(wire)), style)
+	}
+
+	// a cluster per namespace, in the order of the constraints
+	var namespaces []string
+	members := make(map[string][]int)
+	for i, ns := range g.Namespaces {
+		if _, ok := members[ns]; !ok {
+			namespaces = append(namespaces, ns)
+		}
+		members[ns] = append(members[ns], i)
+	}
+	for id, ns := range namespaces {
+		fmt.Fprintf(w, "\tsubgraph cluster_%d {\n\t\tlabel=%s;\n", id, strconv.Quote(ns))
+		for _, i := range members[ns] {
+			// computations are ellipses, assertions are diamonds
+			shape := "ellipse"
+			if i >= c.NbCOConstraints {
+				shape = "diamond"
+			}
+			fmt.Fprintf(w, "\t\tc%d [shape=%s, label=\"%d\"];\n", i, shape, i)
+		}
+		w.WriteString("\t}\n")
+	}
+
+	for i, reads := range g.Reads {
+		producers := make(map[int]bool)
+		for _, wire := range reads {
+			if wire >= c.NbInternal() {
+				fmt.Fprintf(w, "\tw%d -> c%d;\n", wire, i)
+			} else if p := g.Producer[wire]; p != -1 && !producers[p] {
+				producers[p] = true
+				fmt.Fprintf(w, "\tc%d -> c%d;\n", p, i)
+			}
+		}
+	}
+	w.WriteString("}\n")
+}
@@ -0,0 +1,118 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dot
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gurvy"
+	"github.com/stretchr/testify/require"
+)
+
+type point struct {
+	X, Y frontend.Variable
+}
+
+type boundCircuit struct {
+	P     point
+	Bound frontend.Variable `gnark:",public"`
+	Z     frontend.Variable `gnark:",public"`
+}
+
+func checkBound(cs *frontend.ConstraintSystem, p point, bound frontend.Variable) {
+	cs.AssertIsLessOrEqual(cs.Add(p.X, p.Y), bound)
+}
+
+func (circuit *boundCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	checkBound(cs, circuit.P, circuit.Bound)
+	cs.AssertIsEqual(circuit.Z, cs.Mul(circuit.P.X, circuit.P.Y))
+	return nil
+}
+
+func TestNamespaces(t *testing.T) {
+	_r1cs, err := frontend.Compile(gurvy.BN256, &boundCircuit{})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, _r1cs))
+	expected := `digraph circuit {
+	rankdir=LR;
+	in0 [shape=box, label="P\nsecret, 2 inputs"];
+	in1 [shape=box, label="Bound\npublic, 1 input", style=bold];
+	in2 [shape=box, label="Z\npublic, 1 input", style=bold];
+	ns0 [label="dot.checkBound\n1512 constraints"];
+	ns1 [label="circuit\n2 constraints"];
+	in0 -> ns0 [label="2"];
+	in0 -> ns1 [label="2"];
+	in1 -> ns0 [label="1"];
+	in2 -> ns1 [label="1"];
+}
+`
+	require.Equal(t, expected, buf.String())
+}
+
+type namespacedCircuit boundCircuit
+
+func (circuit *namespacedCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	cs.Namespace("bound", func(cs *frontend.ConstraintSystem) {
+		checkBound(cs, circuit.P, circuit.Bound)
+	})
+	cs.Namespace("product", func(cs *frontend.ConstraintSystem) {
+		cs.Namespace("xy", func(cs *frontend.ConstraintSystem) {
+			cs.AssertIsEqual(circuit.Z, cs.Mul(circuit.P.X, circuit.P.Y))
+		})
+	})
+	return nil
+}
+
+func TestFrontendNamespaces(t *testing.T) {
+	_r1cs, err := frontend.Compile(gurvy.BN256, &namespacedCircuit{})
+	require.NoError(t, err)
+
+	// the namespaces of the frontend replace the gadgets
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, _r1cs))
+	out := buf.String()
+	require.Contains(t, out, `[label="bound\n1512 constraints"];`)
+	require.Contains(t, out, `[label="product/xy\n2 constraints"];`)
+	require.NotContains(t, out, "checkBound")
+}
+
+func TestConstraints(t *testing.T) {
+	_r1cs, err := frontend.Compile(gurvy.BN256, &boundCircuit{})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, _r1cs, WithConstraints()))
+	out := buf.String()
+	require.True(t, strings.HasPrefix(out, "digraph circuit {\n"))
+	require.Contains(t, out, `[shape=box, label="P_X"];`)
+	require.Contains(t, out, `[shape=box, label="Bound", style=bold];`)
+	require.Contains(t, out, `subgraph cluster_0 {`)
+	require.Contains(t, out, `label="dot.checkBound";`)
+	require.Contains(t, out, `label="circuit";`)
+	require.Equal(t, int(_r1cs.GetNbConstraints()), strings.Count(out, "\t\tc"))
+}
+
+func TestUntyped(t *testing.T) {
+	_r1cs, err := frontend.Compile(gurvy.UNKNOWN, &boundCircuit{})
+	require.NoError(t, err)
+	require.Equal(t, errCurve, Write(&bytes.Buffer{}, _r1cs))
+}
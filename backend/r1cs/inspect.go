@@ -0,0 +1,207 @@
+// Copyright 2020 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package r1cs
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/r1cs/r1c"
+	backend_bls377 "github.com/consensys/gnark/internal/backend/bls377"
+	backend_bls381 "github.com/consensys/gnark/internal/backend/bls381"
+	backend_bn256 "github.com/consensys/gnark/internal/backend/bn256"
+	backend_bw761 "github.com/consensys/gnark/internal/backend/bw761"
+	"github.com/consensys/gurvy"
+	fr_bls377 "github.com/consensys/gurvy/bls377/fr"
+	fr_bls381 "github.com/consensys/gurvy/bls381/fr"
+	fr_bn256 "github.com/consensys/gurvy/bn256/fr"
+	fr_bw761 "github.com/consensys/gurvy/bw761/fr"
+)
+
+// ErrUnsupported is returned by NewInspector for an unknown implementation of R1CS
+var ErrUnsupported = errors.New("unsupported R1CS")
+
+// Wire describes a wire of a compiled circuit
+type Wire struct {
+	ID         int
+	Visibility backend.Visibility // backend.Internal, backend.Secret or backend.Public
+	Name       string             // name of the input, or "" for an internal wire
+}
+
+// Term is a term Coeff * Wire of a linear expression
+type Term struct {
+	Wire  int
+	Coeff big.Int
+}
+
+// Constraint is a rank-1 constraint L * R == O
+type Constraint struct {
+	L, R, O []Term
+
+	// Computational is set for the constraints computing a wire when solving the circuit, the others
+	// are assertions
+	Computational bool
+}
+
+// Inspector is a read-only view of the wires and constraints of a compiled circuit, for the tools
+// analyzing, visualizing or transpiling it
+//
+// wires are [internal | secret | public]; the constant wire backend.OneWire is a public wire
+type Inspector struct {
+	curveID         gurvy.ID
+	modulus         *big.Int // nil for an UntypedR1CS
+	nbWires         int
+	nbPublicWires   int
+	nbSecretWires   int
+	publicWires     []string
+	secretWires     []string
+	nbCOConstraints int
+	constraints     []r1c.R1C
+	coefficients    []big.Int
+	hints           []r1c.Hint
+}
+
+// NewInspector returns a view of a R1CS returned by frontend.Compile
+//
+// the coefficients of a typed R1CS are reduced modulo the field; those of an UntypedR1CS are the
+// coefficients recorded by the frontend
+func NewInspector(_r1cs R1CS) (*Inspector, error) {
+	in := Inspector{curveID: _r1cs.GetCurveID()}
+	switch _r1cs := _r1cs.(type) {
+	case *UntypedR1CS:
+		in.set(_r1cs.NbWires, _r1cs.NbPublicWires, _r1cs.NbSecretWires, _r1cs.PublicWires, _r1cs.SecretWires, _r1cs.NbCOConstraints, _r1cs.Constraints, _r1cs.Hints)
+		in.coefficients = make([]big.Int, _r1cs.Coefficients.Len())
+		for i := range in.coefficients {
+			_r1cs.Coefficients.Get(i, &in.coefficients[i])
+		}
+	case *backend_bn256.R1CS:
+		in.modulus = fr_bn256.Modulus()
+		in.set(_r1cs.NbWires, _r1cs.NbPublicWires, _r1cs.NbSecretWires, _r1cs.PublicWires, _r1cs.SecretWires, _r1cs.NbCOConstraints, _r1cs.Constraints, _r1cs.Hints)
+		in.coefficients = make([]big.Int, len(_r1cs.Coefficients))
+		for i := range in.coefficients {
+			_r1cs.Coefficients[i].ToBigIntRegular(&in.coefficients[i])
+		}
+	case *backend_bls377.R1CS:
+		in.modulus = fr_bls377.Modulus()
+		in.set(_r1cs.NbWires, _r1cs.NbPublicWires, _r1cs.NbSecretWires, _r1cs.PublicWires, _r1cs.SecretWires, _r1cs.NbCOConstraints, _r1cs.Constraints, _r1cs.Hints)
+		in.coefficients = make([]big.Int, len(_r1cs.Coefficients))
+		for i := range in.coefficients {
+			_r1cs.Coefficients[i].ToBigIntRegular(&in.coefficients[i])
+		}
+	case *backend_bls381.R1CS:
+		in.modulus = fr_bls381.Modulus()
+		in.set(_r1cs.NbWires, _r1cs.NbPublicWires, _r1cs.NbSecretWires, _r1cs.PublicWires, _r1cs.SecretWires, _r1cs.NbCOConstraints, _r1cs.Constraints, _r1cs.Hints)
+		in.coefficients = make([]big.Int, len(_r1cs.Coefficients))
+		for i := range in.coefficients {
+			_r1cs.Coefficients[i].ToBigIntRegular(&in.coefficients[i])
+		}
+	case *backend_bw761.R1CS:
+		in.modulus = fr_bw761.Modulus()
+		in.set(_r1cs.NbWires, _r1cs.NbPublicWires, _r1cs.NbSecretWires, _r1cs.PublicWires, _r1cs.SecretWires, _r1cs.NbCOConstraints, _r1cs.Constraints, _r1cs.Hints)
+		in.coefficients = make([]big.Int, len(_r1cs.Coefficients))
+		for i := range in.coefficients {
+			_r1cs.Coefficients[i].ToBigIntRegular(&in.coefficients[i])
+		}
+	default:
+		return nil, ErrUnsupported
+	}
+	return &in, nil
+}
+
+func (in *Inspector) set(nbWires, nbPublicWires, nbSecretWires uint64, publicWires, secretWires []string, nbCOConstraints uint64, constraints []r1c.R1C, hints []r1c.Hint) {
+	in.nbWires, in.nbPublicWires, in.nbSecretWires = int(nbWires), int(nbPublicWires), int(nbSecretWires)
+	in.publicWires, in.secretWires = publicWires, secretWires
+	in.nbCOConstraints, in.constraints = int(nbCOConstraints), constraints
+	in.hints = hints
+}
+
+// CurveID returns the curve of the R1CS, or gurvy.UNKNOWN for an UntypedR1CS
+func (in *Inspector) CurveID() gurvy.ID {
+	return in.curveID
+}
+
+// NbWires returns the number of wires, including the constant wire
+func (in *Inspector) NbWires() int {
+	return in.nbWires
+}
+
+// Wire returns the description of the wire id
+func (in *Inspector) Wire(id int) Wire {
+	nbInternal := in.nbWires - in.nbPublicWires - in.nbSecretWires
+	switch {
+	case id >= in.nbWires-in.nbPublicWires:
+		return Wire{ID: id, Visibility: backend.Public, Name: in.publicWires[id-in.nbWires+in.nbPublicWires]}
+	case id >= nbInternal:
+		return Wire{ID: id, Visibility: backend.Secret, Name: in.secretWires[id-nbInternal]}
+	}
+	return Wire{ID: id, Visibility: backend.Internal}
+}
+
+// IsHint returns true if the wire id is computed by a hint function, rather than by a constraint
+func (in *Inspector) IsHint(id int) bool {
+	for i := range in.hints {
+		for _, w := range in.hints[i].Outputs() {
+			if w == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// NbConstraints returns the number of constraints
+func (in *Inspector) NbConstraints() int {
+	return len(in.constraints)
+}
+
+// Constraint returns the constraint i; the computational constraints come first
+//
+// the terms are copies, they can be modified without modifying the R1CS
+func (in *Inspector) Constraint(i int) Constraint {
+	r := &in.constraints[i]
+	return Constraint{
+		L:             in.terms(r.L),
+		R:             in.terms(r.R),
+		O:             in.terms(r.O),
+		Computational: i < in.nbCOConstraints,
+	}
+}
+
+// Constraints calls f on each constraint, in order, until f returns false
+func (in *Inspector) Constraints(f func(i int, c Constraint) bool) {
+	for i := range in.constraints {
+		if !f(i, in.Constraint(i)) {
+			return
+		}
+	}
+}
+
+func (in *Inspector) terms(l r1c.LinearExpression) []Term {
+	res := make([]Term, len(l))
+	for i, t := range l {
+		res[i].Wire = t.VariableID()
+		switch v := t.CoeffValue(); v {
+		case -1, 0, 1, 2:
+			res[i].Coeff.SetInt64(int64(v))
+			if in.modulus != nil {
+				res[i].Coeff.Mod(&res[i].Coeff, in.modulus)
+			}
+		default:
+			res[i].Coeff.Set(&in.coefficients[t.CoeffID()])
+		}
+	}
+	return res
+}
@@ -0,0 +1,88 @@
+// Copyright 2020 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package r1cs_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/r1cs"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gurvy"
+	fr_bn256 "github.com/consensys/gurvy/bn256/fr"
+)
+
+type inspectCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (c *inspectCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	cs.AssertIsEqual(cs.Sub(cs.Mul(c.X, c.X), c.X), c.Y)
+	return nil
+}
+
+func TestInspector(t *testing.T) {
+	for _, curveID := range []gurvy.ID{gurvy.UNKNOWN, gurvy.BN256} {
+		compiled, err := frontend.Compile(curveID, &inspectCircuit{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		in, err := r1cs.NewInspector(compiled)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if in.CurveID() != curveID {
+			t.Fatal("unexpected curve", in.CurveID())
+		}
+
+		names := make(map[string]backend.Visibility)
+		for id := 0; id < in.NbWires(); id++ {
+			if w := in.Wire(id); w.Visibility != backend.Internal {
+				names[w.Name] = w.Visibility
+			}
+		}
+		if names["X"] != backend.Secret || names["Y"] != backend.Public || names[backend.OneWire] != backend.Public || len(names) != 3 {
+			t.Fatal("unexpected inputs", names)
+		}
+
+		// the coefficient -1 of X in the assertion is p-1 in the field
+		minusOne := big.NewInt(-1)
+		if curveID != gurvy.UNKNOWN {
+			minusOne.Add(minusOne, fr_bn256.Modulus())
+		}
+		if uint64(in.NbConstraints()) != compiled.GetNbConstraints() {
+			t.Fatal("unexpected number of constraints", in.NbConstraints())
+		}
+		found := false
+		in.Constraints(func(i int, c r1cs.Constraint) bool {
+			if c.Computational {
+				return true
+			}
+			for _, l := range [][]r1cs.Term{c.L, c.R, c.O} {
+				for _, term := range l {
+					if in.Wire(term.Wire).Name == "X" && term.Coeff.Cmp(minusOne) == 0 {
+						found = true
+					}
+				}
+			}
+			return !found
+		})
+		if !found {
+			t.Fatal("expected an assertion with the term -X")
+		}
+	}
+}
@@ -0,0 +1,267 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package compiled is a curve independent view of the typed R1CS, for the tools analyzing circuits
+// (lint, dot, diff)
+package compiled
+
+import (
+	"errors"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/r1cs"
+	"github.com/consensys/gnark/backend/r1cs/r1c"
+	backend_bls377 "github.com/consensys/gnark/internal/backend/bls377"
+	backend_bls381 "github.com/consensys/gnark/internal/backend/bls381"
+	backend_bn256 "github.com/consensys/gnark/internal/backend/bn256"
+	backend_bw761 "github.com/consensys/gnark/internal/backend/bw761"
+	"github.com/consensys/gurvy"
+	fr_bls377 "github.com/consensys/gurvy/bls377/fr"
+	fr_bls381 "github.com/consensys/gurvy/bls381/fr"
+	fr_bn256 "github.com/consensys/gurvy/bn256/fr"
+	fr_bw761 "github.com/consensys/gurvy/bw761/fr"
+)
+
+// ErrUnsupported is returned by New for an untyped R1CS
+var ErrUnsupported = errors.New("unsupported R1CS")
+
+// R1CS is the content of a typed R1CS, with the coefficients as big.Int
+//
+// wires are [internal | secret | public], with backend.OneWire in public
+type R1CS struct {
+	CurveID         gurvy.ID
+	Modulus         *big.Int
+	NbWires         int
+	NbPublicWires   int
+	NbSecretWires   int
+	PublicWires     []string
+	SecretWires     []string
+	NbCOConstraints int
+	Constraints     []r1c.R1C
+	Coefficients    []big.Int
+	Logs, DebugInfo []backend.LogEntry
+	Hints           []r1c.Hint
+	Namespaces      []r1c.Namespace
+}
+
+// New returns the view of a typed R1CS
+func New(_r1cs r1cs.R1CS) (*R1CS, error) {
+	var c R1CS
+	switch _r1cs := _r1cs.(type) {
+	case *backend_bn256.R1CS:
+		c = R1CS{gurvy.BN256, fr_bn256.Modulus(), int(_r1cs.NbWires), int(_r1cs.NbPublicWires), int(_r1cs.NbSecretWires), _r1cs.PublicWires, _r1cs.SecretWires,
+			int(_r1cs.NbCOConstraints), _r1cs.Constraints, make([]big.Int, len(_r1cs.Coefficients)), _r1cs.Logs, _r1cs.DebugInfo, _r1cs.Hints, _r1cs.Namespaces}
+		for i := 0; i < len(_r1cs.Coefficients); i++ {
+			_r1cs.Coefficients[i].ToBigIntRegular(&c.Coefficients[i])
+		}
+	case *backend_bls377.R1CS:
+		c = R1CS{gurvy.BLS377, fr_bls377.Modulus(), int(_r1cs.NbWires), int(_r1cs.NbPublicWires), int(_r1cs.NbSecretWires), _r1cs.PublicWires, _r1cs.SecretWires,
+			int(_r1cs.NbCOConstraints), _r1cs.Constraints, make([]big.Int, len(_r1cs.Coefficients)), _r1cs.Logs, _r1cs.DebugInfo, _r1cs.Hints, _r1cs.Namespaces}
+		for i := 0; i < len(_r1cs.Coefficients); i++ {
+			_r1cs.Coefficients[i].ToBigIntRegular(&c.Coefficients[i])
+		}
+	case *backend_bls381.R1CS:
+		c = R1CS{gurvy.BLS381, fr_bls381.Modulus(), int(_r1cs.NbWires), int(_r1cs.NbPublicWires), int(_r1cs.NbSecretWires), _r1cs.PublicWires, _r1cs.SecretWires,
+			int(_r1cs.NbCOConstraints), _r1cs.Constraints, make([]big.Int, len(_r1cs.Coefficients)), _r1cs.Logs, _r1cs.DebugInfo, _r1cs.Hints, _r1cs.Namespaces}
+		for i := 0; i < len(_r1cs.Coefficients); i++ {
+			_r1cs.Coefficients[i].ToBigIntRegular(&c.Coefficients[i])
+		}
+	case *backend_bw761.R1CS:
+		c = R1CS{gurvy.BW761, fr_bw761.Modulus(), int(_r1cs.NbWires), int(_r1cs.NbPublicWires), int(_r1cs.NbSecretWires), _r1cs.PublicWires, _r1cs.SecretWires,
+			int(_r1cs.NbCOConstraints), _r1cs.Constraints, make([]big.Int, len(_r1cs.Coefficients)), _r1cs.Logs, _r1cs.DebugInfo, _r1cs.Hints, _r1cs.Namespaces}
+		for i := 0; i < len(_r1cs.Coefficients); i++ {
+			_r1cs.Coefficients[i].ToBigIntRegular(&c.Coefficients[i])
+		}
+	default:
+		return nil, ErrUnsupported
+	}
+	return &c, nil
+}
+
+// NbInternal returns the number of internal wires
+func (c *R1CS) NbInternal() int {
+	return c.NbWires - c.NbPublicWires - c.NbSecretWires
+}
+
+// IsPublic returns true if wire is a public input (or the constant wire)
+func (c *R1CS) IsPublic(wire int) bool {
+	return wire >= c.NbWires-c.NbPublicWires
+}
+
+// IsOne returns true if wire is the constant wire
+func (c *R1CS) IsOne(wire int) bool {
+	return c.IsPublic(wire) && c.PublicWires[wire-c.NbWires+c.NbPublicWires] == backend.OneWire
+}
+
+// Name returns the name of an input wire, or "" for an internal wire
+func (c *R1CS) Name(wire int) string {
+	switch {
+	case c.IsPublic(wire):
+		return c.PublicWires[wire-c.NbWires+c.NbPublicWires]
+	case wire >= c.NbInternal():
+		return c.SecretWires[wire-c.NbInternal()]
+	}
+	return ""
+}
+
+// Coeff returns the coefficient of a term, reduced modulo the field
+func (c *R1CS) Coeff(t r1c.Term) big.Int {
+	var res big.Int
+	switch v := t.CoeffValue(); v {
+	case -1, 0, 1, 2:
+		res.SetInt64(int64(v))
+	default:
+		res.Set(&c.Coefficients[t.CoeffID()])
+	}
+	return *res.Mod(&res, c.Modulus)
+}
+
+// Wires returns the wires of a constraint, without the constant wire
+func (c *R1CS) Wires(r *r1c.R1C) []int {
+	var res []int
+	for _, l := range []r1c.LinearExpression{r.L, r.R, r.O} {
+		for _, t := range l {
+			if w := t.VariableID(); !c.IsOne(w) {
+				res = append(res, w)
+			}
+		}
+	}
+	return res
+}
+
+// Location returns the location in Define of an assertion (the last frame of its debug information),
+// or ""
+func (c *R1CS) Location(constraint int) string {
+	lines := c.callStack(constraint)
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+// callStack returns the lines of the call stack in the debug information of an assertion, a list of
+// "function\n\tfile:line" from the frontend up to Define
+func (c *R1CS) callStack(constraint int) []string {
+	i := constraint - c.NbCOConstraints
+	if i < 0 || i >= len(c.DebugInfo) {
+		return nil
+	}
+	lines := strings.Split(c.DebugInfo[i].Format, "\n")
+	return lines[1:]
+}
+
+// namespace returns the namespace the frontend recorded a constraint in (see r1c.Namespace), or the
+// gadget of an assertion from its debug information (the function called by Define, or Define), or ""
+func (c *R1CS) namespace(constraint int) string {
+	if i := sort.Search(len(c.Namespaces), func(i int) bool { return c.Namespaces[i].Constraint > constraint }); i > 0 {
+		if name := c.Namespaces[i-1].Name; name != "" {
+			return name
+		}
+	}
+	var functions []string
+	for _, line := range c.callStack(constraint) {
+		if line != "" && !strings.HasPrefix(line, "\t") && !strings.HasPrefix(line, "frontend.") {
+			functions = append(functions, line)
+		}
+	}
+	switch len(functions) {
+	case 0:
+		return ""
+	case 1:
+		return functions[0]
+	}
+	return functions[len(functions)-2]
+}
+
+// Graph is the dependency graph of the constraints
+type Graph struct {
+	Reads      [][]int  // wires read by each constraint (without the constant wire)
+	Producer   []int    // constraint computing each internal wire, or -1 (for the wires computed by a hint)
+	Namespaces []string // of each constraint
+}
+
+// Graph returns the dependency graph of the constraints
+//
+// the namespace of a constraint is the namespace it was recorded in (see frontend.ConstraintSystem.Namespace),
+// or the gadget it was recorded in (the function called by Define, from the debug information of the
+// assertions); a computational constraint belongs to the namespace of the
+// first constraint using its result, and an assertion without debug information to the namespace of the
+// computation it checks. The remaining constraints are in the namespace "circuit".
+func (c *R1CS) Graph() *Graph {
+	g := &Graph{
+		Reads:      make([][]int, len(c.Constraints)),
+		Producer:   make([]int, c.NbInternal()),
+		Namespaces: make([]string, len(c.Constraints)),
+	}
+	for i := range g.Producer {
+		g.Producer[i] = -1
+	}
+	// the wires computed by a hint have no producer
+	hints := make(map[int]bool, len(c.Hints))
+	for _, h := range c.Hints {
+		hints[h.Wire] = true
+	}
+	for i := range c.Constraints {
+		r := &c.Constraints[i]
+		seen := make(map[int]bool)
+		for _, l := range []r1c.LinearExpression{r.L, r.R, r.O} {
+			for _, t := range l {
+				w := t.VariableID()
+				if c.IsOne(w) || seen[w] {
+					continue
+				}
+				seen[w] = true
+				// the internal wires a computational constraint uses first are the ones it computes
+				if w < c.NbInternal() && g.Producer[w] == -1 && i < c.NbCOConstraints && !hints[w] {
+					g.Producer[w] = i
+					continue
+				}
+				g.Reads[i] = append(g.Reads[i], w)
+			}
+		}
+		g.Namespaces[i] = c.namespace(i)
+	}
+
+	consumers := make([][]int, len(c.Constraints))
+	for i, reads := range g.Reads {
+		for _, w := range reads {
+			if w < c.NbInternal() && g.Producer[w] != -1 {
+				consumers[g.Producer[w]] = append(consumers[g.Producer[w]], i)
+			}
+		}
+	}
+	for i := c.NbCOConstraints - 1; i >= 0; i-- {
+		for _, j := range consumers[i] {
+			if g.Namespaces[i] == "" {
+				g.Namespaces[i] = g.Namespaces[j]
+			}
+		}
+	}
+	for i := range c.Constraints {
+		for _, w := range g.Reads[i] {
+			if g.Namespaces[i] == "" && w < c.NbInternal() && g.Producer[w] != -1 {
+				g.Namespaces[i] = g.Namespaces[g.Producer[w]]
+			}
+		}
+		if g.Namespaces[i] == "" {
+			g.Namespaces[i] = "circuit"
+		}
+	}
+	return g
+}
@@ -0,0 +1,303 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package lint looks for the patterns of under-constrained circuits in a compiled R1CS
+//
+// The solver computes the internal wires with Go code (a division, a binary decomposition...), but a
+// proof only shows that the constraints are satisfied: a wire the constraints don't determine can take
+// any value, and a proof can be forged. The linter flags:
+//
+//	UnusedInput             an input in no constraint: a proof verifies with any value of the input
+//	UnusedWire              a computed wire in no other constraint: the result of an operation
+//	                        is not asserted (a missing AssertIsEqual); or the result of a hint
+//	                        in no constraint
+//	UnconstrainedBit        a bit of a binary decomposition without a boolean constraint: the
+//	                        decomposition is not unique
+//	AmbiguousDecomposition  a binary decomposition on as many bits as the field: a value has several
+//	                        decompositions (v and v+p), so a comparison on the bits is not sound
+//	NotBoundToPublic        assertions on wires which don't depend on a public input: the proof
+//	                        doesn't prove anything about the statement
+//
+// The findings are hints for an audit, not proofs of a bug:
+//
+//	r1cs, _ := frontend.Compile(gurvy.BN256, &circuit)
+//	findings, _ := lint.Lint(r1cs)
+//	for _, f := range findings {
+//		fmt.Println(f)
+//	}
+package lint
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/consensys/gnark/backend/r1cs"
+	"github.com/consensys/gnark/backend/r1cs/internal/compiled"
+	"github.com/consensys/gnark/backend/r1cs/r1c"
+)
+
+var errCurve = errors.New("lint: unsupported R1CS")
+
+// Kind is the pattern of a Finding
+type Kind int
+
+// patterns of under-constrained circuits
+const (
+	UnusedInput Kind = iota
+	UnusedWire
+	UnconstrainedBit
+	AmbiguousDecomposition
+	NotBoundToPublic
+)
+
+func (k Kind) String() string {
+	switch k {
+	case UnusedInput:
+		return "unused input"
+	case UnusedWire:
+		return "unused wire"
+	case UnconstrainedBit:
+		return "unconstrained bit"
+	case AmbiguousDecomposition:
+		return "ambiguous decomposition"
+	case NotBoundToPublic:
+		return "not bound to public"
+	default:
+		return "unknown"
+	}
+}
+
+// Finding is a suspicious wire or constraint of a R1CS
+type Finding struct {
+	Kind       Kind
+	Constraint int    // index of the constraint in R1CS.Constraints, or -1
+	Wire       string // name of the input, or "" for an internal wire
+	Location   string // file:line in Define, when the R1CS has the debug information
+	Message    string
+}
+
+func (f Finding) String() string {
+	var sb strings.Builder
+	sb.WriteString(f.Kind.String())
+	if f.Location != "" {
+		sb.WriteString(" (" + f.Location + ")")
+	}
+	sb.WriteString(": " + f.Message)
+	return sb.String()
+}
+
+// Lint returns the findings of the checks on a compiled R1CS, ordered by kind
+func Lint(_r1cs r1cs.R1CS) ([]Finding, error) {
+	c, err := compiled.New(_r1cs)
+	if err != nil {
+		return nil, errCurve
+	}
+	return linter{c}.lint(), nil
+}
+
+// linter runs the checks on a R1CS
+type linter struct {
+	*compiled.R1CS
+}
+
+// booleanWire returns the wire of a constraint b·(1-b) == 0 (or (1-b)·b == 0), or -1
+func (c linter) booleanWire(r *r1c.R1C) int {
+	for _, o := range r.O {
+		if v := c.Coeff(o); v.Sign() != 0 {
+			return -1
+		}
+	}
+	check := func(b, oneMinusB r1c.LinearExpression) int {
+		if len(b) != 1 || len(oneMinusB) != 2 || c.IsOne(b[0].VariableID()) {
+			return -1
+		}
+		wire := b[0].VariableID()
+		var k, one, minusK big.Int
+		k = c.Coeff(b[0]) // k·b·(1-b) == 0 is also a boolean constraint
+		if k.Sign() == 0 {
+			return -1
+		}
+		one.SetInt64(1)
+		minusK.Sub(c.Modulus, big.NewInt(1))
+		foundOne, foundB := false, false
+		for _, t := range oneMinusB {
+			v := c.Coeff(t)
+			switch {
+			case c.IsOne(t.VariableID()) && v.Cmp(&one) == 0:
+				foundOne = true
+			case t.VariableID() == wire && v.Cmp(&minusK) == 0:
+				foundB = true
+			}
+		}
+		if foundOne && foundB {
+			return wire
+		}
+		return -1
+	}
+	if w := check(r.L, r.R); w != -1 {
+		return w
+	}
+	return check(r.R, r.L)
+}
+
+func (c linter) lint() []Finding {
+	// constraints of each wire
+	uses := make([][]int, c.NbWires)
+	for i := range c.Constraints {
+		for _, w := range c.Wires(&c.Constraints[i]) {
+			if n := len(uses[w]); n == 0 || uses[w][n-1] != i {
+				uses[w] = append(uses[w], i)
+			}
+		}
+	}
+	// locations of the wires: the location of their first assertion
+	location := func(wire int) string {
+		for _, i := range uses[wire] {
+			if l := c.Location(i); l != "" {
+				return l
+			}
+		}
+		return ""
+	}
+	describe := func(wire int) string {
+		if name := c.Name(wire); name != "" {
+			return name
+		}
+		return fmt.Sprintf("internal wire %d", wire)
+	}
+	booleans := make([]bool, c.NbWires)
+	for i := c.NbCOConstraints; i < len(c.Constraints); i++ {
+		if w := c.booleanWire(&c.Constraints[i]); w != -1 {
+			booleans[w] = true
+		}
+	}
+
+	// the wires computed by a hint are in no computational constraint solving them
+	hints := make(map[int]bool, len(c.Hints))
+	for i := range c.Hints {
+		for _, w := range c.Hints[i].Outputs() {
+			hints[w] = true
+		}
+	}
+
+	var unusedInputs, unusedWires, bits, decompositions, unbound []Finding
+
+	for w := 0; w < c.NbWires; w++ {
+		if c.IsOne(w) {
+			continue
+		}
+		if w >= c.NbInternal() {
+			if len(uses[w]) == 0 {
+				msg := fmt.Sprintf("secret input %s is in no constraint", c.Name(w))
+				if c.IsPublic(w) {
+					msg = fmt.Sprintf("public input %s is in no constraint: a proof verifies with any value", c.Name(w))
+				}
+				unusedInputs = append(unusedInputs, Finding{Kind: UnusedInput, Constraint: -1, Wire: c.Name(w), Message: msg})
+			}
+		} else if hints[w] {
+			if len(uses[w]) == 0 {
+				unusedWires = append(unusedWires, Finding{Kind: UnusedWire, Constraint: -1,
+					Message: fmt.Sprintf("%s is computed by a hint, and is in no constraint", describe(w))})
+			}
+		} else if len(uses[w]) == 1 {
+			// the only constraint of a computed wire is the one solving it
+			unusedWires = append(unusedWires, Finding{Kind: UnusedWire, Constraint: uses[w][0], Location: location(w),
+				Message: fmt.Sprintf("%s is computed by constraint %d, and asserted by no other constraint", describe(w), uses[w][0])})
+		}
+	}
+
+	for i := 0; i < c.NbCOConstraints; i++ {
+		r := &c.Constraints[i]
+		if r.Solver != r1c.BinaryDec {
+			continue
+		}
+		nbBits := 0
+		for _, t := range r.L {
+			if c.IsOne(t.VariableID()) {
+				continue
+			}
+			nbBits++
+			if w := t.VariableID(); !booleans[w] {
+				bits = append(bits, Finding{Kind: UnconstrainedBit, Constraint: i, Location: location(w),
+					Message: fmt.Sprintf("bit %s of the decomposition of constraint %d is not constrained to be 0 or 1", describe(w), i)})
+			}
+		}
+		if nbBits >= c.Modulus.BitLen() {
+			loc := ""
+			for _, t := range r.L {
+				if loc = location(t.VariableID()); loc != "" {
+					break
+				}
+			}
+			decompositions = append(decompositions, Finding{Kind: AmbiguousDecomposition, Constraint: i, Location: loc,
+				Message: fmt.Sprintf("constraint %d decomposes a value on %d bits, the field has %d bits: the decomposition is not unique", i, nbBits, c.Modulus.BitLen())})
+		}
+	}
+
+	// connected components of the wires, through the constraints
+	parent := make([]int, c.NbWires)
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(w int) int {
+		if parent[w] != w {
+			parent[w] = find(parent[w])
+		}
+		return parent[w]
+	}
+	for i := range c.Constraints {
+		wires := c.Wires(&c.Constraints[i])
+		for _, w := range wires[min(1, len(wires)):] {
+			parent[find(w)] = find(wires[0])
+		}
+	}
+	public := make(map[int]bool)
+	for w := c.NbWires - c.NbPublicWires; w < c.NbWires; w++ {
+		if !c.IsOne(w) {
+			public[find(w)] = true
+		}
+	}
+	reported := make(map[int]bool)
+	for i := c.NbCOConstraints; i < len(c.Constraints); i++ {
+		wires := c.Wires(&c.Constraints[i])
+		if len(wires) == 0 {
+			continue
+		}
+		root := find(wires[0])
+		if public[root] || reported[root] {
+			continue
+		}
+		reported[root] = true
+		unbound = append(unbound, Finding{Kind: NotBoundToPublic, Constraint: i, Location: c.Location(i),
+			Message: fmt.Sprintf("constraint %d (and the constraints sharing its wires) depends on no public input", i)})
+	}
+
+	var res []Finding
+	for _, findings := range [][]Finding{unusedInputs, unusedWires, bits, decompositions, unbound} {
+		res = append(res, findings...)
+	}
+	return res
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,192 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lint
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/hint"
+	"github.com/consensys/gnark/backend/r1cs"
+	"github.com/consensys/gnark/backend/r1cs/r1c"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gurvy"
+	"github.com/stretchr/testify/require"
+)
+
+func lint(t *testing.T, circuit frontend.Circuit) []Finding {
+	_r1cs, err := frontend.Compile(gurvy.BN256, circuit)
+	require.NoError(t, err)
+	findings, err := Lint(_r1cs)
+	require.NoError(t, err)
+	return findings
+}
+
+func kinds(findings []Finding) []Kind {
+	res := make([]Kind, len(findings))
+	for i, f := range findings {
+		res[i] = f.Kind
+	}
+	return res
+}
+
+type cubicCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (circuit *cubicCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	x3 := cs.Mul(circuit.X, circuit.X, circuit.X)
+	cs.AssertIsEqual(circuit.Y, cs.Add(x3, circuit.X, 5))
+	return nil
+}
+
+func TestSound(t *testing.T) {
+	require.Empty(t, lint(t, &cubicCircuit{}))
+}
+
+type unusedCircuit struct {
+	X, Unused frontend.Variable
+	Y         frontend.Variable `gnark:",public"`
+}
+
+func (circuit *unusedCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	cs.Mul(circuit.X, circuit.Y) // not asserted
+	cs.AssertIsEqual(circuit.Y, cs.Mul(circuit.X, circuit.X))
+	return nil
+}
+
+func TestUnused(t *testing.T) {
+	findings := lint(t, &unusedCircuit{})
+	require.Equal(t, []Kind{UnusedInput, UnusedWire}, kinds(findings))
+	require.Equal(t, "Unused", findings[0].Wire)
+	require.Equal(t, "", findings[1].Wire)
+}
+
+type hintCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (circuit *hintCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	cs.NewHint(hint.IsZero, circuit.X) // not constrained
+	z := cs.NewHint(hint.IsZero, circuit.Y)
+	cs.AssertIsEqual(cs.Mul(z, circuit.X), circuit.Y)
+	return nil
+}
+
+func TestHint(t *testing.T) {
+	findings := lint(t, &hintCircuit{})
+	require.Equal(t, []Kind{UnusedWire}, kinds(findings))
+	require.Equal(t, -1, findings[0].Constraint)
+	require.Contains(t, findings[0].Message, "hint")
+}
+
+type decompositionCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (circuit *decompositionCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	cs.ToBinary(circuit.X, 256)
+	cs.ToBinary(circuit.Y, 256)
+	return nil
+}
+
+type lessOrEqualCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (circuit *lessOrEqualCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	cs.AssertIsLessOrEqual(circuit.X, circuit.Y)
+	return nil
+}
+
+// ambiguousDecompositions returns the AmbiguousDecomposition findings of circuit
+func ambiguousDecompositions(t *testing.T, circuit frontend.Circuit) []Finding {
+	var decompositions []Finding
+	for _, f := range lint(t, circuit) {
+		if f.Kind == AmbiguousDecomposition {
+			decompositions = append(decompositions, f)
+		}
+	}
+	return decompositions
+}
+
+func TestAmbiguousDecomposition(t *testing.T) {
+	// 256 bits decompositions of X and Y, on a 254 bits field
+	decompositions := ambiguousDecompositions(t, &decompositionCircuit{})
+	require.Len(t, decompositions, 2)
+	require.Contains(t, decompositions[0].Location, "lint_test.go")
+
+	// AssertIsLessOrEqual decomposes on 252 bits
+	require.Empty(t, ambiguousDecompositions(t, &lessOrEqualCircuit{}))
+}
+
+type secretCircuit struct {
+	X, Y frontend.Variable
+	Z    frontend.Variable `gnark:",public"`
+}
+
+func (circuit *secretCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	cs.AssertIsEqual(circuit.Z, cs.Mul(circuit.X, circuit.X))
+	cs.AssertIsEqual(circuit.Y, 3) // doesn't depend on Z
+	return nil
+}
+
+func TestNotBoundToPublic(t *testing.T) {
+	findings := lint(t, &secretCircuit{})
+	require.Equal(t, []Kind{NotBoundToPublic}, kinds(findings))
+}
+
+// a decomposition without boolean constraints, as an imported circuit could have
+func TestUnconstrainedBit(t *testing.T) {
+	coeffs := r1cs.NewCoeffArena(2, 1)
+	one := coeffs.Append(big.NewInt(1))
+	two := coeffs.Append(big.NewInt(2))
+
+	// wires: 2 bits, X (secret), ONE_WIRE
+	untyped := r1cs.UntypedR1CS{
+		NbWires:       4,
+		NbPublicWires: 1,
+		NbSecretWires: 1,
+		SecretWires:   []string{"X"},
+		PublicWires:   []string{backend.OneWire},
+		Constraints: []r1c.R1C{{
+			L:      r1c.LinearExpression{r1c.Pack(0, one, backend.Internal), r1c.Pack(1, two, backend.Internal)},
+			R:      r1c.LinearExpression{r1c.Pack(3, one, backend.Public)},
+			O:      r1c.LinearExpression{r1c.Pack(2, one, backend.Secret)},
+			Solver: r1c.BinaryDec,
+		}},
+		NbConstraints:   1,
+		NbCOConstraints: 1,
+		Coefficients:    coeffs,
+	}
+	findings, err := Lint(untyped.ToR1CS(gurvy.BN256))
+	require.NoError(t, err)
+	// the bits are only in the decomposition
+	require.Equal(t, []Kind{UnusedWire, UnusedWire, UnconstrainedBit, UnconstrainedBit}, kinds(findings))
+}
+
+func TestUntyped(t *testing.T) {
+	_r1cs, err := frontend.Compile(gurvy.UNKNOWN, &cubicCircuit{})
+	require.NoError(t, err)
+	_, err = Lint(_r1cs)
+	require.Equal(t, errCurve, err)
+}
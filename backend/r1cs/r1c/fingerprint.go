@@ -0,0 +1,119 @@
+// Copyright 2020 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package r1c
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"hash"
+	"math/big"
+)
+
+// fingerprintVersion is the first value hashed by a Fingerprint: it changes with the content hashed
+const fingerprintVersion = 1
+
+// Fingerprint hashes (sha256) the content of a R1CS which defines the circuit, for the Fingerprint
+// method of the R1CS (note: it is not in backend/r1cs so the typed R1CS can use it)
+//
+// the coefficients of the terms are hashed by value (coeff returns the value of the coefficient of a
+// term), not by their index in the coefficients of the R1CS
+type Fingerprint struct {
+	h     hash.Hash
+	coeff func(t Term) *big.Int
+}
+
+// NewFingerprint returns a Fingerprint of the R1CS of a curve (its gurvy.ID), whose coefficients are
+// given by coeff
+func NewFingerprint(curveID uint64, coeff func(t Term) *big.Int) *Fingerprint {
+	f := &Fingerprint{h: sha256.New(), coeff: coeff}
+	f.WriteUint64(fingerprintVersion)
+	f.WriteUint64(curveID)
+	return f
+}
+
+// WriteUint64 hashes v
+func (f *Fingerprint) WriteUint64(v uint64) {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], v)
+	f.h.Write(buf[:])
+}
+
+// WriteStrings hashes a list of names (of wires)
+func (f *Fingerprint) WriteStrings(s []string) {
+	f.WriteUint64(uint64(len(s)))
+	for i := range s {
+		f.WriteUint64(uint64(len(s[i])))
+		f.h.Write([]byte(s[i]))
+	}
+}
+
+// WriteConstraints hashes the constraints, with their solving methods
+func (f *Fingerprint) WriteConstraints(constraints []R1C) {
+	f.WriteUint64(uint64(len(constraints)))
+	for i := range constraints {
+		f.writeLinearExpression(constraints[i].L)
+		f.writeLinearExpression(constraints[i].R)
+		f.writeLinearExpression(constraints[i].O)
+		f.WriteUint64(uint64(constraints[i].Solver))
+	}
+}
+
+// WriteHints hashes the hints and the commitment (nil if the circuit has none)
+func (f *Fingerprint) WriteHints(hints []Hint, commitment *Commitment) {
+	f.WriteUint64(uint64(len(hints)))
+	for i := range hints {
+		f.WriteUint64(uint64(hints[i].ID))
+		f.WriteUint64(uint64(len(hints[i].Inputs)))
+		for _, l := range hints[i].Inputs {
+			f.writeLinearExpression(l)
+		}
+		f.writeInts(hints[i].Outputs())
+	}
+	if commitment == nil {
+		f.WriteUint64(0)
+		return
+	}
+	f.WriteUint64(1)
+	f.writeInts(commitment.Committed)
+	f.WriteUint64(uint64(commitment.Wire))
+}
+
+// Sum returns the fingerprint
+func (f *Fingerprint) Sum() []byte {
+	return f.h.Sum(nil)
+}
+
+func (f *Fingerprint) writeInts(v []int) {
+	f.WriteUint64(uint64(len(v)))
+	for _, w := range v {
+		f.WriteUint64(uint64(w))
+	}
+}
+
+func (f *Fingerprint) writeLinearExpression(l LinearExpression) {
+	f.WriteUint64(uint64(len(l)))
+	for _, t := range l {
+		f.WriteUint64(uint64(t.VariableID()))
+		c := f.coeff(t)
+		if c.Sign() < 0 {
+			f.h.Write([]byte{1})
+		} else {
+			f.h.Write([]byte{0})
+		}
+		b := c.Bytes()
+		f.WriteUint64(uint64(len(b)))
+		f.h.Write(b)
+	}
+}
@@ -14,6 +14,8 @@
 
 package r1c
 
+import "github.com/consensys/gnark/backend/hint"
+
 // LinearExpression represent a linear expression of variables
 type LinearExpression []Term
 
@@ -34,3 +36,39 @@ const (
 	SingleOutput SolvingMethod = iota
 	BinaryDec
 )
+
+// Hint describes a wire computed at solving time by a hint function (see package hint), from the
+// values of linear expressions
+//
+// a hint with several outputs (a hint.MultiFunction) computes the Wires, and Wire is Wires[0]
+type Hint struct {
+	ID     hint.ID
+	Inputs []LinearExpression
+	Wire   int
+	Wires  []int
+}
+
+// Outputs returns the wires computed by the hint
+func (h *Hint) Outputs() []int {
+	if len(h.Wires) != 0 {
+		return h.Wires
+	}
+	return []int{h.Wire}
+}
+
+// Namespace is a run of constraints recorded in a namespace of the frontend (see
+// frontend.ConstraintSystem.Namespace): the run starts at Constraint, and ends at the next Namespace
+// of the R1CS (or at its last constraint)
+type Namespace struct {
+	Name       string // hierarchical name, as "merkle/level3", or "" outside of a namespace
+	Constraint int
+}
+
+// Commitment describes the commitment of a circuit (see frontend.ConstraintSystem.Commit): Wire is
+// computed by the hint hint.Commitment, from the values of the Committed wires
+//
+// the backends may replace the hint by a commitment they enforce (see groth16.Prove)
+type Commitment struct {
+	Committed []int // committed wires, in the order of the call to Commit
+	Wire      int
+}
@@ -35,6 +35,10 @@ type R1CS interface {
 	GetNbWires() uint64
 	GetNbCoefficients() int
 	GetCurveID() gurvy.ID
+
+	// Fingerprint returns a hash of the content of the R1CS which defines the circuit: compiling a
+	// circuit twice gives the same fingerprint, and it changes with the proving and verifying keys
+	Fingerprint() []byte
 }
 
 // New instantiate a concrete curved-typed R1CS and return a R1CS interface
@@ -17,6 +17,8 @@
 package r1cs
 
 import (
+	"math/big"
+
 	bls381backend "github.com/consensys/gnark/internal/backend/bls381"
 
 	"github.com/consensys/gurvy/bls381/fr"
@@ -33,13 +35,18 @@ func (r1cs *UntypedR1CS) toBLS381() *bls381backend.R1CS {
 		NbConstraints:   r1cs.NbConstraints,
 		NbCOConstraints: r1cs.NbCOConstraints,
 		Constraints:     r1cs.Constraints,
-		Coefficients:    make([]fr.Element, len(r1cs.Coefficients)),
+		Coefficients:    make([]fr.Element, r1cs.Coefficients.Len()),
 		Logs:            r1cs.Logs,
 		DebugInfo:       r1cs.DebugInfo,
+		Locations:       r1cs.Locations,
+		Hints:           r1cs.Hints,
+		Namespaces:      r1cs.Namespaces,
+		Commitment:      r1cs.Commitment,
 	}
 
-	for i := 0; i < len(r1cs.Coefficients); i++ {
-		toReturn.Coefficients[i].SetBigInt(&r1cs.Coefficients[i])
+	var coeff big.Int
+	for i := 0; i < len(toReturn.Coefficients); i++ {
+		toReturn.Coefficients[i].SetBigInt(r1cs.Coefficients.Get(i, &coeff))
 	}
 
 	return &toReturn
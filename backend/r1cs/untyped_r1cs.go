@@ -20,12 +20,18 @@ import (
 
 	"github.com/consensys/gnark/backend"
 	"github.com/consensys/gnark/backend/r1cs/r1c"
+	"github.com/consensys/gnark/internal/backend/ioutils"
 	"github.com/consensys/gurvy"
+	"github.com/fxamacker/cbor/v2"
 )
 
 // UntypedR1CS decsribes a set of UntypedR1CS constraint
 // The coefficients from the rank-1 constraint it contains
 // are big.Int and not tied to a curve base field
+//
+// it is returned by frontend.Compile(gurvy.UNKNOWN, circuit), and lowered to the R1CS of a curve with
+// ToR1CS: a circuit is defined once for all the curves. It can be serialized (see WriteTo), to be
+// lowered later
 type UntypedR1CS struct {
 	// Wires
 	NbWires       uint64
@@ -35,12 +41,16 @@ type UntypedR1CS struct {
 	PublicWires   []string // public wire names
 	Logs          []backend.LogEntry
 	DebugInfo     []backend.LogEntry
+	Locations     []string // file:line of each constraint, if recorded (see frontend.WithSourceLocations)
 
 	// Constraints
 	NbConstraints   uint64 // total number of constraints
 	NbCOConstraints uint64 // number of constraints that need to be solved, the first of the Constraints slice
 	Constraints     []r1c.R1C
-	Coefficients    []big.Int
+	Coefficients    CoeffArena
+	Hints           []r1c.Hint      // internal wires computed by a hint function
+	Namespaces      []r1c.Namespace // runs of constraints recorded in a namespace of the frontend
+	Commitment      *r1c.Commitment // nil if the circuit has no commitment
 }
 
 // GetNbConstraints returns the number of constraints
@@ -55,12 +65,80 @@ func (r1cs *UntypedR1CS) GetNbWires() uint64 {
 
 // GetNbCoefficients return the number of unique coefficients needed in the R1CS
 func (r1cs *UntypedR1CS) GetNbCoefficients() int {
-	return len(r1cs.Coefficients)
+	return r1cs.Coefficients.Len()
+}
+
+// Fingerprint returns a hash (sha256) of the wires, constraints, hints and commitment of the R1CS, as
+// the Fingerprint of a typed R1CS; the coefficients are not reduced, so it differs from the
+// Fingerprint of ToR1CS(curveID)
+func (r1cs *UntypedR1CS) Fingerprint() []byte {
+	var coeff big.Int
+	f := r1c.NewFingerprint(uint64(gurvy.UNKNOWN), func(t r1c.Term) *big.Int {
+		return r1cs.Coefficients.Get(t.CoeffID(), &coeff)
+	})
+	f.WriteUint64(r1cs.NbWires)
+	f.WriteUint64(r1cs.NbPublicWires)
+	f.WriteUint64(r1cs.NbSecretWires)
+	f.WriteStrings(r1cs.PublicWires)
+	f.WriteStrings(r1cs.SecretWires)
+	f.WriteUint64(r1cs.NbCOConstraints)
+	f.WriteConstraints(r1cs.Constraints)
+	f.WriteHints(r1cs.Hints, r1cs.Commitment)
+	return f.Sum()
+}
+
+// untypedR1CS is the serialized form of UntypedR1CS: the coefficients are big endian bytes (see
+// big.Int.Bytes), which don't depend on the size of a big.Word
+type untypedR1CS struct {
+	NbWires         uint64
+	NbPublicWires   uint64
+	NbSecretWires   uint64
+	SecretWires     []string
+	PublicWires     []string
+	Logs            []backend.LogEntry
+	DebugInfo       []backend.LogEntry
+	NbConstraints   uint64
+	NbCOConstraints uint64
+	Constraints     []r1c.R1C
+	Coefficients    [][]byte // absolute values of the coefficients
+	Neg             []bool   // Neg[i] is set if coefficient i is negative
+	Hints           []r1c.Hint
+	Namespaces      []r1c.Namespace
+	Locations       []string
+	Commitment      *r1c.Commitment
 }
 
-// WriteTo panics (can't serialize untyped R1CS)
-func (r1cs *UntypedR1CS) WriteTo(w io.Writer) (n int64, err error) {
-	panic("not implemented: can't serialize untyped R1CS")
+// WriteTo encodes UntypedR1CS into provided io.Writer using cbor
+func (r1cs *UntypedR1CS) WriteTo(w io.Writer) (int64, error) {
+	_w := ioutils.WriterCounter{W: w} // wraps writer to count the bytes written
+	encoder := cbor.NewEncoder(&_w)
+
+	toEncode := untypedR1CS{
+		NbWires:         r1cs.NbWires,
+		NbPublicWires:   r1cs.NbPublicWires,
+		NbSecretWires:   r1cs.NbSecretWires,
+		SecretWires:     r1cs.SecretWires,
+		PublicWires:     r1cs.PublicWires,
+		Logs:            r1cs.Logs,
+		DebugInfo:       r1cs.DebugInfo,
+		NbConstraints:   r1cs.NbConstraints,
+		NbCOConstraints: r1cs.NbCOConstraints,
+		Constraints:     r1cs.Constraints,
+		Coefficients:    make([][]byte, r1cs.Coefficients.Len()),
+		Neg:             r1cs.Coefficients.Neg,
+		Hints:           r1cs.Hints,
+		Namespaces:      r1cs.Namespaces,
+		Locations:       r1cs.Locations,
+		Commitment:      r1cs.Commitment,
+	}
+	var coeff big.Int
+	for i := 0; i < len(toEncode.Coefficients); i++ {
+		toEncode.Coefficients[i] = r1cs.Coefficients.Get(i, &coeff).Bytes()
+	}
+
+	// encode our object
+	err := encoder.Encode(&toEncode)
+	return _w.N, err
 }
 
 // GetCurveID returns gurvy.UNKNOWN as this is a untyped R1CS using big.Int
@@ -68,9 +146,42 @@ func (r1cs *UntypedR1CS) GetCurveID() gurvy.ID {
 	return gurvy.UNKNOWN
 }
 
-// ReadFrom panics (can't deserialize untyped R1CS)
-func (r1cs *UntypedR1CS) ReadFrom(r io.Reader) (n int64, err error) {
-	panic("not implemented: can't deserialize untyped R1CS")
+// ReadFrom attempts to decode UntypedR1CS from io.Reader using cbor
+func (r1cs *UntypedR1CS) ReadFrom(r io.Reader) (int64, error) {
+	decoder := cbor.NewDecoder(r)
+
+	var decoded untypedR1CS
+	if err := decoder.Decode(&decoded); err != nil {
+		return int64(decoder.NumBytesRead()), err
+	}
+
+	*r1cs = UntypedR1CS{
+		NbWires:         decoded.NbWires,
+		NbPublicWires:   decoded.NbPublicWires,
+		NbSecretWires:   decoded.NbSecretWires,
+		SecretWires:     decoded.SecretWires,
+		PublicWires:     decoded.PublicWires,
+		Logs:            decoded.Logs,
+		DebugInfo:       decoded.DebugInfo,
+		NbConstraints:   decoded.NbConstraints,
+		NbCOConstraints: decoded.NbCOConstraints,
+		Constraints:     decoded.Constraints,
+		Coefficients:    NewCoeffArena(len(decoded.Coefficients), 4),
+		Hints:           decoded.Hints,
+		Namespaces:      decoded.Namespaces,
+		Locations:       decoded.Locations,
+		Commitment:      decoded.Commitment,
+	}
+	var coeff big.Int
+	for i, b := range decoded.Coefficients {
+		coeff.SetBytes(b)
+		if i < len(decoded.Neg) && decoded.Neg[i] {
+			coeff.Neg(&coeff)
+		}
+		r1cs.Coefficients.Append(&coeff)
+	}
+
+	return int64(decoder.NumBytesRead()), nil
 }
 
 // IsSolved call will panic as we can't solve a UntypedR1CS
@@ -81,7 +192,7 @@ func (r1cs *UntypedR1CS) IsSolved(solution map[string]interface{}) error {
 // ToR1CS will convert the big.Int coefficients in the UntypedR1CS to field elements
 // in the basefield of the provided curveID and return a R1CS
 //
-// this should not be called in a normal circuit development workflow
+// the UntypedR1CS is not modified, it can be converted for several curves
 func (r1cs *UntypedR1CS) ToR1CS(curveID gurvy.ID) R1CS {
 	switch curveID {
 	case gurvy.BN256:
@@ -0,0 +1,113 @@
+// Copyright 2020 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package r1cs_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/consensys/gnark/backend/r1cs"
+	"github.com/consensys/gnark/backend/r1cs/r1c"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/internal/backend/circuits"
+	"github.com/consensys/gurvy"
+)
+
+func TestUntypedR1CSSerialization(t *testing.T) {
+	curves := []gurvy.ID{gurvy.BN256, gurvy.BLS377, gurvy.BLS381, gurvy.BW761}
+
+	for name, circuit := range circuits.Circuits {
+		var buf bytes.Buffer
+		written, err := circuit.R1CS.WriteTo(&buf)
+		if err != nil {
+			t.Fatal(name, err)
+		}
+
+		var reconstructed r1cs.UntypedR1CS
+		read, err := reconstructed.ReadFrom(&buf)
+		if err != nil {
+			t.Fatal(name, err)
+		}
+		if written != read {
+			t.Fatal(name, "didn't read the number of bytes written")
+		}
+
+		// the reconstructed R1CS is lowered to the same R1CS on each curve
+		for _, curveID := range curves {
+			var expected, got bytes.Buffer
+			if _, err := circuit.R1CS.ToR1CS(curveID).WriteTo(&expected); err != nil {
+				t.Fatal(name, err)
+			}
+			if _, err := reconstructed.ToR1CS(curveID).WriteTo(&got); err != nil {
+				t.Fatal(name, err)
+			}
+			if !bytes.Equal(expected.Bytes(), got.Bytes()) {
+				t.Fatal(name, curveID.String(), "serialization round trip doesn't match")
+			}
+		}
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	for name, circuit := range circuits.Circuits {
+		// the circuit is compiled again, twice from the same call site, as the debug information holds
+		// call stacks; the shape of the map circuit is not given by its type
+		if name != "map" {
+			var compiled [2]bytes.Buffer
+			for i := range compiled {
+				empty := reflect.New(reflect.TypeOf(circuit.Good).Elem()).Interface().(frontend.Circuit)
+				_r1cs, err := frontend.Compile(gurvy.UNKNOWN, empty)
+				if err != nil {
+					t.Fatal(name, err)
+				}
+				if !bytes.Equal(circuit.R1CS.Fingerprint(), _r1cs.Fingerprint()) {
+					t.Fatal(name, "the fingerprint is not deterministic")
+				}
+				if _, err := _r1cs.WriteTo(&compiled[i]); err != nil {
+					t.Fatal(name, err)
+				}
+			}
+			if !bytes.Equal(compiled[0].Bytes(), compiled[1].Bytes()) {
+				t.Fatal(name, "the compilation is not deterministic")
+			}
+		}
+
+		// the debug information is not part of the fingerprint
+		withDebug := *circuit.R1CS
+		withDebug.Locations = make([]string, withDebug.NbConstraints)
+		withDebug.Logs = nil
+		if !bytes.Equal(circuit.R1CS.Fingerprint(), withDebug.Fingerprint()) {
+			t.Fatal(name, "the debug information changed the fingerprint")
+		}
+
+		// a change of the constraints changes the fingerprint
+		modified := *circuit.R1CS
+		modified.Constraints = append([]r1c.R1C(nil), modified.Constraints...)
+		last := &modified.Constraints[len(modified.Constraints)-1]
+		last.L, last.O = last.O, last.L
+		if bytes.Equal(circuit.R1CS.Fingerprint(), modified.Fingerprint()) {
+			t.Fatal(name, "a modified constraint didn't change the fingerprint")
+		}
+
+		lowered := circuit.R1CS.ToR1CS(gurvy.BN256).Fingerprint()
+		if !bytes.Equal(lowered, circuit.R1CS.ToR1CS(gurvy.BN256).Fingerprint()) {
+			t.Fatal(name, "the fingerprint of the typed R1CS is not deterministic")
+		}
+		if bytes.Equal(lowered, circuit.R1CS.ToR1CS(gurvy.BLS381).Fingerprint()) {
+			t.Fatal(name, "the R1CS of different curves have the same fingerprint")
+		}
+	}
+}
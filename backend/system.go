@@ -0,0 +1,149 @@
+// Copyright 2020 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/consensys/gurvy"
+)
+
+// ID identifies a proof system
+type ID uint16
+
+// proof systems
+const (
+	UNKNOWN ID = iota
+	GROTH16
+	PLONK
+)
+
+var idNames = map[ID]string{
+	UNKNOWN: "unknown",
+	GROTH16: "groth16",
+	PLONK:   "plonk",
+}
+
+func (id ID) String() string {
+	if name, ok := idNames[id]; ok {
+		return name
+	}
+	return fmt.Sprintf("ID(%d)", uint16(id))
+}
+
+// ParseID returns the ID of a proof system name ("groth16", "plonk"), for example read from a
+// configuration file; the name is case insensitive
+func ParseID(name string) (ID, error) {
+	for id, n := range idNames {
+		if id != UNKNOWN && strings.EqualFold(name, n) {
+			return id, nil
+		}
+	}
+	return UNKNOWN, fmt.Errorf("backend: unknown proof system %q", name)
+}
+
+// ConstraintSystem is a compiled circuit, in the arithmetization of a proof system (r1cs.R1CS for
+// GROTH16)
+type ConstraintSystem interface {
+	io.WriterTo
+	io.ReaderFrom
+	GetNbConstraints() uint64
+	GetCurveID() gurvy.ID
+}
+
+// ProvingKey is the proving key of a proof system
+type ProvingKey interface {
+	io.WriterTo
+	io.ReaderFrom
+}
+
+// VerifyingKey is the verifying key of a proof system
+type VerifyingKey interface {
+	io.WriterTo
+	io.ReaderFrom
+}
+
+// Proof is a proof of a proof system
+type Proof interface {
+	io.WriterTo
+	io.ReaderFrom
+}
+
+// Prover computes proofs
+//
+// solution is the assignment of the inputs of the circuit, in any form accepted by
+// frontend.ParseWitness: the same solution is used with all the proof systems
+type Prover interface {
+	Prove(cs ConstraintSystem, pk ProvingKey, solution interface{}, opts ...Option) (Proof, error)
+}
+
+// Verifier verifies proofs
+//
+// solution is the assignment of the public inputs of the circuit (see Prover)
+type Verifier interface {
+	Verify(proof Proof, vk VerifyingKey, solution interface{}, opts ...Option) error
+}
+
+// System is a proof system; the values it takes must be of its own types, or an error is returned
+type System interface {
+	Prover
+	Verifier
+
+	// Setup returns the keys of the constraint system
+	Setup(cs ConstraintSystem, opts ...Option) (ProvingKey, VerifyingKey, error)
+
+	// NewProvingKey, NewVerifyingKey and NewProof instantiate objects of the curve, to be read with ReadFrom
+	NewProvingKey(curveID gurvy.ID) (ProvingKey, error)
+	NewVerifyingKey(curveID gurvy.ID) (VerifyingKey, error)
+	NewProof(curveID gurvy.ID) (Proof, error)
+}
+
+var (
+	systemsLock sync.RWMutex
+	systems     = make(map[ID]System)
+)
+
+// ErrNotRegistered is returned by Get for a proof system which isn't registered
+var ErrNotRegistered = errors.New("backend: proof system not registered")
+
+// Register registers the System of a proof system; it is called by the packages of the proof systems
+// when they are initialized, so an application selecting a proof system by its ID imports them:
+//
+//	import _ "github.com/consensys/gnark/backend/groth16"
+//
+// Register panics if id is registered twice
+func Register(id ID, system System) {
+	systemsLock.Lock()
+	defer systemsLock.Unlock()
+	if _, ok := systems[id]; ok {
+		panic("backend: proof system " + id.String() + " registered twice")
+	}
+	systems[id] = system
+}
+
+// Get returns the System of a proof system, or ErrNotRegistered
+func Get(id ID) (System, error) {
+	systemsLock.RLock()
+	defer systemsLock.RUnlock()
+	system, ok := systems[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotRegistered, id)
+	}
+	return system, nil
+}
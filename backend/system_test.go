@@ -0,0 +1,112 @@
+package backend_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/consensys/gnark/backend"
+	_ "github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gurvy"
+)
+
+type cubicCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+// x**3 + x + 5 == y
+func (circuit *cubicCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	x3 := cs.Mul(circuit.X, circuit.X, circuit.X)
+	cs.AssertIsEqual(circuit.Y, cs.Add(x3, circuit.X, 5))
+	return nil
+}
+
+func TestParseID(t *testing.T) {
+	for _, id := range []backend.ID{backend.GROTH16, backend.PLONK} {
+		parsed, err := backend.ParseID(id.String())
+		if err != nil || parsed != id {
+			t.Fatal("can't parse", id, err)
+		}
+	}
+	if id, err := backend.ParseID("Groth16"); err != nil || id != backend.GROTH16 {
+		t.Fatal("ParseID should be case insensitive")
+	}
+	for _, name := range []string{"", "unknown", "stark"} {
+		if _, err := backend.ParseID(name); err == nil {
+			t.Fatal("expected error for", name)
+		}
+	}
+}
+
+func TestSystem(t *testing.T) {
+	if _, err := backend.Get(backend.PLONK); !errors.Is(err, backend.ErrNotRegistered) {
+		t.Fatal("expected ErrNotRegistered, got", err)
+	}
+	system, err := backend.Get(backend.GROTH16)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cs, err := frontend.Compile(gurvy.BN256, &cubicCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, vk, err := system.Setup(cs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	solution := map[string]interface{}{"X": 3, "Y": 35}
+	proof, err := system.Prove(cs, pk, solution)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the objects are read with the constructors of the system
+	var bufVK, bufProof bytes.Buffer
+	if _, err := vk.WriteTo(&bufVK); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := proof.WriteTo(&bufProof); err != nil {
+		t.Fatal(err)
+	}
+	_vk, err := system.NewVerifyingKey(cs.GetCurveID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_proof, err := system.NewProof(cs.GetCurveID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := _vk.ReadFrom(&bufVK); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := _proof.ReadFrom(&bufProof); err != nil {
+		t.Fatal(err)
+	}
+	if err := system.Verify(_proof, _vk, map[string]interface{}{"Y": 35}); err != nil {
+		t.Fatal(err)
+	}
+	if err := system.Verify(_proof, _vk, map[string]interface{}{"Y": 36}); err == nil {
+		t.Fatal("expected error with a wrong public input")
+	}
+
+	// objects of another kind or curve are rejected
+	if _, err := system.Prove(cs, vk, solution); err == nil {
+		t.Fatal("expected error with a verifying key as proving key")
+	}
+	if err := system.Verify(proof, pk, solution); err == nil {
+		t.Fatal("expected error with a proving key as verifying key")
+	}
+	other, err := frontend.Compile(gurvy.BLS381, &cubicCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := system.Prove(other, pk, solution); err == nil {
+		t.Fatal("expected error with a key of another curve")
+	}
+	if _, err := system.NewProof(gurvy.UNKNOWN); err == nil {
+		t.Fatal("expected error with an unknown curve")
+	}
+}
@@ -0,0 +1,247 @@
+// Copyright 2020 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+)
+
+// TraceFormat is the encoding of a SolverTrace (see WithSolverTrace)
+type TraceFormat uint8
+
+// encodings of a SolverTrace
+const (
+	TraceBinary TraceFormat = iota // see SolverTrace.WriteTo
+	TraceJSON                      // see SolverTrace.MarshalJSON
+)
+
+// ConstraintStatus is the status of a constraint in a SolverTrace
+type ConstraintStatus uint8
+
+// statuses of a constraint
+const (
+	ConstraintSatisfied ConstraintStatus = iota // a.b == c
+	ConstraintViolated                          // a.b != c
+	ConstraintUnsolved                          // a wire of the constraint wasn't solved, the solver stopped before
+)
+
+var statusNames = [...]string{"satisfied", "violated", "unsolved"}
+
+func (s ConstraintStatus) String() string {
+	if int(s) < len(statusNames) {
+		return statusNames[s]
+	}
+	return fmt.Sprintf("ConstraintStatus(%d)", uint8(s))
+}
+
+// SolverTrace is the outcome of solving a R1CS, for debuggers and auditors replaying the solver
+//
+// the wires and the constraints are indexed by their ID in the R1CS. The trace of a failed solve holds
+// the wires solved until the failure.
+type SolverTrace struct {
+	Wires       []*big.Int // value of each wire (regular form), nil if the wire wasn't solved
+	Constraints []ConstraintStatus
+}
+
+// traceMagic starts the binary encoding of a SolverTrace, followed by its version
+var traceMagic = [4]byte{'g', 't', 'r', 'c'}
+
+const traceVersion = 1
+
+var errInvalidTrace = errors.New("invalid solver trace")
+
+// Encode writes the trace to w in the given format
+func (t *SolverTrace) Encode(w io.Writer, format TraceFormat) error {
+	switch format {
+	case TraceBinary:
+		_, err := t.WriteTo(w)
+		return err
+	case TraceJSON:
+		return json.NewEncoder(w).Encode(t)
+	default:
+		return fmt.Errorf("unknown trace format %d", format)
+	}
+}
+
+// WriteTo writes the binary encoding of the trace, all integers being big endian:
+//
+//	"gtrc" | version (uint8, 1) | number of wires (uint64) | number of constraints (uint64)
+//	for each wire: length of the value in bytes (uint16), 0xffff if the wire wasn't solved | value
+//	for each constraint: status (uint8, 0 satisfied, 1 violated, 2 unsolved)
+func (t *SolverTrace) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	var n int64
+	write := func(v interface{}) error {
+		if err := binary.Write(bw, binary.BigEndian, v); err != nil {
+			return err
+		}
+		n += int64(binary.Size(v))
+		return nil
+	}
+
+	if err := write(traceMagic); err != nil {
+		return n, err
+	}
+	if err := write(uint8(traceVersion)); err != nil {
+		return n, err
+	}
+	if err := write([]uint64{uint64(len(t.Wires)), uint64(len(t.Constraints))}); err != nil {
+		return n, err
+	}
+	for _, v := range t.Wires {
+		if v == nil {
+			if err := write(uint16(math.MaxUint16)); err != nil {
+				return n, err
+			}
+			continue
+		}
+		b := v.Bytes()
+		if err := write(uint16(len(b))); err != nil {
+			return n, err
+		}
+		if err := write(b); err != nil {
+			return n, err
+		}
+	}
+	for _, s := range t.Constraints {
+		if err := write(uint8(s)); err != nil {
+			return n, err
+		}
+	}
+	return n, bw.Flush()
+}
+
+// ReadFrom reads a trace written by WriteTo
+func (t *SolverTrace) ReadFrom(r io.Reader) (int64, error) {
+	br := bufio.NewReader(r)
+	var n int64
+	read := func(v interface{}) error {
+		if err := binary.Read(br, binary.BigEndian, v); err != nil {
+			return err
+		}
+		n += int64(binary.Size(v))
+		return nil
+	}
+
+	var magic [4]byte
+	var version uint8
+	if err := read(&magic); err != nil {
+		return n, err
+	}
+	if err := read(&version); err != nil {
+		return n, err
+	}
+	if magic != traceMagic || version != traceVersion {
+		return n, errInvalidTrace
+	}
+	var sizes [2]uint64
+	if err := read(&sizes); err != nil {
+		return n, err
+	}
+
+	// the sizes aren't trusted to allocate the slices
+	t.Wires, t.Constraints = nil, nil
+	for i := uint64(0); i < sizes[0]; i++ {
+		var size uint16
+		if err := read(&size); err != nil {
+			return n, err
+		}
+		if size == math.MaxUint16 {
+			t.Wires = append(t.Wires, nil)
+			continue
+		}
+		b := make([]byte, size)
+		if err := read(b); err != nil {
+			return n, err
+		}
+		t.Wires = append(t.Wires, new(big.Int).SetBytes(b))
+	}
+	for i := uint64(0); i < sizes[1]; i++ {
+		var s ConstraintStatus
+		if err := read(&s); err != nil {
+			return n, err
+		}
+		if int(s) >= len(statusNames) {
+			return n, errInvalidTrace
+		}
+		t.Constraints = append(t.Constraints, s)
+	}
+	return n, nil
+}
+
+// jsonTrace is the JSON encoding of a SolverTrace
+type jsonTrace struct {
+	Wires       []*string `json:"wires"`
+	Constraints []string  `json:"constraints"`
+}
+
+// MarshalJSON returns the JSON encoding of the trace:
+//
+//	{"wires": ["1", "42", null, ...], "constraints": ["satisfied", "violated", "unsolved", ...]}
+//
+// the values of the wires are decimal strings, null if the wire wasn't solved
+func (t *SolverTrace) MarshalJSON() ([]byte, error) {
+	res := jsonTrace{Wires: make([]*string, len(t.Wires)), Constraints: make([]string, len(t.Constraints))}
+	for i, v := range t.Wires {
+		if v != nil {
+			s := v.String()
+			res.Wires[i] = &s
+		}
+	}
+	for i, s := range t.Constraints {
+		res.Constraints[i] = s.String()
+	}
+	return json.Marshal(res)
+}
+
+// UnmarshalJSON reads a trace encoded by MarshalJSON
+func (t *SolverTrace) UnmarshalJSON(data []byte) error {
+	var res jsonTrace
+	if err := json.Unmarshal(data, &res); err != nil {
+		return err
+	}
+	t.Wires = make([]*big.Int, len(res.Wires))
+	for i, s := range res.Wires {
+		if s == nil {
+			continue
+		}
+		v, ok := new(big.Int).SetString(*s, 10)
+		if !ok {
+			return errInvalidTrace
+		}
+		t.Wires[i] = v
+	}
+	t.Constraints = make([]ConstraintStatus, len(res.Constraints))
+	for i, s := range res.Constraints {
+		status := -1
+		for j, name := range statusNames {
+			if s == name {
+				status = j
+			}
+		}
+		if status < 0 {
+			return errInvalidTrace
+		}
+		t.Constraints[i] = ConstraintStatus(status)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+// Copyright 2020 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestSolverTraceEncoding(t *testing.T) {
+	var large big.Int
+	large.Lsh(big.NewInt(1), 380).Sub(&large, big.NewInt(1))
+	trace := SolverTrace{
+		Wires:       []*big.Int{big.NewInt(1), big.NewInt(0), nil, &large},
+		Constraints: []ConstraintStatus{ConstraintSatisfied, ConstraintViolated, ConstraintUnsolved},
+	}
+
+	for _, format := range []TraceFormat{TraceBinary, TraceJSON} {
+		var buf bytes.Buffer
+		if err := trace.Encode(&buf, format); err != nil {
+			t.Fatal(err)
+		}
+		var decoded SolverTrace
+		var err error
+		if format == TraceBinary {
+			var n int64
+			n, err = decoded.ReadFrom(bytes.NewReader(buf.Bytes()))
+			if n != int64(buf.Len()) {
+				t.Fatal("ReadFrom didn't read the whole trace")
+			}
+		} else {
+			err = json.Unmarshal(buf.Bytes(), &decoded)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(decoded.Constraints, trace.Constraints) || len(decoded.Wires) != len(trace.Wires) {
+			t.Fatal("decoded trace doesn't match")
+		}
+		for i, v := range trace.Wires {
+			if (v == nil) != (decoded.Wires[i] == nil) || (v != nil && v.Cmp(decoded.Wires[i]) != 0) {
+				t.Fatal("decoded wire doesn't match", i)
+			}
+		}
+	}
+
+	// the documented JSON encoding
+	data, err := json.Marshal(&trace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"wires":["1","0",null,"` + large.String() + `"],"constraints":["satisfied","violated","unsolved"]}`
+	if string(data) != expected {
+		t.Fatal("unexpected JSON encoding", string(data))
+	}
+
+	var buf bytes.Buffer
+	if _, err := trace.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var decoded SolverTrace
+	if _, err := decoded.ReadFrom(bytes.NewReader(buf.Bytes()[:buf.Len()-1])); err == nil {
+		t.Fatal("expected error with a truncated trace")
+	}
+	buf.Bytes()[0] = 'x'
+	if _, err := decoded.ReadFrom(&buf); err != errInvalidTrace {
+		t.Fatal("expected errInvalidTrace, got", err)
+	}
+	if err := json.Unmarshal([]byte(`{"wires":[],"constraints":["solved"]}`), &decoded); err != errInvalidTrace {
+		t.Fatal("expected errInvalidTrace, got", err)
+	}
+	if err := trace.Encode(&buf, TraceFormat(42)); err == nil {
+		t.Fatal("expected error with an unknown format")
+	}
+	if _, err := NewConfig(WithSolverTrace(nil, TraceJSON)); err == nil {
+		t.Fatal("expected error with a nil writer")
+	}
+}
@@ -15,52 +15,119 @@
 package backend
 
 import (
+	"encoding"
+	"errors"
+	"fmt"
 	"math/big"
 	"reflect"
+	"strings"
 )
 
 type toBigIntInterface interface {
 	ToBigIntRegular(res *big.Int) *big.Int
 }
 
-// FromInterface converts an interface to a big.Int element
-// interface must implement ToBigIntRegular(res *big.Int) *big.Int
-// (which is the case for field generated by goff)
-// or be uint64, int, string, []byte or big.Int
+// FromInterface converts an interface to a big.Int element (see ToBigInt)
 // it panics if the input is invalid
 func FromInterface(i1 interface{}) big.Int {
+	val, err := ToBigInt(i1)
+	if err != nil {
+		panic(err.Error())
+	}
+	return val
+}
+
+// ToBigInt converts an interface to a big.Int element
+//
+// the interface can be
+//   - big.Int or *big.Int
+//   - a signed integer (int, int8, ..., int64): a negative value -x is p-x in the scalar field
+//   - an unsigned integer (uint, uint8, ..., uint64)
+//   - a string, in base 10 or, with the prefix "0x", in base 16, optionally signed ("-12", "0xff", "-0x1")
+//   - []byte, the big endian bytes of an unsigned value
+//   - a field element generated by goff, implementing ToBigIntRegular(res *big.Int) *big.Int
+//   - an encoding.TextMarshaler, whose text is a string as above
+func ToBigInt(i1 interface{}) (big.Int, error) {
 	var val big.Int
 
 	switch c1 := i1.(type) {
 	case big.Int:
 		val.Set(&c1)
 	case *big.Int:
+		if c1 == nil {
+			return val, errors.New("nil *big.Int")
+		}
 		val.Set(c1)
-	case uint64:
-		val.SetUint64(c1)
 	case int:
 		val.SetInt64(int64(c1))
+	case int8:
+		val.SetInt64(int64(c1))
+	case int16:
+		val.SetInt64(int64(c1))
+	case int32:
+		val.SetInt64(int64(c1))
+	case int64:
+		val.SetInt64(c1)
+	case uint:
+		val.SetUint64(uint64(c1))
+	case uint8:
+		val.SetUint64(uint64(c1))
+	case uint16:
+		val.SetUint64(uint64(c1))
+	case uint32:
+		val.SetUint64(uint64(c1))
+	case uint64:
+		val.SetUint64(c1)
 	case string:
-		if _, ok := val.SetString(c1, 10); !ok {
-			panic("unable to set big.Int from base10 string")
-		}
+		return parseBigInt(c1)
 	case []byte:
 		val.SetBytes(c1)
 	default:
 		if v, ok := i1.(toBigIntInterface); ok {
 			v.ToBigIntRegular(&val)
-			return val
+			return val, nil
 		} else if reflect.ValueOf(i1).Kind() == reflect.Ptr {
 			vv := reflect.ValueOf(i1).Elem()
 			if vv.CanInterface() {
 				if v, ok := vv.Interface().(toBigIntInterface); ok {
 					v.ToBigIntRegular(&val)
-					return val
+					return val, nil
 				}
 			}
 		}
-		panic("unsupported type")
+		if v, ok := i1.(encoding.TextMarshaler); ok {
+			text, err := v.MarshalText()
+			if err != nil {
+				return val, err
+			}
+			return parseBigInt(string(text))
+		}
+		return val, fmt.Errorf("unsupported type %T", i1)
 	}
 
-	return val
+	return val, nil
+}
+
+// parseBigInt parses a signed integer in base 10, or in base 16 with the prefix "0x"
+func parseBigInt(s string) (big.Int, error) {
+	var val big.Int
+	digits, neg := s, false
+	if strings.HasPrefix(digits, "-") {
+		digits, neg = digits[1:], true
+	}
+	base := 10
+	if strings.HasPrefix(digits, "0x") || strings.HasPrefix(digits, "0X") {
+		digits, base = digits[2:], 16
+	}
+	// SetString would accept a second sign, or underscores
+	if digits == "" || strings.ContainsAny(digits, "+-_") {
+		return val, fmt.Errorf("invalid integer %q", s)
+	}
+	if _, ok := val.SetString(digits, base); !ok {
+		return val, fmt.Errorf("invalid integer %q", s)
+	}
+	if neg {
+		val.Neg(&val)
+	}
+	return val, nil
 }
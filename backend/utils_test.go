@@ -25,3 +25,52 @@ func TestFromInterfaceValidFormats(t *testing.T) {
 	_ = FromInterface("8000")
 
 }
+
+// decimal implements encoding.TextMarshaler
+type decimal int
+
+func (d decimal) MarshalText() ([]byte, error) {
+	return []byte(big.NewInt(int64(d)).String()), nil
+}
+
+func TestToBigInt(t *testing.T) {
+	var one fr.Element
+	one.SetOne()
+
+	valid := []struct {
+		input    interface{}
+		expected int64
+	}{
+		{int8(-3), -3},
+		{int16(-300), -300},
+		{int32(7), 7},
+		{int64(-1), -1},
+		{uint(5), 5},
+		{uint8(255), 255},
+		{uint32(1 << 31), 1 << 31},
+		{"-12", -12},
+		{"0xff", 255},
+		{"-0x1", -1},
+		{"0XA", 10},
+		{[]byte{1, 0}, 256},
+		{one, 1},
+		{&one, 1},
+		{decimal(-42), -42},
+	}
+	for _, v := range valid {
+		res, err := ToBigInt(v.input)
+		if err != nil {
+			t.Fatal(v.input, err)
+		}
+		if !res.IsInt64() || res.Int64() != v.expected {
+			t.Fatal("wrong conversion of", v.input, "got", res.String())
+		}
+	}
+
+	invalid := []interface{}{"", "-", "0x", "12a", "--1", "-+1", "1_000", "0b101", 1.5, (*big.Int)(nil), true}
+	for _, v := range invalid {
+		if _, err := ToBigInt(v); err == nil {
+			t.Fatal("expected an error converting", v)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Command capi exports the gnark Groth16 API as a C shared library
+//
+//	go build -buildmode=c-shared -o libgnark.so github.com/consensys/gnark/capi
+//
+// also generates libgnark.h. The circuits are compiled in Go: the R1CS, keys and proofs are
+// serialized with their WriteTo methods, and witnesses are JSON objects mapping input names to
+// decimal or hexadecimal strings. Curves are named "bn256", "bls377", "bls381" or "bw761".
+//
+// Every function returns 0 on success. On failure, it returns -1 and sets *err to the error message.
+// The buffers and messages returned by the library must be freed with gnark_free.
+//
+//	uint8_t *proof; size_t proof_len; char *err;
+//	if (gnark_prove("bn256", r1cs, r1cs_len, pk, pk_len, "{\"X\":\"3\",\"Y\":\"35\"}", &proof, &proof_len, &err) != 0) {
+//		fprintf(stderr, "%s\n", err);
+//		gnark_free(err);
+//	}
+package main
+
+/*
+#include <stdint.h>
+#include <stdlib.h>
+*/
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/consensys/gnark/internal/bindings"
+)
+
+func main() {}
+
+//export gnark_free
+func gnark_free(ptr unsafe.Pointer) {
+	C.free(ptr)
+}
+
+// gnark_setup runs the Groth16 setup of r1cs, and returns the proving and verifying keys
+//
+//export gnark_setup
+func gnark_setup(curve *C.char, r1cs *C.uint8_t, r1csLen C.size_t,
+	pk **C.uint8_t, pkLen *C.size_t, vk **C.uint8_t, vkLen *C.size_t, err **C.char) (res C.int) {
+	defer recoverError(err, &res)
+
+	_pk, _vk, e := bindings.Setup(C.GoString(curve), goBytes(r1cs, r1csLen))
+	if e != nil {
+		return setError(err, e)
+	}
+	*pk, *pkLen = cBytes(_pk)
+	*vk, *vkLen = cBytes(_vk)
+	return 0
+}
+
+// gnark_is_solved checks the witness solves r1cs
+//
+//export gnark_is_solved
+func gnark_is_solved(curve *C.char, r1cs *C.uint8_t, r1csLen C.size_t, witness *C.char, err **C.char) (res C.int) {
+	defer recoverError(err, &res)
+
+	if e := bindings.IsSolved(C.GoString(curve), goBytes(r1cs, r1csLen), C.GoString(witness)); e != nil {
+		return setError(err, e)
+	}
+	return 0
+}
+
+// gnark_prove returns a proof of the witness
+//
+//export gnark_prove
+func gnark_prove(curve *C.char, r1cs *C.uint8_t, r1csLen C.size_t, pk *C.uint8_t, pkLen C.size_t, witness *C.char,
+	proof **C.uint8_t, proofLen *C.size_t, err **C.char) (res C.int) {
+	defer recoverError(err, &res)
+
+	_proof, e := bindings.Prove(C.GoString(curve), goBytes(r1cs, r1csLen), goBytes(pk, pkLen), C.GoString(witness))
+	if e != nil {
+		return setError(err, e)
+	}
+	*proof, *proofLen = cBytes(_proof)
+	return 0
+}
+
+// gnark_verify checks the proof against the public inputs of publicWitness
+//
+//export gnark_verify
+func gnark_verify(curve *C.char, proof *C.uint8_t, proofLen C.size_t, vk *C.uint8_t, vkLen C.size_t,
+	publicWitness *C.char, err **C.char) (res C.int) {
+	defer recoverError(err, &res)
+
+	if e := bindings.Verify(C.GoString(curve), goBytes(proof, proofLen), goBytes(vk, vkLen), C.GoString(publicWitness)); e != nil {
+		return setError(err, e)
+	}
+	return 0
+}
+
+func goBytes(buf *C.uint8_t, n C.size_t) []byte {
+	return C.GoBytes(unsafe.Pointer(buf), C.int(n))
+}
+
+// cBytes copies buf in a buffer allocated with malloc
+func cBytes(buf []byte) (*C.uint8_t, C.size_t) {
+	return (*C.uint8_t)(C.CBytes(buf)), C.size_t(len(buf))
+}
+
+func setError(err **C.char, e error) C.int {
+	if err != nil {
+		*err = C.CString(e.Error())
+	}
+	return -1
+}
+
+// recoverError converts a panic to an error: a panic must not cross the C boundary
+func recoverError(err **C.char, res *C.int) {
+	if r := recover(); r != nil {
+		*res = setError(err, fmt.Errorf("%v", r))
+	}
+}
@@ -0,0 +1,495 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Command gnark runs the lifecycle of a circuit (compile, setup, prove, verify) on serialized objects
+//
+//	go install github.com/consensys/gnark/cmd/gnark
+//
+// Circuits are written in Go: a program compiles them and exports them to the zkir JSON IR (see
+// interop/zkir), which gnark compile turns into a R1CS. The R1CS, keys and proofs are serialized with
+// their WriteTo methods, and don't record their curve: the commands reading them take a -curve flag.
+// Witnesses are JSON objects mapping input names to decimal or hexadecimal ("0x") strings, as written
+// by io.WriteWitness; the public witness of verify only has the public inputs.
+//
+//	gnark compile -o circuit.r1cs circuit.json
+//	gnark setup -r1cs circuit.r1cs -pk circuit.pk -vk circuit.vk
+//	gnark prove -r1cs circuit.r1cs -pk circuit.pk -witness witness.json -o circuit.proof
+//	gnark verify -vk circuit.vk -proof circuit.proof -public public.json
+//	gnark export solidity -vk circuit.vk -o Verifier.sol
+//	gnark diff old.r1cs new.r1cs
+//	gnark hash circuit.r1cs
+//	gnark reproduce -hash 4f2a...91 go run ./cmd/export
+//	gnark debug -r1cs circuit.r1cs -witness witness.json
+//	gnark gas -vk circuit.vk
+//
+// The flags have the defaults above, and -curve is bn256. verify exits with status 1 if the proof is
+// invalid. export writes the verifier contract (solidity) or the snarkjs verification key (snarkjs)
+// of a verifying key, and the zkir IR (zkir) or the graphviz graph (dot) of a R1CS. diff prints the
+// constraints added and removed by namespace, and the changes of the inputs (see backend/r1cs/diff);
+// like diff(1), it exits with status 1 if the circuits differ.
+//
+// hash prints the hash of the constraints of a R1CS, which doesn't depend on the paths of the source
+// files (see artifact.ConstraintsHash): it is the hash to publish with the keys of a circuit.
+// reproduce runs twice a command writing the R1CS on its standard output, checks that the two R1CS are
+// the same, and that their hash is the published hash (-hash); it prints the hash.
+//
+// debug solves a R1CS on a witness step by step, with the commands read from the standard input (see
+// backend/r1cs/debug): type help for the list. It exits with status 1 if a constraint is not satisfied.
+//
+// gas estimates the gas used by the verifier contract of a verifying key (see ethereum.GasCost), or of a
+// number of public inputs (-inputs), before a circuit is written.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/r1cs"
+	"github.com/consensys/gnark/backend/r1cs/debug"
+	"github.com/consensys/gnark/backend/r1cs/diff"
+	"github.com/consensys/gnark/backend/r1cs/dot"
+	"github.com/consensys/gnark/internal/bindings"
+	"github.com/consensys/gnark/interop/ethereum"
+	"github.com/consensys/gnark/interop/snarkjs"
+	"github.com/consensys/gnark/interop/zkir"
+	gnarkio "github.com/consensys/gnark/io"
+	"github.com/consensys/gnark/io/artifact"
+	"github.com/consensys/gurvy"
+)
+
+const usage = `usage: gnark <command> [flags]
+
+commands:
+  compile    compile a zkir circuit to a R1CS
+  setup      generate the proving and verifying keys of a R1CS
+  prove      prove a witness
+  verify     verify a proof
+  export     export a verifying key (solidity, snarkjs) or a R1CS (zkir, dot)
+  diff       compare two versions of a R1CS
+  hash       print the hash of the constraints of a R1CS
+  reproduce  check that a command compiles a circuit reproducibly, to a published hash
+  debug      solve a R1CS on a witness step by step
+  gas        estimate the gas used by the solidity verifier
+
+run gnark <command> -h for the flags of a command
+`
+
+// stdin is read by the interactive commands (debug)
+var stdin io.Reader = os.Stdin
+
+var (
+	errUsage     = errors.New("invalid usage")
+	errDifferent = errors.New("the circuits differ")
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		if err != errUsage && err != errDifferent && err != flag.ErrHelp {
+			fmt.Fprintln(os.Stderr, "gnark:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdout io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return errUsage
+	}
+	commands := map[string]func([]string, io.Writer) error{
+		"compile":   compile,
+		"setup":     setup,
+		"prove":     prove,
+		"verify":    verify,
+		"export":    export,
+		"diff":      diffR1CS,
+		"hash":      hash,
+		"reproduce": reproduce,
+		"debug":     debugR1CS,
+		"gas":       gas,
+	}
+	command, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprint(os.Stderr, usage)
+		return errUsage
+	}
+	return command(args[1:], stdout)
+}
+
+func newFlagSet(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: gnark %s [flags] %s\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func compile(args []string, stdout io.Writer) error {
+	fs := newFlagSet("compile", "circuit.json")
+	output := fs.String("o", "circuit.r1cs", "output R1CS")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_r1cs, err := zkir.Read(f)
+	if err != nil {
+		return err
+	}
+	if err := writeFile(*output, _r1cs); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "%s: %d constraints, %d wires\n", _r1cs.GetCurveID(), _r1cs.GetNbConstraints(), _r1cs.GetNbWires())
+	return nil
+}
+
+func setup(args []string, stdout io.Writer) error {
+	fs := newFlagSet("setup", "")
+	curve := fs.String("curve", "bn256", "curve of the R1CS")
+	r1csPath := fs.String("r1cs", "circuit.r1cs", "input R1CS")
+	pkPath := fs.String("pk", "circuit.pk", "output proving key")
+	vkPath := fs.String("vk", "circuit.vk", "output verifying key")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	_r1cs, err := ioutil.ReadFile(*r1csPath)
+	if err != nil {
+		return err
+	}
+	pk, vk, err := bindings.Setup(*curve, _r1cs)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(*pkPath, pk, 0644); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(*vkPath, vk, 0644)
+}
+
+func prove(args []string, stdout io.Writer) error {
+	fs := newFlagSet("prove", "")
+	curve := fs.String("curve", "bn256", "curve of the R1CS")
+	r1csPath := fs.String("r1cs", "circuit.r1cs", "input R1CS")
+	pkPath := fs.String("pk", "circuit.pk", "input proving key")
+	witnessPath := fs.String("witness", "witness.json", "input witness")
+	output := fs.String("o", "circuit.proof", "output proof")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	_r1cs, err := ioutil.ReadFile(*r1csPath)
+	if err != nil {
+		return err
+	}
+	pk, err := ioutil.ReadFile(*pkPath)
+	if err != nil {
+		return err
+	}
+	witness, err := ioutil.ReadFile(*witnessPath)
+	if err != nil {
+		return err
+	}
+	proof, err := bindings.Prove(*curve, _r1cs, pk, string(witness))
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(*output, proof, 0644)
+}
+
+func verify(args []string, stdout io.Writer) error {
+	fs := newFlagSet("verify", "")
+	curve := fs.String("curve", "bn256", "curve of the verifying key")
+	vkPath := fs.String("vk", "circuit.vk", "input verifying key")
+	proofPath := fs.String("proof", "circuit.proof", "input proof")
+	publicPath := fs.String("public", "public.json", "input public witness")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	vk, err := ioutil.ReadFile(*vkPath)
+	if err != nil {
+		return err
+	}
+	proof, err := ioutil.ReadFile(*proofPath)
+	if err != nil {
+		return err
+	}
+	publicWitness, err := ioutil.ReadFile(*publicPath)
+	if err != nil {
+		return err
+	}
+	if err := bindings.Verify(*curve, proof, vk, string(publicWitness)); err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, "proof is valid")
+	return nil
+}
+
+func export(args []string, stdout io.Writer) error {
+	if len(args) == 0 || args[0] != "solidity" && args[0] != "snarkjs" && args[0] != "zkir" && args[0] != "dot" {
+		fmt.Fprintln(os.Stderr, "usage: gnark export solidity|snarkjs|zkir|dot [flags]")
+		return errUsage
+	}
+	format := args[0]
+	fs := newFlagSet("export "+format, "")
+	curve := fs.String("curve", "bn256", "curve of the input")
+	output := fs.String("o", "", "output file (default: standard output)")
+	var vkPath, r1csPath *string
+	var constraints *bool
+	switch format {
+	case "solidity", "snarkjs":
+		vkPath = fs.String("vk", "circuit.vk", "input verifying key")
+	case "zkir", "dot":
+		r1csPath = fs.String("r1cs", "circuit.r1cs", "input R1CS")
+	}
+	if format == "dot" {
+		constraints = fs.Bool("constraints", false, "a node per constraint, instead of a node per namespace")
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	curveID, err := bindings.ParseCurve(*curve)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	switch format {
+	case "solidity", "snarkjs":
+		vk := groth16.NewVerifyingKey(curveID)
+		if err := readObject(*vkPath, vk); err != nil {
+			return err
+		}
+		if format == "solidity" {
+			err = ethereum.ExportSolidity(&buf, vk)
+		} else {
+			err = exportSnarkjs(&buf, vk)
+		}
+	case "zkir", "dot":
+		_r1cs := r1cs.New(curveID)
+		if err := readObject(*r1csPath, _r1cs); err != nil {
+			return err
+		}
+		if format == "zkir" {
+			err = zkir.Write(&buf, _r1cs)
+		} else if *constraints {
+			err = dot.Write(&buf, _r1cs, dot.WithConstraints())
+		} else {
+			err = dot.Write(&buf, _r1cs)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		_, err = stdout.Write(buf.Bytes())
+		return err
+	}
+	return ioutil.WriteFile(*output, buf.Bytes(), 0644)
+}
+
+func diffR1CS(args []string, stdout io.Writer) error {
+	fs := newFlagSet("diff", "old.r1cs new.r1cs")
+	curve := fs.String("curve", "bn256", "curve of the R1CS")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return errUsage
+	}
+	curveID, err := bindings.ParseCurve(*curve)
+	if err != nil {
+		return err
+	}
+
+	old, new := r1cs.New(curveID), r1cs.New(curveID)
+	if err := readObject(fs.Arg(0), old); err != nil {
+		return err
+	}
+	if err := readObject(fs.Arg(1), new); err != nil {
+		return err
+	}
+	report, err := diff.Compare(old, new)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(stdout, report.String()); err != nil {
+		return err
+	}
+	if !report.Equal() {
+		return errDifferent
+	}
+	return nil
+}
+
+func hash(args []string, stdout io.Writer) error {
+	fs := newFlagSet("hash", "circuit.r1cs")
+	curve := fs.String("curve", "bn256", "curve of the R1CS")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+	curveID, err := bindings.ParseCurve(*curve)
+	if err != nil {
+		return err
+	}
+
+	_r1cs := r1cs.New(curveID)
+	if err := readObject(fs.Arg(0), _r1cs); err != nil {
+		return err
+	}
+	h, err := artifact.ConstraintsHash(_r1cs)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, h)
+	return nil
+}
+
+func reproduce(args []string, stdout io.Writer) error {
+	fs := newFlagSet("reproduce", "command [args...]")
+	curve := fs.String("curve", "bn256", "curve of the R1CS")
+	published := fs.String("hash", "", "published hash of the circuit (default: only check that the compilation is reproducible)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errUsage
+	}
+	curveID, err := bindings.ParseCurve(*curve)
+	if err != nil {
+		return err
+	}
+
+	h, err := artifact.CheckReproducibleCommand(curveID, fs.Arg(0), fs.Args()[1:]...)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, h)
+	if *published != "" && h != *published {
+		return fmt.Errorf("%w: expected %s", artifact.ErrHashMismatch, *published)
+	}
+	return nil
+}
+
+func debugR1CS(args []string, stdout io.Writer) error {
+	fs := newFlagSet("debug", "")
+	curve := fs.String("curve", "bn256", "curve of the R1CS")
+	r1csPath := fs.String("r1cs", "circuit.r1cs", "input R1CS")
+	witnessPath := fs.String("witness", "witness.json", "input witness")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	curveID, err := bindings.ParseCurve(*curve)
+	if err != nil {
+		return err
+	}
+
+	_r1cs := r1cs.New(curveID)
+	if err := readObject(*r1csPath, _r1cs); err != nil {
+		return err
+	}
+	f, err := os.Open(*witnessPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	witness := make(map[string]interface{})
+	if err := gnarkio.ReadWitness(f, witness); err != nil {
+		return err
+	}
+	d, err := debug.New(_r1cs, witness)
+	if err != nil {
+		return err
+	}
+	return debug.Run(d, stdin, stdout)
+}
+
+func gas(args []string, stdout io.Writer) error {
+	fs := newFlagSet("gas", "")
+	vkPath := fs.String("vk", "circuit.vk", "input verifying key (bn256)")
+	nbInputs := fs.Int("inputs", -1, "number of public inputs, instead of a verifying key")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var cost ethereum.GasCost
+	if *nbInputs >= 0 {
+		cost = ethereum.EstimateGasInputs(*nbInputs)
+	} else {
+		vk := groth16.NewVerifyingKey(gurvy.BN256)
+		if err := readObject(*vkPath, vk); err != nil {
+			return err
+		}
+		var err error
+		if cost, err = ethereum.EstimateGas(vk); err != nil {
+			return err
+		}
+	}
+	fmt.Fprint(stdout, cost)
+	return nil
+}
+
+func exportSnarkjs(w io.Writer, vk groth16.VerifyingKey) error {
+	_vk, err := snarkjs.ExportVerifyingKey(vk)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(_vk)
+}
+
+func readObject(path string, v io.ReaderFrom) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = v.ReadFrom(f)
+	return err
+}
+
+func writeFile(path string, v io.WriterTo) error {
+	var buf bytes.Buffer
+	if _, err := v.WriteTo(&buf); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, buf.Bytes(), 0644)
+}
@@ -0,0 +1,155 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/interop/ethereum"
+	"github.com/consensys/gnark/interop/zkir"
+	"github.com/consensys/gnark/io/artifact"
+	"github.com/consensys/gurvy"
+	"github.com/stretchr/testify/require"
+)
+
+type cubicCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+// x**3 + x + 5 == y
+func (circuit *cubicCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	x3 := cs.Mul(circuit.X, circuit.X, circuit.X)
+	cs.AssertIsEqual(circuit.Y, cs.Add(x3, circuit.X, 5))
+	return nil
+}
+
+func TestLifecycle(t *testing.T) {
+	assert := require.New(t)
+	dir, err := ioutil.TempDir("", "gnark-cli")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+	path := func(name string) string {
+		return filepath.Join(dir, name)
+	}
+
+	_r1cs, err := frontend.Compile(gurvy.BN256, &cubicCircuit{})
+	assert.NoError(err)
+	f, err := os.Create(path("circuit.json"))
+	assert.NoError(err)
+	assert.NoError(zkir.Write(f, _r1cs))
+	assert.NoError(f.Close())
+	assert.NoError(ioutil.WriteFile(path("witness.json"), []byte(`{"X": "3", "Y": "0x23"}`), 0644))
+	assert.NoError(ioutil.WriteFile(path("public.json"), []byte(`{"Y": "35"}`), 0644))
+	assert.NoError(ioutil.WriteFile(path("wrong.json"), []byte(`{"Y": "36"}`), 0644))
+
+	var stdout bytes.Buffer
+	assert.NoError(run([]string{"compile", "-o", path("circuit.r1cs"), path("circuit.json")}, &stdout))
+	assert.Equal("bn256: 3 constraints, 5 wires\n", stdout.String())
+	assert.NoError(run([]string{"setup", "-r1cs", path("circuit.r1cs"), "-pk", path("circuit.pk"), "-vk", path("circuit.vk")}, &stdout))
+	assert.NoError(run([]string{"prove", "-r1cs", path("circuit.r1cs"), "-pk", path("circuit.pk"), "-witness", path("witness.json"), "-o", path("circuit.proof")}, &stdout))
+
+	stdout.Reset()
+	assert.NoError(run([]string{"verify", "-vk", path("circuit.vk"), "-proof", path("circuit.proof"), "-public", path("public.json")}, &stdout))
+	assert.Equal("proof is valid\n", stdout.String())
+	err = run([]string{"verify", "-vk", path("circuit.vk"), "-proof", path("circuit.proof"), "-public", path("wrong.json")}, &stdout)
+	assert.Error(err)
+
+	// a witness which doesn't solve the circuit
+	assert.NoError(ioutil.WriteFile(path("witness.json"), []byte(`{"X": "3", "Y": "36"}`), 0644))
+	err = run([]string{"prove", "-r1cs", path("circuit.r1cs"), "-pk", path("circuit.pk"), "-witness", path("witness.json"), "-o", path("circuit.proof")}, &stdout)
+	assert.Error(err)
+	stdin = strings.NewReader("continue\n")
+	defer func() { stdin = os.Stdin }()
+	stdout.Reset()
+	err = run([]string{"debug", "-r1cs", path("circuit.r1cs"), "-witness", path("witness.json")}, &stdout)
+	assert.True(errors.Is(err, backend.ErrUnsatisfiedConstraint), "%v", err)
+	assert.Contains(stdout.String(), "inputs:\n  X = 3\n  Y = 36\n")
+
+	stdout.Reset()
+	assert.NoError(run([]string{"export", "solidity", "-vk", path("circuit.vk")}, &stdout))
+	assert.Contains(stdout.String(), "function verifyProof(")
+	stdout.Reset()
+	assert.NoError(run([]string{"gas", "-vk", path("circuit.vk")}, &stdout))
+	assert.Equal(ethereum.EstimateGasInputs(1).String(), stdout.String())
+	stdout.Reset()
+	assert.NoError(run([]string{"export", "snarkjs", "-vk", path("circuit.vk")}, &stdout))
+	assert.Contains(stdout.String(), `"protocol": "groth16"`)
+	stdout.Reset()
+	assert.NoError(run([]string{"export", "dot", "-r1cs", path("circuit.r1cs")}, &stdout))
+	assert.True(strings.HasPrefix(stdout.String(), "digraph circuit {"))
+	assert.NoError(run([]string{"export", "zkir", "-r1cs", path("circuit.r1cs"), "-o", path("exported.json")}, &stdout))
+	exported, err := ioutil.ReadFile(path("exported.json"))
+	assert.NoError(err)
+	original, err := ioutil.ReadFile(path("circuit.json"))
+	assert.NoError(err)
+	assert.JSONEq(string(original), string(exported))
+
+	stdout.Reset()
+	assert.NoError(run([]string{"diff", path("circuit.r1cs"), path("circuit.r1cs")}, &stdout))
+	assert.Equal("constraints: 3 -> 3\n", stdout.String())
+	_r1cs, err = frontend.Compile(gurvy.BN256, &squareCircuit{})
+	assert.NoError(err)
+	f, err = os.Create(path("square.r1cs"))
+	assert.NoError(err)
+	_, err = _r1cs.WriteTo(f)
+	assert.NoError(err)
+	assert.NoError(f.Close())
+	stdout.Reset()
+	assert.Equal(errDifferent, run([]string{"diff", path("circuit.r1cs"), path("square.r1cs")}, &stdout))
+	assert.Contains(stdout.String(), "constraints: 3 -> 2\n")
+
+	hash, err := artifact.ConstraintsHash(_r1cs)
+	assert.NoError(err)
+	stdout.Reset()
+	assert.NoError(run([]string{"hash", path("square.r1cs")}, &stdout))
+	assert.Equal(hash+"\n", stdout.String())
+	stdout.Reset()
+	assert.NoError(run([]string{"reproduce", "-hash", hash, "cat", path("square.r1cs")}, &stdout))
+	assert.Equal(hash+"\n", stdout.String())
+	err = run([]string{"reproduce", "-hash", hash, "cat", path("circuit.r1cs")}, &stdout)
+	assert.True(errors.Is(err, artifact.ErrHashMismatch), "%v", err)
+}
+
+type squareCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (circuit *squareCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	cs.AssertIsEqual(circuit.Y, cs.Mul(circuit.X, circuit.X))
+	return nil
+}
+
+func TestUsage(t *testing.T) {
+	assert := require.New(t)
+	assert.Equal(errUsage, run(nil, ioutil.Discard))
+	assert.Equal(errUsage, run([]string{"unknown"}, ioutil.Discard))
+	assert.Equal(errUsage, run([]string{"export", "pdf"}, ioutil.Discard))
+	assert.Equal(errUsage, run([]string{"compile"}, ioutil.Discard))
+	assert.Equal(errUsage, run([]string{"diff", "old.r1cs"}, ioutil.Discard))
+	assert.Equal(errUsage, run([]string{"reproduce"}, ioutil.Discard))
+	assert.Error(run([]string{"setup", "-curve", "secp256k1", "-r1cs", os.DevNull}, ioutil.Discard))
+}
@@ -1,3 +1,6 @@
 // Package crypto implements pure-go implementations of some crypto primitives used in gnark circuits
 // these are needed for correctness test of the components in gnark/std/ package
+//
+// it also has building blocks of proof systems, usable on their own on top of the curve arithmetic,
+// like the KZG polynomial commitment scheme (crypto/kzg) and the inner product argument (crypto/ipa)
 package crypto
@@ -0,0 +1,126 @@
+// Copyright 2020 ConsenSys Software Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pedersen
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gurvy/bls381/fr"
+	"github.com/consensys/gurvy/bls381/twistededwards"
+)
+
+// bitsOf returns the n bits of v, least significant first
+func bitsOf(v *fr.Element, n int) []bool {
+	var b big.Int
+	v.ToBigIntRegular(&b)
+	res := make([]bool, n)
+	for i := range res {
+		res[i] = b.Bit(i) == 1
+	}
+	return res
+}
+
+func TestSaplingEmptyRoot(t *testing.T) {
+	// the root of the empty note commitment tree of Sapling (depth 32), whose leaves are 1: the
+	// hashFinalSaplingRoot of the blocks before Sapling, as shown by zcashd (big endian)
+	const emptyRoot = "3e49b5f954aa9d3545bc6c37744661eea48d7c34e3000d82b7f0010c30f4c2fb"
+
+	// MerkleCRH(layer, left, right) = PedersenHash("Zcash_PH", l | left | right), l on 6 bits being
+	// the depth of the children
+	var node fr.Element
+	node.SetOne()
+	for l := 0; l < 32; l++ {
+		bits := bitsOf(new(fr.Element).SetUint64(uint64(l)), 6)
+		bits = append(bits, bitsOf(&node, 255)...)
+		bits = append(bits, bitsOf(&node, 255)...)
+		var err error
+		if node, err = Hash(SaplingPersonalization, bits); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var root big.Int
+	root.SetString(emptyRoot, 16)
+	var expected fr.Element
+	expected.SetBigInt(&root)
+	if !node.Equal(&expected) {
+		t.Fatalf("wrong empty root %s", node.String())
+	}
+}
+
+func TestCommit(t *testing.T) {
+	curve := twistededwards.GetEdwardsCurve()
+	msg := []bool{true, false, true, true, false, false, true}
+
+	h, err := HashToPoint(SaplingPersonalization, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !h.IsOnCurve() {
+		t.Fatal("the hash should be on the curve")
+	}
+	// the hash is in the prime order subgroup
+	var o twistededwards.Point
+	o.ScalarMul(&h, &curve.Order)
+	if !o.X.IsZero() {
+		t.Fatal("the hash should be in the prime order subgroup")
+	}
+
+	// without randomness, the commitment is the hash; the commitment is additive in the randomness
+	c0, err := Commit(SaplingPersonalization, msg, big.NewInt(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c0.Equal(&h) {
+		t.Fatal("a commitment with no randomness should be the hash")
+	}
+	c1, err := Commit(SaplingPersonalization, msg, big.NewInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c2, err := Commit(SaplingPersonalization, msg, big.NewInt(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := RandomnessGenerator(SaplingPersonalization)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c1.Add(&c1, &r)
+	if !c1.Equal(&c2) {
+		t.Fatal("the commitment should be additive in the randomness")
+	}
+
+	// the padding of a chunk doesn't change the hash, the personalization does
+	h2, err := HashToPoint(SaplingPersonalization, append(msg, false, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !h2.Equal(&h) {
+		t.Fatal("the bits should be padded with zeros")
+	}
+	h3, err := HashToPoint("gnark_PH", msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h3.Equal(&h) {
+		t.Fatal("the generators should depend on the personalization")
+	}
+
+	if _, err := GroupHash("Zcash", nil); err != errPersonalization {
+		t.Fatal("a personalization of 5 bytes should be rejected")
+	}
+}
@@ -0,0 +1,187 @@
+// Copyright 2020 ConsenSys Software Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pedersen implements windowed Pedersen hashes and commitments as the ones of Zcash Sapling, on
+// the twisted Edwards curve of BN256 (Baby Jubjub)
+//
+// the message, a sequence of bits, is cut in segments of ChunksPerSegment chunks of 3 bits; the chunk
+// j of the segment i adds [enc(chunk)*2^(4j)] G_i to the hash, enc(s0, s1, s2) = (1-2*s2)*(1+s0+2*s1),
+// the generators G_i being derived from a personalization with GroupHash.
+// cf https://zips.z.cash/protocol/protocol.pdf, sections 5.4.1.7 and 5.4.8.3
+package pedersen
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	"github.com/consensys/gnark/crypto/hash/pedersen/internal/blake2s"
+	"github.com/consensys/gurvy/bn256/fr"
+	"github.com/consensys/gurvy/bn256/twistededwards"
+)
+
+// SaplingPersonalization is the personalization of the generators of Zcash Sapling (on Jubjub)
+const SaplingPersonalization = "Zcash_PH"
+
+// ChunksPerSegment is the number of chunks of 3 bits hashed with the same generator
+const ChunksPerSegment = 63
+
+// urs is the uniform random string hashed by GroupHash
+const urs = "096b36a5804bfacef1691e173c366a47ff5ba84a44f26ddd7e8d9f79d5b42df0"
+
+var (
+	errPersonalization = errors.New("pedersen: the personalization must be 8 bytes long")
+	errGroupHash       = errors.New("pedersen: the group hash found no point")
+)
+
+// GroupHash returns a point of the prime order subgroup derived from the personalization (8 bytes) and
+// msg, as FindGroupHash^J of Zcash: the first [cofactor]P which isn't the identity, P being decoded from
+// BLAKE2s-256(personalization, urs | msg | i) for i = 0, 1, ... 255
+func GroupHash(personalization string, msg []byte) (twistededwards.Point, error) {
+	if len(personalization) != 8 {
+		return twistededwards.Point{}, errPersonalization
+	}
+	var p [8]byte
+	copy(p[:], personalization)
+
+	curve := twistededwards.GetEdwardsCurve()
+	var cofactor big.Int
+	curve.Cofactor.ToBigInt(&cofactor)
+
+	input := append(append([]byte(urs), msg...), 0)
+	for i := 0; i < 256; i++ {
+		input[len(input)-1] = byte(i)
+		point, ok := decompress(blake2s.Sum256(input, p), &curve)
+		if !ok {
+			continue
+		}
+		point.ScalarMul(&point, &cofactor)
+		if point.X.IsZero() {
+			continue
+		}
+		return point, nil
+	}
+	return twistededwards.Point{}, errGroupHash
+}
+
+// decompress decodes a point encoded as Zcash's repr_J: Y in little endian on 255 bits, then the
+// parity of X
+func decompress(b [32]byte, curve *twistededwards.CurveParams) (twistededwards.Point, bool) {
+	sign := uint(b[31] >> 7)
+	b[31] &= 0x7f
+	for i := 0; i < len(b)/2; i++ {
+		b[i], b[len(b)-1-i] = b[len(b)-1-i], b[i]
+	}
+	var yInt big.Int
+	if yInt.SetBytes(b[:]).Cmp(fr.Modulus()) >= 0 {
+		return twistededwards.Point{}, false
+	}
+
+	// x² = (1 - y²) / (a - d y²)
+	var x, y, y2, num, den fr.Element
+	y.SetBigInt(&yInt)
+	y2.Square(&y)
+	num.SetOne().Sub(&num, &y2)
+	den.Mul(&curve.D, &y2).Sub(&curve.A, &den)
+	if den.IsZero() {
+		return twistededwards.Point{}, false
+	}
+	den.Inverse(&den)
+	num.Mul(&num, &den)
+	if x.Sqrt(&num) == nil {
+		return twistededwards.Point{}, false
+	}
+
+	var xInt big.Int
+	x.ToBigIntRegular(&xInt)
+	if xInt.Bit(0) != sign {
+		if x.IsZero() {
+			return twistededwards.Point{}, false
+		}
+		x.Neg(&x)
+	}
+	return twistededwards.NewPoint(x, y), true
+}
+
+// Generator returns the generator of the i-th segment: GroupHash(personalization, i) with i on 4 bytes,
+// in little endian
+func Generator(personalization string, i int) (twistededwards.Point, error) {
+	var msg [4]byte
+	binary.LittleEndian.PutUint32(msg[:], uint32(i))
+	return GroupHash(personalization, msg[:])
+}
+
+// RandomnessGenerator returns the generator of the randomness of the commitments: GroupHash(personalization, "r")
+func RandomnessGenerator(personalization string) (twistededwards.Point, error) {
+	return GroupHash(personalization, []byte("r"))
+}
+
+// HashToPoint returns the Pedersen hash of the bits, padded with zeros to a multiple of 3 bits: Zcash's
+// PedersenHashToPoint
+func HashToPoint(personalization string, bits []bool) (twistededwards.Point, error) {
+	curve := twistededwards.GetEdwardsCurve()
+	var zero, one fr.Element
+	one.SetOne()
+	res := twistededwards.NewPoint(zero, one)
+
+	bit := func(i int) int64 {
+		if i < len(bits) && bits[i] {
+			return 1
+		}
+		return 0
+	}
+
+	for i := 0; i*3*ChunksPerSegment < len(bits); i++ {
+		var scalar, enc big.Int
+		start := i * 3 * ChunksPerSegment
+		for j := 0; j < ChunksPerSegment && start+3*j < len(bits); j++ {
+			k := start + 3*j
+			enc.SetInt64((1 - 2*bit(k+2)) * (1 + bit(k) + 2*bit(k+1)))
+			scalar.Add(&scalar, enc.Lsh(&enc, uint(4*j)))
+		}
+		scalar.Mod(&scalar, &curve.Order)
+
+		g, err := Generator(personalization, i)
+		if err != nil {
+			return twistededwards.Point{}, err
+		}
+		g.ScalarMul(&g, &scalar)
+		res.Add(&res, &g)
+	}
+	return res, nil
+}
+
+// Hash returns the X coordinate of HashToPoint: Zcash's PedersenHash
+func Hash(personalization string, bits []bool) (fr.Element, error) {
+	p, err := HashToPoint(personalization, bits)
+	return p.X, err
+}
+
+// Commit returns the windowed Pedersen commitment to the bits: HashToPoint(bits) + [randomness] R, R being
+// RandomnessGenerator(personalization)
+func Commit(personalization string, bits []bool, randomness *big.Int) (twistededwards.Point, error) {
+	res, err := HashToPoint(personalization, bits)
+	if err != nil {
+		return twistededwards.Point{}, err
+	}
+	r, err := RandomnessGenerator(personalization)
+	if err != nil {
+		return twistededwards.Point{}, err
+	}
+	curve := twistededwards.GetEdwardsCurve()
+	var s big.Int
+	s.Mod(randomness, &curve.Order)
+	r.ScalarMul(&r, &s)
+	return *res.Add(&res, &r), nil
+}
@@ -0,0 +1,86 @@
+// Copyright 2020 ConsenSys Software Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pedersen
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gurvy/bn256/twistededwards"
+)
+
+func TestCommit(t *testing.T) {
+	curve := twistededwards.GetEdwardsCurve()
+	msg := []bool{true, false, true, true, false, false, true}
+
+	h, err := HashToPoint(SaplingPersonalization, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !h.IsOnCurve() {
+		t.Fatal("the hash should be on the curve")
+	}
+	// the hash is in the prime order subgroup
+	var o twistededwards.Point
+	o.ScalarMul(&h, &curve.Order)
+	if !o.X.IsZero() {
+		t.Fatal("the hash should be in the prime order subgroup")
+	}
+
+	// without randomness, the commitment is the hash; the commitment is additive in the randomness
+	c0, err := Commit(SaplingPersonalization, msg, big.NewInt(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c0.Equal(&h) {
+		t.Fatal("a commitment with no randomness should be the hash")
+	}
+	c1, err := Commit(SaplingPersonalization, msg, big.NewInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c2, err := Commit(SaplingPersonalization, msg, big.NewInt(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := RandomnessGenerator(SaplingPersonalization)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c1.Add(&c1, &r)
+	if !c1.Equal(&c2) {
+		t.Fatal("the commitment should be additive in the randomness")
+	}
+
+	// the padding of a chunk doesn't change the hash, the personalization does
+	h2, err := HashToPoint(SaplingPersonalization, append(msg, false, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !h2.Equal(&h) {
+		t.Fatal("the bits should be padded with zeros")
+	}
+	h3, err := HashToPoint("gnark_PH", msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h3.Equal(&h) {
+		t.Fatal("the generators should depend on the personalization")
+	}
+
+	if _, err := GroupHash("Zcash", nil); err != errPersonalization {
+		t.Fatal("a personalization of 5 bytes should be rejected")
+	}
+}
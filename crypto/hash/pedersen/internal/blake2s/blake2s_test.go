@@ -0,0 +1,58 @@
+// Copyright 2020 ConsenSys Software Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blake2s
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"golang.org/x/crypto/blake2s"
+)
+
+func data(n int) []byte {
+	res := make([]byte, n)
+	for i := range res {
+		res[i] = byte(i)
+	}
+	return res
+}
+
+func TestSum256(t *testing.T) {
+	// without personalization, the digests of golang.org/x/crypto/blake2s
+	for _, n := range []int{0, 1, 63, 64, 65, 200} {
+		if Sum256(data(n), [8]byte{}) != blake2s.Sum256(data(n)) {
+			t.Fatalf("wrong digest of %d bytes", n)
+		}
+	}
+
+	// with a personalization, digests computed with python's hashlib
+	vectors := []struct {
+		personalization string
+		data            []byte
+		digest          string
+	}{
+		{"Zcash_PH", nil, "479cbfb374ecacb5f567b8185ddabb6d1ee703df40fc0dc9fe34d0c9f9e2b6b5"},
+		{"Zcash_PH", data(64), "80eac167076fc215a0ae83ef83f9e04c59f2ae3d14c0c9afaa9d76a41791d902"},
+		{"12345678", data(100), "cdbf5d3ca026af36d8e9ea856eeb2f92657543bf54cdeeaace8cc6827c2f88ac"},
+	}
+	for _, v := range vectors {
+		var personalization [8]byte
+		copy(personalization[:], v.personalization)
+		digest := Sum256(v.data, personalization)
+		if hex.EncodeToString(digest[:]) != v.digest {
+			t.Fatalf("wrong digest with personalization %s", v.personalization)
+		}
+	}
+}
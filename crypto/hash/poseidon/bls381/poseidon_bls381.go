@@ -0,0 +1,333 @@
+// Copyright 2020 ConsenSys Software Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by gnark DO NOT EDIT
+
+// Package bls381 implements the Poseidon hash function on the scalar field of BLS381
+//
+// cf https://eprint.iacr.org/2019/458
+package bls381
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"sync"
+
+	"github.com/consensys/gurvy/bls381/fr"
+)
+
+var (
+	errWidth = errors.New("poseidon: the state must have at least 2 elements")
+	errEmpty = errors.New("poseidon: nothing to hash")
+)
+
+// securityLevel of the parameters, in bits
+const securityLevel = 128
+
+// Params are the parameters of the Poseidon permutation of a state of T elements
+//
+// they are shared by the callers of NewParams and must not be modified.
+type Params struct {
+	T              int
+	Alpha          uint64 // the S-box is x -> x^Alpha
+	FullRounds     int    // half of them before the partial rounds, half after
+	PartialRounds  int
+	RoundConstants []fr.Element // T constants per round
+	MDS            [][]fr.Element
+	alpha          big.Int
+}
+
+var cache = struct {
+	sync.Mutex
+	params map[int]*Params
+}{params: make(map[int]*Params)}
+
+// NewParams returns the parameters of the Poseidon permutation of a state of t elements, generated as
+// in the reference implementation of the paper:
+//
+//   - Alpha is the smallest integer >= 3 such that x -> x^Alpha is a permutation of fr
+//   - the numbers of rounds are the smallest ones (in S-boxes) resisting the attacks of the paper at the
+//     128 bits security level, with its security margin; the partial rounds are rounded up to a multiple of t
+//   - the round constants and the Cauchy MDS matrix are sampled with the Grain LFSR
+//
+// on BN256, the hash of 1 to 16 elements is the one of circomlib. The reference checks that the MDS
+// matrix has no invariant subspace of infinitely long subspace trails, which isn't checked here.
+// cf https://eprint.iacr.org/2019/458
+func NewParams(t int) (*Params, error) {
+	if t < 2 {
+		return nil, errWidth
+	}
+	cache.Lock()
+	defer cache.Unlock()
+	if p, ok := cache.params[t]; ok {
+		return p, nil
+	}
+
+	modulus := fr.Modulus()
+	p := &Params{T: t, Alpha: sboxExponent(modulus)}
+	p.alpha.SetUint64(p.Alpha)
+	p.FullRounds, p.PartialRounds = roundNumbers(modulus, t, p.Alpha)
+
+	n := modulus.BitLen()
+	g := newGrain(n, t, p.FullRounds, p.PartialRounds)
+	p.RoundConstants = make([]fr.Element, (p.FullRounds+p.PartialRounds)*t)
+	for i := range p.RoundConstants {
+		v := g.bigInt(n)
+		for v.Cmp(modulus) >= 0 {
+			v = g.bigInt(n)
+		}
+		p.RoundConstants[i].SetBigInt(v)
+	}
+
+	// M[i][j] = 1 / (x[i] + y[j]) with the x[i] and the y[j] pairwise distinct
+	var xy []fr.Element
+	for distinct := false; !distinct; {
+		xy = make([]fr.Element, 2*t)
+		for i := range xy {
+			v := g.bigInt(n)
+			xy[i].SetBigInt(v.Mod(v, modulus))
+		}
+		distinct = true
+		for i := range xy {
+			for j := 0; j < i; j++ {
+				distinct = distinct && !xy[i].Equal(&xy[j])
+			}
+		}
+		for i := 0; i < t; i++ {
+			for j := 0; j < t; j++ {
+				var sum fr.Element
+				distinct = distinct && !sum.Add(&xy[i], &xy[t+j]).IsZero()
+			}
+		}
+	}
+	p.MDS = make([][]fr.Element, t)
+	for i := range p.MDS {
+		p.MDS[i] = make([]fr.Element, t)
+		for j := range p.MDS[i] {
+			p.MDS[i][j].Add(&xy[i], &xy[t+j]).Inverse(&p.MDS[i][j])
+		}
+	}
+
+	cache.params[t] = p
+	return p, nil
+}
+
+// sboxExponent returns the smallest alpha >= 3 coprime with q-1
+func sboxExponent(q *big.Int) uint64 {
+	var qMinusOne, alpha, gcd big.Int
+	one := big.NewInt(1)
+	qMinusOne.Sub(q, one)
+	for a := uint64(3); ; a++ {
+		alpha.SetUint64(a)
+		if gcd.GCD(nil, nil, &alpha, &qMinusOne).Cmp(one) == 0 {
+			return a
+		}
+	}
+}
+
+// roundNumbers returns the numbers of full and partial rounds, as the script calc_round_numbers.py
+// of the reference implementation (including its quirks, for the parameters to match)
+func roundNumbers(q *big.Int, t int, alpha uint64) (fullRounds, partialRounds int) {
+	n := float64(q.BitLen())
+	qf, _ := new(big.Float).SetInt(q).Float64()
+	log2q := math.Log2(qf)
+	la := math.Log2(float64(alpha))
+	m := float64(securityLevel)
+	ft := float64(t)
+
+	// the bounds of the statistical, interpolation and Gröbner basis attacks
+	secure := func(rf, rp int) bool {
+		rf1 := 10.0
+		if m <= math.Floor(log2q-(float64(alpha)-1)/2)*(ft+1) {
+			rf1 = 6
+		}
+		rf2 := 1 + math.Ceil(math.Min(m, n)/la) + math.Ceil(math.Log2(ft)/la) - float64(rp)
+		rf3 := math.Min(m, log2q)/la - float64(rp)
+		rf4 := ft - 1 + math.Min(m/(ft+1), log2q/2)/la - float64(rp)
+		rf5 := (ft - 2 + m/(2*la) - float64(rp)) / (ft - 1)
+		bound := math.Max(math.Max(rf1, math.Ceil(rf2)), math.Max(math.Ceil(rf3), math.Max(math.Ceil(rf4), math.Ceil(rf5))))
+		return float64(rf) >= bound
+	}
+
+	cost := math.MaxInt32
+	for rp := 1; rp < 500; rp++ {
+		rpMargin := rp
+		for rf := 4; rf < 100; rf += 2 {
+			if !secure(rf, rpMargin) {
+				continue
+			}
+			// security margin: 2 more full rounds, 7.5% more partial rounds
+			rfMargin := rf + 2
+			rpMargin = int(math.Ceil(float64(rpMargin) * 1.075))
+			if c := rfMargin*t + rpMargin; c < cost || (c == cost && rfMargin < fullRounds) {
+				fullRounds, partialRounds, cost = rfMargin, rpMargin, c
+			}
+		}
+	}
+	partialRounds = (partialRounds + t - 1) / t * t
+	return
+}
+
+// grain is the Grain LFSR of the reference implementation, generating the parameters
+type grain struct {
+	state [80]uint8 // state[(start+i)%80] is the i-th bit
+	start int
+}
+
+func newGrain(n, t, fullRounds, partialRounds int) *grain {
+	var g grain
+	// field (1: prime field), S-box (0: x^alpha), field size, t, full rounds, partial rounds, padding with 1
+	values := []int{1, 0, n, t, fullRounds, partialRounds}
+	sizes := []int{2, 4, 12, 12, 10, 10}
+	i := 0
+	for k, v := range values {
+		for j := sizes[k] - 1; j >= 0; j-- {
+			g.state[i] = uint8(v >> uint(j) & 1)
+			i++
+		}
+	}
+	for ; i < len(g.state); i++ {
+		g.state[i] = 1
+	}
+	for i := 0; i < 160; i++ {
+		g.next()
+	}
+	return &g
+}
+
+func (g *grain) next() uint8 {
+	at := func(i int) uint8 { return g.state[(g.start+i)%80] }
+	b := at(62) ^ at(51) ^ at(38) ^ at(23) ^ at(13) ^ at(0)
+	g.state[g.start] = b
+	g.start = (g.start + 1) % 80
+	return b
+}
+
+// bit returns the next output bit: the second bit of the next pair of bits starting with 1
+func (g *grain) bit() uint {
+	for {
+		b1, b2 := g.next(), g.next()
+		if b1 == 1 {
+			return uint(b2)
+		}
+	}
+}
+
+// bigInt returns the integer of the next n output bits, most significant first
+func (g *grain) bigInt(n int) *big.Int {
+	v := new(big.Int)
+	for i := 0; i < n; i++ {
+		v.Lsh(v, 1)
+		v.SetBit(v, 0, g.bit())
+	}
+	return v
+}
+
+// Permute applies the Poseidon permutation to the state, of length T
+func (p *Params) Permute(state []fr.Element) {
+	if len(state) != p.T {
+		panic("poseidon: the state must have T elements")
+	}
+	tmp := make([]fr.Element, p.T)
+	for r := 0; r < p.FullRounds+p.PartialRounds; r++ {
+		for i := range state {
+			state[i].Add(&state[i], &p.RoundConstants[r*p.T+i])
+		}
+		if r < p.FullRounds/2 || r >= p.FullRounds/2+p.PartialRounds {
+			for i := range state {
+				state[i].Exp(state[i], &p.alpha)
+			}
+		} else {
+			state[0].Exp(state[0], &p.alpha)
+		}
+		for i := range tmp {
+			tmp[i].SetZero()
+			for j := range state {
+				var m fr.Element
+				m.Mul(&p.MDS[i][j], &state[j])
+				tmp[i].Add(&tmp[i], &m)
+			}
+		}
+		copy(state, tmp)
+	}
+}
+
+// Hash returns the Poseidon hash of the inputs: the first element of the permutation (with T = len(inputs)+1)
+// of the inputs, preceded by 0
+func Hash(inputs ...fr.Element) (fr.Element, error) {
+	if len(inputs) == 0 {
+		return fr.Element{}, errEmpty
+	}
+	p, err := NewParams(len(inputs) + 1)
+	if err != nil {
+		return fr.Element{}, err
+	}
+	state := make([]fr.Element, p.T)
+	copy(state[1:], inputs)
+	p.Permute(state)
+	return state[0], nil
+}
+
+// Sponge is a sponge of capacity 1 (the first element of the state) and rate T-1, absorbing and
+// squeezing elements of fr
+//
+// squeezing pads the absorbed elements with 1 then zeros (as many as needed to fill the rate), so that
+// absorbing a and a | 0 gives different outputs. Absorbing after squeezing starts a new absorption.
+type Sponge struct {
+	params    *Params
+	state     []fr.Element
+	pos       int // position in the rate
+	squeezing bool
+}
+
+// NewSponge returns a sponge over a state of t elements
+func NewSponge(t int) (*Sponge, error) {
+	p, err := NewParams(t)
+	if err != nil {
+		return nil, err
+	}
+	return &Sponge{params: p, state: make([]fr.Element, t)}, nil
+}
+
+// Absorb absorbs the inputs
+func (s *Sponge) Absorb(inputs ...fr.Element) {
+	if s.squeezing {
+		s.squeezing, s.pos = false, 0
+	}
+	for i := range inputs {
+		s.state[1+s.pos].Add(&s.state[1+s.pos], &inputs[i])
+		if s.pos++; s.pos == s.params.T-1 {
+			s.params.Permute(s.state)
+			s.pos = 0
+		}
+	}
+}
+
+// Squeeze returns the next output element
+func (s *Sponge) Squeeze() fr.Element {
+	if !s.squeezing {
+		var one fr.Element
+		one.SetOne()
+		s.state[1+s.pos].Add(&s.state[1+s.pos], &one)
+		s.params.Permute(s.state)
+		s.squeezing, s.pos = true, 0
+	}
+	if s.pos == s.params.T-1 {
+		s.params.Permute(s.state)
+		s.pos = 0
+	}
+	s.pos++
+	return s.state[s.pos]
+}
@@ -0,0 +1,89 @@
+// Copyright 2020 ConsenSys Software Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by gnark DO NOT EDIT
+
+package bw761
+
+import (
+	"testing"
+
+	"github.com/consensys/gurvy/bw761/fr"
+)
+
+func elements(n int) []fr.Element {
+	res := make([]fr.Element, n)
+	for i := range res {
+		res[i].SetUint64(uint64(i + 1))
+	}
+	return res
+}
+
+func TestHash(t *testing.T) {
+	// computed with the reference implementation of the parameters
+	expected := []string{
+		"33116861685449954351671099512338868269169571258579530673937954155718529508683954857850691134473240755914807251269",
+		"231824206193180926796170285667453811532309234717541267196921894724216086079805102525650720858369592745432589267971",
+	}
+	for i, n := range []int{2, 16} {
+		h, err := Hash(elements(n)...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var e fr.Element
+		e.SetString(expected[i])
+		if !h.Equal(&e) {
+			t.Fatalf("hash of %d elements: got %s, expected %s", n, h.String(), expected[i])
+		}
+	}
+
+	if _, err := Hash(); err != errEmpty {
+		t.Fatal("hashing nothing should fail")
+	}
+	if _, err := NewParams(1); err != errWidth {
+		t.Fatal("a state of 1 element should be rejected")
+	}
+}
+
+func TestSponge(t *testing.T) {
+	inputs := elements(7)
+
+	squeeze := func(chunks ...[]fr.Element) []fr.Element {
+		s, err := NewSponge(3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, c := range chunks {
+			s.Absorb(c...)
+		}
+		return []fr.Element{s.Squeeze(), s.Squeeze(), s.Squeeze()}
+	}
+
+	all := squeeze(inputs)
+	chunked := squeeze(inputs[:1], inputs[1:4], inputs[4:])
+	for i := range all {
+		if !all[i].Equal(&chunked[i]) {
+			t.Fatal("the output depends on how the inputs are split")
+		}
+	}
+	if all[0].Equal(&all[1]) || all[1].Equal(&all[2]) {
+		t.Fatal("consecutive outputs should differ")
+	}
+
+	var zero fr.Element
+	padded := squeeze(inputs, []fr.Element{zero})
+	if all[0].Equal(&padded[0]) {
+		t.Fatal("absorbing a trailing zero should change the output")
+	}
+}
@@ -0,0 +1,290 @@
+// Copyright 2020 ConsenSys Software Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by gnark DO NOT EDIT
+
+// Package bls377 implements the Rescue-Prime hash function on the scalar field of BLS377
+//
+// cf https://eprint.iacr.org/2020/1143
+package bls377
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/consensys/gurvy/bls377/fr"
+	"golang.org/x/crypto/sha3"
+)
+
+var errWidth = errors.New("rescue: the state must have at least 2 elements")
+
+const (
+	// securityLevel of the parameters, in bits
+	securityLevel = 128
+
+	// capacity of the sponge, in elements
+	capacity = 1
+
+	// primitiveElement is the smallest generator of the multiplicative group of fr
+	primitiveElement = 22
+)
+
+// Params are the parameters of the Rescue-Prime permutation of a state of T elements
+//
+// they are shared by the callers of NewParams and must not be modified.
+type Params struct {
+	T              int
+	Alpha          uint64  // the S-box is x -> x^Alpha, the inverse S-box x -> x^AlphaInv
+	AlphaInv       big.Int // inverse of Alpha modulo r-1
+	Rounds         int
+	RoundConstants []fr.Element // 2*T constants per round
+	MDS            [][]fr.Element
+	alpha          big.Int
+}
+
+var cache = struct {
+	sync.Mutex
+	params map[int]*Params
+}{params: make(map[int]*Params)}
+
+// NewParams returns the parameters of the Rescue-Prime permutation of a state of t elements, generated as
+// in the reference implementation of the paper, for a sponge of capacity 1:
+//
+//   - Alpha is the smallest integer >= 3 such that x -> x^Alpha is a permutation of fr
+//   - the number of rounds resists the Gröbner basis attacks at the 128 bits security level, with a
+//     margin of 50%, and is at least 8
+//   - the MDS matrix is derived from the Vandermonde matrix of the smallest generator of fr*
+//   - the round constants are sampled with SHAKE256, from a description of the parameters
+//
+// cf https://eprint.iacr.org/2020/1143
+func NewParams(t int) (*Params, error) {
+	if t < 2 {
+		return nil, errWidth
+	}
+	cache.Lock()
+	defer cache.Unlock()
+	if p, ok := cache.params[t]; ok {
+		return p, nil
+	}
+
+	modulus := fr.Modulus()
+	p := &Params{T: t, Alpha: sboxExponent(modulus)}
+	p.alpha.SetUint64(p.Alpha)
+	var qMinusOne big.Int
+	qMinusOne.Sub(modulus, big.NewInt(1))
+	p.AlphaInv.ModInverse(&p.alpha, &qMinusOne)
+	p.Rounds = numberOfRounds(t, p.Alpha)
+	p.MDS = mdsMatrix(t)
+	p.RoundConstants = roundConstants(modulus, t, p.Rounds)
+
+	cache.params[t] = p
+	return p, nil
+}
+
+// sboxExponent returns the smallest alpha >= 3 coprime with q-1
+func sboxExponent(q *big.Int) uint64 {
+	var qMinusOne, alpha, gcd big.Int
+	one := big.NewInt(1)
+	qMinusOne.Sub(q, one)
+	for a := uint64(3); ; a++ {
+		alpha.SetUint64(a)
+		if gcd.GCD(nil, nil, &alpha, &qMinusOne).Cmp(one) == 0 {
+			return a
+		}
+	}
+}
+
+// numberOfRounds returns the number of rounds of the reference implementation: 1.5 times the smallest
+// number of rounds l (at least 5) such that binomial(v(l) + d(l), v(l))² > 2^securityLevel, with
+// v(l) = t(l-1) + rate and d(l) = (alpha-1)t(l-1)/2 + 2
+func numberOfRounds(t int, alpha uint64) int {
+	var target, b big.Int
+	target.Lsh(big.NewInt(1), securityLevel)
+	l := 1
+	for ; l < 24; l++ {
+		v := int64(t*(l-1) + t - capacity)
+		d := int64((int(alpha)-1)*t*(l-1)/2 + 2)
+		b.Binomial(v+d, v)
+		if b.Mul(&b, &b).Cmp(&target) > 0 {
+			break
+		}
+	}
+	if l < 5 {
+		l = 5
+	}
+	return (3*l + 1) / 2
+}
+
+// mdsMatrix returns the transpose of the right half of the reduced row echelon form of the t x 2t
+// Vandermonde matrix V[i][j] = g^(i*j), g being primitiveElement
+func mdsMatrix(t int) [][]fr.Element {
+	var g fr.Element
+	g.SetUint64(primitiveElement)
+
+	v := make([][]fr.Element, t)
+	for i := range v {
+		v[i] = make([]fr.Element, 2*t)
+		var gi fr.Element
+		gi.Exp(g, big.NewInt(int64(i)))
+		v[i][0].SetOne()
+		for j := 1; j < 2*t; j++ {
+			v[i][j].Mul(&v[i][j-1], &gi)
+		}
+	}
+
+	// the left half is an invertible Vandermonde matrix: its pivots are on the diagonal
+	for c := 0; c < t; c++ {
+		var inv fr.Element
+		inv.Inverse(&v[c][c])
+		for j := range v[c] {
+			v[c][j].Mul(&v[c][j], &inv)
+		}
+		for r := range v {
+			if r == c || v[r][c].IsZero() {
+				continue
+			}
+			f := v[r][c]
+			for j := range v[r] {
+				var m fr.Element
+				m.Mul(&f, &v[c][j])
+				v[r][j].Sub(&v[r][j], &m)
+			}
+		}
+	}
+
+	res := make([][]fr.Element, t)
+	for i := range res {
+		res[i] = make([]fr.Element, t)
+		for j := range res[i] {
+			res[i][j] = v[j][t+i]
+		}
+	}
+	return res
+}
+
+// roundConstants returns the 2*t*rounds constants of the reference implementation: SHAKE256 of
+// "Rescue-XLIX(q,t,capacity,securityLevel)" is cut in integers of len(q)+1 bytes, in little endian,
+// reduced modulo q
+func roundConstants(q *big.Int, t, rounds int) []fr.Element {
+	bytesPerInt := (q.BitLen()+7)/8 + 1
+	shake := sha3.NewShake256()
+	shake.Write([]byte(fmt.Sprintf("Rescue-XLIX(%s,%d,%d,%d)", q.String(), t, capacity, securityLevel)))
+
+	res := make([]fr.Element, 2*t*rounds)
+	buf := make([]byte, bytesPerInt)
+	var v big.Int
+	for i := range res {
+		shake.Read(buf)
+		for j := 0; j < len(buf)/2; j++ {
+			buf[j], buf[len(buf)-1-j] = buf[len(buf)-1-j], buf[j]
+		}
+		res[i].SetBigInt(v.SetBytes(buf))
+	}
+	return res
+}
+
+// Permute applies the Rescue-Prime permutation to the state, of length T: each round applies the S-box,
+// the MDS matrix and constants, then the inverse S-box, the MDS matrix and constants
+func (p *Params) Permute(state []fr.Element) {
+	if len(state) != p.T {
+		panic("rescue: the state must have T elements")
+	}
+	tmp := make([]fr.Element, p.T)
+	mix := func(constants []fr.Element) {
+		for i := range tmp {
+			tmp[i].Set(&constants[i])
+			for j := range state {
+				var m fr.Element
+				m.Mul(&p.MDS[i][j], &state[j])
+				tmp[i].Add(&tmp[i], &m)
+			}
+		}
+		copy(state, tmp)
+	}
+	for r := 0; r < p.Rounds; r++ {
+		for i := range state {
+			state[i].Exp(state[i], &p.alpha)
+		}
+		mix(p.RoundConstants[2*r*p.T : (2*r+1)*p.T])
+		for i := range state {
+			state[i].Exp(state[i], &p.AlphaInv)
+		}
+		mix(p.RoundConstants[(2*r+1)*p.T : (2*r+2)*p.T])
+	}
+}
+
+// Hash returns the Rescue-Prime hash of the inputs: the first output of a Sponge over 3 elements
+// absorbing them
+func Hash(inputs ...fr.Element) fr.Element {
+	s, err := NewSponge(3)
+	if err != nil {
+		panic(err)
+	}
+	s.Absorb(inputs...)
+	return s.Squeeze()
+}
+
+// Sponge is a sponge of capacity 1 (the first element of the state) and rate T-1, absorbing and
+// squeezing elements of fr, as the one of crypto/hash/poseidon
+//
+// squeezing pads the absorbed elements with 1 then zeros (as many as needed to fill the rate), so that
+// absorbing a and a | 0 gives different outputs. Absorbing after squeezing starts a new absorption.
+type Sponge struct {
+	params    *Params
+	state     []fr.Element
+	pos       int // position in the rate
+	squeezing bool
+}
+
+// NewSponge returns a sponge over a state of t elements
+func NewSponge(t int) (*Sponge, error) {
+	p, err := NewParams(t)
+	if err != nil {
+		return nil, err
+	}
+	return &Sponge{params: p, state: make([]fr.Element, t)}, nil
+}
+
+// Absorb absorbs the inputs
+func (s *Sponge) Absorb(inputs ...fr.Element) {
+	if s.squeezing {
+		s.squeezing, s.pos = false, 0
+	}
+	for i := range inputs {
+		s.state[1+s.pos].Add(&s.state[1+s.pos], &inputs[i])
+		if s.pos++; s.pos == s.params.T-1 {
+			s.params.Permute(s.state)
+			s.pos = 0
+		}
+	}
+}
+
+// Squeeze returns the next output element
+func (s *Sponge) Squeeze() fr.Element {
+	if !s.squeezing {
+		var one fr.Element
+		one.SetOne()
+		s.state[1+s.pos].Add(&s.state[1+s.pos], &one)
+		s.params.Permute(s.state)
+		s.squeezing, s.pos = true, 0
+	}
+	if s.pos == s.params.T-1 {
+		s.params.Permute(s.state)
+		s.pos = 0
+	}
+	s.pos++
+	return s.state[s.pos]
+}
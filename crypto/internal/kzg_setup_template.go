@@ -0,0 +1,317 @@
+package main
+
+const kzgSetupTemplate = `
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+
+	"github.com/consensys/gnark/internal/utils"
+	curve "github.com/consensys/gurvy/{{toLower .Curve}}"
+	"github.com/consensys/gurvy/{{toLower .Curve}}/fr"
+)
+
+// errors returned by the verifications of the updates
+var (
+	ErrInvalidUpdateProof = errors.New("kzg: invalid proof of knowledge of the update")
+	ErrInvalidUpdate      = errors.New("kzg: the SRS doesn't match the update proof")
+	ErrInvalidSRS         = errors.New("kzg: the points of the SRS aren't powers of the same secret")
+	ErrInvalidBeacon      = errors.New("kzg: the SRS isn't the update of the previous one with the beacon")
+)
+
+// UpdateProof proves that an SRS is the update of the previous one by a secret x known to the
+// contributor: the secret s of the SRS becomes s.x
+//
+// the knowledge of x is proven with a Schnorr signature of the hash of the previous SRS
+type UpdateProof struct {
+	X curve.G1Affine // [x]1
+	R curve.G1Affine // [k]1, k the nonce of the signature
+	S fr.Element     // k + c.x, c the hash of the previous SRS, X and R
+}
+
+// InitSRS returns the initial SRS of a ceremony, with the secret 1
+//
+// a ceremony starts from InitSRS (or from the output of a previous ceremony); each contributor calls
+// Update, and the last contribution should be UpdateWithBeacon. Each update is checked against the
+// previous SRS with VerifyUpdate: the secret of the final SRS is unknown as long as one contributor is
+// honest
+func InitSRS(size int) (*SRS, error) {
+	return NewSRS(size, big.NewInt(1))
+}
+
+// Hash returns the hash (sha256) of the points of the SRS, the challenge of the next update
+func (srs *SRS) Hash() []byte {
+	h := sha256.New()
+	for i := range srs.G1 {
+		b := srs.G1[i].RawBytes()
+		h.Write(b[:])
+	}
+	for i := range srs.G2 {
+		b := srs.G2[i].RawBytes()
+		h.Write(b[:])
+	}
+	return h.Sum(nil)
+}
+
+// Update multiplies the secret of the SRS by a random x, which isn't kept, and returns the proof of the
+// update
+func (srs *SRS) Update() (UpdateProof, error) {
+	var x, k fr.Element
+	if _, err := x.SetRandom(); err != nil {
+		return UpdateProof{}, err
+	}
+	if _, err := k.SetRandom(); err != nil {
+		return UpdateProof{}, err
+	}
+	return srs.update(x, k)
+}
+
+// UpdateWithBeacon multiplies the secret of the SRS by a secret derived from a public random beacon
+// (a future block hash, ...), which is hashed 2^iterations times with the hash of the SRS
+//
+// it is the last update of a ceremony: it doesn't depend on the contributors, so none of them could
+// choose their update knowing the final SRS. The update is deterministic: VerifyBeacon replays it
+func (srs *SRS) UpdateWithBeacon(beacon []byte, iterations int) (UpdateProof, error) {
+	h := sha256.Sum256(append(srs.Hash(), beacon...))
+	for i := 0; i < 1<<iterations; i++ {
+		h = sha256.Sum256(h[:])
+	}
+
+	var x, k fr.Element
+	x.SetBytes(h[:])
+	kh := sha256.Sum256(append(h[:], 1))
+	k.SetBytes(kh[:])
+	if x.IsZero() || k.IsZero() {
+		return UpdateProof{}, errors.New("kzg: invalid beacon")
+	}
+	return srs.update(x, k)
+}
+
+func (srs *SRS) update(x, k fr.Element) (UpdateProof, error) {
+	if x.IsZero() {
+		return UpdateProof{}, errors.New("kzg: zero update")
+	}
+	_, _, g1, _ := curve.Generators()
+	var proof UpdateProof
+	var b big.Int
+	proof.X.ScalarMultiplication(&g1, x.ToBigIntRegular(&b))
+	proof.R.ScalarMultiplication(&g1, k.ToBigIntRegular(&b))
+	c := updateChallenge(srs.Hash(), &proof.X, &proof.R)
+	proof.S.Mul(&c, &x).Add(&proof.S, &k)
+
+	// [sⁱ]1 becomes [(s.x)ⁱ]1
+	xs := make([]fr.Element, len(srs.G1))
+	xs[0].SetOne()
+	for i := 1; i < len(xs); i++ {
+		xs[i].Mul(&xs[i-1], &x)
+	}
+	utils.Parallelize(len(srs.G1), func(start, end int) {
+		var b big.Int
+		for i := start; i < end; i++ {
+			srs.G1[i].ScalarMultiplication(&srs.G1[i], xs[i].ToBigIntRegular(&b))
+		}
+	})
+	srs.G2[1].ScalarMultiplication(&srs.G2[1], x.ToBigIntRegular(&b))
+	return proof, nil
+}
+
+// VerifyUpdate checks that next is the update of prev proven by proof, and that the points of next are
+// powers of the same secret
+//
+// the points are assumed to be in the correct subgroup, as checked by ReadFrom
+func VerifyUpdate(prev, next *SRS, proof *UpdateProof) error {
+	if len(prev.G1) != len(next.G1) || len(next.G1) < 2 {
+		return ErrInvalidSRS
+	}
+
+	// the Schnorr signature: [S]1 == R + [c]X
+	if proof.X.IsInfinity() || !proof.X.IsInSubGroup() || !proof.R.IsInSubGroup() {
+		return ErrInvalidUpdateProof
+	}
+	_, _, g1, g2 := curve.Generators()
+	c := updateChallenge(prev.Hash(), &proof.X, &proof.R)
+	var left, right, t curve.G1Jac
+	var b big.Int
+	left.ScalarMultiplication(jacobian(&g1), proof.S.ToBigIntRegular(&b))
+	right.FromAffine(&proof.R)
+	t.ScalarMultiplication(jacobian(&proof.X), c.ToBigIntRegular(&b))
+	right.AddAssign(&t)
+	if !left.Equal(&right) {
+		return ErrInvalidUpdateProof
+	}
+
+	// e([s.x]1, [1]2) == e([x]1, [s]2)
+	var xNeg curve.G1Affine
+	xNeg.Neg(&proof.X)
+	if ok, err := pairingCheck([]curve.G1Affine{next.G1[1], xNeg}, []curve.G2Affine{g2, prev.G2[1]}); err != nil {
+		return err
+	} else if !ok {
+		return ErrInvalidUpdate
+	}
+	return checkPowers(next)
+}
+
+// VerifyBeacon checks that next is the update of prev by UpdateWithBeacon, replaying it
+func VerifyBeacon(prev, next *SRS, beacon []byte, iterations int) error {
+	replay := SRS{G1: append([]curve.G1Affine(nil), prev.G1...), G2: prev.G2}
+	if _, err := replay.UpdateWithBeacon(beacon, iterations); err != nil {
+		return err
+	}
+	if !bytes.Equal(replay.Hash(), next.Hash()) {
+		return ErrInvalidBeacon
+	}
+	return nil
+}
+
+// checkPowers checks that the points of the SRS are [sⁱ]1 and [1]2, [s]2 for the same s, with a random
+// linear combination: e(Σrᵢ.[sⁱ]1, [s]2) == e(Σrᵢ.[sⁱ⁺¹]1, [1]2)
+func checkPowers(srs *SRS) error {
+	_, _, g1, g2 := curve.Generators()
+	if !srs.G1[0].Equal(&g1) || !srs.G2[0].Equal(&g2) || srs.G1[1].IsInfinity() {
+		return ErrInvalidSRS
+	}
+
+	// e([s]1, [1]2) == e([1]1, [s]2)
+	var g1Neg curve.G1Affine
+	g1Neg.Neg(&g1)
+	if ok, err := pairingCheck([]curve.G1Affine{srs.G1[1], g1Neg}, []curve.G2Affine{g2, srs.G2[1]}); err != nil {
+		return err
+	} else if !ok {
+		return ErrInvalidSRS
+	}
+
+	n := len(srs.G1) - 1
+	r := make([]fr.Element, n)
+	var buf [16]byte
+	for i := range r {
+		if _, err := rand.Read(buf[:]); err != nil {
+			return err
+		}
+		r[i].SetBytes(buf[:])
+	}
+	var a, b curve.G1Affine
+	a.MultiExp(srs.G1[:n], regular(r))
+	b.MultiExp(srs.G1[1:], regular(r))
+	b.Neg(&b)
+	if ok, err := pairingCheck([]curve.G1Affine{a, b}, []curve.G2Affine{srs.G2[1], g2}); err != nil {
+		return err
+	} else if !ok {
+		return ErrInvalidSRS
+	}
+	return nil
+}
+
+// updateChallenge returns the hash (sha256) of the hash of the previous SRS, X and R, reduced modulo r
+func updateChallenge(prevHash []byte, X, R *curve.G1Affine) (res fr.Element) {
+	h := sha256.New()
+	h.Write(prevHash)
+	bx, br := X.RawBytes(), R.RawBytes()
+	h.Write(bx[:])
+	h.Write(br[:])
+	res.SetBytes(h.Sum(nil))
+	return
+}
+`
+
+const kzgSetupTestTemplate = `
+import (
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gurvy/{{toLower .Curve}}/fr"
+)
+
+func copySRS(srs *SRS) *SRS {
+	return &SRS{G1: append(srs.G1[:0:0], srs.G1...), G2: srs.G2}
+}
+
+func TestCeremony(t *testing.T) {
+	srs, err := InitSRS(8)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// two contributions and the beacon
+	for i := 0; i < 2; i++ {
+		prev := copySRS(srs)
+		proof, err := srs.Update()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := VerifyUpdate(prev, srs, &proof); err != nil {
+			t.Fatal(err)
+		}
+		// the proof is bound to the previous SRS
+		if err := VerifyUpdate(srs, srs, &proof); err == nil {
+			t.Fatal("expected the verification of a replayed update to fail")
+		}
+	}
+	prev := copySRS(srs)
+	proof, err := srs.UpdateWithBeacon([]byte("beacon"), 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyUpdate(prev, srs, &proof); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyBeacon(prev, srs, []byte("beacon"), 4); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyBeacon(prev, srs, []byte("other beacon"), 4); err != ErrInvalidBeacon {
+		t.Fatal("expected ErrInvalidBeacon, got", err)
+	}
+
+	// the final SRS commits and opens
+	p := randomPolynomial(8)
+	digest, err := Commit(p, srs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var point fr.Element
+	point.SetRandom()
+	opening, err := Open(p, point, srs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify(&digest, &opening, srs); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifyTamperedUpdate(t *testing.T) {
+	srs, err := InitSRS(8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev := copySRS(srs)
+	proof, err := srs.Update()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a point of the SRS isn't a power of the secret
+	tampered := copySRS(srs)
+	tampered.G1[5] = tampered.G1[4]
+	if err := VerifyUpdate(prev, tampered, &proof); err != ErrInvalidSRS {
+		t.Fatal("expected ErrInvalidSRS, got", err)
+	}
+
+	// the SRS is replaced by one with a known secret
+	known, err := NewSRS(8, big.NewInt(42))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyUpdate(prev, known, &proof); err != ErrInvalidUpdate {
+		t.Fatal("expected ErrInvalidUpdate, got", err)
+	}
+
+	// the signature doesn't match
+	forged := proof
+	forged.S.Double(&forged.S)
+	if err := VerifyUpdate(prev, srs, &forged); err != ErrInvalidUpdateProof {
+		t.Fatal("expected ErrInvalidUpdateProof, got", err)
+	}
+}
+`
@@ -0,0 +1,507 @@
+package main
+
+const kzgTemplate = `
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"math/big"
+
+	curve "github.com/consensys/gurvy/{{toLower .Curve}}"
+	"github.com/consensys/gurvy/{{toLower .Curve}}/fr"
+)
+
+var (
+	// ErrInvalidPolynomialSize is returned for a polynomial with more coefficients than the SRS has points
+	ErrInvalidPolynomialSize = errors.New("kzg: the polynomial is larger than the SRS")
+	// ErrInvalidNbDigests is returned when the number of digests doesn't match the number of polynomials
+	// or of opening proofs
+	ErrInvalidNbDigests = errors.New("kzg: the number of digests doesn't match")
+	// ErrVerifyOpeningProof is returned when an opening proof is invalid
+	ErrVerifyOpeningProof = errors.New("kzg: invalid opening proof")
+
+	errSRSSize = errors.New("kzg: the size of the SRS must be at least 2")
+)
+
+// SRS is the structured reference string of the scheme: [sⁱ]1 for i < size, and [1]2, [s]2
+type SRS struct {
+	G1 []curve.G1Affine
+	G2 [2]curve.G2Affine
+}
+
+// NewSRS returns a SRS of the given size from the secret s
+//
+// anyone knowing s can open a commitment to any value: NewSRS is meant for tests, the SRS of a
+// deployment comes from a ceremony
+func NewSRS(size int, s *big.Int) (*SRS, error) {
+	if size < 2 {
+		return nil, errSRSSize
+	}
+	var srs SRS
+	_, _, g1, g2 := curve.Generators()
+
+	var alpha fr.Element
+	alpha.SetBigInt(s)
+	powers := make([]fr.Element, size)
+	powers[0].SetOne()
+	for i := 1; i < size; i++ {
+		powers[i].Mul(&powers[i-1], &alpha)
+	}
+	srs.G1 = curve.BatchScalarMultiplicationG1(&g1, regular(powers))
+
+	srs.G2[0] = g2
+	var b big.Int
+	srs.G2[1].ScalarMultiplication(&g2, alpha.ToBigIntRegular(&b))
+	return &srs, nil
+}
+
+// WriteTo writes the points of the SRS, compressed
+func (srs *SRS) WriteTo(w io.Writer) (int64, error) {
+	enc := curve.NewEncoder(w)
+	toEncode := []interface{}{srs.G1, &srs.G2[0], &srs.G2[1]}
+	for _, v := range toEncode {
+		if err := enc.Encode(v); err != nil {
+			return enc.BytesWritten(), err
+		}
+	}
+	return enc.BytesWritten(), nil
+}
+
+// ReadFrom reads a SRS written by WriteTo
+//
+// the points are checked to be on the curve and in the correct subgroup, not to be powers of the
+// same secret
+func (srs *SRS) ReadFrom(r io.Reader) (int64, error) {
+	dec := curve.NewDecoder(r)
+	toDecode := []interface{}{&srs.G1, &srs.G2[0], &srs.G2[1]}
+	for _, v := range toDecode {
+		if err := dec.Decode(v); err != nil {
+			return dec.BytesRead(), err
+		}
+	}
+	if len(srs.G1) < 2 {
+		return dec.BytesRead(), errSRSSize
+	}
+	return dec.BytesRead(), nil
+}
+
+// Digest is the commitment to a polynomial: [p(s)]1
+type Digest = curve.G1Affine
+
+// OpeningProof proves that a committed polynomial p evaluates to ClaimedValue at Point
+type OpeningProof struct {
+	// H = [(p(s) - p(Point)) / (s - Point)]1
+	H curve.G1Affine
+
+	Point, ClaimedValue fr.Element
+}
+
+// BatchOpeningProof proves that committed polynomials pᵢ evaluate to ClaimedValues[i] at Point
+type BatchOpeningProof struct {
+	// H is the quotient of the opening of Σγⁱpᵢ, see BatchOpenSinglePoint
+	H curve.G1Affine
+
+	Point         fr.Element
+	ClaimedValues []fr.Element
+}
+
+// Commit returns the commitment to the polynomial p, given by its coefficients (constant coefficient
+// first)
+func Commit(p []fr.Element, srs *SRS) (Digest, error) {
+	if len(p) > len(srs.G1) {
+		return Digest{}, ErrInvalidPolynomialSize
+	}
+	var res Digest
+	res.MultiExp(srs.G1[:len(p)], regular(p))
+	return res, nil
+}
+
+// Open returns the proof that p evaluates to p(point) at point
+func Open(p []fr.Element, point fr.Element, srs *SRS) (OpeningProof, error) {
+	if len(p) > len(srs.G1) {
+		return OpeningProof{}, ErrInvalidPolynomialSize
+	}
+	res := OpeningProof{Point: point, ClaimedValue: eval(p, point)}
+	h, err := Commit(quotient(p, point), srs)
+	if err != nil {
+		return OpeningProof{}, err
+	}
+	res.H = h
+	return res, nil
+}
+
+// Verify checks that the polynomial committed in digest evaluates to proof.ClaimedValue at
+// proof.Point, that is e([p(s)]1 - [v]1 + z.H, [1]2) == e(H, [s]2)
+func Verify(digest *Digest, proof *OpeningProof, srs *SRS) error {
+	var v, z big.Int
+	var left, t curve.G1Jac
+	left.FromAffine(digest)
+	t.ScalarMultiplication(jacobian(&srs.G1[0]), proof.ClaimedValue.ToBigIntRegular(&v))
+	left.SubAssign(&t)
+	t.ScalarMultiplication(jacobian(&proof.H), proof.Point.ToBigIntRegular(&z))
+	left.AddAssign(&t)
+
+	var leftAff, hNeg curve.G1Affine
+	leftAff.FromJacobian(&left)
+	hNeg.Neg(&proof.H)
+	ok, err := pairingCheck([]curve.G1Affine{leftAff, hNeg}, srs.G2[:])
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrVerifyOpeningProof
+	}
+	return nil
+}
+
+// BatchOpenSinglePoint returns the proof that the polynomials, committed in digests, evaluate to their
+// ClaimedValues at point
+//
+// the polynomials are folded into Σγⁱpᵢ, γ being the hash (sha256) of the point, the digests and the
+// claimed values, and the proof is the opening of the folded polynomial
+func BatchOpenSinglePoint(polynomials [][]fr.Element, digests []Digest, point fr.Element, srs *SRS) (BatchOpeningProof, error) {
+	if len(polynomials) != len(digests) {
+		return BatchOpeningProof{}, ErrInvalidNbDigests
+	}
+	res := BatchOpeningProof{Point: point, ClaimedValues: make([]fr.Element, len(polynomials))}
+	size := 0
+	for i, p := range polynomials {
+		if len(p) > len(srs.G1) {
+			return BatchOpeningProof{}, ErrInvalidPolynomialSize
+		}
+		if len(p) > size {
+			size = len(p)
+		}
+		res.ClaimedValues[i] = eval(p, point)
+	}
+
+	gamma := challenge(point, digests, res.ClaimedValues)
+	folded := make([]fr.Element, size)
+	var gammaI, t fr.Element
+	gammaI.SetOne()
+	for _, p := range polynomials {
+		for j := range p {
+			t.Mul(&p[j], &gammaI)
+			folded[j].Add(&folded[j], &t)
+		}
+		gammaI.Mul(&gammaI, &gamma)
+	}
+
+	h, err := Commit(quotient(folded, point), srs)
+	if err != nil {
+		return BatchOpeningProof{}, err
+	}
+	res.H = h
+	return res, nil
+}
+
+// BatchVerifySinglePoint checks a proof returned by BatchOpenSinglePoint
+func BatchVerifySinglePoint(digests []Digest, proof *BatchOpeningProof, srs *SRS) error {
+	if len(digests) != len(proof.ClaimedValues) {
+		return ErrInvalidNbDigests
+	}
+	if len(digests) == 0 {
+		return nil
+	}
+	gamma := challenge(proof.Point, digests, proof.ClaimedValues)
+	gammas := make([]fr.Element, len(digests))
+	gammas[0].SetOne()
+	for i := 1; i < len(gammas); i++ {
+		gammas[i].Mul(&gammas[i-1], &gamma)
+	}
+
+	folded := OpeningProof{H: proof.H, Point: proof.Point}
+	var t fr.Element
+	for i := range proof.ClaimedValues {
+		t.Mul(&proof.ClaimedValues[i], &gammas[i])
+		folded.ClaimedValue.Add(&folded.ClaimedValue, &t)
+	}
+	var digest Digest
+	digest.MultiExp(digests, regular(gammas))
+	return Verify(&digest, &folded, srs)
+}
+
+// BatchVerifyMultiPoints checks the opening proofs of digests, at possibly different points, with a
+// single pairing check
+//
+// the checks of the proofs are combined with random coefficients rᵢ:
+// 	e(Σrᵢ.([pᵢ(s)]1 - [vᵢ]1 + zᵢ.Hᵢ), [1]2) == e(Σrᵢ.Hᵢ, [s]2)
+// the error doesn't tell which proof is invalid; Verify does
+func BatchVerifyMultiPoints(digests []Digest, proofs []OpeningProof, srs *SRS) error {
+	if len(digests) != len(proofs) {
+		return ErrInvalidNbDigests
+	}
+	if len(digests) == 0 {
+		return nil
+	}
+
+	// r₀ = 1 and rᵢ random of 128 bits
+	r := make([]fr.Element, len(proofs))
+	r[0].SetOne()
+	var buf [16]byte
+	for i := 1; i < len(r); i++ {
+		if _, err := rand.Read(buf[:]); err != nil {
+			return err
+		}
+		r[i].SetBytes(buf[:])
+	}
+
+	// Σrᵢ.[pᵢ(s)]1 + Σrᵢzᵢ.Hᵢ - (Σrᵢvᵢ).[1]1, and Σrᵢ.Hᵢ
+	points := make([]curve.G1Affine, 0, 2*len(proofs))
+	scalars := make([]fr.Element, 0, 2*len(proofs))
+	hs := make([]curve.G1Affine, len(proofs))
+	var v, t fr.Element
+	for i := range proofs {
+		points = append(points, digests[i], proofs[i].H)
+		t.Mul(&r[i], &proofs[i].Point)
+		scalars = append(scalars, r[i], t)
+		t.Mul(&r[i], &proofs[i].ClaimedValue)
+		v.Add(&v, &t)
+		hs[i] = proofs[i].H
+	}
+	v.Neg(&v)
+	points = append(points, srs.G1[0])
+	scalars = append(scalars, v)
+
+	var left, hSum curve.G1Affine
+	left.MultiExp(points, regular(scalars))
+	hSum.MultiExp(hs, regular(r))
+	hSum.Neg(&hSum)
+	ok, err := pairingCheck([]curve.G1Affine{left, hSum}, srs.G2[:])
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrVerifyOpeningProof
+	}
+	return nil
+}
+
+// challenge returns the hash (sha256) of the point, the digests and the claimed values, reduced
+// modulo r
+func challenge(point fr.Element, digests []Digest, claimedValues []fr.Element) (res fr.Element) {
+	h := sha256.New()
+	b := point.Bytes()
+	h.Write(b[:])
+	for i := range digests {
+		b := digests[i].RawBytes()
+		h.Write(b[:])
+	}
+	for i := range claimedValues {
+		b := claimedValues[i].Bytes()
+		h.Write(b[:])
+	}
+	res.SetBytes(h.Sum(nil))
+	return
+}
+
+// pairingCheck returns true if e(P[0], Q[0]).e(P[1], Q[1]) == 1
+func pairingCheck(P []curve.G1Affine, Q []curve.G2Affine) (bool, error) {
+	{{- if eq .Curve "BW761"}}
+	// TODO temporary while bw761 API catches up in gurvy: MillerLoop only handles one pair, and
+	// FinalExponentiation of a product of Miller loops isn't the product of their final exponentiations,
+	// so each pair is exponentiated separately
+	var res curve.GT
+	res.SetOne()
+	for i := range P {
+		ml, err := curve.MillerLoop(P[i:i+1], Q[i:i+1])
+		if err != nil {
+			return false, err
+		}
+		e := curve.FinalExponentiation(&ml)
+		res.Mul(&res, &e)
+	}
+	var one curve.GT
+	one.SetOne()
+	return res.Equal(&one), nil
+	{{- else}}
+	return curve.PairingCheck(P, Q)
+	{{- end}}
+}
+
+// eval returns p(x), by Horner's method
+func eval(p []fr.Element, x fr.Element) fr.Element {
+	var res fr.Element
+	for i := len(p) - 1; i >= 0; i-- {
+		res.Mul(&res, &x).Add(&res, &p[i])
+	}
+	return res
+}
+
+// quotient returns the coefficients of (p(X) - p(z)) / (X - z)
+func quotient(p []fr.Element, z fr.Element) []fr.Element {
+	if len(p) < 2 {
+		return nil
+	}
+	q := make([]fr.Element, len(p)-1)
+	q[len(q)-1] = p[len(p)-1]
+	for i := len(q) - 2; i >= 0; i-- {
+		q[i].Mul(&q[i+1], &z).Add(&q[i], &p[i+1])
+	}
+	return q
+}
+
+// regular returns a copy of the scalars in regular form, for a multi exponentiation
+func regular(scalars []fr.Element) []fr.Element {
+	res := make([]fr.Element, len(scalars))
+	for i := range scalars {
+		res[i] = scalars[i]
+		res[i].FromMont()
+	}
+	return res
+}
+
+func jacobian(p *curve.G1Affine) *curve.G1Jac {
+	var res curve.G1Jac
+	res.FromAffine(p)
+	return &res
+}
+`
+
+const kzgTestTemplate = `
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gurvy/{{toLower .Curve}}/fr"
+)
+
+func randomPolynomial(size int) []fr.Element {
+	p := make([]fr.Element, size)
+	for i := range p {
+		p[i].SetRandom()
+	}
+	return p
+}
+
+func testSRS(t *testing.T, size int) *SRS {
+	srs, err := NewSRS(size, new(big.Int).SetInt64(42))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return srs
+}
+
+func TestOpen(t *testing.T) {
+	srs := testSRS(t, 16)
+	p := randomPolynomial(16)
+	digest, err := Commit(p, srs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var point fr.Element
+	point.SetRandom()
+	proof, err := Open(p, point, srs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify(&digest, &proof, srs); err != nil {
+		t.Fatal(err)
+	}
+
+	tampered := proof
+	tampered.ClaimedValue.Double(&tampered.ClaimedValue)
+	if err := Verify(&digest, &tampered, srs); err != ErrVerifyOpeningProof {
+		t.Fatal("expected ErrVerifyOpeningProof, got", err)
+	}
+	tampered = proof
+	tampered.Point.Double(&tampered.Point)
+	if err := Verify(&digest, &tampered, srs); err != ErrVerifyOpeningProof {
+		t.Fatal("expected ErrVerifyOpeningProof, got", err)
+	}
+
+	if _, err := Commit(randomPolynomial(17), srs); err != ErrInvalidPolynomialSize {
+		t.Fatal("expected ErrInvalidPolynomialSize, got", err)
+	}
+}
+
+func TestBatchOpenSinglePoint(t *testing.T) {
+	srs := testSRS(t, 16)
+	polynomials := [][]fr.Element{randomPolynomial(16), randomPolynomial(3), randomPolynomial(10)}
+	digests := make([]Digest, len(polynomials))
+	for i, p := range polynomials {
+		var err error
+		if digests[i], err = Commit(p, srs); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var point fr.Element
+	point.SetRandom()
+	proof, err := BatchOpenSinglePoint(polynomials, digests, point, srs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, p := range polynomials {
+		if expected := eval(p, point); !proof.ClaimedValues[i].Equal(&expected) {
+			t.Fatal("wrong claimed value", i)
+		}
+	}
+	if err := BatchVerifySinglePoint(digests, &proof, srs); err != nil {
+		t.Fatal(err)
+	}
+
+	proof.ClaimedValues[1].Double(&proof.ClaimedValues[1])
+	if err := BatchVerifySinglePoint(digests, &proof, srs); err != ErrVerifyOpeningProof {
+		t.Fatal("expected ErrVerifyOpeningProof, got", err)
+	}
+	if err := BatchVerifySinglePoint(digests[1:], &proof, srs); err != ErrInvalidNbDigests {
+		t.Fatal("expected ErrInvalidNbDigests, got", err)
+	}
+}
+
+func TestBatchVerifyMultiPoints(t *testing.T) {
+	srs := testSRS(t, 16)
+	var digests []Digest
+	var proofs []OpeningProof
+	for i := 0; i < 3; i++ {
+		p := randomPolynomial(16 - i)
+		digest, err := Commit(p, srs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var point fr.Element
+		point.SetRandom()
+		proof, err := Open(p, point, srs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		digests = append(digests, digest)
+		proofs = append(proofs, proof)
+	}
+	if err := BatchVerifyMultiPoints(digests, proofs, srs); err != nil {
+		t.Fatal(err)
+	}
+
+	// the proofs of two digests are swapped
+	proofs[0], proofs[1] = proofs[1], proofs[0]
+	if err := BatchVerifyMultiPoints(digests, proofs, srs); err != ErrVerifyOpeningProof {
+		t.Fatal("expected ErrVerifyOpeningProof, got", err)
+	}
+}
+
+func TestSRSSerialization(t *testing.T) {
+	srs := testSRS(t, 8)
+	var buf bytes.Buffer
+	written, err := srs.WriteTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if written != int64(buf.Len()) {
+		t.Fatal("WriteTo returned", written, "bytes, wrote", buf.Len())
+	}
+	var read SRS
+	if n, err := read.ReadFrom(&buf); err != nil || n != written {
+		t.Fatal("ReadFrom returned", n, err)
+	}
+	if len(read.G1) != len(srs.G1) || !read.G2[1].Equal(&srs.G2[1]) {
+		t.Fatal("the SRS doesn't match")
+	}
+	for i := range srs.G1 {
+		if !read.G1[i].Equal(&srs.G1[i]) {
+			t.Fatal("the SRS doesn't match")
+		}
+	}
+}
+`
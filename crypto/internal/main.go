@@ -9,7 +9,7 @@ import (
 	"github.com/consensys/bavard"
 )
 
-//go:generate go run main.go mimc_template.go
+//go:generate go run main.go mimc_template.go kzg_template.go kzg_setup_template.go ipa_template.go poseidon_template.go rescue_template.go
 func main() {
 
 	// -----------------------------------------------------
@@ -44,6 +44,111 @@ func main() {
 		mimcbls377,
 	}
 
+	// -----------------------------------------------------
+	// kzg files
+	for _, curve := range []string{"BN256", "BLS377", "BLS381", "BW761"} {
+		path := "../kzg/" + strings.ToLower(curve) + "/"
+		data = append(data, templateData{
+			Curve:    curve,
+			Path:     path,
+			FileName: "kzg.go",
+			Src:      []string{kzgTemplate},
+			Package:  "kzg",
+			Doc: "implements the KZG polynomial commitment scheme on " + curve + "\n" +
+				"//\n" +
+				"// the polynomials are given by their coefficients (constant coefficient first), in Montgomery form;\n" +
+				"// a polynomial of degree d is committed with a SRS of size at least d+1.\n" +
+				"// cf https://www.iacr.org/archive/asiacrypt2010/6477178/6477178.pdf",
+		}, templateData{
+			Curve:    curve,
+			Path:     path,
+			FileName: "kzg_test.go",
+			Src:      []string{kzgTestTemplate},
+			Package:  "kzg",
+		}, templateData{
+			Curve:    curve,
+			Path:     path,
+			FileName: "setup.go",
+			Src:      []string{kzgSetupTemplate},
+			Package:  "kzg",
+		}, templateData{
+			Curve:    curve,
+			Path:     path,
+			FileName: "setup_test.go",
+			Src:      []string{kzgSetupTestTemplate},
+			Package:  "kzg",
+		})
+	}
+
+	// -----------------------------------------------------
+	// ipa files
+	for _, curve := range []string{"BN256", "BLS377", "BLS381", "BW761"} {
+		path := "../ipa/" + strings.ToLower(curve) + "/"
+		data = append(data, templateData{
+			Curve:    curve,
+			Path:     path,
+			FileName: "ipa.go",
+			Src:      []string{ipaTemplate},
+			Package:  "ipa",
+			Doc: "implements a polynomial commitment scheme on " + curve + " with an inner product argument\n" +
+				"//\n" +
+				"// unlike crypto/kzg, it doesn't use pairings and its reference string has no trapdoor (it is derived\n" +
+				"// from a seed), but the opening proofs have log₂(n) points and their verification is linear in n.\n" +
+				"// The API is the one of crypto/kzg. The commitments aren't hiding.\n" +
+				"// cf https://eprint.iacr.org/2019/1021, section 3",
+		}, templateData{
+			Curve:    curve,
+			Path:     path,
+			FileName: "ipa_test.go",
+			Src:      []string{ipaTestTemplate},
+			Package:  "ipa",
+		})
+	}
+
+	// -----------------------------------------------------
+	// poseidon files
+	for _, curve := range []string{"BN256", "BLS377", "BLS381", "BW761"} {
+		path := "../hash/poseidon/" + strings.ToLower(curve) + "/"
+		data = append(data, templateData{
+			Curve:    curve,
+			Path:     path,
+			FileName: "poseidon_" + strings.ToLower(curve) + ".go",
+			Src:      []string{poseidonTemplate},
+			Package:  strings.ToLower(curve),
+			Doc: "implements the Poseidon hash function on the scalar field of " + curve + "\n" +
+				"//\n" +
+				"// cf https://eprint.iacr.org/2019/458",
+		}, templateData{
+			Curve:    curve,
+			Path:     path,
+			FileName: "poseidon_" + strings.ToLower(curve) + "_test.go",
+			Src:      []string{poseidonTestTemplate},
+			Package:  strings.ToLower(curve),
+		})
+	}
+
+	// -----------------------------------------------------
+	// rescue files
+	for _, curve := range []string{"BN256", "BLS377", "BLS381"} {
+		path := "../hash/rescue/" + strings.ToLower(curve) + "/"
+		data = append(data, templateData{
+			Curve:    curve,
+			Path:     path,
+			FileName: "rescue_" + strings.ToLower(curve) + ".go",
+			Src:      []string{rescueTemplate},
+			Package:  strings.ToLower(curve),
+			Doc: "implements the Rescue-Prime hash function on the scalar field of " + curve + "\n" +
+				"//\n" +
+				"// cf https://eprint.iacr.org/2020/1143",
+		}, templateData{
+			Curve:    curve,
+			Path:     path,
+			FileName: "rescue_" + strings.ToLower(curve) + "_test.go",
+			Src:      []string{rescueTestTemplate},
+			Package:  strings.ToLower(curve),
+		})
+	}
+
 	var wg sync.WaitGroup
 	for _, d := range data {
 		wg.Add(1)
@@ -75,6 +180,7 @@ type templateData struct {
 	FileName string
 	Src      []string
 	Package  string
+	Doc      string // package doc, after "// Package <Package>"
 }
 
 const copyrightHolder = "ConsenSys Software Inc."
@@ -87,7 +193,7 @@ func generate(d templateData) error {
 	}
 
 	if err := bavard.Generate(d.Path+d.FileName, d.Src, d,
-		bavard.Package(d.Package),
+		bavard.Package(d.Package, d.Doc),
 		bavard.Apache2(copyrightHolder, 2020),
 		bavard.GeneratedBy("gnark"),
 		bavard.Format(false),
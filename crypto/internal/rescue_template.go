@@ -0,0 +1,428 @@
+package main
+
+const rescueTemplate = `
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/consensys/gurvy/{{toLower .Curve}}/fr"
+	"golang.org/x/crypto/sha3"
+)
+
+var errWidth = errors.New("rescue: the state must have at least 2 elements")
+
+const (
+	// securityLevel of the parameters, in bits
+	securityLevel = 128
+
+	// capacity of the sponge, in elements
+	capacity = 1
+
+	// primitiveElement is the smallest generator of the multiplicative group of fr
+	{{- if eq .Curve "BN256"}}
+	primitiveElement = 5
+	{{- else if eq .Curve "BLS381"}}
+	primitiveElement = 7
+	{{- else if eq .Curve "BLS377"}}
+	primitiveElement = 22
+	{{- end}}
+)
+
+// Params are the parameters of the Rescue-Prime permutation of a state of T elements
+//
+// they are shared by the callers of NewParams and must not be modified.
+type Params struct {
+	T              int
+	Alpha          uint64  // the S-box is x -> x^Alpha, the inverse S-box x -> x^AlphaInv
+	AlphaInv       big.Int // inverse of Alpha modulo r-1
+	Rounds         int
+	RoundConstants []fr.Element // 2*T constants per round
+	MDS            [][]fr.Element
+	alpha          big.Int
+}
+
+var cache = struct {
+	sync.Mutex
+	params map[int]*Params
+}{params: make(map[int]*Params)}
+
+// NewParams returns the parameters of the Rescue-Prime permutation of a state of t elements, generated as
+// in the reference implementation of the paper, for a sponge of capacity 1:
+//
+// 	- Alpha is the smallest integer >= 3 such that x -> x^Alpha is a permutation of fr
+// 	- the number of rounds resists the Gröbner basis attacks at the 128 bits security level, with a
+// 	margin of 50%, and is at least 8
+// 	- the MDS matrix is derived from the Vandermonde matrix of the smallest generator of fr*
+// 	- the round constants are sampled with SHAKE256, from a description of the parameters
+//
+// cf https://eprint.iacr.org/2020/1143
+func NewParams(t int) (*Params, error) {
+	if t < 2 {
+		return nil, errWidth
+	}
+	cache.Lock()
+	defer cache.Unlock()
+	if p, ok := cache.params[t]; ok {
+		return p, nil
+	}
+
+	modulus := fr.Modulus()
+	p := &Params{T: t, Alpha: sboxExponent(modulus)}
+	p.alpha.SetUint64(p.Alpha)
+	var qMinusOne big.Int
+	qMinusOne.Sub(modulus, big.NewInt(1))
+	p.AlphaInv.ModInverse(&p.alpha, &qMinusOne)
+	p.Rounds = numberOfRounds(t, p.Alpha)
+	p.MDS = mdsMatrix(t)
+	p.RoundConstants = roundConstants(modulus, t, p.Rounds)
+
+	cache.params[t] = p
+	return p, nil
+}
+
+// sboxExponent returns the smallest alpha >= 3 coprime with q-1
+func sboxExponent(q *big.Int) uint64 {
+	var qMinusOne, alpha, gcd big.Int
+	one := big.NewInt(1)
+	qMinusOne.Sub(q, one)
+	for a := uint64(3); ; a++ {
+		alpha.SetUint64(a)
+		if gcd.GCD(nil, nil, &alpha, &qMinusOne).Cmp(one) == 0 {
+			return a
+		}
+	}
+}
+
+// numberOfRounds returns the number of rounds of the reference implementation: 1.5 times the smallest
+// number of rounds l (at least 5) such that binomial(v(l) + d(l), v(l))² > 2^securityLevel, with
+// v(l) = t(l-1) + rate and d(l) = (alpha-1)t(l-1)/2 + 2
+func numberOfRounds(t int, alpha uint64) int {
+	var target, b big.Int
+	target.Lsh(big.NewInt(1), securityLevel)
+	l := 1
+	for ; l < 24; l++ {
+		v := int64(t*(l-1) + t - capacity)
+		d := int64((int(alpha)-1)*t*(l-1)/2 + 2)
+		b.Binomial(v+d, v)
+		if b.Mul(&b, &b).Cmp(&target) > 0 {
+			break
+		}
+	}
+	if l < 5 {
+		l = 5
+	}
+	return (3*l + 1) / 2
+}
+
+// mdsMatrix returns the transpose of the right half of the reduced row echelon form of the t x 2t
+// Vandermonde matrix V[i][j] = g^(i*j), g being primitiveElement
+func mdsMatrix(t int) [][]fr.Element {
+	var g fr.Element
+	g.SetUint64(primitiveElement)
+
+	v := make([][]fr.Element, t)
+	for i := range v {
+		v[i] = make([]fr.Element, 2*t)
+		var gi fr.Element
+		gi.Exp(g, big.NewInt(int64(i)))
+		v[i][0].SetOne()
+		for j := 1; j < 2*t; j++ {
+			v[i][j].Mul(&v[i][j-1], &gi)
+		}
+	}
+
+	// the left half is an invertible Vandermonde matrix: its pivots are on the diagonal
+	for c := 0; c < t; c++ {
+		var inv fr.Element
+		inv.Inverse(&v[c][c])
+		for j := range v[c] {
+			v[c][j].Mul(&v[c][j], &inv)
+		}
+		for r := range v {
+			if r == c || v[r][c].IsZero() {
+				continue
+			}
+			f := v[r][c]
+			for j := range v[r] {
+				var m fr.Element
+				m.Mul(&f, &v[c][j])
+				v[r][j].Sub(&v[r][j], &m)
+			}
+		}
+	}
+
+	res := make([][]fr.Element, t)
+	for i := range res {
+		res[i] = make([]fr.Element, t)
+		for j := range res[i] {
+			res[i][j] = v[j][t+i]
+		}
+	}
+	return res
+}
+
+// roundConstants returns the 2*t*rounds constants of the reference implementation: SHAKE256 of
+// "Rescue-XLIX(q,t,capacity,securityLevel)" is cut in integers of len(q)+1 bytes, in little endian,
+// reduced modulo q
+func roundConstants(q *big.Int, t, rounds int) []fr.Element {
+	bytesPerInt := (q.BitLen()+7)/8 + 1
+	shake := sha3.NewShake256()
+	shake.Write([]byte(fmt.Sprintf("Rescue-XLIX(%s,%d,%d,%d)", q.String(), t, capacity, securityLevel)))
+
+	res := make([]fr.Element, 2*t*rounds)
+	buf := make([]byte, bytesPerInt)
+	var v big.Int
+	for i := range res {
+		shake.Read(buf)
+		for j := 0; j < len(buf)/2; j++ {
+			buf[j], buf[len(buf)-1-j] = buf[len(buf)-1-j], buf[j]
+		}
+		res[i].SetBigInt(v.SetBytes(buf))
+	}
+	return res
+}
+
+// Permute applies the Rescue-Prime permutation to the state, of length T: each round applies the S-box,
+// the MDS matrix and constants, then the inverse S-box, the MDS matrix and constants
+func (p *Params) Permute(state []fr.Element) {
+	if len(state) != p.T {
+		panic("rescue: the state must have T elements")
+	}
+	tmp := make([]fr.Element, p.T)
+	mix := func(constants []fr.Element) {
+		for i := range tmp {
+			tmp[i].Set(&constants[i])
+			for j := range state {
+				var m fr.Element
+				m.Mul(&p.MDS[i][j], &state[j])
+				tmp[i].Add(&tmp[i], &m)
+			}
+		}
+		copy(state, tmp)
+	}
+	for r := 0; r < p.Rounds; r++ {
+		for i := range state {
+			state[i].Exp(state[i], &p.alpha)
+		}
+		mix(p.RoundConstants[2*r*p.T : (2*r+1)*p.T])
+		for i := range state {
+			state[i].Exp(state[i], &p.AlphaInv)
+		}
+		mix(p.RoundConstants[(2*r+1)*p.T : (2*r+2)*p.T])
+	}
+}
+
+// Hash returns the Rescue-Prime hash of the inputs: the first output of a Sponge over 3 elements
+// absorbing them
+func Hash(inputs ...fr.Element) fr.Element {
+	s, err := NewSponge(3)
+	if err != nil {
+		panic(err)
+	}
+	s.Absorb(inputs...)
+	return s.Squeeze()
+}
+
+// Sponge is a sponge of capacity 1 (the first element of the state) and rate T-1, absorbing and
+// squeezing elements of fr, as the one of crypto/hash/poseidon
+//
+// squeezing pads the absorbed elements with 1 then zeros (as many as needed to fill the rate), so that
+// absorbing a and a | 0 gives different outputs. Absorbing after squeezing starts a new absorption.
+type Sponge struct {
+	params    *Params
+	state     []fr.Element
+	pos       int // position in the rate
+	squeezing bool
+}
+
+// NewSponge returns a sponge over a state of t elements
+func NewSponge(t int) (*Sponge, error) {
+	p, err := NewParams(t)
+	if err != nil {
+		return nil, err
+	}
+	return &Sponge{params: p, state: make([]fr.Element, t)}, nil
+}
+
+// Absorb absorbs the inputs
+func (s *Sponge) Absorb(inputs ...fr.Element) {
+	if s.squeezing {
+		s.squeezing, s.pos = false, 0
+	}
+	for i := range inputs {
+		s.state[1+s.pos].Add(&s.state[1+s.pos], &inputs[i])
+		if s.pos++; s.pos == s.params.T-1 {
+			s.params.Permute(s.state)
+			s.pos = 0
+		}
+	}
+}
+
+// Squeeze returns the next output element
+func (s *Sponge) Squeeze() fr.Element {
+	if !s.squeezing {
+		var one fr.Element
+		one.SetOne()
+		s.state[1+s.pos].Add(&s.state[1+s.pos], &one)
+		s.params.Permute(s.state)
+		s.squeezing, s.pos = true, 0
+	}
+	if s.pos == s.params.T-1 {
+		s.params.Permute(s.state)
+		s.pos = 0
+	}
+	s.pos++
+	return s.state[s.pos]
+}
+`
+
+const rescueTestTemplate = `
+import (
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gurvy/{{toLower .Curve}}/fr"
+)
+
+func elements(n int) []fr.Element {
+	res := make([]fr.Element, n)
+	for i := range res {
+		res[i].SetUint64(uint64(i + 1))
+	}
+	return res
+}
+
+func TestParams(t *testing.T) {
+	// computed with the reference implementation of the parameters
+	{{- if eq .Curve "BLS377"}}
+	expected := []int{15, 11, 8, 8}
+	{{- else}}
+	expected := []int{20, 14, 11, 9}
+	{{- end}}
+	for i, rounds := range expected {
+		p, err := NewParams(i + 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.Rounds != rounds {
+			t.Fatalf("state of %d elements: got %d rounds, expected %d", i+2, p.Rounds, rounds)
+		}
+	}
+
+	// the inverse S-box inverts the S-box
+	p, err := NewParams(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var x, y fr.Element
+	x.SetUint64(42)
+	y.Exp(x, &p.AlphaInv).Exp(y, new(big.Int).SetUint64(p.Alpha))
+	if !x.Equal(&y) {
+		t.Fatal("x^(1/alpha)^alpha should be x")
+	}
+
+	if _, err := NewParams(1); err != errWidth {
+		t.Fatal("a state of 1 element should be rejected")
+	}
+}
+
+func TestPermute(t *testing.T) {
+	// computed with the reference implementation
+	{{- if eq .Curve "BN256"}}
+	expected := []string{
+		"6224690566795026170272976986384432621080028281436539532889157379570648910802",
+		"11125085147280074555337181371265636082619440214910773293161304065299707718600",
+		"12118779605307541175395572293313884052054477690855880723785138715937774904848",
+	}
+	{{- else if eq .Curve "BLS381"}}
+	expected := []string{
+		"20837336434853470849910909576721791703386530727763098803394615300550680488910",
+		"25771045850287316209319297577315389859184751579565922583267218707663223737221",
+		"47778332175771177523183464148522719206884558815624567948365727904575578981390",
+	}
+	{{- else if eq .Curve "BLS377"}}
+	expected := []string{
+		"7440479546399644654209174587982400383281606968977568871151553572268084857377",
+		"1529233308784379756927808940345466122578522376546610044729127327489457560468",
+		"5680049028888272925558065028584524603260891508883862133819771698721589645747",
+	}
+	{{- end}}
+	p, err := NewParams(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := make([]fr.Element, 3)
+	state[1].SetUint64(1)
+	state[2].SetUint64(2)
+	p.Permute(state)
+	for i := range state {
+		var e fr.Element
+		e.SetString(expected[i])
+		if !state[i].Equal(&e) {
+			t.Fatalf("element %d: got %s, expected %s", i, state[i].String(), expected[i])
+		}
+	}
+}
+
+func TestHash(t *testing.T) {
+	// computed with the reference implementation of the permutation
+	{{- if eq .Curve "BN256"}}
+	expected := []string{
+		"16173007539050388096964118646278514833761985142775054256198730743978044887917",
+		"14077421430476766671459177958269964626799439113610861461818384827311057099972",
+	}
+	{{- else if eq .Curve "BLS381"}}
+	expected := []string{
+		"1138847655672117030780496901056676388885033893970142468989174212865132394236",
+		"19067309814607356469030970600752248835863186033168646456260852807727923007431",
+	}
+	{{- else if eq .Curve "BLS377"}}
+	expected := []string{
+		"1584927444153968441555617451955652105947305904493609137917507603894129104140",
+		"6188974914589113107129943911045480976147293669560854641708340304942884791131",
+	}
+	{{- end}}
+	for i, n := range []int{2, 16} {
+		h := Hash(elements(n)...)
+		var e fr.Element
+		e.SetString(expected[i])
+		if !h.Equal(&e) {
+			t.Fatalf("hash of %d elements: got %s, expected %s", n, h.String(), expected[i])
+		}
+	}
+}
+
+func TestSponge(t *testing.T) {
+	inputs := elements(7)
+
+	squeeze := func(chunks ...[]fr.Element) []fr.Element {
+		s, err := NewSponge(3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, c := range chunks {
+			s.Absorb(c...)
+		}
+		return []fr.Element{s.Squeeze(), s.Squeeze(), s.Squeeze()}
+	}
+
+	all := squeeze(inputs)
+	chunked := squeeze(inputs[:1], inputs[1:4], inputs[4:])
+	for i := range all {
+		if !all[i].Equal(&chunked[i]) {
+			t.Fatal("the output depends on how the inputs are split")
+		}
+	}
+	if all[0].Equal(&all[1]) || all[1].Equal(&all[2]) {
+		t.Fatal("consecutive outputs should differ")
+	}
+
+	var zero fr.Element
+	padded := squeeze(inputs, []fr.Element{zero})
+	if all[0].Equal(&padded[0]) {
+		t.Fatal("absorbing a trailing zero should change the output")
+	}
+}
+`
@@ -0,0 +1,385 @@
+// Copyright 2020 ConsenSys Software Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by gnark DO NOT EDIT
+
+// Package ipa implements a polynomial commitment scheme on BW761 with an inner product argument
+//
+// unlike crypto/kzg, it doesn't use pairings and its reference string has no trapdoor (it is derived
+// from a seed), but the opening proofs have log₂(n) points and their verification is linear in n.
+// The API is the one of crypto/kzg. The commitments aren't hiding.
+// cf https://eprint.iacr.org/2019/1021, section 3
+package ipa
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/big"
+	"math/bits"
+
+	curve "github.com/consensys/gurvy/bw761"
+	"github.com/consensys/gurvy/bw761/fr"
+)
+
+var (
+	// ErrInvalidPolynomialSize is returned for a polynomial with more coefficients than the SRS has points
+	ErrInvalidPolynomialSize = errors.New("ipa: the polynomial is larger than the SRS")
+	// ErrVerifyOpeningProof is returned when an opening proof is invalid
+	ErrVerifyOpeningProof = errors.New("ipa: invalid opening proof")
+
+	errSRSSize       = errors.New("ipa: the size of the SRS must be a power of 2")
+	errZeroChallenge = errors.New("ipa: zero challenge")
+)
+
+// dst is the domain separation tag of the points of the SRS
+const dst = "gnark-ipa-srs"
+
+// SRS is the reference string of the scheme: points G[i] and U of unknown discrete logarithms,
+// derived from a seed
+type SRS struct {
+	G []curve.G1Affine
+	U curve.G1Affine
+}
+
+// NewSRS returns the SRS of the given size (a power of 2) derived from the seed
+//
+// the points are hashed to the curve, so anyone can check that the SRS doesn't have a trapdoor by
+// deriving it again
+func NewSRS(size int, seed []byte) (*SRS, error) {
+	if size < 1 || size&(size-1) != 0 {
+		return nil, errSRSSize
+	}
+	srs := SRS{G: make([]curve.G1Affine, size)}
+	var err error
+	for i := range srs.G {
+		if srs.G[i], err = hashToG1(seed, uint64(i)); err != nil {
+			return nil, err
+		}
+	}
+	if srs.U, err = hashToG1(seed, uint64(size)); err != nil {
+		return nil, err
+	}
+	return &srs, nil
+}
+
+// hashToG1 hashes seed | i to a point of G1 which isn't the point at infinity
+func hashToG1(seed []byte, i uint64) (curve.G1Affine, error) {
+	msg := make([]byte, len(seed)+9)
+	copy(msg, seed)
+	binary.BigEndian.PutUint64(msg[len(seed):], i)
+
+	// HashToCurveG1Svdw doesn't always return a point of the curve: the message is extended with a
+	// counter until it does
+	for counter := byte(0); ; counter++ {
+		msg[len(msg)-1] = counter
+		p, err := curve.HashToCurveG1Svdw(msg, []byte(dst))
+		if err != nil {
+			return p, err
+		}
+		if p.IsOnCurve() && p.IsInSubGroup() && !p.IsInfinity() {
+			return p, nil
+		}
+	}
+}
+
+// WriteTo writes the points of the SRS, compressed
+func (srs *SRS) WriteTo(w io.Writer) (int64, error) {
+	enc := curve.NewEncoder(w)
+	toEncode := []interface{}{srs.G, &srs.U}
+	for _, v := range toEncode {
+		if err := enc.Encode(v); err != nil {
+			return enc.BytesWritten(), err
+		}
+	}
+	return enc.BytesWritten(), nil
+}
+
+// ReadFrom reads a SRS written by WriteTo
+//
+// the points are checked to be on the curve and in the correct subgroup; NewSRS checks that they are
+// derived from a seed
+func (srs *SRS) ReadFrom(r io.Reader) (int64, error) {
+	dec := curve.NewDecoder(r)
+	toDecode := []interface{}{&srs.G, &srs.U}
+	for _, v := range toDecode {
+		if err := dec.Decode(v); err != nil {
+			return dec.BytesRead(), err
+		}
+	}
+	if len(srs.G) == 0 || len(srs.G)&(len(srs.G)-1) != 0 {
+		return dec.BytesRead(), errSRSSize
+	}
+	return dec.BytesRead(), nil
+}
+
+// Digest is the commitment to a polynomial p: Σpᵢ.G[i]
+type Digest = curve.G1Affine
+
+// OpeningProof proves that a committed polynomial p evaluates to ClaimedValue at Point
+type OpeningProof struct {
+	// L, R are the commitments of the log₂(n) rounds of the inner product argument
+	L, R []curve.G1Affine
+
+	// A is the last coefficient of the folded polynomial
+	A fr.Element
+
+	Point, ClaimedValue fr.Element
+}
+
+// Commit returns the commitment to the polynomial p, given by its coefficients (constant coefficient
+// first)
+func Commit(p []fr.Element, srs *SRS) (Digest, error) {
+	if len(p) > len(srs.G) {
+		return Digest{}, ErrInvalidPolynomialSize
+	}
+	var res Digest
+	res.MultiExp(srs.G[:len(p)], regular(p))
+	return res, nil
+}
+
+// Open returns the proof that p evaluates to p(point) at point
+//
+// the proof is an inner product argument of <p, (1, z, z², ...)> = p(z), with log₂(n) rounds
+// (https://eprint.iacr.org/2019/1021, section 3)
+func Open(p []fr.Element, point fr.Element, srs *SRS) (OpeningProof, error) {
+	if len(p) > len(srs.G) {
+		return OpeningProof{}, ErrInvalidPolynomialSize
+	}
+	n := len(srs.G)
+	a := make([]fr.Element, n)
+	copy(a, p)
+	b := powers(point, n)
+	G := make([]curve.G1Affine, n)
+	copy(G, srs.G)
+
+	res := OpeningProof{Point: point, ClaimedValue: eval(p, point)}
+	digest, err := Commit(p, srs)
+	if err != nil {
+		return OpeningProof{}, err
+	}
+
+	// U' = [ξ]U
+	t := newTranscript(&digest, &res.Point, &res.ClaimedValue)
+	xi, err := t.challenge()
+	if err != nil {
+		return OpeningProof{}, err
+	}
+	var U curve.G1Affine
+	U.ScalarMultiplication(&srs.U, xi.ToBigIntRegular(new(big.Int)))
+
+	for n > 1 {
+		n /= 2
+		aL, aR := a[:n], a[n:]
+		bL, bR := b[:n], b[n:]
+		GL, GR := G[:n], G[n:]
+
+		// L = <aL, G_R> + <aL, bR>.U', R = <aR, G_L> + <aR, bL>.U'
+		L := commitRound(GR, aL, innerProduct(aL, bR), &U)
+		R := commitRound(GL, aR, innerProduct(aR, bL), &U)
+		res.L = append(res.L, L)
+		res.R = append(res.R, R)
+
+		u, err := t.append(&L, &R).challenge()
+		if err != nil {
+			return OpeningProof{}, err
+		}
+		var uInv fr.Element
+		uInv.Inverse(&u)
+
+		// a' = u.aL + u⁻¹.aR, b' = u⁻¹.bL + u.bR, G' = u⁻¹.G_L + u.G_R
+		var x, y fr.Element
+		var uBig, uInvBig big.Int
+		u.ToBigIntRegular(&uBig)
+		uInv.ToBigIntRegular(&uInvBig)
+		for i := 0; i < n; i++ {
+			x.Mul(&aL[i], &u)
+			y.Mul(&aR[i], &uInv)
+			a[i].Add(&x, &y)
+			x.Mul(&bL[i], &uInv)
+			y.Mul(&bR[i], &u)
+			b[i].Add(&x, &y)
+
+			var gl, gr curve.G1Jac
+			gl.ScalarMultiplication(jacobian(&GL[i]), &uInvBig)
+			gr.ScalarMultiplication(jacobian(&GR[i]), &uBig)
+			gl.AddAssign(&gr)
+			G[i].FromJacobian(&gl)
+		}
+		a, b, G = a[:n], b[:n], G[:n]
+	}
+	res.A = a[0]
+	return res, nil
+}
+
+// Verify checks that the polynomial committed in digest evaluates to proof.ClaimedValue at proof.Point
+//
+// the cost of the verification is linear in the size of the SRS (a multi exponentiation)
+func Verify(digest *Digest, proof *OpeningProof, srs *SRS) error {
+	k := bits.TrailingZeros(uint(len(srs.G)))
+	if len(proof.L) != k || len(proof.R) != k {
+		return ErrVerifyOpeningProof
+	}
+
+	t := newTranscript(digest, &proof.Point, &proof.ClaimedValue)
+	xi, err := t.challenge()
+	if err != nil {
+		return err
+	}
+	u := make([]fr.Element, k)
+	for j := range u {
+		if u[j], err = t.append(&proof.L[j], &proof.R[j]).challenge(); err != nil {
+			return err
+		}
+	}
+	uInv := make([]fr.Element, k)
+	for j := range u {
+		uInv[j].Inverse(&u[j])
+	}
+
+	// the folded G and b are <s, G> and <s, b>, with sᵢ the product of the u_j (if the bit of i folded
+	// at round j is 1) or u_j⁻¹ (if it is 0)
+	n := len(srs.G)
+	s := make([]fr.Element, n)
+	for i := range s {
+		s[i].SetOne()
+		for j := 0; j < k; j++ {
+			if (i>>(k-1-j))&1 == 1 {
+				s[i].Mul(&s[i], &u[j])
+			} else {
+				s[i].Mul(&s[i], &uInv[j])
+			}
+		}
+	}
+	bFinal := innerProduct(s, powers(proof.Point, n))
+
+	// C + v.ξ.U + Σ(u_j².L_j + u_j⁻².R_j) == Σ a.sᵢ.G[i] + a.b.ξ.U
+	points := make([]curve.G1Affine, 0, n+2*k+2)
+	scalars := make([]fr.Element, 0, n+2*k+2)
+	var x fr.Element
+	for i := range srs.G {
+		x.Mul(&proof.A, &s[i]).Neg(&x)
+		points = append(points, srs.G[i])
+		scalars = append(scalars, x)
+	}
+	for j := 0; j < k; j++ {
+		var u2, uInv2 fr.Element
+		u2.Square(&u[j])
+		uInv2.Square(&uInv[j])
+		points = append(points, proof.L[j], proof.R[j])
+		scalars = append(scalars, u2, uInv2)
+	}
+	var ab fr.Element
+	ab.Mul(&proof.A, &bFinal)
+	x.Sub(&proof.ClaimedValue, &ab).Mul(&x, &xi)
+	var one fr.Element
+	one.SetOne()
+	points = append(points, srs.U, *digest)
+	scalars = append(scalars, x, one)
+
+	var res curve.G1Jac
+	res.MultiExp(points, regular(scalars))
+	if !res.Z.IsZero() {
+		return ErrVerifyOpeningProof
+	}
+	return nil
+}
+
+// commitRound returns <a, G> + c.U
+func commitRound(G []curve.G1Affine, a []fr.Element, c fr.Element, U *curve.G1Affine) curve.G1Affine {
+	points := append(append(make([]curve.G1Affine, 0, len(G)+1), G...), *U)
+	scalars := append(append(make([]fr.Element, 0, len(a)+1), a...), c)
+	var res curve.G1Affine
+	res.MultiExp(points, regular(scalars))
+	return res
+}
+
+// transcript derives the challenges of the argument (Fiat-Shamir) with sha256
+type transcript struct {
+	state []byte
+}
+
+func newTranscript(digest *Digest, point, claimedValue *fr.Element) *transcript {
+	var t transcript
+	b := digest.RawBytes()
+	t.state = append(t.state, b[:]...)
+	p, v := point.Bytes(), claimedValue.Bytes()
+	t.state = append(t.state, p[:]...)
+	t.state = append(t.state, v[:]...)
+	return &t
+}
+
+func (t *transcript) append(L, R *curve.G1Affine) *transcript {
+	l, r := L.RawBytes(), R.RawBytes()
+	t.state = append(t.state, l[:]...)
+	t.state = append(t.state, r[:]...)
+	return t
+}
+
+// challenge returns the hash of the transcript, reduced modulo r, which becomes the new state
+func (t *transcript) challenge() (fr.Element, error) {
+	h := sha256.Sum256(t.state)
+	t.state = h[:]
+	var res fr.Element
+	res.SetBytes(h[:])
+	if res.IsZero() {
+		return res, errZeroChallenge
+	}
+	return res, nil
+}
+
+// powers returns 1, x, x², ..., xⁿ⁻¹
+func powers(x fr.Element, n int) []fr.Element {
+	res := make([]fr.Element, n)
+	res[0].SetOne()
+	for i := 1; i < n; i++ {
+		res[i].Mul(&res[i-1], &x)
+	}
+	return res
+}
+
+func innerProduct(a, b []fr.Element) fr.Element {
+	var res, t fr.Element
+	for i := range a {
+		t.Mul(&a[i], &b[i])
+		res.Add(&res, &t)
+	}
+	return res
+}
+
+// eval returns p(x), by Horner's method
+func eval(p []fr.Element, x fr.Element) fr.Element {
+	var res fr.Element
+	for i := len(p) - 1; i >= 0; i-- {
+		res.Mul(&res, &x).Add(&res, &p[i])
+	}
+	return res
+}
+
+// regular returns a copy of the scalars in regular form, for a multi exponentiation
+func regular(scalars []fr.Element) []fr.Element {
+	res := make([]fr.Element, len(scalars))
+	for i := range scalars {
+		res[i] = scalars[i]
+		res[i].FromMont()
+	}
+	return res
+}
+
+func jacobian(p *curve.G1Affine) *curve.G1Jac {
+	var res curve.G1Jac
+	res.FromAffine(p)
+	return &res
+}
@@ -0,0 +1,362 @@
+// Copyright 2020 ConsenSys Software Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by gnark DO NOT EDIT
+
+// Package kzg implements the KZG polynomial commitment scheme on BLS381
+//
+// the polynomials are given by their coefficients (constant coefficient first), in Montgomery form;
+// a polynomial of degree d is committed with a SRS of size at least d+1.
+// cf https://www.iacr.org/archive/asiacrypt2010/6477178/6477178.pdf
+package kzg
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"math/big"
+
+	curve "github.com/consensys/gurvy/bls381"
+	"github.com/consensys/gurvy/bls381/fr"
+)
+
+var (
+	// ErrInvalidPolynomialSize is returned for a polynomial with more coefficients than the SRS has points
+	ErrInvalidPolynomialSize = errors.New("kzg: the polynomial is larger than the SRS")
+	// ErrInvalidNbDigests is returned when the number of digests doesn't match the number of polynomials
+	// or of opening proofs
+	ErrInvalidNbDigests = errors.New("kzg: the number of digests doesn't match")
+	// ErrVerifyOpeningProof is returned when an opening proof is invalid
+	ErrVerifyOpeningProof = errors.New("kzg: invalid opening proof")
+
+	errSRSSize = errors.New("kzg: the size of the SRS must be at least 2")
+)
+
+// SRS is the structured reference string of the scheme: [sⁱ]1 for i < size, and [1]2, [s]2
+type SRS struct {
+	G1 []curve.G1Affine
+	G2 [2]curve.G2Affine
+}
+
+// NewSRS returns a SRS of the given size from the secret s
+//
+// anyone knowing s can open a commitment to any value: NewSRS is meant for tests, the SRS of a
+// deployment comes from a ceremony
+func NewSRS(size int, s *big.Int) (*SRS, error) {
+	if size < 2 {
+		return nil, errSRSSize
+	}
+	var srs SRS
+	_, _, g1, g2 := curve.Generators()
+
+	var alpha fr.Element
+	alpha.SetBigInt(s)
+	powers := make([]fr.Element, size)
+	powers[0].SetOne()
+	for i := 1; i < size; i++ {
+		powers[i].Mul(&powers[i-1], &alpha)
+	}
+	srs.G1 = curve.BatchScalarMultiplicationG1(&g1, regular(powers))
+
+	srs.G2[0] = g2
+	var b big.Int
+	srs.G2[1].ScalarMultiplication(&g2, alpha.ToBigIntRegular(&b))
+	return &srs, nil
+}
+
+// WriteTo writes the points of the SRS, compressed
+func (srs *SRS) WriteTo(w io.Writer) (int64, error) {
+	enc := curve.NewEncoder(w)
+	toEncode := []interface{}{srs.G1, &srs.G2[0], &srs.G2[1]}
+	for _, v := range toEncode {
+		if err := enc.Encode(v); err != nil {
+			return enc.BytesWritten(), err
+		}
+	}
+	return enc.BytesWritten(), nil
+}
+
+// ReadFrom reads a SRS written by WriteTo
+//
+// the points are checked to be on the curve and in the correct subgroup, not to be powers of the
+// same secret
+func (srs *SRS) ReadFrom(r io.Reader) (int64, error) {
+	dec := curve.NewDecoder(r)
+	toDecode := []interface{}{&srs.G1, &srs.G2[0], &srs.G2[1]}
+	for _, v := range toDecode {
+		if err := dec.Decode(v); err != nil {
+			return dec.BytesRead(), err
+		}
+	}
+	if len(srs.G1) < 2 {
+		return dec.BytesRead(), errSRSSize
+	}
+	return dec.BytesRead(), nil
+}
+
+// Digest is the commitment to a polynomial: [p(s)]1
+type Digest = curve.G1Affine
+
+// OpeningProof proves that a committed polynomial p evaluates to ClaimedValue at Point
+type OpeningProof struct {
+	// H = [(p(s) - p(Point)) / (s - Point)]1
+	H curve.G1Affine
+
+	Point, ClaimedValue fr.Element
+}
+
+// BatchOpeningProof proves that committed polynomials pᵢ evaluate to ClaimedValues[i] at Point
+type BatchOpeningProof struct {
+	// H is the quotient of the opening of Σγⁱpᵢ, see BatchOpenSinglePoint
+	H curve.G1Affine
+
+	Point         fr.Element
+	ClaimedValues []fr.Element
+}
+
+// Commit returns the commitment to the polynomial p, given by its coefficients (constant coefficient
+// first)
+func Commit(p []fr.Element, srs *SRS) (Digest, error) {
+	if len(p) > len(srs.G1) {
+		return Digest{}, ErrInvalidPolynomialSize
+	}
+	var res Digest
+	res.MultiExp(srs.G1[:len(p)], regular(p))
+	return res, nil
+}
+
+// Open returns the proof that p evaluates to p(point) at point
+func Open(p []fr.Element, point fr.Element, srs *SRS) (OpeningProof, error) {
+	if len(p) > len(srs.G1) {
+		return OpeningProof{}, ErrInvalidPolynomialSize
+	}
+	res := OpeningProof{Point: point, ClaimedValue: eval(p, point)}
+	h, err := Commit(quotient(p, point), srs)
+	if err != nil {
+		return OpeningProof{}, err
+	}
+	res.H = h
+	return res, nil
+}
+
+// Verify checks that the polynomial committed in digest evaluates to proof.ClaimedValue at
+// proof.Point, that is e([p(s)]1 - [v]1 + z.H, [1]2) == e(H, [s]2)
+func Verify(digest *Digest, proof *OpeningProof, srs *SRS) error {
+	var v, z big.Int
+	var left, t curve.G1Jac
+	left.FromAffine(digest)
+	t.ScalarMultiplication(jacobian(&srs.G1[0]), proof.ClaimedValue.ToBigIntRegular(&v))
+	left.SubAssign(&t)
+	t.ScalarMultiplication(jacobian(&proof.H), proof.Point.ToBigIntRegular(&z))
+	left.AddAssign(&t)
+
+	var leftAff, hNeg curve.G1Affine
+	leftAff.FromJacobian(&left)
+	hNeg.Neg(&proof.H)
+	ok, err := pairingCheck([]curve.G1Affine{leftAff, hNeg}, srs.G2[:])
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrVerifyOpeningProof
+	}
+	return nil
+}
+
+// BatchOpenSinglePoint returns the proof that the polynomials, committed in digests, evaluate to their
+// ClaimedValues at point
+//
+// the polynomials are folded into Σγⁱpᵢ, γ being the hash (sha256) of the point, the digests and the
+// claimed values, and the proof is the opening of the folded polynomial
+func BatchOpenSinglePoint(polynomials [][]fr.Element, digests []Digest, point fr.Element, srs *SRS) (BatchOpeningProof, error) {
+	if len(polynomials) != len(digests) {
+		return BatchOpeningProof{}, ErrInvalidNbDigests
+	}
+	res := BatchOpeningProof{Point: point, ClaimedValues: make([]fr.Element, len(polynomials))}
+	size := 0
+	for i, p := range polynomials {
+		if len(p) > len(srs.G1) {
+			return BatchOpeningProof{}, ErrInvalidPolynomialSize
+		}
+		if len(p) > size {
+			size = len(p)
+		}
+		res.ClaimedValues[i] = eval(p, point)
+	}
+
+	gamma := challenge(point, digests, res.ClaimedValues)
+	folded := make([]fr.Element, size)
+	var gammaI, t fr.Element
+	gammaI.SetOne()
+	for _, p := range polynomials {
+		for j := range p {
+			t.Mul(&p[j], &gammaI)
+			folded[j].Add(&folded[j], &t)
+		}
+		gammaI.Mul(&gammaI, &gamma)
+	}
+
+	h, err := Commit(quotient(folded, point), srs)
+	if err != nil {
+		return BatchOpeningProof{}, err
+	}
+	res.H = h
+	return res, nil
+}
+
+// BatchVerifySinglePoint checks a proof returned by BatchOpenSinglePoint
+func BatchVerifySinglePoint(digests []Digest, proof *BatchOpeningProof, srs *SRS) error {
+	if len(digests) != len(proof.ClaimedValues) {
+		return ErrInvalidNbDigests
+	}
+	if len(digests) == 0 {
+		return nil
+	}
+	gamma := challenge(proof.Point, digests, proof.ClaimedValues)
+	gammas := make([]fr.Element, len(digests))
+	gammas[0].SetOne()
+	for i := 1; i < len(gammas); i++ {
+		gammas[i].Mul(&gammas[i-1], &gamma)
+	}
+
+	folded := OpeningProof{H: proof.H, Point: proof.Point}
+	var t fr.Element
+	for i := range proof.ClaimedValues {
+		t.Mul(&proof.ClaimedValues[i], &gammas[i])
+		folded.ClaimedValue.Add(&folded.ClaimedValue, &t)
+	}
+	var digest Digest
+	digest.MultiExp(digests, regular(gammas))
+	return Verify(&digest, &folded, srs)
+}
+
+// BatchVerifyMultiPoints checks the opening proofs of digests, at possibly different points, with a
+// single pairing check
+//
+// the checks of the proofs are combined with random coefficients rᵢ:
+//
+//	e(Σrᵢ.([pᵢ(s)]1 - [vᵢ]1 + zᵢ.Hᵢ), [1]2) == e(Σrᵢ.Hᵢ, [s]2)
+//
+// the error doesn't tell which proof is invalid; Verify does
+func BatchVerifyMultiPoints(digests []Digest, proofs []OpeningProof, srs *SRS) error {
+	if len(digests) != len(proofs) {
+		return ErrInvalidNbDigests
+	}
+	if len(digests) == 0 {
+		return nil
+	}
+
+	// r₀ = 1 and rᵢ random of 128 bits
+	r := make([]fr.Element, len(proofs))
+	r[0].SetOne()
+	var buf [16]byte
+	for i := 1; i < len(r); i++ {
+		if _, err := rand.Read(buf[:]); err != nil {
+			return err
+		}
+		r[i].SetBytes(buf[:])
+	}
+
+	// Σrᵢ.[pᵢ(s)]1 + Σrᵢzᵢ.Hᵢ - (Σrᵢvᵢ).[1]1, and Σrᵢ.Hᵢ
+	points := make([]curve.G1Affine, 0, 2*len(proofs))
+	scalars := make([]fr.Element, 0, 2*len(proofs))
+	hs := make([]curve.G1Affine, len(proofs))
+	var v, t fr.Element
+	for i := range proofs {
+		points = append(points, digests[i], proofs[i].H)
+		t.Mul(&r[i], &proofs[i].Point)
+		scalars = append(scalars, r[i], t)
+		t.Mul(&r[i], &proofs[i].ClaimedValue)
+		v.Add(&v, &t)
+		hs[i] = proofs[i].H
+	}
+	v.Neg(&v)
+	points = append(points, srs.G1[0])
+	scalars = append(scalars, v)
+
+	var left, hSum curve.G1Affine
+	left.MultiExp(points, regular(scalars))
+	hSum.MultiExp(hs, regular(r))
+	hSum.Neg(&hSum)
+	ok, err := pairingCheck([]curve.G1Affine{left, hSum}, srs.G2[:])
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrVerifyOpeningProof
+	}
+	return nil
+}
+
+// challenge returns the hash (sha256) of the point, the digests and the claimed values, reduced
+// modulo r
+func challenge(point fr.Element, digests []Digest, claimedValues []fr.Element) (res fr.Element) {
+	h := sha256.New()
+	b := point.Bytes()
+	h.Write(b[:])
+	for i := range digests {
+		b := digests[i].RawBytes()
+		h.Write(b[:])
+	}
+	for i := range claimedValues {
+		b := claimedValues[i].Bytes()
+		h.Write(b[:])
+	}
+	res.SetBytes(h.Sum(nil))
+	return
+}
+
+// pairingCheck returns true if e(P[0], Q[0]).e(P[1], Q[1]) == 1
+func pairingCheck(P []curve.G1Affine, Q []curve.G2Affine) (bool, error) {
+	return curve.PairingCheck(P, Q)
+}
+
+// eval returns p(x), by Horner's method
+func eval(p []fr.Element, x fr.Element) fr.Element {
+	var res fr.Element
+	for i := len(p) - 1; i >= 0; i-- {
+		res.Mul(&res, &x).Add(&res, &p[i])
+	}
+	return res
+}
+
+// quotient returns the coefficients of (p(X) - p(z)) / (X - z)
+func quotient(p []fr.Element, z fr.Element) []fr.Element {
+	if len(p) < 2 {
+		return nil
+	}
+	q := make([]fr.Element, len(p)-1)
+	q[len(q)-1] = p[len(p)-1]
+	for i := len(q) - 2; i >= 0; i-- {
+		q[i].Mul(&q[i+1], &z).Add(&q[i], &p[i+1])
+	}
+	return q
+}
+
+// regular returns a copy of the scalars in regular form, for a multi exponentiation
+func regular(scalars []fr.Element) []fr.Element {
+	res := make([]fr.Element, len(scalars))
+	for i := range scalars {
+		res[i] = scalars[i]
+		res[i].FromMont()
+	}
+	return res
+}
+
+func jacobian(p *curve.G1Affine) *curve.G1Jac {
+	var res curve.G1Jac
+	res.FromAffine(p)
+	return &res
+}
@@ -0,0 +1,52 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package frontend
+
+import "github.com/consensys/gnark/backend/r1cs/r1c"
+
+// arenaBlockSize is the number of terms of the blocks of a linExpArena
+const arenaBlockSize = 1 << 16
+
+// linExpArena allocates the linear expressions of a constraint system in large blocks of terms, instead
+// of one small slice per expression: compiling a large circuit copies millions of linear expressions
+//
+// the expressions are never freed: a block is collected with the constraint system, or with the R1CS
+// whose constraints use it
+type linExpArena struct {
+	block []r1c.Term // free terms of the current block
+}
+
+// alloc returns a linear expression of n terms; its capacity is n, so appending to it doesn't
+// overwrite the next expression of the block
+func (a *linExpArena) alloc(n int) r1c.LinearExpression {
+	if n > len(a.block) {
+		if n > arenaBlockSize/4 {
+			return make(r1c.LinearExpression, n)
+		}
+		a.block = make([]r1c.Term, arenaBlockSize)
+	}
+	res := a.block[:n:n]
+	a.block = a.block[n:]
+	return res
+}
+
+// copyLinExp returns a copy of l allocated in the arena of the constraint system
+func (cs *ConstraintSystem) copyLinExp(l r1c.LinearExpression) r1c.LinearExpression {
+	res := cs.linExps.alloc(len(l))
+	copy(res, l)
+	return res
+}
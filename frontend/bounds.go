@@ -0,0 +1,126 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package frontend
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/consensys/gnark/backend"
+)
+
+// inputBound is the bound of the inputs of a field tagged with bits or range (see Tag)
+type inputBound struct {
+	name   string
+	inputs []Variable // the leaves of the field
+	nbBits int        // bits=nbBits, or 0
+	lo, hi big.Int    // range=lo..hi, if nbBits is 0
+}
+
+// parseInputBounds returns the bounds of the fields tagged with bits or range in input
+//
+// input is a compiled circuit (the inputs are allocated) or a witness; the fields in the values of a
+// map are not supported (a map value is a copy)
+func parseInputBounds(input interface{}) ([]inputBound, error) {
+	var res []inputBound
+
+	leaves := func(name string, visibility backend.Visibility, f reflect.Value) ([]Variable, error) {
+		var inputs []Variable
+		var handler leafHandler = func(visibility backend.Visibility, name string, tInput reflect.Value) error {
+			inputs = append(inputs, tInput.Interface().(Variable))
+			return nil
+		}
+		if err := parseType(f.Addr().Interface(), name, visibility, handler, ignoreWarning); err != nil {
+			return nil, err
+		}
+		return inputs, nil
+	}
+
+	err := parseTaggedFields(input, "", backend.Unset, optBits, func(name string, visibility backend.Visibility, f reflect.Value, value string) error {
+		nbBits, err := strconv.Atoi(value)
+		if err != nil || nbBits <= 0 {
+			return fmt.Errorf("%s: invalid bits %q", name, value)
+		}
+		if nbBits > maxReducedBits {
+			// the decomposition on nbBits bits wouldn't be unique, and wouldn't bound the inputs (see Cmp)
+			return fmt.Errorf("%s: bits=%d, more than %d", name, nbBits, maxReducedBits)
+		}
+		inputs, err := leaves(name, visibility, f)
+		if err != nil {
+			return err
+		}
+		res = append(res, inputBound{name: name, inputs: inputs, nbBits: nbBits})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = parseTaggedFields(input, "", backend.Unset, optRange, func(name string, visibility backend.Visibility, f reflect.Value, value string) error {
+		b := inputBound{name: name}
+		bounds := strings.SplitN(value, "..", 2)
+		if len(bounds) != 2 {
+			return fmt.Errorf("%s: invalid range %q, expected lo..hi", name, value)
+		}
+		for i, bound := range []*big.Int{&b.lo, &b.hi} {
+			if _, ok := bound.SetString(bounds[i], 0); !ok || bound.Sign() < 0 {
+				return fmt.Errorf("%s: invalid range %q, expected lo..hi", name, value)
+			}
+		}
+		if b.lo.Cmp(&b.hi) > 0 {
+			return fmt.Errorf("%s: empty range %q", name, value)
+		}
+		if b.hi.BitLen() > maxReducedBits {
+			// AssertIsLessOrEqual compares on maxReducedBits bits
+			return fmt.Errorf("%s: range %q doesn't fit in %d bits", name, value, maxReducedBits)
+		}
+		inputs, err := leaves(name, visibility, f)
+		if err != nil {
+			return err
+		}
+		b.inputs = inputs
+		res = append(res, b)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// assert records the constraints bounding the inputs
+func (b *inputBound) assert(cs *ConstraintSystem) {
+	for _, v := range b.inputs {
+		if b.nbBits != 0 {
+			// the binary decomposition constrains v to fit in nbBits
+			cs.ToBinary(v, b.nbBits)
+			continue
+		}
+		if b.lo.Sign() == 0 {
+			cs.AssertIsLessOrEqual(v, b.hi)
+			continue
+		}
+		// lo <= v <= hi iff v-lo <= hi-lo, as v-lo wraps around the modulus if v < lo
+		var width big.Int
+		width.Sub(&b.hi, &b.lo)
+		cs.AssertIsLessOrEqual(cs.Sub(v, b.lo), width)
+	}
+}
@@ -0,0 +1,74 @@
+package frontend
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/consensys/gurvy"
+)
+
+type boundsCircuit struct {
+	X [2]Variable `gnark:",bits=4"`
+	Y Variable    `gnark:",public,range=3..0x10"`
+}
+
+func (circuit *boundsCircuit) Define(curveID gurvy.ID, cs *ConstraintSystem) error {
+	cs.AssertIsEqual(cs.Add(circuit.X[0], circuit.X[1]), circuit.Y)
+	return nil
+}
+
+func TestInputBounds(t *testing.T) {
+
+	_r1cs, err := Compile(gurvy.BN256, &boundsCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		x0, x1 int
+		valid  bool
+	}{
+		{1, 2, true},
+		{15, 1, true},
+		{1, 1, false},   // y < 3
+		{15, 15, false}, // y > 16
+		{16, 0, false},  // x0 doesn't fit in 4 bits
+		{-1, 4, false},  // x0 is p-1
+	} {
+		var witness boundsCircuit
+		witness.X[0].Assign(c.x0)
+		witness.X[1].Assign(c.x1)
+		witness.Y.Assign(c.x0 + c.x1)
+		assignment, err := ParseWitness(&witness)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := _r1cs.IsSolved(assignment); (err == nil) != c.valid {
+			t.Fatal(c.x0, c.x1, "IsSolved: expected valid =", c.valid, "got", err)
+		}
+		if err := Evaluate(gurvy.BN256, &witness); (err == nil) != c.valid {
+			t.Fatal(c.x0, c.x1, "Evaluate: expected valid =", c.valid, "got", err)
+		}
+	}
+
+	type invalidRange struct {
+		X Variable `gnark:",range=5..4"`
+	}
+	if _, err := parseInputBounds(&invalidRange{}); err == nil {
+		t.Fatal("expected an empty range to be rejected")
+	}
+
+	// a decomposition on the bit length of the field isn't unique, and bounds nothing
+	type tooManyBits struct {
+		X Variable `gnark:",bits=254"`
+	}
+	if _, err := parseInputBounds(&tooManyBits{}); err == nil || !strings.Contains(err.Error(), "bits=254") {
+		t.Fatal("expected bits=254 to be rejected, got", err)
+	}
+	type rangeTooLarge struct {
+		X Variable `gnark:",range=0..0x10000000000000000000000000000000000000000000000000000000000000000"`
+	}
+	if _, err := parseInputBounds(&rangeTooLarge{}); err == nil {
+		t.Fatal("expected a range of more than 252 bits to be rejected")
+	}
+}
@@ -0,0 +1,114 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package frontend
+
+import (
+	"math/big"
+
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/r1cs/r1c"
+)
+
+// Builder records a circuit in another arithmetization (a PLONKish or AIR constraint system, ...)
+// while Compile parses its inputs and calls its Define method (see WithBuilder)
+//
+// the builder receives the wires and the constraints recorded by the API of the ConstraintSystem
+// and the std gadgets, in order: the inputs are allocated first, and a constraint only uses wires
+// already allocated. The constraints are rank-1 constraints L * R == O on linear expressions; the
+// builder may split or merge them into its own gates
+type Builder interface {
+	// NewVariable records a new wire: an input (in the order of Inputs), or an internal wire computed
+	// by a computational constraint or by a hint function
+	NewVariable(w BuilderWire)
+
+	// AddConstraint records the constraint L * R == O
+	AddConstraint(c BuilderConstraint)
+
+	// MarkBoolean records that the value of the wire is 0 or 1; the API already recorded the
+	// constraint checking it
+	MarkBoolean(w BuilderWire)
+}
+
+// BuilderWire identifies a wire of the circuit
+type BuilderWire struct {
+	ID         int                // index of the wire among the wires of the same visibility
+	Visibility backend.Visibility // backend.Internal, backend.Secret or backend.Public
+	Name       string             // name of an input, or "" for an internal wire
+}
+
+// BuilderTerm is a term Coeff * Wire of a linear expression
+//
+// the coefficients are not reduced modulo the scalar field (see Compile)
+type BuilderTerm struct {
+	Wire  BuilderWire
+	Coeff big.Int
+}
+
+// BuilderConstraint is a constraint L * R == O
+type BuilderConstraint struct {
+	L, R, O []BuilderTerm
+
+	// Computational is set if the constraint computes a wire of O when solving the circuit, else it
+	// is an assertion
+	Computational bool
+}
+
+// WithBuilder forwards the wires and the constraints of the circuit to b; Compile still returns
+// the R1CS of the circuit
+//
+// the constant wire backend.OneWire is the public wire 0
+func WithBuilder(b Builder) CompileOption {
+	return func(cs *ConstraintSystem) error {
+		cs.builder = b
+		for _, v := range cs.public.variables {
+			b.NewVariable(cs.builderWire(v.Wire))
+		}
+		return nil
+	}
+}
+
+// builderWire returns the description of w given to the Builder
+func (cs *ConstraintSystem) builderWire(w Wire) BuilderWire {
+	res := BuilderWire{ID: w.id, Visibility: w.visibility}
+	switch w.visibility {
+	case backend.Public:
+		res.Name = cs.public.names[w.id]
+	case backend.Secret:
+		res.Name = cs.secret.names[w.id]
+	}
+	return res
+}
+
+// forwardConstraint records r with the Builder
+func (cs *ConstraintSystem) forwardConstraint(r *r1c.R1C, computational bool) {
+	cs.builder.AddConstraint(BuilderConstraint{
+		L:             cs.builderTerms(r.L),
+		R:             cs.builderTerms(r.R),
+		O:             cs.builderTerms(r.O),
+		Computational: computational,
+	})
+}
+
+func (cs *ConstraintSystem) builderTerms(l r1c.LinearExpression) []BuilderTerm {
+	res := make([]BuilderTerm, len(l))
+	for i, t := range l {
+		_, coeffID, id, visibility := t.Unpack()
+		res[i].Wire = cs.builderWire(Wire{visibility: visibility, id: id})
+		cs.coeffs.Get(coeffID, &res[i].Coeff)
+	}
+	return res
+}
@@ -0,0 +1,98 @@
+package frontend_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/r1cs"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/internal/backend/circuits"
+	"github.com/consensys/gurvy"
+)
+
+// recordingBuilder records the wires and counts the constraints of a circuit
+type recordingBuilder struct {
+	t                           *testing.T
+	wires                       map[frontend.BuilderWire]bool
+	nbConstraints, nbAssertions int
+	nbBooleans                  int
+	inputs                      []string
+}
+
+func (b *recordingBuilder) NewVariable(w frontend.BuilderWire) {
+	key := frontend.BuilderWire{ID: w.ID, Visibility: w.Visibility}
+	if b.wires[key] {
+		b.t.Fatal("wire allocated twice", w)
+	}
+	b.wires[key] = true
+	if w.Visibility != backend.Internal {
+		b.inputs = append(b.inputs, w.Name)
+	}
+}
+
+func (b *recordingBuilder) AddConstraint(c frontend.BuilderConstraint) {
+	for _, l := range [][]frontend.BuilderTerm{c.L, c.R, c.O} {
+		for _, term := range l {
+			if !b.wires[frontend.BuilderWire{ID: term.Wire.ID, Visibility: term.Wire.Visibility}] {
+				b.t.Fatal("constraint on a wire not allocated", term.Wire)
+			}
+		}
+	}
+	if c.Computational {
+		b.nbConstraints++
+	} else {
+		b.nbAssertions++
+	}
+}
+
+func (b *recordingBuilder) MarkBoolean(w frontend.BuilderWire) {
+	b.nbBooleans++
+}
+
+type booleanCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (circuit *booleanCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	bits := cs.ToBinary(circuit.X, 4)
+	cs.AssertIsEqual(cs.FromBinary(bits...), circuit.Y)
+	return nil
+}
+
+func TestBuilder(t *testing.T) {
+	b := &recordingBuilder{t: t, wires: make(map[frontend.BuilderWire]bool)}
+	compiled, err := frontend.Compile(gurvy.UNKNOWN, &booleanCircuit{}, frontend.WithBuilder(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b.wires) != int(compiled.GetNbWires()) {
+		t.Fatal("expected", compiled.GetNbWires(), "wires, got", len(b.wires))
+	}
+	if b.nbConstraints+b.nbAssertions != int(compiled.GetNbConstraints()) || b.nbConstraints != int(compiled.(*r1cs.UntypedR1CS).NbCOConstraints) {
+		t.Fatal("unexpected number of constraints", b.nbConstraints, b.nbAssertions)
+	}
+	if b.nbBooleans != 4 {
+		t.Fatal("expected 4 booleans, got", b.nbBooleans)
+	}
+	if len(b.inputs) != 3 || b.inputs[0] != backend.OneWire {
+		t.Fatal("unexpected inputs", b.inputs)
+	}
+
+	// the builder sees the constraints of all the test circuits
+	for name, circuit := range circuits.Circuits {
+		if name == "map" {
+			continue // the shape of the circuit (its maps) is not given by its type
+		}
+		empty := reflect.New(reflect.TypeOf(circuit.Good).Elem()).Interface().(frontend.Circuit)
+		b := &recordingBuilder{t: t, wires: make(map[frontend.BuilderWire]bool)}
+		compiled, err := frontend.Compile(gurvy.UNKNOWN, empty, frontend.WithBuilder(b))
+		if err != nil {
+			t.Fatal(name, err)
+		}
+		if b.nbConstraints+b.nbAssertions != int(compiled.GetNbConstraints()) {
+			t.Fatal(name, "unexpected number of constraints", b.nbConstraints+b.nbAssertions)
+		}
+	}
+}
@@ -2,10 +2,14 @@ package frontend
 
 import (
 	"errors"
+	"fmt"
+	"math/big"
 	"reflect"
+	"strconv"
 
 	"github.com/consensys/gnark/backend"
 	"github.com/consensys/gnark/backend/r1cs"
+	"github.com/consensys/gnark/internal/utils"
 	"github.com/consensys/gurvy"
 )
 
@@ -25,13 +29,39 @@ type Circuit interface {
 // in that case, Compile() will allocate one public variable with id "exponent"
 //
 // 2. it then calls circuit.Define(curveID, constraintSystem) to build the internal constraint system
-// from the declarative code
+// from the declarative code. Operations on constants, including Variables whose linear expression is
+// constant (for example cs.Sub(x, x)), are evaluated there and record no constraint; an operation
+// repeated on identical operands (for example cs.Mul(x, y) and cs.Mul(y, x)) reuses the same wire.
+// The functions registered with ConstraintSystem.Defer are called after Define returns
 //
 // 3. finally, it converts that to a R1CS
-func Compile(curveID gurvy.ID, circuit Circuit) (r1cs.R1CS, error) {
+//
+// if curveID is gurvy.UNKNOWN, the R1CS is a *r1cs.UntypedR1CS, which is not bound to a field: it is
+// converted to the R1CS of each curve with ToR1CS(curveID), without calling Define again
+//
+// the compilation is deterministic: the wires and constraints of a circuit are recorded in the same order
+// on each run, and its R1CS has the same Fingerprint
+//
+// opts configure the compilation (see WithSourceLocations, WithProfile, WithContext, WithProgress,
+// WithOptimization, WithStrict, WithCapacity, WithLogger, WithBuilder)
+func Compile(curveID gurvy.ID, circuit Circuit, opts ...CompileOption) (_ r1cs.R1CS, err error) {
 
 	// instantiate our constraint system
-	cs := newConstraintSystem()
+	cs := newConstraintSystemWithCapacity(0)
+	cs.capacity = initialCapacity
+	cs.modulus = scalarField(curveID)
+	for _, opt := range opts {
+		if err := opt(&cs); err != nil {
+			return nil, err
+		}
+	}
+	cs.reserve()
+	if err := cs.progress.err(); err != nil {
+		return nil, err
+	}
+	if cs.progress != nil {
+		defer recoverCanceled(&err)
+	}
 
 	// leaf handlers are called when encoutering leafs in the circuit data struct
 	// leafs are Constraints that need to be initialized in the context of compiling a circuit
@@ -56,52 +86,192 @@ func Compile(curveID gurvy.ID, circuit Circuit) (r1cs.R1CS, error) {
 		return errors.New("can't set val " + name)
 	}
 
+	// the slices tagged with maxlen get their maximum length (see Tag)
+	varLenSlices, err := parseVarLenSlices(circuit, "", backend.Unset)
+	if err != nil {
+		return nil, err
+	}
+	for i := range varLenSlices {
+		if err := varLenSlices[i].grow(Variable{}); err != nil {
+			return nil, err
+		}
+	}
+
 	// recursively parse through reflection the circuits members to find all Constraints that need to be allOoutputcated
 	// (secret or public inputs)
-	if err := parseType(circuit, "", backend.Unset, handler); err != nil {
+	if err := parseType(circuit, "", backend.Unset, handler, cs.warn); err != nil {
+		return nil, err
+	}
+
+	// the length wires of the slices tagged with maxlen follow the inputs
+	for _, s := range varLenSlices {
+		var length Variable
+		if s.visibility == backend.Public {
+			length = cs.newPublicVariable(s.lengthName())
+		} else {
+			length = cs.newSecretVariable(s.lengthName())
+		}
+		s.assert(&cs, length)
+		cs.lengths[s.first()] = length
+	}
+
+	// the inputs tagged with bits or range are bounded
+	bounds, err := parseInputBounds(circuit)
+	if err != nil {
 		return nil, err
 	}
+	for i := range bounds {
+		bounds[i].assert(&cs)
+	}
 
 	// call Define() to fill in the Constraints
 	if err := circuit.Define(curveID, &cs); err != nil {
 		return nil, err
 	}
+	cs.runDeferred()
+	if err := cs.progress.err(); err != nil {
+		return nil, err
+	}
+	cs.reportProgress()
 	// return R1CS
 	//return cs.toR1CS(curveID), nil
 	res, err := cs.toR1CS(curveID)
 	if err != nil {
 		return nil, err
 	}
+	if cs.profile != nil {
+		if err := cs.profile.write(); err != nil {
+			return nil, err
+		}
+	}
 
 	return res, nil
 }
 
+// Inputs returns the names of the public and secret inputs of a circuit, in the order in which Compile
+// allocates them (the names of the inputs of a witness, see ParseWitness); circuit is not modified
+//
+// the public inputs don't include the constant wire backend.OneWire
+func Inputs(circuit Circuit) (public, secret []string, err error) {
+
+	circuit = copyWitness(circuit)
+
+	varLenSlices, err := parseVarLenSlices(circuit, "", backend.Unset)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range varLenSlices {
+		if err := varLenSlices[i].grow(Variable{}); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var handler leafHandler = func(visibility backend.Visibility, name string, tInput reflect.Value) error {
+		if visibility == backend.Public {
+			public = append(public, name)
+		} else {
+			secret = append(secret, name)
+		}
+		return nil
+	}
+	if err := parseType(circuit, "", backend.Unset, handler, printWarning); err != nil {
+		return nil, nil, err
+	}
+
+	for _, s := range varLenSlices {
+		if s.visibility == backend.Public {
+			public = append(public, s.lengthName())
+		} else {
+			secret = append(secret, s.lengthName())
+		}
+	}
+	return public, secret, nil
+}
+
 // ParseWitness will returns a map[string]interface{} to be used as input in
 // in R1CS.Solve(), groth16.Prove()
 //
 // if input is not already a map[string]interface{}, it must implement frontend.Circuit
+//
+// the slices tagged with maxlen are padded with 0, and their length is assigned (see Tag)
 func ParseWitness(input interface{}) (map[string]interface{}, error) {
 
+	res, err := parseWitness(input)
+	if err != nil {
+		return nil, err
+	}
+	if c, ok := input.(Circuit); ok {
+		varLenSlices, err := parseVarLenSlices(c, "", backend.Unset)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range varLenSlices {
+			n := s.slice.Len()
+			if n > s.maxLen {
+				return nil, fmt.Errorf("%s: %d elements, more than maxlen=%d", s.name, n, s.maxLen)
+			}
+			for i := n; i < s.maxLen; i++ {
+				res[appendName(s.name, strconv.Itoa(i))] = big.Int{}
+			}
+			res[s.lengthName()] = *big.NewInt(int64(n))
+		}
+	}
+	return res, nil
+}
+
+func parseWitness(input interface{}) (map[string]interface{}, error) {
+
 	switch c := input.(type) {
 	case map[string]interface{}:
 		return c, nil
 	case Circuit:
-		toReturn := make(map[string]interface{})
+		// the leaves of the circuit are parsed concurrently (top level fields, and chunks of large slices)
+		// each task fills its own map, merged at the end
+		tasks := splitParseType(c, "", backend.Unset)
+		values := make([]map[string]interface{}, len(tasks))
+		errs := make([]error, len(tasks))
 
-		var extractHandler leafHandler = func(visibility backend.Visibility, name string, tInput reflect.Value) error {
+		utils.Parallelize(len(tasks), func(start, end int) {
+			for i := start; i < end; i++ {
+				values[i] = make(map[string]interface{})
+				toReturn := values[i]
 
-			v := tInput.Interface().(Variable)
+				var extractHandler leafHandler = func(visibility backend.Visibility, name string, tInput reflect.Value) error {
 
-			if v.val != nil {
-				toReturn[name] = v.val
+					v := tInput.Interface().(Variable)
+
+					if v.val != nil {
+						toReturn[name] = v.val
+					}
+
+					return nil
+				}
+
+				// recursively parse through reflection the circuits members to find all inputs that need to be allOoutputcated
+				// (secret or public inputs)
+				errs[i] = tasks[i](extractHandler, printWarning)
 			}
+		})
 
-			return nil
+		for _, err := range errs {
+			if err != nil {
+				return nil, err
+			}
 		}
-
-		// recursively parse through reflection the circuits members to find all inputs that need to be allOoutputcated
-		// (secret or public inputs)
-		return toReturn, parseType(c, "", backend.Unset, extractHandler)
+		if len(values) == 1 {
+			return values[0], nil
+		}
+		size := 0
+		for _, v := range values {
+			size += len(v)
+		}
+		toReturn := make(map[string]interface{}, size)
+		for _, v := range values {
+			for name, val := range v {
+				toReturn[name] = val
+			}
+		}
+		return toReturn, nil
 	default:
 		rValue := reflect.ValueOf(input)
 		if rValue.Kind() != reflect.Ptr {
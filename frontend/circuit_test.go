@@ -1,6 +1,8 @@
 package frontend_test
 
 import (
+	"testing"
+
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gurvy"
 )
@@ -39,3 +41,30 @@ func (circuit *benchCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSys
 // 	}
 
 // }
+
+// linearCircuit records many linear combinations, which allocate linear expressions without
+// recording constraints
+type linearCircuit struct {
+	X [8]frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (circuit *linearCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	acc := cs.Constant(0)
+	for i := 0; i < 100000; i++ {
+		l := cs.Add(circuit.X[i%8], circuit.X[(i+3)%8], i)
+		l = cs.Sub(l, circuit.X[(i+5)%8])
+		acc = cs.Mul(acc, l)
+	}
+	cs.AssertIsEqual(acc, circuit.Y)
+	return nil
+}
+
+func BenchmarkCompile(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := frontend.Compile(gurvy.BN256, &linearCircuit{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
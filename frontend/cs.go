@@ -18,10 +18,12 @@ package frontend
 
 import (
 	"fmt"
+	"log"
 	"math/big"
 	"path/filepath"
 	"reflect"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -54,24 +56,83 @@ type ConstraintSystem struct {
 		booleans  map[int]struct{} // keep track of boolean variables (we constrain them once)
 	}
 
+	// linear expressions constrained to be boolean, keyed by their canonical key (see isBoolean)
+	booleanExps map[string]struct{}
+
 	// Constraints
 	constraints []r1c.R1C // list of R1C that yield an output (for example v3 == v1 * v2, return v3)
 	assertions  []r1c.R1C // list of R1C that yield no output (for example ensuring v1 == v2)
 	oneTerm     r1c.Term
+	hints       []r1c.Hint // internal variables computed by a hint function when solving
+	commitment  int        // index in hints of the commitment (see Commit), or -1
+
+	// wires computed by the API, keyed by the operation and its operands (see cseKey): an identical
+	// expression reuses them instead of recording new constraints
+	cse map[string][]Variable
+
+	// the linear expressions of the constraints and of the Variables are allocated in blocks
+	linExps linExpArena
+
+	// scratch space of reduce, reused by each call
+	reduceScratch struct {
+		index  map[uint64]int // variable (id and visibility) -> index in wires
+		wires  []Wire
+		coeffs []big.Int
+	}
+
+	// length wires of the slices tagged with maxlen, keyed by their first element (see Len)
+	lengths map[*Variable]Variable
+
+	// modulus of the scalar field the constants are folded in, nil if the curve is unknown (see fold)
+	modulus *big.Int
+
+	// functions called at the end of Define (see Defer)
+	deferred []deferredCall
+
+	// namespaces (see Namespace)
+	namespace           []string        // names of the current namespace and of its parents
+	coNamespaces        []r1c.Namespace // runs of cs.constraints recorded in a namespace
+	assertionNamespaces []r1c.Namespace // runs of cs.assertions recorded in a namespace
 
 	// Coefficients in the constraints
-	coeffs    []big.Int      // list of unique coefficients.
-	coeffsIDs map[string]int // map to fast check existence of a coefficient (key = coeff.Text(16))
+	coeffs    r1cs.CoeffArena // list of unique coefficients.
+	coeffsIDs map[string]int  // map to fast check existence of a coefficient (key = coeff.Text(16))
 
 	// debug info
 	logs           []logEntry // list of logs to be printed when solving a circuit. The logs are called with the method Println
 	debugInfo      []logEntry // list of logs storing information about assertions. If an assertion fails, it prints it in a friendly format
 	unsetVariables []logEntry // unset variables. If a variable is unset, the error is caught when compiling the circuit
 
+	// source locations (see WithSourceLocations)
+	sourceLocations    bool
+	coLocations        []string // file:line of the call recording each of cs.constraints
+	assertionLocations []string // file:line of the call recording each of cs.assertions
+
+	// constraints per call stack (see WithProfile)
+	profile *profile
+
+	// cancellation and progress of the compilation (see WithContext, WithProgress)
+	progress *progress
+
+	// optimize the R1CS (see WithOptimization)
+	optimize bool
+
+	// warnings of the parsing of the circuit (see WithStrict, WithLogger)
+	strict bool
+	logger *log.Logger
+
+	// expected number of constraints and internal variables (see WithCapacity)
+	capacity int
+
+	// records the circuit in another arithmetization (see WithBuilder)
+	builder Builder
+
+	// engine is set by Evaluate: the API evaluates the values of a witness and records no constraint
+	engine *engine
 }
 
 func (cs *ConstraintSystem) buildVarFromPartialVar(pv Wire) Variable {
-	return Variable{pv, cs.LinearExpression(cs.makeTerm(pv, bOne)), false}
+	return Variable{pv, cs.LinearExpression(cs.makeTerm(pv, bOne))}
 }
 
 // this has quite some impact on frontend performance, especially on large circuits size
@@ -79,11 +140,22 @@ func (cs *ConstraintSystem) buildVarFromPartialVar(pv Wire) Variable {
 const initialCapacity = 1e6
 
 func newConstraintSystem() ConstraintSystem {
+	return newConstraintSystemWithCapacity(initialCapacity)
+}
+
+// newConstraintSystemWithCapacity returns a constraint system with room for capacity constraints and
+// internal variables
+func newConstraintSystemWithCapacity(capacity int) ConstraintSystem {
 	cs := ConstraintSystem{
-		coeffs:      make([]big.Int, 0),
+		coeffs:      r1cs.NewCoeffArena(0, 0),
 		coeffsIDs:   make(map[string]int),
-		constraints: make([]r1c.R1C, 0, initialCapacity),
+		constraints: make([]r1c.R1C, 0, capacity),
 		assertions:  make([]r1c.R1C, 0),
+		cse:         make(map[string][]Variable),
+		lengths:     make(map[*Variable]Variable),
+		commitment:  -1,
+		logger:      defaultLogger,
+		capacity:    capacity,
 	}
 
 	cs.public.names = make([]string, 0)
@@ -94,8 +166,11 @@ func newConstraintSystem() ConstraintSystem {
 	cs.secret.variables = make([]Variable, 0)
 	cs.secret.booleans = make(map[int]struct{})
 
-	cs.internal.variables = make([]Variable, 0, initialCapacity)
+	cs.internal.variables = make([]Variable, 0, capacity)
 	cs.internal.booleans = make(map[int]struct{})
+	cs.booleanExps = make(map[string]struct{})
+
+	cs.reduceScratch.index = make(map[uint64]int)
 
 	// by default the circuit is given on public wire equal to 1
 	cs.public.variables[0] = cs.newPublicVariable(backend.OneWire)
@@ -103,6 +178,12 @@ func newConstraintSystem() ConstraintSystem {
 	return cs
 }
 
+// reserve allocates room for cs.capacity constraints and internal variables; cs is empty
+func (cs *ConstraintSystem) reserve() {
+	cs.constraints = make([]r1c.R1C, 0, cs.capacity)
+	cs.internal.variables = make([]Variable, 0, cs.capacity)
+}
+
 type logEntry struct {
 	format    string
 	toResolve []r1c.Term
@@ -168,56 +249,13 @@ func (cs *ConstraintSystem) NbConstraints() int {
 
 // LinearExpression packs a list of r1c.Term in a r1c.LinearExpression and returns it.
 func (cs *ConstraintSystem) LinearExpression(terms ...r1c.Term) r1c.LinearExpression {
-	res := make(r1c.LinearExpression, len(terms))
+	res := cs.linExps.alloc(len(terms))
 	for i, args := range terms {
 		res[i] = args
 	}
 	return res
 }
 
-// reduces redundancy in a linear expression
-// Non deterministic function
-func (cs *ConstraintSystem) partialReduce(linExp r1c.LinearExpression, visibility backend.Visibility) r1c.LinearExpression {
-
-	if len(linExp) == 0 {
-		return r1c.LinearExpression{}
-	}
-
-	coeffRecord := make(map[int]big.Int) // id variable -> coeff
-	varRecord := make(map[int]Wire)      // id variable -> Wire
-
-	// the variables are collected and the coefficients are accumulated
-	for _, t := range linExp {
-
-		_, coeffID, variableID, vis := t.Unpack()
-
-		if vis == visibility {
-			tmp := Wire{vis, variableID, nil}
-
-			if _, ok := varRecord[variableID]; !ok {
-				varRecord[variableID] = tmp
-				var coef, coefCopy big.Int
-				coef = cs.coeffs[coeffID]
-				coefCopy.Set(&coef)
-				coeffRecord[variableID] = coefCopy
-			} else {
-				ccoef := coeffRecord[variableID]
-				ccoef.Add(&ccoef, &cs.coeffs[coeffID])
-				coeffRecord[variableID] = ccoef
-			}
-		}
-	}
-
-	// creation of the reduced linear expression
-	var res r1c.LinearExpression
-	for k := range coeffRecord {
-		bCoeff := coeffRecord[k]
-		res = append(res, cs.makeTerm(varRecord[k], &bCoeff))
-	}
-
-	return res
-}
-
 // complete allocate linExp if linExp is empty. If a variable
 // is created like 'var a Variable', it will be unset but Compile(..)
 // will not understand it since a.linExp is empty
@@ -226,38 +264,96 @@ func (cs *ConstraintSystem) completeDanglingVariable(v *Variable) {
 		tmp := Wire{backend.Unset, v.id, v.val}
 		tmpVar := cs.buildVarFromPartialVar(tmp)
 		cs.unsetVariables = append(cs.unsetVariables, debugInfoUnsetVariable(tmpVar.linExp[0]))
-		v.linExp = tmpVar.getLinExpCopy()
+		v.linExp = cs.copyLinExp(tmpVar.linExp)
 	}
 }
 
-// reduces redundancy in linear expression
-// Non deterministic function
+// reduces redundancy in linear expression: the coefficients of each variable are accumulated
+// the variables are grouped by visibility (public, secret, internal, unset), and kept in the order of
+// their first occurrence, so that compiling a circuit is reproducible; we collect also the unset
+// variables so it stays consistant (useful for debugging)
+//
+// the result is allocated in the arena of the constraint system
 func (cs *ConstraintSystem) reduce(linExp r1c.LinearExpression) r1c.LinearExpression {
-	reducePublic := cs.partialReduce(linExp, backend.Public)
-	reduceSecret := cs.partialReduce(linExp, backend.Secret)
-	reduceInternal := cs.partialReduce(linExp, backend.Internal)
-	reduceUnset := cs.partialReduce(linExp, backend.Unset) // we collect also the unset variables so it stays consistant (useful for debugging)
-	res := make(r1c.LinearExpression, len(reducePublic)+len(reduceSecret)+len(reduceInternal)+len(reduceUnset))
-	accSize := 0
-	copy(res[:], reducePublic)
-	accSize += len(reducePublic)
-	copy(res[accSize:], reduceSecret)
-	accSize += len(reduceSecret)
-	copy(res[accSize:], reduceInternal)
-	accSize += len(reduceInternal)
-	copy(res[accSize:], reduceUnset)
+	if len(linExp) <= 1 {
+		return cs.copyLinExp(linExp)
+	}
+
+	index, wires, coeffs := cs.reduceScratch.index, cs.reduceScratch.wires[:0], cs.reduceScratch.coeffs[:0]
+	var coeff big.Int
+	for _, t := range linExp {
+		_, coeffID, variableID, visibility := t.Unpack()
+		key := uint64(variableID)<<2 | uint64(visibility)
+		if i, ok := index[key]; ok {
+			coeffs[i].Add(&coeffs[i], cs.coeffs.Get(coeffID, &coeff))
+			continue
+		}
+		index[key] = len(wires)
+		wires = append(wires, Wire{visibility, variableID, nil})
+		if len(coeffs) < cap(coeffs) {
+			coeffs = coeffs[:len(coeffs)+1]
+		} else {
+			coeffs = append(coeffs, big.Int{})
+		}
+		cs.coeffs.Get(coeffID, &coeffs[len(coeffs)-1])
+	}
+
+	res := cs.linExps.alloc(len(wires))
+	n := 0
+	for _, visibility := range []backend.Visibility{backend.Public, backend.Secret, backend.Internal, backend.Unset} {
+		for i := range wires {
+			if wires[i].visibility == visibility {
+				res[n] = cs.makeTerm(wires[i], &coeffs[i])
+				n++
+			}
+		}
+	}
+
+	for key := range index {
+		delete(index, key)
+	}
+	cs.reduceScratch.wires, cs.reduceScratch.coeffs = wires, coeffs
 	return res
 }
 
 func (cs *ConstraintSystem) bigIntValue(term r1c.Term) big.Int {
 	var coeff big.Int
-	coeff.Set(&cs.coeffs[term.CoeffID()])
+	cs.coeffs.Get(term.CoeffID(), &coeff)
 	return coeff
 }
 
+// addConstraint records a computational constraint
+func (cs *ConstraintSystem) addConstraint(constraint r1c.R1C) {
+	cs.constraints = append(cs.constraints, constraint)
+	if cs.builder != nil {
+		cs.forwardConstraint(&constraint, true)
+	}
+	if cs.sourceLocations {
+		cs.coLocations = append(cs.coLocations, sourceLocation())
+	}
+	if cs.profile != nil {
+		cs.profile.record()
+	}
+	if cs.progress != nil {
+		cs.checkProgress()
+	}
+}
+
 func (cs *ConstraintSystem) addAssertion(constraint r1c.R1C, debugInfo logEntry) {
 	cs.assertions = append(cs.assertions, constraint)
 	cs.debugInfo = append(cs.debugInfo, debugInfo)
+	if cs.builder != nil {
+		cs.forwardConstraint(&constraint, false)
+	}
+	if cs.sourceLocations {
+		cs.assertionLocations = append(cs.assertionLocations, sourceLocation())
+	}
+	if cs.profile != nil {
+		cs.profile.record()
+	}
+	if cs.progress != nil {
+		cs.checkProgress()
+	}
 }
 
 // toR1CS constructs a rank-1 constraint sytem
@@ -278,12 +374,23 @@ func (cs *ConstraintSystem) toR1CS(curveID gurvy.ID) (r1cs.R1CS, error) {
 		Coefficients:    cs.coeffs,
 		Logs:            make([]backend.LogEntry, len(cs.logs)),
 		DebugInfo:       make([]backend.LogEntry, len(cs.debugInfo)),
+		Hints:           make([]r1c.Hint, len(cs.hints)),
 	}
 
 	// computational constraints (= gates)
 	copy(res.Constraints, cs.constraints)
 	copy(res.Constraints[len(cs.constraints):], cs.assertions)
 
+	if cs.sourceLocations {
+		res.Locations = make([]string, 0, len(res.Constraints))
+		res.Locations = append(res.Locations, cs.coLocations...)
+		res.Locations = append(res.Locations, cs.assertionLocations...)
+	}
+
+	// the runs of the assertions follow the runs of the computational constraints
+	res.Namespaces = appendNamespaces(nil, cs.coNamespaces, 0, len(cs.constraints))
+	res.Namespaces = appendNamespaces(res.Namespaces, cs.assertionNamespaces, len(cs.constraints), len(res.Constraints))
+
 	// we just need to offset our ids, such that wires = [internalVariables | secretVariables | publicVariables]
 	offsetIDs := func(exp r1c.LinearExpression) error {
 		for j := 0; j < len(exp); j++ {
@@ -316,6 +423,27 @@ func (cs *ConstraintSystem) toR1CS(curveID gurvy.ID) (r1cs.R1CS, error) {
 		}
 	}
 
+	// the hints are computed in internal variables, only their inputs need an offset
+	for i, h := range cs.hints {
+		res.Hints[i] = r1c.Hint{ID: h.ID, Inputs: make([]r1c.LinearExpression, len(h.Inputs)), Wire: h.Wire, Wires: h.Wires}
+		for j := 0; j < len(h.Inputs); j++ {
+			res.Hints[i].Inputs[j] = make(r1c.LinearExpression, len(h.Inputs[j]))
+			copy(res.Hints[i].Inputs[j], h.Inputs[j])
+			if err = offsetIDs(res.Hints[i].Inputs[j]); err != nil {
+				return &res, err
+			}
+		}
+	}
+
+	// the inputs of the commitment hint are single wires
+	if cs.commitment != -1 {
+		h := res.Hints[cs.commitment]
+		res.Commitment = &r1c.Commitment{Committed: make([]int, len(h.Inputs)), Wire: h.Wire}
+		for i := range h.Inputs {
+			res.Commitment.Committed[i] = h.Inputs[i][0].VariableID()
+		}
+	}
+
 	// we need to offset the ids in logs too
 	for i := 0; i < len(cs.logs); i++ {
 		entry := backend.LogEntry{
@@ -358,6 +486,10 @@ func (cs *ConstraintSystem) toR1CS(curveID gurvy.ID) (r1cs.R1CS, error) {
 		res.DebugInfo[i] = entry
 	}
 
+	if cs.optimize {
+		optimize(&res)
+	}
+
 	if curveID == gurvy.UNKNOWN {
 		return &res, nil
 	}
@@ -375,11 +507,8 @@ func (cs *ConstraintSystem) coeffID(b *big.Int) int {
 		return idx
 	}
 
-	// else add it in the cs.coeffs map and update the cs.coeffsIDs map
-	var bCopy big.Int
-	bCopy.Set(b)
-	resID := len(cs.coeffs)
-	cs.coeffs = append(cs.coeffs, bCopy)
+	// else add it in the cs.coeffs arena and update the cs.coeffsIDs map
+	resID := cs.coeffs.Append(b)
 	cs.coeffsIDs[key] = resID
 	return resID
 }
@@ -391,8 +520,8 @@ func (cs *ConstraintSystem) allocate(v Variable) Variable {
 	if v.visibility == backend.Unset && len(v.linExp) > 0 {
 		iv := cs.newInternalVariable()
 		one := cs.getOneVariable()
-		constraint := r1c.R1C{L: v.getLinExpCopy(), R: one.getLinExpCopy(), O: iv.getLinExpCopy(), Solver: r1c.SingleOutput}
-		cs.constraints = append(cs.constraints, constraint)
+		constraint := r1c.R1C{L: cs.copyLinExp(v.linExp), R: cs.copyLinExp(one.linExp), O: cs.copyLinExp(iv.linExp), Solver: r1c.SingleOutput}
+		cs.addConstraint(constraint)
 		return iv
 	}
 	return v
@@ -400,10 +529,17 @@ func (cs *ConstraintSystem) allocate(v Variable) Variable {
 
 // Println enables circuit debugging and behaves almost like fmt.Println()
 //
-// the print will be done once the R1CS.Solve() method is executed
+// the print will be done once the R1CS.IsSolved() method is executed (for example, cs.Println("x", x)
+// prints the value of x in the solved witness); the prover, which solves the R1CS too, doesn't print
 //
-// if one of the input is a Variable, its value will be resolved avec R1CS.Solve() method is called
+// if one of the input is a Variable, its value will be resolved when R1CS.IsSolved() method is called,
+// or printed as <unsolved> if the solver stopped at an unsatisfied constraint before computing it
 func (cs *ConstraintSystem) Println(a ...interface{}) {
+	if cs.engine != nil {
+		cs.engine.println(a...)
+		return
+	}
+
 	var sbb strings.Builder
 
 	// prefix log line with file.go:line
@@ -458,6 +594,211 @@ func (cs *ConstraintSystem) Println(a ...interface{}) {
 	cs.logs = append(cs.logs, entry)
 }
 
+// booleans returns the record of the boolean wires of v's visibility, or nil if v is not a wire (a
+// linear expression of wires)
+func (cs *ConstraintSystem) booleans(v Variable) map[int]struct{} {
+	switch v.visibility {
+	case backend.Public:
+		return cs.public.booleans
+	case backend.Secret:
+		return cs.secret.booleans
+	case backend.Internal:
+		return cs.internal.booleans
+	default:
+		return nil
+	}
+}
+
+// booleanWire returns the wire equal to the linear expression of v, if its only non null term is a
+// wire with coefficient 1, and the canonical key of the linear expression otherwise (see cseKey)
+func (cs *ConstraintSystem) booleanWire(v Variable) (Variable, string, bool) {
+	terms := make(r1c.LinearExpression, 0, len(v.linExp))
+	for _, t := range v.linExp {
+		if t.CoeffValue() != 0 {
+			terms = append(terms, t)
+		}
+	}
+	if len(terms) == 1 && terms[0].CoeffValue() == 1 {
+		if visibility := terms[0].ConstraintVisibility(); visibility != backend.Unset {
+			return Variable{Wire: Wire{visibility: visibility, id: terms[0].VariableID()}}, "", true
+		}
+	}
+	return Variable{}, cs.cseKey("", false, terms), false
+}
+
+// isBoolean returns true if v is a wire, or a linear expression, already constrained to be boolean
+//
+// the record is kept by wire in the ConstraintSystem, so that the copies of a Variable share it; a
+// linear expression is recorded by its canonical key, so that an identical expression shares it too
+func (cs *ConstraintSystem) isBoolean(v Variable) bool {
+	if booleans := cs.booleans(v); booleans != nil {
+		_, ok := booleans[v.id]
+		return ok
+	}
+	w, key, isWire := cs.booleanWire(v)
+	if isWire {
+		return cs.isBoolean(w)
+	}
+	_, ok := cs.booleanExps[key]
+	return ok
+}
+
+// markBoolean records that v is constrained to be boolean
+func (cs *ConstraintSystem) markBoolean(v Variable) {
+	if booleans := cs.booleans(v); booleans != nil {
+		booleans[v.id] = struct{}{}
+		if cs.builder != nil {
+			cs.builder.MarkBoolean(cs.builderWire(v.Wire))
+		}
+		return
+	}
+	w, key, isWire := cs.booleanWire(v)
+	if isWire {
+		cs.markBoolean(w)
+		return
+	}
+	cs.booleanExps[key] = struct{}{}
+}
+
+// constantValue returns the value of v if its linear expression is constant (its terms are on the
+// ONE_WIRE, or have a null coefficient), so that the API evaluates it when compiling the circuit
+//
+// the value is not reduced modulo the scalar field, which is unknown at compile time
+func (cs *ConstraintSystem) constantValue(v Variable) (big.Int, bool) {
+	var res big.Int
+	if len(v.linExp) == 0 {
+		// unset variable
+		return res, false
+	}
+	var coeff big.Int
+	for _, t := range v.linExp {
+		_, coeffID, variableID, vis := t.Unpack()
+		cs.coeffs.Get(coeffID, &coeff)
+		if vis == backend.Public && variableID == 0 {
+			res.Add(&res, &coeff)
+		} else if coeff.Sign() != 0 {
+			return res, false
+		}
+	}
+	return res, true
+}
+
+// the scalar fields of the supported curves have more than 252 bits: a constant of at most 252 bits (in
+// absolute value) is not changed by the reduction modulo the field
+const maxReducedBits = 252
+
+// boolConstant returns the value of v if it is the constant 0 or 1
+func (cs *ConstraintSystem) boolConstant(v Variable) (uint64, bool) {
+	c, ok := cs.constantValue(v)
+	if !ok || !c.IsUint64() || c.Uint64() > 1 {
+		return 0, false
+	}
+	return c.Uint64(), true
+}
+
+// maxFoldedBits bounds the constants folded when the curve is unknown: the scalar fields of the
+// supported curves have at most 377 bits
+const maxFoldedBits = 377
+
+// fold returns the value of i if it is a constant Variable, and i otherwise
+//
+// the value is reduced modulo the scalar field if the curve is known; otherwise, a value larger than
+// the scalar fields is not folded, so that the folded constants don't grow without bound (a product of
+// constants is then recorded as a constraint)
+func (cs *ConstraintSystem) fold(i interface{}) interface{} {
+	if v, ok := i.(Variable); ok {
+		cs.completeDanglingVariable(&v)
+		if c, ok := cs.constantValue(v); ok {
+			if cs.modulus != nil {
+				return *c.Mod(&c, cs.modulus)
+			}
+			if c.BitLen() <= maxFoldedBits {
+				return c
+			}
+		}
+		return v
+	}
+	return i
+}
+
+// cseKey returns a canonical key of the operation op on operands, to find the wires of an identical
+// expression in cs.cse
+//
+// the terms of each operand are sorted, so that the key doesn't depend on their order; the operands of
+// a commutative operation are sorted too
+func (cs *ConstraintSystem) cseKey(op string, commutative bool, operands ...r1c.LinearExpression) string {
+	keys := make([]string, len(operands))
+	for i, l := range operands {
+		terms := make([]uint64, len(l))
+		for j, t := range l {
+			terms[j] = uint64(t)
+		}
+		sort.Slice(terms, func(a, b int) bool { return terms[a] < terms[b] })
+		var sbb strings.Builder
+		for _, t := range terms {
+			sbb.WriteString(strconv.FormatUint(t, 16))
+			sbb.WriteByte(',')
+		}
+		keys[i] = sbb.String()
+	}
+	if commutative {
+		sort.Strings(keys)
+	}
+	return op + "(" + strings.Join(keys, "|") + ")"
+}
+
+// deferredCall is a function registered with Defer, and the namespace it was registered in
+type deferredCall struct {
+	namespace []string
+	f         func(cs *ConstraintSystem)
+}
+
+// runDeferred calls the functions registered with Defer, in the order of registration, including the
+// ones they register
+func (cs *ConstraintSystem) runDeferred() {
+	for len(cs.deferred) != 0 {
+		call := cs.deferred[0]
+		cs.deferred = cs.deferred[1:]
+		if cs.engine != nil {
+			call.f(cs)
+			continue
+		}
+		cs.namespace = call.namespace
+		cs.startNamespace()
+		call.f(cs)
+		cs.namespace = nil
+		cs.startNamespace()
+	}
+}
+
+// startNamespace starts a run of constraints and of assertions in the current namespace
+func (cs *ConstraintSystem) startNamespace() {
+	name := strings.Join(cs.namespace, "/")
+	cs.coNamespaces = append(cs.coNamespaces, r1c.Namespace{Name: name, Constraint: len(cs.constraints)})
+	cs.assertionNamespaces = append(cs.assertionNamespaces, r1c.Namespace{Name: name, Constraint: len(cs.assertions)})
+}
+
+// appendNamespaces appends to res the runs of the constraints [offset, end) of the R1CS, from the runs
+// of the list of constraints they were recorded in; the empty runs are dropped and the runs of the same
+// namespace are merged
+func appendNamespaces(res []r1c.Namespace, runs []r1c.Namespace, offset, end int) []r1c.Namespace {
+	if len(runs) == 0 || runs[0].Constraint != 0 {
+		// the first constraints are outside of a namespace
+		runs = append([]r1c.Namespace{{Name: "", Constraint: 0}}, runs...)
+	}
+	for i, run := range runs {
+		run.Constraint += offset
+		if run.Constraint == end || (i+1 < len(runs) && runs[i+1].Constraint+offset == run.Constraint) {
+			continue
+		}
+		if len(res) != 0 && res[len(res)-1].Name == run.Name {
+			continue
+		}
+		res = append(res, run)
+	}
+	return res
+}
+
 // newInternalVariable creates a new wire, appends it on the list of wires of the circuit, sets
 // the wire's id to the number of wires, and returns it
 func (cs *ConstraintSystem) newInternalVariable() Variable {
@@ -467,6 +808,9 @@ func (cs *ConstraintSystem) newInternalVariable() Variable {
 	}
 	res := cs.buildVarFromPartialVar(resVar)
 	cs.internal.variables = append(cs.internal.variables, res)
+	if cs.builder != nil {
+		cs.builder.NewVariable(cs.builderWire(res.Wire))
+	}
 	return res
 }
 
@@ -486,6 +830,9 @@ func (cs *ConstraintSystem) newPublicVariable(name string) Variable {
 	res := cs.buildVarFromPartialVar(resVar)
 	cs.public.names = append(cs.public.names, name)
 	cs.public.variables = append(cs.public.variables, res)
+	if cs.builder != nil {
+		cs.builder.NewVariable(cs.builderWire(res.Wire))
+	}
 	return res
 }
 
@@ -504,6 +851,9 @@ func (cs *ConstraintSystem) newSecretVariable(name string) Variable {
 	res := cs.buildVarFromPartialVar(resVar)
 	cs.secret.names = append(cs.secret.names, name)
 	cs.secret.variables = append(cs.secret.variables, res)
+	if cs.builder != nil {
+		cs.builder.NewVariable(cs.builderWire(res.Wire))
+	}
 	return res
 }
 
@@ -532,6 +882,29 @@ func parseLogValue(input interface{}, name string, handler logValueHandler) {
 	}
 }
 
+// frontendDir is the directory of the sources of the package
+var frontendDir = func() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Dir(file)
+}()
+
+// sourceLocation returns the file:line of the first caller outside of the package (the circuit, or
+// the gadget of the circuit, calling the API)
+func sourceLocation() string {
+	pc := make([]uintptr, 16)
+	n := runtime.Callers(3, pc)
+	frames := runtime.CallersFrames(pc[:n])
+	for {
+		frame, more := frames.Next()
+		if filepath.Dir(frame.File) != frontendDir || strings.HasSuffix(frame.File, "_test.go") {
+			return frame.File + ":" + strconv.Itoa(frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
 // derived from: https://golang.org/pkg/runtime/#example_Frames
 // we stop when func name == Define as it is where the gnark circuit code should start
 func getCallStack() []string {
@@ -19,24 +19,30 @@ package frontend
 import (
 	"fmt"
 	"math/big"
+	"strconv"
 
 	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/hint"
 	"github.com/consensys/gnark/backend/r1cs/r1c"
 )
 
 // Add returns res = i1+i2+...in
 func (cs *ConstraintSystem) Add(i1, i2 interface{}, in ...interface{}) Variable {
 
+	if cs.engine != nil {
+		return cs.engine.add(i1, i2, in...)
+	}
+
 	var res Variable
 
 	add := func(_i interface{}) {
 		switch t := _i.(type) {
 		case Variable:
 			cs.completeDanglingVariable(&t) // always call this in case of a dangling variable, otherwise compile will not recognize Unset variables
-			res.linExp = append(res.linExp, t.getLinExpCopy()...)
+			res.linExp = append(res.linExp, t.linExp...)
 		default:
 			v := cs.Constant(t)
-			res.linExp = append(res.linExp, v.getLinExpCopy()...)
+			res.linExp = append(res.linExp, v.linExp...)
 		}
 	}
 	add(i1)
@@ -53,11 +59,11 @@ func (cs *ConstraintSystem) Add(i1, i2 interface{}, in ...interface{}) Variable
 // returns -le, the result is a copy
 func (cs *ConstraintSystem) negateLinExp(le r1c.LinearExpression) r1c.LinearExpression {
 	res := make(r1c.LinearExpression, len(le))
-	var coeff, coeffCopy big.Int
+	var coeffCopy big.Int
 	for i, t := range le {
 		_, coeffID, variableID, constraintVis := t.Unpack()
-		coeff = cs.coeffs[coeffID]
-		coeffCopy.Neg(&coeff)
+		cs.coeffs.Get(coeffID, &coeffCopy)
+		coeffCopy.Neg(&coeffCopy)
 		res[i] = cs.makeTerm(Wire{constraintVis, variableID, nil}, &coeffCopy)
 	}
 	return res
@@ -66,26 +72,29 @@ func (cs *ConstraintSystem) negateLinExp(le r1c.LinearExpression) r1c.LinearExpr
 // Sub returns res = i1 - i2
 func (cs *ConstraintSystem) Sub(i1, i2 interface{}) Variable {
 
+	if cs.engine != nil {
+		return cs.engine.sub(i1, i2)
+	}
+
 	var res Variable
 
+	// the terms are collected in a temporary expression, reduced into the arena
 	switch t := i1.(type) {
 	case Variable:
 		cs.completeDanglingVariable(&t)
-		res.linExp = t.getLinExpCopy()
+		res.linExp = append(res.linExp, t.linExp...)
 	default:
 		v := cs.Constant(t)
-		res.linExp = v.getLinExpCopy()
+		res.linExp = append(res.linExp, v.linExp...)
 	}
 
 	switch t := i2.(type) {
 	case Variable:
 		cs.completeDanglingVariable(&t)
-		negLinExp := cs.negateLinExp(t.getLinExpCopy())
-		res.linExp = append(res.getLinExpCopy(), negLinExp...)
+		res.linExp = append(res.linExp, cs.negateLinExp(t.linExp)...)
 	default:
 		v := cs.Constant(t)
-		negLinExp := cs.negateLinExp(v.getLinExpCopy())
-		res.linExp = append(res.getLinExpCopy(), negLinExp...)
+		res.linExp = append(res.linExp, cs.negateLinExp(v.linExp)...)
 	}
 
 	res.linExp = cs.reduce(res.linExp)
@@ -94,32 +103,42 @@ func (cs *ConstraintSystem) Sub(i1, i2 interface{}) Variable {
 }
 
 func (cs *ConstraintSystem) mulConstant(i interface{}, v Variable) Variable {
-	var linExp r1c.LinearExpression
+	linExp := cs.linExps.alloc(len(v.linExp))
 	lambda := backend.FromInterface(i)
-	for _, t := range v.linExp {
-		var coeffCopy big.Int
+	var coeffCopy big.Int
+	for j, t := range v.linExp {
 		_, coeffID, variableID, constraintVis := t.Unpack()
-		coeff := cs.coeffs[coeffID]
-		coeffCopy.Mul(&coeff, &lambda)
-		linExp = append(linExp, cs.makeTerm(Wire{constraintVis, variableID, nil}, &coeffCopy))
+		cs.coeffs.Get(coeffID, &coeffCopy)
+		coeffCopy.Mul(&coeffCopy, &lambda)
+		linExp[j] = cs.makeTerm(Wire{constraintVis, variableID, nil}, &coeffCopy)
 	}
-	return Variable{Wire{}, linExp, false}
+	return Variable{Wire{}, linExp}
 }
 
 // Mul returns res = i1 * i2 * ... in
 func (cs *ConstraintSystem) Mul(i1, i2 interface{}, in ...interface{}) Variable {
 
+	if cs.engine != nil {
+		return cs.engine.mul(i1, i2, in...)
+	}
+
 	mul := func(_i1, _i2 interface{}) Variable {
 		var _res Variable
+		_i1, _i2 = cs.fold(_i1), cs.fold(_i2) // constant Variables are multiplied at compile time
 		switch t1 := _i1.(type) {
 		case Variable:
 			cs.completeDanglingVariable(&t1)
 			switch t2 := _i2.(type) {
 			case Variable:
 				cs.completeDanglingVariable(&t2)
+				key := cs.cseKey("Mul", true, t1.linExp, t2.linExp)
+				if res, ok := cs.cse[key]; ok {
+					return res[0]
+				}
 				_res = cs.newInternalVariable() // only in this case we record the constraint in the cs
-				constraint := r1c.R1C{L: t1.getLinExpCopy(), R: t2.getLinExpCopy(), O: _res.getLinExpCopy(), Solver: r1c.SingleOutput}
-				cs.constraints = append(cs.constraints, constraint)
+				constraint := r1c.R1C{L: cs.copyLinExp(t1.linExp), R: cs.copyLinExp(t2.linExp), O: cs.copyLinExp(_res.linExp), Solver: r1c.SingleOutput}
+				cs.addConstraint(constraint)
+				cs.cse[key] = []Variable{_res}
 				return _res
 			default:
 				_res = cs.mulConstant(t2, t1)
@@ -135,6 +154,9 @@ func (cs *ConstraintSystem) Mul(i1, i2 interface{}, in ...interface{}) Variable
 				n1 := backend.FromInterface(t1)
 				n2 := backend.FromInterface(t2)
 				n1.Mul(&n1, &n2)
+				if cs.modulus != nil {
+					n1.Mod(&n1, cs.modulus)
+				}
 				_res = cs.Constant(n1)
 				return _res
 			}
@@ -150,12 +172,63 @@ func (cs *ConstraintSystem) Mul(i1, i2 interface{}, in ...interface{}) Variable
 	return res
 }
 
-// Inverse returns res = inverse(v)
+// MulAcc returns res = acc + a * b
+//
+// as Mul, it records one constraint (a * b == res - acc) when a and b are not constant, but res is a
+// single wire: the linear expression of a running sum doesn't grow with its number of terms, as with
+// cs.Add(acc, cs.Mul(a, b)), which bounds the size of the constraints using it (inner products,
+// polynomial evaluation)
+func (cs *ConstraintSystem) MulAcc(acc, a, b interface{}) Variable {
+
+	if cs.engine != nil {
+		return cs.engine.mulAcc(acc, a, b)
+	}
+
+	_acc := cs.Constant(acc)
+	_a, _b := cs.fold(a), cs.fold(b)
+	_, aVariable := _a.(Variable)
+	_, bVariable := _b.(Variable)
+	if !aVariable || !bVariable {
+		// the product is a linear expression
+		return cs.Add(_acc, cs.Mul(_a, _b))
+	}
+	if v, ok := cs.constantValue(_acc); ok && v.Sign() == 0 {
+		return cs.Mul(_a, _b)
+	}
+	t1, t2 := _a.(Variable), _b.(Variable)
+	cs.completeDanglingVariable(&t1)
+	cs.completeDanglingVariable(&t2)
+
+	key := cs.cseKey("MulAcc", false, _acc.linExp, t1.linExp, t2.linExp)
+	if res, ok := cs.cse[key]; ok {
+		return res[0]
+	}
+
+	res := cs.newInternalVariable()
+	O := append(cs.copyLinExp(res.linExp), cs.negateLinExp(_acc.linExp)...)
+	cs.addConstraint(r1c.R1C{L: cs.copyLinExp(t1.linExp), R: cs.copyLinExp(t2.linExp), O: O, Solver: r1c.SingleOutput})
+	cs.cse[key] = []Variable{res}
+
+	return res
+}
+
+// Inverse returns res = inverse(v), with a single constraint: v * res == 1
+//
+// v can't be 0: solving the circuit fails (see InverseOrZero)
 // TODO the function should take an interface
 func (cs *ConstraintSystem) Inverse(v Variable) Variable {
 
+	if cs.engine != nil {
+		return cs.engine.inverse(v)
+	}
+
 	cs.completeDanglingVariable(&v)
 
+	key := cs.cseKey("Inverse", false, v.linExp)
+	if res, ok := cs.cse[key]; ok {
+		return res[0]
+	}
+
 	// allocate resulting variable
 	res := cs.newInternalVariable()
 
@@ -163,76 +236,531 @@ func (cs *ConstraintSystem) Inverse(v Variable) Variable {
 	R := res.linExp
 	O := cs.LinearExpression(cs.getOneTerm())
 	constraint := r1c.R1C{L: L, R: R, O: O, Solver: r1c.SingleOutput}
-	cs.constraints = append(cs.constraints, constraint)
+	cs.addConstraint(constraint)
+	cs.cse[key] = []Variable{res}
 
 	return res
 }
 
-// Div returns res = i1 / i2
+// NewHint returns a new internal variable, computed by f from the values of inputs when the circuit is
+// solved (inputs are Variables or constants)
+//
+// no constraint is recorded: the circuit must constrain the result, else a proof is valid for any value.
+// f is registered (see hint.Register); a program solving the circuit without compiling it must register f
+func (cs *ConstraintSystem) NewHint(f hint.Function, inputs ...interface{}) Variable {
+
+	if cs.engine != nil {
+		return cs.engine.newHint(f, inputs...)
+	}
+
+	res := cs.newInternalVariable()
+
+	h := r1c.Hint{ID: hint.Register(f), Inputs: make([]r1c.LinearExpression, len(inputs)), Wire: res.id}
+	for i, input := range inputs {
+		v := cs.Constant(input)
+		h.Inputs[i] = cs.copyLinExp(v.linExp)
+	}
+	cs.hints = append(cs.hints, h)
+
+	return res
+}
+
+// NewMultiHint returns nbOutputs new internal variables, computed together by f from the values of inputs
+// when the circuit is solved, as NewHint
+func (cs *ConstraintSystem) NewMultiHint(f hint.MultiFunction, nbOutputs int, inputs ...interface{}) []Variable {
+
+	if cs.engine != nil {
+		return cs.engine.newMultiHint(f, nbOutputs, inputs...)
+	}
+	if nbOutputs <= 0 {
+		panic("NewMultiHint: a hint has at least one output")
+	}
+
+	res := make([]Variable, nbOutputs)
+	h := r1c.Hint{ID: hint.RegisterMulti(f), Inputs: make([]r1c.LinearExpression, len(inputs)), Wires: make([]int, nbOutputs)}
+	for i := range res {
+		res[i] = cs.newInternalVariable()
+		h.Wires[i] = res[i].id
+	}
+	h.Wire = h.Wires[0]
+	for i, input := range inputs {
+		v := cs.Constant(input)
+		h.Inputs[i] = cs.copyLinExp(v.linExp)
+	}
+	cs.hints = append(cs.hints, h)
+
+	return res
+}
+
+// Commit returns a new internal variable, a commitment to the values of vars: a random challenge, which
+// the witness can't choose once the values of vars are set (Fiat-Shamir)
+//
+// the groth16 backend folds a commitment to the secret variables in the proof, and the challenge is
+// derived from it and from the public ones; else (see r1cs.R1CS.IsSolved) the challenge is the hash of
+// the values (see hint.Commitment). A circuit has at most one commitment
+func (cs *ConstraintSystem) Commit(vars ...Variable) Variable {
+
+	if cs.engine != nil {
+		return cs.engine.commit(vars)
+	}
+	if cs.commitment != -1 {
+		panic("Commit: the circuit already has a commitment")
+	}
+	if len(vars) == 0 {
+		panic("Commit: nothing to commit")
+	}
+
+	// the committed values are single wires
+	inputs := make([]interface{}, len(vars))
+	for i := range vars {
+		inputs[i] = cs.buildVarFromPartialVar(cs.toWire(vars[i]))
+	}
+	res := cs.NewHint(hint.Commitment, inputs...)
+	cs.commitment = len(cs.hints) - 1
+
+	return res
+}
+
+// Len returns the length wire of a slice tagged with maxlen (see Tag): the number of elements the
+// witness assigns, the other elements being 0. v must be the slice field itself (not a subslice)
+func (cs *ConstraintSystem) Len(v []Variable) Variable {
+	if cs.engine != nil {
+		return cs.engine.len(v)
+	}
+	if len(v) > 0 {
+		if length, ok := cs.lengths[&v[0]]; ok {
+			return length
+		}
+	}
+	panic(errNotVarLen)
+}
+
+// Defer registers f, to be called with the constraint system at the end of Define
+//
+// a gadget can accumulate work over all its uses (for example the values of a batched range check, or
+// of a lookup table) and record the constraints once they are all known. The functions are called in
+// the order of registration, in the namespace they were registered in (see Namespace); they can
+// register other functions, called after them
+func (cs *ConstraintSystem) Defer(f func(cs *ConstraintSystem)) {
+	cs.deferred = append(cs.deferred, deferredCall{namespace: append([]string(nil), cs.namespace...), f: f})
+}
+
+// Namespace calls f, and records the wires and constraints f creates in the namespace name
+//
+// namespaces nest: in cs.Namespace("merkle", func(cs *ConstraintSystem) { cs.Namespace("level3", ...) }),
+// the constraints of level3 are in the namespace "merkle/level3". The namespaces are kept in the R1CS:
+// the solver prefixes the error of a constraint with its namespace, and the tools analyzing a R1CS
+// (dot, diff, debug) count and group the constraints by namespace
+func (cs *ConstraintSystem) Namespace(name string, f func(cs *ConstraintSystem)) {
+
+	if cs.engine != nil {
+		f(cs)
+		return
+	}
+
+	cs.namespace = append(cs.namespace, name)
+	cs.startNamespace()
+	defer func() {
+		cs.namespace = cs.namespace[:len(cs.namespace)-1]
+		cs.startNamespace()
+	}()
+
+	f(cs)
+}
+
+// IsZero returns a boolean variable equal to 1 if a == 0, and to 0 otherwise
+//
+// the inverse of a (or 0) is computed by a hint, and checked by 2 constraints:
+// a * inv == 1 - res, and a * res == 0
+func (cs *ConstraintSystem) IsZero(a Variable) Variable {
+
+	if cs.engine != nil {
+		return cs.engine.isZero(a)
+	}
+
+	cs.completeDanglingVariable(&a)
+
+	if c, ok := cs.constantValue(a); ok && c.BitLen() <= maxReducedBits {
+		// no constraint is recorded
+		if c.Sign() == 0 {
+			return cs.Constant(1)
+		}
+		return cs.Constant(0)
+	}
+
+	key := cs.cseKey("IsZero", false, a.linExp)
+	if res, ok := cs.cse[key]; ok {
+		return res[0]
+	}
+
+	inv := cs.NewHint(hint.InvZero, a)
+	res := cs.newInternalVariable()
+	cs.cse[key] = []Variable{res}
+
+	// a * inv == 1 - res
+	o := cs.Sub(1, res) // no constraint is recorded
+	constraint := r1c.R1C{L: cs.copyLinExp(a.linExp), R: cs.copyLinExp(inv.linExp), O: cs.copyLinExp(o.linExp), Solver: r1c.SingleOutput}
+	cs.addConstraint(constraint)
+
+	// a * res == 0, so res is 0 if a != 0; if a == 0, res is 1 by the first constraint
+	zero := cs.Constant(0) // no constraint is recorded
+	debugInfo := logEntry{format: "error IsZero"}
+	for _, frame := range getCallStack() {
+		debugInfo.format += "\n" + frame
+	}
+	cs.addAssertion(r1c.R1C{L: cs.copyLinExp(a.linExp), R: cs.copyLinExp(res.linExp), O: cs.copyLinExp(zero.linExp), Solver: r1c.SingleOutput}, debugInfo)
+
+	// res is 0 or 1
+	cs.markBoolean(res)
+
+	return res
+}
+
+// Div returns res = i1 / i2, and asserts that i2 != 0
+//
+// res = i1 * inverse(i2), where i2 * inverse(i2) == 1: 2 constraints (1 if i1 is constant), the inverse of
+// i2 is shared by the divisions by i2. See DivUnchecked for a single constraint, without the assertion
 func (cs *ConstraintSystem) Div(i1, i2 interface{}) Variable {
 
+	if cs.engine != nil {
+		return cs.engine.div(i1, i2)
+	}
+
+	d := cs.Constant(i2) // no constraint is recorded
+	if c, ok := cs.constantValue(d); ok && c.Sign() == 0 {
+		panic("Div: division by 0")
+	}
+
+	return cs.Mul(i1, cs.Inverse(d))
+}
+
+// DivUnchecked returns res = i1 / i2, with a single constraint: i2 * res == i1
+//
+// i2 is not asserted to be different from 0: if i2 == 0 and i1 != 0, the circuit has no solution, and
+// solving it fails; if i2 == 0 and i1 == 0 (0/0), the solver sets res to 0, but the constraint holds for
+// any value of res, which a prover can choose. The caller must constrain res if i2 can be 0
+func (cs *ConstraintSystem) DivUnchecked(i1, i2 interface{}) Variable {
+
+	if cs.engine != nil {
+		return cs.engine.divUnchecked(i1, i2)
+	}
+
+	// i1 and i2 are Variables or constants
+	n := cs.Constant(i1) // no constraint is recorded
+	d := cs.Constant(i2) // no constraint is recorded
+
+	key := cs.cseKey("DivUnchecked", false, n.linExp, d.linExp)
+	if res, ok := cs.cse[key]; ok {
+		return res[0]
+	}
+
 	// allocate resulting variable
 	res := cs.newInternalVariable()
 
-	// O
-	switch t1 := i1.(type) {
-	case Variable:
-		cs.completeDanglingVariable(&t1)
-		switch t2 := i2.(type) {
-		case Variable:
-			cs.completeDanglingVariable(&t2)
-			constraint := r1c.R1C{L: t2.linExp, R: res.linExp, O: t1.linExp, Solver: r1c.SingleOutput}
-			cs.constraints = append(cs.constraints, constraint)
-		default:
-			tmp := cs.Constant(t2)
-			constraint := r1c.R1C{L: tmp.getLinExpCopy(), R: res.getLinExpCopy(), O: t1.getLinExpCopy(), Solver: r1c.SingleOutput}
-			cs.constraints = append(cs.constraints, constraint)
+	// i2 * res == i1
+	constraint := r1c.R1C{L: cs.copyLinExp(d.linExp), R: cs.copyLinExp(res.linExp), O: cs.copyLinExp(n.linExp), Solver: r1c.SingleOutput}
+	cs.addConstraint(constraint)
+	cs.cse[key] = []Variable{res}
+
+	return res
+}
+
+// InverseOrZero returns res = 1/a if a != 0, and res = 0 if a == 0
+//
+// the inverse of a (or 0) is computed by a hint, and checked by 3 constraints:
+// a * res == 1 - z, a * z == 0 and res * z == 0 (z is 1 if a == 0, as in IsZero)
+func (cs *ConstraintSystem) InverseOrZero(a Variable) Variable {
+
+	if cs.engine != nil {
+		return cs.engine.inverseOrZero(a)
+	}
+
+	cs.completeDanglingVariable(&a)
+
+	if c, ok := cs.constantValue(a); ok && c.BitLen() <= maxReducedBits {
+		if c.Sign() == 0 {
+			return cs.Constant(0)
 		}
-	default:
-		switch t2 := i2.(type) {
-		case Variable:
-			cs.completeDanglingVariable(&t2)
-			tmp := cs.Constant(t1)
-			constraint := r1c.R1C{L: t2.getLinExpCopy(), R: res.getLinExpCopy(), O: tmp.getLinExpCopy(), Solver: r1c.SingleOutput}
-			cs.constraints = append(cs.constraints, constraint)
-		default:
-			tmp1 := cs.Constant(t1)
-			tmp2 := cs.Constant(t2)
-			constraint := r1c.R1C{L: tmp2.getLinExpCopy(), R: res.getLinExpCopy(), O: tmp1.getLinExpCopy(), Solver: r1c.SingleOutput}
-			cs.constraints = append(cs.constraints, constraint)
+		return cs.Inverse(a)
+	}
+
+	key := cs.cseKey("InverseOrZero", false, a.linExp)
+	if res, ok := cs.cse[key]; ok {
+		return res[0]
+	}
+
+	res := cs.NewHint(hint.InvZero, a)
+	z := cs.newInternalVariable()
+	cs.cse[key] = []Variable{res}
+
+	// a * res == 1 - z
+	o := cs.Sub(1, z) // no constraint is recorded
+	cs.addConstraint(r1c.R1C{L: cs.copyLinExp(a.linExp), R: cs.copyLinExp(res.linExp), O: cs.copyLinExp(o.linExp), Solver: r1c.SingleOutput})
+
+	// a * z == 0, so z is 0 and res is 1/a if a != 0; res * z == 0, so res is 0 if a == 0
+	zero := cs.Constant(0) // no constraint is recorded
+	debugInfo := logEntry{format: "error InverseOrZero"}
+	for _, frame := range getCallStack() {
+		debugInfo.format += "\n" + frame
+	}
+	cs.addAssertion(r1c.R1C{L: cs.copyLinExp(a.linExp), R: cs.copyLinExp(z.linExp), O: cs.copyLinExp(zero.linExp), Solver: r1c.SingleOutput}, debugInfo)
+	cs.addAssertion(r1c.R1C{L: cs.copyLinExp(res.linExp), R: cs.copyLinExp(z.linExp), O: cs.copyLinExp(zero.linExp), Solver: r1c.SingleOutput}, debugInfo)
+
+	return res
+}
+
+// BatchInvert returns the inverses of vars, computed together when the circuit is solved with a single
+// field inversion (see hint.BatchInvert) instead of one per variable; each inverse is constrained by one
+// multiplication, vars[i] * res[i] == 1
+//
+// as with Inverse, the circuit is not satisfied if one of vars is 0
+func (cs *ConstraintSystem) BatchInvert(vars []Variable) []Variable {
+
+	if cs.engine != nil {
+		return cs.engine.batchInvert(vars)
+	}
+
+	res := make([]Variable, len(vars))
+	var toInvert []int // index of the variables which are not constants
+	linExps := make([]r1c.LinearExpression, len(vars))
+	for i := range vars {
+		cs.completeDanglingVariable(&vars[i])
+		linExps[i] = vars[i].linExp
+		if _, ok := cs.constantValue(vars[i]); ok {
+			res[i] = cs.Inverse(vars[i])
+		} else {
+			toInvert = append(toInvert, i)
 		}
 	}
+	if len(toInvert) == 0 {
+		return res
+	}
+
+	key := cs.cseKey("BatchInvert", false, linExps...)
+	if inverses, ok := cs.cse[key]; ok {
+		return append([]Variable(nil), inverses...)
+	}
+
+	inputs := make([]interface{}, len(toInvert))
+	for i, j := range toInvert {
+		inputs[i] = vars[j]
+	}
+	inverses := cs.NewMultiHint(hint.BatchInvert, len(toInvert), inputs...)
+
+	one := cs.Constant(1) // no constraint is recorded
+	debugInfo := logEntry{format: "error BatchInvert"}
+	for _, frame := range getCallStack() {
+		debugInfo.format += "\n" + frame
+	}
+	for i, j := range toInvert {
+		res[j] = inverses[i]
+		cs.addAssertion(r1c.R1C{L: cs.copyLinExp(vars[j].linExp), R: cs.copyLinExp(res[j].linExp), O: cs.copyLinExp(one.linExp), Solver: r1c.SingleOutput}, debugInfo)
+	}
+	cs.cse[key] = res
+
+	return append([]Variable(nil), res...)
+}
+
+// Xor compute the xor between two variables (or more: Xor(a, b, c) == Xor(Xor(a, b), c))
+//
+// the inputs are constrained to be boolean (once, see AssertIsBoolean), the result is a boolean wire
+func (cs *ConstraintSystem) Xor(a, b Variable, in ...Variable) Variable {
+
+	if cs.engine != nil {
+		return cs.engine.xor(a, b, in...)
+	}
+
+	xor := func(a, b Variable) Variable {
+		cs.completeDanglingVariable(&a)
+		cs.completeDanglingVariable(&b)
+
+		cs.AssertIsBoolean(a)
+		cs.AssertIsBoolean(b)
+
+		// a constant input is folded, no constraint is recorded
+		if _, ok := cs.boolConstant(a); ok {
+			a, b = b, a
+		}
+		if c, ok := cs.boolConstant(b); ok {
+			if c == 0 {
+				return a
+			}
+			return cs.Sub(1, a)
+		}
+
+		key := cs.cseKey("Xor", true, a.linExp, b.linExp)
+		if res, ok := cs.cse[key]; ok {
+			return res[0]
+		}
+
+		res := cs.newInternalVariable()
+		cs.cse[key] = []Variable{res}
+		v1 := cs.Mul(2, a)   // no constraint recorded
+		v2 := cs.Add(a, b)   // no constraint recorded
+		v2 = cs.Sub(v2, res) // no constraint recorded
+
+		constraint := r1c.R1C{L: cs.copyLinExp(v1.linExp), R: cs.copyLinExp(b.linExp), O: cs.copyLinExp(v2.linExp), Solver: r1c.SingleOutput}
+		cs.addConstraint(constraint)
+
+		// a + b - 2ab is 0 or 1
+		cs.markBoolean(res)
+
+		return res
+	}
+
+	res := xor(a, b)
+	for i := 0; i < len(in); i++ {
+		res = xor(res, in[i])
+	}
+
+	return res
+}
+
+// And compute the and between two variables (or more: And(a, b, c) == And(And(a, b), c))
+//
+// the inputs are constrained to be boolean (once, see AssertIsBoolean), the result is a boolean wire
+func (cs *ConstraintSystem) And(a, b Variable, in ...Variable) Variable {
+
+	if cs.engine != nil {
+		return cs.engine.and(a, b, in...)
+	}
+
+	and := func(a, b Variable) Variable {
+		cs.completeDanglingVariable(&a)
+		cs.completeDanglingVariable(&b)
+
+		cs.AssertIsBoolean(a)
+		cs.AssertIsBoolean(b)
+
+		// a constant input is folded, no constraint is recorded
+		if _, ok := cs.boolConstant(a); ok {
+			a, b = b, a
+		}
+		if c, ok := cs.boolConstant(b); ok {
+			if c == 0 {
+				return cs.Constant(0)
+			}
+			return a
+		}
+
+		res := cs.Mul(a, b)
+
+		// ab is 0 or 1
+		cs.markBoolean(res)
+
+		return res
+	}
+
+	res := and(a, b)
+	for i := 0; i < len(in); i++ {
+		res = and(res, in[i])
+	}
+
+	return res
+}
+
+// Or compute the or between two variables (or more: Or(a, b, c) == Or(Or(a, b), c))
+//
+// the inputs are constrained to be boolean (once, see AssertIsBoolean), the result is a boolean wire
+func (cs *ConstraintSystem) Or(a, b Variable, in ...Variable) Variable {
+
+	if cs.engine != nil {
+		return cs.engine.or(a, b, in...)
+	}
+
+	or := func(a, b Variable) Variable {
+		cs.completeDanglingVariable(&a)
+		cs.completeDanglingVariable(&b)
+
+		cs.AssertIsBoolean(a)
+		cs.AssertIsBoolean(b)
+
+		// a constant input is folded, no constraint is recorded
+		if _, ok := cs.boolConstant(a); ok {
+			a, b = b, a
+		}
+		if c, ok := cs.boolConstant(b); ok {
+			if c == 0 {
+				return a
+			}
+			return cs.Constant(1)
+		}
+
+		key := cs.cseKey("Or", true, a.linExp, b.linExp)
+		if res, ok := cs.cse[key]; ok {
+			return res[0]
+		}
+
+		// a * b == a + b - res
+		res := cs.newInternalVariable()
+		cs.cse[key] = []Variable{res}
+		v := cs.Add(a, b)  // no constraint recorded
+		v = cs.Sub(v, res) // no constraint recorded
+
+		constraint := r1c.R1C{L: cs.copyLinExp(a.linExp), R: cs.copyLinExp(b.linExp), O: cs.copyLinExp(v.linExp), Solver: r1c.SingleOutput}
+		cs.addConstraint(constraint)
+
+		// a + b - ab is 0 or 1
+		cs.markBoolean(res)
+
+		return res
+	}
+
+	res := or(a, b)
+	for i := 0; i < len(in); i++ {
+		res = or(res, in[i])
+	}
 
 	return res
 }
 
-// Xor compute the xor between two variables
-func (cs *ConstraintSystem) Xor(a, b Variable) Variable {
+// Not returns 1 - a, a is constrained to be boolean
+//
+// the result is a linear expression, recorded as boolean (see AssertIsBoolean)
+func (cs *ConstraintSystem) Not(a Variable) Variable {
+
+	if cs.engine != nil {
+		return cs.engine.not(a)
+	}
 
 	cs.completeDanglingVariable(&a)
-	cs.completeDanglingVariable(&b)
 
 	cs.AssertIsBoolean(a)
-	cs.AssertIsBoolean(b)
 
-	res := cs.newInternalVariable()
-	v1 := cs.Mul(2, a)   // no constraint recorded
-	v2 := cs.Add(a, b)   // no constraint recorded
-	v2 = cs.Sub(v2, res) // no constraint recorded
+	res := cs.Sub(1, a) // no constraint recorded
 
-	constraint := r1c.R1C{L: v1.getLinExpCopy(), R: b.getLinExpCopy(), O: v2.getLinExpCopy(), Solver: r1c.SingleOutput}
-	cs.constraints = append(cs.constraints, constraint)
+	// 1 - a is 0 or 1
+	cs.markBoolean(res)
 
 	return res
 }
 
 // ToBinary unpacks a variable in binary, n is the number of bits of the variable
 //
-// The result in in little endian (first bit= lsb)
+// The result in in little endian (first bit= lsb): FromBinary(ToBinary(a, n)...) == a. The bits are
+// constrained to be boolean; if a doesn't fit in nbBits bits, the decomposition is not satisfied
 func (cs *ConstraintSystem) ToBinary(a Variable, nbBits int) []Variable {
 
+	if cs.engine != nil {
+		return cs.engine.toBinary(a, nbBits)
+	}
+
 	cs.completeDanglingVariable(&a)
 
+	if c, ok := cs.constantValue(a); ok && c.Sign() >= 0 && c.BitLen() <= nbBits && c.BitLen() <= maxReducedBits {
+		// the bits are constants, no constraint is recorded
+		res := make([]Variable, nbBits)
+		for i := 0; i < nbBits; i++ {
+			res[i] = cs.Constant(int(c.Bit(i)))
+		}
+		return res
+	}
+
+	key := cs.cseKey("ToBinary"+strconv.Itoa(nbBits), false, a.linExp)
+	if res, ok := cs.cse[key]; ok {
+		return append([]Variable(nil), res...)
+	}
+
 	// allocate the resulting variables
 	res := make([]Variable, nbBits)
 	for i := 0; i < nbBits; i++ {
@@ -255,16 +783,23 @@ func (cs *ConstraintSystem) ToBinary(a Variable, nbBits int) []Variable {
 
 	r := cs.getOneVariable()
 
-	constraint := r1c.R1C{L: v.getLinExpCopy(), R: r.getLinExpCopy(), O: a.getLinExpCopy(), Solver: r1c.BinaryDec}
-	cs.constraints = append(cs.constraints, constraint)
+	constraint := r1c.R1C{L: cs.copyLinExp(v.linExp), R: cs.copyLinExp(r.linExp), O: cs.copyLinExp(a.linExp), Solver: r1c.BinaryDec}
+	cs.addConstraint(constraint)
+	cs.cse[key] = append([]Variable(nil), res...)
 
 	return res
 
 }
 
-// FromBinary packs b, seen as a fr.Element in little endian
+// FromBinary packs b, seen as a fr.Element in little endian (b[0] is the lsb)
+//
+// the b[i]'s are constrained to be boolean, if they are not already
 func (cs *ConstraintSystem) FromBinary(b ...Variable) Variable {
 
+	if cs.engine != nil {
+		return cs.engine.fromBinary(b...)
+	}
+
 	for i := 0; i < len(b); i++ {
 		cs.completeDanglingVariable(&b[i])
 	}
@@ -274,8 +809,7 @@ func (cs *ConstraintSystem) FromBinary(b ...Variable) Variable {
 
 	var coeff big.Int
 
-	L := make(r1c.LinearExpression, len(b))
-	for i := 0; i < len(L); i++ {
+	for i := 0; i < len(b); i++ {
 		if i == 0 {
 			coeff.Set(bOne)
 		} else if i == 1 {
@@ -291,15 +825,40 @@ func (cs *ConstraintSystem) FromBinary(b ...Variable) Variable {
 	return res
 }
 
-// Select if b is true, yields i1 else yields i2
+// Select if b is true, yields i1 else yields i2 (b*i1 + (1-b)*i2)
+//
+// b is constrained to be boolean once, whatever the number of selections on b (see AssertIsBoolean)
 func (cs *ConstraintSystem) Select(b Variable, i1, i2 interface{}) Variable {
 
+	if cs.engine != nil {
+		return cs.engine.selectValue(b, i1, i2)
+	}
+
 	cs.completeDanglingVariable(&b)
 
-	// ensures that b is boolean
+	// a constant selector is folded, no constraint is recorded
+	if c, ok := cs.boolConstant(b); ok {
+		if c == 1 {
+			return cs.Constant(i1)
+		}
+		return cs.Constant(i2)
+	}
+
+	// constant Variables are selected as constants
+	i1, i2 = cs.fold(i1), cs.fold(i2)
+
+	v1 := cs.Constant(i1) // no constraint is recorded
+	v2 := cs.Constant(i2) // no constraint is recorded
+	key := cs.cseKey("Select", false, b.linExp, v1.linExp, v2.linExp)
+	if res, ok := cs.cse[key]; ok {
+		return res[0]
+	}
+
+	// ensures that b is boolean; on a cache hit it already is
 	cs.AssertIsBoolean(b)
 
 	var res Variable
+	defer func() { cs.cse[key] = []Variable{res} }()
 
 	switch t1 := i1.(type) {
 	case Variable:
@@ -308,8 +867,8 @@ func (cs *ConstraintSystem) Select(b Variable, i1, i2 interface{}) Variable {
 		v := cs.Sub(t1, i2)  // no constraint is recorded
 		w := cs.Sub(res, i2) // no constraint is recorded
 		//cs.Println("u-v: ", v)
-		constraint := r1c.R1C{L: b.getLinExpCopy(), R: v.getLinExpCopy(), O: w.getLinExpCopy(), Solver: r1c.SingleOutput}
-		cs.constraints = append(cs.constraints, constraint)
+		constraint := r1c.R1C{L: cs.copyLinExp(b.linExp), R: cs.copyLinExp(v.linExp), O: cs.copyLinExp(w.linExp), Solver: r1c.SingleOutput}
+		cs.addConstraint(constraint)
 		return res
 	default:
 		switch t2 := i2.(type) {
@@ -318,8 +877,8 @@ func (cs *ConstraintSystem) Select(b Variable, i1, i2 interface{}) Variable {
 			res = cs.newInternalVariable()
 			v := cs.Sub(t1, t2)  // no constraint is recorded
 			w := cs.Sub(res, t2) // no constraint is recorded
-			constraint := r1c.R1C{L: b.getLinExpCopy(), R: v.getLinExpCopy(), O: w.getLinExpCopy(), Solver: r1c.SingleOutput}
-			cs.constraints = append(cs.constraints, constraint)
+			constraint := r1c.R1C{L: cs.copyLinExp(b.linExp), R: cs.copyLinExp(v.linExp), O: cs.copyLinExp(w.linExp), Solver: r1c.SingleOutput}
+			cs.addConstraint(constraint)
 			return res
 		default:
 			// in this case, no constraint is recorded
@@ -333,11 +892,50 @@ func (cs *ConstraintSystem) Select(b Variable, i1, i2 interface{}) Variable {
 	}
 }
 
+// Lookup2 performs a 2-bit lookup between i0, i1, i2, i3 based on the bits b0 (lsb) and b1: it yields
+// i0 if b0 = b1 = 0, i1 if b0 = 1 and b1 = 0, i2 if b0 = 0 and b1 = 1, and i3 if b0 = b1 = 1
+//
+// it records at most 3 constraints, plus the boolean constraints of b0 and b1 (once, see AssertIsBoolean)
+func (cs *ConstraintSystem) Lookup2(b0, b1 Variable, i0, i1, i2, i3 interface{}) Variable {
+
+	if cs.engine != nil {
+		return cs.engine.lookup2(b0, b1, i0, i1, i2, i3)
+	}
+
+	cs.completeDanglingVariable(&b0)
+	cs.completeDanglingVariable(&b1)
+
+	// ensures that b0 and b1 are boolean
+	cs.AssertIsBoolean(b0)
+	cs.AssertIsBoolean(b1)
+
+	// (i3 - i2 - i1 + i0) * b1 == tmp1 - i1 + i0
+	tmp1 := cs.Sub(cs.Add(i3, i0), cs.Add(i2, i1)) // no constraint is recorded
+	tmp1 = cs.Mul(tmp1, b1)
+	tmp1 = cs.Add(tmp1, i1)
+	tmp1 = cs.Sub(tmp1, i0) // no constraint is recorded
+
+	// tmp1 * b0 == tmp2
+	tmp2 := cs.Mul(tmp1, b0)
+
+	// (i2 - i0) * b1 == res - tmp2 - i0
+	res := cs.Sub(i2, i0) // no constraint is recorded
+	res = cs.Mul(res, b1)
+	res = cs.Add(res, tmp2, i0) // no constraint is recorded
+
+	return res
+}
+
 // Constant will return (and allocate if neccesary) a constant Variable
 //
 // input can be a Variable or must be convertible to big.Int (see backend.FromInterface)
 func (cs *ConstraintSystem) Constant(input interface{}) Variable {
 
+	if cs.engine != nil {
+		v := cs.engine.value(input)
+		return cs.engine.variable(&v)
+	}
+
 	switch t := input.(type) {
 	case Variable:
 		cs.completeDanglingVariable(&t)
@@ -359,16 +957,22 @@ func (cs *ConstraintSystem) buildLogEntryFromVariable(v Variable) logEntry {
 		if i > 0 {
 			res.format += " + "
 		}
-		c := cs.coeffs[v.linExp[i].CoeffID()]
+		var c big.Int
+		cs.coeffs.Get(v.linExp[i].CoeffID(), &c)
 		res.format += fmt.Sprintf("(%%s * %s)", c.String())
 	}
-	res.toResolve = v.getLinExpCopy()
+	res.toResolve = cs.copyLinExp(v.linExp)
 	return res
 }
 
 // AssertIsEqual adds an assertion in the constraint system (i1 == i2)
 func (cs *ConstraintSystem) AssertIsEqual(i1, i2 interface{}) {
 
+	if cs.engine != nil {
+		cs.engine.assertIsEqual(i1, i2)
+		return
+	}
+
 	// encoded as L * R == O
 	// set L = i1
 	// set R = 1
@@ -380,7 +984,15 @@ func (cs *ConstraintSystem) AssertIsEqual(i1, i2 interface{}) {
 	l := cs.Constant(i1) // no constraint is recorded
 	r := cs.Constant(1)  // no constraint is recorded
 	o := cs.Constant(i2) // no constraint is recorded
-	constraint := r1c.R1C{L: l.getLinExpCopy(), R: r.getLinExpCopy(), O: o.getLinExpCopy(), Solver: r1c.SingleOutput}
+
+	// two equal constants record no assertion; otherwise the assertion is recorded, and the solver
+	// checks it modulo the scalar field
+	if c1, ok := cs.constantValue(l); ok {
+		if c2, ok := cs.constantValue(o); ok && c1.Cmp(&c2) == 0 {
+			return
+		}
+	}
+	constraint := r1c.R1C{L: cs.copyLinExp(l.linExp), R: cs.copyLinExp(r.linExp), O: cs.copyLinExp(o.linExp), Solver: r1c.SingleOutput}
 
 	debugInfo.format += "["
 	lhs := cs.buildLogEntryFromVariable(l)
@@ -398,17 +1010,26 @@ func (cs *ConstraintSystem) AssertIsEqual(i1, i2 interface{}) {
 // AssertIsBoolean adds an assertion in the constraint system (v == 0 || v == 1)
 func (cs *ConstraintSystem) AssertIsBoolean(v Variable) {
 
+	if cs.engine != nil {
+		cs.engine.assertIsBoolean(v)
+		return
+	}
+
 	cs.completeDanglingVariable(&v)
 
-	if v.isBoolean {
+	if cs.isBoolean(v) {
+		return
+	}
+	if _, ok := cs.boolConstant(v); ok {
+		// no constraint is recorded
 		return
 	}
+	cs.markBoolean(v)
 
 	_v := cs.Sub(1, v)  // no variable is recorded in the cs
 	o := cs.Constant(0) // no variable is recorded in the cs
-	v.isBoolean = true
 
-	constraint := r1c.R1C{L: v.getLinExpCopy(), R: _v.getLinExpCopy(), O: o.getLinExpCopy(), Solver: r1c.SingleOutput}
+	constraint := r1c.R1C{L: cs.copyLinExp(v.linExp), R: cs.copyLinExp(_v.linExp), O: cs.copyLinExp(o.linExp), Solver: r1c.SingleOutput}
 
 	// prepare debug info to be displayed in case the constraint is not solved
 	// debugInfo := logEntry{
@@ -432,10 +1053,21 @@ func (cs *ConstraintSystem) AssertIsBoolean(v Variable) {
 //
 // bound can be a constant or a Variable
 //
+// v and a Variable bound are decomposed on 252 bits, so that the decomposition is unique (see Cmp): the
+// assertion fails if they don't fit. AssertIsLessOrEqual panics if a constant bound is negative or doesn't
+// fit in 252 bits.
+//
 // derived from:
 // https://github.com/zcash/zips/blOoutputb/master/protocol/protocol.pdf
 func (cs *ConstraintSystem) AssertIsLessOrEqual(v Variable, bound interface{}) {
 
+	checkLessOrEqBound(bound)
+
+	if cs.engine != nil {
+		cs.engine.assertIsLessOrEqual(v, bound)
+		return
+	}
+
 	cs.completeDanglingVariable(&v)
 
 	switch b := bound.(type) {
@@ -448,6 +1080,118 @@ func (cs *ConstraintSystem) AssertIsLessOrEqual(v Variable, bound interface{}) {
 
 }
 
+// Cmp returns 1 if i1 > i2, 0 if i1 == i2, and -1 if i1 < i2
+//
+// i1 and i2 can be constants or Variables of at most nbBits bits; the Variables are decomposed on nbBits
+// bits (see ToBinary), and the bits are compared from the most significant one. The bits of a constant
+// are known, comparing them records no constraint.
+//
+// nbBits is at most 252: the scalar fields of the supported curves have more bits, so the decomposition
+// of a Variable is unique (a decomposition on the bit length of the field would also be satisfied by the
+// bits of v + r, for v < 2^bitlen - r). Cmp panics if nbBits is out of range, or if a constant doesn't fit.
+func (cs *ConstraintSystem) Cmp(i1, i2 interface{}, nbBits int) Variable {
+
+	checkCmp(i1, i2, nbBits)
+
+	if cs.engine != nil {
+		return cs.engine.cmp(i1, i2, nbBits)
+	}
+
+	_, ok1 := i1.(Variable)
+	_, ok2 := i2.(Variable)
+	if !ok1 && !ok2 {
+		n1 := backend.FromInterface(i1)
+		n2 := backend.FromInterface(i2)
+		return cs.Constant(n1.Cmp(&n2))
+	}
+
+	a := cs.toBits(i1, nbBits)
+	b := cs.toBits(i2, nbBits)
+
+	// res is the comparison of the bits seen so far, and eq is 1 if they are all equal
+	var res, eq Variable
+	for i := nbBits - 1; i >= 0; i-- {
+		d := cs.Sub(a[i], b[i]) // no constraint is recorded, d is -1, 0 or 1
+		if i == nbBits-1 {
+			res = d
+		} else {
+			res = cs.Add(res, cs.Mul(eq, d))
+		}
+		if i > 0 {
+			neq := cs.Sub(1, cs.xorBits(a[i], b[i])) // 1 if the bits are equal
+			if i == nbBits-1 {
+				eq = neq
+			} else {
+				eq = cs.Mul(eq, neq)
+			}
+		}
+	}
+
+	return res
+}
+
+// checkLessOrEqBound panics if bound is a constant that AssertIsLessOrEqual can't compare on
+// maxReducedBits bits
+func checkLessOrEqBound(bound interface{}) {
+	if _, ok := bound.(Variable); ok {
+		return
+	}
+	if n := backend.FromInterface(bound); n.Sign() < 0 || n.BitLen() > maxReducedBits {
+		panic("AssertIsLessOrEqual: bound " + n.String() + " doesn't fit in " + strconv.Itoa(maxReducedBits) + " bits")
+	}
+}
+
+// checkCmp panics if the arguments of Cmp are invalid
+func checkCmp(i1, i2 interface{}, nbBits int) {
+	if nbBits <= 0 || nbBits > maxReducedBits {
+		panic("Cmp: nbBits must be between 1 and " + strconv.Itoa(maxReducedBits))
+	}
+	for _, i := range []interface{}{i1, i2} {
+		if _, ok := i.(Variable); ok {
+			continue
+		}
+		if n := backend.FromInterface(i); n.Sign() < 0 || n.BitLen() > nbBits {
+			panic("Cmp: constant " + n.String() + " doesn't fit in " + strconv.Itoa(nbBits) + " bits")
+		}
+	}
+}
+
+// toBits returns the nbBits bits of i, in little endian: the bits of a Variable (see ToBinary), or the
+// bits of a constant as int
+func (cs *ConstraintSystem) toBits(i interface{}, nbBits int) []interface{} {
+	res := make([]interface{}, nbBits)
+	if v, ok := i.(Variable); ok {
+		for j, bit := range cs.ToBinary(v, nbBits) {
+			res[j] = bit
+		}
+		return res
+	}
+	n := backend.FromInterface(i)
+	for j := 0; j < nbBits; j++ {
+		res[j] = int(n.Bit(j))
+	}
+	return res
+}
+
+// xorBits returns a xor b, for bits returned by toBits (a constant bit records no constraint)
+func (cs *ConstraintSystem) xorBits(a, b interface{}) Variable {
+	va, okA := a.(Variable)
+	vb, okB := b.(Variable)
+	switch {
+	case okA && okB:
+		return cs.Xor(va, vb)
+	case okA:
+		if b.(int) == 0 {
+			return va
+		}
+		return cs.Sub(1, va)
+	case okB:
+		return cs.xorBits(b, a)
+	default:
+		return cs.Constant(a.(int) ^ b.(int))
+	}
+}
+
 func (cs *ConstraintSystem) mustBeLessOrEqVar(w, bound Variable) {
 
 	// prepare debug info to be displayed in case the constraint is not solved
@@ -464,7 +1208,8 @@ func (cs *ConstraintSystem) mustBeLessOrEqVar(w, bound Variable) {
 		debugInfo.format += "\n" + stack[i]
 	}
 
-	const nbBits = 256
+	// the decomposition is unique on maxReducedBits bits (see Cmp)
+	const nbBits = maxReducedBits
 
 	binw := cs.ToBinary(w, nbBits)
 	binbound := cs.ToBinary(bound, nbBits)
@@ -488,7 +1233,7 @@ func (cs *ConstraintSystem) mustBeLessOrEqVar(w, bound Variable) {
 
 		o := cs.Constant(0) // no constraint is recorded
 
-		constraint := r1c.R1C{L: l.getLinExpCopy(), R: r.getLinExpCopy(), O: o.getLinExpCopy(), Solver: r1c.SingleOutput}
+		constraint := r1c.R1C{L: cs.copyLinExp(l.linExp), R: cs.copyLinExp(r.linExp), O: cs.copyLinExp(o.linExp), Solver: r1c.SingleOutput}
 		cs.addAssertion(constraint, debugInfo)
 	}
 
@@ -507,41 +1252,29 @@ func (cs *ConstraintSystem) mustBeLessOrEqCst(v Variable, bound big.Int) {
 		debugInfo.format += "\n" + stack[i]
 	}
 
-	// TODO store those constant elsewhere (for the moment they don't depend on the base curve, but that might change)
-	const nbBits = 256
-	const nbWords = 4
-	const wordSize = 64
+	// the decomposition is unique on maxReducedBits bits (see Cmp)
+	const nbBits = maxReducedBits
 
 	vBits := cs.ToBinary(v, nbBits)
-	boundBits := bound.Bits()
-	l := len(boundBits)
-	if len(boundBits) < nbWords {
-		for i := 0; i < nbWords-l; i++ {
-			boundBits = append(boundBits, big.Word(0))
-		}
-	}
 
 	p := make([]Variable, nbBits+1)
 
 	p[nbBits] = cs.Constant(1)
-	for i := nbWords - 1; i >= 0; i-- {
-		for j := 0; j < wordSize; j++ {
-			b := (boundBits[i] >> (wordSize - 1 - j)) & 1
-			if b == 0 {
-				p[(i+1)*wordSize-1-j] = p[(i+1)*wordSize-j]
+	for i := nbBits - 1; i >= 0; i-- {
+		if bound.Bit(i) == 0 {
+			p[i] = p[i+1]
 
-				l := cs.getOneVariable()
-				l = cs.Sub(l, p[(i+1)*wordSize-j])       // no constraint is recorded
-				l = cs.Sub(l, vBits[(i+1)*wordSize-1-j]) // no constraint is recorded
+			l := cs.getOneVariable()
+			l = cs.Sub(l, p[i+1])   // no constraint is recorded
+			l = cs.Sub(l, vBits[i]) // no constraint is recorded
 
-				r := vBits[(i+1)*wordSize-1-j]
-				o := cs.Constant(0)
-				constraint := r1c.R1C{L: l.linExp, R: r.linExp, O: o.linExp, Solver: r1c.SingleOutput}
-				cs.addAssertion(constraint, debugInfo)
+			r := vBits[i]
+			o := cs.Constant(0)
+			constraint := r1c.R1C{L: cs.copyLinExp(l.linExp), R: cs.copyLinExp(r.linExp), O: cs.copyLinExp(o.linExp), Solver: r1c.SingleOutput}
+			cs.addAssertion(constraint, debugInfo)
 
-			} else {
-				p[(i+1)*wordSize-1-j] = cs.Mul(p[(i+1)*wordSize-j], vBits[(i+1)*wordSize-1-j])
-			}
+		} else {
+			p[i] = cs.Mul(p[i+1], vBits[i])
 		}
 	}
 }
@@ -2,12 +2,16 @@ package frontend
 
 import (
 	"errors"
+	"fmt"
 	"math/big"
 	"testing"
 
 	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/r1cs"
 	"github.com/consensys/gnark/backend/r1cs/r1c"
+	backend_bn256 "github.com/consensys/gnark/internal/backend/bn256"
 	"github.com/consensys/gurvy"
+	"github.com/consensys/gurvy/bn256/fr"
 	"github.com/leanovate/gopter"
 	"github.com/leanovate/gopter/commands"
 	"github.com/leanovate/gopter/gen"
@@ -247,18 +251,21 @@ func rfDiv() runfunc {
 		incVariableName()
 		sVariablesCreated = append(sVariablesCreated, b)
 
-		u := systemUnderTest.(*ConstraintSystem).Div(a, b)
+		u := systemUnderTest.(*ConstraintSystem).DivUnchecked(a, b)
 		iVariablesCreated = append(iVariablesCreated, u)
 
-		v := systemUnderTest.(*ConstraintSystem).Div(a, 3)
+		v := systemUnderTest.(*ConstraintSystem).DivUnchecked(a, 3)
 		iVariablesCreated = append(iVariablesCreated, v)
 
-		w := systemUnderTest.(*ConstraintSystem).Div(3, a)
+		w := systemUnderTest.(*ConstraintSystem).DivUnchecked(3, a)
 		iVariablesCreated = append(iVariablesCreated, w)
 
-		x := systemUnderTest.(*ConstraintSystem).Div(3, 3)
+		x := systemUnderTest.(*ConstraintSystem).DivUnchecked(b, 3)
 		iVariablesCreated = append(iVariablesCreated, x)
 
+		// the quotient of a and b is reused
+		systemUnderTest.(*ConstraintSystem).DivUnchecked(a, b)
+
 		csRes := csResult{
 			systemUnderTest.(*ConstraintSystem),
 			pVariablesCreated,
@@ -306,6 +313,48 @@ func rfXor() runfunc {
 
 var nsXor = deltaState{1, 1, 1, 1, 2}
 
+// boolean operations between two variables
+func rfBoolean() runfunc {
+	res := func(systemUnderTest commands.SystemUnderTest) commands.Result {
+
+		pVariablesCreated := make([]Variable, 0)
+		sVariablesCreated := make([]Variable, 0)
+		iVariablesCreated := make([]Variable, 0)
+
+		a := systemUnderTest.(*ConstraintSystem).newPublicVariable(variableName.String())
+		incVariableName()
+		pVariablesCreated = append(pVariablesCreated, a)
+
+		b := systemUnderTest.(*ConstraintSystem).newSecretVariable(variableName.String())
+		incVariableName()
+		sVariablesCreated = append(sVariablesCreated, b)
+
+		u := systemUnderTest.(*ConstraintSystem).And(a, b)
+		iVariablesCreated = append(iVariablesCreated, u)
+
+		v := systemUnderTest.(*ConstraintSystem).Or(a, b)
+		iVariablesCreated = append(iVariablesCreated, v)
+
+		// the results are boolean, they are not constrained again
+		w := systemUnderTest.(*ConstraintSystem).Xor(u, v, a)
+		iVariablesCreated = append(iVariablesCreated, w)
+
+		systemUnderTest.(*ConstraintSystem).Not(b)
+
+		csRes := csResult{
+			systemUnderTest.(*ConstraintSystem),
+			pVariablesCreated,
+			sVariablesCreated,
+			iVariablesCreated,
+			r1c.SingleOutput}
+
+		return csRes
+	}
+	return res
+}
+
+var nsBoolean = deltaState{1, 1, 4, 4, 2} // Xor(u, v, a) records 2 wires
+
 // binary decomposition of a variable
 func rfToBinary() runfunc {
 	res := func(systemUnderTest commands.SystemUnderTest) commands.Result {
@@ -376,7 +425,78 @@ func rfSelect() runfunc {
 	return res
 }
 
-var nsSelect = deltaState{1, 2, 3, 3, 4}
+var nsSelect = deltaState{1, 2, 3, 3, 1} // a is constrained to be boolean once
+
+// zero test of a variable
+func rfIsZero() runfunc {
+	res := func(systemUnderTest commands.SystemUnderTest) commands.Result {
+
+		pVariablesCreated := make([]Variable, 0)
+		sVariablesCreated := make([]Variable, 0)
+		iVariablesCreated := make([]Variable, 0)
+
+		a := systemUnderTest.(*ConstraintSystem).newSecretVariable(variableName.String())
+		incVariableName()
+		sVariablesCreated = append(sVariablesCreated, a)
+
+		// the result is boolean, it is not constrained again
+		b := systemUnderTest.(*ConstraintSystem).IsZero(a)
+		systemUnderTest.(*ConstraintSystem).AssertIsBoolean(b)
+		iVariablesCreated = append(iVariablesCreated, b)
+
+		csRes := csResult{
+			systemUnderTest.(*ConstraintSystem),
+			pVariablesCreated,
+			sVariablesCreated,
+			iVariablesCreated,
+			r1c.SingleOutput}
+
+		return csRes
+	}
+	return res
+}
+
+var nsIsZero = deltaState{0, 1, 2, 1, 1} // the inverse (a hint) and the result
+
+// 2-bit lookup between variables and constants
+func rfLookup2() runfunc {
+	res := func(systemUnderTest commands.SystemUnderTest) commands.Result {
+
+		pVariablesCreated := make([]Variable, 0)
+		sVariablesCreated := make([]Variable, 0)
+		iVariablesCreated := make([]Variable, 0)
+
+		b0 := systemUnderTest.(*ConstraintSystem).newPublicVariable(variableName.String())
+		incVariableName()
+		pVariablesCreated = append(pVariablesCreated, b0)
+
+		b1 := systemUnderTest.(*ConstraintSystem).newSecretVariable(variableName.String())
+		incVariableName()
+		sVariablesCreated = append(sVariablesCreated, b1)
+
+		a := systemUnderTest.(*ConstraintSystem).newSecretVariable(variableName.String())
+		incVariableName()
+		sVariablesCreated = append(sVariablesCreated, a)
+
+		u := systemUnderTest.(*ConstraintSystem).Lookup2(b0, b1, a, 3, 5, a)
+		iVariablesCreated = append(iVariablesCreated, u)
+
+		// b0 and b1 are constrained to be boolean once, and the lookup of a constant table is folded
+		systemUnderTest.(*ConstraintSystem).Lookup2(b0, b1, a, a, a, a)
+
+		csRes := csResult{
+			systemUnderTest.(*ConstraintSystem),
+			pVariablesCreated,
+			sVariablesCreated,
+			iVariablesCreated,
+			r1c.SingleOutput}
+
+		return csRes
+	}
+	return res
+}
+
+var nsLookup2 = deltaState{1, 2, 3, 3, 2}
 
 // copy of variable
 func rfConstant() runfunc {
@@ -508,7 +628,7 @@ func rfIsBoolean() runfunc {
 	return res
 }
 
-var nsIsBoolean = deltaState{1, 1, 0, 0, 4}
+var nsIsBoolean = deltaState{1, 1, 0, 0, 2}
 
 // bound a variable by another variable
 func rfMustBeLessOrEqVar() runfunc {
@@ -540,7 +660,7 @@ func rfMustBeLessOrEqVar() runfunc {
 	return res
 }
 
-var nsMustBeLessOrEqVar = deltaState{1, 1, 1281, 771, 768}
+var nsMustBeLessOrEqVar = deltaState{1, 1, 1258, 756, 756} // nb internal variables: 2*252+3*252-2, nb constraints: 2+3*252-2 (the first product and selection are by the constant 1), nb assertions: 2*252+252
 
 // bound a variable by a constant
 func rfMustBeLessOrEqConst() runfunc {
@@ -568,7 +688,42 @@ func rfMustBeLessOrEqConst() runfunc {
 	return res
 }
 
-var nsMustBeLessOrEqConst = csState{1, 0, 257, 2, 511} // nb internal variables: 256+HW(bound), nb constraints: 1+HW(bound), nb assertions: 256+HW(^bound)
+var nsMustBeLessOrEqConst = csState{1, 0, 252, 1, 503} // nb internal variables: 252+HW(bound)-1, nb constraints: 1+HW(bound)-1 (the first product is by the constant 1), nb assertions: 252+HW(^bound) on 252 bits
+
+// compare a variable to a variable and to a constant
+func rfCmp() runfunc {
+	res := func(systemUnderTest commands.SystemUnderTest) commands.Result {
+
+		pVariablesCreated := make([]Variable, 0)
+		sVariablesCreated := make([]Variable, 0)
+		iVariablesCreated := make([]Variable, 0)
+
+		a := systemUnderTest.(*ConstraintSystem).newPublicVariable(variableName.String())
+		incVariableName()
+		pVariablesCreated = append(pVariablesCreated, a)
+
+		b := systemUnderTest.(*ConstraintSystem).newSecretVariable(variableName.String())
+		incVariableName()
+		sVariablesCreated = append(sVariablesCreated, b)
+
+		systemUnderTest.(*ConstraintSystem).Cmp(a, b, 8)
+		systemUnderTest.(*ConstraintSystem).Cmp(200, b, 8)
+
+		csRes := csResult{
+			systemUnderTest.(*ConstraintSystem),
+			pVariablesCreated,
+			sVariablesCreated,
+			iVariablesCreated,
+			r1c.SingleOutput}
+
+		return csRes
+	}
+	return res
+}
+
+// nb internal variables: 2*8+7+7+6 (bits, xor, res, eq) then 7+6 (the bits of b are reused)
+// nb constraints: 2+7+7+6 then 7+6, nb assertions: 2*8 then 0
+var nsCmp = deltaState{1, 1, 36 + 13, 22 + 13, 16}
 
 // ------------------------------------------------------------------------------
 // build the next state function using the delta state
@@ -594,16 +749,20 @@ func TestAPI(t *testing.T) {
 		buildProtoCommands("Add Sub", rfAddSub(), nextStateFunc(nsAddSub)),
 		buildProtoCommands("Mul", rfMul(), nextStateFunc(nsMul)),
 		buildProtoCommands("Inv", rfInverse(), nextStateFunc(nsInverse)),
-		buildProtoCommands("Div", rfDiv(), nextStateFunc(nsDiv)),
+		buildProtoCommands("DivUnchecked", rfDiv(), nextStateFunc(nsDiv)),
 		buildProtoCommands("Xor", rfXor(), nextStateFunc(nsXor)),
+		buildProtoCommands("And Or Not", rfBoolean(), nextStateFunc(nsBoolean)),
 		buildProtoCommands("ToBinary", rfToBinary(), nextStateFunc(nsToBinary)),
 		buildProtoCommands("Select 2 variables", rfSelect(), nextStateFunc(nsSelect)),
+		buildProtoCommands("Lookup2", rfLookup2(), nextStateFunc(nsLookup2)),
+		buildProtoCommands("IsZero", rfIsZero(), nextStateFunc(nsIsZero)),
+		buildProtoCommands("Cmp", rfCmp(), nextStateFunc(nsCmp)),
 		buildProtoCommands("Constant", rfConstant(), nextStateFunc(nsConstant)),
 		buildProtoCommands("IsEqual", rfIsEqual(), nextStateFunc(nsIsEqual)),
 		buildProtoCommands("FromBinary", rfFromBinary(), nextStateFunc(nsFromBinary)),
-		buildProtoCommands("IsBoolean", rfIsBoolean(), nextStateFunc(nsIsBoolean)), // TODO fix isBoolean to record if it was already boolean constrained
-		// buildProtoCommands("Must be less or eq var", rfMustBeLessOrEqVar(), nextStateFunc(nsMustBeLessOrEqVar)), // TODO restore once isBoolean is fixed
-		// buildProtoCommands("Must be less or eq const", rfMustBeLessOrEqConst(), nextStateFunc(nsMustBeLessOrEqConst)), // TODO idem
+		buildProtoCommands("IsBoolean", rfIsBoolean(), nextStateFunc(nsIsBoolean)),
+		buildProtoCommands("Must be less or eq var", rfMustBeLessOrEqVar(), nextStateFunc(nsMustBeLessOrEqVar)),
+		buildProtoCommands("Must be less or eq const", rfMustBeLessOrEqConst(), nextStateFunc(nsMustBeLessOrEqConst)),
 	}
 
 	// generate randomly a sequence of commands
@@ -792,3 +951,408 @@ func TestUnsetVariables(t *testing.T) {
 	}
 
 }
+
+func TestNotIsBoolean(t *testing.T) {
+
+	cs := newConstraintSystem()
+	a := cs.newSecretVariable("a")
+	b := cs.newSecretVariable("b")
+	x := cs.newSecretVariable("x")
+	y := cs.newSecretVariable("y")
+	cs.AssertIsBoolean(a)
+	cs.AssertIsBoolean(b)
+
+	// the booleanity of 1 - a is not constrained again
+	cs.And(cs.Not(a), b)
+	if len(cs.constraints) != 1 || len(cs.assertions) != 2 {
+		t.Fatal("expected 1 constraint and 2 assertions, got", len(cs.constraints), len(cs.assertions))
+	}
+	cs.Select(cs.Not(a), x, y)
+	cs.Select(cs.Not(a), y, x)
+	cs.Not(cs.Not(a))
+	if len(cs.constraints) != 3 || len(cs.assertions) != 2 {
+		t.Fatal("expected 3 constraints and 2 assertions, got", len(cs.constraints), len(cs.assertions))
+	}
+}
+
+func TestBooleanExpressionSelector(t *testing.T) {
+
+	cs := newConstraintSystem()
+	x := cs.newSecretVariable("x")
+	y := cs.newSecretVariable("y")
+	b := cs.newSecretVariable("b")
+	cs.AssertIsBoolean(b)
+
+	// the selectors are b rebuilt as a linear expression, and 1 - b: the booleanity of the first one is
+	// the booleanity of b, and the second one is constrained once
+	cs.Select(cs.Add(b, 0), x, y)
+	cs.Select(cs.Sub(1, b), x, y)
+	cs.Select(cs.Sub(1, b), y, x)
+	if len(cs.constraints) != 3 || len(cs.assertions) != 2 {
+		t.Fatal("expected 3 constraints and 2 assertions, got", len(cs.constraints), len(cs.assertions))
+	}
+}
+
+type commitCircuit struct {
+	X Variable
+	Y Variable `gnark:",public"`
+}
+
+func (circuit *commitCircuit) Define(curveID gurvy.ID, cs *ConstraintSystem) error {
+	// x == y iff (x-y)*r == 0 for a random r
+	r := cs.Commit(circuit.X, cs.Add(circuit.X, circuit.Y), circuit.Y)
+	cs.AssertIsEqual(cs.Mul(cs.Sub(circuit.X, circuit.Y), r), 0)
+	return nil
+}
+
+func TestCommit(t *testing.T) {
+
+	_r1cs, err := Compile(gurvy.UNKNOWN, &commitCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	untyped := _r1cs.(*r1cs.UntypedR1CS)
+	commitment := untyped.Commitment
+	if commitment == nil {
+		t.Fatal("expected the R1CS to have a commitment")
+	}
+	// wires = [internal | X | ONE, Y]
+	nbInternal := int(untyped.NbWires - untyped.NbPublicWires - untyped.NbSecretWires)
+	if len(commitment.Committed) != 3 || commitment.Committed[0] != nbInternal || commitment.Committed[1] >= nbInternal ||
+		commitment.Committed[2] != int(untyped.NbWires)-1 || commitment.Wire >= nbInternal {
+		t.Fatal("unexpected commitment", commitment)
+	}
+	typed := untyped.ToR1CS(gurvy.BN256)
+
+	for _, c := range []struct {
+		y     int
+		valid bool
+	}{
+		{3, true},
+		{4, false},
+	} {
+		var witness commitCircuit
+		witness.X.Assign(3)
+		witness.Y.Assign(c.y)
+		assignment, err := ParseWitness(&witness)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := typed.IsSolved(assignment); (err == nil) != c.valid {
+			t.Fatal(c.y, "IsSolved: expected valid =", c.valid, "got", err)
+		}
+		if err := Evaluate(gurvy.BN256, &witness); (err == nil) != c.valid {
+			t.Fatal(c.y, "Evaluate: expected valid =", c.valid, "got", err)
+		}
+	}
+
+	// a circuit has at most one commitment
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic when committing twice")
+		}
+	}()
+	cs := newConstraintSystem()
+	x := cs.newSecretVariable("x")
+	cs.Commit(x)
+	cs.Commit(x)
+}
+
+func TestMulAcc(t *testing.T) {
+	cs := newConstraintSystem()
+	var x, y [10]Variable
+	acc := cs.Constant(0)
+	for i := range x {
+		x[i] = cs.newSecretVariable(fmt.Sprintf("x%d", i))
+		y[i] = cs.newSecretVariable(fmt.Sprintf("y%d", i))
+		acc = cs.MulAcc(acc, x[i], y[i])
+	}
+	if len(acc.linExp) != 1 {
+		t.Fatal("expected the accumulator to be a single wire, got", len(acc.linExp), "terms")
+	}
+	if cs.NbConstraints() != len(x) {
+		t.Fatal("expected", len(x), "constraints, got", cs.NbConstraints())
+	}
+
+	// a constant operand records no constraint
+	cs.MulAcc(acc, 3, x[0])
+	cs.MulAcc(acc, x[0], cs.Sub(y[0], y[0]))
+	if cs.NbConstraints() != len(x) {
+		t.Fatal("expected no new constraint with a constant operand")
+	}
+}
+
+type divisionCircuit struct {
+	A, B Variable
+	Q    Variable `gnark:",public"`
+	op   string
+}
+
+func (circuit *divisionCircuit) Define(curveID gurvy.ID, cs *ConstraintSystem) error {
+	switch circuit.op {
+	case "Div":
+		cs.AssertIsEqual(cs.Div(circuit.A, circuit.B), circuit.Q)
+	case "DivUnchecked":
+		cs.AssertIsEqual(cs.DivUnchecked(circuit.A, circuit.B), circuit.Q)
+	case "InverseOrZero":
+		cs.AssertIsEqual(cs.Mul(circuit.A, cs.InverseOrZero(circuit.B)), circuit.Q)
+	}
+	return nil
+}
+
+func TestDivision(t *testing.T) {
+	for _, c := range []struct {
+		op      string
+		a, b, q int
+		valid   bool
+	}{
+		{"Div", 6, 3, 2, true},
+		{"Div", 6, 0, 0, false},
+		{"Div", 0, 0, 0, false},
+		{"DivUnchecked", 6, 3, 2, true},
+		{"DivUnchecked", 6, 0, 0, false},
+		{"DivUnchecked", 0, 0, 0, true},
+		{"InverseOrZero", 6, 3, 2, true},
+		{"InverseOrZero", 6, 0, 0, true},
+		{"InverseOrZero", 6, 0, 1, false},
+	} {
+		_r1cs, err := Compile(gurvy.BN256, &divisionCircuit{op: c.op})
+		if err != nil {
+			t.Fatal(err)
+		}
+		witness := divisionCircuit{op: c.op}
+		witness.A.Assign(c.a)
+		witness.B.Assign(c.b)
+		witness.Q.Assign(c.q)
+		assignment, err := ParseWitness(&witness)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := _r1cs.IsSolved(assignment); (err == nil) != c.valid {
+			t.Fatal(c, "IsSolved: expected valid =", c.valid, "got", err)
+		}
+		if err := Evaluate(gurvy.BN256, &witness); (err == nil) != c.valid {
+			t.Fatal(c, "Evaluate: expected valid =", c.valid, "got", err)
+		}
+	}
+
+	// a constant division by 0 is caught at compile time
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic on a division by the constant 0")
+		}
+	}()
+	cs := newConstraintSystem()
+	cs.Div(cs.newSecretVariable("a"), 0)
+}
+
+type batchInvertCircuit struct {
+	X [3]Variable
+	Z Variable `gnark:",public"`
+}
+
+func (circuit *batchInvertCircuit) Define(curveID gurvy.ID, cs *ConstraintSystem) error {
+	inverses := cs.BatchInvert(circuit.X[:])
+	cs.AssertIsEqual(cs.Add(inverses[0], inverses[1], inverses[2]), circuit.Z)
+	return nil
+}
+
+func TestBatchInvert(t *testing.T) {
+	_r1cs, err := Compile(gurvy.UNKNOWN, &batchInvertCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	untyped := _r1cs.(*r1cs.UntypedR1CS)
+	if len(untyped.Hints) != 1 || len(untyped.Hints[0].Wires) != 3 {
+		t.Fatal("expected a single hint computing the 3 inverses")
+	}
+	if untyped.NbCOConstraints != 0 || len(untyped.Constraints) != 4 {
+		t.Fatal("expected one assertion per inverse, got", len(untyped.Constraints), "constraints")
+	}
+
+	for _, c := range []struct {
+		x     [3]int
+		z     int
+		valid bool
+	}{
+		{[3]int{1, 1, 1}, 3, true},
+		{[3]int{1, 1, 1}, 2, false},
+		{[3]int{1, 0, 1}, 2, false},
+	} {
+		var witness batchInvertCircuit
+		for i := range c.x {
+			witness.X[i].Assign(c.x[i])
+		}
+		witness.Z.Assign(c.z)
+		assignment, err := ParseWitness(&witness)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := untyped.ToR1CS(gurvy.BN256).IsSolved(assignment); (err == nil) != c.valid {
+			t.Fatal(c, "IsSolved: expected valid =", c.valid, "got", err)
+		}
+		if err := Evaluate(gurvy.BN256, &witness); (err == nil) != c.valid {
+			t.Fatal(c, "Evaluate: expected valid =", c.valid, "got", err)
+		}
+	}
+}
+
+type cmpCircuit struct {
+	A Variable
+}
+
+func (circuit *cmpCircuit) Define(curveID gurvy.ID, cs *ConstraintSystem) error {
+	cs.Cmp(circuit.A, 10, 8)
+	return nil
+}
+
+// TestCmpNonCanonical checks that the canonical decomposition of a Variable is the only assignment of its
+// bits satisfying the decomposition constraint of Cmp. On 256 bits, the bits of a + r also satisfied it
+// (a + r < 2^256), and the prover could flip the result of the comparison.
+func TestCmpNonCanonical(t *testing.T) {
+	_r1cs, err := Compile(gurvy.BN256, &cmpCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	typed := _r1cs.(*backend_bn256.R1CS)
+	var dec *r1c.R1C
+	for i := range typed.Constraints {
+		if typed.Constraints[i].Solver == r1c.BinaryDec {
+			dec = &typed.Constraints[i]
+		}
+	}
+	if dec == nil || len(dec.L) != 8 {
+		t.Fatal("expected the decomposition of A on 8 bits")
+	}
+
+	// L is Σ 2^i bit_i, R is the constant wire and O is A: the bits b satisfy the constraint iff L(b) == A
+	const a = 100
+	var expected fr.Element
+	expected.SetUint64(a)
+	one := fr.One()
+	for b := 0; b < 1<<8; b++ {
+		var l fr.Element
+		for _, term := range dec.L {
+			var coeff, bit fr.Element
+			var i big.Int
+			typed.AddTerm(&coeff, term, one).ToBigIntRegular(&i)
+			if b>>(i.BitLen()-1)&1 == 1 {
+				bit.SetOne()
+			}
+			typed.AddTerm(&l, term, bit)
+		}
+		if l.Equal(&expected) != (b == a) {
+			t.Fatal("unexpected decomposition of A", b)
+		}
+	}
+
+	// the bits of a + r don't fit in the decomposition of Cmp
+	var nonCanonical big.Int
+	nonCanonical.Add(big.NewInt(a), fr.Modulus())
+	if nonCanonical.BitLen() <= maxReducedBits {
+		t.Fatal("the decomposition of Cmp isn't unique on bn256")
+	}
+
+	// invalid widths and constants
+	for _, f := range []func(cs *ConstraintSystem, v Variable){
+		func(cs *ConstraintSystem, v Variable) { cs.Cmp(v, 10, maxReducedBits+1) },
+		func(cs *ConstraintSystem, v Variable) { cs.Cmp(v, 10, 0) },
+		func(cs *ConstraintSystem, v Variable) { cs.Cmp(v, 256, 8) },
+		func(cs *ConstraintSystem, v Variable) { cs.Cmp(-1, v, 8) },
+	} {
+		cs := newConstraintSystem()
+		v := cs.newSecretVariable("A")
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatal("expected Cmp to panic")
+				}
+			}()
+			f(&cs, v)
+		}()
+	}
+}
+
+type lessOrEqCircuit struct {
+	W, Bound Variable
+}
+
+func (circuit *lessOrEqCircuit) Define(curveID gurvy.ID, cs *ConstraintSystem) error {
+	cs.AssertIsLessOrEqual(circuit.W, circuit.Bound)
+	return nil
+}
+
+// TestAssertIsLessOrEqualNonCanonical checks that the decompositions of AssertIsLessOrEqual are unique. On
+// 256 bits, the bits of bound + r also satisfied the decomposition of a Variable bound (bound + r < 2^256),
+// and the prover could assert w <= bound for any w.
+func TestAssertIsLessOrEqualNonCanonical(t *testing.T) {
+	_r1cs, err := Compile(gurvy.BN256, &lessOrEqCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	typed := _r1cs.(*backend_bn256.R1CS)
+
+	// the bits of W and Bound have the coefficients 2^0, ..., 2^251: their sum is less than 2^252 < r,
+	// so a single assignment of the bits satisfies each decomposition
+	nbDecompositions := 0
+	one := fr.One()
+	for _, c := range typed.Constraints {
+		if c.Solver != r1c.BinaryDec {
+			continue
+		}
+		nbDecompositions++
+		if len(c.L) != maxReducedBits {
+			t.Fatal("expected a decomposition on", maxReducedBits, "bits, got", len(c.L))
+		}
+		seen := make(map[int]bool)
+		for _, term := range c.L {
+			var coeff fr.Element
+			var i big.Int
+			typed.AddTerm(&coeff, term, one).ToBigIntRegular(&i)
+			if i.BitLen() == 0 || i.BitLen() > maxReducedBits || i.Cmp(new(big.Int).Lsh(big.NewInt(1), uint(i.BitLen()-1))) != 0 || seen[i.BitLen()] {
+				t.Fatal("unexpected coefficient in the decomposition", i.String())
+			}
+			seen[i.BitLen()] = true
+		}
+	}
+	if nbDecompositions != 2 {
+		t.Fatal("expected the decompositions of W and Bound, got", nbDecompositions)
+	}
+
+	// the bits of bound + r don't fit in the decomposition
+	var nonCanonical big.Int
+	nonCanonical.Add(big.NewInt(5), fr.Modulus())
+	if nonCanonical.BitLen() <= maxReducedBits {
+		t.Fatal("the decomposition of AssertIsLessOrEqual isn't unique on bn256")
+	}
+
+	var tooLarge big.Int
+	tooLarge.Sub(fr.Modulus(), big.NewInt(1))
+	for _, c := range []struct {
+		w, bound interface{}
+		valid    bool
+	}{
+		{5, 10, true},
+		{10, 10, true},
+		{10, 5, false},
+		{10, tooLarge, false}, // a Variable bound is decomposed on 252 bits
+	} {
+		if err := typed.IsSolved(map[string]interface{}{"W": c.w, "Bound": c.bound}); (err == nil) != c.valid {
+			t.Fatal(c.w, c.bound, "IsSolved: expected valid =", c.valid, "got", err)
+		}
+	}
+
+	// invalid constant bounds
+	for _, bound := range []interface{}{-1, new(big.Int).Lsh(big.NewInt(1), maxReducedBits)} {
+		cs := newConstraintSystem()
+		v := cs.newSecretVariable("W")
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatal("expected AssertIsLessOrEqual to panic")
+				}
+			}()
+			cs.AssertIsLessOrEqual(v, bound)
+		}()
+	}
+}
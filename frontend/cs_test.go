@@ -2,7 +2,18 @@ package frontend
 
 import (
 	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/consensys/gnark/backend/r1cs"
+	"github.com/consensys/gnark/backend/r1cs/r1c"
+	backend_bn256 "github.com/consensys/gnark/internal/backend/bn256"
+	"github.com/consensys/gurvy"
+	"github.com/consensys/gurvy/bn256/fr"
 )
 
 func TestReduce(t *testing.T) {
@@ -28,6 +39,353 @@ func TestReduce(t *testing.T) {
 
 	// check coefficients
 	for _, t := range toTest.linExp {
-		fmt.Println(cs.coeffs[t.CoeffID()])
+		fmt.Println(cs.bigIntValue(t))
+	}
+}
+
+func TestBooleans(t *testing.T) {
+
+	cs := newConstraintSystem()
+	x := cs.newSecretVariable("x")
+
+	// the copies of a variable share its booleanity
+	y := x
+	cs.AssertIsBoolean(x)
+	cs.AssertIsBoolean(y)
+	if len(cs.assertions) != 1 {
+		t.Fatal("x is constrained to be boolean more than once")
+	}
+
+	// the bits of a decomposition are constrained once
+	bits := cs.ToBinary(cs.newInternalVariable(), 8)
+	cs.FromBinary(bits...)
+	cs.Select(bits[0], x, y)
+	if len(cs.assertions) != 1+8 {
+		t.Fatal("the bits are constrained to be boolean more than once")
+	}
+
+	// the booleanity of a linear expression is recorded by its canonical key
+	z := cs.Add(x, y)
+	cs.AssertIsBoolean(z)
+	cs.AssertIsBoolean(cs.Add(y, x))
+	if len(cs.assertions) != 1+8+1 {
+		t.Fatal("the linear expression is constrained to be boolean more than once")
+	}
+
+	// a linear expression equal to a boolean wire is boolean
+	cs.AssertIsBoolean(cs.Sub(cs.Add(x, y), y))
+	cs.AssertIsBoolean(cs.Add(x, 0))
+	if len(cs.assertions) != 1+8+1 {
+		t.Fatal("x is constrained to be boolean more than once")
+	}
+}
+
+func TestConstantFolding(t *testing.T) {
+
+	cs := newConstraintSystem()
+	x := cs.newSecretVariable("x")
+
+	// constant Variables record no constraint
+	a := cs.Add(cs.Constant(3), 2)
+	b := cs.Sub(x, x)
+	c := cs.Mul(a, b, 7)
+	cs.Mul(x, a)
+	cs.Select(cs.Constant(1), x, a)
+	cs.Select(x, a, c)
+	cs.And(cs.IsZero(c), x)
+	cs.Xor(cs.Constant(0), x)
+	cs.ToBinary(a, 8)
+	cs.AssertIsEqual(c, 0)
+	cs.AssertIsBoolean(cs.Constant(1))
+
+	if v, ok := cs.constantValue(c); !ok || v.Sign() != 0 {
+		t.Fatal("a*(x-x)*7 should be the constant 0")
+	}
+	if len(cs.constraints) != 0 {
+		t.Fatal("constant operations should be evaluated at compile time")
+	}
+	// x is constrained to be boolean (And, Xor, and as the selector of Select)
+	if len(cs.assertions) != 1 {
+		t.Fatal("unexpected number of assertions")
+	}
+
+	// a constant which is not boolean can't be folded
+	cs.AssertIsBoolean(cs.Constant(2))
+	if len(cs.assertions) != 2 {
+		t.Fatal("the booleanity of 2 should be recorded (and not satisfied)")
+	}
+}
+
+type squaringCircuit struct {
+	X Variable
+}
+
+func (circuit *squaringCircuit) Define(curveID gurvy.ID, cs *ConstraintSystem) error {
+	c := cs.Constant(3)
+	for i := 0; i < 30; i++ {
+		c = cs.Mul(c, c)
+	}
+	cs.AssertIsEqual(circuit.X, c)
+	return nil
+}
+
+// TestConstantFoldingChain checks that the folded constants don't grow without bound: squaring 3 thirty
+// times would have 2^30 * log2(3) bits
+func TestConstantFoldingChain(t *testing.T) {
+
+	// the constants are reduced modulo the scalar field, and the chain records no constraint
+	_r1cs, err := Compile(gurvy.BN256, &squaringCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nbConstraints := _r1cs.GetNbConstraints(); nbConstraints != 1 {
+		t.Fatal("expected only the assertion to be recorded, got", nbConstraints, "constraints")
+	}
+	var expected big.Int
+	expected.Exp(big.NewInt(3), new(big.Int).Lsh(big.NewInt(1), 30), fr.Modulus())
+	if err := _r1cs.IsSolved(map[string]interface{}{"X": expected}); err != nil {
+		t.Fatal(err)
+	}
+
+	// on an unknown curve, the constants larger than the scalar fields are multiplied by a constraint
+	untyped, err := Compile(gurvy.UNKNOWN, &squaringCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nbConstraints := untyped.GetNbConstraints(); nbConstraints <= 1 || nbConstraints > 31 {
+		t.Fatal("unexpected number of constraints", nbConstraints)
+	}
+	if err := untyped.(*r1cs.UntypedR1CS).ToR1CS(gurvy.BN256).IsSolved(map[string]interface{}{"X": expected}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCommonSubexpressions(t *testing.T) {
+
+	cs := newConstraintSystem()
+	x := cs.newSecretVariable("x")
+	y := cs.newSecretVariable("y")
+
+	// the terms of a linear expression and the operands of a product are sorted
+	a := cs.Mul(cs.Add(x, y), y)
+	b := cs.Mul(y, cs.Add(y, x))
+	if a.id != b.id || len(cs.constraints) != 1 {
+		t.Fatal("identical products should share their wire")
+	}
+
+	// the order of the operands of a division matters
+	c := cs.DivUnchecked(x, y)
+	d := cs.DivUnchecked(y, x)
+	if c.id == d.id || len(cs.constraints) != 3 {
+		t.Fatal("different divisions should not share their wire")
+	}
+
+	// a decomposition is reused, for the same number of bits
+	bits := cs.ToBinary(x, 8)
+	bits[0] = y
+	if cs.ToBinary(x, 8)[0].Wire == y.Wire || len(cs.constraints) != 4 {
+		t.Fatal("identical decompositions should share their wires")
+	}
+	cs.ToBinary(x, 16)
+	if len(cs.constraints) != 5 {
+		t.Fatal("decompositions on different numbers of bits should not share their wires")
+	}
+
+	// a selection is reused with its booleanity constraint, on a linear expression selector too
+	s := cs.Sub(1, x)
+	nbAssertions := len(cs.assertions)
+	e := cs.Select(s, x, y)
+	if len(cs.constraints) != 6 || len(cs.assertions) != nbAssertions+1 {
+		t.Fatal("unexpected number of constraints for a selection")
+	}
+	if cs.Select(cs.Sub(1, x), x, y).id != e.id || len(cs.constraints) != 6 || len(cs.assertions) != nbAssertions+1 {
+		t.Fatal("identical selections should share their wire and their booleanity constraint")
+	}
+}
+
+func TestNamespace(t *testing.T) {
+
+	cs := newConstraintSystem()
+	x := cs.newSecretVariable("x")
+
+	cs.Mul(x, x)
+	cs.Namespace("a", func(cs *ConstraintSystem) {
+		cs.AssertIsBoolean(x)
+		cs.Namespace("b", func(cs *ConstraintSystem) {
+			cs.Inverse(x)
+		})
+		cs.Namespace("c", func(cs *ConstraintSystem) {})
+	})
+	cs.AssertIsEqual(x, 3)
+
+	res, err := cs.toR1CS(gurvy.UNKNOWN)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// constraints: x*x, 1/x (a/b), then assertions: x boolean (a), x == 3
+	expected := []r1c.Namespace{{Name: "", Constraint: 0}, {Name: "a/b", Constraint: 1}, {Name: "a", Constraint: 2}, {Name: "", Constraint: 3}}
+	if got := res.(*r1cs.UntypedR1CS).Namespaces; !reflect.DeepEqual(got, expected) {
+		t.Fatal("unexpected namespaces", got)
+	}
+}
+
+type namespaceCircuit struct {
+	X Variable
+}
+
+func (circuit *namespaceCircuit) Define(curveID gurvy.ID, cs *ConstraintSystem) error {
+	cs.Namespace("merkle", func(cs *ConstraintSystem) {
+		cs.Namespace("level3", func(cs *ConstraintSystem) {
+			cs.AssertIsEqual(circuit.X, 3)
+		})
+	})
+	return nil
+}
+
+func TestNamespaceError(t *testing.T) {
+
+	_r1cs, err := Compile(gurvy.BN256, &namespaceCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the solver prefixes the error with the namespace of the constraint
+	err = _r1cs.IsSolved(map[string]interface{}{"X": 2})
+	if err == nil || !strings.Contains(err.Error(), "merkle/level3: ") {
+		t.Fatal("the error should be in the namespace merkle/level3", err)
+	}
+}
+
+type printlnCircuit struct {
+	X Variable
+}
+
+func (circuit *printlnCircuit) Define(curveID gurvy.ID, cs *ConstraintSystem) error {
+	x2 := cs.Mul(circuit.X, circuit.X)
+	cs.Println("x2", x2)
+	cs.Println("x2+1", cs.Add(x2, 1))
+	bits := cs.ToBinary(circuit.X, 3)
+	cs.Println("y", cs.Mul(bits[0], x2))
+	cs.AssertIsEqual(x2, 9)
+	return nil
+}
+
+// captureStdout returns what f prints on the standard output
+func captureStdout(t *testing.T, f func()) string {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	f()
+	os.Stdout = stdout
+	w.Close()
+	out, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func TestPrintln(t *testing.T) {
+
+	_r1cs, err := Compile(gurvy.BN256, &printlnCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the logs are printed with the values of the wires when the witness is solved
+	out := captureStdout(t, func() { _r1cs.IsSolved(map[string]interface{}{"X": 3}) })
+	if !strings.Contains(out, "x2 9\n") || !strings.Contains(out, "x2+1 10\n") || !strings.Contains(out, "y 9\n") {
+		t.Fatal("unexpected logs", out)
+	}
+
+	// a wire the solver didn't compute is printed as <unsolved>
+	// (9 doesn't fit in 3 bits)
+	out = captureStdout(t, func() { _r1cs.IsSolved(map[string]interface{}{"X": 9}) })
+	if !strings.Contains(out, "x2 81\n") || !strings.Contains(out, "y <unsolved>\n") {
+		t.Fatal("unexpected logs", out)
+	}
+
+	// the prover doesn't print them
+	typed := _r1cs.(*backend_bn256.R1CS)
+	a := make([]fr.Element, typed.NbConstraints)
+	b := make([]fr.Element, typed.NbConstraints)
+	c := make([]fr.Element, typed.NbConstraints)
+	wireValues := make([]fr.Element, typed.NbWires)
+	out = captureStdout(t, func() { typed.Solve(map[string]interface{}{"X": 3}, a, b, c, wireValues) })
+	if out != "" {
+		t.Fatal("Solve should not print the logs", out)
+	}
+}
+
+// deferCircuit checks the sum of the values it accumulates, once, at the end of Define
+type deferCircuit struct {
+	X   [3]Variable
+	Sum Variable `gnark:",public"`
+}
+
+func (circuit *deferCircuit) Define(curveID gurvy.ID, cs *ConstraintSystem) error {
+	var values []Variable
+	cs.Namespace("sum", func(cs *ConstraintSystem) {
+		cs.Defer(func(cs *ConstraintSystem) {
+			cs.AssertIsEqual(cs.Add(values[0], values[1], values[2]), circuit.Sum)
+		})
+	})
+	for i := range circuit.X {
+		values = append(values, circuit.X[i])
+	}
+	return nil
+}
+
+func TestDefer(t *testing.T) {
+
+	// the deferred functions are called in the order of registration, including the ones they register
+	cs := newConstraintSystem()
+	var calls []int
+	cs.Defer(func(cs *ConstraintSystem) {
+		calls = append(calls, 1)
+		cs.Defer(func(cs *ConstraintSystem) { calls = append(calls, 3) })
+	})
+	cs.Defer(func(cs *ConstraintSystem) { calls = append(calls, 2) })
+	cs.runDeferred()
+	if !reflect.DeepEqual(calls, []int{1, 2, 3}) {
+		t.Fatal("unexpected order of the deferred calls", calls)
+	}
+
+	_r1cs, err := Compile(gurvy.UNKNOWN, &deferCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []r1c.Namespace{{Name: "sum", Constraint: 0}}
+	if got := _r1cs.(*r1cs.UntypedR1CS).Namespaces; !reflect.DeepEqual(got, expected) {
+		t.Fatal("unexpected namespaces", got)
+	}
+	typed := _r1cs.(*r1cs.UntypedR1CS).ToR1CS(gurvy.BN256)
+
+	for _, c := range []struct {
+		sum   int
+		valid bool
+	}{
+		{1 + 2 + 3, true},
+		{1 + 2 + 4, false},
+	} {
+		var witness deferCircuit
+		for i := range witness.X {
+			witness.X[i].Assign(i + 1)
+		}
+		witness.Sum.Assign(c.sum)
+		assignment, err := ParseWitness(&witness)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := typed.IsSolved(assignment); (err == nil) != c.valid {
+			t.Fatal(c.sum, "IsSolved: expected valid =", c.valid, "got", err)
+		}
+		if err := Evaluate(gurvy.BN256, &witness); (err == nil) != c.valid {
+			t.Fatal(c.sum, "Evaluate: expected valid =", c.valid, "got", err)
+		}
 	}
 }
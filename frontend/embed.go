@@ -0,0 +1,296 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package frontend
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/r1cs"
+	"github.com/consensys/gnark/backend/r1cs/r1c"
+	backend_bls377 "github.com/consensys/gnark/internal/backend/bls377"
+	backend_bls381 "github.com/consensys/gnark/internal/backend/bls381"
+	backend_bn256 "github.com/consensys/gnark/internal/backend/bn256"
+	backend_bw761 "github.com/consensys/gnark/internal/backend/bw761"
+	fr_bls377 "github.com/consensys/gurvy/bls377/fr"
+	fr_bls381 "github.com/consensys/gurvy/bls381/fr"
+	fr_bn256 "github.com/consensys/gurvy/bn256/fr"
+	fr_bw761 "github.com/consensys/gurvy/bw761/fr"
+)
+
+// Embed records the constraints of a sub circuit compiled with Compile(gurvy.UNKNOWN, subCircuit), and
+// possibly read back from disk (see r1cs.UntypedR1CS.ReadFrom), without calling its Define method
+//
+// the inputs of the sub circuit (public or secret) are bound by name: inputs gives the value of some of
+// them (a Variable or a constant), and the others, listed in outputs, are computed from the constraints
+// of the sub circuit and returned in the same order. An output must be determined by an assertion of
+// the sub circuit, as Y in cs.AssertIsEqual(cs.Mul(X, X), Y)
+//
+// Embed panics if the inputs and outputs don't match the inputs of the sub circuit
+func (cs *ConstraintSystem) Embed(sub *r1cs.UntypedR1CS, inputs map[string]interface{}, outputs ...string) []Variable {
+
+	if cs.engine != nil {
+		return cs.engine.embed(sub, inputs, outputs)
+	}
+	if sub.Commitment != nil {
+		panic("Embed: the sub circuit has a commitment")
+	}
+
+	nbInternal := int(sub.NbWires - sub.NbPublicWires - sub.NbSecretWires)
+	wires := make([]Wire, sub.NbWires) // wire of cs of each wire of sub
+
+	// the inputs of sub are bound to the given values, or to new internal variables for the outputs
+	inputIDs := make(map[string]int, len(sub.SecretWires)+len(sub.PublicWires))
+	for i, name := range sub.SecretWires {
+		inputIDs[name] = nbInternal + i
+	}
+	for i, name := range sub.PublicWires {
+		inputIDs[name] = nbInternal + int(sub.NbSecretWires) + i
+	}
+	bound := make([]bool, sub.NbWires)
+	wires[inputIDs[backend.OneWire]] = cs.getOneVariable().Wire
+	bound[inputIDs[backend.OneWire]] = true
+
+	for _, name := range sortedNames(inputs) {
+		id, ok := inputIDs[name]
+		if !ok || name == backend.OneWire {
+			panic(fmt.Sprintf("Embed: the sub circuit has no input %q", name))
+		}
+		wires[id] = cs.toWire(inputs[name])
+		bound[id] = true
+	}
+	solved := make([]bool, sub.NbWires) // the outputs are solved by the assertions which determine them
+	copy(solved, bound)
+	res := make([]Variable, len(outputs))
+	for i, name := range outputs {
+		id, ok := inputIDs[name]
+		if !ok || bound[id] {
+			panic(fmt.Sprintf("Embed: the sub circuit has no input %q, or it is already bound", name))
+		}
+		res[i] = cs.newInternalVariable()
+		wires[id] = res[i].Wire
+		bound[id] = true
+	}
+	for name, id := range inputIDs {
+		if !bound[id] {
+			panic(fmt.Sprintf("Embed: the input %q of the sub circuit is not bound", name))
+		}
+	}
+
+	// the internal variables of sub are new internal variables
+	for id := 0; id < nbInternal; id++ {
+		wires[id] = cs.newInternalVariable().Wire
+		solved[id] = true
+	}
+
+	remap := func(l r1c.LinearExpression) r1c.LinearExpression {
+		res := make(r1c.LinearExpression, len(l))
+		var coeff big.Int
+		for i, t := range l {
+			sub.Coefficients.Get(t.CoeffID(), &coeff)
+			res[i] = cs.makeTerm(wires[t.VariableID()], &coeff)
+		}
+		return res
+	}
+	remapEntry := func(entry backend.LogEntry) logEntry {
+		res := logEntry{format: entry.Format, toResolve: make([]r1c.Term, len(entry.ToResolve))}
+		for i, id := range entry.ToResolve {
+			res.toResolve[i] = cs.makeTerm(wires[id], bOne)
+		}
+		return res
+	}
+	unsolved := func(r *r1c.R1C) (terms []r1c.Term) {
+		for _, l := range []r1c.LinearExpression{r.L, r.R, r.O} {
+			for _, t := range l {
+				if !solved[t.VariableID()] {
+					terms = append(terms, t)
+				}
+			}
+		}
+		return
+	}
+	nameOf := func(id int) string {
+		for name, inputID := range inputIDs {
+			if inputID == id {
+				return name
+			}
+		}
+		return ""
+	}
+
+	// the computational constraints of sub can't use an output
+	for i := 0; i < int(sub.NbCOConstraints); i++ {
+		if terms := unsolved(&sub.Constraints[i]); len(terms) != 0 {
+			panic(fmt.Sprintf("Embed: the output %q is used before it is determined", nameOf(terms[0].VariableID())))
+		}
+		r := sub.Constraints[i]
+		cs.addConstraint(r1c.R1C{L: remap(r.L), R: remap(r.R), O: remap(r.O), Solver: r.Solver})
+	}
+	for _, h := range sub.Hints {
+		hint := r1c.Hint{ID: h.ID, Inputs: make([]r1c.LinearExpression, len(h.Inputs)), Wire: wires[h.Wire].id}
+		for _, w := range h.Wires {
+			hint.Wires = append(hint.Wires, wires[w].id)
+		}
+		for i := range h.Inputs {
+			hint.Inputs[i] = remap(h.Inputs[i])
+		}
+		cs.hints = append(cs.hints, hint)
+	}
+
+	// an assertion with a single unsolved output determines it: it is recorded as a computational
+	// constraint
+	determines := make([]bool, len(sub.Constraints))
+	for progress := true; progress; {
+		progress = false
+		for i := int(sub.NbCOConstraints); i < len(sub.Constraints); i++ {
+			if determines[i] {
+				continue
+			}
+			if terms := unsolved(&sub.Constraints[i]); len(terms) == 1 {
+				r := sub.Constraints[i]
+				cs.addConstraint(r1c.R1C{L: remap(r.L), R: remap(r.R), O: remap(r.O), Solver: r1c.SingleOutput})
+				solved[terms[0].VariableID()] = true
+				determines[i] = true
+				progress = true
+			}
+		}
+	}
+	for _, name := range outputs {
+		if !solved[inputIDs[name]] {
+			panic(fmt.Sprintf("Embed: the output %q is not determined by an assertion of the sub circuit", name))
+		}
+	}
+	for i := int(sub.NbCOConstraints); i < len(sub.Constraints); i++ {
+		if !determines[i] {
+			r := sub.Constraints[i]
+			cs.addAssertion(r1c.R1C{L: remap(r.L), R: remap(r.R), O: remap(r.O), Solver: r.Solver}, remapEntry(sub.DebugInfo[i-int(sub.NbCOConstraints)]))
+		}
+	}
+
+	for _, entry := range sub.Logs {
+		cs.logs = append(cs.logs, remapEntry(entry))
+	}
+
+	return res
+}
+
+// toWire returns the wire of a Variable which is a single wire (as the inputs of a sub circuit, see
+// Embed), or else a new internal variable equal to value
+func (cs *ConstraintSystem) toWire(value interface{}) Wire {
+	v := cs.Constant(value)
+	if len(v.linExp) == 1 && v.linExp[0].CoeffValue() == 1 {
+		_, _, id, visibility := v.linExp[0].Unpack()
+		return Wire{visibility: visibility, id: id}
+	}
+	res := cs.newInternalVariable()
+	one := cs.getOneVariable()
+	cs.addConstraint(r1c.R1C{L: cs.copyLinExp(v.linExp), R: cs.copyLinExp(one.linExp), O: cs.copyLinExp(res.linExp), Solver: r1c.SingleOutput})
+	return res.Wire
+}
+
+// sortedNames returns the keys of inputs in sorted order, so that the wires are allocated
+// deterministically
+func sortedNames(inputs map[string]interface{}) []string {
+	names := make([]string, 0, len(inputs))
+	for name := range inputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// embed solves the sub circuit, embedded in a constraint system of its own where the inputs are secret
+// inputs, and returns the values of the outputs
+func (e *engine) embed(sub *r1cs.UntypedR1CS, inputs map[string]interface{}, outputs []string) []Variable {
+	cs := newConstraintSystemWithCapacity(int(sub.NbCOConstraints) + len(inputs))
+	assignment := make(map[string]interface{}, len(inputs))
+	embedded := make(map[string]interface{}, len(inputs))
+	for _, name := range sortedNames(inputs) {
+		assignment[name] = e.value(inputs[name])
+		embedded[name] = cs.newSecretVariable(name)
+	}
+	wires := cs.Embed(sub, embedded, outputs...)
+
+	lowered, err := cs.toR1CS(e.curveID)
+	if err != nil {
+		panic(engineError{err})
+	}
+	ids := make([]int, len(wires))
+	for i := range wires {
+		ids[i] = wires[i].id // the internal wires come first
+	}
+	values, err := solveWires(lowered, assignment, ids)
+	if err != nil {
+		panic(engineError{err})
+	}
+
+	res := make([]Variable, len(values))
+	for i := range values {
+		res[i] = e.variable(&values[i])
+	}
+	return res
+}
+
+// solveWires solves a R1CS and returns the values of the wires ids
+func solveWires(_r1cs r1cs.R1CS, assignment map[string]interface{}, ids []int) ([]big.Int, error) {
+	res := make([]big.Int, len(ids))
+	nbConstraints := _r1cs.GetNbConstraints()
+
+	switch typed := _r1cs.(type) {
+	case *backend_bn256.R1CS:
+		a, b, c := make([]fr_bn256.Element, nbConstraints), make([]fr_bn256.Element, nbConstraints), make([]fr_bn256.Element, nbConstraints)
+		wireValues := make([]fr_bn256.Element, typed.NbWires)
+		if err := typed.Solve(assignment, a, b, c, wireValues); err != nil {
+			return nil, err
+		}
+		for i, id := range ids {
+			wireValues[id].ToBigIntRegular(&res[i])
+		}
+	case *backend_bls377.R1CS:
+		a, b, c := make([]fr_bls377.Element, nbConstraints), make([]fr_bls377.Element, nbConstraints), make([]fr_bls377.Element, nbConstraints)
+		wireValues := make([]fr_bls377.Element, typed.NbWires)
+		if err := typed.Solve(assignment, a, b, c, wireValues); err != nil {
+			return nil, err
+		}
+		for i, id := range ids {
+			wireValues[id].ToBigIntRegular(&res[i])
+		}
+	case *backend_bls381.R1CS:
+		a, b, c := make([]fr_bls381.Element, nbConstraints), make([]fr_bls381.Element, nbConstraints), make([]fr_bls381.Element, nbConstraints)
+		wireValues := make([]fr_bls381.Element, typed.NbWires)
+		if err := typed.Solve(assignment, a, b, c, wireValues); err != nil {
+			return nil, err
+		}
+		for i, id := range ids {
+			wireValues[id].ToBigIntRegular(&res[i])
+		}
+	case *backend_bw761.R1CS:
+		a, b, c := make([]fr_bw761.Element, nbConstraints), make([]fr_bw761.Element, nbConstraints), make([]fr_bw761.Element, nbConstraints)
+		wireValues := make([]fr_bw761.Element, typed.NbWires)
+		if err := typed.Solve(assignment, a, b, c, wireValues); err != nil {
+			return nil, err
+		}
+		for i, id := range ids {
+			wireValues[id].ToBigIntRegular(&res[i])
+		}
+	default:
+		return nil, fmt.Errorf("unsupported R1CS %T", _r1cs)
+	}
+	return res, nil
+}
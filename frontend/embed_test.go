@@ -0,0 +1,94 @@
+package frontend
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/consensys/gnark/backend/r1cs"
+	"github.com/consensys/gurvy"
+)
+
+type cubeCircuit struct {
+	X Variable
+	Y Variable `gnark:",public"`
+}
+
+func (circuit *cubeCircuit) Define(curveID gurvy.ID, cs *ConstraintSystem) error {
+	cs.AssertIsEqual(cs.Mul(circuit.X, circuit.X, circuit.X), circuit.Y)
+	return nil
+}
+
+type embedCircuit struct {
+	cube *r1cs.UntypedR1CS
+	A    Variable
+	B    Variable `gnark:",public"`
+}
+
+func (circuit *embedCircuit) Define(curveID gurvy.ID, cs *ConstraintSystem) error {
+	// b = (a+1)**3 + 2**3
+	y1 := cs.Embed(circuit.cube, map[string]interface{}{"X": cs.Add(circuit.A, 1)}, "Y")
+	y2 := cs.Embed(circuit.cube, map[string]interface{}{"X": 2}, "Y")
+	cs.AssertIsEqual(cs.Add(y1[0], y2[0]), circuit.B)
+	return nil
+}
+
+func TestEmbed(t *testing.T) {
+
+	// the sub circuit is compiled once, and read back as from a cache on disk
+	compiled, err := Compile(gurvy.UNKNOWN, &cubeCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if _, err := compiled.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var cube r1cs.UntypedR1CS
+	if _, err := cube.ReadFrom(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	_r1cs, err := Compile(gurvy.BN256, &embedCircuit{cube: &cube})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		a, b  int
+		valid bool
+	}{
+		{2, 27 + 8, true},
+		{2, 27 + 7, false},
+	} {
+		witness := embedCircuit{cube: &cube}
+		witness.A.Assign(c.a)
+		witness.B.Assign(c.b)
+		assignment, err := ParseWitness(&witness)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := _r1cs.IsSolved(assignment); (err == nil) != c.valid {
+			t.Fatal(c.a, c.b, "IsSolved: expected valid =", c.valid, "got", err)
+		}
+		if err := Evaluate(gurvy.BN256, &witness); (err == nil) != c.valid {
+			t.Fatal(c.a, c.b, "Evaluate: expected valid =", c.valid, "got", err)
+		}
+	}
+
+	// the inputs of the sub circuit must all be bound
+	for _, bind := range []func(cs *ConstraintSystem){
+		func(cs *ConstraintSystem) { cs.Embed(&cube, map[string]interface{}{"X": 2}) },
+		func(cs *ConstraintSystem) { cs.Embed(&cube, map[string]interface{}{"Z": 2}, "Y") },
+		func(cs *ConstraintSystem) { cs.Embed(&cube, map[string]interface{}{"X": 2, "Y": 8}, "Y") },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatal("expected Embed to panic")
+				}
+			}()
+			cs := newConstraintSystem()
+			bind(&cs)
+		}()
+	}
+}
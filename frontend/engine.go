@@ -0,0 +1,521 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package frontend
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/hint"
+	"github.com/consensys/gurvy"
+	fr_bls377 "github.com/consensys/gurvy/bls377/fr"
+	fr_bls381 "github.com/consensys/gurvy/bls381/fr"
+	fr_bn256 "github.com/consensys/gurvy/bn256/fr"
+	fr_bw761 "github.com/consensys/gurvy/bw761/fr"
+)
+
+// engine evaluates the API of a ConstraintSystem on the values of a witness, instead of recording
+// constraints: the Variables it returns hold their value (in Wire.val, as a big.Int reduced modulo
+// the scalar field of the curve)
+type engine struct {
+	curveID gurvy.ID
+	modulus *big.Int
+	lengths map[*Variable]Variable // lengths of the slices tagged with maxlen (see ConstraintSystem.Len)
+
+	committed bool // see ConstraintSystem.Commit
+}
+
+// engineError is the panic value of a failed assertion, recovered by Evaluate
+type engineError struct {
+	err error
+}
+
+// scalarField returns the modulus of the scalar field of curveID, or nil if the curve is not supported
+func scalarField(curveID gurvy.ID) *big.Int {
+	switch curveID {
+	case gurvy.BN256:
+		return fr_bn256.Modulus()
+	case gurvy.BLS377:
+		return fr_bls377.Modulus()
+	case gurvy.BLS381:
+		return fr_bls381.Modulus()
+	case gurvy.BW761:
+		return fr_bw761.Modulus()
+	default:
+		return nil
+	}
+}
+
+// Evaluate calls witness.Define, evaluating each operation on the values of the witness, without
+// compiling the circuit; it returns the first assertion the witness doesn't satisfy (an error wrapping
+// backend.ErrUnsatisfiedConstraint), with the location of the failing call
+//
+// a witness solves the compiled circuit if and only if it satisfies Evaluate; see package test
+//
+// Define is called on a copy of witness, so that the witness can be evaluated again, for example on
+// another curve
+func Evaluate(curveID gurvy.ID, witness Circuit) (err error) {
+	modulus := scalarField(curveID)
+	if modulus == nil {
+		return errors.New("unsupported curve")
+	}
+
+	var handler leafHandler = func(visibility backend.Visibility, name string, tInput reflect.Value) error {
+		v := tInput.Interface().(Variable)
+		if v.val == nil {
+			return fmt.Errorf("%q: %w", name, backend.ErrInputNotSet)
+		}
+		if val := backend.FromInterface(v.val); val.CmpAbs(modulus) >= 0 {
+			return fmt.Errorf("%q: %w", name, backend.ErrInputOverflow)
+		}
+		return nil
+	}
+	if err := parseType(witness, "", backend.Unset, handler, printWarning); err != nil {
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			if e, ok := r.(engineError); ok {
+				err = e.err
+			} else {
+				err = fmt.Errorf("%v", r)
+			}
+		}
+	}()
+	e := &engine{curveID: curveID, modulus: modulus, lengths: make(map[*Variable]Variable)}
+	witness = copyWitness(witness)
+
+	// the slices tagged with maxlen are padded with 0, as by ParseWitness
+	varLenSlices, err := parseVarLenSlices(witness, "", backend.Unset)
+	if err != nil {
+		return err
+	}
+	for i := range varLenSlices {
+		n := varLenSlices[i].slice.Len()
+		if err := varLenSlices[i].grow(e.variable(new(big.Int))); err != nil {
+			return err
+		}
+		e.lengths[varLenSlices[i].first()] = e.variable(big.NewInt(int64(n)))
+	}
+
+	cs := ConstraintSystem{engine: e}
+
+	// the inputs tagged with bits or range are checked, as in Compile
+	bounds, err := parseInputBounds(witness)
+	if err != nil {
+		return err
+	}
+	for i := range bounds {
+		bounds[i].assert(&cs)
+	}
+
+	if err := witness.Define(curveID, &cs); err != nil {
+		return err
+	}
+	cs.runDeferred()
+	return nil
+}
+
+// len returns the length of a slice tagged with maxlen
+func (e *engine) len(v []Variable) Variable {
+	if len(v) > 0 {
+		if length, ok := e.lengths[&v[0]]; ok {
+			return length
+		}
+	}
+	panic(errNotVarLen)
+}
+
+// copyWitness returns a deep copy of the exported fields of witness (Define may set them)
+func copyWitness(witness Circuit) Circuit {
+	src := reflect.ValueOf(witness)
+	if src.Kind() != reflect.Ptr || src.IsNil() {
+		return witness
+	}
+	dst := reflect.New(src.Elem().Type())
+	deepCopy(dst.Elem(), src.Elem())
+	return dst.Interface().(Circuit)
+}
+
+func deepCopy(dst, src reflect.Value) {
+	switch src.Kind() {
+	case reflect.Ptr:
+		if src.IsNil() {
+			return
+		}
+		dst.Set(reflect.New(src.Elem().Type()))
+		deepCopy(dst.Elem(), src.Elem())
+	case reflect.Struct:
+		// unexported fields are copied, but not deeply
+		dst.Set(src)
+		for i := 0; i < src.NumField(); i++ {
+			if dst.Field(i).CanSet() {
+				deepCopy(dst.Field(i), src.Field(i))
+			}
+		}
+	case reflect.Slice:
+		if src.IsNil() {
+			return
+		}
+		dst.Set(reflect.MakeSlice(src.Type(), src.Len(), src.Len()))
+		for i := 0; i < src.Len(); i++ {
+			deepCopy(dst.Index(i), src.Index(i))
+		}
+	case reflect.Array:
+		for i := 0; i < src.Len(); i++ {
+			deepCopy(dst.Index(i), src.Index(i))
+		}
+	case reflect.Map:
+		if src.IsNil() {
+			return
+		}
+		dst.Set(reflect.MakeMapWithSize(src.Type(), src.Len()))
+		iter := src.MapRange()
+		for iter.Next() {
+			value := reflect.New(src.Type().Elem()).Elem()
+			deepCopy(value, iter.Value())
+			dst.SetMapIndex(iter.Key(), value)
+		}
+	default:
+		dst.Set(src)
+	}
+}
+
+// fail aborts the evaluation with an unsatisfied constraint
+func (e *engine) fail(format string, a ...interface{}) {
+	msg := fmt.Sprintf(format, a...)
+	for _, frame := range getCallStack() {
+		// the frames of the frontend are the same for all assertions
+		if !strings.HasPrefix(frame, "frontend.") {
+			msg += "\n" + frame
+		}
+	}
+	panic(engineError{fmt.Errorf("%w: %s", backend.ErrUnsatisfiedConstraint, msg)})
+}
+
+// value returns the value of a Variable or of a constant, reduced modulo the field
+func (e *engine) value(i interface{}) big.Int {
+	var res big.Int
+	switch t := i.(type) {
+	case Variable:
+		if t.val == nil {
+			panic(engineError{errors.New("variable is not assigned")})
+		}
+		res = backend.FromInterface(t.val)
+	default:
+		res = backend.FromInterface(t)
+	}
+	return *res.Mod(&res, e.modulus)
+}
+
+func (e *engine) variable(v *big.Int) Variable {
+	var res Variable
+	res.val = *new(big.Int).Mod(v, e.modulus)
+	return res
+}
+
+func (e *engine) add(i1, i2 interface{}, in ...interface{}) Variable {
+	res := e.value(i1)
+	b := e.value(i2)
+	res.Add(&res, &b)
+	for _, i := range in {
+		b = e.value(i)
+		res.Add(&res, &b)
+	}
+	return e.variable(&res)
+}
+
+func (e *engine) sub(i1, i2 interface{}) Variable {
+	a, b := e.value(i1), e.value(i2)
+	return e.variable(a.Sub(&a, &b))
+}
+
+func (e *engine) mul(i1, i2 interface{}, in ...interface{}) Variable {
+	res := e.value(i1)
+	b := e.value(i2)
+	res.Mul(&res, &b).Mod(&res, e.modulus)
+	for _, i := range in {
+		b = e.value(i)
+		res.Mul(&res, &b).Mod(&res, e.modulus)
+	}
+	return e.variable(&res)
+}
+
+func (e *engine) mulAcc(acc, a, b interface{}) Variable {
+	return e.add(acc, e.mul(a, b))
+}
+
+func (e *engine) inverse(v Variable) Variable {
+	a := e.value(v)
+	if a.Sign() == 0 {
+		e.fail("inverse of 0")
+	}
+	return e.variable(a.ModInverse(&a, e.modulus))
+}
+
+func (e *engine) newHint(f hint.Function, inputs ...interface{}) Variable {
+	values := make([]*big.Int, len(inputs))
+	for i, input := range inputs {
+		v := e.value(input)
+		values[i] = &v
+	}
+	var res big.Int
+	if err := f(e.curveID, values, &res); err != nil {
+		panic(engineError{fmt.Errorf("hint: %w", err)})
+	}
+	return e.variable(&res)
+}
+
+func (e *engine) newMultiHint(f hint.MultiFunction, nbOutputs int, inputs ...interface{}) []Variable {
+	if nbOutputs <= 0 {
+		panic("NewMultiHint: a hint has at least one output")
+	}
+	values := make([]*big.Int, len(inputs))
+	for i, input := range inputs {
+		v := e.value(input)
+		values[i] = &v
+	}
+	results := make([]*big.Int, nbOutputs)
+	for i := range results {
+		results[i] = new(big.Int)
+	}
+	if err := f(e.curveID, values, results); err != nil {
+		panic(engineError{fmt.Errorf("hint: %w", err)})
+	}
+	res := make([]Variable, nbOutputs)
+	for i := range results {
+		res[i] = e.variable(results[i])
+	}
+	return res
+}
+
+// commit returns the hash of the values of vars: the commitment computed when the R1CS is solved
+// without a backend (see hint.Commitment)
+func (e *engine) commit(vars []Variable) Variable {
+	if e.committed {
+		panic("Commit: the circuit already has a commitment")
+	}
+	e.committed = true
+	inputs := make([]interface{}, len(vars))
+	for i := range vars {
+		inputs[i] = vars[i]
+	}
+	return e.newHint(hint.Commitment, inputs...)
+}
+
+func (e *engine) isZero(a Variable) Variable {
+	if v := e.value(a); v.Sign() == 0 {
+		return e.variable(big.NewInt(1))
+	}
+	return e.variable(big.NewInt(0))
+}
+
+func (e *engine) div(i1, i2 interface{}) Variable {
+	a, b := e.value(i1), e.value(i2)
+	if b.Sign() == 0 {
+		e.fail("division of %s by 0", a.String())
+	}
+	b.ModInverse(&b, e.modulus)
+	return e.variable(a.Mul(&a, &b))
+}
+
+func (e *engine) divUnchecked(i1, i2 interface{}) Variable {
+	a, b := e.value(i1), e.value(i2)
+	if b.Sign() == 0 {
+		// the constraint b * res == a is satisfied by res = 0 if a is 0
+		if a.Sign() != 0 {
+			e.fail("division of %s by 0", a.String())
+		}
+		return e.variable(&a)
+	}
+	b.ModInverse(&b, e.modulus)
+	return e.variable(a.Mul(&a, &b))
+}
+
+func (e *engine) inverseOrZero(v Variable) Variable {
+	a := e.value(v)
+	if a.Sign() == 0 {
+		return e.variable(&a)
+	}
+	return e.variable(a.ModInverse(&a, e.modulus))
+}
+
+func (e *engine) batchInvert(vars []Variable) []Variable {
+	res := make([]Variable, len(vars))
+	for i := range vars {
+		res[i] = e.inverse(vars[i])
+	}
+	return res
+}
+
+func (e *engine) assertIsBoolean(i interface{}) big.Int {
+	v := e.value(i)
+	if !v.IsUint64() || v.Uint64() > 1 {
+		e.fail("%s == (0 or 1)", v.String())
+	}
+	return v
+}
+
+func (e *engine) xor(a, b Variable, in ...Variable) Variable {
+	res := e.assertIsBoolean(a)
+	for _, v := range append([]Variable{b}, in...) {
+		bit := e.assertIsBoolean(v)
+		res.Xor(&res, &bit)
+	}
+	return e.variable(&res)
+}
+
+func (e *engine) and(a, b Variable, in ...Variable) Variable {
+	res := e.assertIsBoolean(a)
+	for _, v := range append([]Variable{b}, in...) {
+		bit := e.assertIsBoolean(v)
+		res.And(&res, &bit)
+	}
+	return e.variable(&res)
+}
+
+func (e *engine) or(a, b Variable, in ...Variable) Variable {
+	res := e.assertIsBoolean(a)
+	for _, v := range append([]Variable{b}, in...) {
+		bit := e.assertIsBoolean(v)
+		res.Or(&res, &bit)
+	}
+	return e.variable(&res)
+}
+
+func (e *engine) not(a Variable) Variable {
+	bit := e.assertIsBoolean(a)
+	return e.variable(bit.Xor(&bit, big.NewInt(1)))
+}
+
+func (e *engine) toBinary(a Variable, nbBits int) []Variable {
+	v := e.value(a)
+	if v.BitLen() > nbBits {
+		e.fail("%s doesn't fit in %d bits", v.String(), nbBits)
+	}
+	res := make([]Variable, nbBits)
+	for i := 0; i < nbBits; i++ {
+		res[i] = e.variable(big.NewInt(int64(v.Bit(i))))
+	}
+	return res
+}
+
+func (e *engine) fromBinary(b ...Variable) Variable {
+	var res big.Int
+	for i := len(b) - 1; i >= 0; i-- {
+		bit := e.assertIsBoolean(b[i])
+		res.Lsh(&res, 1).Add(&res, &bit)
+	}
+	return e.variable(&res)
+}
+
+func (e *engine) selectValue(b Variable, i1, i2 interface{}) Variable {
+	bit := e.assertIsBoolean(b)
+	v1, v2 := e.value(i1), e.value(i2)
+	if bit.Sign() != 0 {
+		return e.variable(&v1)
+	}
+	return e.variable(&v2)
+}
+
+func (e *engine) lookup2(b0, b1 Variable, i0, i1, i2, i3 interface{}) Variable {
+	bit0, bit1 := e.assertIsBoolean(b0), e.assertIsBoolean(b1)
+	v := []interface{}{i0, i1, i2, i3}[bit1.Uint64()<<1|bit0.Uint64()]
+	res := e.value(v)
+	return e.variable(&res)
+}
+
+func (e *engine) assertIsEqual(i1, i2 interface{}) {
+	a, b := e.value(i1), e.value(i2)
+	if a.Cmp(&b) != 0 {
+		e.fail("[%s != %s]", a.String(), b.String())
+	}
+}
+
+func (e *engine) assertIsLessOrEqual(v Variable, bound interface{}) {
+	// the constraint system decomposes the values on maxReducedBits bits
+	const nbBits = maxReducedBits
+	a := e.value(v)
+	var b big.Int
+	if _bound, ok := bound.(Variable); ok {
+		b = e.value(_bound)
+		if b.BitLen() > nbBits {
+			e.fail("%s doesn't fit in %d bits", b.String(), nbBits)
+		}
+	} else {
+		b = backend.FromInterface(bound)
+	}
+	if a.BitLen() > nbBits {
+		e.fail("%s doesn't fit in %d bits", a.String(), nbBits)
+	}
+	if a.Cmp(&b) > 0 {
+		e.fail("%s <= %s", a.String(), b.String())
+	}
+}
+
+func (e *engine) cmp(i1, i2 interface{}, nbBits int) Variable {
+	a, b := e.value(i1), e.value(i2)
+	for _, i := range []interface{}{i1, i2} {
+		if _, ok := i.(Variable); ok {
+			if v := e.value(i); v.BitLen() > nbBits {
+				e.fail("%s doesn't fit in %d bits", v.String(), nbBits)
+			}
+		}
+	}
+	return e.variable(big.NewInt(int64(a.Cmp(&b))))
+}
+
+// println prints the values of the Variables of a, like ConstraintSystem.Println once solved
+func (e *engine) println(a ...interface{}) {
+	var sbb strings.Builder
+	if _, file, line, ok := runtime.Caller(2); ok {
+		sbb.WriteString(filepath.Base(file))
+		sbb.WriteByte(':')
+		sbb.WriteString(strconv.Itoa(line))
+		sbb.WriteByte(' ')
+	}
+	foundVariable := false
+	var handler logValueHandler = func(name string, tInput reflect.Value) {
+		v := e.value(tInput.Interface().(Variable))
+		if name == "" {
+			sbb.WriteString(v.String())
+		} else {
+			sbb.WriteString(fmt.Sprintf("[%s: %s]", name, v.String()))
+		}
+		foundVariable = true
+	}
+	for i, arg := range a {
+		if i > 0 {
+			sbb.WriteByte(' ')
+		}
+		foundVariable = false
+		parseLogValue(arg, "", handler)
+		if !foundVariable {
+			sbb.WriteString(fmt.Sprint(arg))
+		}
+	}
+	sbb.WriteByte('\n')
+	fmt.Print(sbb.String())
+}
@@ -0,0 +1,105 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package frontend
+
+import (
+	"github.com/consensys/gnark/backend/r1cs/r1c"
+)
+
+// LookupTable is a table of values (constants or Variables), for the membership assertions of
+// AssertInTable (see ConstraintSystem.LookupTable)
+type LookupTable struct {
+	entries []Variable
+}
+
+// Len returns the number of entries of the table
+func (t LookupTable) Len() int {
+	return len(t.entries)
+}
+
+// LookupTable returns a table of entries (constants or Variables); the duplicated constants are
+// recorded once
+func (cs *ConstraintSystem) LookupTable(entries ...interface{}) LookupTable {
+	var t LookupTable
+	constants := make(map[string]struct{}, len(entries))
+	for _, entry := range entries {
+		v := cs.Constant(entry)
+		if cs.engine == nil {
+			if c, ok := cs.constantValue(v); ok {
+				if _, ok := constants[c.String()]; ok {
+					continue
+				}
+				constants[c.String()] = struct{}{}
+			}
+		}
+		t.entries = append(t.entries, v)
+	}
+	return t
+}
+
+// AssertInTable adds an assertion in the constraint system (v is one of the entries of t)
+//
+// the R1CS has no lookup argument: the assertion is (v - t[0]) * (v - t[1]) * ... == 0, which records
+// t.Len() - 1 constraints (the constant factors are folded). It fits small tables; a range check on
+// many bits is cheaper with AssertIsLessOrEqual or ToBinary
+func (cs *ConstraintSystem) AssertInTable(t LookupTable, v interface{}) {
+
+	if cs.engine != nil {
+		cs.engine.assertInTable(t, v)
+		return
+	}
+
+	switch len(t.entries) {
+	case 0:
+		panic("AssertInTable: empty table")
+	case 1:
+		cs.AssertIsEqual(v, t.entries[0])
+		return
+	}
+
+	x := cs.Constant(v)
+	prod := cs.Sub(x, t.entries[0])
+	for _, entry := range t.entries[1 : len(t.entries)-1] {
+		prod = cs.Mul(prod, cs.Sub(x, entry))
+	}
+	last := cs.Sub(x, t.entries[len(t.entries)-1])
+
+	// the last product is the assertion
+	if c1, ok := cs.constantValue(prod); ok {
+		if c2, ok := cs.constantValue(last); ok && (c1.Sign() == 0 || c2.Sign() == 0) {
+			return
+		}
+	}
+	zero := cs.Constant(0)
+	constraint := r1c.R1C{L: cs.copyLinExp(prod.linExp), R: cs.copyLinExp(last.linExp), O: cs.copyLinExp(zero.linExp), Solver: r1c.SingleOutput}
+
+	debugInfo := logEntry{format: "error AssertInTable"}
+	for _, frame := range getCallStack() {
+		debugInfo.format += "\n" + frame
+	}
+	cs.addAssertion(constraint, debugInfo)
+}
+
+func (e *engine) assertInTable(t LookupTable, v interface{}) {
+	x := e.value(v)
+	for _, entry := range t.entries {
+		if y := e.value(entry); x.Cmp(&y) == 0 {
+			return
+		}
+	}
+	e.fail("%s is not in the table", x.String())
+}
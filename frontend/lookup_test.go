@@ -0,0 +1,54 @@
+package frontend
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark/backend/r1cs"
+	"github.com/consensys/gurvy"
+)
+
+type lookupCircuit struct {
+	X Variable
+}
+
+func (circuit *lookupCircuit) Define(curveID gurvy.ID, cs *ConstraintSystem) error {
+	table := cs.LookupTable(1, 5, 7, 5)
+	cs.AssertInTable(table, circuit.X)
+	cs.AssertInTable(table, 7)
+	return nil
+}
+
+func TestAssertInTable(t *testing.T) {
+	_r1cs, err := Compile(gurvy.UNKNOWN, &lookupCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the duplicated entry is recorded once, and the constant in the table records no constraint
+	if n := _r1cs.GetNbConstraints(); n != 2 {
+		t.Fatal("expected 2 constraints, got", n)
+	}
+
+	for _, c := range []struct {
+		x     int
+		valid bool
+	}{
+		{1, true},
+		{5, true},
+		{7, true},
+		{0, false},
+		{6, false},
+	} {
+		var witness lookupCircuit
+		witness.X.Assign(c.x)
+		assignment, err := ParseWitness(&witness)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := _r1cs.(*r1cs.UntypedR1CS).ToR1CS(gurvy.BN256).IsSolved(assignment); (err == nil) != c.valid {
+			t.Fatal(c, "IsSolved: expected valid =", c.valid, "got", err)
+		}
+		if err := Evaluate(gurvy.BN256, &witness); (err == nil) != c.valid {
+			t.Fatal(c, "Evaluate: expected valid =", c.valid, "got", err)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package frontend
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"reflect"
+	"strconv"
+
+	"github.com/consensys/gnark/backend"
+)
+
+// varLenSlice is a slice of Variables tagged with maxlen (see Tag)
+type varLenSlice struct {
+	name       string // the elements are name_0, name_1, ..., and the length wire is name_len
+	visibility backend.Visibility
+	slice      reflect.Value // settable
+	maxLen     int
+}
+
+// parseVarLenSlices returns the slices tagged with maxlen in input, in the order of parseType
+//
+// the slices in the values of a map are not supported (a map value is a copy)
+func parseVarLenSlices(input interface{}, baseName string, parentVisibility backend.Visibility) ([]varLenSlice, error) {
+	var res []varLenSlice
+	tVariable := reflect.TypeOf(Variable{})
+
+	err := parseTaggedFields(input, baseName, parentVisibility, optMaxLen, func(name string, visibility backend.Visibility, f reflect.Value, value string) error {
+		if f.Kind() != reflect.Slice || f.Type().Elem() != tVariable {
+			return fmt.Errorf("%s: maxlen applies to a []frontend.Variable", name)
+		}
+		maxLen, err := strconv.Atoi(value)
+		if err != nil || maxLen <= 0 {
+			return fmt.Errorf("%s: invalid maxlen %q", name, value)
+		}
+		if visibility == backend.Unset {
+			visibility = backend.Secret
+		}
+		res = append(res, varLenSlice{name: name, visibility: visibility, slice: f, maxLen: maxLen})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// parseTaggedFields calls fn on the settable struct fields of input (recursively, in the order of
+// parseType) whose tag has the option optionName=value; the other fields are walked through
+//
+// the fields in the values of a map are not visited (a map value is a copy)
+func parseTaggedFields(input interface{}, baseName string, parentVisibility backend.Visibility, optionName Tag,
+	fn func(name string, visibility backend.Visibility, f reflect.Value, value string) error) error {
+	tVariable := reflect.TypeOf(Variable{})
+
+	var parse func(tValue reflect.Value, baseName string, parentVisibility backend.Visibility) error
+	parse = func(tValue reflect.Value, baseName string, parentVisibility backend.Visibility) error {
+		switch tValue.Kind() {
+		case reflect.Ptr:
+			if !tValue.IsNil() {
+				return parse(tValue.Elem(), baseName, parentVisibility)
+			}
+		case reflect.Struct:
+			if tValue.Type() == tVariable {
+				return nil
+			}
+			for i := 0; i < tValue.NumField(); i++ {
+				field := tValue.Type().Field(i)
+				name, visibility, omit := parseField(field, parentVisibility)
+				f := tValue.Field(i)
+				if omit || !f.CanSet() {
+					continue
+				}
+				fullName := appendName(baseName, name)
+
+				_, opts := parseTag(field.Tag.Get(string(tagKey)))
+				if value, ok := opts.Value(string(optionName)); ok {
+					if err := fn(fullName, visibility, f, value); err != nil {
+						return err
+					}
+					continue
+				}
+				if err := parse(f, fullName, visibility); err != nil {
+					return err
+				}
+			}
+		case reflect.Slice, reflect.Array:
+			for j := 0; j < tValue.Len(); j++ {
+				if err := parse(tValue.Index(j), appendName(baseName, strconv.Itoa(j)), parentVisibility); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	return parse(reflect.ValueOf(input), baseName, parentVisibility)
+}
+
+// grow sets the length of the slice to maxLen, with padding elements
+func (s *varLenSlice) grow(padding Variable) error {
+	n := s.slice.Len()
+	if n > s.maxLen {
+		return fmt.Errorf("%s: %d elements, more than maxlen=%d", s.name, n, s.maxLen)
+	}
+	grown := make([]Variable, s.maxLen)
+	reflect.Copy(reflect.ValueOf(grown), s.slice)
+	for i := n; i < s.maxLen; i++ {
+		grown[i] = padding
+	}
+	s.slice.Set(reflect.ValueOf(grown))
+	return nil
+}
+
+// assert records the constraints of the length wire of the slice: length is at most maxLen, and the
+// elements at index length and above are 0, so that a witness can't assign the padding
+func (s *varLenSlice) assert(cs *ConstraintSystem, length Variable) {
+	// length is an integer in [0, 2^bits.Len(maxLen)): it is equal to at most one index
+	cs.ToBinary(length, bits.Len(uint(s.maxLen)))
+
+	// past is 1 if the index i is at or past the length (1 - past is the prefix mask of the elements)
+	var past Variable
+	for i := 0; i < s.maxLen; i++ {
+		if i == 0 {
+			past = cs.IsZero(length)
+		} else {
+			past = cs.Add(past, cs.IsZero(cs.Sub(length, i)))
+		}
+		cs.AssertIsEqual(cs.Mul(past, s.slice.Index(i).Interface().(Variable)), 0)
+	}
+
+	// past is 0 if length > maxLen - 1, in which case length is maxLen
+	cs.AssertIsEqual(cs.Mul(cs.Sub(1, past), cs.Sub(length, s.maxLen)), 0)
+}
+
+// first returns the first element of the slice, which identifies it in ConstraintSystem.Len
+func (s *varLenSlice) first() *Variable {
+	return s.slice.Index(0).Addr().Interface().(*Variable)
+}
+
+// lengthName returns the name of the length wire
+func (s *varLenSlice) lengthName() string {
+	return appendName(s.name, "len")
+}
+
+// errNotVarLen is the panic of ConstraintSystem.Len on a slice which is not tagged with maxlen
+var errNotVarLen = errors.New("Len: the slice is not a field tagged with maxlen (or was resliced)")
@@ -0,0 +1,85 @@
+package frontend
+
+import (
+	"math/big"
+	"testing"
+
+	backend_bn256 "github.com/consensys/gnark/internal/backend/bn256"
+	"github.com/consensys/gurvy"
+)
+
+type maxLenCircuit struct {
+	Msg []Variable `gnark:",public,maxlen=4"`
+	Sum Variable
+}
+
+func (circuit *maxLenCircuit) Define(curveID gurvy.ID, cs *ConstraintSystem) error {
+	sum := cs.Add(circuit.Msg[0], circuit.Msg[1], circuit.Msg[2], circuit.Msg[3])
+	cs.AssertIsEqual(cs.Add(sum, cs.Len(circuit.Msg)), circuit.Sum)
+	return nil
+}
+
+func TestMaxLen(t *testing.T) {
+
+	var circuit maxLenCircuit
+	_r1cs, err := Compile(gurvy.BN256, &circuit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(circuit.Msg) != 4 {
+		t.Fatal("expected 4 wires for Msg, got", len(circuit.Msg))
+	}
+	// Msg_0 ... Msg_3, Msg_len and the constant wire
+	if nbPublic := _r1cs.(*backend_bn256.R1CS).NbPublicWires; nbPublic != 6 {
+		t.Fatal("expected 6 public wires, got", nbPublic)
+	}
+
+	var witness maxLenCircuit
+	witness.Msg = make([]Variable, 2)
+	witness.Msg[0].Assign(3)
+	witness.Msg[1].Assign(5)
+	witness.Sum.Assign(3 + 5 + 2)
+	assignment, err := ParseWitness(&witness)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := assignment["Msg_3"].(big.Int); !ok || v.Sign() != 0 {
+		t.Fatal("expected Msg_3 to be padded with 0, got", assignment["Msg_3"])
+	}
+	if v, ok := assignment["Msg_len"].(big.Int); !ok || v.Int64() != 2 {
+		t.Fatal("expected Msg_len to be 2, got", assignment["Msg_len"])
+	}
+	if err := _r1cs.IsSolved(assignment); err != nil {
+		t.Fatal(err)
+	}
+	if err := Evaluate(gurvy.BN256, &witness); err != nil {
+		t.Fatal(err)
+	}
+
+	// the elements past the length are 0: a nonzero padding doesn't satisfy the constraints, even if the
+	// rest of the circuit accepts it
+	assignment["Msg_3"] = 7
+	assignment["Sum"] = 3 + 5 + 7 + 2
+	if err := _r1cs.IsSolved(assignment); err == nil {
+		t.Fatal("expected a nonzero padding to be rejected")
+	}
+	assignment["Msg_len"] = 4
+	assignment["Sum"] = 3 + 5 + 7 + 4
+	if err := _r1cs.IsSolved(assignment); err != nil {
+		t.Fatal(err)
+	}
+
+	// a length above maxlen doesn't satisfy the constraints
+	for _, length := range []int{5, 8, 9} {
+		assignment["Msg_len"] = length
+		assignment["Sum"] = 3 + 5 + 7 + length
+		if err := _r1cs.IsSolved(assignment); err == nil {
+			t.Fatal("expected a length above maxlen to be rejected", length)
+		}
+	}
+
+	witness.Msg = make([]Variable, 5)
+	if _, err := ParseWitness(&witness); err == nil {
+		t.Fatal("expected a witness with more than maxlen elements to be rejected")
+	}
+}
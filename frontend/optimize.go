@@ -0,0 +1,418 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package frontend
+
+import (
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/r1cs"
+	"github.com/consensys/gnark/backend/r1cs/r1c"
+)
+
+// WithOptimization shrinks the R1CS once Define returns:
+//
+//   - a computational constraint copying a wire (x * 1 == y, to compute y) is removed, and y is replaced
+//     by x in the other constraints
+//   - a computational constraint whose result is not used (as the result of cs.Mul(x, y) discarded by a
+//     gadget) is removed, if it holds for any value of its inputs; so is a hint whose results are not used
+//   - an assertion which always holds (x * 1 == x) is removed
+//   - the internal wires no constraint uses are removed
+//
+// the optimized R1CS is satisfied by the same inputs, but has other keys (and Fingerprint)
+func WithOptimization() CompileOption {
+	return func(cs *ConstraintSystem) error {
+		cs.optimize = true
+		return nil
+	}
+}
+
+// optimizer holds the state of the optimization of a R1CS (see WithOptimization)
+type optimizer struct {
+	r1cs       *r1cs.UntypedR1CS
+	nbInternal int
+	one        int // the constant wire
+
+	solved       []int  // wire solved by each computational constraint, or -1
+	substitute   []int  // wire replacing each wire
+	uses         []int  // number of terms of each wire, in the constraints, hints, logs and commitment
+	removed      []bool // of each constraint
+	removedHints []bool
+}
+
+// optimize applies the optimizations of WithOptimization to a R1CS; the wires are [internal | secret | public]
+func optimize(res *r1cs.UntypedR1CS) {
+	o := optimizer{
+		r1cs:         res,
+		nbInternal:   int(res.NbWires - res.NbPublicWires - res.NbSecretWires),
+		solved:       make([]int, res.NbCOConstraints),
+		substitute:   make([]int, res.NbWires),
+		uses:         make([]int, res.NbWires),
+		removed:      make([]bool, len(res.Constraints)),
+		removedHints: make([]bool, len(res.Hints)),
+	}
+	for i, name := range res.PublicWires {
+		if name == backend.OneWire {
+			o.one = int(res.NbWires-res.NbPublicWires) + i
+		}
+	}
+	for w := range o.substitute {
+		o.substitute[w] = w
+	}
+
+	o.findSolvedWires()
+	o.collapseCopies()
+	o.countUses()
+	o.removeTautologies()
+	o.removeUnused()
+	o.compact()
+}
+
+// findSolvedWires sets the wire solved by each computational constraint, the only wire of the constraint
+// which is not an input, the result of a hint, or solved by a previous constraint
+func (o *optimizer) findSolvedWires() {
+	known := make([]bool, len(o.uses))
+	for w := o.nbInternal; w < len(known); w++ {
+		known[w] = true
+	}
+	for i := range o.r1cs.Hints {
+		for _, w := range o.r1cs.Hints[i].Outputs() {
+			known[w] = true
+		}
+	}
+	for i := range o.solved {
+		r := &o.r1cs.Constraints[i]
+		o.solved[i] = -1
+		for _, l := range []r1c.LinearExpression{r.L, r.R, r.O} {
+			for _, t := range l {
+				if w := t.VariableID(); !known[w] {
+					if r.Solver == r1c.SingleOutput {
+						o.solved[i] = w
+					}
+					known[w] = true
+				}
+			}
+		}
+	}
+}
+
+// single returns the wire of a linear expression which is a single wire (with the coefficient 1), or -1
+func single(l r1c.LinearExpression) int {
+	if len(l) != 1 || l[0].CoeffValue() != 1 {
+		return -1
+	}
+	return l[0].VariableID()
+}
+
+// find returns the wire replacing w
+func (o *optimizer) find(w int) int {
+	for o.substitute[w] != w {
+		w = o.substitute[w]
+	}
+	return w
+}
+
+// isCopy returns the wires of a constraint x * 1 == y, or -1
+func (o *optimizer) isCopy(r *r1c.R1C) (x, y int) {
+	l, right, out := single(r.L), single(r.R), single(r.O)
+	switch {
+	case l == -1 || right == -1 || out == -1:
+		return -1, -1
+	case right == o.one:
+		return l, out
+	case l == o.one:
+		return right, out
+	}
+	return -1, -1
+}
+
+// collapseCopies removes the computational constraints copying a wire, and replaces the wire they solve
+// by the copied wire; the committed wires are kept
+func (o *optimizer) collapseCopies() {
+	committed := make(map[int]bool)
+	if c := o.r1cs.Commitment; c != nil {
+		for _, w := range c.Committed {
+			committed[w] = true
+		}
+	}
+	for i, w := range o.solved {
+		x, y := o.isCopy(&o.r1cs.Constraints[i])
+		if w == -1 || x == -1 || committed[w] {
+			continue
+		}
+		other := x
+		if w == x {
+			other = y
+		}
+		if other == w {
+			continue
+		}
+		o.substitute[w] = o.find(other)
+		o.removed[i] = true
+	}
+
+	replace := func(l r1c.LinearExpression) {
+		for j := range l {
+			if w := l[j].VariableID(); o.substitute[w] != w {
+				l[j].SetVariableID(o.find(w))
+			}
+		}
+	}
+	for i := range o.r1cs.Constraints {
+		if !o.removed[i] {
+			r := &o.r1cs.Constraints[i]
+			replace(r.L)
+			replace(r.R)
+			replace(r.O)
+		}
+	}
+	for i := range o.r1cs.Hints {
+		for _, l := range o.r1cs.Hints[i].Inputs {
+			replace(l)
+		}
+	}
+	for _, entries := range [][]backend.LogEntry{o.r1cs.Logs, o.r1cs.DebugInfo} {
+		for i := range entries {
+			for j, w := range entries[i].ToResolve {
+				entries[i].ToResolve[j] = o.find(w)
+			}
+		}
+	}
+}
+
+// countUses counts the terms of each wire
+func (o *optimizer) countUses() {
+	for i := range o.r1cs.Constraints {
+		if !o.removed[i] {
+			o.addUses(&o.r1cs.Constraints[i], 1)
+		}
+	}
+	for i := range o.r1cs.Hints {
+		o.addHintUses(&o.r1cs.Hints[i], 1)
+	}
+	for _, entries := range [][]backend.LogEntry{o.r1cs.Logs, o.r1cs.DebugInfo} {
+		for i := range entries {
+			for _, w := range entries[i].ToResolve {
+				o.uses[w]++
+			}
+		}
+	}
+	if c := o.r1cs.Commitment; c != nil {
+		for _, w := range c.Committed {
+			o.uses[w]++
+		}
+		o.uses[c.Wire]++
+	}
+}
+
+func (o *optimizer) addUses(r *r1c.R1C, delta int) {
+	for _, l := range []r1c.LinearExpression{r.L, r.R, r.O} {
+		for _, t := range l {
+			o.uses[t.VariableID()] += delta
+		}
+	}
+}
+
+func (o *optimizer) addHintUses(h *r1c.Hint, delta int) {
+	for _, l := range h.Inputs {
+		for _, t := range l {
+			o.uses[t.VariableID()] += delta
+		}
+	}
+}
+
+// removeUnused removes the computational constraints whose solved wire is used only in their output,
+// and the hints whose results are not used, until none is removed: the constraint holds for any value
+// of its other wires
+func (o *optimizer) removeUnused() {
+	for progress := true; progress; {
+		progress = false
+		for i := len(o.solved) - 1; i >= 0; i-- {
+			w := o.solved[i]
+			if o.removed[i] || w == -1 {
+				continue
+			}
+			r := &o.r1cs.Constraints[i]
+			inOutput := 0
+			for _, t := range r.O {
+				if t.VariableID() == w {
+					inOutput++
+				}
+			}
+			if inOutput == 0 || o.uses[w] != inOutput {
+				continue
+			}
+			o.addUses(r, -1)
+			o.removed[i] = true
+			progress = true
+		}
+		for i := len(o.r1cs.Hints) - 1; i >= 0; i-- {
+			if o.removedHints[i] {
+				continue
+			}
+			used := false
+			for _, w := range o.r1cs.Hints[i].Outputs() {
+				used = used || o.uses[w] != 0
+			}
+			if used {
+				continue
+			}
+			o.addHintUses(&o.r1cs.Hints[i], -1)
+			o.removedHints[i] = true
+			progress = true
+		}
+	}
+}
+
+// removeTautologies removes the assertions x * 1 == x
+func (o *optimizer) removeTautologies() {
+	for i := int(o.r1cs.NbCOConstraints); i < len(o.r1cs.Constraints); i++ {
+		if x, y := o.isCopy(&o.r1cs.Constraints[i]); x != -1 && x == y {
+			o.addUses(&o.r1cs.Constraints[i], -1)
+			o.removed[i] = true
+		}
+	}
+}
+
+// compact removes the constraints, the hints and the internal wires no longer used, and renumbers the
+// wires
+func (o *optimizer) compact() {
+	res := o.r1cs
+
+	// the internal wires are kept if a constraint, a hint, a log or the commitment use them
+	live := make([]bool, o.nbInternal)
+	mark := func(l r1c.LinearExpression) {
+		for _, t := range l {
+			if w := t.VariableID(); w < o.nbInternal {
+				live[w] = true
+			}
+		}
+	}
+	for i := range res.Constraints {
+		if !o.removed[i] {
+			mark(res.Constraints[i].L)
+			mark(res.Constraints[i].R)
+			mark(res.Constraints[i].O)
+		}
+	}
+	for i := range res.Hints {
+		if o.removedHints[i] {
+			continue
+		}
+		for _, l := range res.Hints[i].Inputs {
+			mark(l)
+		}
+		for _, w := range res.Hints[i].Outputs() {
+			live[w] = true
+		}
+	}
+	for w := range live {
+		live[w] = live[w] || o.uses[w] != 0
+	}
+
+	wires := make([]int, len(o.uses)) // new id of each wire
+	nbInternal := 0
+	for w := 0; w < o.nbInternal; w++ {
+		if live[w] {
+			wires[w] = nbInternal
+			nbInternal++
+		}
+	}
+	shift := o.nbInternal - nbInternal
+	for w := o.nbInternal; w < len(wires); w++ {
+		wires[w] = w - shift
+	}
+	renumber := func(l r1c.LinearExpression) {
+		for j := range l {
+			l[j].SetVariableID(wires[l[j].VariableID()])
+		}
+	}
+
+	// constraints, and their locations, debug information and namespaces
+	index := make([]int, len(res.Constraints)+1) // new index of each constraint
+	constraints := res.Constraints[:0]
+	var locations []string
+	var debugInfo []backend.LogEntry
+	nbCO := 0
+	for i := range res.Constraints {
+		index[i] = len(constraints)
+		if o.removed[i] {
+			continue
+		}
+		r := res.Constraints[i]
+		renumber(r.L)
+		renumber(r.R)
+		renumber(r.O)
+		constraints = append(constraints, r)
+		if res.Locations != nil {
+			locations = append(locations, res.Locations[i])
+		}
+		if i < int(res.NbCOConstraints) {
+			nbCO++
+		} else {
+			debugInfo = append(debugInfo, res.DebugInfo[i-int(res.NbCOConstraints)])
+		}
+	}
+	index[len(res.Constraints)] = len(constraints)
+	var namespaces []r1c.Namespace
+	for _, n := range res.Namespaces {
+		n.Constraint = index[n.Constraint]
+		if len(namespaces) != 0 && namespaces[len(namespaces)-1].Constraint == n.Constraint {
+			namespaces = namespaces[:len(namespaces)-1] // the previous run is empty
+		}
+		if n.Constraint < len(constraints) {
+			namespaces = append(namespaces, n)
+		}
+	}
+
+	hints := res.Hints[:0]
+	for i := range res.Hints {
+		if o.removedHints[i] {
+			continue
+		}
+		h := res.Hints[i]
+		for _, l := range h.Inputs {
+			renumber(l)
+		}
+		h.Wire = wires[h.Wire]
+		for j := range h.Wires {
+			h.Wires[j] = wires[h.Wires[j]]
+		}
+		hints = append(hints, h)
+	}
+
+	for _, entries := range [][]backend.LogEntry{res.Logs, debugInfo} {
+		for i := range entries {
+			for j, w := range entries[i].ToResolve {
+				entries[i].ToResolve[j] = wires[w]
+			}
+		}
+	}
+	if c := res.Commitment; c != nil {
+		for i, w := range c.Committed {
+			c.Committed[i] = wires[w]
+		}
+		c.Wire = wires[c.Wire]
+	}
+
+	res.NbWires -= uint64(shift)
+	res.NbConstraints = uint64(len(constraints))
+	res.NbCOConstraints = uint64(nbCO)
+	res.Constraints = constraints
+	res.Locations = locations
+	res.DebugInfo = debugInfo
+	res.Namespaces = namespaces
+	res.Hints = hints
+}
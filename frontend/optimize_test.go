@@ -0,0 +1,122 @@
+package frontend_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/consensys/gnark/backend/r1cs"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/internal/backend/circuits"
+	"github.com/consensys/gurvy"
+)
+
+type copyCircuit struct {
+	X, Y frontend.Variable
+}
+
+func (circuit *copyCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	cs.AssertIsEqual(circuit.X, circuit.Y)
+	return nil
+}
+
+type deadWeightCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+
+	copy *r1cs.UntypedR1CS
+}
+
+func (circuit *deadWeightCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	// unused results
+	x2 := cs.Mul(circuit.X, circuit.X)
+	cs.Mul(x2, x2)
+
+	// unused, but x != 0
+	cs.Inverse(circuit.X)
+
+	// y = x * 1, recorded to compute y
+	y := cs.Embed(circuit.copy, map[string]interface{}{"X": circuit.X}, "Y")[0]
+
+	cs.AssertIsEqual(cs.Mul(y, circuit.X), circuit.Y)
+	return nil
+}
+
+func TestOptimization(t *testing.T) {
+	copyR1CS, err := frontend.Compile(gurvy.UNKNOWN, &copyCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	circuit := deadWeightCircuit{copy: copyR1CS.(*r1cs.UntypedR1CS)}
+
+	full, err := frontend.Compile(gurvy.UNKNOWN, &deadWeightCircuit{copy: circuit.copy})
+	if err != nil {
+		t.Fatal(err)
+	}
+	optimized, err := frontend.Compile(gurvy.UNKNOWN, &circuit, frontend.WithOptimization(), frontend.WithSourceLocations())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if full.GetNbConstraints() != 6 || full.GetNbWires() != 8 {
+		t.Fatal("unexpected R1CS", full.GetNbConstraints(), full.GetNbWires())
+	}
+	// inverse, x * x, assertion; the wires of the inverse and of x * x, x, y and the constant wire
+	if optimized.GetNbConstraints() != 3 || optimized.GetNbWires() != 5 {
+		t.Fatal("unexpected optimized R1CS", optimized.GetNbConstraints(), optimized.GetNbWires())
+	}
+	if len(optimized.(*r1cs.UntypedR1CS).Locations) != 3 {
+		t.Fatal("the locations of the removed constraints must be removed")
+	}
+
+	for _, c := range []struct {
+		x, y  int
+		valid bool
+	}{
+		{3, 9, true},
+		{3, 8, false},
+		{0, 0, false},
+	} {
+		var witness deadWeightCircuit
+		witness.X.Assign(c.x)
+		witness.Y.Assign(c.y)
+		assignment, err := frontend.ParseWitness(&witness)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, _r1cs := range []*r1cs.UntypedR1CS{full.(*r1cs.UntypedR1CS), optimized.(*r1cs.UntypedR1CS)} {
+			if err := _r1cs.ToR1CS(gurvy.BN256).IsSolved(assignment); (err == nil) != c.valid {
+				t.Fatal(c, "expected valid =", c.valid, "got", err)
+			}
+		}
+	}
+}
+
+func TestOptimizedCircuits(t *testing.T) {
+	for name, circuit := range circuits.Circuits {
+		if name == "map" {
+			continue // the shape of the circuit (its maps) is not given by its type
+		}
+		empty := reflect.New(reflect.TypeOf(circuit.Good).Elem()).Interface().(frontend.Circuit)
+		optimized, err := frontend.Compile(gurvy.BN256, empty, frontend.WithOptimization())
+		if err != nil {
+			t.Fatal(name, err)
+		}
+		if optimized.GetNbConstraints() > circuit.R1CS.GetNbConstraints() {
+			t.Fatal(name, "the optimization added constraints")
+		}
+
+		good, err := frontend.ParseWitness(circuit.Good)
+		if err != nil {
+			t.Fatal(name, err)
+		}
+		if err := optimized.IsSolved(good); err != nil {
+			t.Fatal(name, "good witness:", err)
+		}
+		bad, err := frontend.ParseWitness(circuit.Bad)
+		if err != nil {
+			t.Fatal(name, err)
+		}
+		if err := optimized.IsSolved(bad); err == nil {
+			t.Fatal(name, "bad witness: expected an error")
+		}
+	}
+}
@@ -0,0 +1,96 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package frontend
+
+import (
+	"errors"
+	"log"
+	"os"
+)
+
+// CompileOption configures the compilation of a circuit (see Compile)
+type CompileOption func(cs *ConstraintSystem) error
+
+// WithSourceLocations records the Go source location (file:line) of the call adding each constraint,
+// in the circuit or in one of its gadgets; the solver reports it with an unsatisfied constraint
+//
+// it slows down the compilation, and the R1CS stores a location per constraint
+func WithSourceLocations() CompileOption {
+	return func(cs *ConstraintSystem) error {
+		cs.sourceLocations = true
+		return nil
+	}
+}
+
+// WithStrict makes the compilation fail on the fields of the circuit which are ignored (an unexported
+// Variable, an empty slice, a map with non string keys), instead of logging a warning
+func WithStrict() CompileOption {
+	return func(cs *ConstraintSystem) error {
+		cs.strict = true
+		return nil
+	}
+}
+
+// WithCapacity allocates room for nbConstraints constraints (and as many internal variables) before
+// calling Define, instead of a default capacity of 1e6; it doesn't limit the size of the circuit
+func WithCapacity(nbConstraints int) CompileOption {
+	return func(cs *ConstraintSystem) error {
+		if nbConstraints < 0 {
+			return errors.New("WithCapacity: negative capacity")
+		}
+		cs.capacity = nbConstraints
+		return nil
+	}
+}
+
+// WithLogger sets the logger of the warnings of the compilation (by default they are printed on
+// stdout); a nil logger discards them
+func WithLogger(logger *log.Logger) CompileOption {
+	return func(cs *ConstraintSystem) error {
+		cs.logger = logger
+		return nil
+	}
+}
+
+// warnFunc handles a warning of the parsing of a circuit (see parseType); a non nil error stops the
+// parsing
+type warnFunc func(msg string) error
+
+// defaultLogger prints the warnings on stdout
+var defaultLogger = log.New(os.Stdout, "", 0)
+
+// warn reports a warning with the logger of the compilation, or returns it as an error in strict mode
+func (cs *ConstraintSystem) warn(msg string) error {
+	if cs.strict {
+		return errors.New(msg)
+	}
+	if cs.logger != nil {
+		cs.logger.Println("warning: " + msg)
+	}
+	return nil
+}
+
+// printWarning reports a warning with the default logger, outside of a compilation
+func printWarning(msg string) error {
+	defaultLogger.Println("warning: " + msg)
+	return nil
+}
+
+// ignoreWarning discards a warning already reported
+func ignoreWarning(msg string) error {
+	return nil
+}
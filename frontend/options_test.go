@@ -0,0 +1,90 @@
+package frontend
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+
+	backend_bn256 "github.com/consensys/gnark/internal/backend/bn256"
+	"github.com/consensys/gurvy"
+)
+
+type locationCircuit struct {
+	X Variable
+}
+
+func (circuit *locationCircuit) Define(curveID gurvy.ID, cs *ConstraintSystem) error {
+	x2 := cs.Mul(circuit.X, circuit.X)
+	cs.ToBinary(circuit.X, 3)
+	cs.AssertIsEqual(x2, 9)
+	return nil
+}
+
+func TestSourceLocations(t *testing.T) {
+
+	// the locations are recorded only with the option
+	_r1cs, err := Compile(gurvy.BN256, &locationCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = _r1cs.IsSolved(map[string]interface{}{"X": 2}); err == nil || strings.Contains(err.Error(), "options_test.go") {
+		t.Fatal("unexpected error", err)
+	}
+
+	_r1cs, err = Compile(gurvy.BN256, &locationCircuit{}, WithSourceLocations())
+	if err != nil {
+		t.Fatal(err)
+	}
+	locations := _r1cs.(*backend_bn256.R1CS).Locations
+	if len(locations) != int(_r1cs.GetNbConstraints()) {
+		t.Fatal("a location should be recorded for each constraint")
+	}
+	for _, location := range locations {
+		if !strings.Contains(location, "options_test.go:") {
+			t.Fatal("the location should be in the circuit", location)
+		}
+	}
+
+	// the solver reports the location of an unsatisfied assertion, and of a decomposition
+	if err = _r1cs.IsSolved(map[string]interface{}{"X": 2}); err == nil || !strings.Contains(err.Error(), locations[len(locations)-1]) {
+		t.Fatal("the error should have the location of AssertIsEqual", err)
+	}
+	if err = _r1cs.IsSolved(map[string]interface{}{"X": 9}); err == nil || !strings.Contains(err.Error(), "(at ") {
+		t.Fatal("the error should have the location of ToBinary", err)
+	}
+}
+
+type ignoredFieldsCircuit struct {
+	X     Variable
+	Y     Variable `gnark:",public"`
+	Empty []Variable
+	z     Variable
+}
+
+func (circuit *ignoredFieldsCircuit) Define(curveID gurvy.ID, cs *ConstraintSystem) error {
+	cs.AssertIsEqual(cs.Mul(circuit.X, circuit.X), circuit.Y)
+	return nil
+}
+
+func TestCompileWarnings(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := Compile(gurvy.BN256, &ignoredFieldsCircuit{}, WithLogger(log.New(&buf, "", 0)), WithCapacity(1)); err != nil {
+		t.Fatal(err)
+	}
+	warnings := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(warnings) != 2 || !strings.Contains(warnings[0], "Empty") || !strings.Contains(warnings[1], "z") {
+		t.Fatal("unexpected warnings", warnings)
+	}
+
+	// a nil logger discards the warnings, and the strict mode returns the first one
+	if _, err := Compile(gurvy.BN256, &ignoredFieldsCircuit{}, WithLogger(nil)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Compile(gurvy.BN256, &ignoredFieldsCircuit{}, WithStrict()); err == nil || !strings.Contains(err.Error(), "Empty") {
+		t.Fatal("expected an error for the empty slice, got", err)
+	}
+	if _, err := Compile(gurvy.BN256, &ignoredFieldsCircuit{}, WithCapacity(-1)); err == nil {
+		t.Fatal("expected an error for a negative capacity")
+	}
+}
@@ -0,0 +1,167 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package frontend
+
+import (
+	"compress/gzip"
+	"io"
+	"runtime"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// maxProfileDepth is the maximum number of frames of the call stacks of a profile
+const maxProfileDepth = 64
+
+// profile counts the constraints added by each call stack (see WithProfile)
+type profile struct {
+	w       io.Writer
+	samples map[[maxProfileDepth]uintptr]int
+}
+
+// WithProfile attributes the constraints of the circuit to the Go call stacks adding them, and writes
+// the profile to w once the circuit is compiled, in the pprof format:
+//
+//	go tool pprof -top circuit.pprof
+//	go tool pprof -http=:8080 circuit.pprof
+//
+// the second command shows the profile as a flame graph. The value of a sample is a number of
+// constraints (computational constraints and assertions); the operations folded at compile time, or
+// reusing the wires of an identical expression, add none
+func WithProfile(w io.Writer) CompileOption {
+	return func(cs *ConstraintSystem) error {
+		cs.profile = &profile{w: w, samples: make(map[[maxProfileDepth]uintptr]int)}
+		return nil
+	}
+}
+
+// record attributes a constraint to the call stack of the API method adding it
+func (p *profile) record() {
+	var stack [maxProfileDepth]uintptr
+	runtime.Callers(3, stack[:]) // skips runtime.Callers, record, and addConstraint or addAssertion
+	p.samples[stack]++
+}
+
+// write writes the gzipped protobuf encoding of the profile to p.w (see github.com/google/pprof/proto/profile.proto)
+func (p *profile) write() error {
+
+	// string_table[0] is ""
+	strings := []string{""}
+	stringIDs := map[string]uint64{"": 0}
+	str := func(s string) uint64 {
+		if id, ok := stringIDs[s]; ok {
+			return id
+		}
+		id := uint64(len(strings))
+		strings = append(strings, s)
+		stringIDs[s] = id
+		return id
+	}
+
+	// a function and a location per frame; an inlined call is a frame of its own
+	type location struct {
+		function string
+		file     string
+		line     int
+	}
+	functionIDs := make(map[string]uint64)
+	locationIDs := make(map[location]uint64)
+	var functions, locations, samples []byte
+
+	for stack, count := range p.samples {
+		n := 0
+		for n < len(stack) && stack[n] != 0 {
+			n++
+		}
+		var ids []byte
+		frames := runtime.CallersFrames(stack[:n])
+		for {
+			frame, more := frames.Next()
+			l := location{frame.Function, frame.File, frame.Line}
+			id, ok := locationIDs[l]
+			if !ok {
+				functionID, ok := functionIDs[frame.Function]
+				if !ok {
+					functionID = uint64(len(functionIDs) + 1)
+					functionIDs[frame.Function] = functionID
+					var function []byte
+					function = protowire.AppendTag(function, 1, protowire.VarintType) // id
+					function = protowire.AppendVarint(function, functionID)
+					function = protowire.AppendTag(function, 2, protowire.VarintType) // name
+					function = protowire.AppendVarint(function, str(frame.Function))
+					function = protowire.AppendTag(function, 3, protowire.VarintType) // system_name
+					function = protowire.AppendVarint(function, str(frame.Function))
+					function = protowire.AppendTag(function, 4, protowire.VarintType) // filename
+					function = protowire.AppendVarint(function, str(frame.File))
+					functions = protowire.AppendTag(functions, 5, protowire.BytesType)
+					functions = protowire.AppendBytes(functions, function)
+				}
+				id = uint64(len(locationIDs) + 1)
+				locationIDs[l] = id
+				var line []byte
+				line = protowire.AppendTag(line, 1, protowire.VarintType) // function_id
+				line = protowire.AppendVarint(line, functionID)
+				line = protowire.AppendTag(line, 2, protowire.VarintType) // line
+				line = protowire.AppendVarint(line, uint64(frame.Line))
+				var loc []byte
+				loc = protowire.AppendTag(loc, 1, protowire.VarintType) // id
+				loc = protowire.AppendVarint(loc, id)
+				loc = protowire.AppendTag(loc, 4, protowire.BytesType) // line
+				loc = protowire.AppendBytes(loc, line)
+				locations = protowire.AppendTag(locations, 4, protowire.BytesType)
+				locations = protowire.AppendBytes(locations, loc)
+			}
+			ids = protowire.AppendVarint(ids, id)
+			if !more {
+				break
+			}
+		}
+		var sample []byte
+		sample = protowire.AppendTag(sample, 1, protowire.BytesType) // location_id, leaf first
+		sample = protowire.AppendBytes(sample, ids)
+		sample = protowire.AppendTag(sample, 2, protowire.BytesType) // value
+		sample = protowire.AppendBytes(sample, protowire.AppendVarint(nil, uint64(count)))
+		samples = protowire.AppendTag(samples, 2, protowire.BytesType)
+		samples = protowire.AppendBytes(samples, sample)
+	}
+
+	var valueType []byte
+	valueType = protowire.AppendTag(valueType, 1, protowire.VarintType) // type
+	valueType = protowire.AppendVarint(valueType, str("constraints"))
+	valueType = protowire.AppendTag(valueType, 2, protowire.VarintType) // unit
+	valueType = protowire.AppendVarint(valueType, str("count"))
+
+	var res []byte
+	res = protowire.AppendTag(res, 1, protowire.BytesType) // sample_type
+	res = protowire.AppendBytes(res, valueType)
+	res = append(res, samples...)
+	res = append(res, locations...)
+	res = append(res, functions...)
+	for _, s := range strings {
+		res = protowire.AppendTag(res, 6, protowire.BytesType) // string_table
+		res = protowire.AppendString(res, s)
+	}
+	res = protowire.AppendTag(res, 9, protowire.VarintType) // time_nanos
+	res = protowire.AppendVarint(res, uint64(time.Now().UnixNano()))
+
+	zw := gzip.NewWriter(p.w)
+	if _, err := zw.Write(res); err != nil {
+		return err
+	}
+	return zw.Close()
+}
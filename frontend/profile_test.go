@@ -0,0 +1,57 @@
+package frontend
+
+import (
+	"bytes"
+	"compress/gzip"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/consensys/gurvy"
+)
+
+func TestProfile(t *testing.T) {
+
+	var buf bytes.Buffer
+	var cs *ConstraintSystem
+	keep := func(_cs *ConstraintSystem) error { cs = _cs; return nil }
+	_r1cs, err := Compile(gurvy.BN256, &locationCircuit{}, WithProfile(&buf), keep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gzip.NewReader(&buf); err != nil {
+		t.Fatal("the profile should be gzipped", err)
+	}
+
+	nbConstraints, functions := profileSamples(cs.profile)
+	if nbConstraints != int(_r1cs.GetNbConstraints()) {
+		t.Fatal("the profile should count each constraint once", nbConstraints)
+	}
+	foundDefine := false
+	for _, f := range functions {
+		foundDefine = foundDefine || strings.HasSuffix(f, "(*locationCircuit).Define")
+	}
+	if !foundDefine {
+		t.Fatal("the constraints should be attributed to the Define method of the circuit")
+	}
+}
+
+// profileSamples returns the sum of the values of the samples of p, and the functions of their call stacks
+func profileSamples(p *profile) (nbConstraints int, functions []string) {
+	for stack, count := range p.samples {
+		nbConstraints += count
+		n := 0
+		for n < len(stack) && stack[n] != 0 {
+			n++
+		}
+		frames := runtime.CallersFrames(stack[:n])
+		for {
+			frame, more := frames.Next()
+			functions = append(functions, frame.Function)
+			if !more {
+				break
+			}
+		}
+	}
+	return
+}
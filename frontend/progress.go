@@ -0,0 +1,132 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package frontend
+
+import (
+	"context"
+	"errors"
+)
+
+// defaultProgressInterval is the number of constraints recorded between two checks of the context of the
+// compilation
+const defaultProgressInterval = 1 << 14
+
+// Progress is the state of a compilation, reported by WithProgress
+type Progress struct {
+	NbConstraints int // computational constraints and assertions recorded
+	NbWires       int // public, secret and internal wires allocated
+}
+
+// progress stops the compilation when its context is done, and reports its progress (see WithContext,
+// WithProgress)
+type progress struct {
+	ctx      context.Context
+	f        func(Progress)
+	interval int // number of constraints between two checks
+	next     int // number of constraints of the next check
+}
+
+// compileCanceled is the panic value of a compilation whose context is done, recovered by Compile
+type compileCanceled struct {
+	err error
+}
+
+// WithContext stops the compilation once ctx is done: Compile returns ctx.Err()
+//
+// ctx is checked before and after Define, and while Define records the constraints (every few thousand
+// constraints, see WithProgress): Define is interrupted, and the functions registered with Defer are
+// not called
+func WithContext(ctx context.Context) CompileOption {
+	return func(cs *ConstraintSystem) error {
+		if ctx == nil {
+			return errors.New("WithContext: nil context")
+		}
+		cs.getProgress().ctx = ctx
+		return nil
+	}
+}
+
+// WithProgress calls f every interval constraints recorded (interval > 0), and once Define returns;
+// the context of WithContext is checked at the same interval
+func WithProgress(interval int, f func(Progress)) CompileOption {
+	return func(cs *ConstraintSystem) error {
+		if interval <= 0 {
+			return errors.New("WithProgress: the interval must be positive")
+		}
+		p := cs.getProgress()
+		p.f = f
+		p.interval, p.next = interval, interval
+		return nil
+	}
+}
+
+func (cs *ConstraintSystem) getProgress() *progress {
+	if cs.progress == nil {
+		cs.progress = &progress{interval: defaultProgressInterval, next: defaultProgressInterval}
+	}
+	return cs.progress
+}
+
+// currentProgress returns the state of the compilation
+func (cs *ConstraintSystem) currentProgress() Progress {
+	return Progress{
+		NbConstraints: len(cs.constraints) + len(cs.assertions),
+		NbWires:       len(cs.public.variables) + len(cs.secret.variables) + len(cs.internal.variables),
+	}
+}
+
+// checkProgress is called when a constraint is recorded: every interval constraints, it panics with
+// compileCanceled if the context is done, and reports the progress
+func (cs *ConstraintSystem) checkProgress() {
+	p := cs.progress
+	n := len(cs.constraints) + len(cs.assertions)
+	if n < p.next {
+		return
+	}
+	p.next = n + p.interval
+	if err := p.err(); err != nil {
+		panic(compileCanceled{err})
+	}
+	cs.reportProgress()
+}
+
+// reportProgress calls the function of WithProgress, if any
+func (cs *ConstraintSystem) reportProgress() {
+	if cs.progress != nil && cs.progress.f != nil {
+		cs.progress.f(cs.currentProgress())
+	}
+}
+
+// err returns the error of the context, if it is done
+func (p *progress) err() error {
+	if p == nil || p.ctx == nil {
+		return nil
+	}
+	return p.ctx.Err()
+}
+
+// recoverCanceled sets *err to the error of a compilation whose context is done; other panics are
+// propagated
+func recoverCanceled(err *error) {
+	if r := recover(); r != nil {
+		c, ok := r.(compileCanceled)
+		if !ok {
+			panic(r)
+		}
+		*err = c.err
+	}
+}
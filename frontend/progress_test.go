@@ -0,0 +1,96 @@
+package frontend
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/consensys/gurvy"
+)
+
+type chainCircuit struct {
+	X Variable
+	Y Variable `gnark:",public"`
+	n int
+}
+
+func (circuit *chainCircuit) Define(curveID gurvy.ID, cs *ConstraintSystem) error {
+	x := circuit.X
+	for i := 0; i < circuit.n; i++ {
+		x = cs.Mul(x, x)
+	}
+	cs.AssertIsEqual(x, circuit.Y)
+	return nil
+}
+
+func TestCompileProgress(t *testing.T) {
+	var reports []Progress
+	_, err := Compile(gurvy.BN256, &chainCircuit{n: 999}, WithProgress(100, func(p Progress) {
+		reports = append(reports, p)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 11 {
+		t.Fatal("expected 10 reports while recording the constraints, and a last one, got", len(reports))
+	}
+	for i := 0; i < 10; i++ {
+		if reports[i].NbConstraints != 100*(i+1) {
+			t.Fatal("unexpected number of constraints", reports[i])
+		}
+	}
+	if last := reports[10]; last.NbConstraints != 1000 || last.NbWires != 1002 {
+		t.Fatal("unexpected last report", last)
+	}
+
+	// the compilation stops once the context is done
+	ctx, cancel := context.WithCancel(context.Background())
+	deferred := false
+	_, err = Compile(gurvy.BN256, &interruptedCircuit{n: 999, called: &deferred}, WithContext(ctx), WithProgress(100, func(p Progress) {
+		if p.NbConstraints >= 500 {
+			cancel()
+		}
+	}))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatal("expected context.Canceled, got", err)
+	}
+	if deferred {
+		t.Fatal("the deferred functions of an interrupted compilation must not be called")
+	}
+	if _, err := Compile(gurvy.BN256, &chainCircuit{n: 1}, WithContext(ctx)); !errors.Is(err, context.Canceled) {
+		t.Fatal("expected context.Canceled, got", err)
+	}
+
+	// other panics are propagated
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected the panic of Define to be propagated")
+		}
+	}()
+	_, _ = Compile(gurvy.BN256, &panicCircuit{}, WithContext(context.Background()))
+}
+
+type interruptedCircuit struct {
+	X      Variable
+	n      int
+	called *bool
+}
+
+func (circuit *interruptedCircuit) Define(curveID gurvy.ID, cs *ConstraintSystem) error {
+	cs.Defer(func(cs *ConstraintSystem) {
+		*circuit.called = true
+	})
+	x := circuit.X
+	for i := 0; i < circuit.n; i++ {
+		x = cs.Mul(x, x)
+	}
+	return nil
+}
+
+type panicCircuit struct {
+	X Variable
+}
+
+func (circuit *panicCircuit) Define(curveID gurvy.ID, cs *ConstraintSystem) error {
+	panic("invalid circuit")
+}
@@ -14,8 +14,9 @@ limitations under the License.
 package frontend
 
 import (
-	"fmt"
 	"reflect"
+	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"unicode"
@@ -38,24 +39,23 @@ type Wire struct {
 // circuit when there is no other choice (to avoid wasting wires doing only linear expressions)
 type Variable struct {
 	Wire
-	linExp    r1c.LinearExpression
-	isBoolean bool // TODO it doesn't work, we need a pointer for that
+	linExp r1c.LinearExpression
 }
 
 // Assign v = value . This must called when using a Circuit as a witness data structure
+//
+// value is converted to a big.Int (see backend.ToBigInt for the supported types: integers, decimal and
+// hex strings, big endian bytes, field elements, ...); Assign panics if it can't be converted. A value
+// which doesn't fit in the scalar field is rejected by the solver (see backend.ErrInputOverflow)
 func (v *Variable) Assign(value interface{}) {
 	if v.val != nil {
 		panic("variable already assigned")
 	}
-	v.val = value
-}
-
-// getCopyLinExp returns a copy of the linear expression
-// to avoid sharing same data, leading to bugs when updating the variables id
-func (v *Variable) getLinExpCopy() r1c.LinearExpression {
-	res := make(r1c.LinearExpression, len(v.linExp))
-	copy(res, v.linExp)
-	return res
+	val, err := backend.ToBigInt(value)
+	if err != nil {
+		panic("can't assign variable: " + err.Error())
+	}
+	v.val = val
 }
 
 // Tag is a (optional) struct tag one can add to Variable
@@ -75,6 +75,27 @@ func (v *Variable) getLinExpCopy() r1c.LinearExpression {
 //			Z frontend.Variable `gnark:"-"`
 // 		}
 // it is then the developer responsability to do circuit.Z = circuit.Y in the Define() method
+//
+// the values of a map with string keys (map[string]frontend.Variable, or a map of structs) are named
+// after their keys, as the elements of a slice after their index; the keys are allocated in sorted
+// order. A map value is not addressable: it is assigned through a copy, stored back in the map
+//
+// a slice of Variables tagged with maxlen, for example
+// 		type MyCircuit struct {
+// 			Msg []frontend.Variable `gnark:",public,maxlen=64"`
+// 		}
+// has a variable length: Compile allocates 64 wires (Msg_0 to Msg_63) and the length wire Msg_len,
+// constrained to be at most 64 (see ConstraintSystem.Len); a witness assigns at most 64 elements, the
+// missing ones are 0, and the elements from index Msg_len are constrained to be 0
+//
+// the inputs of a field tagged with bits or range, for example
+// 		type MyCircuit struct {
+// 			Msg []frontend.Variable `gnark:",public,bits=8"`
+// 			Age frontend.Variable   `gnark:",range=18..120"`
+// 		}
+// are bounded by constraints recorded by Compile: each element of Msg fits in 8 bits, and
+// 18 <= Age <= 120 (the bounds are integers, in base 10 or, with the prefix "0x", in base 16). bits and
+// the bounds of range are at most 252 bits, so that the decompositions bounding the inputs are unique
 type Tag string
 
 const (
@@ -83,11 +104,15 @@ const (
 	optSecret Tag = "secret"
 	optEmbed  Tag = "embed"
 	optOmit   Tag = "-"
+	optMaxLen Tag = "maxlen"
+	optBits   Tag = "bits"
+	optRange  Tag = "range"
 )
 
 type leafHandler func(visibility backend.Visibility, name string, tValue reflect.Value) error
 
-func parseType(input interface{}, baseName string, parentVisibility backend.Visibility, handler leafHandler) error {
+// parseType calls handler on each Variable of input; the fields which can't be parsed are reported to warn
+func parseType(input interface{}, baseName string, parentVisibility backend.Visibility, handler leafHandler, warn warnFunc) error {
 
 	// types we are lOoutputoking for
 	tVariable := reflect.TypeOf(Variable{})
@@ -110,42 +135,17 @@ func parseType(input interface{}, baseName string, parentVisibility backend.Visi
 		default:
 			for i := 0; i < tValue.NumField(); i++ {
 				field := tValue.Type().Field((i))
-
-				// get gnark tag
-				tag := field.Tag.Get(string(tagKey))
-				if tag == string(optOmit) {
+				name, visibility, omit := parseField(field, parentVisibility)
+				if omit {
 					continue // skipping "-"
 				}
 
-				visibility := backend.Secret
-				name := field.Name
-				if tag != "" {
-					// gnark tag is set
-					var opts tagOptions
-					name, opts = parseTag(tag)
-					if !isValidTag(name) {
-						name = field.Name
-					}
-
-					if opts.Contains(string(optSecret)) {
-						visibility = backend.Secret
-					} else if opts.Contains(string(optPublic)) {
-						visibility = backend.Public
-					} else if opts.Contains(string(optEmbed)) {
-						name = ""
-						visibility = backend.Unset
-					}
-				}
-				if parentVisibility != backend.Unset {
-					visibility = parentVisibility // parent visibility overhides
-				}
-
 				fullName := appendName(baseName, name)
 
 				f := tValue.FieldByName(field.Name)
 				if f.CanAddr() && f.Addr().CanInterface() {
 					value := f.Addr().Interface()
-					if err := parseType(value, fullName, visibility, handler); err != nil {
+					if err := parseType(value, fullName, visibility, handler, warn); err != nil {
 						return err
 					}
 				} else {
@@ -153,7 +153,9 @@ func parseType(input interface{}, baseName string, parentVisibility backend.Visi
 						f = f.Elem()
 					}
 					if (f.Kind() == reflect.Struct) && (f.Type() == tVariable) {
-						fmt.Println("warning: Variable is unexported or unadressable", fullName)
+						if err := warn("Variable is unexported or unadressable: " + fullName); err != nil {
+							return err
+						}
 					}
 				}
 			}
@@ -161,26 +163,144 @@ func parseType(input interface{}, baseName string, parentVisibility backend.Visi
 
 	case reflect.Slice, reflect.Array:
 		if tValue.Len() == 0 {
-			fmt.Println("warning, got unitizalized slice (or empty array). Ignoring;")
-			return nil
+			return warn("uninitialized slice (or empty array), ignoring: " + baseName)
 		}
 		for j := 0; j < tValue.Len(); j++ {
 
 			val := tValue.Index(j)
 			if val.CanAddr() && val.Addr().CanInterface() {
-				if err := parseType(val.Addr().Interface(), appendName(baseName, strconv.Itoa(j)), parentVisibility, handler); err != nil {
+				if err := parseType(val.Addr().Interface(), appendName(baseName, strconv.Itoa(j)), parentVisibility, handler, warn); err != nil {
 					return err
 				}
 			}
 
 		}
 	case reflect.Map:
-		fmt.Println("warning: map values are not addressable, ignoring")
+		if tValue.Type().Key().Kind() != reflect.String {
+			return warn("only maps with string keys are supported, ignoring: " + baseName)
+		}
+		// the keys are visited in sorted order, so that the wires are allocated deterministically
+		keys := tValue.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		for _, key := range keys {
+			// map values are not addressable: the value is parsed in a copy, which is stored back
+			val := reflect.New(tValue.Type().Elem())
+			val.Elem().Set(tValue.MapIndex(key))
+			if err := parseType(val.Interface(), appendName(baseName, key.String()), parentVisibility, handler, warn); err != nil {
+				return err
+			}
+			tValue.SetMapIndex(key, val.Elem())
+		}
 	}
 
 	return nil
 }
 
+// parseField returns the name and visibility of a struct field, from its gnark tag (see Tag)
+// omit is set if the field must be ignored
+func parseField(field reflect.StructField, parentVisibility backend.Visibility) (name string, visibility backend.Visibility, omit bool) {
+	tag := field.Tag.Get(string(tagKey))
+	if tag == string(optOmit) {
+		return "", backend.Unset, true
+	}
+
+	visibility = backend.Secret
+	name = field.Name
+	if tag != "" {
+		// gnark tag is set
+		var opts tagOptions
+		name, opts = parseTag(tag)
+		if !isValidTag(name) {
+			name = field.Name
+		}
+
+		if opts.Contains(string(optSecret)) {
+			visibility = backend.Secret
+		} else if opts.Contains(string(optPublic)) {
+			visibility = backend.Public
+		} else if opts.Contains(string(optEmbed)) {
+			name = ""
+			visibility = backend.Unset
+		}
+	}
+	if parentVisibility != backend.Unset {
+		visibility = parentVisibility // parent visibility overhides
+	}
+	return name, visibility, false
+}
+
+// parseTask parses a part of a circuit (see splitParseType)
+type parseTask func(handler leafHandler, warn warnFunc) error
+
+// parallelParseThreshold is the minimum length of a slice for its elements to be parsed in parallel
+const parallelParseThreshold = 1 << 10
+
+// splitParseType splits parseType(input, baseName, parentVisibility, handler) in independent tasks:
+// one per top level struct field, and large slices (or arrays) are split by chunks of elements
+//
+// the tasks visit the same leaves as parseType, and may run concurrently as long as the handler doesn't depend
+// on the visiting order (that's not the case of Compile, that allocates the variables)
+func splitParseType(input interface{}, baseName string, parentVisibility backend.Visibility) []parseTask {
+	single := []parseTask{func(handler leafHandler, warn warnFunc) error {
+		return parseType(input, baseName, parentVisibility, handler, warn)
+	}}
+
+	tValue := reflect.ValueOf(input)
+	if tValue.Kind() == reflect.Ptr {
+		tValue = tValue.Elem()
+	}
+	if tValue.Kind() != reflect.Struct || tValue.Type() == reflect.TypeOf(Variable{}) {
+		return single
+	}
+
+	var tasks []parseTask
+	for i := 0; i < tValue.NumField(); i++ {
+		field := tValue.Type().Field(i)
+		name, visibility, omit := parseField(field, parentVisibility)
+		if omit {
+			continue
+		}
+		fullName := appendName(baseName, name)
+		f := tValue.Field(i)
+		if !f.CanAddr() || !f.Addr().CanInterface() {
+			// parseType reports the warnings
+			continue
+		}
+
+		if (f.Kind() == reflect.Slice || f.Kind() == reflect.Array) && f.Len() >= parallelParseThreshold {
+			chunkSize := f.Len() / runtime.NumCPU()
+			if chunkSize < parallelParseThreshold {
+				chunkSize = parallelParseThreshold
+			}
+			for start := 0; start < f.Len(); start += chunkSize {
+				end := start + chunkSize
+				if end > f.Len() {
+					end = f.Len()
+				}
+				start, f := start, f
+				tasks = append(tasks, func(handler leafHandler, warn warnFunc) error {
+					for j := start; j < end; j++ {
+						val := f.Index(j)
+						if val.CanAddr() && val.Addr().CanInterface() {
+							if err := parseType(val.Addr().Interface(), appendName(fullName, strconv.Itoa(j)), visibility, handler, warn); err != nil {
+								return err
+							}
+						}
+					}
+					return nil
+				})
+			}
+			continue
+		}
+
+		value := f.Addr().Interface()
+		tasks = append(tasks, func(handler leafHandler, warn warnFunc) error {
+			return parseType(value, fullName, visibility, handler, warn)
+		})
+	}
+	return tasks
+}
+
 func appendName(baseName, name string) string {
 	if baseName == "" {
 		return name
@@ -224,6 +344,16 @@ func (o tagOptions) Contains(optionName string) bool {
 	return false
 }
 
+// Value returns the value of an option "optionName=value" of a comma-separated list of options
+func (o tagOptions) Value(optionName string) (string, bool) {
+	for _, opt := range strings.Split(string(o), ",") {
+		if kv := strings.SplitN(strings.TrimSpace(opt), "=", 2); len(kv) == 2 && kv[0] == optionName {
+			return kv[1], true
+		}
+	}
+	return "", false
+}
+
 func isValidTag(s string) bool {
 	if s == "" {
 		return false
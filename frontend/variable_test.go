@@ -3,10 +3,13 @@ package frontend
 import (
 	"errors"
 	"fmt"
+	"math/big"
 	"reflect"
 	"testing"
 
 	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gurvy"
+	"github.com/consensys/gurvy/bn256/fr"
 )
 
 func TestStructTags(t *testing.T) {
@@ -20,7 +23,7 @@ func TestStructTags(t *testing.T) {
 			collected[name] = visibility
 			return nil
 		}
-		if err := parseType(input, "", backend.Unset, collectHandler); err != nil {
+		if err := parseType(input, "", backend.Unset, collectHandler, printWarning); err != nil {
 			t.Fatal(err)
 		}
 
@@ -160,4 +163,152 @@ func TestStructTags(t *testing.T) {
 		testParseType(&s, expected)
 	}
 
+	// map, of Variables or of structs
+	{
+		s := struct {
+			A map[string]Variable `gnark:",public"`
+			B map[string]struct {
+				X Variable
+			}
+		}{A: map[string]Variable{"x": {}, "y": {}}, B: map[string]struct{ X Variable }{"z": {}}}
+		expected := make(map[string]backend.Visibility)
+		expected["A_x"] = backend.Public
+		expected["A_y"] = backend.Public
+		expected["B_z_X"] = backend.Secret
+		testParseType(&s, expected)
+	}
+
+}
+
+type largeWitness struct {
+	X     Variable
+	Y     Variable `gnark:",public"`
+	Z     [3]Variable
+	Inner struct {
+		W []Variable `gnark:",public"`
+	}
+	Leaves []Variable
+}
+
+func (circuit *largeWitness) Define(curveID gurvy.ID, cs *ConstraintSystem) error {
+	return nil
+}
+
+func TestParseWitnessParallel(t *testing.T) {
+	var witness largeWitness
+	witness.X.Assign(1)
+	witness.Y.Assign(2)
+	for i := 0; i < len(witness.Z); i++ {
+		witness.Z[i].Assign(3 + i)
+	}
+	witness.Inner.W = make([]Variable, 2)
+	witness.Inner.W[0].Assign(10)
+	witness.Inner.W[1].Assign(11)
+	witness.Leaves = make([]Variable, 3*parallelParseThreshold+5)
+	for i := 0; i < len(witness.Leaves); i++ {
+		witness.Leaves[i].Assign(i)
+	}
+
+	// the split tasks must visit the same leaves as the sequential walk
+	expected := make(map[string]backend.Visibility)
+	if err := parseType(&witness, "", backend.Unset, func(visibility backend.Visibility, name string, tInput reflect.Value) error {
+		expected[name] = visibility
+		return nil
+	}, printWarning); err != nil {
+		t.Fatal(err)
+	}
+	collected := make(map[string]backend.Visibility)
+	tasks := splitParseType(&witness, "", backend.Unset)
+	if len(tasks) < 4 {
+		t.Fatal("expected the large slice to be split, got", len(tasks), "tasks")
+	}
+	for _, task := range tasks {
+		if err := task(func(visibility backend.Visibility, name string, tInput reflect.Value) error {
+			if _, ok := collected[name]; ok {
+				return errors.New("duplicate name collected")
+			}
+			collected[name] = visibility
+			return nil
+		}, printWarning); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !reflect.DeepEqual(expected, collected) {
+		t.Fatal("split tasks didn't collect the same leaves than parseType")
+	}
+
+	values, err := ParseWitness(&witness)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != len(expected) {
+		t.Fatal("expected", len(expected), "values, got", len(values))
+	}
+	// the values are converted to big.Int by Assign
+	value := func(name string) int64 {
+		v := values[name].(big.Int)
+		return v.Int64()
+	}
+	if value("Inner_W_1") != 11 || value("Leaves_2000") != 2000 || value("Z_2") != 5 {
+		t.Fatal("wrong witness values")
+	}
+}
+
+func TestParseMapSorted(t *testing.T) {
+	s := struct {
+		M map[string]Variable
+	}{M: map[string]Variable{"c": {}, "a": {}, "b": {}, "aa": {}}}
+
+	// the keys are visited in sorted order, and the values set by the handler are stored in the map
+	var names []string
+	if err := parseType(&s, "", backend.Unset, func(visibility backend.Visibility, name string, tInput reflect.Value) error {
+		names = append(names, name)
+		v := tInput.Interface().(Variable)
+		v.id = len(names)
+		tInput.Set(reflect.ValueOf(v))
+		return nil
+	}, printWarning); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(names, []string{"M_a", "M_aa", "M_b", "M_c"}) {
+		t.Fatal("unexpected order", names)
+	}
+	if s.M["a"].id != 1 || s.M["c"].id != 4 {
+		t.Fatal("the values of the map should be set")
+	}
+}
+
+func TestInputOverflow(t *testing.T) {
+
+	_r1cs, err := Compile(gurvy.BN256, &namespaceCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// p+3 is 3 in the field, but doesn't fit in it
+	var tooLarge big.Int
+	tooLarge.Add(fr.Modulus(), big.NewInt(3))
+	if err := _r1cs.IsSolved(map[string]interface{}{"X": tooLarge}); !errors.Is(err, backend.ErrInputOverflow) {
+		t.Fatal("expected ErrInputOverflow, got", err)
+	}
+	var witness namespaceCircuit
+	witness.X.Assign(tooLarge)
+	if err := Evaluate(gurvy.BN256, &witness); !errors.Is(err, backend.ErrInputOverflow) {
+		t.Fatal("expected ErrInputOverflow, got", err)
+	}
+
+	// the values are converted by Assign, a negative value -x is p-x
+	var threeMinusP big.Int
+	threeMinusP.Sub(big.NewInt(3), fr.Modulus())
+	for _, value := range []interface{}{"0x3", int8(3), uint16(3), threeMinusP} {
+		var witness namespaceCircuit
+		witness.X.Assign(value)
+		assignment, err := ParseWitness(&witness)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := _r1cs.IsSolved(assignment); err != nil {
+			t.Fatal(value, err)
+		}
+	}
 }
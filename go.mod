@@ -6,8 +6,12 @@ require (
 	github.com/consensys/bavard v0.1.7
 	github.com/consensys/gurvy v0.3.6
 	github.com/fxamacker/cbor/v2 v2.2.0
+	github.com/golang/protobuf v1.4.2
+	github.com/klauspost/compress v1.11.13
 	github.com/leanovate/gopter v0.2.8
 	github.com/stretchr/testify v1.6.1
 	golang.org/x/crypto v0.0.0-20200709230013-948cd5f35899
+	google.golang.org/grpc v1.27.0
+	google.golang.org/protobuf v1.25.0
 	gopkg.in/yaml.v3 v3.0.0-20200615113413-eeeca48fe776 // indirect
 )
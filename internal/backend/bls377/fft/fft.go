@@ -17,6 +17,7 @@
 package fft
 
 import (
+	"math/big"
 	"math/bits"
 	"runtime"
 
@@ -36,13 +37,52 @@ const (
 // parallelize threshold for a single butterfly op, if the fft stage is not parallelized already
 const butterflyThreshold = 16
 
+// Option configures the FFTs of this package
+type Option func(*fftConfig)
+
+type fftConfig struct {
+	nbTasks int
+}
+
+// WithNbTasks caps the number of goroutines used by the FFT (runtime.NumCPU() by default)
+func WithNbTasks(nbTasks int) Option {
+	return func(config *fftConfig) {
+		if nbTasks < 1 {
+			nbTasks = 1
+		}
+		config.nbTasks = nbTasks
+	}
+}
+
+func newFFTConfig(opts []Option) fftConfig {
+	config := fftConfig{nbTasks: runtime.NumCPU()}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	return config
+}
+
 // FFT computes (recursively) the discrete Fourier transform of a and stores the result in a
 // if decimation == DIT (decimation in time), the input must be in bit-reversed order
 // if decimation == DIF (decimation in frequency), the output will be in bit-reversed order
+// if coset is set, the FFT is computed on the coset GeneratorSqRt * <Generator> (ie a is first scaled by GeneratorSqRt^i)
 // len(a) must be a power of 2, and w must be a len(a)th root of unity in field F.
-func (domain *Domain) FFT(a []fr.Element, decimation Decimation) {
-
-	numCPU := uint64(runtime.NumCPU())
+func (domain *Domain) FFT(a []fr.Element, decimation Decimation, coset bool, opts ...Option) {
+	config := newFFTConfig(opts)
+	numCPU := uint64(config.nbTasks)
+
+	if coset {
+		if decimation == DIT {
+			// the input is in bit-reversed order, as the coset table
+			utils.Parallelize(len(a), func(start, end int) {
+				for i := start; i < end; i++ {
+					a[i].Mul(&a[i], &domain.CosetTable[i])
+				}
+			})
+		} else {
+			scaleByPowers(a, domain.GeneratorSqRt, fr.One(), config.nbTasks)
+		}
+	}
 
 	// find the stage where we should stop spawning go routines in our recursive calls
 	// (ie when we have as many go routines running as we have available CPUs)
@@ -53,9 +93,9 @@ func (domain *Domain) FFT(a []fr.Element, decimation Decimation) {
 
 	switch decimation {
 	case DIF:
-		difFFT(a, domain.Twiddles, 0, maxSplits, nil)
+		difFFT(a, domain.Twiddles, 0, maxSplits, config.nbTasks, nil)
 	case DIT:
-		ditFFT(a, domain.Twiddles, 0, maxSplits, nil)
+		ditFFT(a, domain.Twiddles, 0, maxSplits, config.nbTasks, nil)
 	default:
 		panic("not implemented")
 	}
@@ -64,10 +104,11 @@ func (domain *Domain) FFT(a []fr.Element, decimation Decimation) {
 // FFTInverse computes (recursively) the inverse discrete Fourier transform of a and stores the result in a
 // if decimation == DIT (decimation in time), the input must be in bit-reversed order
 // if decimation == DIF (decimation in frequency), the output will be in bit-reversed order
+// if coset is set, the inverse FFT is computed on the coset GeneratorSqRt * <Generator> (ie the result is scaled by GeneratorSqRtInv^i)
 // len(a) must be a power of 2, and w must be a len(a)th root of unity in field F.
-func (domain *Domain) FFTInverse(a []fr.Element, decimation Decimation) {
-
-	numCPU := uint64(runtime.NumCPU())
+func (domain *Domain) FFTInverse(a []fr.Element, decimation Decimation, coset bool, opts ...Option) {
+	config := newFFTConfig(opts)
+	numCPU := uint64(config.nbTasks)
 
 	// find the stage where we should stop spawning go routines in our recursive calls
 	// (ie when we have as many go routines running as we have available CPUs)
@@ -77,22 +118,48 @@ func (domain *Domain) FFTInverse(a []fr.Element, decimation Decimation) {
 	}
 	switch decimation {
 	case DIF:
-		difFFT(a, domain.TwiddlesInv, 0, maxSplits, nil)
+		difFFT(a, domain.TwiddlesInv, 0, maxSplits, config.nbTasks, nil)
 	case DIT:
-		ditFFT(a, domain.TwiddlesInv, 0, maxSplits, nil)
+		ditFFT(a, domain.TwiddlesInv, 0, maxSplits, config.nbTasks, nil)
 	default:
 		panic("not implemented")
 	}
 
-	// scale by CardinalityInv
+	// scale by CardinalityInv (and by the coset powers in the same pass)
+	switch {
+	case !coset:
+		utils.Parallelize(len(a), func(start, end int) {
+			for i := start; i < end; i++ {
+				a[i].MulAssign(&domain.CardinalityInv)
+			}
+		}, config.nbTasks)
+	case decimation == DIF:
+		// the output is in bit-reversed order, as the coset table
+		utils.Parallelize(len(a), func(start, end int) {
+			for i := start; i < end; i++ {
+				a[i].Mul(&a[i], &domain.CosetTableInv[i]).
+					MulAssign(&domain.CardinalityInv)
+			}
+		}, config.nbTasks)
+	default:
+		scaleByPowers(a, domain.GeneratorSqRtInv, domain.CardinalityInv, config.nbTasks)
+	}
+}
+
+// scaleByPowers sets a[i] = a[i] * c * x^i
+func scaleByPowers(a []fr.Element, x, c fr.Element, nbTasks int) {
 	utils.Parallelize(len(a), func(start, end int) {
+		var xi fr.Element
+		xi.Exp(x, new(big.Int).SetUint64(uint64(start))).
+			MulAssign(&c)
 		for i := start; i < end; i++ {
-			a[i].MulAssign(&domain.CardinalityInv)
+			a[i].MulAssign(&xi)
+			xi.MulAssign(&x)
 		}
-	})
+	}, nbTasks)
 }
 
-func difFFT(a []fr.Element, twiddles [][]fr.Element, stage, maxSplits int, chDone chan struct{}) {
+func difFFT(a []fr.Element, twiddles [][]fr.Element, stage, maxSplits, nbTasks int, chDone chan struct{}) {
 	if chDone != nil {
 		defer func() {
 			chDone <- struct{}{}
@@ -108,7 +175,7 @@ func difFFT(a []fr.Element, twiddles [][]fr.Element, stage, maxSplits int, chDon
 	// but we have only numCPU / stage cpus available
 	if (m > butterflyThreshold) && (stage < maxSplits) {
 		// 1 << stage == estimated used CPUs
-		numCPU := runtime.NumCPU() / (1 << (stage))
+		numCPU := nbTasks / (1 << (stage))
 		utils.Parallelize(m, func(start, end int) {
 			var t fr.Element
 			for i := start; i < end; i++ {
@@ -145,16 +212,16 @@ func difFFT(a []fr.Element, twiddles [][]fr.Element, stage, maxSplits int, chDon
 	nextStage := stage + 1
 	if stage < maxSplits {
 		chDone := make(chan struct{}, 1)
-		go difFFT(a[m:n], twiddles, nextStage, maxSplits, chDone)
-		difFFT(a[0:m], twiddles, nextStage, maxSplits, nil)
+		go difFFT(a[m:n], twiddles, nextStage, maxSplits, nbTasks, chDone)
+		difFFT(a[0:m], twiddles, nextStage, maxSplits, nbTasks, nil)
 		<-chDone
 	} else {
-		difFFT(a[0:m], twiddles, nextStage, maxSplits, nil)
-		difFFT(a[m:n], twiddles, nextStage, maxSplits, nil)
+		difFFT(a[0:m], twiddles, nextStage, maxSplits, nbTasks, nil)
+		difFFT(a[m:n], twiddles, nextStage, maxSplits, nbTasks, nil)
 	}
 }
 
-func ditFFT(a []fr.Element, twiddles [][]fr.Element, stage, maxSplits int, chDone chan struct{}) {
+func ditFFT(a []fr.Element, twiddles [][]fr.Element, stage, maxSplits, nbTasks int, chDone chan struct{}) {
 	if chDone != nil {
 		defer func() {
 			chDone <- struct{}{}
@@ -171,12 +238,12 @@ func ditFFT(a []fr.Element, twiddles [][]fr.Element, stage, maxSplits int, chDon
 	if stage < maxSplits {
 		// that's the only time we fire go routines
 		chDone := make(chan struct{}, 1)
-		go ditFFT(a[m:], twiddles, nextStage, maxSplits, chDone)
-		ditFFT(a[0:m], twiddles, nextStage, maxSplits, nil)
+		go ditFFT(a[m:], twiddles, nextStage, maxSplits, nbTasks, chDone)
+		ditFFT(a[0:m], twiddles, nextStage, maxSplits, nbTasks, nil)
 		<-chDone
 	} else {
-		ditFFT(a[0:m], twiddles, nextStage, maxSplits, nil)
-		ditFFT(a[m:n], twiddles, nextStage, maxSplits, nil)
+		ditFFT(a[0:m], twiddles, nextStage, maxSplits, nbTasks, nil)
+		ditFFT(a[m:n], twiddles, nextStage, maxSplits, nbTasks, nil)
 
 	}
 
@@ -184,7 +251,7 @@ func ditFFT(a []fr.Element, twiddles [][]fr.Element, stage, maxSplits int, chDon
 	// but we have only numCPU / stage cpus available
 	if (m > butterflyThreshold) && (stage < maxSplits) {
 		// 1 << stage == estimated used CPUs
-		numCPU := runtime.NumCPU() / (1 << (stage))
+		numCPU := nbTasks / (1 << (stage))
 		utils.Parallelize(m, func(start, end int) {
 			var t, tm fr.Element
 			for k := start; k < end; k++ {
@@ -214,8 +281,15 @@ func ditFFT(a []fr.Element, twiddles [][]fr.Element, stage, maxSplits int, chDon
 
 // BitReverse applies the bit-reversal permutation to a.
 // len(a) must be a power of 2 (as in every single function in this file)
+//
+// large vectors are permuted by tiles (see bitReverseBlocked), as the naive swap loop
+// does a cache miss on nearly every access
 func BitReverse(a []fr.Element) {
 	n := uint64(len(a))
+	if n >= bitReverseBlockedThreshold {
+		bitReverseBlocked(a)
+		return
+	}
 	nn := uint64(64 - bits.TrailingZeros64(n))
 
 	for i := uint64(0); i < n; i++ {
@@ -225,3 +299,47 @@ func BitReverse(a []fr.Element) {
 		}
 	}
 }
+
+const (
+	bitReverseTileLog          = 5       // tiles have 2^5 x 2^5 elements, and fit in L1/L2 caches
+	bitReverseBlockedThreshold = 1 << 16 // under this size, a is likely to be in cache anyway
+)
+
+// bitReverseBlocked applies the bit-reversal permutation to a, by tiles
+//
+// an index is split in (hi, mid, lo), with hi and lo on bitReverseTileLog bits;
+// then bitReverse(hi, mid, lo) == (rev(lo), rev(mid), rev(hi)). For a given mid, the indexes
+// (_, mid, _) form a tile of 2^bitReverseTileLog contiguous blocks, that is swapped with the tile (_, rev(mid), _).
+// The pairs of tiles are independent, and processed in parallel.
+// len(a) must be >= 2^(2*bitReverseTileLog)
+func bitReverseBlocked(a []fr.Element) {
+	const tileSize = 1 << bitReverseTileLog
+	logN := bits.TrailingZeros64(uint64(len(a)))
+	logMid := logN - 2*bitReverseTileLog
+	shiftHi := uint(logN - bitReverseTileLog)
+
+	var rev [tileSize]int
+	for i := 0; i < tileSize; i++ {
+		rev[i] = int(bits.Reverse64(uint64(i)) >> (64 - bitReverseTileLog))
+	}
+
+	utils.Parallelize(1<<logMid, func(start, end int) {
+		for mid := start; mid < end; mid++ {
+			mrev := int(bits.Reverse64(uint64(mid)) >> (64 - uint(logMid)))
+			if mrev < mid {
+				// the pair (mrev, mid) is processed with mrev
+				continue
+			}
+			for hi := 0; hi < tileSize; hi++ {
+				for lo := 0; lo < tileSize; lo++ {
+					i := hi<<shiftHi | mid<<bitReverseTileLog | lo
+					j := rev[lo]<<shiftHi | mrev<<bitReverseTileLog | rev[hi]
+					if mid == mrev && j <= i {
+						continue
+					}
+					a[i], a[j] = a[j], a[i]
+				}
+			}
+		}
+	})
+}
@@ -20,19 +20,20 @@ import (
 	curve "github.com/consensys/gurvy/bls381"
 
 	"encoding/binary"
+	"errors"
 	"github.com/fxamacker/cbor/v2"
 	"io"
 )
 
 // WriteTo writes binary encoding of the Proof elements to writer
-// points are stored in compressed form Ar | Krs | Bs
+// points are stored in compressed form Ar | Bs | Krs | nb commitments (0 or 1) | Commitment | CommitmentPok
 // use WriteRawTo(...) to encode the proof without point compression
 func (proof *Proof) WriteTo(w io.Writer) (n int64, err error) {
 	return proof.writeTo(w, false)
 }
 
 // WriteRawTo writes binary encoding of the Proof elements to writer
-// points are stored in uncompressed form Ar | Krs | Bs
+// points are stored in uncompressed form Ar | Bs | Krs | nb commitments (0 or 1) | Commitment | CommitmentPok
 // use WriteTo(...) to encode the proof with point compression
 func (proof *Proof) WriteRawTo(w io.Writer) (n int64, err error) {
 	return proof.writeTo(w, true)
@@ -55,12 +56,25 @@ func (proof *Proof) writeTo(w io.Writer, raw bool) (int64, error) {
 	if err := enc.Encode(&proof.Krs); err != nil {
 		return enc.BytesWritten(), err
 	}
+
+	// the commitment follows, if the circuit has one
+	toEncode := []interface{}{uint64(0)}
+	if !proof.Commitment.IsInfinity() || !proof.CommitmentPok.IsInfinity() {
+		toEncode = []interface{}{uint64(1), &proof.Commitment, &proof.CommitmentPok}
+	}
+	for _, v := range toEncode {
+		if err := enc.Encode(v); err != nil {
+			return enc.BytesWritten(), err
+		}
+	}
 	return enc.BytesWritten(), nil
 }
 
 // ReadFrom attempts to decode a Proof from reader
 // Proof must be encoded through WriteTo (compressed) or WriteRawTo (uncompressed)
 // note that we don't check that the points are on the curve or in the correct subgroup at this point
+//
+// proofs written before the commitments (Ar | Bs | Krs) are decoded with no commitment
 func (proof *Proof) ReadFrom(r io.Reader) (n int64, err error) {
 
 	dec := curve.NewDecoder(r)
@@ -75,9 +89,54 @@ func (proof *Proof) ReadFrom(r io.Reader) (n int64, err error) {
 		return dec.BytesRead(), err
 	}
 
+	// the commitment is infinity if the circuit has none
+	proof.Commitment, proof.CommitmentPok = curve.G1Affine{}, curve.G1Affine{}
+	nbCommitments, err := decodeNbCommitments(dec, true)
+	if err != nil || nbCommitments == 0 {
+		return dec.BytesRead(), err
+	}
+	if err := dec.Decode(&proof.Commitment); err != nil {
+		return dec.BytesRead(), err
+	}
+	if err := dec.Decode(&proof.CommitmentPok); err != nil {
+		return dec.BytesRead(), err
+	}
+
 	return dec.BytesRead(), nil
 }
 
+var errNbCommitments = errors.New("invalid number of commitments")
+
+// decodeNbCommitments decodes the number of commitments of a proof or a key, 0 or 1
+//
+// if optional is set, an encoding ending before the number of commitments has no commitment: the
+// proofs and the proving keys written before the commitments end there, and are not versioned
+func decodeNbCommitments(dec *curve.Decoder, optional bool) (uint64, error) {
+	var nbCommitments uint64
+	if err := dec.Decode(&nbCommitments); err != nil {
+		if err == io.EOF && optional {
+			return 0, nil
+		}
+		return 0, err
+	}
+	if nbCommitments > 1 {
+		return 0, errNbCommitments
+	}
+	return nbCommitments, nil
+}
+
+// vkVersion is the version of the VerifyingKey encoding
+//
+// it is written in the first 8 bytes of the key, with the most significant bit set (vkVersionFlag).
+// Keys written before the encoding was versioned (version 0) start with the length of the public
+// input names instead, which never has this bit set, and don't have vk.G1.Alpha and vk.G2.Beta.
+// Keys of version 1 have no number of commitments: the commitment key follows if the key doesn't end.
+const vkVersion = 2
+
+const vkVersionFlag = 1 << 63
+
+var errVKVersion = errors.New("unsupported verifying key version")
+
 // WriteTo writes binary encoding of the key elements to writer
 // points are compressed
 // use WriteRawTo(...) to encode the key without point compression
@@ -95,6 +154,12 @@ func (vk *VerifyingKey) WriteRawTo(w io.Writer) (n int64, err error) {
 func (vk *VerifyingKey) writeTo(w io.Writer, raw bool) (n int64, err error) {
 	var written int
 
+	err = binary.Write(w, binary.BigEndian, uint64(vkVersionFlag|vkVersion))
+	if err != nil {
+		return
+	}
+	n += 8
+
 	// encode public input names
 	var pBytes []byte
 	pBytes, err = cbor.Marshal(vk.PublicInputs)
@@ -127,27 +192,68 @@ func (vk *VerifyingKey) writeTo(w io.Writer, raw bool) (n int64, err error) {
 		enc = curve.NewEncoder(w)
 	}
 
-	err = enc.Encode(&vk.G2.GammaNeg)
+	// the encoder counts the bytes of all the points
+	toEncode := []interface{}{
+		&vk.G2.GammaNeg,
+		&vk.G2.DeltaNeg,
+		vk.G1.K,
+		&vk.G1.Alpha,
+		&vk.G2.Beta,
+	}
+	for _, v := range toEncode {
+		if err = enc.Encode(v); err != nil {
+			n += enc.BytesWritten()
+			return
+		}
+	}
+	// the commitment key follows, if the circuit has one
+	nbCommitments := uint64(0)
+	if vk.Commitment != nil {
+		nbCommitments = 1
+	}
+	err = enc.Encode(nbCommitments)
 	n += enc.BytesWritten()
-	if err != nil {
+	if err != nil || vk.Commitment == nil {
 		return
 	}
+	written64, err := vk.Commitment.writeTo(w, raw)
+	n += written64
+	return
+}
 
-	err = enc.Encode(&vk.G2.DeltaNeg)
-	n += enc.BytesWritten()
-	if err != nil {
-		return
+func (ck *CommitmentVerifyingKey) writeTo(w io.Writer, raw bool) (int64, error) {
+	var enc *curve.Encoder
+	if raw {
+		enc = curve.NewEncoder(w, curve.RawEncoding())
+	} else {
+		enc = curve.NewEncoder(w)
 	}
 
-	err = enc.Encode(vk.G1.K)
-	n += enc.BytesWritten()
-	return
+	toEncode := []interface{}{
+		&ck.K,
+		&ck.G,
+		&ck.GSigma,
+		uint64(len(ck.PublicCommitted)),
+	}
+	for _, j := range ck.PublicCommitted {
+		toEncode = append(toEncode, uint64(j))
+	}
+
+	for _, v := range toEncode {
+		if err := enc.Encode(v); err != nil {
+			return enc.BytesWritten(), err
+		}
+	}
+	return enc.BytesWritten(), nil
 }
 
 // ReadFrom attempts to decode a VerifyingKey from reader
 // VerifyingKey must be encoded through WriteTo (compressed) or WriteRawTo (uncompressed)
 // note that we don't check that the points are on the curve or in the correct subgroup at this point
 // TODO while Proof points correctness is checkd in the Verifier, here may be a good place to check key
+//
+// keys written before the encoding was versioned are decoded with vk.G1.Alpha and vk.G2.Beta set to
+// infinity; they can still be used to verify proofs, as vk.E is set. Keys of version 1 are decoded too.
 func (vk *VerifyingKey) ReadFrom(r io.Reader) (n int64, err error) {
 
 	var read int
@@ -160,6 +266,21 @@ func (vk *VerifyingKey) ReadFrom(r io.Reader) (n int64, err error) {
 	}
 	lPublicInputs := binary.BigEndian.Uint64(buf[:8])
 
+	var version uint64
+	if lPublicInputs&vkVersionFlag != 0 {
+		version = lPublicInputs &^ vkVersionFlag
+		if version > vkVersion {
+			err = errVKVersion
+			return
+		}
+		read, err = io.ReadFull(r, buf[:8])
+		n += int64(read)
+		if err != nil {
+			return
+		}
+		lPublicInputs = binary.BigEndian.Uint64(buf[:8])
+	}
+
 	bPublicInputs := make([]byte, lPublicInputs)
 	read, err = io.ReadFull(r, bPublicInputs)
 	n += int64(read)
@@ -173,7 +294,7 @@ func (vk *VerifyingKey) ReadFrom(r io.Reader) (n int64, err error) {
 
 	// read vk.E
 
-	read, err = r.Read(buf[:])
+	read, err = io.ReadFull(r, buf[:])
 	n += int64(read)
 	if err != nil {
 		return
@@ -185,22 +306,74 @@ func (vk *VerifyingKey) ReadFrom(r io.Reader) (n int64, err error) {
 
 	dec := curve.NewDecoder(r)
 
-	err = dec.Decode(&vk.G2.GammaNeg)
-	n += dec.BytesRead()
-	if err != nil {
+	// the decoder counts the bytes of all the points
+	toDecode := []interface{}{
+		&vk.G2.GammaNeg,
+		&vk.G2.DeltaNeg,
+		&vk.G1.K,
+	}
+	vk.G1.Alpha, vk.G2.Beta = curve.G1Affine{}, curve.G2Affine{}
+	if version > 0 {
+		toDecode = append(toDecode, &vk.G1.Alpha, &vk.G2.Beta)
+	}
+	for _, v := range toDecode {
+		if err = dec.Decode(v); err != nil {
+			n += dec.BytesRead()
+			return
+		}
+	}
+	vk.Commitment = nil
+	switch version {
+	case 0:
+		n += dec.BytesRead()
 		return
+	case 1:
+		// the key has no commitment if it ends here
+		n += dec.BytesRead()
+		vk.Commitment = &CommitmentVerifyingKey{}
+		read64, err := vk.Commitment.readFrom(r)
+		n += read64
+		if err == io.EOF && read64 == 0 {
+			vk.Commitment, err = nil, nil
+		}
+		return n, err
 	}
-
-	err = dec.Decode(&vk.G2.DeltaNeg)
+	nbCommitments, err := decodeNbCommitments(dec, false)
 	n += dec.BytesRead()
-	if err != nil {
+	if err != nil || nbCommitments == 0 {
 		return
 	}
+	vk.Commitment = &CommitmentVerifyingKey{}
+	read64, err := vk.Commitment.readFrom(r)
+	n += read64
+	return
+}
 
-	err = dec.Decode(&vk.G1.K)
-	n += dec.BytesRead()
+func (ck *CommitmentVerifyingKey) readFrom(r io.Reader) (int64, error) {
+	dec := curve.NewDecoder(r)
 
-	return
+	var nbPublicCommitted uint64
+	toDecode := []interface{}{
+		&ck.K,
+		&ck.G,
+		&ck.GSigma,
+		&nbPublicCommitted,
+	}
+	for _, v := range toDecode {
+		if err := dec.Decode(v); err != nil {
+			return dec.BytesRead(), err
+		}
+	}
+
+	ck.PublicCommitted = make([]int, nbPublicCommitted)
+	for i := range ck.PublicCommitted {
+		var j uint64
+		if err := dec.Decode(&j); err != nil {
+			return dec.BytesRead(), err
+		}
+		ck.PublicCommitted[i] = int(j)
+	}
+	return dec.BytesRead(), nil
 }
 
 // WriteTo writes binary encoding of the key elements to writer
@@ -243,6 +416,20 @@ func (pk *ProvingKey) writeTo(w io.Writer, raw bool) (int64, error) {
 		pk.G2.B,
 	}
 
+	// the commitment key follows, if the circuit has one
+	if pk.Commitment == nil {
+		toEncode = append(toEncode, uint64(0))
+	} else {
+		toEncode = append(toEncode,
+			uint64(1),
+			pk.Commitment.Basis,
+			pk.Commitment.BasisExpSigma,
+			&pk.Commitment.Blinding,
+			&pk.Commitment.BlindingExpSigma,
+			&pk.Commitment.BlindingKrs,
+		)
+	}
+
 	for _, v := range toEncode {
 		if err := enc.Encode(v); err != nil {
 			return n + enc.BytesWritten(), err
@@ -257,6 +444,8 @@ func (pk *ProvingKey) writeTo(w io.Writer, raw bool) (int64, error) {
 // ProvingKey must be encoded through WriteTo (compressed) or WriteRawTo (uncompressed)
 // note that we don't check that the points are on the curve or in the correct subgroup at this point
 // TODO while Proof points correctness is checkd in the Verifier, here may be a good place to check key
+//
+// keys written before the commitments, ending with pk.G2.B, are decoded with no commitment
 func (pk *ProvingKey) ReadFrom(r io.Reader) (int64, error) {
 
 	n, err := pk.Domain.ReadFrom(r)
@@ -285,5 +474,32 @@ func (pk *ProvingKey) ReadFrom(r io.Reader) (int64, error) {
 		}
 	}
 
+	pk.Commitment = nil
+	nbCommitments, err := decodeNbCommitments(dec, true)
+	if err != nil || nbCommitments == 0 {
+		return n + dec.BytesRead(), err
+	}
+	pk.Commitment = &CommitmentKey{}
+	if err := pk.Commitment.decode(dec); err != nil {
+		return n + dec.BytesRead(), err
+	}
+
 	return n + dec.BytesRead(), nil
 }
+
+// decode decodes the commitment key, written by ProvingKey.WriteTo after the number of commitments
+func (ck *CommitmentKey) decode(dec *curve.Decoder) error {
+	toDecode := []interface{}{
+		&ck.Basis,
+		&ck.BasisExpSigma,
+		&ck.Blinding,
+		&ck.BlindingExpSigma,
+		&ck.BlindingKrs,
+	}
+	for _, v := range toDecode {
+		if err := dec.Decode(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -20,11 +20,14 @@ import (
 	curve "github.com/consensys/gurvy/bls381"
 
 	"bytes"
+	"encoding/binary"
+	"io"
 	"math/big"
 	"reflect"
 
 	"github.com/consensys/gnark/internal/backend/bls381/fft"
 
+	"github.com/fxamacker/cbor/v2"
 	"github.com/leanovate/gopter"
 	"github.com/leanovate/gopter/gen"
 	"github.com/leanovate/gopter/prop"
@@ -103,6 +106,8 @@ func TestVerifyingKeySerialization(t *testing.T) {
 			vk.E.SetRandom()
 			vk.G2.GammaNeg = p2
 			vk.G2.DeltaNeg = p2
+			vk.G2.Beta = p2
+			vk.G1.Alpha = p1
 
 			vk.G1.K = make([]curve.G1Affine, nbWires)
 			for i := 0; i < nbWires; i++ {
@@ -160,6 +165,158 @@ func TestVerifyingKeySerialization(t *testing.T) {
 	properties.TestingRun(t, gopter.ConsoleReporter(false))
 }
 
+func TestVerifyingKeyVersion(t *testing.T) {
+	_, _, g1, g2 := curve.Generators()
+
+	var vk VerifyingKey
+	vk.E.SetRandom()
+	vk.G2.GammaNeg = g2
+	vk.G2.DeltaNeg = g2
+	vk.G1.K = []curve.G1Affine{g1, g1}
+	vk.PublicInputs = []string{"one", "x"}
+
+	// a key written before the encoding was versioned: public inputs | E | GammaNeg | DeltaNeg | K
+	var legacy bytes.Buffer
+	pBytes, err := cbor.Marshal(vk.PublicInputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	binary.Write(&legacy, binary.BigEndian, uint64(len(pBytes)))
+	legacy.Write(pBytes)
+	e := vk.E.Bytes()
+	legacy.Write(e[:])
+	enc := curve.NewEncoder(&legacy)
+	for _, v := range []interface{}{&vk.G2.GammaNeg, &vk.G2.DeltaNeg, vk.G1.K} {
+		if err := enc.Encode(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var decoded VerifyingKey
+	decoded.G1.Alpha = g1
+	size := int64(legacy.Len())
+	read, err := decoded.ReadFrom(&legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read != size {
+		t.Fatal("the legacy key wasn't fully read")
+	}
+	if !reflect.DeepEqual(&vk, &decoded) {
+		t.Fatal("legacy key decoded incorrectly")
+	}
+
+	// a key of version 1, without the number of commitments
+	var buf bytes.Buffer
+	vk.G1.Alpha, vk.G2.Beta = g1, g2
+	if _, err := vk.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	v1 := buf.Bytes()[:buf.Len()-8]
+	binary.BigEndian.PutUint64(v1, vkVersionFlag|1)
+	read, err = decoded.ReadFrom(bytes.NewReader(v1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read != int64(len(v1)) || !reflect.DeepEqual(&vk, &decoded) {
+		t.Fatal("key of version 1 decoded incorrectly")
+	}
+
+	// a key written by a newer version is rejected
+	buf.Reset()
+	if _, err := vk.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()
+	binary.BigEndian.PutUint64(b, vkVersionFlag|(vkVersion+1))
+	if _, err := decoded.ReadFrom(bytes.NewReader(b)); err != errVKVersion {
+		t.Fatal("expected errVKVersion, got", err)
+	}
+}
+
+// TestCommitmentSerialization checks that the commitments are read back, that an encoding truncated in
+// its commitment is rejected, and that the proofs and proving keys written before the commitments (ending
+// before the number of commitments) are read as having no commitment
+func TestCommitmentSerialization(t *testing.T) {
+	_, _, g1, g2 := curve.Generators()
+
+	var proof Proof
+	proof.Ar, proof.Krs, proof.Bs = g1, g1, g2
+	proof.Commitment, proof.CommitmentPok = g1, g1
+
+	var vk VerifyingKey
+	vk.E.SetRandom()
+	vk.G2.GammaNeg, vk.G2.DeltaNeg, vk.G2.Beta = g2, g2, g2
+	vk.G1.Alpha = g1
+	vk.G1.K = []curve.G1Affine{g1, g1}
+	vk.PublicInputs = []string{"one", "x"}
+	vk.Commitment = &CommitmentVerifyingKey{K: g1, G: g2, GSigma: g2, PublicCommitted: []int{1}}
+
+	var pk ProvingKey
+	pk.Domain = *fft.NewDomain(8)
+	pk.G1.A = []curve.G1Affine{g1}
+	pk.G1.B = []curve.G1Affine{g1}
+	pk.G1.Z = make([]curve.G1Affine, pk.Domain.Cardinality)
+	pk.G2.B = []curve.G2Affine{g2}
+	pk.Commitment = &CommitmentKey{
+		Basis:            []curve.G1Affine{g1},
+		BasisExpSigma:    []curve.G1Affine{g1},
+		Blinding:         g1,
+		BlindingExpSigma: g1,
+		BlindingKrs:      g1,
+	}
+
+	type serializable interface {
+		WriteTo(w io.Writer) (int64, error)
+		ReadFrom(r io.Reader) (int64, error)
+	}
+	for _, v := range []struct {
+		name                    string
+		value, without, decoded serializable
+	}{
+		{"proof", &proof, &Proof{Ar: g1, Krs: g1, Bs: g2}, new(Proof)},
+		{"verifying key", &vk, &VerifyingKey{E: vk.E, G1: vk.G1, G2: vk.G2, PublicInputs: vk.PublicInputs}, new(VerifyingKey)},
+		{"proving key", &pk, &ProvingKey{Domain: pk.Domain, G1: pk.G1, G2: pk.G2}, new(ProvingKey)},
+	} {
+		var buf, bufWithout bytes.Buffer
+		if _, err := v.value.WriteTo(&buf); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := v.without.WriteTo(&bufWithout); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := v.decoded.ReadFrom(bytes.NewReader(buf.Bytes())); err != nil {
+			t.Fatal(v.name, err)
+		}
+		if !reflect.DeepEqual(v.value, v.decoded) {
+			t.Fatal(v.name, "decoded incorrectly")
+		}
+
+		// the encoding without a commitment ends with the number of commitments (0)
+		end := bufWithout.Len()
+		for _, truncated := range [][]byte{buf.Bytes()[:end-4], buf.Bytes()[:end]} {
+			if _, err := v.decoded.ReadFrom(bytes.NewReader(truncated)); err == nil {
+				t.Fatal(v.name, "expected error with a truncated commitment")
+			}
+		}
+
+		// the verifying key is versioned, the proof and the proving key end before the number of commitments
+		_, err := v.decoded.ReadFrom(bytes.NewReader(buf.Bytes()[:end-8]))
+		if _, versioned := v.value.(*VerifyingKey); versioned {
+			if err == nil {
+				t.Fatal(v.name, "expected error without the number of commitments")
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(v.name, err)
+		}
+		if !reflect.DeepEqual(v.without, v.decoded) {
+			t.Fatal(v.name, "encoding without the number of commitments decoded incorrectly")
+		}
+	}
+}
+
 func TestProvingKeySerialization(t *testing.T) {
 	parameters := gopter.DefaultTestParameters()
 	parameters.MinSuccessfulTests = 10
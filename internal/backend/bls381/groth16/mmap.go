@@ -0,0 +1,267 @@
+// Copyright 2020 ConsenSys Software Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by gnark DO NOT EDIT
+
+package groth16
+
+import (
+	curve "github.com/consensys/gurvy/bls381"
+
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"github.com/consensys/gnark/internal/utils"
+	"io"
+	"reflect"
+	"unsafe"
+)
+
+// layout of the files written by WriteMmapTo:
+//
+//	magic | byte order mark | size of G1Affine, G2Affine | size of the header | lengths of the slices
+//	header: the key without its slices of points, as written by WriteTo
+//	the slices of points, in memory layout
+//
+// the integers are little-endian, except the byte order mark written in the native order
+const mmapMagic = "gnarkpk2"
+
+// number of slices of points of a ProvingKey (see mmapSlices)
+const nbMmapSlices = 7
+
+// ErrMmapLayout is returned by MmapProvingKey for a file written on a machine with another memory layout
+var ErrMmapLayout = errors.New("the proving key was written with another memory layout (byte order, point size)")
+
+// WriteMmapTo writes pk in the memory layout of the points, to be loaded by MmapProvingKey
+//
+// the file is only readable on machines with the same byte order and the same version of gurvy
+func (pk *ProvingKey) WriteMmapTo(w io.Writer) (int64, error) {
+	g1, g2 := pk.mmapSlices()
+
+	// the header is the key without its slices
+	header := *pk
+	header.G1.A, header.G1.B, header.G1.Z, header.G1.K, header.G2.B = nil, nil, nil, nil, nil
+	if pk.Commitment != nil {
+		ck := *pk.Commitment
+		ck.Basis, ck.BasisExpSigma = nil, nil
+		header.Commitment = &ck
+	}
+	var hbuf bytes.Buffer
+	if _, err := header.WriteTo(&hbuf); err != nil {
+		return 0, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(mmapMagic)
+	bom := uint64(1)
+	buf.Write((*[8]byte)(unsafe.Pointer(&bom))[:])
+	ints := []uint64{uint64(unsafe.Sizeof(curve.G1Affine{})), uint64(unsafe.Sizeof(curve.G2Affine{})), uint64(hbuf.Len())}
+	for _, s := range g1 {
+		ints = append(ints, uint64(len(*s)))
+	}
+	for _, s := range g2 {
+		ints = append(ints, uint64(len(*s)))
+	}
+	binary.Write(&buf, binary.LittleEndian, ints)
+	buf.Write(hbuf.Bytes())
+	buf.Write(make([]byte, padding(buf.Len())))
+
+	n, err := w.Write(buf.Bytes())
+	written := int64(n)
+	if err != nil {
+		return written, err
+	}
+	for _, s := range g1 {
+		n, err := w.Write(g1Bytes(*s))
+		written += int64(n)
+		if err != nil {
+			return written, err
+		}
+	}
+	for _, s := range g2 {
+		n, err := w.Write(g2Bytes(*s))
+		written += int64(n)
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// MmapProvingKey loads a proving key written by WriteMmapTo: the file is mapped in memory, and the
+// slices of points of the key point to the mapped memory, so that the pages are read from the file when
+// the prover needs them (and may be evicted by the OS under memory pressure)
+//
+// the pages are copy-on-write: the key can be modified, the file isn't. Close releases the mapping, after
+// which the key must not be used. On the platforms without mmap, the key is read in memory.
+func MmapProvingKey(path string) (*ProvingKey, io.Closer, error) {
+	data, mapped, unmap, err := utils.MapFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	pk, err := parseMmap(data, mapped)
+	if err != nil || !mapped {
+		unmap()
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return pk, mmapCloser(unmap), nil
+}
+
+// parseMmap returns the key written by WriteMmapTo in data; its slices alias data if mapped is set,
+// otherwise they are copied
+func parseMmap(data []byte, mapped bool) (*ProvingKey, error) {
+	const nbInts = 3 + nbMmapSlices
+	const prefix = len(mmapMagic) + 8 + 8*nbInts
+	if len(data) < prefix || string(data[:len(mmapMagic)]) != mmapMagic {
+		return nil, errors.New("not a proving key written by WriteMmapTo")
+	}
+	if *(*uint64)(unsafe.Pointer(&data[len(mmapMagic)])) != 1 {
+		return nil, ErrMmapLayout
+	}
+	ints := make([]uint64, nbInts)
+	for i := range ints {
+		ints[i] = binary.LittleEndian.Uint64(data[len(mmapMagic)+8+8*i:])
+	}
+	sizeG1, sizeG2 := uint64(unsafe.Sizeof(curve.G1Affine{})), uint64(unsafe.Sizeof(curve.G2Affine{}))
+	if ints[0] != sizeG1 || ints[1] != sizeG2 {
+		return nil, ErrMmapLayout
+	}
+	headerLen, lens := ints[2], ints[3:]
+
+	// the lengths are bounded by the size of the file before being multiplied, to avoid overflows
+	errSize := errors.New("invalid size of the proving key file")
+	if headerLen > uint64(len(data)) {
+		return nil, errSize
+	}
+	offset := uint64(prefix) + headerLen
+	offset += uint64(padding(int(offset)))
+	size := offset
+	for i, l := range lens {
+		if l > uint64(len(data)) {
+			return nil, errSize
+		}
+		if i < nbMmapSlices-1 {
+			size += l * sizeG1
+		} else {
+			size += l * sizeG2
+		}
+	}
+	if uint64(len(data)) != size {
+		return nil, errSize
+	}
+
+	pk := &ProvingKey{}
+	if _, err := pk.ReadFrom(bytes.NewReader(data[prefix : uint64(prefix)+headerLen])); err != nil {
+		return nil, err
+	}
+	g1, g2 := pk.mmapSlices()
+	for i, s := range g1 {
+		n := int(lens[i])
+		b := data[offset : offset+uint64(n)*sizeG1]
+		offset += uint64(len(b))
+		if n == 0 {
+			continue
+		}
+		if mapped {
+			*s = g1Slice(b, n)
+		} else {
+			*s = make([]curve.G1Affine, n)
+			copy(g1Bytes(*s), b)
+		}
+	}
+	for _, s := range g2 {
+		n := int(lens[nbMmapSlices-1])
+		b := data[offset : offset+uint64(n)*sizeG2]
+		offset += uint64(len(b))
+		if n == 0 {
+			continue
+		}
+		if mapped {
+			*s = g2Slice(b, n)
+		} else {
+			*s = make([]curve.G2Affine, n)
+			copy(g2Bytes(*s), b)
+		}
+	}
+	return pk, nil
+}
+
+// mmapSlices returns the slices of points of pk, in the order of the file; the commitment key is
+// created if the key has none
+func (pk *ProvingKey) mmapSlices() ([]*[]curve.G1Affine, []*[]curve.G2Affine) {
+	g1 := []*[]curve.G1Affine{&pk.G1.A, &pk.G1.B, &pk.G1.Z, &pk.G1.K}
+	if pk.Commitment != nil {
+		g1 = append(g1, &pk.Commitment.Basis, &pk.Commitment.BasisExpSigma)
+	} else {
+		g1 = append(g1, new([]curve.G1Affine), new([]curve.G1Affine))
+	}
+	return g1, []*[]curve.G2Affine{&pk.G2.B}
+}
+
+type mmapCloser func() error
+
+func (c mmapCloser) Close() error {
+	return c()
+}
+
+// padding returns the number of bytes to align n on 8 bytes
+func padding(n int) int {
+	return (8 - n%8) % 8
+}
+
+// g1Bytes returns the memory of points
+func g1Bytes(points []curve.G1Affine) []byte {
+	if len(points) == 0 {
+		return nil
+	}
+	var b []byte
+	h := (*reflect.SliceHeader)(unsafe.Pointer(&b))
+	h.Data = uintptr(unsafe.Pointer(&points[0]))
+	h.Len = len(points) * int(unsafe.Sizeof(points[0]))
+	h.Cap = h.Len
+	return b
+}
+
+// g2Bytes returns the memory of points
+func g2Bytes(points []curve.G2Affine) []byte {
+	if len(points) == 0 {
+		return nil
+	}
+	var b []byte
+	h := (*reflect.SliceHeader)(unsafe.Pointer(&b))
+	h.Data = uintptr(unsafe.Pointer(&points[0]))
+	h.Len = len(points) * int(unsafe.Sizeof(points[0]))
+	h.Cap = h.Len
+	return b
+}
+
+// g1Slice returns the n points in the memory b, which must not be managed by Go
+func g1Slice(b []byte, n int) []curve.G1Affine {
+	var points []curve.G1Affine
+	h := (*reflect.SliceHeader)(unsafe.Pointer(&points))
+	h.Data = uintptr(unsafe.Pointer(&b[0]))
+	h.Len, h.Cap = n, n
+	return points
+}
+
+// g2Slice returns the n points in the memory b, which must not be managed by Go
+func g2Slice(b []byte, n int) []curve.G2Affine {
+	var points []curve.G2Affine
+	h := (*reflect.SliceHeader)(unsafe.Pointer(&points))
+	h.Data = uintptr(unsafe.Pointer(&b[0]))
+	h.Len, h.Cap = n, n
+	return points
+}
@@ -0,0 +1,62 @@
+// Copyright 2020 ConsenSys Software Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by gnark DO NOT EDIT
+
+package groth16
+
+import (
+	curve "github.com/consensys/gurvy/bls381"
+
+	"github.com/leanovate/gopter"
+	"github.com/leanovate/gopter/prop"
+
+	"testing"
+)
+
+func TestMillerLoopFixed(t *testing.T) {
+	parameters := gopter.DefaultTestParameters()
+	parameters.MinSuccessfulTests = 5
+
+	properties := gopter.NewProperties(parameters)
+
+	properties.Property("the Miller loop with precomputed lines should match the pairing", prop.ForAll(
+		func(p1, p2 curve.G1Affine, q1, q2 curve.G2Affine) bool {
+			P := []curve.G1Affine{p1, p2, {}}
+			Q := []curve.G2Affine{q1, q2, q1}
+			lines := [][]line{computeLines(&q1), computeLines(&q2), computeLines(&q1)}
+
+			ml := millerLoopFixed(P, lines)
+			res := curve.FinalExponentiation(&ml)
+
+			// the point at infinity is skipped
+			var expected curve.GT
+			expected.SetOne()
+			for i := 0; i < 2; i++ {
+				e, err := curve.Pair(P[i:i+1], Q[i:i+1])
+				if err != nil {
+					return false
+				}
+				expected.Mul(&expected, &e)
+			}
+			return res.Equal(&expected)
+		},
+		GenG1(),
+		GenG1(),
+		GenG2(),
+		GenG2(),
+	))
+
+	properties.TestingRun(t, gopter.ConsoleReporter(false))
+}
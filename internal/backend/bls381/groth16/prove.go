@@ -25,10 +25,30 @@ import (
 
 	"github.com/consensys/gnark/internal/backend/bls381/fft"
 
+	"crypto/sha512"
+	"errors"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/hint"
 	"github.com/consensys/gnark/internal/utils"
 	"github.com/consensys/gurvy"
+	"io/ioutil"
 	"math/big"
-	"runtime"
+	"os"
+	"sync"
+)
+
+// prover steps, reported through backend.ProgressFunc
+const (
+	stepSolve = "solve" // R1CS solver (in constraints)
+	stepFFT   = "fft"   // FFTs of the quotient h (in FFTs)
+	stepMSM   = "msm"   // multi exponentiations (in multi exponentiations)
+	stepProof = "proof" // proofs of ProveBatch (in proofs)
+)
+
+// number of FFTs and of multi exponentiations of a proof
+const (
+	nbFFTs = 7
+	nbMSMs = 5
 )
 
 // Proof represents a Groth16 proof that was encoded with a ProvingKey and can be verified
@@ -37,11 +57,16 @@ import (
 type Proof struct {
 	Ar, Krs curve.G1Affine
 	Bs      curve.G2Affine
+
+	// commitment to the committed private wires, and proof of knowledge (see CommitmentKey); infinity if
+	// the circuit has no commitment
+	Commitment, CommitmentPok curve.G1Affine
 }
 
 // isValid ensures proof elements are in the correct subgroup
 func (proof *Proof) isValid() bool {
-	return proof.Ar.IsInSubGroup() && proof.Krs.IsInSubGroup() && proof.Bs.IsInSubGroup()
+	return proof.Ar.IsInSubGroup() && proof.Krs.IsInSubGroup() && proof.Bs.IsInSubGroup() &&
+		proof.Commitment.IsInSubGroup() && proof.CommitmentPok.IsInSubGroup()
 }
 
 // GetCurveID returns the curveID
@@ -49,69 +74,385 @@ func (proof *Proof) GetCurveID() gurvy.ID {
 	return curve.ID
 }
 
+// Accelerator computes the multi exponentiations and the FFTs of the prover on an external device (a
+// GPU for example), see backend.WithAccelerator
+//
+// the methods may be called concurrently. The scalars of the multi exponentiations are in regular form,
+// as for curve.G1Jac.MultiExp, and the elements of the FFTs are in Montgomery form (the memory layout of
+// fr.Element)
+type Accelerator interface {
+	// MultiExpG1 returns Σ scalars[i].points[i]
+	MultiExpG1(points []curve.G1Affine, scalars []fr.Element) (curve.G1Jac, error)
+
+	// MultiExpG2 returns Σ scalars[i].points[i]
+	MultiExpG2(points []curve.G2Affine, scalars []fr.Element) (curve.G2Jac, error)
+
+	// FFT replaces the coefficients a of a polynomial p by its evaluations p(shift.ωⁱ), ω of order
+	// len(a); if inverse is set, it replaces the evaluations by the coefficients. Inputs and outputs are
+	// in natural order
+	FFT(a []fr.Element, omega, shift fr.Element, inverse bool) error
+}
+
+// accelerator returns the accelerator of the config, nil if the operations run on the CPU
+func accelerator(config backend.Config) (Accelerator, error) {
+	if config.Accelerator == nil {
+		return nil, nil
+	}
+	acc, ok := config.Accelerator.(Accelerator)
+	if !ok {
+		return nil, errors.New("the accelerator doesn't support BLS381")
+	}
+	return acc, nil
+}
+
 // Prove generates the proof of knoweldge of a r1cs with solution.
-// if force flag is set, Prove ignores R1CS solving error (ie invalid solution) and executes
-// the FFTs and MultiExponentiations to compute an (invalid) Proof object
-func Prove(r1cs *bls381backend.R1CS, pk *ProvingKey, solution map[string]interface{}, force bool) (*Proof, error) {
-	nbPrivateWires := r1cs.NbWires - r1cs.NbPublicWires
+// if force flag (or backend.IgnoreSolverError) is set, Prove ignores R1CS solving error (ie invalid solution)
+// and executes the FFTs and MultiExponentiations to compute an (invalid) Proof object
+func Prove(r1cs *bls381backend.R1CS, pk *ProvingKey, solution map[string]interface{}, force bool, opts ...backend.Option) (*Proof, error) {
+	config, err := backend.NewConfig(opts...)
+	if err != nil {
+		return nil, err
+	}
+	if force {
+		config.IgnoreSolverError = true
+	}
 
-	// solve the R1CS and compute the a, b, c vectors
-	a := make([]fr.Element, r1cs.NbConstraints, pk.Domain.Cardinality)
-	b := make([]fr.Element, r1cs.NbConstraints, pk.Domain.Cardinality)
-	c := make([]fr.Element, r1cs.NbConstraints, pk.Domain.Cardinality)
-	wireValues := make([]fr.Element, r1cs.NbWires)
-	if err := r1cs.Solve(solution, a, b, c, wireValues); err != nil && !force {
+	w, err := solveWitness(r1cs, pk, solution, config)
+	if err != nil {
 		return nil, err
 	}
+	h, err := w.reduce(&pk.Domain, config)
+	if err != nil {
+		return nil, err
+	}
+	return computeProof(r1cs, pk, w, h, config)
+}
 
-	// set the wire values in regular form
-	utils.Parallelize(len(wireValues), func(start, end int) {
-		for i := start; i < end; i++ {
-			wireValues[i].FromMont()
+// ProveBatch generates the proofs of r1cs for each of the solutions (see Prove)
+//
+// the instances are pipelined: the solver, the FFTs and the multi exponentiations run concurrently
+// on consecutive instances, which keeps the CPUs busy during the sequential parts of each phase.
+// The memory is bounded by the number of phases: at most one instance per phase is in flight.
+// ProveBatch stops at the first error. With backend.WithMaxWorkers, each phase is capped separately.
+func ProveBatch(r1cs *bls381backend.R1CS, pk *ProvingKey, solutions []map[string]interface{}, opts ...backend.Option) ([]*Proof, error) {
+	config, err := backend.NewConfig(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// the phases run concurrently: only the proofs are reported
+	proofDone := progressCounter(config.Progress, stepProof, len(solutions))
+	config.Progress = func(string, int, int) {}
+
+	type job struct {
+		i int
+		w *witness
+		h []fr.Element
+	}
+	chSolved := make(chan job)
+	chReduced := make(chan job)
+
+	proofs := make([]*Proof, len(solutions))
+	errs := make([]error, len(solutions))
+	chStop := make(chan struct{})
+	var once sync.Once
+	fail := func(i int, err error) {
+		errs[i] = err
+		once.Do(func() { close(chStop) })
+	}
+	stopped := func() bool {
+		select {
+		case <-chStop:
+			return true
+		default:
+			return false
 		}
-	})
+	}
 
-	// H (witness reduction / FFT part)
-	var h []fr.Element
-	chHDone := make(chan struct{}, 1)
+	// solver
 	go func() {
-		h = computeH(a, b, c, &pk.Domain)
-		a = nil
-		b = nil
-		c = nil
-		chHDone <- struct{}{}
+		defer close(chSolved)
+		for i := 0; i < len(solutions) && !stopped(); i++ {
+			w, err := solveWitness(r1cs, pk, solutions[i], config)
+			if err != nil {
+				fail(i, err)
+				return
+			}
+			chSolved <- job{i: i, w: w}
+		}
 	}()
 
-	// sample random r and s
-	var r, s big.Int
-	var _r, _s, _kr fr.Element
-	if _, err := _r.SetRandom(); err != nil {
+	// FFTs
+	go func() {
+		defer close(chReduced)
+		for j := range chSolved {
+			if stopped() {
+				continue
+			}
+			h, err := j.w.reduce(&pk.Domain, config)
+			if err != nil {
+				fail(j.i, err)
+				continue
+			}
+			j.h = h
+			chReduced <- j
+		}
+	}()
+
+	// multi exponentiations
+	for j := range chReduced {
+		if stopped() {
+			continue
+		}
+		proof, err := computeProof(r1cs, pk, j.w, j.h, config)
+		if err != nil {
+			fail(j.i, err)
+			continue
+		}
+		proofs[j.i] = proof
+		proofDone()
+	}
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return proofs, nil
+}
+
+// witness holds the solved R1CS of an instance, as needed by the FFTs (a, b, c) and the multi exponentiations (wireValues)
+type witness struct {
+	a, b, c    []fr.Element
+	wireValues []fr.Element // regular form
+
+	// set by the commitment hint, if the circuit has one; commitmentKrs is added to Krs (see CommitmentKey)
+	commitment, commitmentPok, commitmentKrs curve.G1Affine
+}
+
+// solveWitness solves the R1CS and computes the a, b, c vectors
+func solveWitness(r1cs *bls381backend.R1CS, pk *ProvingKey, solution map[string]interface{}, config backend.Config) (*witness, error) {
+	w := &witness{
+		a:          make([]fr.Element, r1cs.NbConstraints, pk.Domain.Cardinality),
+		b:          make([]fr.Element, r1cs.NbConstraints, pk.Domain.Cardinality),
+		c:          make([]fr.Element, r1cs.NbConstraints, pk.Domain.Cardinality),
+		wireValues: make([]fr.Element, r1cs.NbWires),
+	}
+	if err := w.solve(r1cs, pk, solution, config); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// solve solves the R1CS in the vectors of w, of lengths r1cs.NbConstraints (with a capacity of
+// pk.Domain.Cardinality) and r1cs.NbWires
+func (w *witness) solve(r1cs *bls381backend.R1CS, pk *ProvingKey, solution map[string]interface{}, config backend.Config) error {
+	if err := config.Context.Err(); err != nil {
+		return err
+	}
+	var overrides map[hint.ID]hint.Function
+	if r1cs.Commitment != nil {
+		if pk.Commitment == nil {
+			return errors.New("the circuit has a commitment, but the proving key has no commitment key")
+		}
+		overrides = map[hint.ID]hint.Function{hint.UUID(hint.Commitment): w.commitmentHint(r1cs, pk, config.Seed)}
+	}
+
+	// the trace is written before the error of the solver is returned
+	var trace *backend.SolverTrace
+	if config.SolverTrace != nil {
+		trace = new(backend.SolverTrace)
+	}
+	err := r1cs.SolveTrace(solution, w.a, w.b, w.c, w.wireValues, overrides, trace)
+	var errTrace error
+	if trace != nil {
+		errTrace = trace.Encode(config.SolverTrace, config.SolverTraceFormat)
+	}
+	if err != nil && !config.IgnoreSolverError {
+		return err
+	}
+	if errTrace != nil {
+		return errTrace
+	}
+
+	// set the wire values in regular form
+	utils.Parallelize(len(w.wireValues), func(start, end int) {
+		for i := start; i < end; i++ {
+			w.wireValues[i].FromMont()
+		}
+	}, config.MaxWorkers)
+	config.Progress(stepSolve, int(r1cs.NbConstraints), int(r1cs.NbConstraints))
+	return nil
+}
+
+// commitmentHint returns the hint computing the commitment wire of the circuit (see r1c.Commitment):
+// it commits to the values of the committed private wires with pk.Commitment, and the value of the wire
+// is the challenge derived from the commitment and from the values of the committed public wires
+//
+// the blinding ρ of the commitment is random, or derived from seed (see backend.WithSeed)
+func (w *witness) commitmentHint(r1cs *bls381backend.R1CS, pk *ProvingKey, seed []byte) hint.Function {
+	nbPrivateWires := int(r1cs.NbWires - r1cs.NbPublicWires)
+	privateCommitted, _ := committedWires(r1cs)
+	index := make(map[int]int, len(privateCommitted)) // index in the commitment key of a private wire
+	for i, wireID := range privateCommitted {
+		index[wireID] = i
+	}
+
+	return func(_ gurvy.ID, inputs []*big.Int, result *big.Int) error {
+		if len(inputs) != len(r1cs.Commitment.Committed) || len(privateCommitted) != len(pk.Commitment.Basis) {
+			return errors.New("the commitment key doesn't match the circuit")
+		}
+		private := make([]fr.Element, len(privateCommitted)) // regular form
+		public := make([]fr.Element, 0, len(inputs)-len(privateCommitted))
+		for i, wireID := range r1cs.Commitment.Committed {
+			var v fr.Element
+			v.SetBigInt(inputs[i])
+			if wireID >= nbPrivateWires {
+				public = append(public, v)
+			} else {
+				private[index[wireID]] = v.ToRegular()
+			}
+		}
+
+		// ρ[ξ]1 is added to the commitment, ρ[σ ξ]1 to the proof of knowledge, and -ρ[ξ γ/δ]1 to Krs
+		rho, err := sampleCommitmentBlinding(seed, private)
+		if err != nil {
+			return err
+		}
+		n := len(private)
+		scalars := append(private, rho)
+		w.commitment.MultiExp(append(pk.Commitment.Basis[:n:n], pk.Commitment.Blinding), scalars)
+		w.commitmentPok.MultiExp(append(pk.Commitment.BasisExpSigma[:n:n], pk.Commitment.BlindingExpSigma), scalars)
+		var b big.Int
+		rho.ToBigInt(&b)
+		w.commitmentKrs.ScalarMultiplication(&pk.Commitment.BlindingKrs, &b)
+		w.commitmentKrs.Neg(&w.commitmentKrs)
+
+		challenge := commitmentChallenge(&w.commitment, public)
+		challenge.ToBigIntRegular(result)
+		return nil
+	}
+}
+
+// sampleCommitmentBlinding returns a random ρ, or ρ = H(seed, private) reduced modulo the order of fr if
+// seed is set; ρ is in regular form
+func sampleCommitmentBlinding(seed []byte, private []fr.Element) (rho fr.Element, err error) {
+	if seed == nil {
+		_, err = rho.SetRandom()
+		return rho.ToRegular(), err
+	}
+	h := sha512.New()
+	h.Write(seed)
+	h.Write([]byte("commitment"))
+	for i := range private {
+		b := private[i].Bytes()
+		h.Write(b[:])
+	}
+	rho.SetBytes(h.Sum(nil))
+	return rho.ToRegular(), nil
+}
+
+// reduce computes H (witness reduction / FFT part), and releases the a, b, c vectors
+func (w *witness) reduce(domain *fft.Domain, config backend.Config) ([]fr.Element, error) {
+	a, b, c := w.a, w.b, w.c
+	w.a, w.b, w.c = nil, nil, nil
+	if err := config.Context.Err(); err != nil {
 		return nil, err
 	}
-	if _, err := _s.SetRandom(); err != nil {
+	acc, err := accelerator(config)
+	if err != nil {
 		return nil, err
 	}
-	_kr.Mul(&_r, &_s).Neg(&_kr)
+	var h []fr.Element
+	switch {
+	case acc != nil:
+		h, err = computeHAccelerated(a, b, c, domain, acc, config.MaxWorkers)
+	case config.FFTMaxElements > 0 && domain.Cardinality > uint64(config.FFTMaxElements):
+		// the slices are released by computeHOutOfCore once stored on disk
+		h, err = computeHOutOfCore(a, b, c, domain, config.FFTDir, config.FFTMaxElements, config.MaxWorkers)
+	default:
+		h = computeH(a, b, c, domain, config.MaxWorkers)
+	}
+	if err != nil {
+		return nil, err
+	}
+	config.Progress(stepFFT, nbFFTs, nbFFTs)
+	return h, nil
+}
 
-	_r.FromMont()
-	_s.FromMont()
-	_kr.FromMont()
-	_r.ToBigInt(&r)
-	_s.ToBigInt(&s)
+// computeProof computes the proof from the solved wires and h (multi exponentiations part)
+func computeProof(r1cs *bls381backend.R1CS, pk *ProvingKey, w *witness, h []fr.Element, config backend.Config) (*Proof, error) {
+	if err := config.Context.Err(); err != nil {
+		return nil, err
+	}
+	acc, err := accelerator(config)
+	if err != nil {
+		return nil, err
+	}
+	nbPrivateWires := r1cs.NbWires - r1cs.NbPublicWires
+	wireValues := w.wireValues
 
-	// computes r[δ], s[δ], kr[δ]
-	deltas := curve.BatchScalarMultiplicationG1(&pk.G1.Delta, []fr.Element{_r, _s, _kr})
+	r, s, deltas, err := sampleRS(pk, wireValues, config)
+	if err != nil {
+		return nil, err
+	}
 
-	proof := &Proof{}
+	// the committed private wires and the commitment wire are in the commitment, not in Krs (their
+	// entries of pk.G1.K are infinity)
+	proof := &Proof{Commitment: w.commitment, CommitmentPok: w.commitmentPok}
 	var bs1, ar curve.G1Jac
 
 	// using this ensures that our multiExps running in parallel won't use more than
 	// provided CPUs
-	cpuSemaphore := curve.NewCPUSemaphore(runtime.NumCPU())
+	cpuSemaphore := curve.NewCPUSemaphore(config.MaxWorkers)
+
+	// the multi exponentiations run on the accelerator if any, and are skipped once the context is done;
+	// the first error is returned
+	var msmErr error
+	var msmLock sync.Mutex
+	setMSMErr := func(err error) {
+		msmLock.Lock()
+		if msmErr == nil {
+			msmErr = err
+		}
+		msmLock.Unlock()
+	}
+	msmDone := progressCounter(config.Progress, stepMSM, nbMSMs)
+	multiExpG1 := func(res *curve.G1Jac, points []curve.G1Affine, scalars []fr.Element) {
+		if err := config.Context.Err(); err != nil {
+			setMSMErr(err)
+			return
+		}
+		if acc == nil {
+			res.MultiExp(points, scalars, cpuSemaphore)
+			return
+		}
+		p, err := acc.MultiExpG1(points, scalars)
+		if err != nil {
+			setMSMErr(err)
+		}
+		*res = p
+	}
+	multiExpG2 := func(res *curve.G2Jac, points []curve.G2Affine, scalars []fr.Element) {
+		if err := config.Context.Err(); err != nil {
+			setMSMErr(err)
+			return
+		}
+		if acc == nil {
+			res.MultiExp(points, scalars, cpuSemaphore)
+			return
+		}
+		p, err := acc.MultiExpG2(points, scalars)
+		if err != nil {
+			setMSMErr(err)
+		}
+		*res = p
+	}
 
 	chBs1Done := make(chan struct{}, 1)
 	computeBS1 := func() {
-		bs1.MultiExp(pk.G1.B, wireValues, cpuSemaphore)
+		multiExpG1(&bs1, pk.G1.B, wireValues)
+		msmDone()
 		bs1.AddMixed(&pk.G1.Beta)
 		bs1.AddMixed(&deltas[1])
 		chBs1Done <- struct{}{}
@@ -119,7 +460,8 @@ func Prove(r1cs *bls381backend.R1CS, pk *ProvingKey, solution map[string]interfa
 
 	chArDone := make(chan struct{}, 1)
 	computeAR1 := func() {
-		ar.MultiExp(pk.G1.A, wireValues, cpuSemaphore)
+		multiExpG1(&ar, pk.G1.A, wireValues)
+		msmDone()
 		ar.AddMixed(&pk.G1.Alpha)
 		ar.AddMixed(&deltas[0])
 		proof.Ar.FromJacobian(&ar)
@@ -134,11 +476,14 @@ func Prove(r1cs *bls381backend.R1CS, pk *ProvingKey, solution map[string]interfa
 		var krs, krs2, p1 curve.G1Jac
 		chKrs2Done := make(chan struct{}, 1)
 		go func() {
-			krs2.MultiExp(pk.G1.Z, h, cpuSemaphore)
+			multiExpG1(&krs2, pk.G1.Z, h)
+			msmDone()
 			chKrs2Done <- struct{}{}
 		}()
-		krs.MultiExp(pk.G1.K[:nbPrivateWires], wireValues[:nbPrivateWires], cpuSemaphore)
+		multiExpG1(&krs, pk.G1.K[:nbPrivateWires], wireValues[:nbPrivateWires])
+		msmDone()
 		krs.AddMixed(&deltas[2])
+		krs.AddMixed(&w.commitmentKrs)
 		n := 3
 		for n != 0 {
 			select {
@@ -166,27 +511,29 @@ func Prove(r1cs *bls381backend.R1CS, pk *ProvingKey, solution map[string]interfa
 		// and is good for parallelism. However, on a machine with limited CPUs, this may not be
 		// a good idea, as the MultiExp scales slightly better than linearly
 		bsSplit := len(pk.G2.B) / 3
-		if bsSplit > 10 {
+		// (the accelerator gets the whole multi exponentiation)
+		if bsSplit > 10 && acc == nil {
 			chDone1 := make(chan struct{}, 1)
 			chDone2 := make(chan struct{}, 1)
 			var bs1, bs2 curve.G2Jac
 			go func() {
-				bs1.MultiExp(pk.G2.B[:bsSplit], wireValues[:bsSplit], cpuSemaphore)
+				multiExpG2(&bs1, pk.G2.B[:bsSplit], wireValues[:bsSplit])
 				chDone1 <- struct{}{}
 			}()
 			go func() {
-				bs2.MultiExp(pk.G2.B[bsSplit:bsSplit*2], wireValues[bsSplit:bsSplit*2], cpuSemaphore)
+				multiExpG2(&bs2, pk.G2.B[bsSplit:bsSplit*2], wireValues[bsSplit:bsSplit*2])
 				chDone2 <- struct{}{}
 			}()
-			Bs.MultiExp(pk.G2.B[bsSplit*2:], wireValues[bsSplit*2:], cpuSemaphore)
+			multiExpG2(&Bs, pk.G2.B[bsSplit*2:], wireValues[bsSplit*2:])
 
 			<-chDone1
 			Bs.AddAssign(&bs1)
 			<-chDone2
 			Bs.AddAssign(&bs2)
 		} else {
-			Bs.MultiExp(pk.G2.B, wireValues, cpuSemaphore)
+			multiExpG2(&Bs, pk.G2.B, wireValues)
 		}
+		msmDone()
 
 		deltaS.FromAffine(&pk.G2.Delta)
 		deltaS.ScalarMultiplication(&deltaS, &s)
@@ -196,9 +543,6 @@ func Prove(r1cs *bls381backend.R1CS, pk *ProvingKey, solution map[string]interfa
 		proof.Bs.FromJacobian(&Bs)
 	}
 
-	// wait for FFT to end, as it uses all our CPUs
-	<-chHDone
-
 	// schedule our proof part computations
 	go computeKRS()
 	go computeAR1()
@@ -208,10 +552,70 @@ func Prove(r1cs *bls381backend.R1CS, pk *ProvingKey, solution map[string]interfa
 	// wait for all parts of the proof to be computed.
 	<-chKrsDone
 
+	if msmErr != nil {
+		return nil, msmErr
+	}
 	return proof, nil
 }
 
-func computeH(a, b, c []fr.Element, domain *fft.Domain) []fr.Element {
+// progressCounter returns a function reporting one more unit of step done, out of total; it may be
+// called concurrently, the calls to progress are serialized
+func progressCounter(progress backend.ProgressFunc, step string, total int) func() {
+	var lock sync.Mutex
+	done := 0
+	return func() {
+		lock.Lock()
+		defer lock.Unlock()
+		done++
+		progress(step, done, total)
+	}
+}
+
+// sampleRS samples the random r and s of a proof (or derives them from backend.WithSeed and the wire
+// values), and returns them with r[δ], s[δ], -rs[δ]
+func sampleRS(pk *ProvingKey, wireValues []fr.Element, config backend.Config) (r, s big.Int, deltas []curve.G1Affine, err error) {
+	var _r, _s, _kr fr.Element
+	if config.Seed != nil {
+		_r, _s = deriveRS(config.Seed, wireValues)
+	} else {
+		if _, err = _r.SetRandom(); err != nil {
+			return
+		}
+		if _, err = _s.SetRandom(); err != nil {
+			return
+		}
+	}
+	_kr.Mul(&_r, &_s).Neg(&_kr)
+
+	_r.FromMont()
+	_s.FromMont()
+	_kr.FromMont()
+	_r.ToBigInt(&r)
+	_s.ToBigInt(&s)
+
+	deltas = curve.BatchScalarMultiplicationG1(&pk.G1.Delta, []fr.Element{_r, _s, _kr})
+	return
+}
+
+// deriveRS returns r, s = H(H(seed, wireValues), i), i ∈ {0, 1}, reduced modulo the order of fr
+func deriveRS(seed []byte, wireValues []fr.Element) (r, s fr.Element) {
+	h := sha512.New()
+	h.Write(seed)
+	for i := range wireValues {
+		b := wireValues[i].Bytes()
+		h.Write(b[:])
+	}
+	digest := h.Sum(nil)
+	for i, x := range []*fr.Element{&r, &s} {
+		h.Reset()
+		h.Write(digest)
+		h.Write([]byte{byte(i)})
+		x.SetBytes(h.Sum(nil))
+	}
+	return
+}
+
+func computeH(a, b, c []fr.Element, domain *fft.Domain, nbTasks int) []fr.Element {
 	// H part of Krs
 	// Compute H (hz=ab-c, where z=-2 on ker X^n+1 (z(x)=x^n-1))
 	// 	1 - _a = ifft(a), _b = ifft(b), _c = ifft(c)
@@ -227,21 +631,14 @@ func computeH(a, b, c []fr.Element, domain *fft.Domain) []fr.Element {
 	c = append(c, padding...)
 	n = len(a)
 
-	domain.FFTInverse(a, fft.DIF)
-	domain.FFTInverse(b, fft.DIF)
-	domain.FFTInverse(c, fft.DIF)
+	domain.FFTInverse(a, fft.DIF, false, fft.WithNbTasks(nbTasks))
+	domain.FFTInverse(b, fft.DIF, false, fft.WithNbTasks(nbTasks))
+	domain.FFTInverse(c, fft.DIF, false, fft.WithNbTasks(nbTasks))
 
-	utils.Parallelize(n, func(start, end int) {
-		for i := start; i < end; i++ {
-			a[i].Mul(&a[i], &domain.CosetTable[i])
-			b[i].Mul(&b[i], &domain.CosetTable[i])
-			c[i].Mul(&c[i], &domain.CosetTable[i])
-		}
-	})
-
-	domain.FFT(a, fft.DIT)
-	domain.FFT(b, fft.DIT)
-	domain.FFT(c, fft.DIT)
+	// the coset fft takes bit-reversed inputs (DIT), which matches the output of the DIF inverse fft
+	domain.FFT(a, fft.DIT, true, fft.WithNbTasks(nbTasks))
+	domain.FFT(b, fft.DIT, true, fft.WithNbTasks(nbTasks))
+	domain.FFT(c, fft.DIT, true, fft.WithNbTasks(nbTasks))
 
 	var minusTwoInv fr.Element
 	minusTwoInv.SetUint64(2)
@@ -256,16 +653,174 @@ func computeH(a, b, c []fr.Element, domain *fft.Domain) []fr.Element {
 				Sub(&a[i], &c[i]).
 				Mul(&a[i], &minusTwoInv)
 		}
-	})
+	}, nbTasks)
 
 	// ifft_coset
-	domain.FFTInverse(a, fft.DIF)
+	domain.FFTInverse(a, fft.DIF, true, fft.WithNbTasks(nbTasks))
 
 	utils.Parallelize(n, func(start, end int) {
 		for i := start; i < end; i++ {
-			a[i].Mul(&a[i], &domain.CosetTableInv[i]).FromMont()
+			a[i].FromMont()
 		}
-	})
+	}, nbTasks)
 
 	return a
 }
+
+// computeHAccelerated computes the same h as computeH, with the FFTs of the accelerator
+func computeHAccelerated(a, b, c []fr.Element, domain *fft.Domain, acc Accelerator, nbTasks int) ([]fr.Element, error) {
+	n := int(domain.Cardinality)
+	padding := make([]fr.Element, n-len(a))
+	a = append(a, padding...)
+	b = append(b, padding...)
+	c = append(c, padding...)
+
+	// evaluations on the coset: fft_coset(ifft(p))
+	for _, p := range [][]fr.Element{a, b, c} {
+		if err := acc.FFT(p, domain.Generator, fr.One(), true); err != nil {
+			return nil, err
+		}
+		if err := acc.FFT(p, domain.Generator, domain.GeneratorSqRt, false); err != nil {
+			return nil, err
+		}
+	}
+
+	var minusTwoInv fr.Element
+	minusTwoInv.SetUint64(2)
+	minusTwoInv.Neg(&minusTwoInv).
+		Inverse(&minusTwoInv)
+
+	utils.Parallelize(n, func(start, end int) {
+		for i := start; i < end; i++ {
+			a[i].Mul(&a[i], &b[i]).
+				Sub(&a[i], &c[i]).
+				Mul(&a[i], &minusTwoInv)
+		}
+	}, nbTasks)
+
+	if err := acc.FFT(a, domain.Generator, domain.GeneratorSqRt, true); err != nil {
+		return nil, err
+	}
+
+	// computeH outputs h in bit reversed order, and pk.G1.Z is ordered accordingly
+	fft.BitReverse(a)
+	utils.Parallelize(n, func(start, end int) {
+		for i := start; i < end; i++ {
+			a[i].FromMont()
+		}
+	}, nbTasks)
+
+	return a, nil
+}
+
+// computeHOutOfCore computes the same h as computeH, but the polynomials are stored in temporary files
+// in dir and at most maxElements elements are transformed in memory at once
+func computeHOutOfCore(a, b, c []fr.Element, domain *fft.Domain, dir string, maxElements, nbTasks int) ([]fr.Element, error) {
+	n := int(domain.Cardinality)
+
+	// one file per polynomial, and a scratch file for the ffts
+	files := make([]*os.File, 4)
+	for i := 0; i < len(files); i++ {
+		f, err := ioutil.TempFile(dir, "gnark_fft")
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			f.Close()
+			os.Remove(f.Name())
+		}()
+		files[i] = f
+	}
+	scratch := files[3]
+
+	// 1 - a, b, c evaluated on the coset: fft_coset(ifft(p))
+	polynomials := [][]fr.Element{a, b, c}
+	a, b, c = nil, nil, nil
+	padding := make([]fr.Element, maxElements)
+	for i := 0; i < len(polynomials); i++ {
+		p := polynomials[i]
+		if err := fft.WriteElements(files[i], 0, p); err != nil {
+			return nil, err
+		}
+		for offset := len(p); offset < n; offset += len(padding) {
+			end := offset + len(padding)
+			if end > n {
+				end = n
+			}
+			if err := fft.WriteElements(files[i], offset, padding[:end-offset]); err != nil {
+				return nil, err
+			}
+		}
+		polynomials[i] = nil // stored on disk
+
+		if err := domain.FFTInverseOutOfCore(scratch, files[i], maxElements, fft.WithNbTasks(nbTasks)); err != nil {
+			return nil, err
+		}
+		if err := fft.ScaleOutOfCore(scratch, n, domain.GeneratorSqRt, maxElements); err != nil {
+			return nil, err
+		}
+		if err := domain.FFTOutOfCore(files[i], scratch, maxElements, fft.WithNbTasks(nbTasks)); err != nil {
+			return nil, err
+		}
+	}
+
+	// 2 - (ca o cb - cc) / -2, by chunks, stored in the file of a
+	var minusTwoInv fr.Element
+	minusTwoInv.SetUint64(2)
+	minusTwoInv.Neg(&minusTwoInv).
+		Inverse(&minusTwoInv)
+
+	chunkSize := maxElements / 3
+	if chunkSize == 0 {
+		chunkSize = 1
+	}
+	ca := make([]fr.Element, chunkSize)
+	cb := make([]fr.Element, chunkSize)
+	cc := make([]fr.Element, chunkSize)
+	for start := 0; start < n; start += chunkSize {
+		end := start + chunkSize
+		if end > n {
+			end = n
+		}
+		m := end - start
+		if err := fft.ReadElements(files[0], start, ca[:m]); err != nil {
+			return nil, err
+		}
+		if err := fft.ReadElements(files[1], start, cb[:m]); err != nil {
+			return nil, err
+		}
+		if err := fft.ReadElements(files[2], start, cc[:m]); err != nil {
+			return nil, err
+		}
+		for i := 0; i < m; i++ {
+			ca[i].Mul(&ca[i], &cb[i]).
+				Sub(&ca[i], &cc[i]).
+				Mul(&ca[i], &minusTwoInv)
+		}
+		if err := fft.WriteElements(files[0], start, ca[:m]); err != nil {
+			return nil, err
+		}
+	}
+
+	// 3 - ifft_coset
+	if err := domain.FFTInverseOutOfCore(scratch, files[0], maxElements, fft.WithNbTasks(nbTasks)); err != nil {
+		return nil, err
+	}
+	if err := fft.ScaleOutOfCore(scratch, n, domain.GeneratorSqRtInv, maxElements); err != nil {
+		return nil, err
+	}
+	h := make([]fr.Element, n)
+	if err := fft.ReadElements(scratch, 0, h); err != nil {
+		return nil, err
+	}
+
+	// computeH outputs h in bit reversed order, and pk.G1.Z is ordered accordingly
+	fft.BitReverse(h)
+	utils.Parallelize(n, func(start, end int) {
+		for i := start; i < end; i++ {
+			h[i].FromMont()
+		}
+	}, nbTasks)
+
+	return h, nil
+}
@@ -21,10 +21,12 @@ import (
 	"fmt"
 	"io"
 	"math/big"
+	"sort"
 
 	"github.com/fxamacker/cbor/v2"
 
 	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/hint"
 	"github.com/consensys/gnark/backend/r1cs/r1c"
 	"github.com/consensys/gnark/internal/backend/ioutils"
 
@@ -43,12 +45,16 @@ type R1CS struct {
 	PublicWires   []string // public wire names, correctly ordered (the i-th entry is the name of the (offset+)i-th wire)
 	Logs          []backend.LogEntry
 	DebugInfo     []backend.LogEntry
+	Locations     []string // file:line of each constraint, if recorded (see frontend.WithSourceLocations)
 
 	// Constraints
 	NbConstraints   uint64 // total number of constraints
 	NbCOConstraints uint64 // number of constraints that need to be solved, the first of the Constraints slice
 	Constraints     []r1c.R1C
-	Coefficients    []fr.Element // R1C coefficients indexes point here
+	Coefficients    []fr.Element    // R1C coefficients indexes point here
+	Hints           []r1c.Hint      // internal wires computed by a hint function
+	Namespaces      []r1c.Namespace // runs of constraints recorded in a namespace of the frontend
+	Commitment      *r1c.Commitment // nil if the circuit has no commitment
 }
 
 // GetNbConstraints returns the total number of constraints
@@ -71,6 +77,25 @@ func (r1cs *R1CS) GetCurveID() gurvy.ID {
 	return gurvy.BLS381
 }
 
+// Fingerprint returns a hash (sha256) of the wires, constraints, hints and commitment of the R1CS: a
+// change of the circuit invalidating its proving and verifying keys changes it, and the debug
+// information (logs, source locations, namespaces) is not part of it
+func (r1cs *R1CS) Fingerprint() []byte {
+	var coeff big.Int
+	f := r1c.NewFingerprint(uint64(gurvy.BLS381), func(t r1c.Term) *big.Int {
+		return r1cs.Coefficients[t.CoeffID()].ToBigIntRegular(&coeff)
+	})
+	f.WriteUint64(r1cs.NbWires)
+	f.WriteUint64(r1cs.NbPublicWires)
+	f.WriteUint64(r1cs.NbSecretWires)
+	f.WriteStrings(r1cs.PublicWires)
+	f.WriteStrings(r1cs.SecretWires)
+	f.WriteUint64(r1cs.NbCOConstraints)
+	f.WriteConstraints(r1cs.Constraints)
+	f.WriteHints(r1cs.Hints, r1cs.Commitment)
+	return f.Sum()
+}
+
 // WriteTo encodes R1CS into provided io.Writer using cbor
 func (r1cs *R1CS) WriteTo(w io.Writer) (int64, error) {
 	_w := ioutils.WriterCounter{W: w} // wraps writer to count the bytes written
@@ -89,14 +114,130 @@ func (r1cs *R1CS) ReadFrom(r io.Reader) (int64, error) {
 	return int64(decoder.NumBytesRead()), err
 }
 
+// r1csHeader is the header section of a compressed R1CS
+type r1csHeader struct {
+	NbWires         uint64
+	NbPublicWires   uint64
+	NbSecretWires   uint64
+	SecretWires     []string
+	PublicWires     []string
+	NbConstraints   uint64
+	NbCOConstraints uint64
+	Hints           []r1c.Hint
+	Commitment      *r1c.Commitment
+}
+
+// r1csDebug is the debug section of a compressed R1CS
+type r1csDebug struct {
+	Logs       []backend.LogEntry
+	DebugInfo  []backend.LogEntry
+	Namespaces []r1c.Namespace
+	Locations  []string
+}
+
+// WriteCompressedTo encodes R1CS into provided io.Writer, split in sections (header, constraints,
+// coefficients and debug info) which are cbor encoded and compressed with zstd
+//
+// see ReadCompressedFrom
+func (r1cs *R1CS) WriteCompressedTo(w io.Writer) (int64, error) {
+	sections := make([]interface{}, ioutils.NbSections)
+	sections[ioutils.SectionHeader] = r1csHeader{
+		NbWires:         r1cs.NbWires,
+		NbPublicWires:   r1cs.NbPublicWires,
+		NbSecretWires:   r1cs.NbSecretWires,
+		SecretWires:     r1cs.SecretWires,
+		PublicWires:     r1cs.PublicWires,
+		NbConstraints:   r1cs.NbConstraints,
+		NbCOConstraints: r1cs.NbCOConstraints,
+		Hints:           r1cs.Hints,
+		Commitment:      r1cs.Commitment,
+	}
+	sections[ioutils.SectionConstraints] = r1cs.Constraints
+	sections[ioutils.SectionCoefficients] = r1cs.Coefficients
+	sections[ioutils.SectionDebug] = r1csDebug{Logs: r1cs.Logs, DebugInfo: r1cs.DebugInfo, Namespaces: r1cs.Namespaces, Locations: r1cs.Locations}
+
+	encoded := make([][]byte, len(sections))
+	for i := 0; i < len(sections); i++ {
+		var err error
+		if encoded[i], err = cbor.Marshal(sections[i]); err != nil {
+			return 0, err
+		}
+	}
+
+	return ioutils.WriteSections(w, encoded)
+}
+
+// ReadCompressedFrom decodes a R1CS written by WriteCompressedTo
+//
+// only the header and the sections set in the load bit mask (ioutils.LoadConstraints, ...) are decompressed;
+// the other fields of the R1CS are left empty
+func (r1cs *R1CS) ReadCompressedFrom(r io.ReaderAt, load int) error {
+	sr, err := ioutils.NewSectionReader(r)
+	if err != nil {
+		return err
+	}
+	if sr.NbSections() != ioutils.NbSections {
+		return ioutils.ErrInvalidSections
+	}
+
+	decode := func(section int, v interface{}) error {
+		data, err := sr.Section(section)
+		if err != nil {
+			return err
+		}
+		return cbor.Unmarshal(data, v)
+	}
+
+	var header r1csHeader
+	if err := decode(ioutils.SectionHeader, &header); err != nil {
+		return err
+	}
+	*r1cs = R1CS{
+		NbWires:         header.NbWires,
+		NbPublicWires:   header.NbPublicWires,
+		NbSecretWires:   header.NbSecretWires,
+		SecretWires:     header.SecretWires,
+		PublicWires:     header.PublicWires,
+		NbConstraints:   header.NbConstraints,
+		NbCOConstraints: header.NbCOConstraints,
+		Hints:           header.Hints,
+		Commitment:      header.Commitment,
+	}
+
+	if load&ioutils.LoadConstraints != 0 {
+		if err := decode(ioutils.SectionConstraints, &r1cs.Constraints); err != nil {
+			return err
+		}
+	}
+	if load&ioutils.LoadCoefficients != 0 {
+		if err := decode(ioutils.SectionCoefficients, &r1cs.Coefficients); err != nil {
+			return err
+		}
+	}
+	if load&ioutils.LoadDebug != 0 {
+		var debug r1csDebug
+		if err := decode(ioutils.SectionDebug, &debug); err != nil {
+			return err
+		}
+		r1cs.Logs = debug.Logs
+		r1cs.DebugInfo = debug.DebugInfo
+		r1cs.Namespaces = debug.Namespaces
+		r1cs.Locations = debug.Locations
+	}
+
+	return nil
+}
+
 // IsSolved returns nil if given assignment solves the R1CS and error otherwise
 // this method wraps r1cs.Solve() and allocates r1cs.Solve() inputs
+//
+// the logs of the circuit (see frontend.ConstraintSystem.Println) are printed with the solved values
 func (r1cs *R1CS) IsSolved(assignment map[string]interface{}) error {
 	a := make([]fr.Element, r1cs.NbConstraints)
 	b := make([]fr.Element, r1cs.NbConstraints)
 	c := make([]fr.Element, r1cs.NbConstraints)
 	wireValues := make([]fr.Element, r1cs.NbWires)
-	return r1cs.Solve(assignment, a, b, c, wireValues)
+	return r1cs.solve(assignment, a, b, c, wireValues, true, nil, nil)
 }
 
 // Solve sets all the wires and returns the a, b, c vectors.
@@ -104,7 +245,26 @@ func (r1cs *R1CS) IsSolved(assignment map[string]interface{}) error {
 // assignment: map[string]value: contains the input variables
 // a, b, c vectors: ab-c = hz
 // wireValues =  [intermediateVariables | privateInputs | publicInputs]
+//
+// Solve is called by the prover, and doesn't print the logs of the circuit (IsSolved does)
 func (r1cs *R1CS) Solve(assignment map[string]interface{}, a, b, c, wireValues []fr.Element) error {
+	return r1cs.solve(assignment, a, b, c, wireValues, false, nil, nil)
+}
+
+// SolveWithHints behaves like Solve, but the wires of the hints whose ID is a key of overrides are computed
+// by the given functions instead of the registered ones (the prover replaces the commitment of the
+// circuit, see r1c.Commitment)
+func (r1cs *R1CS) SolveWithHints(assignment map[string]interface{}, a, b, c, wireValues []fr.Element, overrides map[hint.ID]hint.Function) error {
+	return r1cs.solve(assignment, a, b, c, wireValues, false, overrides, nil)
+}
+
+// SolveTrace behaves like SolveWithHints, and sets trace to the values of the wires and the status of
+// the constraints once the solver returns, whether it succeeds or not (see backend.WithSolverTrace)
+func (r1cs *R1CS) SolveTrace(assignment map[string]interface{}, a, b, c, wireValues []fr.Element, overrides map[hint.ID]hint.Function, trace *backend.SolverTrace) error {
+	return r1cs.solve(assignment, a, b, c, wireValues, false, overrides, trace)
+}
+
+func (r1cs *R1CS) solve(assignment map[string]interface{}, a, b, c, wireValues []fr.Element, printLogs bool, overrides map[hint.ID]hint.Function, trace *backend.SolverTrace) error {
 	// compute the wires and the a, b, c polynomials
 	if len(a) != int(r1cs.NbConstraints) || len(b) != int(r1cs.NbConstraints) || len(c) != int(r1cs.NbConstraints) || len(wireValues) != int(r1cs.NbWires) {
 		return errors.New("invalid input size: len(a, b, c) == r1cs.NbConstraints and len(wireValues) == r1cs.NbWires")
@@ -112,12 +272,16 @@ func (r1cs *R1CS) Solve(assignment map[string]interface{}, a, b, c, wireValues [
 
 	// keep track of wire that have a value
 	wireInstantiated := make([]bool, r1cs.NbWires)
+	if trace != nil {
+		defer r1cs.setTrace(trace, wireValues, wireInstantiated)
+	}
 
 	// instantiate the public/ private inputs
 	// note that currently, there is a convertion from interface{} to fr.Element for each entry in the
 	// assignment map. It can cost a SetBigInt() which converts from Regular ton Montgomery rep (1 mul)
 	// while it's unlikely to be noticeable compared to the FFT and the MultiExp compute times,
 	// there should be a faster (statically typed) path
+	modulus := fr.Modulus()
 	instantiateInputs := func(offset int, inputNames []string) error {
 		for i := 0; i < len(inputNames); i++ {
 			name := inputNames[i]
@@ -126,7 +290,9 @@ func (r1cs *R1CS) Solve(assignment map[string]interface{}, a, b, c, wireValues [
 				wireInstantiated[i+offset] = true
 			} else {
 				if val, ok := assignment[name]; ok {
-					wireValues[i+offset].SetInterface(val)
+					if err := setInput(&wireValues[i+offset], val, modulus); err != nil {
+						return fmt.Errorf("%q: %w", name, err)
+					}
 					wireInstantiated[i+offset] = true
 				} else {
 					return fmt.Errorf("%q: %w", name, backend.ErrInputNotSet)
@@ -152,7 +318,17 @@ func (r1cs *R1CS) Solve(assignment map[string]interface{}, a, b, c, wireValues [
 
 	// now that we know all inputs are set, defer log printing once all wireValues are computed
 	// (or sooner, if a constraint is not satisfied)
-	defer r1cs.printLogs(wireValues, wireInstantiated)
+	if printLogs {
+		defer r1cs.printLogs(wireValues, wireInstantiated)
+	}
+
+	// the wires computed by a hint are solved when a constraint first uses them
+	hints := make(map[int]*r1c.Hint, len(r1cs.Hints))
+	for i := 0; i < len(r1cs.Hints); i++ {
+		for _, w := range r1cs.Hints[i].Outputs() {
+			hints[w] = &r1cs.Hints[i]
+		}
+	}
 
 	// check if there is an inconsistant constraint
 	var check fr.Element
@@ -161,18 +337,34 @@ func (r1cs *R1CS) Solve(assignment map[string]interface{}, a, b, c, wireValues [
 	for i := 0; i < int(r1cs.NbCOConstraints); i++ {
 
 		// solve the constraint, this will compute the missing wire of the gate
-		r1cs.solveR1C(&r1cs.Constraints[i], wireInstantiated, wireValues)
+		if err := r1cs.solveR1C(&r1cs.Constraints[i], hints, overrides, wireInstantiated, wireValues); err != nil {
+			if err == errDivisionByZero {
+				return fmt.Errorf("%w: %s%s%s", backend.ErrUnsatisfiedConstraint, r1cs.inNamespace(i), err, r1cs.atLocation(i))
+			}
+			return err
+		}
 
 		// at this stage we are guaranteed that a[i]*b[i]=c[i]
-		// if not, it means there is a bug in the solver
+		// if not, it means there is a bug in the solver (or a value which doesn't fit in the bits of its
+		// binary decomposition)
 		a[i], b[i], c[i] = instantiateR1C(&r1cs.Constraints[i], r1cs, wireValues)
 
 		check.Mul(&a[i], &b[i])
 		if !check.Equal(&c[i]) {
+			if r1cs.Constraints[i].Solver == r1c.BinaryDec {
+				return fmt.Errorf("%w: %s%s doesn't fit in %d bits%s", backend.ErrUnsatisfiedConstraint, r1cs.inNamespace(i), c[i].String(), len(r1cs.Constraints[i].L), r1cs.atLocation(i))
+			}
 			panic("error solving r1c: " + a[i].String() + "*" + b[i].String() + "=" + c[i].String())
 		}
 	}
 
+	// the hints no computational constraint uses
+	for i := 0; i < len(r1cs.Hints); i++ {
+		if err := r1cs.solveHint(&r1cs.Hints[i], hints, overrides, wireInstantiated, wireValues); err != nil {
+			return err
+		}
+	}
+
 	// Loop through the assertions -- here all wireValues should be instantiated
 	// if a[i] * b[i] != c[i]; it means the constraint is not satisfied
 	for i := int(r1cs.NbCOConstraints); i < len(r1cs.Constraints); i++ {
@@ -186,19 +378,93 @@ func (r1cs *R1CS) Solve(assignment map[string]interface{}, a, b, c, wireValues [
 		if !check.Equal(&c[i]) {
 			debugInfo := r1cs.DebugInfo[i-int(r1cs.NbCOConstraints)]
 			debugInfoStr := r1cs.logValue(debugInfo, wireValues, wireInstantiated)
-			return fmt.Errorf("%w: %s", backend.ErrUnsatisfiedConstraint, debugInfoStr)
+			return fmt.Errorf("%w: %s%s%s", backend.ErrUnsatisfiedConstraint, r1cs.inNamespace(i), debugInfoStr, r1cs.atLocation(i))
+		}
+	}
+
+	return nil
+}
+
+// setTrace sets trace to the values of the instantiated wires and the status of the constraints
+func (r1cs *R1CS) setTrace(trace *backend.SolverTrace, wireValues []fr.Element, wireInstantiated []bool) {
+	trace.Wires = make([]*big.Int, len(wireValues))
+	for i := range wireValues {
+		if wireInstantiated[i] {
+			trace.Wires[i] = new(big.Int)
+			wireValues[i].ToBigIntRegular(trace.Wires[i])
+		}
+	}
+
+	trace.Constraints = make([]backend.ConstraintStatus, len(r1cs.Constraints))
+	var check fr.Element
+	for i := range r1cs.Constraints {
+		r := &r1cs.Constraints[i]
+		solved := true
+		for _, l := range []r1c.LinearExpression{r.L, r.R, r.O} {
+			for _, t := range l {
+				solved = solved && wireInstantiated[t.VariableID()]
+			}
+		}
+		if !solved {
+			trace.Constraints[i] = backend.ConstraintUnsolved
+			continue
+		}
+		a, b, c := instantiateR1C(r, r1cs, wireValues)
+		if check.Mul(&a, &b); !check.Equal(&c) {
+			trace.Constraints[i] = backend.ConstraintViolated
 		}
 	}
+}
 
+// setInput sets z to the value of an input (see backend.ToBigInt), if its absolute value is smaller than
+// the modulus
+func setInput(z *fr.Element, val interface{}, modulus *big.Int) error {
+	switch v := val.(type) {
+	case fr.Element:
+		z.Set(&v)
+		return nil
+	case *fr.Element:
+		z.Set(v)
+		return nil
+	}
+	v, err := backend.ToBigInt(val)
+	if err != nil {
+		return err
+	}
+	if v.CmpAbs(modulus) >= 0 {
+		return backend.ErrInputOverflow
+	}
+	z.SetBigInt(&v)
 	return nil
 }
 
+// inNamespace returns the prefix "namespace: " of the error messages of a constraint recorded in a
+// namespace of the frontend, or ""
+func (r1cs *R1CS) inNamespace(constraint int) string {
+	i := sort.Search(len(r1cs.Namespaces), func(i int) bool { return r1cs.Namespaces[i].Constraint > constraint })
+	if i == 0 || r1cs.Namespaces[i-1].Name == "" {
+		return ""
+	}
+	return r1cs.Namespaces[i-1].Name + ": "
+}
+
+// atLocation returns the suffix " (at file:line)" of the error messages of a constraint, if the frontend
+// recorded its source location, or ""
+func (r1cs *R1CS) atLocation(constraint int) string {
+	if constraint >= len(r1cs.Locations) || r1cs.Locations[constraint] == "" {
+		return ""
+	}
+	return " (at " + r1cs.Locations[constraint] + ")"
+}
+
 func (r1cs *R1CS) logValue(entry backend.LogEntry, wireValues []fr.Element, wireInstantiated []bool) string {
 	var toResolve []interface{}
 	for j := 0; j < len(entry.ToResolve); j++ {
 		wireID := entry.ToResolve[j]
 		if !wireInstantiated[wireID] {
-			panic("wire values was not instantiated")
+			// the solver stopped at an unsatisfied constraint before computing this wire
+			toResolve = append(toResolve, "<unsolved>")
+			continue
 		}
 		toResolve = append(toResolve, wireValues[wireID].String())
 	}
@@ -276,7 +542,20 @@ func instantiateR1C(r *r1c.R1C, r1cs *R1CS, wireValues []fr.Element) (a, b, c fr
 // alone, or it can be computed without ambiguity using the other computed wires
 // , eg when doing a binary decomposition: either way the missing wire can
 // be computed without ambiguity because the r1cs is correctly ordered)
-func (r1cs *R1CS) solveR1C(r *r1c.R1C, wireInstantiated []bool, wireValues []fr.Element) {
+func (r1cs *R1CS) solveR1C(r *r1c.R1C, hints map[int]*r1c.Hint, overrides map[hint.ID]hint.Function, wireInstantiated []bool, wireValues []fr.Element) error {
+
+	// the wires computed by a hint are not solved by the constraint
+	if len(hints) != 0 {
+		for _, l := range []r1c.LinearExpression{r.L, r.R, r.O} {
+			for _, t := range l {
+				if h, ok := hints[t.VariableID()]; ok {
+					if err := r1cs.solveHint(h, hints, overrides, wireInstantiated, wireValues); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
 
 	switch r.Solver {
 
@@ -318,24 +597,30 @@ func (r1cs *R1CS) solveR1C(r *r1c.R1C, wireInstantiated []bool, wireValues []fr.
 		// ensure we found the unset wire
 		if loc == 0 {
 			// this wire may have been instantiated as part of moExpression already
-			return
+			return nil
 		}
 
 		// we compute the wire value and instantiate it
 		cID := termToCompute.VariableID()
 
+		// if the wire is multiplied by 0, the constraint holds for any value (0 is chosen) if c is 0, and
+		// for none otherwise
 		switch loc {
 		case 1:
 			if !b.IsZero() {
 				wireValues[cID].Div(&c, &b).
 					Sub(&wireValues[cID], &a)
 				r1cs.mulWireByCoeff(&wireValues[cID], termToCompute)
+			} else if !c.IsZero() {
+				return errDivisionByZero
 			}
 		case 2:
 			if !a.IsZero() {
 				wireValues[cID].Div(&c, &a).
 					Sub(&wireValues[cID], &b)
 				r1cs.mulWireByCoeff(&wireValues[cID], termToCompute)
+			} else if !c.IsZero() {
+				return errDivisionByZero
 			}
 		case 3:
 			wireValues[cID].Mul(&a, &b).
@@ -404,4 +689,72 @@ func (r1cs *R1CS) solveR1C(r *r1c.R1C, wireInstantiated []bool, wireValues []fr.
 	default:
 		panic("unimplemented solving method")
 	}
+
+	return nil
+}
+
+// errDivisionByZero is returned by solveR1C when the wire to compute is multiplied by 0, and the
+// constraint has no solution
+var errDivisionByZero = errors.New("division by 0")
+
+// solveHint computes the wires of a hint, if they are not instantiated yet, from the values of its
+// inputs; the inputs computed by a hint are solved first
+func (r1cs *R1CS) solveHint(h *r1c.Hint, hints map[int]*r1c.Hint, overrides map[hint.ID]hint.Function, wireInstantiated []bool, wireValues []fr.Element) error {
+	if wireInstantiated[h.Wire] {
+		return nil
+	}
+	var f hint.Function
+	var fMulti hint.MultiFunction
+	var ok bool
+	if len(h.Wires) != 0 {
+		fMulti, ok = hint.LookupMulti(h.ID)
+	} else if f, ok = overrides[h.ID]; !ok {
+		f, ok = hint.Lookup(h.ID)
+	}
+	if !ok {
+		return fmt.Errorf("%w: %d", hint.ErrUnknownHint, h.ID)
+	}
+
+	inputs := make([]*big.Int, len(h.Inputs))
+	for i := 0; i < len(h.Inputs); i++ {
+		var v fr.Element
+		for _, t := range h.Inputs[i] {
+			cID := t.VariableID()
+			if !wireInstantiated[cID] {
+				input, ok := hints[cID]
+				if !ok {
+					return fmt.Errorf("input of hint %d is not instantiated", h.ID)
+				}
+				if err := r1cs.solveHint(input, hints, overrides, wireInstantiated, wireValues); err != nil {
+					return err
+				}
+			}
+			r1cs.AddTerm(&v, t, wireValues[cID])
+		}
+		inputs[i] = new(big.Int)
+		v.ToBigIntRegular(inputs[i])
+	}
+
+	if fMulti != nil {
+		results := make([]*big.Int, len(h.Wires))
+		for i := range results {
+			results[i] = new(big.Int)
+		}
+		if err := fMulti(gurvy.BLS381, inputs, results); err != nil {
+			return fmt.Errorf("hint %d: %w", h.ID, err)
+		}
+		for i, w := range h.Wires {
+			wireValues[w].SetBigInt(results[i])
+			wireInstantiated[w] = true
+		}
+		return nil
+	}
+
+	var result big.Int
+	if err := f(gurvy.BLS381, inputs, &result); err != nil {
+		return fmt.Errorf("hint %d: %w", h.ID, err)
+	}
+	wireValues[h.Wire].SetBigInt(&result)
+	wireInstantiated[h.Wire] = true
+	return nil
 }
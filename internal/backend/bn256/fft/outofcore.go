@@ -0,0 +1,226 @@
+// Copyright 2020 ConsenSys Software Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by gnark DO NOT EDIT
+
+package fft
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/big"
+	"math/bits"
+
+	"github.com/consensys/gurvy/bn256/fr"
+)
+
+// ElementStore stores a vector of fr.Element outside of RAM (typically, a *os.File)
+//
+// the i-th element is encoded with its fr.Limbs raw (montgomery) words in little endian
+// at offset i*fr.Bytes; see ReadElements and WriteElements
+type ElementStore interface {
+	io.ReaderAt
+	io.WriterAt
+}
+
+// ErrMemoryLimit is returned by the out-of-core functions when maxElements is too small for the domain
+var ErrMemoryLimit = errors.New("out-of-core fft: memory limit too small for domain cardinality")
+
+// ReadElements reads len(dst) elements from store, starting at the offset-th element
+func ReadElements(store ElementStore, offset int, dst []fr.Element) error {
+	buf := make([]byte, len(dst)*fr.Bytes)
+	if _, err := store.ReadAt(buf, int64(offset)*fr.Bytes); err != nil {
+		return err
+	}
+	for i := 0; i < len(dst); i++ {
+		for j := 0; j < fr.Limbs; j++ {
+			dst[i][j] = binary.LittleEndian.Uint64(buf[i*fr.Bytes+j*8:])
+		}
+	}
+	return nil
+}
+
+// WriteElements writes src in store, starting at the offset-th element
+func WriteElements(store ElementStore, offset int, src []fr.Element) error {
+	buf := make([]byte, len(src)*fr.Bytes)
+	for i := 0; i < len(src); i++ {
+		for j := 0; j < fr.Limbs; j++ {
+			binary.LittleEndian.PutUint64(buf[i*fr.Bytes+j*8:], src[i][j])
+		}
+	}
+	_, err := store.WriteAt(buf, int64(offset)*fr.Bytes)
+	return err
+}
+
+// FFTOutOfCore computes the discrete Fourier transform of the domain.Cardinality elements in src and writes the result in dst
+//
+// input and output are in natural order. It implements the "four-step" FFT: the vector is seen as a
+// n1 x n2 matrix, on which we do n2 FFTs of size n1 (columns), a twiddle multiplication and n1 FFTs of
+// size n2 (rows), followed by a blocked transposition. At most maxElements are loaded in memory at once, and
+// maxElements must be larger than sqrt(domain.Cardinality).
+// The content of src is modified. The options are passed to the in-memory FFTs.
+func (domain *Domain) FFTOutOfCore(dst, src ElementStore, maxElements int, opts ...Option) error {
+	return domain.fftOutOfCore(dst, src, maxElements, false, opts)
+}
+
+// FFTInverseOutOfCore computes the inverse discrete Fourier transform of the domain.Cardinality elements in src
+// and writes the result in dst
+//
+// see FFTOutOfCore for the constraints on the parameters
+func (domain *Domain) FFTInverseOutOfCore(dst, src ElementStore, maxElements int, opts ...Option) error {
+	return domain.fftOutOfCore(dst, src, maxElements, true, opts)
+}
+
+// ScaleOutOfCore sets store[i] = store[i] * x^i for i < n, loading at most maxElements in memory at once
+//
+// it is typically used with x = domain.GeneratorSqRt (or its inverse) to move to (or from) the coset
+func ScaleOutOfCore(store ElementStore, n int, x fr.Element, maxElements int) error {
+	if maxElements < 1 {
+		return ErrMemoryLimit
+	}
+	buf := make([]fr.Element, min(n, maxElements))
+	for start := 0; start < n; start += len(buf) {
+		chunk := buf[:min(len(buf), n-start)]
+		if err := ReadElements(store, start, chunk); err != nil {
+			return err
+		}
+		var xi fr.Element
+		xi.Exp(x, new(big.Int).SetUint64(uint64(start)))
+		for i := 0; i < len(chunk); i++ {
+			chunk[i].MulAssign(&xi)
+			xi.MulAssign(&x)
+		}
+		if err := WriteElements(store, start, chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (domain *Domain) fftOutOfCore(dst, src ElementStore, maxElements int, inverse bool, opts []Option) error {
+	n := int(domain.Cardinality)
+
+	// n = n1 * n2, with n1 >= n2
+	logN := bits.TrailingZeros64(domain.Cardinality)
+	n2 := 1 << (logN / 2)
+	n1 := n / n2
+	if maxElements < n1 {
+		return ErrMemoryLimit
+	}
+
+	// ω_n1 = ω^n2 and ω_n2 = ω^n1, as all domains are built from the same root of unity
+	domainN1 := NewDomain(uint64(n1))
+	domainN2 := NewDomain(uint64(n2))
+
+	subFFT := func(d *Domain, a []fr.Element) {
+		if inverse {
+			d.FFTInverse(a, DIF, false, opts...)
+		} else {
+			d.FFT(a, DIF, false, opts...)
+		}
+		BitReverse(a)
+	}
+	w := domain.Generator
+	if inverse {
+		w = domain.GeneratorInv
+	}
+
+	// 1 - FFTs on the columns (by panels of width b), and twiddle factors:
+	// Y[k1][j2] = ω^(j2*k1) * Σ_j1 x[j1][j2] * ω_n1^(j1*k1)
+	b := prevPowerOfTwo(min(n2, maxElements/n1))
+	panel := make([]fr.Element, b*n1)
+	column := make([]fr.Element, n1)
+	for c0 := 0; c0 < n2; c0 += b {
+		for j1 := 0; j1 < n1; j1++ {
+			if err := ReadElements(src, j1*n2+c0, panel[j1*b:(j1+1)*b]); err != nil {
+				return err
+			}
+		}
+		for c := 0; c < b; c++ {
+			for j1 := 0; j1 < n1; j1++ {
+				column[j1] = panel[j1*b+c]
+			}
+			subFFT(domainN1, column)
+
+			// twiddle factors ω^((c0+c)*k1)
+			var wj2, wk fr.Element
+			wj2.Exp(w, new(big.Int).SetUint64(uint64(c0+c)))
+			wk.SetOne()
+			for k1 := 0; k1 < n1; k1++ {
+				panel[k1*b+c].Mul(&column[k1], &wk)
+				wk.MulAssign(&wj2)
+			}
+		}
+		for k1 := 0; k1 < n1; k1++ {
+			if err := WriteElements(src, k1*n2+c0, panel[k1*b:(k1+1)*b]); err != nil {
+				return err
+			}
+		}
+	}
+
+	// 2 - FFTs on the rows: Z[k1][k2] = Σ_j2 Y[k1][j2] * ω_n2^(j2*k2)
+	nbRows := prevPowerOfTwo(min(n1, maxElements/n2))
+	rows := make([]fr.Element, nbRows*n2)
+	for r0 := 0; r0 < n1; r0 += nbRows {
+		if err := ReadElements(src, r0*n2, rows); err != nil {
+			return err
+		}
+		for r := 0; r < nbRows; r++ {
+			subFFT(domainN2, rows[r*n2:(r+1)*n2])
+		}
+		if err := WriteElements(src, r0*n2, rows); err != nil {
+			return err
+		}
+	}
+
+	// 3 - transposition by tiles of size t x t: X[k1 + n1*k2] = Z[k1][k2]
+	t := 1
+	for 4*t*t <= maxElements && 2*t <= n2 {
+		t *= 2
+	}
+	tile := make([]fr.Element, t*t)
+	line := make([]fr.Element, t)
+	for r0 := 0; r0 < n1; r0 += t {
+		for c0 := 0; c0 < n2; c0 += t {
+			for r := 0; r < t; r++ {
+				if err := ReadElements(src, (r0+r)*n2+c0, tile[r*t:(r+1)*t]); err != nil {
+					return err
+				}
+			}
+			for c := 0; c < t; c++ {
+				for r := 0; r < t; r++ {
+					line[r] = tile[r*t+c]
+				}
+				if err := WriteElements(dst, (c0+c)*n1+r0, line); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// prevPowerOfTwo returns the largest power of 2 <= n (n > 0)
+func prevPowerOfTwo(n int) int {
+	return 1 << (bits.Len(uint(n)) - 1)
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
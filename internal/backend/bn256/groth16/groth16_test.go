@@ -24,7 +24,14 @@ import (
 	bn256backend "github.com/consensys/gnark/internal/backend/bn256"
 
 	"bytes"
+	"context"
+	"encoding/json"
 	"github.com/fxamacker/cbor/v2"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync/atomic"
 	"testing"
 
 	bn256groth16 "github.com/consensys/gnark/internal/backend/bn256/groth16"
@@ -48,6 +55,900 @@ func TestCircuits(t *testing.T) {
 	}
 }
 
+func TestSetupProgress(t *testing.T) {
+	r1cs := circuits.Circuits["reference_small"].R1CS.ToR1CS(curve.ID)
+
+	last := make(map[string]int)
+	progress := func(step string, done, total int) {
+		if done > total || done < last[step] {
+			t.Fatalf("inconsistent progress for step %s: %d/%d", step, done, total)
+		}
+		last[step] = done
+	}
+
+	var pk bn256groth16.ProvingKey
+	var vk bn256groth16.VerifyingKey
+	if err := bn256groth16.Setup(r1cs.(*bn256backend.R1CS), &pk, &vk, backend.WithProgress(progress)); err != nil {
+		t.Fatal(err)
+	}
+	for _, step := range []string{"lagrange", "g1", "g2"} {
+		if _, ok := last[step]; !ok {
+			t.Fatalf("step %s wasn't reported", step)
+		}
+	}
+
+	if err := bn256groth16.Setup(r1cs.(*bn256backend.R1CS), &pk, &vk, backend.WithProgress(nil)); err == nil {
+		t.Fatal("expected error with nil progress callback")
+	}
+}
+
+func TestUpdateKeys(t *testing.T) {
+	compile := func(nbConstraints int) (r1cs.R1CS, map[string]interface{}) {
+		circuit := refCircuit{nbConstraints: nbConstraints}
+		r1cs, err := frontend.Compile(curve.ID, &circuit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var expectedY fr.Element
+		expectedY.SetUint64(2)
+		for i := 0; i < nbConstraints; i++ {
+			expectedY.Mul(&expectedY, &expectedY)
+		}
+		return r1cs, map[string]interface{}{"X": 2, "Y": expectedY}
+	}
+
+	// the new circuit appends constraints and internal wires to the old one
+	oldR1CS, _ := compile(10)
+	newR1CS, good := compile(13)
+
+	// the trapdoor isn't exposed by the groth16 package (see backend/groth16/devsetup)
+	var pk bn256groth16.ProvingKey
+	var vk bn256groth16.VerifyingKey
+	trapdoor, err := bn256groth16.UnsafeSetup(oldR1CS.(*bn256backend.R1CS), &pk, &vk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bn256groth16.UpdateKeys(oldR1CS.(*bn256backend.R1CS), newR1CS.(*bn256backend.R1CS), &pk, &vk, trapdoor); err != nil {
+		t.Fatal(err)
+	}
+	proof, err := groth16.Prove(newR1CS, &pk, good)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := groth16.Verify(proof, &vk, good); err != nil {
+		t.Fatal(err)
+	}
+
+	// the domain of the keys is too small
+	largeR1CS, _ := compile(20)
+	if err := bn256groth16.UpdateKeys(newR1CS.(*bn256backend.R1CS), largeR1CS.(*bn256backend.R1CS), &pk, &vk, trapdoor); err == nil {
+		t.Fatal("expected error when the constraints don't fit in the fft domain")
+	}
+}
+
+func TestProveOutOfCoreFFT(t *testing.T) {
+	circuit := refCircuit{nbConstraints: 100}
+	r1cs, err := frontend.Compile(curve.ID, &circuit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expectedY fr.Element
+	expectedY.SetUint64(2)
+	for i := 0; i < circuit.nbConstraints; i++ {
+		expectedY.Mul(&expectedY, &expectedY)
+	}
+	good := map[string]interface{}{"X": 2, "Y": expectedY}
+
+	pk, vk, err := groth16.Setup(r1cs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the domain has 128 elements, the polynomials are transformed by blocks of 16
+	proof, err := groth16.ProveWithOptions(r1cs, pk, good, backend.WithOutOfCoreFFT(t.TempDir(), 16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := groth16.Verify(proof, vk, good); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := groth16.ProveWithOptions(r1cs, pk, good, backend.WithOutOfCoreFFT(t.TempDir(), 4)); err == nil {
+		t.Fatal("expected error with a memory limit smaller than sqrt(domain cardinality)")
+	}
+}
+
+// testAccelerator implements the Accelerator interface on the CPU, with naive FFTs, and counts the calls
+type testAccelerator struct {
+	nbMultiExp, nbFFT int32
+}
+
+func (acc *testAccelerator) MultiExpG1(points []curve.G1Affine, scalars []fr.Element) (curve.G1Jac, error) {
+	atomic.AddInt32(&acc.nbMultiExp, 1)
+	var res curve.G1Jac
+	res.MultiExp(points, scalars)
+	return res, nil
+}
+
+func (acc *testAccelerator) MultiExpG2(points []curve.G2Affine, scalars []fr.Element) (curve.G2Jac, error) {
+	atomic.AddInt32(&acc.nbMultiExp, 1)
+	var res curve.G2Jac
+	res.MultiExp(points, scalars)
+	return res, nil
+}
+
+func (acc *testAccelerator) FFT(a []fr.Element, omega, shift fr.Element, inverse bool) error {
+	atomic.AddInt32(&acc.nbFFT, 1)
+	n := len(a)
+	res := make([]fr.Element, n)
+	if !inverse {
+		// res[i] = Σ a[j].(shift.ωⁱ)ʲ
+		x := shift
+		for i := range res {
+			var xj fr.Element
+			xj.SetOne()
+			for j := range a {
+				var t fr.Element
+				t.Mul(&a[j], &xj)
+				res[i].Add(&res[i], &t)
+				xj.Mul(&xj, &x)
+			}
+			x.Mul(&x, &omega)
+		}
+	} else {
+		// res[j] = shift⁻ʲ/n . Σ a[i].ω⁻ⁱʲ
+		var omegaInv, c fr.Element
+		omegaInv.Inverse(&omega)
+		c.SetUint64(uint64(n)).Inverse(&c)
+		var shiftInv fr.Element
+		shiftInv.Inverse(&shift)
+		var omegaJ fr.Element // ω⁻ʲ
+		omegaJ.SetOne()
+		for j := range res {
+			var x fr.Element
+			x.SetOne()
+			for i := range a {
+				var t fr.Element
+				t.Mul(&a[i], &x)
+				res[j].Add(&res[j], &t)
+				x.Mul(&x, &omegaJ)
+			}
+			res[j].Mul(&res[j], &c)
+			c.Mul(&c, &shiftInv)
+			omegaJ.Mul(&omegaJ, &omegaInv)
+		}
+	}
+	copy(a, res)
+	return nil
+}
+
+func TestProveAccelerator(t *testing.T) {
+	circuit := refCircuit{nbConstraints: 10}
+	r1cs, err := frontend.Compile(curve.ID, &circuit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expectedY fr.Element
+	expectedY.SetUint64(2)
+	for i := 0; i < circuit.nbConstraints; i++ {
+		expectedY.Mul(&expectedY, &expectedY)
+	}
+	good := map[string]interface{}{"X": 2, "Y": expectedY}
+
+	pk, vk, err := groth16.Setup(r1cs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var acc testAccelerator
+	var _ groth16.AcceleratorBN256 = &acc
+	proof, err := groth16.ProveWithOptions(r1cs, pk, good, backend.WithAccelerator(&acc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := groth16.Verify(proof, vk, good); err != nil {
+		t.Fatal(err)
+	}
+	// A, B in G1 and G2, the 2 parts of Krs; 6 FFTs for a, b, c and 1 for h
+	if acc.nbMultiExp != 5 || acc.nbFFT != 7 {
+		t.Fatal("unexpected calls to the accelerator:", acc.nbMultiExp, "multi exponentiations,", acc.nbFFT, "ffts")
+	}
+
+	if _, err := groth16.ProveWithOptions(r1cs, pk, good, backend.WithAccelerator(struct{}{})); err == nil {
+		t.Fatal("expected error with an accelerator of another curve")
+	}
+}
+
+func TestProveFromFile(t *testing.T) {
+	for _, name := range []string{"reference_small", "commit"} {
+		t.Run(name, func(t *testing.T) {
+			circuit := circuits.Circuits[name]
+			r1cs := circuit.R1CS.ToR1CS(curve.ID)
+			pk, vk, err := groth16.Setup(r1cs)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, raw := range []bool{true, false} {
+				var buf bytes.Buffer
+				if raw {
+					_, err = pk.WriteRawTo(&buf)
+				} else {
+					_, err = pk.WriteTo(&buf)
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				// chunks of 3 points, the key slices aren't multiples of 3
+				proof, err := groth16.ProveFromFile(r1cs, bytes.NewReader(buf.Bytes()), circuit.Good, backend.WithKeyChunkSize(3))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := groth16.Verify(proof, vk, circuit.Public); err != nil {
+					t.Fatal(err)
+				}
+
+				if _, err := groth16.ProveFromFile(r1cs, bytes.NewReader(buf.Bytes()), circuit.Bad); err == nil {
+					t.Fatal("expected error with a wrong solution")
+				}
+				if _, err := groth16.ProveFromFile(r1cs, bytes.NewReader(buf.Bytes()[:buf.Len()/2]), circuit.Good); err == nil {
+					t.Fatal("expected error with a truncated key")
+				}
+			}
+		})
+	}
+}
+
+func TestDistributedProof(t *testing.T) {
+	for _, name := range []string{"reference_small", "commit"} {
+		t.Run(name, func(t *testing.T) {
+			circuit := circuits.Circuits[name]
+			r1cs := circuit.R1CS.ToR1CS(curve.ID)
+			pk, vk, err := groth16.Setup(r1cs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var key bytes.Buffer
+			if _, err := pk.WriteTo(&key); err != nil {
+				t.Fatal(err)
+			}
+			solution, err := frontend.ParseWitness(circuit.Good)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_r1cs := r1cs.(*bn256backend.R1CS)
+			_pk := pk.(*bn256groth16.ProvingKey)
+
+			// the proof of Prove with the same seed
+			seed := backend.WithSeed([]byte("seed"))
+			expected, err := groth16.ProveWithOptions(r1cs, pk, circuit.Good, seed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var expectedBytes bytes.Buffer
+			if _, err := expected.WriteTo(&expectedBytes); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, fromFile := range []bool{false, true} {
+				// chunks of 3 points, the key slices aren't multiples of 3
+				var d *bn256groth16.DistributedProof
+				var worker *bn256groth16.MSMWorker
+				if fromFile {
+					d, err = bn256groth16.NewDistributedProofFromFile(_r1cs, bytes.NewReader(key.Bytes()), solution, 3, seed)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if worker, err = bn256groth16.NewMSMWorkerFromFile(bytes.NewReader(key.Bytes())); err != nil {
+						t.Fatal(err)
+					}
+				} else {
+					if d, err = bn256groth16.NewDistributedProof(_r1cs, _pk, solution, 3, seed); err != nil {
+						t.Fatal(err)
+					}
+					worker = bn256groth16.NewMSMWorker(_pk)
+				}
+				if _, err := d.Proof(); err == nil {
+					t.Fatal("expected error with missing results")
+				}
+
+				// the tasks and the results go through their encodings, as between machines
+				for _, task := range d.Tasks() {
+					var buf bytes.Buffer
+					if _, err := task.WriteTo(&buf); err != nil {
+						t.Fatal(err)
+					}
+					var received bn256groth16.MSMTask
+					if _, err := received.ReadFrom(&buf); err != nil {
+						t.Fatal(err)
+					}
+					res, err := worker.Compute(&received, backend.WithKeyChunkSize(2))
+					if err != nil {
+						t.Fatal(err)
+					}
+					buf.Reset()
+					if _, err := res.WriteTo(&buf); err != nil {
+						t.Fatal(err)
+					}
+					var merged bn256groth16.MSMResult
+					if _, err := merged.ReadFrom(&buf); err != nil {
+						t.Fatal(err)
+					}
+					if err := d.AddResult(merged); err != nil {
+						t.Fatal(err)
+					}
+				}
+				proof, err := d.Proof()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := groth16.Verify(proof, vk, circuit.Public); err != nil {
+					t.Fatal(err)
+				}
+				var proofBytes bytes.Buffer
+				if _, err := proof.WriteTo(&proofBytes); err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(proofBytes.Bytes(), expectedBytes.Bytes()) {
+					t.Fatal("the distributed proof and the proof of Prove with the same seed differ")
+				}
+
+				if err := d.AddResult(bn256groth16.MSMResult{ID: len(d.Tasks())}); err == nil {
+					t.Fatal("expected error with the result of an unknown task")
+				}
+				task := d.Tasks()[0]
+				task.Start = len(_pk.G1.A)
+				if _, err := worker.Compute(&task); err == nil {
+					t.Fatal("expected error with a task out of the key")
+				}
+			}
+
+			if _, err := bn256groth16.NewDistributedProof(_r1cs, _pk, solution, 0); err == nil {
+				t.Fatal("expected error with empty chunks")
+			}
+		})
+	}
+}
+
+func TestMmapProvingKey(t *testing.T) {
+	for _, name := range []string{"reference_small", "commit"} {
+		t.Run(name, func(t *testing.T) {
+			circuit := circuits.Circuits[name]
+			r1cs := circuit.R1CS.ToR1CS(curve.ID)
+			pk, vk, err := groth16.Setup(r1cs)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			path := filepath.Join(t.TempDir(), "pk")
+			f, err := os.Create(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := groth16.WriteMmapProvingKey(pk, f); err != nil {
+				t.Fatal(err)
+			}
+			if err := f.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			mapped, closer, err := groth16.MmapProvingKey(curve.ID, path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer closer.Close()
+			if pk.IsDifferent(mapped) {
+				t.Fatal("the mapped key is different from the written one")
+			}
+			proof, err := groth16.Prove(r1cs, mapped, circuit.Good)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := groth16.Verify(proof, vk, circuit.Public); err != nil {
+				t.Fatal(err)
+			}
+
+			// the file is truncated
+			data, err := ioutil.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := ioutil.WriteFile(path, data[:len(data)-1], 0600); err != nil {
+				t.Fatal(err)
+			}
+			if _, _, err := groth16.MmapProvingKey(curve.ID, path); err == nil {
+				t.Fatal("expected error with a truncated file")
+			}
+		})
+	}
+}
+
+func TestProveBatch(t *testing.T) {
+	circuit := refCircuit{nbConstraints: 10}
+	r1cs, err := frontend.Compile(curve.ID, &circuit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	solutions := make([]interface{}, 5)
+	for i := 0; i < len(solutions); i++ {
+		var expectedY fr.Element
+		expectedY.SetUint64(uint64(i + 2))
+		for j := 0; j < circuit.nbConstraints; j++ {
+			expectedY.Mul(&expectedY, &expectedY)
+		}
+		solutions[i] = map[string]interface{}{"X": i + 2, "Y": expectedY}
+	}
+
+	pk, vk, err := groth16.Setup(r1cs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proofs, err := groth16.ProveBatch(r1cs, pk, solutions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(proofs) != len(solutions) {
+		t.Fatal("expected", len(solutions), "proofs, got", len(proofs))
+	}
+	for i := 0; i < len(proofs); i++ {
+		if err := groth16.Verify(proofs[i], vk, solutions[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the pipeline stops on a wrong solution
+	solutions[2] = map[string]interface{}{"X": 2, "Y": 42}
+	if _, err := groth16.ProveBatch(r1cs, pk, solutions); err == nil {
+		t.Fatal("expected error with a wrong solution")
+	}
+}
+
+func TestProveProgress(t *testing.T) {
+	circuit := circuits.Circuits["reference_small"]
+	r1cs := circuit.R1CS.ToR1CS(curve.ID)
+	pk, vk, err := groth16.Setup(r1cs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if _, err := pk.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	provers := map[string]func(opts ...backend.Option) (groth16.Proof, error){
+		"Prove": func(opts ...backend.Option) (groth16.Proof, error) {
+			return groth16.ProveWithOptions(r1cs, pk, circuit.Good, opts...)
+		},
+		"ProveFromFile": func(opts ...backend.Option) (groth16.Proof, error) {
+			return groth16.ProveFromFile(r1cs, bytes.NewReader(buf.Bytes()), circuit.Good, opts...)
+		},
+	}
+	for name, prove := range provers {
+		t.Run(name, func(t *testing.T) {
+			last := make(map[string][2]int)
+			proof, err := prove(backend.WithProgress(func(step string, done, total int) {
+				if done > total || done < last[step][0] {
+					t.Errorf("inconsistent progress for step %s: %d/%d", step, done, total)
+				}
+				last[step] = [2]int{done, total}
+			}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := groth16.Verify(proof, vk, circuit.Good); err != nil {
+				t.Fatal(err)
+			}
+			nbConstraints := int(r1cs.GetNbConstraints())
+			if last["solve"] != [2]int{nbConstraints, nbConstraints} ||
+				last["fft"] != [2]int{7, 7} || last["msm"] != [2]int{5, 5} {
+				t.Fatal("unexpected progress", last)
+			}
+
+			// cancelled before, and between the steps
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			if _, err := prove(backend.WithContext(ctx)); err != context.Canceled {
+				t.Fatal("expected context.Canceled, got", err)
+			}
+			for _, step := range []string{"solve", "fft"} {
+				ctx, cancel := context.WithCancel(context.Background())
+				_, err := prove(backend.WithContext(ctx), backend.WithProgress(func(s string, _, _ int) {
+					if s == step {
+						cancel()
+					}
+				}))
+				if err != context.Canceled {
+					t.Fatal("expected context.Canceled after step", step, "got", err)
+				}
+			}
+		})
+	}
+
+	// the multi exponentiations of a key in a file are cancelled between the chunks
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err = groth16.ProveFromFile(r1cs, bytes.NewReader(buf.Bytes()), circuit.Good, backend.WithKeyChunkSize(1),
+		backend.WithContext(ctx), backend.WithProgress(func(step string, _, _ int) {
+			if step == "msm" {
+				cancel()
+			}
+		}))
+	if err != context.Canceled {
+		t.Fatal("expected context.Canceled, got", err)
+	}
+
+	// ProveBatch reports the proofs
+	var nbProofs int
+	solutions := []interface{}{circuit.Good, circuit.Good}
+	if _, err := groth16.ProveBatch(r1cs, pk, solutions, backend.WithProgress(func(step string, done, total int) {
+		if step != "proof" || total != len(solutions) {
+			t.Errorf("unexpected step %s: %d/%d", step, done, total)
+		}
+		nbProofs = done
+	})); err != nil {
+		t.Fatal(err)
+	}
+	if nbProofs != len(solutions) {
+		t.Fatal("expected", len(solutions), "proofs, got", nbProofs)
+	}
+	if _, err := groth16.ProveWithOptions(r1cs, pk, circuit.Good, backend.WithContext(nil)); err == nil {
+		t.Fatal("expected error with a nil context")
+	}
+}
+
+func TestProveSeed(t *testing.T) {
+	circuit := circuits.Circuits["reference_small"]
+	r1cs := circuit.R1CS.ToR1CS(curve.ID)
+	pk, vk, err := groth16.Setup(r1cs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var key bytes.Buffer
+	if _, err := pk.WriteTo(&key); err != nil {
+		t.Fatal(err)
+	}
+
+	prove := func(opts ...backend.Option) []byte {
+		t.Helper()
+		proof, err := groth16.ProveWithOptions(r1cs, pk, circuit.Good, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := groth16.Verify(proof, vk, circuit.Good); err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if _, err := proof.WriteTo(&buf); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+
+	seeded := prove(backend.WithSeed([]byte("seed")))
+	if !bytes.Equal(seeded, prove(backend.WithSeed([]byte("seed")))) {
+		t.Fatal("proofs with the same seed differ")
+	}
+	if bytes.Equal(seeded, prove(backend.WithSeed([]byte("another seed")))) {
+		t.Fatal("proofs with different seeds are equal")
+	}
+	if bytes.Equal(prove(), prove()) {
+		t.Fatal("proofs without seed are equal")
+	}
+
+	// the key read from a file gives the same proof
+	proof, err := groth16.ProveFromFile(r1cs, bytes.NewReader(key.Bytes()), circuit.Good, backend.WithSeed([]byte("seed")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if _, err := proof.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(seeded, buf.Bytes()) {
+		t.Fatal("ProveFromFile and Prove with the same seed differ")
+	}
+
+	if _, err := groth16.ProveWithOptions(r1cs, pk, circuit.Good, backend.WithSeed(nil)); err == nil {
+		t.Fatal("expected error with an empty seed")
+	}
+}
+
+func TestSolverTrace(t *testing.T) {
+	circuit := circuits.Circuits["reference_small"]
+	r1cs := circuit.R1CS.ToR1CS(curve.ID)
+	pk, _, err := groth16.Setup(r1cs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_r1cs := r1cs.(*bn256backend.R1CS)
+
+	// the trace of a solved circuit, in binary
+	var buf bytes.Buffer
+	if _, err := groth16.ProveWithOptions(r1cs, pk, circuit.Good, backend.WithSolverTrace(&buf, backend.TraceBinary)); err != nil {
+		t.Fatal(err)
+	}
+	var trace backend.SolverTrace
+	if _, err := trace.ReadFrom(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if len(trace.Wires) != int(_r1cs.NbWires) || len(trace.Constraints) != int(_r1cs.NbConstraints) {
+		t.Fatal("the trace doesn't match the circuit")
+	}
+	for i, v := range trace.Wires {
+		if v == nil {
+			t.Fatal("wire not solved", i)
+		}
+	}
+	for i, s := range trace.Constraints {
+		if s != backend.ConstraintSatisfied {
+			t.Fatal("constraint not satisfied", i, s)
+		}
+	}
+	offset := int(_r1cs.NbWires - _r1cs.NbPublicWires)
+	for i, name := range _r1cs.PublicWires {
+		if name == backend.OneWire && trace.Wires[offset+i].Cmp(big.NewInt(1)) != 0 {
+			t.Fatal("the value of the one wire isn't 1")
+		}
+	}
+
+	// the trace of an unsatisfied circuit, in JSON, is written before the error is returned
+	buf.Reset()
+	if _, err := groth16.ProveWithOptions(r1cs, pk, circuit.Bad, backend.WithSolverTrace(&buf, backend.TraceJSON)); err == nil {
+		t.Fatal("expected error with a wrong solution")
+	}
+	if err := json.Unmarshal(buf.Bytes(), &trace); err != nil {
+		t.Fatal(err)
+	}
+	failed := false
+	for _, s := range trace.Constraints {
+		failed = failed || s != backend.ConstraintSatisfied
+	}
+	if !failed {
+		t.Fatal("the trace of a wrong solution has no violated or unsolved constraint")
+	}
+}
+
+func TestMaxWorkers(t *testing.T) {
+	circuit := refCircuit{nbConstraints: 10}
+	r1cs, err := frontend.Compile(curve.ID, &circuit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expectedY fr.Element
+	expectedY.SetUint64(2)
+	for i := 0; i < circuit.nbConstraints; i++ {
+		expectedY.Mul(&expectedY, &expectedY)
+	}
+	good := map[string]interface{}{"X": 2, "Y": expectedY}
+
+	pk, vk, err := groth16.Setup(r1cs, backend.WithMaxWorkers(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := groth16.ProveWithOptions(r1cs, pk, good, backend.WithMaxWorkers(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := groth16.Verify(proof, vk, good); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := groth16.Setup(r1cs, backend.WithMaxWorkers(0)); err == nil {
+		t.Fatal("expected error with 0 workers")
+	}
+}
+
+func TestVerifyConstantTime(t *testing.T) {
+	circuit := refCircuit{nbConstraints: 10}
+	r1cs, err := frontend.Compile(curve.ID, &circuit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expectedY fr.Element
+	expectedY.SetUint64(2)
+	for i := 0; i < circuit.nbConstraints; i++ {
+		expectedY.Mul(&expectedY, &expectedY)
+	}
+	good := map[string]interface{}{"X": 2, "Y": expectedY}
+	bad := map[string]interface{}{"X": 2, "Y": 42}
+
+	pk, vk, err := groth16.Setup(r1cs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := groth16.Prove(r1cs, pk, good)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := groth16.Verify(proof, vk, good, backend.ConstantTime()); err != nil {
+		t.Fatal(err)
+	}
+	if err := groth16.Verify(proof, vk, bad, backend.ConstantTime()); err == nil {
+		t.Fatal("expected verification to fail with wrong public inputs")
+	}
+}
+
+func TestVerifyPrecompute(t *testing.T) {
+	circuit := refCircuit{nbConstraints: 10}
+	r1cs, err := frontend.Compile(curve.ID, &circuit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expectedY fr.Element
+	expectedY.SetUint64(2)
+	for i := 0; i < circuit.nbConstraints; i++ {
+		expectedY.Mul(&expectedY, &expectedY)
+	}
+	good := map[string]interface{}{"X": 2, "Y": expectedY}
+	bad := map[string]interface{}{"X": 2, "Y": 42}
+
+	pk, vk, err := groth16.Setup(r1cs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := groth16.Prove(r1cs, pk, good)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vk.Precompute()
+	if err := groth16.Verify(proof, vk, good); err != nil {
+		t.Fatal(err)
+	}
+	if err := groth16.Verify(proof, vk, good, backend.ConstantTime()); err != nil {
+		t.Fatal(err)
+	}
+	if err := groth16.Verify(proof, vk, bad); err == nil {
+		t.Fatal("expected verification to fail with wrong public inputs")
+	}
+	if err := groth16.BatchVerify(vk, []groth16.Proof{proof, proof}, []interface{}{good, good}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the lines are ignored once the key changes
+	_, other, err := groth16.Setup(r1cs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_vk := vk.(*bn256groth16.VerifyingKey)
+	_vk.G2.DeltaNeg = other.(*bn256groth16.VerifyingKey).G2.DeltaNeg
+	if err := groth16.Verify(proof, vk, good); err == nil {
+		t.Fatal("expected verification to fail with a different key")
+	}
+}
+
+func TestCommitment(t *testing.T) {
+	circuit := circuits.Circuits["commit"]
+	r1cs := circuit.R1CS.ToR1CS(curve.ID)
+
+	pk, vk := new(bn256groth16.ProvingKey), new(bn256groth16.VerifyingKey)
+	trapdoor, err := bn256groth16.UnsafeSetup(r1cs.(*bn256backend.R1CS), pk, vk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := groth16.Prove(r1cs, pk, circuit.Good)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := groth16.Verify(proof, vk, circuit.Public); err != nil {
+		t.Fatal(err)
+	}
+
+	// the commitment is blinded: two proofs of the same witness have different commitments, unless they
+	// are derived from the same seed
+	other, err := groth16.Prove(r1cs, pk, circuit.Good)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := groth16.Verify(other, vk, circuit.Public); err != nil {
+		t.Fatal(err)
+	}
+	_proof := proof.(*bn256groth16.Proof)
+	if _other := other.(*bn256groth16.Proof); _other.Commitment.Equal(&_proof.Commitment) {
+		t.Fatal("expected two proofs to have different commitments")
+	}
+	seeded := make([]groth16.Proof, 2)
+	for i := range seeded {
+		if seeded[i], err = groth16.ProveWithOptions(r1cs, pk, circuit.Good, backend.WithSeed([]byte("seed"))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if c0, c1 := seeded[0].(*bn256groth16.Proof).Commitment, seeded[1].(*bn256groth16.Proof).Commitment; !c0.Equal(&c1) {
+		t.Fatal("expected proofs derived from the same seed to have the same commitment")
+	}
+	if err := groth16.Verify(seeded[0], vk, circuit.Public); err != nil {
+		t.Fatal(err)
+	}
+
+	// the commitment is bound to the proof
+	tampered := *_proof
+	tampered.Commitment, tampered.CommitmentPok = _proof.Ar, _proof.Ar
+	if err := groth16.Verify(&tampered, vk, circuit.Public); err == nil {
+		t.Fatal("expected verification to fail with another commitment")
+	}
+	tampered = *_proof
+	tampered.CommitmentPok = _proof.Commitment
+	if err := groth16.Verify(&tampered, vk, circuit.Public, backend.ConstantTime()); err == nil {
+		t.Fatal("expected verification to fail with a wrong proof of knowledge of the commitment")
+	}
+
+	// the keys can't be updated
+	if err := bn256groth16.UpdateKeys(r1cs.(*bn256backend.R1CS), r1cs.(*bn256backend.R1CS), pk, vk, trapdoor); err == nil {
+		t.Fatal("expected error when updating the keys of a circuit with a commitment")
+	}
+}
+
+func TestBatchVerify(t *testing.T) {
+	circuit := refCircuit{nbConstraints: 10}
+	r1cs, err := frontend.Compile(curve.ID, &circuit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, vk, err := groth16.Setup(r1cs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// y = x**(2**nbConstraints), for x = 2, 3, 4
+	var proofs []groth16.Proof
+	var solutions []interface{}
+	for x := uint64(2); x <= 4; x++ {
+		var y fr.Element
+		y.SetUint64(x)
+		for i := 0; i < circuit.nbConstraints; i++ {
+			y.Mul(&y, &y)
+		}
+		solution := map[string]interface{}{"X": x, "Y": y}
+		proof, err := groth16.Prove(r1cs, pk, solution)
+		if err != nil {
+			t.Fatal(err)
+		}
+		proofs = append(proofs, proof)
+		solutions = append(solutions, solution)
+	}
+	if err := groth16.BatchVerify(vk, proofs, solutions); err != nil {
+		t.Fatal(err)
+	}
+
+	// the public inputs of two proofs are swapped
+	swapped := []interface{}{solutions[1], solutions[0], solutions[2]}
+	if err := groth16.BatchVerify(vk, proofs, swapped); err == nil {
+		t.Fatal("expected batch verification to fail with swapped public inputs")
+	}
+	tampered := *proofs[2].(*bn256groth16.Proof)
+	tampered.Krs = tampered.Ar
+	if err := groth16.BatchVerify(vk, []groth16.Proof{proofs[0], proofs[1], &tampered}, solutions); err == nil {
+		t.Fatal("expected batch verification to fail with a tampered proof")
+	}
+	if err := groth16.BatchVerify(vk, proofs, solutions[:2]); err == nil {
+		t.Fatal("expected batch verification to fail with a missing public witness")
+	}
+
+	// the proofs of knowledge of the commitments are checked
+	commit := circuits.Circuits["commit"]
+	r1cs = commit.R1CS.ToR1CS(curve.ID)
+	pk, vk, err = groth16.Setup(r1cs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proofs = proofs[:0]
+	for i := 0; i < 2; i++ {
+		proof, err := groth16.Prove(r1cs, pk, commit.Good)
+		if err != nil {
+			t.Fatal(err)
+		}
+		proofs = append(proofs, proof)
+	}
+	solutions = []interface{}{commit.Public, commit.Public}
+	if err := groth16.BatchVerify(vk, proofs, solutions); err != nil {
+		t.Fatal(err)
+	}
+	tampered = *proofs[1].(*bn256groth16.Proof)
+	tampered.CommitmentPok = tampered.Commitment
+	if err := groth16.BatchVerify(vk, []groth16.Proof{proofs[0], &tampered}, solutions); err == nil {
+		t.Fatal("expected batch verification to fail with a wrong proof of knowledge of the commitment")
+	}
+}
+
 func TestParsePublicInput(t *testing.T) {
 
 	expectedNames := [2]string{"data", backend.OneWire}
@@ -182,6 +1083,13 @@ func BenchmarkVerifier(b *testing.B) {
 			_ = bn256groth16.Verify(proof, &vk, solution)
 		}
 	})
+
+	vk.Precompute()
+	b.Run("precomputed", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_ = bn256groth16.Verify(proof, &vk, solution)
+		}
+	})
 }
 
 func BenchmarkSerialization(b *testing.B) {
@@ -18,6 +18,7 @@ package fft
 
 import (
 	"math/big"
+	"math/bits"
 	"strconv"
 	"testing"
 
@@ -51,7 +52,7 @@ func TestFFT(t *testing.T) {
 			}
 			copy(backupPol, pol)
 
-			domain.FFT(pol, DIF)
+			domain.FFT(pol, DIF, false)
 			BitReverse(pol)
 
 			sample := domain.Generator
@@ -79,7 +80,7 @@ func TestFFT(t *testing.T) {
 			copy(backupPol, pol)
 
 			BitReverse(pol)
-			domain.FFT(pol, DIT)
+			domain.FFT(pol, DIT, false)
 
 			sample := domain.Generator
 			sample.Exp(sample, big.NewInt(int64(ithpower)))
@@ -105,8 +106,8 @@ func TestFFT(t *testing.T) {
 			copy(backupPol, pol)
 
 			BitReverse(pol)
-			domain.FFT(pol, DIT)
-			domain.FFTInverse(pol, DIF)
+			domain.FFT(pol, DIT, false)
+			domain.FFTInverse(pol, DIF, false)
 			BitReverse(pol)
 
 			check := true
@@ -129,8 +130,8 @@ func TestFFT(t *testing.T) {
 			}
 			copy(backupPol, pol)
 
-			domain.FFTInverse(pol, DIF)
-			domain.FFT(pol, DIT)
+			domain.FFTInverse(pol, DIF, false)
+			domain.FFT(pol, DIT, false)
 
 			check := true
 			for i := 0; i < len(pol); i++ {
@@ -140,10 +141,107 @@ func TestFFT(t *testing.T) {
 		},
 	))
 
+	properties.Property("DIF coset FFT should be consistent with dual basis", prop.ForAll(
+
+		func(ithpower int) bool {
+
+			pol := make([]fr.Element, maxSize)
+			backupPol := make([]fr.Element, maxSize)
+
+			for i := 0; i < maxSize; i++ {
+				pol[i].SetRandom()
+			}
+			copy(backupPol, pol)
+
+			domain.FFT(pol, DIF, true)
+			BitReverse(pol)
+
+			sample := domain.Generator
+			sample.Exp(sample, big.NewInt(int64(ithpower))).
+				Mul(&sample, &domain.GeneratorSqRt)
+
+			eval := evaluatePolynomial(backupPol, sample)
+
+			return eval.Equal(&pol[ithpower])
+
+		},
+		gen.IntRange(0, maxSize-1),
+	))
+
+	properties.Property("coset FFTInverse(coset FFT)==id", prop.ForAll(
+
+		func() bool {
+
+			pol := make([]fr.Element, maxSize)
+			backupPol := make([]fr.Element, maxSize)
+
+			for i := 0; i < maxSize; i++ {
+				pol[i].SetRandom()
+			}
+			copy(backupPol, pol)
+
+			// natural -> bit reversed -> natural -> bit reversed -> natural
+			domain.FFT(pol, DIF, true)
+			domain.FFTInverse(pol, DIT, true)
+			BitReverse(pol)
+			domain.FFT(pol, DIT, true)
+			domain.FFTInverse(pol, DIF, true)
+			BitReverse(pol)
+
+			check := true
+			for i := 0; i < len(pol); i++ {
+				check = check && pol[i].Equal(&backupPol[i])
+			}
+			return check
+		},
+	))
+
+	properties.Property("FFT with a single task should match the parallel FFT", prop.ForAll(
+
+		func() bool {
+
+			pol := make([]fr.Element, maxSize)
+			backupPol := make([]fr.Element, maxSize)
+
+			for i := 0; i < maxSize; i++ {
+				pol[i].SetRandom()
+			}
+			copy(backupPol, pol)
+
+			domain.FFT(pol, DIF, true)
+			domain.FFT(backupPol, DIF, true, WithNbTasks(1))
+
+			check := true
+			for i := 0; i < len(pol); i++ {
+				check = check && pol[i].Equal(&backupPol[i])
+			}
+			return check
+		},
+	))
+
 	properties.TestingRun(t, gopter.ConsoleReporter(false))
 
 }
 
+func TestBitReverseBlocked(t *testing.T) {
+	for logN := 2 * bitReverseTileLog; logN < 2*bitReverseTileLog+4; logN++ {
+		n := 1 << logN
+		pol := make([]fr.Element, n)
+		for i := 0; i < n; i++ {
+			pol[i].SetUint64(uint64(i))
+		}
+		bitReverseBlocked(pol)
+		for i := 0; i < n; i++ {
+			irev := bits.Reverse64(uint64(i)) >> (64 - logN)
+			var expected fr.Element
+			expected.SetUint64(irev)
+			if !pol[i].Equal(&expected) {
+				t.Fatalf("size 2**%d: wrong value at index %d", logN, i)
+			}
+		}
+	}
+}
+
 // --------------------------------------------------------------------
 // benches
 func BenchmarkBitReverse(b *testing.B) {
@@ -155,7 +253,7 @@ func BenchmarkBitReverse(b *testing.B) {
 		pol[i].SetRandom()
 	}
 
-	for i := 8; i < 20; i++ {
+	for i := 8; i <= 20; i++ {
 		b.Run("bit reversing 2**"+strconv.Itoa(i)+"bits", func(b *testing.B) {
 			_pol := make([]fr.Element, 1<<i)
 			copy(_pol, pol)
@@ -185,7 +283,7 @@ func BenchmarkFFT(b *testing.B) {
 			domain := NewDomain(uint64(sizeDomain))
 			b.ResetTimer()
 			for j := 0; j < b.N; j++ {
-				domain.FFT(_pol, DIT)
+				domain.FFT(_pol, DIT, false)
 			}
 		})
 	}
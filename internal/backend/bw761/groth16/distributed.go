@@ -0,0 +1,410 @@
+// Copyright 2020 ConsenSys Software Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by gnark DO NOT EDIT
+
+package groth16
+
+import (
+	"github.com/consensys/gurvy/bw761/fr"
+
+	curve "github.com/consensys/gurvy/bw761"
+
+	bw761backend "github.com/consensys/gnark/internal/backend/bw761"
+
+	"errors"
+	"fmt"
+	"github.com/consensys/gnark/backend"
+	"io"
+	"math/big"
+)
+
+// MSM identifies a multi exponentiation of a proof, by its points in the proving key
+type MSM uint8
+
+// multi exponentiations of a proof
+const (
+	MSMG1A MSM = iota // pk.G1.A, by the wire values
+	MSMG1B            // pk.G1.B, by the wire values
+	MSMG1K            // pk.G1.K, by the private wire values
+	MSMG1Z            // pk.G1.Z, by the coefficients of h
+	MSMG2B            // pk.G2.B, by the wire values
+)
+
+var errUnknownMSM = errors.New("unknown multi exponentiation")
+
+// MSMTask is a chunk of a multi exponentiation of a proof: Σ Scalars[i].points[Start+i], the points being
+// those of the proving key selected by MSM
+//
+// the tasks of a DistributedProof are independent: they can be sent to different machines (WriteTo,
+// ReadFrom), computed by an MSMWorker, and their results merged by the DistributedProof
+type MSMTask struct {
+	ID      int // index of the task in DistributedProof.Tasks
+	MSM     MSM
+	Start   int
+	Scalars []fr.Element // regular form
+}
+
+// MSMResult is the result of an MSMTask: G2 for MSMG2B, G1 for the other multi exponentiations
+type MSMResult struct {
+	ID  int
+	MSM MSM
+	G1  curve.G1Affine
+	G2  curve.G2Affine
+}
+
+// DistributedProof is a proof whose multi exponentiations are split in MSMTasks, computed by workers
+// (on other machines for example), so that no machine holds the whole proving key
+//
+// the coordinator solves the R1CS and computes h with NewDistributedProof, sends the Tasks to the
+// workers, adds their results with AddResult, and then computes the Proof. The results aren't checked:
+// the proof should be verified if the workers aren't trusted.
+type DistributedProof struct {
+	pk                                       *ProvingKey // the single points of the key; the slices of points may be nil
+	r, s                                     big.Int
+	deltas                                   []curve.G1Affine
+	commitment, commitmentPok, commitmentKrs curve.G1Affine
+
+	tasks   []MSMTask
+	results []MSMResult
+	done    []bool
+}
+
+// NewDistributedProof solves the R1CS, computes h, and splits the multi exponentiations of the proof in
+// tasks of at most chunkSize points
+func NewDistributedProof(r1cs *bw761backend.R1CS, pk *ProvingKey, solution map[string]interface{}, chunkSize int, opts ...backend.Option) (*DistributedProof, error) {
+	lengths := [nbMSMs]int{len(pk.G1.A), len(pk.G1.B), len(pk.G1.K), len(pk.G1.Z), len(pk.G2.B)}
+	return newDistributedProof(r1cs, pk, lengths, solution, chunkSize, opts)
+}
+
+// NewDistributedProofFromFile is NewDistributedProof with a proving key read from pkFile (written by
+// ProvingKey.WriteTo or WriteRawTo); only the single points of the key are decoded
+func NewDistributedProofFromFile(r1cs *bw761backend.R1CS, pkFile io.ReaderAt, solution map[string]interface{}, chunkSize int, opts ...backend.Option) (*DistributedProof, error) {
+	key, err := openKeyFile(pkFile)
+	if err != nil {
+		return nil, err
+	}
+	lengths := [nbMSMs]int{key.g1A.len, key.g1B.len, key.g1K.len, key.g1Z.len, key.g2B.len}
+	return newDistributedProof(r1cs, &key.pk, lengths, solution, chunkSize, opts)
+}
+
+// newDistributedProof creates the tasks of the proof, lengths being the numbers of points of the
+// multi exponentiations in the key
+func newDistributedProof(r1cs *bw761backend.R1CS, pk *ProvingKey, lengths [nbMSMs]int, solution map[string]interface{}, chunkSize int, opts []backend.Option) (*DistributedProof, error) {
+	if chunkSize <= 0 {
+		return nil, errors.New("the size of the chunks must be positive")
+	}
+	config, err := backend.NewConfig(opts...)
+	if err != nil {
+		return nil, err
+	}
+	nbPrivateWires := int(r1cs.NbWires - r1cs.NbPublicWires)
+	if lengths[MSMG1A] != int(r1cs.NbWires) || lengths[MSMG1K] != nbPrivateWires {
+		return nil, errors.New("the proving key doesn't match the circuit")
+	}
+
+	w, err := solveWitness(r1cs, pk, solution, config)
+	if err != nil {
+		return nil, err
+	}
+	h, err := w.reduce(&pk.Domain, config)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &DistributedProof{pk: pk, commitment: w.commitment, commitmentPok: w.commitmentPok, commitmentKrs: w.commitmentKrs}
+	if d.r, d.s, d.deltas, err = sampleRS(pk, w.wireValues, config); err != nil {
+		return nil, err
+	}
+
+	scalars := [nbMSMs][]fr.Element{w.wireValues, w.wireValues, w.wireValues[:nbPrivateWires], h, w.wireValues}
+	for m := range scalars {
+		n := min(lengths[m], len(scalars[m]))
+		for start := 0; start < n; start += chunkSize {
+			end := min(start+chunkSize, n)
+			d.tasks = append(d.tasks, MSMTask{ID: len(d.tasks), MSM: MSM(m), Start: start, Scalars: scalars[m][start:end]})
+		}
+	}
+	d.results = make([]MSMResult, len(d.tasks))
+	d.done = make([]bool, len(d.tasks))
+	return d, nil
+}
+
+// Tasks returns the tasks of the proof; they share the scalars of the proof, and must not be modified
+func (d *DistributedProof) Tasks() []MSMTask {
+	return d.tasks
+}
+
+// AddResult adds the result of a task to the proof; the result of a task added twice replaces the first one
+func (d *DistributedProof) AddResult(res MSMResult) error {
+	if res.ID < 0 || res.ID >= len(d.tasks) || d.tasks[res.ID].MSM != res.MSM {
+		return errors.New("the result doesn't match a task of the proof")
+	}
+	d.results[res.ID] = res
+	d.done[res.ID] = true
+	return nil
+}
+
+// Proof merges the results of the tasks in the proof; it fails if a result is missing
+func (d *DistributedProof) Proof() (*Proof, error) {
+	var g1 [nbMSMs]curve.G1Jac
+	var Bs curve.G2Jac
+	for i, t := range d.tasks {
+		if !d.done[i] {
+			return nil, fmt.Errorf("the result of the task %d is missing", i)
+		}
+		if t.MSM == MSMG2B {
+			Bs.AddMixed(&d.results[i].G2)
+		} else {
+			g1[t.MSM].AddMixed(&d.results[i].G1)
+		}
+	}
+
+	// as in computeProof
+	proof := &Proof{Commitment: d.commitment, CommitmentPok: d.commitmentPok}
+	ar, bs1, krs := g1[MSMG1A], g1[MSMG1B], g1[MSMG1K]
+	ar.AddMixed(&d.pk.G1.Alpha)
+	ar.AddMixed(&d.deltas[0])
+	proof.Ar.FromJacobian(&ar)
+
+	bs1.AddMixed(&d.pk.G1.Beta)
+	bs1.AddMixed(&d.deltas[1])
+
+	var p1 curve.G1Jac
+	krs.AddAssign(&g1[MSMG1Z])
+	krs.AddMixed(&d.deltas[2])
+	krs.AddMixed(&d.commitmentKrs)
+	p1.ScalarMultiplication(&ar, &d.s)
+	krs.AddAssign(&p1)
+	p1.ScalarMultiplication(&bs1, &d.r)
+	krs.AddAssign(&p1)
+	proof.Krs.FromJacobian(&krs)
+
+	var deltaS curve.G2Jac
+	deltaS.FromAffine(&d.pk.G2.Delta)
+	deltaS.ScalarMultiplication(&deltaS, &d.s)
+	Bs.AddAssign(&deltaS)
+	Bs.AddMixed(&d.pk.G2.Beta)
+	proof.Bs.FromJacobian(&Bs)
+
+	return proof, nil
+}
+
+// MSMWorker computes MSMTasks with the points of a proving key, in memory or in a file
+type MSMWorker struct {
+	pk  *ProvingKey
+	key *keyFile
+}
+
+// NewMSMWorker returns a worker computing the tasks with the points of pk
+func NewMSMWorker(pk *ProvingKey) *MSMWorker {
+	return &MSMWorker{pk: pk}
+}
+
+// NewMSMWorkerFromFile returns a worker computing the tasks with the points of the proving key in pkFile
+// (written by ProvingKey.WriteTo or WriteRawTo): only the points of a task are decoded, by chunks of
+// backend.WithKeyChunkSize points
+func NewMSMWorkerFromFile(pkFile io.ReaderAt) (*MSMWorker, error) {
+	key, err := openKeyFile(pkFile)
+	if err != nil {
+		return nil, err
+	}
+	return &MSMWorker{key: key}, nil
+}
+
+// Compute returns the result of the task; the multi exponentiation runs on the accelerator of the
+// options if any (see backend.WithAccelerator)
+func (wk *MSMWorker) Compute(task *MSMTask, opts ...backend.Option) (MSMResult, error) {
+	res := MSMResult{ID: task.ID, MSM: task.MSM}
+	config, err := backend.NewConfig(opts...)
+	if err != nil {
+		return res, err
+	}
+	if err := config.Context.Err(); err != nil {
+		return res, err
+	}
+	acc, err := accelerator(config)
+	if err != nil {
+		return res, err
+	}
+
+	if wk.key != nil {
+		s, err := wk.key.slice(task.MSM)
+		if err != nil {
+			return res, err
+		}
+		size := wk.key.g1Size
+		if task.MSM == MSMG2B {
+			size = wk.key.g2Size
+		}
+		if task.Start < 0 || task.Start+len(task.Scalars) > s.len {
+			return res, errors.New("the task is out of the points of the proving key")
+		}
+		s.offset += int64(task.Start * size)
+		s.len = len(task.Scalars)
+
+		if task.MSM == MSMG2B {
+			p, err := wk.key.multiExpG2(s, task.Scalars, config, acc)
+			if err != nil {
+				return res, err
+			}
+			res.G2.FromJacobian(&p)
+			return res, nil
+		}
+		p, err := wk.key.multiExpG1(s, task.Scalars, config, acc)
+		if err != nil {
+			return res, err
+		}
+		res.G1.FromJacobian(&p)
+		return res, nil
+	}
+
+	cpuSemaphore := curve.NewCPUSemaphore(config.MaxWorkers)
+	end := task.Start + len(task.Scalars)
+	if task.MSM == MSMG2B {
+		if task.Start < 0 || end > len(wk.pk.G2.B) {
+			return res, errors.New("the task is out of the points of the proving key")
+		}
+		points := wk.pk.G2.B[task.Start:end]
+		var p curve.G2Jac
+		if acc != nil {
+			if p, err = acc.MultiExpG2(points, task.Scalars); err != nil {
+				return res, err
+			}
+		} else {
+			p.MultiExp(points, task.Scalars, cpuSemaphore)
+		}
+		res.G2.FromJacobian(&p)
+		return res, nil
+	}
+
+	var points []curve.G1Affine
+	switch task.MSM {
+	case MSMG1A:
+		points = wk.pk.G1.A
+	case MSMG1B:
+		points = wk.pk.G1.B
+	case MSMG1K:
+		points = wk.pk.G1.K
+	case MSMG1Z:
+		points = wk.pk.G1.Z
+	default:
+		return res, errUnknownMSM
+	}
+	if task.Start < 0 || end > len(points) {
+		return res, errors.New("the task is out of the points of the proving key")
+	}
+	points = points[task.Start:end]
+	var p curve.G1Jac
+	if acc != nil {
+		if p, err = acc.MultiExpG1(points, task.Scalars); err != nil {
+			return res, err
+		}
+	} else {
+		p.MultiExp(points, task.Scalars, cpuSemaphore)
+	}
+	res.G1.FromJacobian(&p)
+	return res, nil
+}
+
+// slice returns the points of the multi exponentiation m in the file
+func (k *keyFile) slice(m MSM) (keySlice, error) {
+	switch m {
+	case MSMG1A:
+		return k.g1A, nil
+	case MSMG1B:
+		return k.g1B, nil
+	case MSMG1K:
+		return k.g1K, nil
+	case MSMG1Z:
+		return k.g1Z, nil
+	case MSMG2B:
+		return k.g2B, nil
+	}
+	return keySlice{}, errUnknownMSM
+}
+
+// WriteTo writes the binary encoding of the task: ID | MSM | Start | len(Scalars) | Scalars
+func (task *MSMTask) WriteTo(w io.Writer) (int64, error) {
+	enc := curve.NewEncoder(w)
+	for _, v := range []uint64{uint64(task.ID), uint64(task.MSM), uint64(task.Start), uint64(len(task.Scalars))} {
+		if err := enc.Encode(v); err != nil {
+			return enc.BytesWritten(), err
+		}
+	}
+	for i := range task.Scalars {
+		if err := enc.Encode(&task.Scalars[i]); err != nil {
+			return enc.BytesWritten(), err
+		}
+	}
+	return enc.BytesWritten(), nil
+}
+
+// ReadFrom reads a task written by WriteTo
+func (task *MSMTask) ReadFrom(r io.Reader) (int64, error) {
+	dec := curve.NewDecoder(r)
+	var header [4]uint64
+	for i := range header {
+		if err := dec.Decode(&header[i]); err != nil {
+			return dec.BytesRead(), err
+		}
+	}
+	task.ID, task.MSM, task.Start = int(header[0]), MSM(header[1]), int(header[2])
+	task.Scalars = make([]fr.Element, header[3])
+	for i := range task.Scalars {
+		if err := dec.Decode(&task.Scalars[i]); err != nil {
+			return dec.BytesRead(), err
+		}
+	}
+	return dec.BytesRead(), nil
+}
+
+// WriteTo writes the binary encoding of the result: ID | MSM | G1 or G2 (compressed)
+func (res *MSMResult) WriteTo(w io.Writer) (int64, error) {
+	enc := curve.NewEncoder(w)
+	if err := enc.Encode(uint64(res.ID)); err != nil {
+		return enc.BytesWritten(), err
+	}
+	if err := enc.Encode(uint64(res.MSM)); err != nil {
+		return enc.BytesWritten(), err
+	}
+	var err error
+	if res.MSM == MSMG2B {
+		err = enc.Encode(&res.G2)
+	} else {
+		err = enc.Encode(&res.G1)
+	}
+	return enc.BytesWritten(), err
+}
+
+// ReadFrom reads a result written by WriteTo; the point is checked to be on the curve and in the
+// correct subgroup
+func (res *MSMResult) ReadFrom(r io.Reader) (int64, error) {
+	dec := curve.NewDecoder(r)
+	var id, m uint64
+	if err := dec.Decode(&id); err != nil {
+		return dec.BytesRead(), err
+	}
+	if err := dec.Decode(&m); err != nil {
+		return dec.BytesRead(), err
+	}
+	*res = MSMResult{ID: int(id), MSM: MSM(m)}
+	var err error
+	if res.MSM == MSMG2B {
+		err = dec.Decode(&res.G2)
+	} else {
+		err = dec.Decode(&res.G1)
+	}
+	return dec.BytesRead(), err
+}
@@ -25,11 +25,24 @@ import (
 
 	"github.com/consensys/gnark/internal/backend/bw761/fft"
 
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/internal/utils"
 	"github.com/consensys/gurvy"
 	"math/big"
 	"math/bits"
+	"sync"
 )
 
+// setup steps, reported through backend.ProgressFunc
+const (
+	stepLagrange = "lagrange" // evaluation of the QAP at the toxic waste (in constraints)
+	stepG1       = "g1"       // scalar multiplications in G1 (in points)
+	stepG2       = "g2"       // scalar multiplications in G2 (in points)
+)
+
+// batch scalar multiplications are split in chunks of that size to report progress
+const setupChunkSize = 1 << 16
+
 // ProvingKey is used by a Groth16 prover to encode a proof of a statement
 // Notation follows Figure 4. in DIZK paper https://eprint.iacr.org/2018/691.pdf
 type ProvingKey struct {
@@ -49,6 +62,23 @@ type ProvingKey struct {
 		Beta, Delta curve.G2Affine
 		B           []curve.G2Affine
 	}
+
+	// commitment key, nil if the circuit has no commitment (see r1c.Commitment)
+	Commitment *CommitmentKey
+}
+
+// CommitmentKey is used by a Groth16 prover to commit to the committed private wires of a circuit
+//
+// the K(t) of these wires are divided by γ instead of δ, as the public wires: their contribution is
+// given by the commitment in the proof, and the prover proves its knowledge with [σ]
+//
+// the commitment is blinded by a random multiple ρ of [ξ]1, for a random ξ of the setup; ρ is in the
+// proof of knowledge, and the prover subtracts ρ[ξγ/δ]1 from Krs so that the proof still verifies
+type CommitmentKey struct {
+	Basis         []curve.G1Affine // [Kvk(t)]1 of the committed private wires
+	BasisExpSigma []curve.G1Affine // [σ Kvk(t)]1
+
+	Blinding, BlindingExpSigma, BlindingKrs curve.G1Affine // [ξ]1, [σ ξ]1, [ξ γ/δ]1
 }
 
 // VerifyingKey is used by a Groth16 verifier to verify the validity of a proof and a statement
@@ -60,21 +90,50 @@ type VerifyingKey struct {
 	// e(α, β)
 	E curve.GT
 
-	// -[γ]2, -[δ]2
+	// [β]2, -[γ]2, -[δ]2
 	// note: storing GammaNeg and DeltaNeg instead of Gamma and Delta
 	// see proof.Verify() for more details
 	G2 struct {
+		Beta               curve.G2Affine // not used by Verify, needed by verifiers that don't store e(α, β)
 		GammaNeg, DeltaNeg curve.G2Affine
 	}
 
-	// [Kvk]1
+	// [α]1, [Kvk]1
 	G1 struct {
-		K []curve.G1Affine // The indexes correspond to the public wires
+		Alpha curve.G1Affine   // not used by Verify, needed by verifiers that don't store e(α, β)
+		K     []curve.G1Affine // The indexes correspond to the public wires
 	}
+
+	// nil if the circuit has no commitment (see r1c.Commitment)
+	Commitment *CommitmentVerifyingKey
+
+	// lines of the Miller loops of GammaNeg and DeltaNeg, nil until Precompute
+	lines *fixedLines
+}
+
+// CommitmentVerifyingKey is used by a Groth16 verifier to add the commitment of a proof, and the
+// commitment wire, to the public inputs
+type CommitmentVerifyingKey struct {
+	K               curve.G1Affine // [Kvk(t)]1 of the commitment wire
+	G, GSigma       curve.G2Affine // [1]2, [σ]2
+	PublicCommitted []int          // indexes in PublicInputs of the committed public wires, in the order of the call to Commit
 }
 
 // Setup constructs the SRS
-func Setup(r1cs *bw761backend.R1CS, pk *ProvingKey, vk *VerifyingKey) error {
+//
+// the independent computations (QAP evaluation, scalars of the keys, scalar multiplications)
+// are parallelized; progress can be monitored with backend.WithProgress
+func Setup(r1cs *bw761backend.R1CS, pk *ProvingKey, vk *VerifyingKey, opts ...backend.Option) error {
+	// samples toxic waste
+	toxicWaste, err := sampleToxicWaste()
+	if err != nil {
+		return err
+	}
+	return setup(r1cs, pk, vk, toxicWaste, opts...)
+}
+
+// setup builds pk and vk from the provided toxic waste
+func setup(r1cs *bw761backend.R1CS, pk *ProvingKey, vk *VerifyingKey, toxicWaste toxicWaste, opts ...backend.Option) error {
 
 	/*
 		Setup
@@ -86,6 +145,11 @@ func Setup(r1cs *bw761backend.R1CS, pk *ProvingKey, vk *VerifyingKey) error {
 		- loop through the pure structural constraints, eValuate A(X), B(X), C(X) with simple formula, the gate number is len(gateOrdering)+len(InpureStructuralConstraints)+current iterator
 	*/
 
+	config, err := backend.NewConfig(opts...)
+	if err != nil {
+		return err
+	}
+
 	// get R1CS nb constraints, wires and public/private inputs
 	nbWires := int(r1cs.NbWires)
 	nbPublicWires := int(r1cs.NbPublicWires)
@@ -97,14 +161,9 @@ func Setup(r1cs *bw761backend.R1CS, pk *ProvingKey, vk *VerifyingKey) error {
 	// Set public inputs in Verifying Key (Verify does not need the R1CS data structure)
 	vk.PublicInputs = r1cs.PublicWires
 
-	// samples toxic waste
-	toxicWaste, err := sampleToxicWaste()
-	if err != nil {
-		return err
-	}
-
 	// Setup coeffs to compute pk.G1.A, pk.G1.B, pk.G1.K
-	A, B, C := setupABC(r1cs, domain, toxicWaste)
+	A, B, C := setupABC(r1cs, domain, toxicWaste, config.MaxWorkers)
+	config.Progress(stepLagrange, len(r1cs.Constraints), len(r1cs.Constraints))
 
 	// To fill in the Proving and Verifying keys, we need to perform a lot of ecc scalar multiplication (with generator)
 	// and convert the resulting points to affine
@@ -130,57 +189,110 @@ func Setup(r1cs *bw761backend.R1CS, pk *ProvingKey, vk *VerifyingKey) error {
 	pkK := make([]fr.Element, nbPrivateWires)
 	vkK := make([]fr.Element, nbPublicWires)
 
-	var t0, t1 fr.Element
-	for i := 0; i < nbPrivateWires; i++ {
-		t1.Mul(&A[i], &toxicWaste.beta)
-		t0.Mul(&B[i], &toxicWaste.alpha)
-		t1.Add(&t1, &t0).
-			Add(&t1, &C[i]).
-			Div(&t1, &toxicWaste.delta)
-		pkK[i] = t1.ToRegular()
+	// we divide by δ and γ a lot, so we compute their inverses once
+	var deltaInv, gammaInv fr.Element
+	deltaInv.Inverse(&toxicWaste.delta)
+	gammaInv.Inverse(&toxicWaste.gamma)
+
+	// the committed private wires and the commitment wire are divided by γ: the scalars of the
+	// commitment key, [Basis(i)], [σ Basis(i)], [Kvk] of the commitment wire, and [ξ], [σ ξ], [ξ γ/δ]
+	var commitmentScalars []fr.Element
+	privateCommitted, publicCommitted := committedWires(r1cs)
+	if r1cs.Commitment != nil {
+		commitmentScalars = make([]fr.Element, 2*len(privateCommitted)+4)
+		kGamma := func(i int) (res fr.Element) {
+			var t fr.Element
+			res.Mul(&A[i], &toxicWaste.beta)
+			t.Mul(&B[i], &toxicWaste.alpha)
+			res.Add(&res, &t).
+				Add(&res, &C[i]).
+				Mul(&res, &gammaInv)
+			return
+		}
+		for i, wireID := range privateCommitted {
+			basis := kGamma(wireID)
+			commitmentScalars[len(privateCommitted)+i].Mul(&basis, &toxicWaste.sigma).FromMont()
+			commitmentScalars[i] = basis.ToRegular()
+		}
+		k := kGamma(r1cs.Commitment.Wire)
+		commitmentScalars[2*len(privateCommitted)] = k.ToRegular()
+		blinding := commitmentScalars[2*len(privateCommitted)+1:]
+		blinding[0] = toxicWaste.xi.ToRegular()
+		blinding[1].Mul(&toxicWaste.xi, &toxicWaste.sigma).FromMont()
+		blinding[2].Mul(&toxicWaste.xi, &toxicWaste.gamma).Mul(&blinding[2], &deltaInv).FromMont()
 	}
 
-	for i := 0; i < nbPublicWires; i++ {
-		t1.Mul(&A[i+nbPrivateWires], &toxicWaste.beta)
-		t0.Mul(&B[i+nbPrivateWires], &toxicWaste.alpha)
-		t1.Add(&t1, &t0).
-			Add(&t1, &C[i+nbPrivateWires]).
-			Div(&t1, &toxicWaste.gamma)
-		vkK[i] = t1.ToRegular()
+	utils.Parallelize(nbPrivateWires, func(start, end int) {
+		var t0, t1 fr.Element
+		for i := start; i < end; i++ {
+			t1.Mul(&A[i], &toxicWaste.beta)
+			t0.Mul(&B[i], &toxicWaste.alpha)
+			t1.Add(&t1, &t0).
+				Add(&t1, &C[i]).
+				Mul(&t1, &deltaInv)
+			pkK[i] = t1.ToRegular()
+		}
+	}, config.MaxWorkers)
+
+	// the prover can't add the committed private wires and the commitment wire to Krs
+	if r1cs.Commitment != nil {
+		for _, wireID := range privateCommitted {
+			pkK[wireID].SetZero()
+		}
+		pkK[r1cs.Commitment.Wire].SetZero()
 	}
 
+	utils.Parallelize(nbPublicWires, func(start, end int) {
+		var t0, t1 fr.Element
+		for i := start; i < end; i++ {
+			t1.Mul(&A[i+nbPrivateWires], &toxicWaste.beta)
+			t0.Mul(&B[i+nbPrivateWires], &toxicWaste.alpha)
+			t1.Add(&t1, &t0).
+				Add(&t1, &C[i+nbPrivateWires]).
+				Mul(&t1, &gammaInv)
+			vkK[i] = t1.ToRegular()
+		}
+	}, config.MaxWorkers)
+
 	// convert A and B to regular form
-	for i := 0; i < int(nbWires); i++ {
-		A[i].FromMont()
-	}
-	for i := 0; i < int(nbWires); i++ {
-		B[i].FromMont()
-	}
+	utils.Parallelize(nbWires, func(start, end int) {
+		for i := start; i < end; i++ {
+			A[i].FromMont()
+			B[i].FromMont()
+		}
+	}, config.MaxWorkers)
 
 	// Z part of the proving key (scalars)
+	// Z[i] = t^i * (t^n - 1) / δ
 	Z := make([]fr.Element, domain.Cardinality)
 	one := fr.One()
 	var zdt fr.Element
 
 	zdt.Exp(toxicWaste.t, new(big.Int).SetUint64(domain.Cardinality)).
 		Sub(&zdt, &one).
-		Div(&zdt, &toxicWaste.delta) // sets Zdt to Zdt/delta
-
-	for i := 0; i < int(domain.Cardinality); i++ {
-		Z[i] = zdt.ToRegular()
-		zdt.MulAssign(&toxicWaste.t)
-	}
+		Mul(&zdt, &deltaInv) // sets Zdt to Zdt/delta
+
+	utils.Parallelize(len(Z), func(start, end int) {
+		var zi fr.Element
+		zi.Exp(toxicWaste.t, new(big.Int).SetUint64(uint64(start))).
+			Mul(&zi, &zdt)
+		for i := start; i < end; i++ {
+			Z[i] = zi.ToRegular()
+			zi.MulAssign(&toxicWaste.t)
+		}
+	}, config.MaxWorkers)
 
 	// compute our batch scalar multiplication with g1 elements
-	g1Scalars := make([]fr.Element, 0, (nbWires*3)+int(domain.Cardinality)+3)
+	g1Scalars := make([]fr.Element, 0, (nbWires*3)+int(domain.Cardinality)+3+len(commitmentScalars))
 	g1Scalars = append(g1Scalars, toxicWaste.alphaReg, toxicWaste.betaReg, toxicWaste.deltaReg)
 	g1Scalars = append(g1Scalars, A...)
 	g1Scalars = append(g1Scalars, B...)
 	g1Scalars = append(g1Scalars, pkK...)
 	g1Scalars = append(g1Scalars, Z...)
 	g1Scalars = append(g1Scalars, vkK...)
+	g1Scalars = append(g1Scalars, commitmentScalars...)
 
-	g1PointsAff := curve.BatchScalarMultiplicationG1(&g1, g1Scalars)
+	g1PointsAff := batchScalarMultiplicationG1(&g1, g1Scalars, config.Progress)
 
 	// sets pk: [α]1, [β]1, [δ]1
 	pk.G1.Alpha = g1PointsAff[0]
@@ -202,20 +314,41 @@ func Setup(r1cs *bw761backend.R1CS, pk *ProvingKey, vk *VerifyingKey) error {
 
 	offset += int(domain.Cardinality)
 
-	vk.G1.K = g1PointsAff[offset:]
+	vk.G1.K = g1PointsAff[offset : offset+nbPublicWires]
+	offset += nbPublicWires
+
+	pk.Commitment, vk.Commitment = nil, nil
+	if r1cs.Commitment != nil {
+		offset2 := offset + 2*len(privateCommitted)
+		pk.Commitment = &CommitmentKey{
+			Basis:            g1PointsAff[offset : offset+len(privateCommitted)],
+			BasisExpSigma:    g1PointsAff[offset+len(privateCommitted) : offset2],
+			Blinding:         g1PointsAff[offset2+1],
+			BlindingExpSigma: g1PointsAff[offset2+2],
+			BlindingKrs:      g1PointsAff[offset2+3],
+		}
+		vk.Commitment = &CommitmentVerifyingKey{
+			K:               g1PointsAff[offset2],
+			G:               g2,
+			PublicCommitted: publicCommitted,
+		}
+	}
 
 	// ---------------------------------------------------------------------------------------------
 	// G2 scalars
 
 	// the G2 scalars are ordered as follow:
 	//
-	// [[B(i)], [β], [δ], [γ]]
-	// len(B) == nbWires
+	// [[B(i)], [β], [δ], [γ], [σ]]
+	// len(B) == nbWires, and [σ] is computed only if the circuit has a commitment
 
 	// compute our batch scalar multiplication with g2 elements
 	g2Scalars := append(B, toxicWaste.betaReg, toxicWaste.deltaReg, toxicWaste.gammaReg)
+	if r1cs.Commitment != nil {
+		g2Scalars = append(g2Scalars, toxicWaste.sigmaReg)
+	}
 
-	g2PointsAff := curve.BatchScalarMultiplicationG2(&g2, g2Scalars)
+	g2PointsAff := batchScalarMultiplicationG2(&g2, g2Scalars, config.Progress)
 
 	pk.G2.B = g2PointsAff[:nbWires]
 
@@ -229,6 +362,15 @@ func Setup(r1cs *bw761backend.R1CS, pk *ProvingKey, vk *VerifyingKey) error {
 	vk.G2.DeltaNeg.Neg(&vk.G2.DeltaNeg)
 	vk.G2.GammaNeg.Neg(&vk.G2.GammaNeg)
 
+	// sets vk: [σ]2
+	if r1cs.Commitment != nil {
+		vk.Commitment.GSigma = g2PointsAff[nbWires+3]
+	}
+
+	// sets vk: [α]1, [β]2
+	vk.G1.Alpha = pk.G1.Alpha
+	vk.G2.Beta = pk.G2.Beta
+
 	// ---------------------------------------------------------------------------------------------
 	// Pairing: vk.E
 	vk.E, err = curve.Pair([]curve.G1Affine{pk.G1.Alpha}, []curve.G2Affine{pk.G2.Beta})
@@ -241,7 +383,29 @@ func Setup(r1cs *bw761backend.R1CS, pk *ProvingKey, vk *VerifyingKey) error {
 	return nil
 }
 
-func setupABC(r1cs *bw761backend.R1CS, g *fft.Domain, toxicWaste toxicWaste) (A []fr.Element, B []fr.Element, C []fr.Element) {
+// committedWires returns the wires committed by the circuit (see r1c.Commitment): the distinct private
+// wires, and the indexes in the public wires of the public ones, in the order of the call to Commit
+func committedWires(r1cs *bw761backend.R1CS) (private, public []int) {
+	if r1cs.Commitment == nil {
+		return nil, nil
+	}
+	nbPrivateWires := int(r1cs.NbWires - r1cs.NbPublicWires)
+	seen := make(map[int]bool, len(r1cs.Commitment.Committed))
+	for _, wireID := range r1cs.Commitment.Committed {
+		if wireID >= nbPrivateWires {
+			public = append(public, wireID-nbPrivateWires)
+		} else if !seen[wireID] {
+			seen[wireID] = true
+			private = append(private, wireID)
+		}
+	}
+	return
+}
+
+// setupABC evaluates the QAP polynomials A, B and C at the toxic waste t
+// A[i] = Σ_j L[j][i] * Lagrange_j(t), where j is the constraint index and i the wire index
+// (similarly for B and C, with R and O)
+func setupABC(r1cs *bw761backend.R1CS, g *fft.Domain, toxicWaste toxicWaste, nbTasks int) (A []fr.Element, B []fr.Element, C []fr.Element) {
 
 	nbWires := r1cs.NbWires
 
@@ -249,58 +413,128 @@ func setupABC(r1cs *bw761backend.R1CS, g *fft.Domain, toxicWaste toxicWaste) (A
 	B = make([]fr.Element, nbWires)
 	C = make([]fr.Element, nbWires)
 
-	var one fr.Element
-	one.SetOne()
-
-	// evaluation of the i-th lagrange polynomial at t
-	var ithLagrangePolt fr.Element
+	// evaluation of the i-th lagrange polynomial at t, for each constraint
+	lagrange := lagrangeEvaluations(len(r1cs.Constraints), g, toxicWaste.t, nbTasks)
 
-	// L0 = 1/n*(t^n-1)/(t-1), Li+1 = w*Li*(t-w^i)/(t-w^(i+1))
-	var w, wi, tmp fr.Element
-	w.Set(&g.Generator)
-	wi.SetOne()
+	// A, B and C depend respectively only on L, R and O; we compute them concurrently
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		for i, c := range r1cs.Constraints {
+			for _, t := range c.L {
+				r1cs.AddTerm(&A[t.VariableID()], t, lagrange[i])
+			}
+		}
+		wg.Done()
+	}()
+	go func() {
+		for i, c := range r1cs.Constraints {
+			for _, t := range c.R {
+				r1cs.AddTerm(&B[t.VariableID()], t, lagrange[i])
+			}
+		}
+		wg.Done()
+	}()
+	for i, c := range r1cs.Constraints {
+		for _, t := range c.O {
+			r1cs.AddTerm(&C[t.VariableID()], t, lagrange[i])
+		}
+	}
+	wg.Done()
+	wg.Wait()
 
-	// Setting L0
-	ithLagrangePolt.Set(&toxicWaste.t)
-	ithLagrangePolt.Exp(ithLagrangePolt, new(big.Int).SetUint64(uint64(g.Cardinality))).
-		Sub(&ithLagrangePolt, &one)
-	tmp.Set(&toxicWaste.t).Sub(&tmp, &one)
-	ithLagrangePolt.Div(&ithLagrangePolt, &tmp).
-		Mul(&ithLagrangePolt, &g.CardinalityInv)
+	return
+}
 
-	// Constraints
-	for _, c := range r1cs.Constraints {
+// lagrangeEvaluations returns the evaluations at t of the n first Lagrange polynomials on the domain
+//
+// Li(t) = w^i/d * (t^d-1)/(t-w^i), where d == domain.Cardinality and w == domain.Generator
+// the computation is split in independent chunks, and each chunk does a single field inversion
+func lagrangeEvaluations(n int, domain *fft.Domain, t fr.Element, nbTasks int) []fr.Element {
+	res := make([]fr.Element, n)
 
-		for _, t := range c.L {
-			r1cs.AddTerm(&A[t.VariableID()], t, ithLagrangePolt)
-		}
-		for _, t := range c.R {
-			r1cs.AddTerm(&B[t.VariableID()], t, ithLagrangePolt)
+	// c = (t^d-1)/d
+	var c fr.Element
+	one := fr.One()
+	c.Exp(t, new(big.Int).SetUint64(domain.Cardinality)).
+		Sub(&c, &one).
+		Mul(&c, &domain.CardinalityInv)
+
+	utils.Parallelize(n, func(start, end int) {
+		wis := make([]fr.Element, end-start)
+		var wi fr.Element
+		wi.Exp(domain.Generator, new(big.Int).SetUint64(uint64(start)))
+		for i := start; i < end; i++ {
+			wis[i-start] = wi
+			res[i].Sub(&t, &wi)
+			wi.MulAssign(&domain.Generator)
 		}
-		for _, t := range c.O {
-			r1cs.AddTerm(&C[t.VariableID()], t, ithLagrangePolt)
+		batchInvert(res[start:end])
+		for i := start; i < end; i++ {
+			res[i].Mul(&res[i], &wis[i-start]).
+				Mul(&res[i], &c)
 		}
+	}, nbTasks)
 
-		// Li+1 = w*Li*(t-w^i)/(t-w^(i+1))
-		ithLagrangePolt.MulAssign(&w)
-		tmp.Sub(&toxicWaste.t, &wi)
-		ithLagrangePolt.MulAssign(&tmp)
-		wi.MulAssign(&w)
-		tmp.Sub(&toxicWaste.t, &wi)
-		ithLagrangePolt.Div(&ithLagrangePolt, &tmp)
+	return res
+}
+
+// batchInvert sets a[i] = 1/a[i] using Montgomery batch inversion trick
+func batchInvert(a []fr.Element) {
+	if len(a) == 0 {
+		return
 	}
-	return
+	prefix := make([]fr.Element, len(a))
+	var acc fr.Element
+	acc.SetOne()
+	for i := 0; i < len(a); i++ {
+		prefix[i] = acc
+		acc.Mul(&acc, &a[i])
+	}
+	acc.Inverse(&acc)
+	for i := len(a) - 1; i >= 0; i-- {
+		prefix[i].Mul(&prefix[i], &acc)
+		acc.Mul(&acc, &a[i])
+		a[i] = prefix[i]
+	}
+}
 
+// batchScalarMultiplicationG1 calls curve.BatchScalarMultiplicationG1 on chunks of scalars to report progress
+func batchScalarMultiplicationG1(base *curve.G1Affine, scalars []fr.Element, progress backend.ProgressFunc) []curve.G1Affine {
+	res := make([]curve.G1Affine, 0, len(scalars))
+	for start := 0; start < len(scalars); start += setupChunkSize {
+		end := start + setupChunkSize
+		if end > len(scalars) {
+			end = len(scalars)
+		}
+		res = append(res, curve.BatchScalarMultiplicationG1(base, scalars[start:end])...)
+		progress(stepG1, end, len(scalars))
+	}
+	return res
+}
+
+// batchScalarMultiplicationG2 calls curve.BatchScalarMultiplicationG2 on chunks of scalars to report progress
+func batchScalarMultiplicationG2(base *curve.G2Affine, scalars []fr.Element, progress backend.ProgressFunc) []curve.G2Affine {
+	res := make([]curve.G2Affine, 0, len(scalars))
+	for start := 0; start < len(scalars); start += setupChunkSize {
+		end := start + setupChunkSize
+		if end > len(scalars) {
+			end = len(scalars)
+		}
+		res = append(res, curve.BatchScalarMultiplicationG2(base, scalars[start:end])...)
+		progress(stepG2, end, len(scalars))
+	}
+	return res
 }
 
 // toxicWaste toxic waste
 type toxicWaste struct {
 
 	// Montgomery form of params
-	t, alpha, beta, gamma, delta fr.Element
+	t, alpha, beta, gamma, delta, sigma, xi fr.Element
 
 	// Non Montgomery form of params
-	alphaReg, betaReg, gammaReg, deltaReg fr.Element
+	alphaReg, betaReg, gammaReg, deltaReg, sigmaReg fr.Element
 }
 
 func sampleToxicWaste() (toxicWaste, error) {
@@ -322,11 +556,18 @@ func sampleToxicWaste() (toxicWaste, error) {
 	if _, err := res.delta.SetRandom(); err != nil {
 		return res, err
 	}
+	if _, err := res.sigma.SetRandom(); err != nil {
+		return res, err
+	}
+	if _, err := res.xi.SetRandom(); err != nil {
+		return res, err
+	}
 
 	res.alphaReg = res.alpha.ToRegular()
 	res.betaReg = res.beta.ToRegular()
 	res.gammaReg = res.gamma.ToRegular()
 	res.deltaReg = res.delta.ToRegular()
+	res.sigmaReg = res.sigma.ToRegular()
 
 	return res, nil
 }
@@ -381,6 +622,21 @@ func DummySetup(r1cs *bw761backend.R1CS, pk *ProvingKey) error {
 	pk.G2.Beta = r2Aff
 	pk.G2.Delta = r2Aff
 
+	pk.Commitment = nil
+	if privateCommitted, _ := committedWires(r1cs); r1cs.Commitment != nil {
+		pk.Commitment = &CommitmentKey{
+			Basis:         make([]curve.G1Affine, len(privateCommitted)),
+			BasisExpSigma: make([]curve.G1Affine, len(privateCommitted)),
+		}
+		for i := range privateCommitted {
+			pk.Commitment.Basis[i] = r1Aff
+			pk.Commitment.BasisExpSigma[i] = r1Aff
+		}
+		pk.Commitment.Blinding = r1Aff
+		pk.Commitment.BlindingExpSigma = r1Aff
+		pk.Commitment.BlindingKrs = r1Aff
+	}
+
 	pk.Domain = *domain
 
 	return nil
@@ -21,29 +21,55 @@ import (
 
 	curve "github.com/consensys/gurvy/bw761"
 
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
 	"errors"
 	"github.com/consensys/gnark/backend"
+	"math/big"
 )
 
 var (
 	errPairingCheckFailed         = errors.New("pairing doesn't match")
 	errCorrectSubgroupCheckFailed = errors.New("points in the proof are not in the correct subgroup")
+	errCommitmentCheckFailed      = errors.New("the proof of knowledge of the commitment doesn't match")
 )
 
 // Verify verifies a proof
-func Verify(proof *Proof, vk *VerifyingKey, inputs map[string]interface{}) error {
+//
+// if backend.ConstantTime is set, the checks of the proof don't exit early and the pairing result is
+// compared in constant time; the curve arithmetic is not constant time (see backend.ConstantTime)
+//
+// see VerifyingKey.Precompute to speed up the verification of many proofs with the same key
+func Verify(proof *Proof, vk *VerifyingKey, inputs map[string]interface{}, opts ...backend.Option) error {
+	config, err := backend.NewConfig(opts...)
+	if err != nil {
+		return err
+	}
 
 	// check that the points in the proof are in the correct subgroup
-	if !proof.isValid() {
+	validProof := true
+	if config.ConstantTime {
+		validAr, validKrs, validBs := proof.Ar.IsInSubGroup(), proof.Krs.IsInSubGroup(), proof.Bs.IsInSubGroup()
+		validCommitment, validPok := proof.Commitment.IsInSubGroup(), proof.CommitmentPok.IsInSubGroup()
+		validProof = validAr && validKrs && validBs && validCommitment && validPok
+	} else if !proof.isValid() {
 		return errCorrectSubgroupCheckFailed
 	}
 
 	var doubleML curve.GT
 	chDone := make(chan error, 1)
+	lines := vk.fixedLines()
 
-	// compute (eKrsδ, eArBs)
+	// compute (eKrsδ, eArBs), or eArBs only if the lines of -[δ]2 are precomputed
 	go func() {
 		var errML error
+		if lines != nil {
+			doubleML, errML = curve.MillerLoop([]curve.G1Affine{proof.Ar}, []curve.G2Affine{proof.Bs})
+			chDone <- errML
+			close(chDone)
+			return
+		}
 
 		// TODO temporary while bw761 API catches up in gurvy
 		var eKrsδ, eArBs curve.GT
@@ -60,15 +86,32 @@ func Verify(proof *Proof, vk *VerifyingKey, inputs map[string]interface{}) error
 	}()
 
 	// compute e(Σx.[Kvk(t)]1, -[γ]2)
-	var kSum curve.G1Affine
-	kInputs, err := ParsePublicInput(vk.PublicInputs, inputs)
+	kSum, err := vk.publicInputs(proof, inputs)
 	if err != nil {
 		return err
 	}
-	kSum.MultiExp(vk.G1.K, kInputs)
 
-	right, err := curve.MillerLoop([]curve.G1Affine{kSum}, []curve.G2Affine{vk.G2.GammaNeg})
-	if err != nil {
+	validCommitment := true
+	if vk.Commitment != nil {
+		// e(D, [σ]2) == e(Pok, [1]2) proves that D is a combination of the commitment key
+		eD, err := curve.Pair([]curve.G1Affine{proof.Commitment}, []curve.G2Affine{vk.Commitment.GSigma})
+		if err != nil {
+			return err
+		}
+		ePok, err := curve.Pair([]curve.G1Affine{proof.CommitmentPok}, []curve.G2Affine{vk.Commitment.G})
+		if err != nil {
+			return err
+		}
+		if validCommitment = eD.Equal(&ePok); !validCommitment && !config.ConstantTime {
+			return errCommitmentCheckFailed
+		}
+	}
+
+	var right curve.GT
+	if lines != nil {
+		// compute e(Σx.[Kvk(t)]1, -[γ]2) and eKrsδ with the precomputed lines
+		right = millerLoopFixed([]curve.G1Affine{kSum, proof.Krs}, [][]line{lines.gamma, lines.delta})
+	} else if right, err = curve.MillerLoop([]curve.G1Affine{kSum}, []curve.G2Affine{vk.G2.GammaNeg}); err != nil {
 		return err
 	}
 
@@ -79,12 +122,200 @@ func Verify(proof *Proof, vk *VerifyingKey, inputs map[string]interface{}) error
 	}
 
 	right = curve.FinalExponentiation(&right, &doubleML)
+	if config.ConstantTime {
+		e, r := vk.E.Bytes(), right.Bytes()
+		validPairing := subtle.ConstantTimeCompare(e[:], r[:]) == 1
+		if !validProof {
+			return errCorrectSubgroupCheckFailed
+		}
+		if !validCommitment {
+			return errCommitmentCheckFailed
+		}
+		if !validPairing {
+			return errPairingCheckFailed
+		}
+		return nil
+	}
 	if !vk.E.Equal(&right) {
 		return errPairingCheckFailed
 	}
 	return nil
 }
 
+// publicInputs returns Σx.[Kvk(t)]1 of the public inputs of a proof
+//
+// the commitment and the commitment wire are public: the sum includes [D]1 + challenge.[Kvk]1 of the
+// commitment wire
+func (vk *VerifyingKey) publicInputs(proof *Proof, inputs map[string]interface{}) (curve.G1Affine, error) {
+	var kSum curve.G1Affine
+	kInputs, err := ParsePublicInput(vk.PublicInputs, inputs)
+	if err != nil {
+		return kSum, err
+	}
+	kSum.MultiExp(vk.G1.K, kInputs)
+
+	if vk.Commitment != nil {
+		public := make([]fr.Element, len(vk.Commitment.PublicCommitted))
+		for i, j := range vk.Commitment.PublicCommitted {
+			public[i] = kInputs[j]
+			public[i].ToMont()
+		}
+		challenge := commitmentChallenge(&proof.Commitment, public)
+		var c big.Int
+		challenge.ToBigIntRegular(&c)
+
+		var k, t curve.G1Jac
+		t.FromAffine(&vk.Commitment.K)
+		k.ScalarMultiplication(&t, &c)
+		k.AddMixed(&kSum).AddMixed(&proof.Commitment)
+		kSum.FromJacobian(&k)
+	}
+	return kSum, nil
+}
+
+// BatchVerify verifies proofs of the same circuit, proofs[i] with the public inputs inputs[i], with a
+// single final exponentiation
+//
+// the verification equations are combined with random coefficients rᵢ of 128 bits:
+//
+//	Π e(rᵢ.Arᵢ, Bsᵢ) . e(Σrᵢ.Krsᵢ, -[δ]2) . e(Σrᵢ.kSumᵢ, -[γ]2) == e(α, β)^Σrᵢ
+//
+// so that n proofs cost n+2 Miller loops instead of 3n, and an invalid proof makes the check fail except
+// with probability 2⁻¹²⁸. The proofs of knowledge of the commitments are combined in the same way.
+// The error doesn't tell which proof is invalid; Verify does
+func BatchVerify(vk *VerifyingKey, proofs []*Proof, inputs []map[string]interface{}) error {
+	if len(proofs) != len(inputs) {
+		return errors.New("the number of proofs and of public inputs don't match")
+	}
+	if len(proofs) == 0 {
+		return nil
+	}
+	for _, proof := range proofs {
+		if !proof.isValid() {
+			return errCorrectSubgroupCheckFailed
+		}
+	}
+
+	r, rBig, err := randomCoefficients(len(proofs))
+	if err != nil {
+		return err
+	}
+
+	P := make([]curve.G1Affine, 0, len(proofs)+2)
+	Q := make([]curve.G2Affine, 0, len(proofs)+2)
+	krs := make([]curve.G1Affine, len(proofs))
+	kSums := make([]curve.G1Affine, len(proofs))
+	var rSum big.Int
+	for i, proof := range proofs {
+		if kSums[i], err = vk.publicInputs(proof, inputs[i]); err != nil {
+			return err
+		}
+		krs[i] = proof.Krs
+		var ar curve.G1Affine
+		ar.ScalarMultiplication(&proof.Ar, &rBig[i])
+		P = append(P, ar)
+		Q = append(Q, proof.Bs)
+		rSum.Add(&rSum, &rBig[i])
+	}
+	var krsSum, kSum curve.G1Affine
+	krsSum.MultiExp(krs, r)
+	kSum.MultiExp(kSums, r)
+
+	var ml curve.GT
+	if lines := vk.fixedLines(); lines != nil {
+		if ml, err = millerLoop(P, Q); err != nil {
+			return err
+		}
+		fixed := millerLoopFixed([]curve.G1Affine{krsSum, kSum}, [][]line{lines.delta, lines.gamma})
+		ml.Mul(&ml, &fixed)
+	} else {
+		P = append(P, krsSum, kSum)
+		Q = append(Q, vk.G2.DeltaNeg, vk.G2.GammaNeg)
+		if ml, err = millerLoop(P, Q); err != nil {
+			return err
+		}
+	}
+	right := curve.FinalExponentiation(&ml)
+	var left curve.GT
+	left.Exp(&vk.E, rSum)
+	if !left.Equal(&right) {
+		return errPairingCheckFailed
+	}
+
+	if vk.Commitment != nil {
+		// e(Σsᵢ.Dᵢ, [σ]2) == e(Σsᵢ.Pokᵢ, [1]2), with new random coefficients
+		s, _, err := randomCoefficients(len(proofs))
+		if err != nil {
+			return err
+		}
+		d := make([]curve.G1Affine, len(proofs))
+		pok := make([]curve.G1Affine, len(proofs))
+		for i, proof := range proofs {
+			d[i], pok[i] = proof.Commitment, proof.CommitmentPok
+		}
+		var dSum, pokSum curve.G1Affine
+		dSum.MultiExp(d, s)
+		pokSum.MultiExp(pok, s)
+		eD, err := curve.Pair([]curve.G1Affine{dSum}, []curve.G2Affine{vk.Commitment.GSigma})
+		if err != nil {
+			return err
+		}
+		ePok, err := curve.Pair([]curve.G1Affine{pokSum}, []curve.G2Affine{vk.Commitment.G})
+		if err != nil {
+			return err
+		}
+		if !eD.Equal(&ePok) {
+			return errCommitmentCheckFailed
+		}
+	}
+	return nil
+}
+
+// randomCoefficients returns n random coefficients of 128 bits, in regular form (as scalars of a
+// multi exponentiation) and as big.Int
+func randomCoefficients(n int) ([]fr.Element, []big.Int, error) {
+	r := make([]fr.Element, n)
+	rBig := make([]big.Int, n)
+	var buf [16]byte
+	for i := range r {
+		if _, err := rand.Read(buf[:]); err != nil {
+			return nil, nil, err
+		}
+		rBig[i].SetBytes(buf[:])
+		r[i].SetBigInt(&rBig[i]).FromMont()
+	}
+	return r, rBig, nil
+}
+
+// millerLoop returns the product of the Miller loops of (P[i], Q[i])
+func millerLoop(P []curve.G1Affine, Q []curve.G2Affine) (curve.GT, error) {
+	// TODO temporary while bw761 API catches up in gurvy
+	var res curve.GT
+	res.SetOne()
+	for i := range P {
+		ml, err := curve.MillerLoop(P[i:i+1], Q[i:i+1])
+		if err != nil {
+			return res, err
+		}
+		res.Mul(&res, &ml)
+	}
+	return res, nil
+}
+
+// commitmentChallenge returns the value of the commitment wire (see r1c.Commitment): the hash (sha256)
+// of the commitment and of the values of the committed public wires (Montgomery form), reduced modulo r
+func commitmentChallenge(commitment *curve.G1Affine, public []fr.Element) (res fr.Element) {
+	h := sha256.New()
+	b := commitment.RawBytes()
+	h.Write(b[:])
+	for i := range public {
+		b := public[i].Bytes()
+		h.Write(b[:])
+	}
+	res.SetBytes(h.Sum(nil))
+	return
+}
+
 // ParsePublicInput return the ordered public input values
 // in regular form (used as scalars for multi exponentiation).
 // The function is public because it's needed for the recursive snark.
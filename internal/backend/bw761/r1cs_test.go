@@ -21,6 +21,7 @@ import (
 
 	"bytes"
 	"github.com/consensys/gnark/internal/backend/circuits"
+	"github.com/consensys/gnark/internal/backend/ioutils"
 	"github.com/consensys/gurvy"
 	"reflect"
 	"testing"
@@ -59,3 +60,39 @@ func TestSerialization(t *testing.T) {
 		})
 	}
 }
+
+func TestCompressedSerialization(t *testing.T) {
+	var buffer bytes.Buffer
+	for name, circuit := range circuits.Circuits {
+		r1cs := circuit.R1CS.ToR1CS(gurvy.BW761).(*bw761backend.R1CS)
+
+		if testing.Short() && r1cs.GetNbConstraints() > 50 {
+			continue
+		}
+		buffer.Reset()
+
+		t.Run(name, func(t *testing.T) {
+			if _, err := r1cs.WriteCompressedTo(&buffer); err != nil {
+				t.Fatal(err)
+			}
+			reader := bytes.NewReader(buffer.Bytes())
+
+			var reconstructed bw761backend.R1CS
+			if err := reconstructed.ReadCompressedFrom(reader, ioutils.LoadConstraints|ioutils.LoadCoefficients|ioutils.LoadDebug); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(r1cs, &reconstructed) {
+				t.Fatal("round trip serialization failed")
+			}
+
+			// only the header
+			var header bw761backend.R1CS
+			if err := header.ReadCompressedFrom(reader, 0); err != nil {
+				t.Fatal(err)
+			}
+			if header.NbConstraints != r1cs.NbConstraints || !reflect.DeepEqual(header.PublicWires, r1cs.PublicWires) || header.Constraints != nil {
+				t.Fatal("reading the header section failed")
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package circuits
+
+import (
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gurvy"
+)
+
+type batchInvertCircuit struct {
+	X [3]frontend.Variable
+	Z frontend.Variable `gnark:",public"`
+}
+
+func (circuit *batchInvertCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	// z = 1/x[0] + 1/x[1] + 1/x[2] + 1/2
+	inverses := cs.BatchInvert([]frontend.Variable{circuit.X[0], circuit.X[1], circuit.X[2], cs.Constant(2)})
+	cs.AssertIsEqual(cs.Add(inverses[0], inverses[1], inverses[2], inverses[3]), circuit.Z)
+	return nil
+}
+
+func init() {
+	var circuit, good, bad, public batchInvertCircuit
+	r1cs, err := frontend.Compile(gurvy.UNKNOWN, &circuit)
+	if err != nil {
+		panic(err)
+	}
+
+	// 1/1 + 1/2 + 1/1 + 1/2
+	good.X[0].Assign(1)
+	good.X[1].Assign(2)
+	good.X[2].Assign(1)
+	good.Z.Assign(3)
+
+	bad.X[0].Assign(1)
+	bad.X[1].Assign(0)
+	bad.X[2].Assign(1)
+	bad.Z.Assign(3)
+
+	public.Z.Assign(3)
+
+	addEntry("batchinvert", r1cs, &good, &bad, &public)
+}
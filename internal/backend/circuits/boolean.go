@@ -0,0 +1,44 @@
+package circuits
+
+import (
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gurvy"
+)
+
+type booleanCircuit struct {
+	B0, B1, B2 frontend.Variable
+	Y0         frontend.Variable `gnark:",public"`
+}
+
+func (circuit *booleanCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	// (b0 and b1) or (not b2), xor b0 xor b1
+	and := cs.And(circuit.B0, circuit.B1)
+	or := cs.Or(and, cs.Not(circuit.B2))
+	z0 := cs.Xor(or, circuit.B0, circuit.B1)
+
+	cs.AssertIsEqual(z0, circuit.Y0)
+
+	return nil
+}
+
+func init() {
+	var circuit, good, bad, public booleanCircuit
+	r1cs, err := frontend.Compile(gurvy.UNKNOWN, &circuit)
+	if err != nil {
+		panic(err)
+	}
+
+	good.B0.Assign(1)
+	good.B1.Assign(0)
+	good.B2.Assign(1)
+	good.Y0.Assign(1)
+
+	bad.B0.Assign(1)
+	bad.B1.Assign(1)
+	bad.B2.Assign(2)
+	bad.Y0.Assign(1)
+
+	public.Y0.Assign(1)
+
+	addEntry("boolean", r1cs, &good, &bad, &public)
+}
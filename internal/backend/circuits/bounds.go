@@ -0,0 +1,39 @@
+package circuits
+
+import (
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gurvy"
+)
+
+type boundsCircuit struct {
+	Bytes [2]frontend.Variable `gnark:",bits=8"`
+	Y     frontend.Variable    `gnark:",public,range=10..20"`
+}
+
+func (circuit *boundsCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	// y = bytes[0] + bytes[1]
+	cs.AssertIsEqual(cs.Add(circuit.Bytes[0], circuit.Bytes[1]), circuit.Y)
+
+	return nil
+}
+
+func init() {
+	var circuit, good, bad, public boundsCircuit
+	r1cs, err := frontend.Compile(gurvy.UNKNOWN, &circuit)
+	if err != nil {
+		panic(err)
+	}
+
+	good.Bytes[0].Assign(5)
+	good.Bytes[1].Assign(10)
+	good.Y.Assign(15)
+
+	// y = bytes[0] + bytes[1], but bytes[1] doesn't fit in 8 bits
+	bad.Bytes[0].Assign(-241)
+	bad.Bytes[1].Assign(256)
+	bad.Y.Assign(15)
+
+	public.Y.Assign(15)
+
+	addEntry("bounds", r1cs, &good, &bad, &public)
+}
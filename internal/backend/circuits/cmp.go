@@ -0,0 +1,39 @@
+package circuits
+
+import (
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gurvy"
+)
+
+type cmpCircuit struct {
+	X, Y frontend.Variable
+	R    frontend.Variable `gnark:",public"`
+}
+
+func (circuit *cmpCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	xy := cs.Cmp(circuit.X, circuit.Y, 8)
+	y10 := cs.Cmp(circuit.Y, 10, 8)
+	cs.AssertIsEqual(cs.Add(xy, cs.Mul(y10, 3)), circuit.R)
+	return nil
+}
+
+func init() {
+
+	var circuit, good, bad, public cmpCircuit
+	r1cs, err := frontend.Compile(gurvy.UNKNOWN, &circuit)
+	if err != nil {
+		panic(err)
+	}
+
+	good.X.Assign(5)
+	good.Y.Assign(12)
+	good.R.Assign(2)
+
+	bad.X.Assign(12)
+	bad.Y.Assign(5)
+	bad.R.Assign(2)
+
+	public.R.Assign(2)
+
+	addEntry("cmp", r1cs, &good, &bad, &public)
+}
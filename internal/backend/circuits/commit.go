@@ -0,0 +1,47 @@
+package circuits
+
+import (
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gurvy"
+)
+
+type commitCircuit struct {
+	X [3]frontend.Variable
+	Y [3]frontend.Variable `gnark:",public"`
+}
+
+func (circuit *commitCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	// x is a permutation of y: (r-x[0])(r-x[1])(r-x[2]) == (r-y[0])(r-y[1])(r-y[2]) for a random r
+	r := cs.Commit(circuit.X[0], circuit.X[1], circuit.X[2], circuit.Y[0], circuit.Y[1], circuit.Y[2])
+	px, py := cs.Constant(1), cs.Constant(1)
+	for i := 0; i < len(circuit.X); i++ {
+		px = cs.Mul(px, cs.Sub(r, circuit.X[i]))
+		py = cs.Mul(py, cs.Sub(r, circuit.Y[i]))
+	}
+	cs.AssertIsEqual(px, py)
+
+	return nil
+}
+
+func init() {
+	var circuit, good, bad, public commitCircuit
+	r1cs, err := frontend.Compile(gurvy.UNKNOWN, &circuit)
+	if err != nil {
+		panic(err)
+	}
+
+	for i, v := range []int{3, 5, 7} {
+		good.Y[i].Assign(v)
+		bad.Y[i].Assign(v)
+		public.Y[i].Assign(v)
+	}
+	good.X[0].Assign(7)
+	good.X[1].Assign(3)
+	good.X[2].Assign(5)
+
+	bad.X[0].Assign(7)
+	bad.X[1].Assign(3)
+	bad.X[2].Assign(3)
+
+	addEntry("commit", r1cs, &good, &bad, &public)
+}
@@ -0,0 +1,49 @@
+package circuits
+
+import (
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gurvy"
+)
+
+// constantsCircuit mixes its inputs with constant Variables, which are folded at compile time
+type constantsCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (circuit *constantsCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	one := cs.Sub(cs.Constant(3), 2)
+	zero := cs.Sub(circuit.X, circuit.X)
+
+	// 5 * (x + 1), selected by a constant
+	a := cs.Mul(cs.Add(one, 4), cs.Add(circuit.X, one))
+	a = cs.Select(one, a, circuit.X)
+
+	// bits of 6 and constant booleans
+	bits := cs.ToBinary(cs.Mul(one, 6), 3)
+	b := cs.Xor(cs.And(bits[1], bits[2]), zero)
+	b = cs.Or(b, cs.IsZero(one))
+
+	cs.AssertIsEqual(cs.Add(a, b), circuit.Y)
+	cs.AssertIsEqual(cs.Mul(circuit.X, circuit.X, one), 9)
+
+	return nil
+}
+
+func init() {
+	var circuit, good, bad, public constantsCircuit
+	r1cs, err := frontend.Compile(gurvy.UNKNOWN, &circuit)
+	if err != nil {
+		panic(err)
+	}
+
+	good.X.Assign(3)
+	good.Y.Assign(21)
+
+	bad.X.Assign(3)
+	bad.Y.Assign(20)
+
+	public.Y.Assign(21)
+
+	addEntry("constants", r1cs, &good, &bad, &public)
+}
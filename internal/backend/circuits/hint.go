@@ -0,0 +1,45 @@
+package circuits
+
+import (
+	"github.com/consensys/gnark/backend/hint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gurvy"
+)
+
+type hintCircuit struct {
+	A frontend.Variable
+	B frontend.Variable `gnark:",public"`
+}
+
+func (circuit *hintCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	// the bits of A are computed by hints, then constrained
+	b0 := cs.NewHint(hint.IthBit, circuit.A, 0)
+	b1 := cs.NewHint(hint.IthBit, circuit.A, 1)
+	cs.AssertIsBoolean(b0)
+	cs.AssertIsBoolean(b1)
+	cs.AssertIsEqual(cs.Add(cs.Mul(b1, 2), b0), circuit.A)
+
+	// a hint of a hint
+	b := cs.NewHint(hint.IthBit, b1, 0)
+	cs.AssertIsEqual(cs.Mul(b, b0), circuit.B)
+	return nil
+}
+
+func init() {
+
+	var circuit, good, bad, public hintCircuit
+	r1cs, err := frontend.Compile(gurvy.UNKNOWN, &circuit)
+	if err != nil {
+		panic(err)
+	}
+
+	good.A.Assign(3)
+	good.B.Assign(1)
+
+	bad.A.Assign(5)
+	bad.B.Assign(1)
+
+	public.B.Assign(1)
+
+	addEntry("hint", r1cs, &good, &bad, &public)
+}
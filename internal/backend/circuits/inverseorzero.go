@@ -0,0 +1,37 @@
+package circuits
+
+import (
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gurvy"
+)
+
+type inverseOrZeroCircuit struct {
+	X, Y frontend.Variable
+	Z    frontend.Variable `gnark:",public"`
+}
+
+func (circuit *inverseOrZeroCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	// z = 1/x + 1/y, where 1/0 is 0
+	cs.AssertIsEqual(cs.Add(cs.InverseOrZero(circuit.X), cs.InverseOrZero(circuit.Y)), circuit.Z)
+	return nil
+}
+
+func init() {
+	var circuit, good, bad, public inverseOrZeroCircuit
+	r1cs, err := frontend.Compile(gurvy.UNKNOWN, &circuit)
+	if err != nil {
+		panic(err)
+	}
+
+	good.X.Assign(1)
+	good.Y.Assign(0)
+	good.Z.Assign(1)
+
+	bad.X.Assign(0)
+	bad.Y.Assign(0)
+	bad.Z.Assign(1)
+
+	public.Z.Assign(1)
+
+	addEntry("inverseorzero", r1cs, &good, &bad, &public)
+}
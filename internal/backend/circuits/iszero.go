@@ -0,0 +1,39 @@
+package circuits
+
+import (
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gurvy"
+)
+
+type isZeroCircuit struct {
+	X, Y frontend.Variable
+	R    frontend.Variable `gnark:",public"`
+}
+
+func (circuit *isZeroCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	x := cs.IsZero(circuit.X)
+	y := cs.IsZero(circuit.Y)
+	cs.AssertIsEqual(cs.Add(x, cs.Mul(y, 2)), circuit.R)
+	return nil
+}
+
+func init() {
+
+	var circuit, good, bad, public isZeroCircuit
+	r1cs, err := frontend.Compile(gurvy.UNKNOWN, &circuit)
+	if err != nil {
+		panic(err)
+	}
+
+	good.X.Assign(0)
+	good.Y.Assign(5)
+	good.R.Assign(1)
+
+	bad.X.Assign(0)
+	bad.Y.Assign(0)
+	bad.R.Assign(1)
+
+	public.R.Assign(1)
+
+	addEntry("iszero", r1cs, &good, &bad, &public)
+}
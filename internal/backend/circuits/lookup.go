@@ -0,0 +1,36 @@
+package circuits
+
+import (
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gurvy"
+)
+
+type lookupCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (circuit *lookupCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	// x is in {1, 5, 7, y}
+	table := cs.LookupTable(1, 5, 7, circuit.Y)
+	cs.AssertInTable(table, circuit.X)
+	return nil
+}
+
+func init() {
+	var circuit, good, bad, public lookupCircuit
+	r1cs, err := frontend.Compile(gurvy.UNKNOWN, &circuit)
+	if err != nil {
+		panic(err)
+	}
+
+	good.X.Assign(7)
+	good.Y.Assign(3)
+
+	bad.X.Assign(4)
+	bad.Y.Assign(3)
+
+	public.Y.Assign(3)
+
+	addEntry("lookup", r1cs, &good, &bad, &public)
+}
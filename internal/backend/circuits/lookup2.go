@@ -0,0 +1,45 @@
+package circuits
+
+import (
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gurvy"
+)
+
+type lookup2Circuit struct {
+	B0, B1     frontend.Variable
+	I0, I1, I2 frontend.Variable
+	Y          frontend.Variable `gnark:",public"`
+}
+
+func (circuit *lookup2Circuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	v := cs.Lookup2(circuit.B0, circuit.B1, circuit.I0, circuit.I1, circuit.I2, 42)
+	cs.AssertIsEqual(v, circuit.Y)
+	return nil
+}
+
+func init() {
+
+	var circuit, good, bad, public lookup2Circuit
+	r1cs, err := frontend.Compile(gurvy.UNKNOWN, &circuit)
+	if err != nil {
+		panic(err)
+	}
+
+	good.B0.Assign(0)
+	good.B1.Assign(1)
+	good.I0.Assign(10)
+	good.I1.Assign(11)
+	good.I2.Assign(12)
+	good.Y.Assign(12)
+
+	bad.B0.Assign(1)
+	bad.B1.Assign(1)
+	bad.I0.Assign(10)
+	bad.I1.Assign(11)
+	bad.I2.Assign(12)
+	bad.Y.Assign(12)
+
+	public.Y.Assign(12)
+
+	addEntry("lookup2", r1cs, &good, &bad, &public)
+}
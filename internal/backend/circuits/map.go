@@ -0,0 +1,58 @@
+package circuits
+
+import (
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gurvy"
+)
+
+type point struct {
+	X, Y frontend.Variable
+}
+
+type mapCircuit struct {
+	Params map[string]frontend.Variable
+	Points map[string]point
+	Res    map[string]frontend.Variable `gnark:",public"`
+}
+
+func (circuit *mapCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	// res = a * p.x + b * q.y
+	ax := cs.Mul(circuit.Params["a"], circuit.Points["p"].X)
+	by := cs.Mul(circuit.Params["b"], circuit.Points["q"].Y)
+	cs.AssertIsEqual(cs.Add(ax, by), circuit.Res["res"])
+
+	return nil
+}
+
+// newMapCircuit returns a mapCircuit with the given values (nil for the Variables left unassigned)
+func newMapCircuit(params, points, res []interface{}) *mapCircuit {
+	assign := func(value interface{}) frontend.Variable {
+		var v frontend.Variable
+		if value != nil {
+			v.Assign(value)
+		}
+		return v
+	}
+	return &mapCircuit{
+		Params: map[string]frontend.Variable{"a": assign(params[0]), "b": assign(params[1])},
+		Points: map[string]point{
+			"p": {X: assign(points[0]), Y: assign(points[1])},
+			"q": {X: assign(points[2]), Y: assign(points[3])},
+		},
+		Res: map[string]frontend.Variable{"res": assign(res[0])},
+	}
+}
+
+func init() {
+	circuit := newMapCircuit(make([]interface{}, 2), make([]interface{}, 4), make([]interface{}, 1))
+	r1cs, err := frontend.Compile(gurvy.UNKNOWN, circuit)
+	if err != nil {
+		panic(err)
+	}
+
+	good := newMapCircuit([]interface{}{2, 3}, []interface{}{5, 7, 11, 13}, []interface{}{2*5 + 3*13})
+	bad := newMapCircuit([]interface{}{2, 3}, []interface{}{5, 7, 11, 13}, []interface{}{2*5 + 3*11})
+	public := newMapCircuit(make([]interface{}, 2), make([]interface{}, 4), []interface{}{2*5 + 3*13})
+
+	addEntry("map", r1cs, good, bad, public)
+}
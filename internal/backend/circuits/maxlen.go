@@ -0,0 +1,43 @@
+package circuits
+
+import (
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gurvy"
+)
+
+type maxLenCircuit struct {
+	Msg []frontend.Variable `gnark:",maxlen=4"`
+	Y   frontend.Variable   `gnark:",public"`
+}
+
+func (circuit *maxLenCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	// y = (msg[0] + ... + msg[3]) * len(msg)
+	sum := cs.Add(circuit.Msg[0], circuit.Msg[1], circuit.Msg[2], circuit.Msg[3])
+	cs.AssertIsEqual(cs.Mul(sum, cs.Len(circuit.Msg)), circuit.Y)
+
+	return nil
+}
+
+func init() {
+	var circuit, good, bad, public maxLenCircuit
+	r1cs, err := frontend.Compile(gurvy.UNKNOWN, &circuit)
+	if err != nil {
+		panic(err)
+	}
+
+	// the missing elements of msg are 0
+	good.Msg = make([]frontend.Variable, 2)
+	good.Msg[0].Assign(3)
+	good.Msg[1].Assign(5)
+	good.Y.Assign((3 + 5) * 2)
+
+	bad.Msg = make([]frontend.Variable, 3)
+	bad.Msg[0].Assign(3)
+	bad.Msg[1].Assign(5)
+	bad.Msg[2].Assign(0)
+	bad.Y.Assign((3 + 5) * 2)
+
+	public.Y.Assign((3 + 5) * 2)
+
+	addEntry("maxlen", r1cs, &good, &bad, &public)
+}
@@ -0,0 +1,43 @@
+package circuits
+
+import (
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gurvy"
+)
+
+type mulAccCircuit struct {
+	X, Y [4]frontend.Variable
+	Z    frontend.Variable `gnark:",public"`
+}
+
+func (circuit *mulAccCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	// z = 1 + <x, y> + 2 * x[0]
+	acc := cs.Constant(1)
+	for i := 0; i < len(circuit.X); i++ {
+		acc = cs.MulAcc(acc, circuit.X[i], circuit.Y[i])
+	}
+	acc = cs.MulAcc(acc, 2, circuit.X[0])
+	cs.AssertIsEqual(acc, circuit.Z)
+	return nil
+}
+
+func init() {
+	var circuit, good, bad, public mulAccCircuit
+	r1cs, err := frontend.Compile(gurvy.UNKNOWN, &circuit)
+	if err != nil {
+		panic(err)
+	}
+
+	for i := 0; i < len(good.X); i++ {
+		good.X[i].Assign(i + 1)
+		good.Y[i].Assign(i + 2)
+		bad.X[i].Assign(i + 1)
+		bad.Y[i].Assign(i + 3)
+	}
+	// 1 + (1*2 + 2*3 + 3*4 + 4*5) + 2
+	good.Z.Assign(43)
+	bad.Z.Assign(43)
+	public.Z.Assign(43)
+
+	addEntry("mulacc", r1cs, &good, &bad, &public)
+}
@@ -0,0 +1,48 @@
+package circuits
+
+import (
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gurvy"
+)
+
+type toBinaryCircuit struct {
+	X          frontend.Variable
+	B0, B1, B2 frontend.Variable `gnark:",public"`
+}
+
+func (circuit *toBinaryCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	bits := cs.ToBinary(circuit.X, 3)
+
+	// little endian
+	cs.AssertIsEqual(bits[0], circuit.B0)
+	cs.AssertIsEqual(bits[1], circuit.B1)
+	cs.AssertIsEqual(bits[2], circuit.B2)
+	cs.AssertIsEqual(cs.FromBinary(bits...), circuit.X)
+	return nil
+}
+
+func init() {
+
+	var circuit, good, bad, public toBinaryCircuit
+	r1cs, err := frontend.Compile(gurvy.UNKNOWN, &circuit)
+	if err != nil {
+		panic(err)
+	}
+
+	good.X.Assign(6)
+	good.B0.Assign(0)
+	good.B1.Assign(1)
+	good.B2.Assign(1)
+
+	// 14 doesn't fit in 3 bits
+	bad.X.Assign(14)
+	bad.B0.Assign(0)
+	bad.B1.Assign(1)
+	bad.B2.Assign(1)
+
+	public.B0.Assign(0)
+	public.B1.Assign(1)
+	public.B2.Assign(1)
+
+	addEntry("tobinary", r1cs, &good, &bad, &public)
+}
@@ -0,0 +1,123 @@
+package ioutils
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// sections of a compressed R1CS (see WriteSections); the header section is always present and loaded
+const (
+	SectionHeader = iota
+	SectionConstraints
+	SectionCoefficients
+	SectionDebug // logs and debug info
+	NbSections
+)
+
+// bit masks of the sections to load
+const (
+	LoadConstraints  = 1 << SectionConstraints
+	LoadCoefficients = 1 << SectionCoefficients
+	LoadDebug        = 1 << SectionDebug
+)
+
+var sectionsMagic = [4]byte{'g', 'n', 'k', 'z'}
+
+// ErrInvalidSections is returned when reading data that wasn't written by WriteSections
+var ErrInvalidSections = errors.New("invalid compressed sections")
+
+// WriteSections compresses each section with zstd and writes them in w, preceded by a table of offsets,
+// such that SectionReader can decompress a single section without reading the others
+//
+// layout: magic | nbSections (uint32) | (offset, length) (uint64, uint64) for each section | compressed sections
+func WriteSections(w io.Writer, sections [][]byte) (int64, error) {
+	encoder, err := zstd.NewWriter(nil)
+	if err != nil {
+		return 0, err
+	}
+	defer encoder.Close()
+
+	compressed := make([][]byte, len(sections))
+	for i := 0; i < len(sections); i++ {
+		compressed[i] = encoder.EncodeAll(sections[i], nil)
+	}
+
+	headerSize := uint64(len(sectionsMagic) + 4 + 16*len(sections))
+	header := make([]byte, headerSize)
+	copy(header, sectionsMagic[:])
+	binary.LittleEndian.PutUint32(header[4:], uint32(len(sections)))
+	offset := headerSize
+	for i := 0; i < len(compressed); i++ {
+		binary.LittleEndian.PutUint64(header[8+16*i:], offset)
+		binary.LittleEndian.PutUint64(header[16+16*i:], uint64(len(compressed[i])))
+		offset += uint64(len(compressed[i]))
+	}
+
+	_w := WriterCounter{W: w}
+	if _, err := _w.Write(header); err != nil {
+		return _w.N, err
+	}
+	for i := 0; i < len(compressed); i++ {
+		if _, err := _w.Write(compressed[i]); err != nil {
+			return _w.N, err
+		}
+	}
+	return _w.N, nil
+}
+
+// SectionReader decompresses on demand the sections written by WriteSections
+type SectionReader struct {
+	r                io.ReaderAt
+	offsets, lengths []uint64
+}
+
+// NewSectionReader reads the table of sections from r
+func NewSectionReader(r io.ReaderAt) (*SectionReader, error) {
+	var buf [8]byte
+	if _, err := r.ReadAt(buf[:], 0); err != nil {
+		return nil, err
+	}
+	if [4]byte{buf[0], buf[1], buf[2], buf[3]} != sectionsMagic {
+		return nil, ErrInvalidSections
+	}
+	nbSections := int(binary.LittleEndian.Uint32(buf[4:]))
+	table := make([]byte, 16*nbSections)
+	if _, err := r.ReadAt(table, 8); err != nil {
+		return nil, err
+	}
+	sr := &SectionReader{
+		r:       r,
+		offsets: make([]uint64, nbSections),
+		lengths: make([]uint64, nbSections),
+	}
+	for i := 0; i < nbSections; i++ {
+		sr.offsets[i] = binary.LittleEndian.Uint64(table[16*i:])
+		sr.lengths[i] = binary.LittleEndian.Uint64(table[16*i+8:])
+	}
+	return sr, nil
+}
+
+// NbSections returns the number of sections
+func (sr *SectionReader) NbSections() int {
+	return len(sr.offsets)
+}
+
+// Section reads and decompresses the i-th section
+func (sr *SectionReader) Section(i int) ([]byte, error) {
+	if i >= len(sr.offsets) {
+		return nil, ErrInvalidSections
+	}
+	compressed := make([]byte, sr.lengths[i])
+	if _, err := sr.r.ReadAt(compressed, int64(sr.offsets[i])); err != nil {
+		return nil, err
+	}
+	decoder, err := zstd.NewReader(nil)
+	if err != nil {
+		return nil, err
+	}
+	defer decoder.Close()
+	return decoder.DecodeAll(compressed, nil)
+}
@@ -0,0 +1,152 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package bindings implements the gnark API exposed to other languages (wasm, C)
+//
+// Curves are named as gurvy.ID.String() (case insensitive). The R1CS, keys and proofs are serialized
+// with their WriteTo methods, and witnesses are JSON objects mapping input names to decimal or
+// hexadecimal strings (see gnarkio.ReadWitness).
+package bindings
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/r1cs"
+	gnarkio "github.com/consensys/gnark/io"
+	"github.com/consensys/gurvy"
+)
+
+// ParseCurve returns the curve ID named name
+func ParseCurve(name string) (gurvy.ID, error) {
+	for _, curveID := range []gurvy.ID{gurvy.BN256, gurvy.BLS377, gurvy.BLS381, gurvy.BW761} {
+		if strings.EqualFold(name, curveID.String()) {
+			return curveID, nil
+		}
+	}
+	return gurvy.UNKNOWN, fmt.Errorf("unknown curve %q", name)
+}
+
+// Setup runs groth16.Setup on the R1CS and returns the serialized proving and verifying keys
+func Setup(curve string, _r1cs []byte, opts ...backend.Option) (pk, vk []byte, err error) {
+	curveID, err := ParseCurve(curve)
+	if err != nil {
+		return nil, nil, err
+	}
+	r := r1cs.New(curveID)
+	if err := readFrom(r, _r1cs); err != nil {
+		return nil, nil, err
+	}
+	_pk, _vk, err := groth16.Setup(r, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	if pk, err = writeTo(_pk); err != nil {
+		return nil, nil, err
+	}
+	if vk, err = writeTo(_vk); err != nil {
+		return nil, nil, err
+	}
+	return pk, vk, nil
+}
+
+// IsSolved returns nil if the witness solves the R1CS
+func IsSolved(curve string, _r1cs []byte, witness string) error {
+	curveID, err := ParseCurve(curve)
+	if err != nil {
+		return err
+	}
+	r := r1cs.New(curveID)
+	if err := readFrom(r, _r1cs); err != nil {
+		return err
+	}
+	_witness, err := readWitness(witness)
+	if err != nil {
+		return err
+	}
+	return r.IsSolved(_witness)
+}
+
+// Prove runs groth16.ProveWithOptions and returns the serialized proof
+func Prove(curve string, _r1cs, pk []byte, witness string, opts ...backend.Option) ([]byte, error) {
+	curveID, err := ParseCurve(curve)
+	if err != nil {
+		return nil, err
+	}
+	r := r1cs.New(curveID)
+	if err := readFrom(r, _r1cs); err != nil {
+		return nil, err
+	}
+	_pk := groth16.NewProvingKey(curveID)
+	if err := readFrom(_pk, pk); err != nil {
+		return nil, err
+	}
+	_witness, err := readWitness(witness)
+	if err != nil {
+		return nil, err
+	}
+	proof, err := groth16.ProveWithOptions(r, _pk, _witness, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return writeTo(proof)
+}
+
+// Verify returns nil if the proof is valid for the public inputs of publicWitness
+func Verify(curve string, proof, vk []byte, publicWitness string) error {
+	curveID, err := ParseCurve(curve)
+	if err != nil {
+		return err
+	}
+	_proof := groth16.NewProof(curveID)
+	if err := readFrom(_proof, proof); err != nil {
+		return err
+	}
+	_vk := groth16.NewVerifyingKey(curveID)
+	if err := readFrom(_vk, vk); err != nil {
+		return err
+	}
+	_publicWitness, err := readWitness(publicWitness)
+	if err != nil {
+		return err
+	}
+	return groth16.Verify(_proof, _vk, _publicWitness)
+}
+
+func readFrom(v io.ReaderFrom, data []byte) error {
+	_, err := v.ReadFrom(bytes.NewReader(data))
+	return err
+}
+
+func writeTo(v io.WriterTo) ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := v.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func readWitness(witness string) (map[string]interface{}, error) {
+	res := make(map[string]interface{})
+	if err := gnarkio.ReadWitness(strings.NewReader(witness), res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
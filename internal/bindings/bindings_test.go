@@ -0,0 +1,82 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bindings
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gurvy"
+)
+
+type cubicCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+// x**3 + x + 5 == y
+func (circuit *cubicCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	x3 := cs.Mul(circuit.X, circuit.X, circuit.X)
+	cs.AssertIsEqual(circuit.Y, cs.Add(x3, circuit.X, 5))
+	return nil
+}
+
+func TestBindings(t *testing.T) {
+	for _, curveID := range []gurvy.ID{gurvy.BN256, gurvy.BLS377, gurvy.BLS381, gurvy.BW761} {
+		var circuit cubicCircuit
+		r1cs, err := frontend.Compile(curveID, &circuit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if _, err := r1cs.WriteTo(&buf); err != nil {
+			t.Fatal(err)
+		}
+		curve := curveID.String()
+
+		pk, vk, err := Setup(curve, buf.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := IsSolved(curve, buf.Bytes(), `{"X": "3", "Y": "35"}`); err != nil {
+			t.Fatal(err)
+		}
+		if err := IsSolved(curve, buf.Bytes(), `{"X": "4", "Y": "35"}`); !errors.Is(err, backend.ErrUnsatisfiedConstraint) {
+			t.Fatal("expected unsatisfied constraint, got", err)
+		}
+		proof, err := Prove(curve, buf.Bytes(), pk, `{"X": "3", "Y": "0x23"}`, backend.WithMaxWorkers(1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := Verify(curve, proof, vk, `{"Y": "35"}`); err != nil {
+			t.Fatal(err)
+		}
+		if err := Verify(curve, proof, vk, `{"Y": "36"}`); err == nil {
+			t.Fatal("verification should fail with a wrong public input")
+		}
+	}
+
+	if _, err := ParseCurve("secp256k1"); err == nil {
+		t.Fatal("expected error with an unknown curve")
+	}
+	if _, err := ParseCurve("BN256"); err != nil {
+		t.Fatal(err)
+	}
+}
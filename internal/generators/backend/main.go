@@ -76,7 +76,9 @@ func main() {
 				{File: filepath.Join(fftDir, "domain_test.go"), TemplateF: []string{"tests/domain.go.tmpl", importCurve}},
 				{File: filepath.Join(fftDir, "domain.go"), TemplateF: []string{"domain.go.tmpl", importCurve}},
 				{File: filepath.Join(fftDir, "fft_test.go"), TemplateF: []string{"tests/fft.go.tmpl", importCurve}},
+				{File: filepath.Join(fftDir, "outofcore_test.go"), TemplateF: []string{"tests/outofcore.go.tmpl", importCurve}},
 				{File: filepath.Join(fftDir, "fft.go"), TemplateF: []string{"fft.go.tmpl", importCurve}},
+				{File: filepath.Join(fftDir, "outofcore.go"), TemplateF: []string{"outofcore.go.tmpl", importCurve}},
 			}
 
 			if err := bgen.GenerateF(d, "fft", "./template/fft/", entries...); err != nil {
@@ -85,9 +87,16 @@ func main() {
 
 			entries = []bavard.EntryF{
 				{File: filepath.Join(groth16Dir, "verify.go"), TemplateF: []string{"groth16.verify.go.tmpl", importCurve}},
+				{File: filepath.Join(groth16Dir, "precompute.go"), TemplateF: []string{"groth16.precompute.go.tmpl", importCurve}},
 				{File: filepath.Join(groth16Dir, "prove.go"), TemplateF: []string{"groth16.prove.go.tmpl", importCurve}},
+				{File: filepath.Join(groth16Dir, "stream.go"), TemplateF: []string{"groth16.stream.go.tmpl", importCurve}},
+				{File: filepath.Join(groth16Dir, "distributed.go"), TemplateF: []string{"groth16.distributed.go.tmpl", importCurve}},
+				{File: filepath.Join(groth16Dir, "pool.go"), TemplateF: []string{"groth16.pool.go.tmpl", importCurve}},
 				{File: filepath.Join(groth16Dir, "setup.go"), TemplateF: []string{"groth16.setup.go.tmpl", importCurve}},
+				{File: filepath.Join(groth16Dir, "update.go"), TemplateF: []string{"groth16.update.go.tmpl", importCurve}},
 				{File: filepath.Join(groth16Dir, "marshal.go"), TemplateF: []string{"groth16.marshal.go.tmpl", importCurve}},
+				{File: filepath.Join(groth16Dir, "mmap.go"), TemplateF: []string{"groth16.mmap.go.tmpl", importCurve}},
+				{File: filepath.Join(groth16Dir, "precompute_test.go"), TemplateF: []string{"tests/groth16.precompute.go.tmpl", importCurve}},
 				{File: filepath.Join(groth16Dir, "marshal_test.go"), TemplateF: []string{"tests/groth16.marshal.go.tmpl", importCurve}},
 			}
 
@@ -0,0 +1,15 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package utils
+
+import "io/ioutil"
+
+// MapFile reads the file at path in memory, on the platforms without mmap (see mmap_unix.go)
+func MapFile(path string) (data []byte, mapped bool, unmap func() error, err error) {
+	data, err = ioutil.ReadFile(path)
+	if err != nil {
+		return nil, false, nil, err
+	}
+	return data, false, func() error { return nil }, nil
+}
@@ -0,0 +1,34 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package utils
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// MapFile maps the file at path in memory, copy-on-write: the pages are read lazily from the file, and
+// the writes to data aren't written back. mapped is false on the platforms without mmap, where data
+// is read in memory; unmap releases data.
+func MapFile(path string) (data []byte, mapped bool, unmap func() error, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, false, nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, false, nil, err
+	}
+	size := info.Size()
+	if size == 0 || int64(int(size)) != size {
+		return nil, false, nil, errors.New("can't map a file of this size")
+	}
+	data, err = syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_PRIVATE)
+	if err != nil {
+		return nil, false, nil, err
+	}
+	return data, true, func() error { return syscall.Munmap(data) }, nil
+}
@@ -0,0 +1,446 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package arkworks reads and writes gnark Groth16 proofs and verifying keys with the
+// CanonicalSerialize encoding of arkworks (ark-groth16, ark-serialize)
+//
+// The curves map to ark-bn254, ark-bls12-377, ark-bls12-381 and ark-bw6-761.
+// Field elements are encoded in little endian, in regular form, and a coordinate over a quadratic
+// extension as c0 then c1. A point is encoded as x (compressed) or x, y (uncompressed); the flags are
+// set in the two most significant bits of the last byte: 1<<7 if y is the lexicographically largest
+// of ±y, 1<<6 for the point at infinity.
+//
+// A proof is encoded as A, B, C. A verifying key is encoded as α1, β2, γ2, δ2 followed by the IC
+// points (gamma_abc_g1), prefixed by their number as a little endian uint64. IC[0] is the constant
+// wire backend.OneWire, the other points follow the public inputs of the gnark VerifyingKey.
+package arkworks
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/big"
+
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
+	groth16_bls377 "github.com/consensys/gnark/internal/backend/bls377/groth16"
+	groth16_bls381 "github.com/consensys/gnark/internal/backend/bls381/groth16"
+	groth16_bn256 "github.com/consensys/gnark/internal/backend/bn256/groth16"
+	groth16_bw761 "github.com/consensys/gnark/internal/backend/bw761/groth16"
+	"github.com/consensys/gnark/internal/backend/ioutils"
+	"github.com/consensys/gurvy"
+	"github.com/consensys/gurvy/bls377"
+	fp_bls377 "github.com/consensys/gurvy/bls377/fp"
+	"github.com/consensys/gurvy/bls381"
+	fp_bls381 "github.com/consensys/gurvy/bls381/fp"
+	"github.com/consensys/gurvy/bn256"
+	fp_bn256 "github.com/consensys/gurvy/bn256/fp"
+	"github.com/consensys/gurvy/bw761"
+	fp_bw761 "github.com/consensys/gurvy/bw761/fp"
+)
+
+// flags of the last byte of an encoded point
+const (
+	flagInfinity byte = 1 << 6
+	flagLargest  byte = 1 << 7
+	flagMask          = flagInfinity | flagLargest
+)
+
+var (
+	errCurve    = errors.New("arkworks: unsupported curve")
+	errNbPublic = errors.New("arkworks: number of public inputs doesn't match the verifying key")
+	errElement  = errors.New("arkworks: invalid field element")
+)
+
+// point is implemented by the G1 and G2 affine points of all curves
+type point interface {
+	Marshal() []byte
+	Unmarshal([]byte) error
+	IsInfinity() bool
+}
+
+// curveParams describes the gurvy encoding of the points of a curve
+//
+// gurvy encodes the coordinates in big endian, a coordinate over a quadratic extension as A1 then A0,
+// and sets its flags in the most significant bits of the first byte
+type curveParams struct {
+	modulus                        *big.Int
+	n                              int // size of an encoded base field element
+	mSmallest, mLargest, mInfinity byte
+}
+
+var curves = map[gurvy.ID]curveParams{
+	gurvy.BN256:  {fp_bn256.Modulus(), fp_bn256.Limbs * 8, 0b10 << 6, 0b11 << 6, 0b01 << 6},
+	gurvy.BLS377: {fp_bls377.Modulus(), fp_bls377.Limbs * 8, 0b100 << 5, 0b101 << 5, 0b110 << 5},
+	gurvy.BLS381: {fp_bls381.Modulus(), fp_bls381.Limbs * 8, 0b100 << 5, 0b101 << 5, 0b110 << 5},
+	gurvy.BW761:  {fp_bw761.Modulus(), fp_bw761.Limbs * 8, 0b100 << 5, 0b101 << 5, 0b110 << 5},
+}
+
+// WriteProof writes proof to w with the arkworks encoding
+func WriteProof(w io.Writer, proof groth16.Proof, compressed bool) (int64, error) {
+	curveID, points, err := proofPoints(proof)
+	if err != nil {
+		return 0, err
+	}
+	enc := newEncoder(w, curveID, compressed)
+	enc.encode(points...)
+	return enc.w.N, enc.err
+}
+
+// ReadProof reads a proof encoded by arkworks
+func ReadProof(r io.Reader, curveID gurvy.ID, compressed bool) (groth16.Proof, error) {
+	if _, ok := curves[curveID]; !ok {
+		return nil, errCurve
+	}
+	proof := groth16.NewProof(curveID)
+	_, points, err := proofPoints(proof)
+	if err != nil {
+		return nil, err
+	}
+	dec := newDecoder(r, curveID, compressed)
+	dec.decode(points...)
+	if dec.err != nil {
+		return nil, dec.err
+	}
+	return proof, nil
+}
+
+func proofPoints(proof groth16.Proof) (gurvy.ID, []point, error) {
+	switch p := proof.(type) {
+	case *groth16_bn256.Proof:
+		return gurvy.BN256, []point{&p.Ar, &p.Bs, &p.Krs}, nil
+	case *groth16_bls377.Proof:
+		return gurvy.BLS377, []point{&p.Ar, &p.Bs, &p.Krs}, nil
+	case *groth16_bls381.Proof:
+		return gurvy.BLS381, []point{&p.Ar, &p.Bs, &p.Krs}, nil
+	case *groth16_bw761.Proof:
+		return gurvy.BW761, []point{&p.Ar, &p.Bs, &p.Krs}, nil
+	default:
+		return gurvy.UNKNOWN, nil, errCurve
+	}
+}
+
+// WriteVerifyingKey writes vk to w with the arkworks encoding
+func WriteVerifyingKey(w io.Writer, vk groth16.VerifyingKey, compressed bool) (int64, error) {
+	if groth16.HasCommitment(vk) {
+		return 0, groth16.ErrCommitment
+	}
+	var enc *encoder
+	var ic []point
+	var publicInputs []string
+
+	// gnark stores -γ and -δ
+	switch _vk := vk.(type) {
+	case *groth16_bn256.VerifyingKey:
+		var gamma, delta bn256.G2Affine
+		gamma.Neg(&_vk.G2.GammaNeg)
+		delta.Neg(&_vk.G2.DeltaNeg)
+		enc = newEncoder(w, gurvy.BN256, compressed)
+		enc.encode(&_vk.G1.Alpha, &_vk.G2.Beta, &gamma, &delta)
+		for i := 0; i < len(_vk.G1.K); i++ {
+			ic = append(ic, &_vk.G1.K[i])
+		}
+		publicInputs = _vk.PublicInputs
+	case *groth16_bls377.VerifyingKey:
+		var gamma, delta bls377.G2Affine
+		gamma.Neg(&_vk.G2.GammaNeg)
+		delta.Neg(&_vk.G2.DeltaNeg)
+		enc = newEncoder(w, gurvy.BLS377, compressed)
+		enc.encode(&_vk.G1.Alpha, &_vk.G2.Beta, &gamma, &delta)
+		for i := 0; i < len(_vk.G1.K); i++ {
+			ic = append(ic, &_vk.G1.K[i])
+		}
+		publicInputs = _vk.PublicInputs
+	case *groth16_bls381.VerifyingKey:
+		var gamma, delta bls381.G2Affine
+		gamma.Neg(&_vk.G2.GammaNeg)
+		delta.Neg(&_vk.G2.DeltaNeg)
+		enc = newEncoder(w, gurvy.BLS381, compressed)
+		enc.encode(&_vk.G1.Alpha, &_vk.G2.Beta, &gamma, &delta)
+		for i := 0; i < len(_vk.G1.K); i++ {
+			ic = append(ic, &_vk.G1.K[i])
+		}
+		publicInputs = _vk.PublicInputs
+	case *groth16_bw761.VerifyingKey:
+		var gamma, delta bw761.G2Affine
+		gamma.Neg(&_vk.G2.GammaNeg)
+		delta.Neg(&_vk.G2.DeltaNeg)
+		enc = newEncoder(w, gurvy.BW761, compressed)
+		enc.encode(&_vk.G1.Alpha, &_vk.G2.Beta, &gamma, &delta)
+		for i := 0; i < len(_vk.G1.K); i++ {
+			ic = append(ic, &_vk.G1.K[i])
+		}
+		publicInputs = _vk.PublicInputs
+	default:
+		return 0, errCurve
+	}
+
+	// IC[0] is the constant wire
+	oneWire := -1
+	for i, name := range publicInputs {
+		if name == backend.OneWire {
+			oneWire = i
+			break
+		}
+	}
+	if oneWire == -1 || len(ic) != len(publicInputs) {
+		return enc.w.N, errNbPublic
+	}
+	enc.writeLen(len(ic))
+	enc.encode(ic[oneWire])
+	for i := 0; i < len(ic); i++ {
+		if i != oneWire {
+			enc.encode(ic[i])
+		}
+	}
+
+	return enc.w.N, enc.err
+}
+
+// ReadVerifyingKey reads a verifying key encoded by arkworks
+//
+// publicInputs are the names of the public inputs, in the order of the IC points (without IC[0])
+func ReadVerifyingKey(r io.Reader, curveID gurvy.ID, publicInputs []string, compressed bool) (groth16.VerifyingKey, error) {
+	if _, ok := curves[curveID]; !ok {
+		return nil, errCurve
+	}
+	dec := newDecoder(r, curveID, compressed)
+
+	// readIC reads the number of IC points, that must match the public inputs, before they are allocated
+	readIC := func() int {
+		if n := dec.readLen(); dec.err == nil && n != uint64(len(publicInputs)+1) {
+			dec.err = errNbPublic
+		}
+		if dec.err != nil {
+			return 0
+		}
+		return len(publicInputs) + 1
+	}
+
+	var res groth16.VerifyingKey
+	var err error
+	switch curveID {
+	case gurvy.BN256:
+		var vk groth16_bn256.VerifyingKey
+		dec.decode(&vk.G1.Alpha, &vk.G2.Beta, &vk.G2.GammaNeg, &vk.G2.DeltaNeg)
+		vk.G1.K = make([]bn256.G1Affine, readIC())
+		for i := 0; i < len(vk.G1.K); i++ {
+			dec.decode(&vk.G1.K[i])
+		}
+		if dec.err != nil {
+			return nil, dec.err
+		}
+		vk.G2.GammaNeg.Neg(&vk.G2.GammaNeg)
+		vk.G2.DeltaNeg.Neg(&vk.G2.DeltaNeg)
+		vk.E, err = bn256.Pair([]bn256.G1Affine{vk.G1.Alpha}, []bn256.G2Affine{vk.G2.Beta})
+		vk.PublicInputs = append([]string{backend.OneWire}, publicInputs...)
+		res = &vk
+	case gurvy.BLS377:
+		var vk groth16_bls377.VerifyingKey
+		dec.decode(&vk.G1.Alpha, &vk.G2.Beta, &vk.G2.GammaNeg, &vk.G2.DeltaNeg)
+		vk.G1.K = make([]bls377.G1Affine, readIC())
+		for i := 0; i < len(vk.G1.K); i++ {
+			dec.decode(&vk.G1.K[i])
+		}
+		if dec.err != nil {
+			return nil, dec.err
+		}
+		vk.G2.GammaNeg.Neg(&vk.G2.GammaNeg)
+		vk.G2.DeltaNeg.Neg(&vk.G2.DeltaNeg)
+		vk.E, err = bls377.Pair([]bls377.G1Affine{vk.G1.Alpha}, []bls377.G2Affine{vk.G2.Beta})
+		vk.PublicInputs = append([]string{backend.OneWire}, publicInputs...)
+		res = &vk
+	case gurvy.BLS381:
+		var vk groth16_bls381.VerifyingKey
+		dec.decode(&vk.G1.Alpha, &vk.G2.Beta, &vk.G2.GammaNeg, &vk.G2.DeltaNeg)
+		vk.G1.K = make([]bls381.G1Affine, readIC())
+		for i := 0; i < len(vk.G1.K); i++ {
+			dec.decode(&vk.G1.K[i])
+		}
+		if dec.err != nil {
+			return nil, dec.err
+		}
+		vk.G2.GammaNeg.Neg(&vk.G2.GammaNeg)
+		vk.G2.DeltaNeg.Neg(&vk.G2.DeltaNeg)
+		vk.E, err = bls381.Pair([]bls381.G1Affine{vk.G1.Alpha}, []bls381.G2Affine{vk.G2.Beta})
+		vk.PublicInputs = append([]string{backend.OneWire}, publicInputs...)
+		res = &vk
+	case gurvy.BW761:
+		var vk groth16_bw761.VerifyingKey
+		dec.decode(&vk.G1.Alpha, &vk.G2.Beta, &vk.G2.GammaNeg, &vk.G2.DeltaNeg)
+		vk.G1.K = make([]bw761.G1Affine, readIC())
+		for i := 0; i < len(vk.G1.K); i++ {
+			dec.decode(&vk.G1.K[i])
+		}
+		if dec.err != nil {
+			return nil, dec.err
+		}
+		vk.G2.GammaNeg.Neg(&vk.G2.GammaNeg)
+		vk.G2.DeltaNeg.Neg(&vk.G2.DeltaNeg)
+		vk.E, err = bw761.Pair([]bw761.G1Affine{vk.G1.Alpha}, []bw761.G2Affine{vk.G2.Beta})
+		vk.PublicInputs = append([]string{backend.OneWire}, publicInputs...)
+		res = &vk
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// encoder writes points with the arkworks encoding; the first error is kept and the following calls are no-ops
+type encoder struct {
+	w          *ioutils.WriterCounter
+	c          curveParams
+	compressed bool
+	err        error
+}
+
+func newEncoder(w io.Writer, curveID gurvy.ID, compressed bool) *encoder {
+	return &encoder{w: &ioutils.WriterCounter{W: w}, c: curves[curveID], compressed: compressed}
+}
+
+func (enc *encoder) encode(points ...point) {
+	for _, p := range points {
+		if enc.err != nil {
+			return
+		}
+		_, enc.err = enc.w.Write(enc.c.encode(p, enc.compressed))
+	}
+}
+
+func (enc *encoder) writeLen(n int) {
+	if enc.err == nil {
+		enc.err = binary.Write(enc.w, binary.LittleEndian, uint64(n))
+	}
+}
+
+// decoder reads points with the arkworks encoding; the first error is kept and the following calls are no-ops
+type decoder struct {
+	r          io.Reader
+	c          curveParams
+	compressed bool
+	err        error
+}
+
+func newDecoder(r io.Reader, curveID gurvy.ID, compressed bool) *decoder {
+	return &decoder{r: r, c: curves[curveID], compressed: compressed}
+}
+
+func (dec *decoder) decode(points ...point) {
+	for _, p := range points {
+		if dec.err != nil {
+			return
+		}
+		buf := make([]byte, dec.c.size(p, dec.compressed))
+		if _, dec.err = io.ReadFull(dec.r, buf); dec.err == nil {
+			dec.err = dec.c.decode(buf, p, dec.compressed)
+		}
+	}
+}
+
+func (dec *decoder) readLen() (n uint64) {
+	if dec.err == nil {
+		dec.err = binary.Read(dec.r, binary.LittleEndian, &n)
+	}
+	return
+}
+
+// degree returns the number of base field elements of a coordinate of p
+func (c curveParams) degree(p point) int {
+	return len(p.Marshal()) / (2 * c.n)
+}
+
+// size returns the size of the arkworks encoding of p
+func (c curveParams) size(p point, compressed bool) int {
+	if compressed {
+		return c.degree(p) * c.n
+	}
+	return 2 * c.degree(p) * c.n
+}
+
+// encode returns the arkworks encoding of p
+func (c curveParams) encode(p point, compressed bool) []byte {
+	d := c.degree(p)
+	res := make([]byte, c.size(p, compressed))
+	if p.IsInfinity() {
+		res[len(res)-1] = flagInfinity
+		return res
+	}
+
+	raw := p.Marshal()
+	for i := 0; i < len(res)/c.n; i++ {
+		// the k-th element of a gurvy coordinate is the (d-1-k)-th of the arkworks one
+		j := (i/d)*d + d - 1 - i%d
+		reverse(res[j*c.n:(j+1)*c.n], raw[i*c.n:(i+1)*c.n])
+	}
+	if c.largest(raw[d*c.n : 2*d*c.n]) {
+		res[len(res)-1] |= flagLargest
+	}
+	return res
+}
+
+// decode sets p from its arkworks encoding; gurvy checks the point is on the curve and in the subgroup
+func (c curveParams) decode(buf []byte, p point, compressed bool) error {
+	d := c.degree(p)
+	flags := buf[len(buf)-1] & flagMask
+	if flags&flagInfinity != 0 {
+		raw := make([]byte, d*c.n)
+		raw[0] = c.mInfinity
+		return p.Unmarshal(raw)
+	}
+
+	buf[len(buf)-1] &^= flagMask
+	raw := make([]byte, len(buf))
+	for i := 0; i < len(buf)/c.n; i++ {
+		j := (i/d)*d + d - 1 - i%d
+		reverse(raw[j*c.n:(j+1)*c.n], buf[i*c.n:(i+1)*c.n])
+	}
+	for i := 0; i < len(raw)/c.n; i++ {
+		if new(big.Int).SetBytes(raw[i*c.n:(i+1)*c.n]).Cmp(c.modulus) >= 0 {
+			return errElement
+		}
+	}
+
+	if compressed {
+		if flags&flagLargest != 0 {
+			raw[0] |= c.mLargest
+		} else {
+			raw[0] |= c.mSmallest
+		}
+	}
+	return p.Unmarshal(raw)
+}
+
+// largest returns true if the coordinate y, encoded by gurvy, is lexicographically larger than -y:
+// the most significant non zero element of y is larger than (q-1)/2
+func (c curveParams) largest(y []byte) bool {
+	var halfQ, e big.Int
+	halfQ.Rsh(c.modulus, 1)
+	for i := 0; i < len(y)/c.n; i++ {
+		e.SetBytes(y[i*c.n : (i+1)*c.n])
+		if e.Sign() != 0 {
+			return e.Cmp(&halfQ) > 0
+		}
+	}
+	return false
+}
+
+// reverse copies src in dst, in reverse order (big endian <-> little endian)
+func reverse(dst, src []byte) {
+	for i := 0; i < len(src); i++ {
+		dst[len(src)-1-i] = src[i]
+	}
+}
@@ -0,0 +1,157 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package arkworks
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gurvy"
+	"github.com/consensys/gurvy/bls381"
+	"github.com/consensys/gurvy/bn256"
+)
+
+type cubicCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+	Z frontend.Variable `gnark:",public"`
+}
+
+// x**3 + x + 5 == y, z == 2*x
+func (circuit *cubicCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	x3 := cs.Mul(circuit.X, circuit.X, circuit.X)
+	cs.AssertIsEqual(circuit.Y, cs.Add(x3, circuit.X, 5))
+	cs.AssertIsEqual(circuit.Z, cs.Mul(circuit.X, 2))
+	return nil
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, curveID := range []gurvy.ID{gurvy.BN256, gurvy.BLS377, gurvy.BLS381, gurvy.BW761} {
+		for _, compressed := range []bool{true, false} {
+			var circuit cubicCircuit
+			r1cs, err := frontend.Compile(curveID, &circuit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pk, vk, err := groth16.Setup(r1cs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var witness cubicCircuit
+			witness.X.Assign(3)
+			witness.Y.Assign(35)
+			witness.Z.Assign(6)
+			proof, err := groth16.Prove(r1cs, pk, &witness)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var bProof, bVK bytes.Buffer
+			if _, err := WriteProof(&bProof, proof, compressed); err != nil {
+				t.Fatal(err)
+			}
+			n, err := WriteVerifyingKey(&bVK, vk, compressed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != int64(bVK.Len()) {
+				t.Fatal("wrong number of bytes written")
+			}
+
+			proof2, err := ReadProof(&bProof, curveID, compressed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			vk2, err := ReadVerifyingKey(bytes.NewReader(bVK.Bytes()), curveID, []string{"Y", "Z"}, compressed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := groth16.Verify(proof2, vk2, &witness); err != nil {
+				t.Fatal(curveID, compressed, err)
+			}
+
+			if _, err := ReadVerifyingKey(bytes.NewReader(bVK.Bytes()), curveID, []string{"Y"}, compressed); err != errNbPublic {
+				t.Fatal("expected errNbPublic, got", err)
+			}
+		}
+	}
+}
+
+func TestPointEncoding(t *testing.T) {
+	c := curves[gurvy.BN256]
+
+	// (1, 2) is the generator of ark-bn254, y = 2 is the smallest of ±y
+	var g bn256.G1Affine
+	g.X.SetOne()
+	g.Y.SetUint64(2)
+	one, two := make([]byte, 32), make([]byte, 32)
+	one[0], two[0] = 1, 2
+	if !bytes.Equal(c.encode(&g, true), one) {
+		t.Fatal("wrong compressed encoding", c.encode(&g, true))
+	}
+	if !bytes.Equal(c.encode(&g, false), append(one, two...)) {
+		t.Fatal("wrong uncompressed encoding")
+	}
+	var minusG bn256.G1Affine
+	minusG.Neg(&g)
+	buf := c.encode(&minusG, true)
+	if buf[31] != flagLargest {
+		t.Fatal("expected the largest y flag")
+	}
+	var p bn256.G1Affine
+	if err := c.decode(buf, &p, true); err != nil || !p.Equal(&minusG) {
+		t.Fatal("couldn't decode compressed point", err)
+	}
+
+	// infinity
+	var inf bn256.G2Affine
+	buf = c.encode(&inf, true)
+	if len(buf) != 64 || buf[63] != flagInfinity {
+		t.Fatal("wrong encoding of infinity")
+	}
+	q := bn256.G2Affine{X: inf.X, Y: inf.Y}
+	q.Y.SetOne()
+	if err := c.decode(buf, &q, true); err != nil || !q.IsInfinity() {
+		t.Fatal("couldn't decode infinity", err)
+	}
+
+	// a coordinate larger than the modulus
+	buf = c.encode(&g, false)
+	copy(buf[:32], bytes.Repeat([]byte{0xff}, 31))
+	buf[31] = 0x3f
+	if err := c.decode(buf, &p, false); err != errElement {
+		t.Fatal("expected errElement, got", err)
+	}
+
+	// the generator of BLS12-381 in the zcash encoding (big endian, flags in the first byte)
+	// is the arkworks encoding in reverse order, with other flags
+	zcash, _ := hex.DecodeString("97f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb")
+	var g1 bls381.G1Affine
+	if _, err := g1.SetBytes(append([]byte{}, zcash...)); err != nil {
+		t.Fatal(err)
+	}
+	buf = curves[gurvy.BLS381].encode(&g1, true)
+	zcash[0] &^= 0b111 << 5
+	for i := range buf {
+		if buf[i] != zcash[len(zcash)-1-i] {
+			t.Fatal("wrong encoding of the bls12-381 generator")
+		}
+	}
+}
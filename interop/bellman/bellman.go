@@ -0,0 +1,173 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package bellman reads and writes gnark Groth16 proofs and verifying keys with the encoding of
+// bellman (bellman::groth16::Proof and VerifyingKey, on BLS12-381)
+//
+// bellman encodes points as specified by zcash, which is the encoding of gurvy/bls381: coordinates in
+// big endian, x.c1 before x.c0, and flags in the 3 most significant bits of the first byte.
+// A proof is encoded as A, B, C compressed. A verifying key is encoded as α1, β1, β2, γ2, δ1, δ2
+// followed by the IC points, prefixed by their number as a big endian uint32, all uncompressed.
+// IC[0] is the constant wire backend.OneWire, the other points follow the public inputs of the gnark
+// VerifyingKey.
+package bellman
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
+	groth16_bls381 "github.com/consensys/gnark/internal/backend/bls381/groth16"
+	"github.com/consensys/gurvy/bls381"
+)
+
+var (
+	errCurve    = errors.New("bellman: only bls381 is supported")
+	errNbPublic = errors.New("bellman: number of public inputs doesn't match the verifying key")
+)
+
+// WriteProof writes proof to w with the bellman encoding
+func WriteProof(w io.Writer, proof groth16.Proof) (int64, error) {
+	_proof, ok := proof.(*groth16_bls381.Proof)
+	if !ok {
+		return 0, errCurve
+	}
+	enc := bls381.NewEncoder(w)
+	for _, p := range []interface{}{&_proof.Ar, &_proof.Bs, &_proof.Krs} {
+		if err := enc.Encode(p); err != nil {
+			return enc.BytesWritten(), err
+		}
+	}
+	return enc.BytesWritten(), nil
+}
+
+// ReadProof reads a proof encoded by bellman
+func ReadProof(r io.Reader) (groth16.Proof, error) {
+	var proof groth16_bls381.Proof
+	dec := bls381.NewDecoder(r)
+	for _, p := range []interface{}{&proof.Ar, &proof.Bs, &proof.Krs} {
+		if err := dec.Decode(p); err != nil {
+			return nil, err
+		}
+	}
+	return &proof, nil
+}
+
+// WriteVerifyingKey writes vk to w with the bellman encoding
+//
+// the bellman verifying key also contains [β]1 and [δ]1, that are read from the proving key
+func WriteVerifyingKey(w io.Writer, pk groth16.ProvingKey, vk groth16.VerifyingKey) (int64, error) {
+	if groth16.HasCommitment(vk) {
+		return 0, groth16.ErrCommitment
+	}
+	_pk, ok := pk.(*groth16_bls381.ProvingKey)
+	if !ok {
+		return 0, errCurve
+	}
+	_vk, ok := vk.(*groth16_bls381.VerifyingKey)
+	if !ok {
+		return 0, errCurve
+	}
+
+	// IC[0] is the constant wire
+	oneWire := -1
+	for i, name := range _vk.PublicInputs {
+		if name == backend.OneWire {
+			oneWire = i
+			break
+		}
+	}
+	if oneWire == -1 || len(_vk.G1.K) != len(_vk.PublicInputs) {
+		return 0, errNbPublic
+	}
+	ic := make([]bls381.G1Affine, 0, len(_vk.G1.K))
+	ic = append(ic, _vk.G1.K[oneWire])
+	for i := 0; i < len(_vk.G1.K); i++ {
+		if i != oneWire {
+			ic = append(ic, _vk.G1.K[i])
+		}
+	}
+
+	// gnark stores -γ and -δ
+	var gamma, delta bls381.G2Affine
+	gamma.Neg(&_vk.G2.GammaNeg)
+	delta.Neg(&_vk.G2.DeltaNeg)
+
+	enc := bls381.NewEncoder(w, bls381.RawEncoding())
+	toEncode := []interface{}{
+		&_vk.G1.Alpha,
+		&_pk.G1.Beta,
+		&_vk.G2.Beta,
+		&gamma,
+		&_pk.G1.Delta,
+		&delta,
+		ic,
+	}
+	for _, v := range toEncode {
+		if err := enc.Encode(v); err != nil {
+			return enc.BytesWritten(), err
+		}
+	}
+	return enc.BytesWritten(), nil
+}
+
+// ReadVerifyingKey reads a verifying key encoded by bellman
+//
+// publicInputs are the names of the public inputs, in the order of the IC points (without IC[0])
+func ReadVerifyingKey(r io.Reader, publicInputs []string) (groth16.VerifyingKey, error) {
+	var vk groth16_bls381.VerifyingKey
+	var beta, delta bls381.G1Affine // not in the gnark verifying key
+	dec := bls381.NewDecoder(r)
+	toDecode := []interface{}{
+		&vk.G1.Alpha,
+		&beta,
+		&vk.G2.Beta,
+		&vk.G2.GammaNeg,
+		&delta,
+		&vk.G2.DeltaNeg,
+	}
+	for _, v := range toDecode {
+		if err := dec.Decode(v); err != nil {
+			return nil, err
+		}
+	}
+
+	// the number of IC points must match the public inputs before they are allocated
+	var nbIC uint32
+	if err := binary.Read(r, binary.BigEndian, &nbIC); err != nil {
+		return nil, err
+	}
+	if int(nbIC) != len(publicInputs)+1 {
+		return nil, errNbPublic
+	}
+	vk.G1.K = make([]bls381.G1Affine, nbIC)
+	for i := 0; i < len(vk.G1.K); i++ {
+		if err := dec.Decode(&vk.G1.K[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	vk.G2.GammaNeg.Neg(&vk.G2.GammaNeg)
+	vk.G2.DeltaNeg.Neg(&vk.G2.DeltaNeg)
+	vk.PublicInputs = append([]string{backend.OneWire}, publicInputs...)
+	var err error
+	if vk.E, err = bls381.Pair([]bls381.G1Affine{vk.G1.Alpha}, []bls381.G2Affine{vk.G2.Beta}); err != nil {
+		return nil, err
+	}
+	return &vk, nil
+}
@@ -0,0 +1,87 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bellman
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gurvy"
+)
+
+type cubicCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+	Z frontend.Variable `gnark:",public"`
+}
+
+// x**3 + x + 5 == y, z == 2*x
+func (circuit *cubicCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	x3 := cs.Mul(circuit.X, circuit.X, circuit.X)
+	cs.AssertIsEqual(circuit.Y, cs.Add(x3, circuit.X, 5))
+	cs.AssertIsEqual(circuit.Z, cs.Mul(circuit.X, 2))
+	return nil
+}
+
+func TestRoundTrip(t *testing.T) {
+	var circuit cubicCircuit
+	r1cs, err := frontend.Compile(gurvy.BLS381, &circuit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, vk, err := groth16.Setup(r1cs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var witness cubicCircuit
+	witness.X.Assign(3)
+	witness.Y.Assign(35)
+	witness.Z.Assign(6)
+	proof, err := groth16.Prove(r1cs, pk, &witness)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var bProof, bVK bytes.Buffer
+	if n, err := WriteProof(&bProof, proof); err != nil || n != 48+96+48 || bProof.Len() != int(n) {
+		t.Fatal("unexpected proof encoding", n, err)
+	}
+	if n, err := WriteVerifyingKey(&bVK, pk, vk); err != nil || n != 3*96+3*192+4+3*96 || bVK.Len() != int(n) {
+		t.Fatal("unexpected verifying key encoding", n, err)
+	}
+
+	proof2, err := ReadProof(&bProof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vk2, err := ReadVerifyingKey(bytes.NewReader(bVK.Bytes()), []string{"Y", "Z"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := groth16.Verify(proof2, vk2, &witness); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ReadVerifyingKey(bytes.NewReader(bVK.Bytes()), []string{"Y"}); err != errNbPublic {
+		t.Fatal("expected errNbPublic, got", err)
+	}
+	if _, err := ReadProof(bytes.NewReader(bVK.Bytes()[:10])); err == nil {
+		t.Fatal("expected error reading a truncated proof")
+	}
+}
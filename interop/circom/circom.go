@@ -0,0 +1,289 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package circom reads the constraint systems (.r1cs) and witnesses (.wtns) generated by circom
+//
+// A circom circuit can then be proven with the gnark groth16 backend, and the proofs verified by a
+// circom-derived verifier once the keys are exported with interop/snarkjs.
+//
+// circom only targets BN256 (bn128). The witness computed by circom (by its wasm or C++ witness
+// generator) contains the values of all the wires, so every circom wire is an input of the gnark R1CS:
+// wire 0 is backend.OneWire, the public signals (outputs then inputs) are public inputs, and all the
+// other wires are secret inputs. The constraints are all assertions. Wire i is named WireName(i).
+//
+// The keys can be generated with groth16.Setup, or read from the .zkey of a snarkjs trusted setup
+// with ReadZKey.
+package circom
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/r1cs/r1c"
+	backend_bn256 "github.com/consensys/gnark/internal/backend/bn256"
+	"github.com/consensys/gurvy/bn256/fr"
+)
+
+// section types of the circom binary formats
+const (
+	r1csHeader      = 1
+	r1csConstraints = 2
+
+	wtnsHeader = 1
+	wtnsValues = 2
+)
+
+var (
+	errPrime    = errors.New("circom: unsupported field, only bn128 is supported")
+	errTruncate = errors.New("circom: unexpected end of section")
+)
+
+// WireName returns the name of the i-th circom wire in the gnark R1CS and in the assignment returned by ReadWitness
+func WireName(i int) string {
+	return fmt.Sprintf("w%d", i)
+}
+
+// ReadR1CS reads a circom .r1cs file and returns the corresponding gnark R1CS
+func ReadR1CS(r io.Reader) (*backend_bn256.R1CS, error) {
+	sections, err := readSections(r, "r1cs")
+	if err != nil {
+		return nil, err
+	}
+
+	// header
+	h, ok := sections[r1csHeader]
+	if !ok {
+		return nil, errors.New("circom: missing r1cs header")
+	}
+	if err := readPrime(h, fr.Modulus()); err != nil {
+		return nil, err
+	}
+	var header struct {
+		NbWires, NbPubOut, NbPubIn, NbPrvIn uint32
+		NbLabels                            uint64
+		NbConstraints                       uint32
+	}
+	if err := binary.Read(h, binary.LittleEndian, &header); err != nil {
+		return nil, errTruncate
+	}
+	nbWires := int(header.NbWires)
+	nbPublic := int(header.NbPubOut+header.NbPubIn) + 1 // includes the constant wire
+	if nbWires < nbPublic {
+		return nil, errors.New("circom: invalid r1cs header")
+	}
+
+	// snarkjs appends a constraint s * 0 = 0 for each public wire s, see ReadZKey
+	nbConstraints := int(header.NbConstraints) + nbPublic
+
+	res := &backend_bn256.R1CS{
+		NbWires:       uint64(nbWires),
+		NbPublicWires: uint64(nbPublic),
+		NbSecretWires: uint64(nbWires - nbPublic),
+		NbConstraints: uint64(nbConstraints),
+		Constraints:   make([]r1c.R1C, nbConstraints),
+		DebugInfo:     make([]backend.LogEntry, nbConstraints),
+	}
+	res.PublicWires = append(res.PublicWires, backend.OneWire)
+	for i := 1; i < nbPublic; i++ {
+		res.PublicWires = append(res.PublicWires, WireName(i))
+	}
+	for i := nbPublic; i < nbWires; i++ {
+		res.SecretWires = append(res.SecretWires, WireName(i))
+	}
+
+	// constraints, a linear expression is encoded as the number of terms followed by (wire, coefficient) pairs
+	c, ok := sections[r1csConstraints]
+	if !ok {
+		return nil, errors.New("circom: missing r1cs constraints")
+	}
+	coeffIDs := make(map[fr.Element]int)
+	coeffID := func(coeff fr.Element) int {
+		id, ok := coeffIDs[coeff]
+		if !ok {
+			id = len(res.Coefficients)
+			coeffIDs[coeff] = id
+			res.Coefficients = append(res.Coefficients, coeff)
+		}
+		return id
+	}
+	readLinExp := func() (r1c.LinearExpression, error) {
+		var nbTerms uint32
+		if err := binary.Read(c, binary.LittleEndian, &nbTerms); err != nil {
+			return nil, errTruncate
+		}
+		l := make(r1c.LinearExpression, 0, nbTerms)
+		for i := 0; i < int(nbTerms); i++ {
+			var wire uint32
+			if err := binary.Read(c, binary.LittleEndian, &wire); err != nil {
+				return nil, errTruncate
+			}
+			if int(wire) >= nbWires {
+				return nil, fmt.Errorf("circom: wire %d out of range", wire)
+			}
+			coeff, err := readElement(c)
+			if err != nil {
+				return nil, err
+			}
+			id, visibility := wireID(int(wire), nbWires, nbPublic)
+			l = append(l, r1c.Pack(id, coeffID(coeff), visibility))
+		}
+		return l, nil
+	}
+	for i := 0; i < int(header.NbConstraints); i++ {
+		for _, l := range []*r1c.LinearExpression{&res.Constraints[i].L, &res.Constraints[i].R, &res.Constraints[i].O} {
+			if *l, err = readLinExp(); err != nil {
+				return nil, err
+			}
+		}
+		res.DebugInfo[i] = backend.LogEntry{Format: fmt.Sprintf("circom constraint #%d", i)}
+	}
+	one := coeffID(fr.One())
+	for s := 0; s < nbPublic; s++ {
+		i := int(header.NbConstraints) + s
+		id, visibility := wireID(s, nbWires, nbPublic)
+		res.Constraints[i].L = r1c.LinearExpression{r1c.Pack(id, one, visibility)}
+		res.DebugInfo[i] = backend.LogEntry{Format: fmt.Sprintf("snarkjs input constraint #%d", s)}
+	}
+
+	return res, nil
+}
+
+// wireID returns the gnark wire of the i-th circom wire: gnark wires are ordered [secret inputs | public inputs]
+func wireID(i, nbWires, nbPublic int) (int, backend.Visibility) {
+	if i < nbPublic {
+		return nbWires - nbPublic + i, backend.Public
+	}
+	return i - nbPublic, backend.Secret
+}
+
+// ReadWitness reads a circom .wtns file and returns the corresponding assignment of the R1CS returned by ReadR1CS
+func ReadWitness(r io.Reader) (map[string]interface{}, error) {
+	sections, err := readSections(r, "wtns")
+	if err != nil {
+		return nil, err
+	}
+
+	h, ok := sections[wtnsHeader]
+	if !ok {
+		return nil, errors.New("circom: missing wtns header")
+	}
+	if err := readPrime(h, fr.Modulus()); err != nil {
+		return nil, err
+	}
+	var nbWitness uint32
+	if err := binary.Read(h, binary.LittleEndian, &nbWitness); err != nil {
+		return nil, errTruncate
+	}
+
+	v, ok := sections[wtnsValues]
+	if !ok {
+		return nil, errors.New("circom: missing wtns values")
+	}
+	res := make(map[string]interface{}, nbWitness)
+	for i := 0; i < int(nbWitness); i++ {
+		e, err := readElement(v)
+		if err != nil {
+			return nil, err
+		}
+		// wire 0 is the constant wire, set by the solver
+		if i != 0 {
+			res[WireName(i)] = e
+		}
+	}
+	return res, nil
+}
+
+// readSections reads the common layout of the circom binary files: a 4 bytes magic, a version,
+// and a list of (type, size, content) sections, in any order
+func readSections(r io.Reader, magic string) (map[uint32]*bytes.Reader, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 12 || string(data[:4]) != magic {
+		return nil, fmt.Errorf("circom: not a .%s file", magic)
+	}
+	nbSections := binary.LittleEndian.Uint32(data[8:12])
+	data = data[12:]
+
+	res := make(map[uint32]*bytes.Reader, nbSections)
+	for i := 0; i < int(nbSections); i++ {
+		if len(data) < 12 {
+			return nil, errTruncate
+		}
+		sectionType := binary.LittleEndian.Uint32(data[:4])
+		size := binary.LittleEndian.Uint64(data[4:12])
+		data = data[12:]
+		if uint64(len(data)) < size {
+			return nil, errTruncate
+		}
+		res[sectionType] = bytes.NewReader(data[:size])
+		data = data[size:]
+	}
+	return res, nil
+}
+
+// readPrime reads the field size and prime of a header, and checks it is prime, a field of bn256
+//
+// the base and scalar fields of bn256 have the same size
+func readPrime(r io.Reader, prime *big.Int) error {
+	var n8 uint32
+	if err := binary.Read(r, binary.LittleEndian, &n8); err != nil {
+		return errTruncate
+	}
+	if n8 != fr.Bytes {
+		return errPrime
+	}
+	q, err := readBigInt(r)
+	if err != nil {
+		return err
+	}
+	if q.Cmp(prime) != 0 {
+		return errPrime
+	}
+	return nil
+}
+
+// readElement reads a field element, encoded in little endian in regular (non Montgomery) form
+func readElement(r io.Reader) (fr.Element, error) {
+	var res fr.Element
+	v, err := readBigInt(r)
+	if err != nil {
+		return res, err
+	}
+	if v.Cmp(fr.Modulus()) >= 0 {
+		return res, errors.New("circom: invalid field element")
+	}
+	res.SetBigInt(v)
+	return res, nil
+}
+
+func readBigInt(r io.Reader) (*big.Int, error) {
+	var buf [fr.Bytes]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return nil, errTruncate
+	}
+	for i, j := 0, len(buf)-1; i < j; i, j = i+1, j-1 {
+		buf[i], buf[j] = buf[j], buf[i]
+	}
+	return new(big.Int).SetBytes(buf[:]), nil
+}
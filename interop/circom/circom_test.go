@@ -0,0 +1,190 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package circom
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/interop/snarkjs"
+	"github.com/consensys/gurvy/bn256/fr"
+)
+
+// circom encoding of
+//
+//	template Cubic() {
+//		signal input x;
+//		signal output y;
+//		signal t;
+//		signal u;
+//		t <== x * x;
+//		u <== t * x;
+//		y <== u + x + 5;
+//	}
+//
+// wires are [one, y, x, t, u]
+type term struct {
+	wire  uint32
+	coeff int64
+}
+
+var cubicConstraints = [][3][]term{
+	{{{2, 1}}, {{2, 1}}, {{3, 1}}},
+	{{{3, 1}}, {{2, 1}}, {{4, 1}}},
+	{nil, nil, {{4, -1}, {2, -1}, {0, -5}, {1, 1}}},
+}
+
+func writeElement(buf *bytes.Buffer, v *big.Int) {
+	var e big.Int
+	e.Mod(v, fr.Modulus())
+	b := make([]byte, fr.Bytes)
+	e.FillBytes(b)
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	buf.Write(b)
+}
+
+func writeFile(magic string, sections ...[]byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(magic)
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(1))
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(len(sections)))
+	// sections are written in reverse order, as they may appear in any order
+	for i := len(sections) - 1; i >= 0; i-- {
+		_ = binary.Write(&buf, binary.LittleEndian, uint32(i+1))
+		_ = binary.Write(&buf, binary.LittleEndian, uint64(len(sections[i])))
+		buf.Write(sections[i])
+	}
+	return buf.Bytes()
+}
+
+func writePrime(buf *bytes.Buffer, prime *big.Int) {
+	_ = binary.Write(buf, binary.LittleEndian, uint32(fr.Bytes))
+	// the modulus can't be written as an element
+	b := prime.Bytes()
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	buf.Write(b)
+}
+
+func cubicR1CS() []byte {
+	var header, constraints bytes.Buffer
+	writePrime(&header, fr.Modulus())
+	for _, v := range []uint32{5, 1, 0, 1} {
+		_ = binary.Write(&header, binary.LittleEndian, v)
+	}
+	_ = binary.Write(&header, binary.LittleEndian, uint64(5))
+	_ = binary.Write(&header, binary.LittleEndian, uint32(len(cubicConstraints)))
+
+	for _, c := range cubicConstraints {
+		for _, l := range c {
+			_ = binary.Write(&constraints, binary.LittleEndian, uint32(len(l)))
+			for _, t := range l {
+				_ = binary.Write(&constraints, binary.LittleEndian, t.wire)
+				writeElement(&constraints, big.NewInt(t.coeff))
+			}
+		}
+	}
+	return writeFile("r1cs", header.Bytes(), constraints.Bytes())
+}
+
+func cubicWitness(x int64) []byte {
+	var header, values bytes.Buffer
+	writePrime(&header, fr.Modulus())
+	_ = binary.Write(&header, binary.LittleEndian, uint32(5))
+	for _, v := range []int64{1, x*x*x + x + 5, x, x * x, x * x * x} {
+		writeElement(&values, big.NewInt(v))
+	}
+	return writeFile("wtns", header.Bytes(), values.Bytes())
+}
+
+func TestCircom(t *testing.T) {
+	r1cs, err := ReadR1CS(bytes.NewReader(cubicR1CS()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the circuit constraints, and the snarkjs constraints on the 2 public wires
+	if r1cs.NbWires != 5 || r1cs.NbPublicWires != 2 || r1cs.NbConstraints != 3+2 {
+		t.Fatal("unexpected r1cs", r1cs.NbWires, r1cs.NbPublicWires, r1cs.NbConstraints)
+	}
+
+	witness, err := ReadWitness(bytes.NewReader(cubicWitness(3)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r1cs.IsSolved(witness); err != nil {
+		t.Fatal(err)
+	}
+
+	pk, vk, err := groth16.Setup(r1cs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := groth16.Prove(r1cs, pk, witness)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := groth16.Verify(proof, vk, map[string]interface{}{WireName(1): 35}); err != nil {
+		t.Fatal(err)
+	}
+
+	// public signals are in the circom order
+	signals, err := snarkjs.ExportPublicSignals(vk, witness)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(signals) != 1 || signals[0] != "35" {
+		t.Fatal("unexpected public signals", signals)
+	}
+
+	// a wrong witness doesn't satisfy the constraints
+	witness[WireName(1)] = 36
+	if err := r1cs.IsSolved(witness); !errors.Is(err, backend.ErrUnsatisfiedConstraint) {
+		t.Fatal("expected unsatisfied constraint, got", err)
+	}
+}
+
+func TestInvalidFiles(t *testing.T) {
+	r1cs := cubicR1CS()
+	witness := cubicWitness(3)
+
+	if _, err := ReadR1CS(bytes.NewReader(witness)); err == nil {
+		t.Fatal("expected error reading a .wtns as a .r1cs")
+	}
+	if _, err := ReadR1CS(bytes.NewReader(r1cs[:len(r1cs)-1])); err == nil {
+		t.Fatal("expected error with a truncated file")
+	}
+	if _, err := ReadWitness(bytes.NewReader(witness[:len(witness)-1])); err == nil {
+		t.Fatal("expected error with a truncated file")
+	}
+
+	// other prime
+	var header bytes.Buffer
+	_ = binary.Write(&header, binary.LittleEndian, uint32(fr.Bytes))
+	writeElement(&header, big.NewInt(7))
+	_ = binary.Write(&header, binary.LittleEndian, uint32(0))
+	if _, err := ReadWitness(bytes.NewReader(writeFile("wtns", header.Bytes(), nil))); err != errPrime {
+		t.Fatal("expected errPrime, got", err)
+	}
+}
@@ -0,0 +1,316 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package circom
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/big"
+	"math/bits"
+
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/internal/backend/bn256/fft"
+	groth16_bn256 "github.com/consensys/gnark/internal/backend/bn256/groth16"
+	"github.com/consensys/gnark/internal/utils"
+	"github.com/consensys/gurvy/bn256"
+	"github.com/consensys/gurvy/bn256/fp"
+	"github.com/consensys/gurvy/bn256/fr"
+)
+
+// section types of the .zkey format
+const (
+	zkeyHeader        = 1
+	zkeyGroth16Header = 2
+	zkeyIC            = 3
+	zkeyA             = 5
+	zkeyB1            = 6
+	zkeyB2            = 7
+	zkeyC             = 8
+	zkeyH             = 9
+)
+
+// zkeyGroth16 is the protocol of a Groth16 .zkey
+const zkeyGroth16 = 1
+
+var (
+	errProtocol     = errors.New("circom: unsupported zkey protocol, only groth16 is supported")
+	errInvalidPoint = errors.New("circom: point is not on the curve")
+)
+
+// ReadZKey reads the Groth16 proving key of a snarkjs trusted setup (.zkey file), and returns the
+// corresponding gnark keys
+//
+// the keys are meant for the R1CS returned by ReadR1CS on the .r1cs of the setup, which has the
+// constraints s * 0 = 0 that snarkjs appends for each public wire s. Proofs computed with the gnark
+// prover are verified by snarkjs with the verification key exported from the .zkey (or by gnark with
+// the returned verifying key).
+//
+// snarkjs stores the [L_2i+1(τ)/δ]1 of the Lagrange basis on the domain of size 2n; gnark needs the
+// [τ^i Z(τ)/δ]1, which are obtained by a discrete Fourier transform in G1. It needs n log n scalar
+// multiplications, and is by far the most expensive part of the conversion.
+//
+// the points are checked to be on the curve, but not to be in the correct subgroup.
+func ReadZKey(r io.Reader) (*groth16_bn256.ProvingKey, *groth16_bn256.VerifyingKey, error) {
+	sections, err := readSections(r, "zkey")
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, s := range []uint32{zkeyHeader, zkeyGroth16Header, zkeyIC, zkeyA, zkeyB1, zkeyB2, zkeyC, zkeyH} {
+		if _, ok := sections[s]; !ok {
+			return nil, nil, errors.New("circom: missing zkey section")
+		}
+	}
+
+	var protocol uint32
+	if err := binary.Read(sections[zkeyHeader], binary.LittleEndian, &protocol); err != nil {
+		return nil, nil, errTruncate
+	}
+	if protocol != zkeyGroth16 {
+		return nil, nil, errProtocol
+	}
+
+	// groth16 header
+	h := sections[zkeyGroth16Header]
+	if err := readPrime(h, fp.Modulus()); err != nil {
+		return nil, nil, err
+	}
+	if err := readPrime(h, fr.Modulus()); err != nil {
+		return nil, nil, err
+	}
+	var header struct {
+		NbVars, NbPublic, DomainSize uint32
+	}
+	if err := binary.Read(h, binary.LittleEndian, &header); err != nil {
+		return nil, nil, errTruncate
+	}
+	nbWires := int(header.NbVars)
+	nbPublic := int(header.NbPublic) + 1 // includes the constant wire
+	n := int(header.DomainSize)
+
+	// the fft domains of bn256 have at most 2^28 elements, and the .zkey uses the domain of size 2n
+	if nbWires < nbPublic || n == 0 || n&(n-1) != 0 || n > 1<<27 {
+		return nil, nil, errors.New("circom: invalid zkey header")
+	}
+
+	var pk groth16_bn256.ProvingKey
+	var vk groth16_bn256.VerifyingKey
+	var gamma bn256.G2Affine
+	for _, p := range []interface{}{&pk.G1.Alpha, &pk.G1.Beta, &pk.G2.Beta, &gamma, &pk.G1.Delta, &pk.G2.Delta} {
+		if err := readPoint(h, p); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// gnark orders the wires [secret inputs | public inputs], circom [public inputs | secret inputs]
+	readWires := func(section uint32, points interface{}) error {
+		switch points := points.(type) {
+		case []bn256.G1Affine:
+			for i := range points {
+				id, _ := wireID(i, nbWires, nbPublic)
+				if err := readPoint(sections[section], &points[id]); err != nil {
+					return err
+				}
+			}
+		case []bn256.G2Affine:
+			for i := range points {
+				id, _ := wireID(i, nbWires, nbPublic)
+				if err := readPoint(sections[section], &points[id]); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	pk.G1.A = make([]bn256.G1Affine, nbWires)
+	pk.G1.B = make([]bn256.G1Affine, nbWires)
+	pk.G2.B = make([]bn256.G2Affine, nbWires)
+	if err := readWires(zkeyA, pk.G1.A); err != nil {
+		return nil, nil, err
+	}
+	if err := readWires(zkeyB1, pk.G1.B); err != nil {
+		return nil, nil, err
+	}
+	if err := readWires(zkeyB2, pk.G2.B); err != nil {
+		return nil, nil, err
+	}
+
+	// C has the points of the secret wires, IC of the public wires, both in the gnark order
+	pk.G1.K = make([]bn256.G1Affine, nbWires-nbPublic)
+	for i := range pk.G1.K {
+		if err := readPoint(sections[zkeyC], &pk.G1.K[i]); err != nil {
+			return nil, nil, err
+		}
+	}
+	vk.G1.K = make([]bn256.G1Affine, nbPublic)
+	for i := range vk.G1.K {
+		if err := readPoint(sections[zkeyIC], &vk.G1.K[i]); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	pk.Domain = *fft.NewDomain(uint64(n))
+	hPoints := make([]bn256.G1Affine, n)
+	for i := range hPoints {
+		if err := readPoint(sections[zkeyH], &hPoints[i]); err != nil {
+			return nil, nil, err
+		}
+	}
+	pk.G1.Z = zPoints(hPoints, &pk.Domain)
+
+	vk.PublicInputs = append(vk.PublicInputs, backend.OneWire)
+	for i := 1; i < nbPublic; i++ {
+		vk.PublicInputs = append(vk.PublicInputs, WireName(i))
+	}
+	vk.G1.Alpha, vk.G2.Beta = pk.G1.Alpha, pk.G2.Beta
+	vk.G2.GammaNeg.Neg(&gamma)
+	vk.G2.DeltaNeg.Neg(&pk.G2.Delta)
+	if vk.E, err = bn256.Pair([]bn256.G1Affine{vk.G1.Alpha}, []bn256.G2Affine{vk.G2.Beta}); err != nil {
+		return nil, nil, err
+	}
+
+	return &pk, &vk, nil
+}
+
+// zPoints returns the [τ^i Z(τ)/δ]1 of the gnark proving key, in bit reversed order (as the h
+// computed by the prover), from the [L_2j+1(τ)/δ]1 of the .zkey
+//
+// on the domain of size 2n generated by w (w² generates the domain of size n), τ^i Z(τ) is 0 on the
+// even points and -2 w^((2j+1)i) on the odd points, hence τ^i Z(τ) = -2 w^i Σ_j w^(2ij) L_2j+1(τ)
+func zPoints(hPoints []bn256.G1Affine, domain *fft.Domain) []bn256.G1Affine {
+	n := len(hPoints)
+	p := make([]bn256.G1Jac, n)
+	for i := range hPoints {
+		p[i].FromAffine(&hPoints[i])
+	}
+	fftG1(p, domain.Generator)
+
+	utils.Parallelize(n, func(start, end int) {
+		var minusTwo, s fr.Element
+		minusTwo.SetUint64(2).Neg(&minusTwo)
+		s.Exp(domain.GeneratorSqRt, big.NewInt(int64(start))).Mul(&s, &minusTwo)
+		var b big.Int
+		for i := start; i < end; i++ {
+			s.ToBigIntRegular(&b)
+			p[i].ScalarMultiplication(&p[i], &b)
+			s.Mul(&s, &domain.GeneratorSqRt)
+		}
+	})
+
+	res := make([]bn256.G1Affine, n)
+	bn256.BatchJacobianToAffineG1Affine(p, res)
+	bitReverse(res)
+	return res
+}
+
+// fftG1 sets p[i] to Σ_j omega^(ij) p[j], where omega generates the domain of size len(p)
+func fftG1(p []bn256.G1Jac, omega fr.Element) {
+	n := len(p)
+	twiddles := make([]big.Int, n/2)
+	w := fr.One()
+	for i := range twiddles {
+		w.ToBigIntRegular(&twiddles[i])
+		w.Mul(&w, &omega)
+	}
+
+	// iterative radix 2 decimation in time, on bit reversed inputs
+	bitReverse(p)
+	for m := 2; m <= n; m <<= 1 {
+		half, stride := m/2, n/m
+		utils.Parallelize(n/2, func(start, end int) {
+			var t, u bn256.G1Jac
+			for b := start; b < end; b++ {
+				j := b % half
+				k := (b/half)*m + j
+				if j == 0 {
+					t.Set(&p[k+half])
+				} else {
+					t.ScalarMultiplication(&p[k+half], &twiddles[j*stride])
+				}
+				u.Set(&p[k])
+				p[k].AddAssign(&t)
+				p[k+half].Set(&u).SubAssign(&t)
+			}
+		})
+	}
+}
+
+func bitReverse(a interface{}) {
+	switch a := a.(type) {
+	case []bn256.G1Jac:
+		n := uint64(len(a))
+		nn := uint64(64 - bits.TrailingZeros64(n))
+		for i := uint64(0); i < n; i++ {
+			if irev := bits.Reverse64(i) >> nn; irev > i {
+				a[i], a[irev] = a[irev], a[i]
+			}
+		}
+	case []bn256.G1Affine:
+		n := uint64(len(a))
+		nn := uint64(64 - bits.TrailingZeros64(n))
+		for i := uint64(0); i < n; i++ {
+			if irev := bits.Reverse64(i) >> nn; irev > i {
+				a[i], a[irev] = a[irev], a[i]
+			}
+		}
+	}
+}
+
+// readPoint reads a *bn256.G1Affine or a *bn256.G2Affine, encoded by snarkjs as its affine coordinates
+// in little endian montgomery form; infinity is encoded as zeros
+func readPoint(r io.Reader, p interface{}) error {
+	switch p := p.(type) {
+	case *bn256.G1Affine:
+		for _, c := range []*fp.Element{&p.X, &p.Y} {
+			if err := readCoordinate(r, c); err != nil {
+				return err
+			}
+		}
+		if !p.IsOnCurve() {
+			return errInvalidPoint
+		}
+	case *bn256.G2Affine:
+		for _, c := range []*fp.Element{&p.X.A0, &p.X.A1, &p.Y.A0, &p.Y.A1} {
+			if err := readCoordinate(r, c); err != nil {
+				return err
+			}
+		}
+		if !p.IsOnCurve() {
+			return errInvalidPoint
+		}
+	}
+	return nil
+}
+
+// readCoordinate reads an element of fp in little endian montgomery form, which is the internal
+// representation of fp.Element
+func readCoordinate(r io.Reader, c *fp.Element) error {
+	var buf [fp.Bytes]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return errTruncate
+	}
+	for i := range c {
+		c[i] = binary.LittleEndian.Uint64(buf[8*i:])
+	}
+	for i, j := 0, len(buf)-1; i < j; i, j = i+1, j-1 {
+		buf[i], buf[j] = buf[j], buf[i]
+	}
+	if new(big.Int).SetBytes(buf[:]).Cmp(fp.Modulus()) >= 0 {
+		return errors.New("circom: invalid coordinate")
+	}
+	return nil
+}
@@ -0,0 +1,301 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package circom
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/internal/backend/bn256/fft"
+	"github.com/consensys/gnark/interop/snarkjs"
+	"github.com/consensys/gurvy/bn256"
+	"github.com/consensys/gurvy/bn256/fp"
+	"github.com/consensys/gurvy/bn256/fr"
+)
+
+// toxicWaste of the .zkey of the tests
+type toxicWaste struct {
+	tau, alpha, beta, gamma, delta fr.Element
+}
+
+func newToxicWaste() toxicWaste {
+	var res toxicWaste
+	for _, v := range []*fr.Element{&res.tau, &res.alpha, &res.beta, &res.gamma, &res.delta} {
+		v.SetRandom()
+	}
+	return res
+}
+
+// lagrange returns L_j(τ) on the domain of size n generated by w: (τ^n - 1) / n * w^j / (τ - w^j)
+func lagrange(tau, w fr.Element, n, j int) fr.Element {
+	var res, wj, d fr.Element
+	one := fr.One()
+	res.Exp(tau, big.NewInt(int64(n))).Sub(&res, &one)
+	d.SetUint64(uint64(n))
+	res.Div(&res, &d)
+	wj.Exp(w, big.NewInt(int64(j)))
+	d.Sub(&tau, &wj)
+	res.Mul(&res, &wj).Div(&res, &d)
+	return res
+}
+
+func writePoint(buf *bytes.Buffer, p interface{}) {
+	var coordinates []*fp.Element
+	switch p := p.(type) {
+	case *bn256.G1Affine:
+		coordinates = []*fp.Element{&p.X, &p.Y}
+	case *bn256.G2Affine:
+		coordinates = []*fp.Element{&p.X.A0, &p.X.A1, &p.Y.A0, &p.Y.A1}
+	}
+	for _, c := range coordinates {
+		for _, limb := range c {
+			_ = binary.Write(buf, binary.LittleEndian, limb)
+		}
+	}
+}
+
+// cubicZKey returns the .zkey that snarkjs computes for cubicR1CS with the given toxic waste
+func cubicZKey(tw toxicWaste) []byte {
+	const nbWires, nbPublic = 5, 2
+
+	// the constraints s * 0 = 0 on the public wires s are appended to the circuit constraints
+	constraints := append([][3][]term{}, cubicConstraints...)
+	for s := uint32(0); s < nbPublic; s++ {
+		constraints = append(constraints, [3][]term{{{s, 1}}, nil, nil})
+	}
+	domain := fft.NewDomain(uint64(len(constraints)))
+	n := int(domain.Cardinality)
+
+	// QAP polynomials at τ
+	var abc [3][nbWires]fr.Element
+	for j, c := range constraints {
+		l := lagrange(tw.tau, domain.Generator, n, j)
+		for k := range c {
+			for _, t := range c[k] {
+				var v fr.Element
+				v.SetBigInt(big.NewInt(t.coeff)).Mul(&v, &l)
+				abc[k][t.wire].Add(&abc[k][t.wire], &v)
+			}
+		}
+	}
+
+	_, _, g1, g2 := bn256.Generators()
+	g1Point := func(buf *bytes.Buffer, s fr.Element) {
+		var b big.Int
+		var p bn256.G1Affine
+		p.ScalarMultiplication(&g1, s.ToBigIntRegular(&b))
+		writePoint(buf, &p)
+	}
+	g2Point := func(buf *bytes.Buffer, s fr.Element) {
+		var b big.Int
+		var p bn256.G2Affine
+		p.ScalarMultiplication(&g2, s.ToBigIntRegular(&b))
+		writePoint(buf, &p)
+	}
+
+	var protocol, header, ic, coeffs, a, b1, b2, c, h bytes.Buffer
+	_ = binary.Write(&protocol, binary.LittleEndian, uint32(zkeyGroth16))
+
+	writePrime(&header, fp.Modulus())
+	writePrime(&header, fr.Modulus())
+	for _, v := range []uint32{nbWires, nbPublic - 1, uint32(n)} {
+		_ = binary.Write(&header, binary.LittleEndian, v)
+	}
+	g1Point(&header, tw.alpha)
+	g1Point(&header, tw.beta)
+	g2Point(&header, tw.beta)
+	g2Point(&header, tw.gamma)
+	g1Point(&header, tw.delta)
+	g2Point(&header, tw.delta)
+
+	// the coefficients are not read by ReadZKey
+	_ = binary.Write(&coeffs, binary.LittleEndian, uint32(0))
+
+	for i := 0; i < nbWires; i++ {
+		g1Point(&a, abc[0][i])
+		g1Point(&b1, abc[1][i])
+		g2Point(&b2, abc[1][i])
+
+		// (β A(τ) + α B(τ) + C(τ)) / γ for the public wires, / δ for the others
+		var k, t fr.Element
+		k.Mul(&tw.beta, &abc[0][i])
+		t.Mul(&tw.alpha, &abc[1][i])
+		k.Add(&k, &t).Add(&k, &abc[2][i])
+		if i < nbPublic {
+			g1Point(&ic, *k.Div(&k, &tw.gamma))
+		} else {
+			g1Point(&c, *k.Div(&k, &tw.delta))
+		}
+	}
+
+	// odd points of the Lagrange basis of the domain of size 2n, / δ
+	for i := 0; i < n; i++ {
+		l := lagrange(tw.tau, domain.GeneratorSqRt, 2*n, 2*i+1)
+		g1Point(&h, *l.Div(&l, &tw.delta))
+	}
+
+	return writeFile("zkey", protocol.Bytes(), header.Bytes(), ic.Bytes(), coeffs.Bytes(), a.Bytes(), b1.Bytes(), b2.Bytes(), c.Bytes(), h.Bytes())
+}
+
+func TestZKey(t *testing.T) {
+	tw := newToxicWaste()
+	r1cs, err := ReadR1CS(bytes.NewReader(cubicR1CS()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, vk, err := ReadZKey(bytes.NewReader(cubicZKey(tw)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// pk.G1.Z[i] = [τ^i Z(τ)/δ]1, in bit reversed order
+	_, _, g1, _ := bn256.Generators()
+	n := pk.Domain.Cardinality
+	var z, ti fr.Element
+	one := fr.One()
+	z.Exp(tw.tau, new(big.Int).SetUint64(n)).Sub(&z, &one).Div(&z, &tw.delta)
+	ti.SetOne()
+	expected := make([]bn256.G1Affine, n)
+	for i := range expected {
+		var s fr.Element
+		var b big.Int
+		s.Mul(&ti, &z)
+		expected[i].ScalarMultiplication(&g1, s.ToBigIntRegular(&b))
+		ti.Mul(&ti, &tw.tau)
+	}
+	bitReverse(expected)
+	for i := range expected {
+		if !expected[i].Equal(&pk.G1.Z[i]) {
+			t.Fatal("unexpected pk.G1.Z", i)
+		}
+	}
+
+	witness, err := ReadWitness(bytes.NewReader(cubicWitness(3)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := groth16.Prove(r1cs, pk, witness)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := groth16.Verify(proof, vk, map[string]interface{}{WireName(1): 35}); err != nil {
+		t.Fatal(err)
+	}
+	if err := groth16.Verify(proof, vk, map[string]interface{}{WireName(1): 36}); err == nil {
+		t.Fatal("expected verification error with a wrong public input")
+	}
+}
+
+// TestZKeySnarkjs verifies with snarkjs a gnark proof computed with the keys of a .zkey, when snarkjs
+// is installed
+func TestZKeySnarkjs(t *testing.T) {
+	snarkjsPath, err := exec.LookPath("snarkjs")
+	if err != nil {
+		t.Skip("snarkjs not found in PATH")
+	}
+	zkey := cubicZKey(newToxicWaste())
+	r1cs, err := ReadR1CS(bytes.NewReader(cubicR1CS()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, vk, err := ReadZKey(bytes.NewReader(zkey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	witness, err := ReadWitness(bytes.NewReader(cubicWitness(3)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := groth16.Prove(r1cs, pk, witness)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sProof, err := snarkjs.ExportProof(proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signals, err := snarkjs.ExportPublicSignals(vk, witness)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the verification key is exported by snarkjs from the .zkey
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "circuit.zkey"), zkey, 0600); err != nil {
+		t.Fatal(err)
+	}
+	files := []string{"public.json", "proof.json"}
+	for i, v := range []interface{}{signals, sProof} {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, files[i]), data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, args := range [][]string{
+		{"zkey", "export", "verificationkey", "circuit.zkey", "verification_key.json"},
+		{"groth16", "verify", "verification_key.json", files[0], files[1]},
+	} {
+		cmd := exec.Command(snarkjsPath, args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatal(string(out), err)
+		}
+	}
+}
+
+func TestInvalidZKey(t *testing.T) {
+	zkey := cubicZKey(newToxicWaste())
+	if _, _, err := ReadZKey(bytes.NewReader(zkey[:len(zkey)-1])); err == nil {
+		t.Fatal("expected error with a truncated file")
+	}
+	if _, _, err := ReadZKey(bytes.NewReader(cubicR1CS())); err == nil {
+		t.Fatal("expected error reading a .r1cs as a .zkey")
+	}
+
+	// plonk .zkey
+	plonk := append([]byte{}, zkey...)
+	binary.LittleEndian.PutUint32(plonk[section(plonk, zkeyHeader):], 2)
+	if _, _, err := ReadZKey(bytes.NewReader(plonk)); err != errProtocol {
+		t.Fatal("expected errProtocol, got", err)
+	}
+
+	// a point of A isn't on the curve
+	invalid := append([]byte{}, zkey...)
+	invalid[section(invalid, zkeyA)] ^= 1
+	if _, _, err := ReadZKey(bytes.NewReader(invalid)); err != errInvalidPoint {
+		t.Fatal("expected errInvalidPoint, got", err)
+	}
+}
+
+// section returns the offset of the content of a section in a file written by writeFile
+func section(data []byte, sectionType uint32) int {
+	offset := 12
+	for binary.LittleEndian.Uint32(data[offset:]) != sectionType {
+		offset += 12 + int(binary.LittleEndian.Uint64(data[offset+4:]))
+	}
+	return offset + 12
+}
@@ -0,0 +1,203 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cose encodes gnark Groth16 proofs and their public inputs in CBOR (RFC 8949), to be
+// embedded in COSE (RFC 8152) and CWT (RFC 8392) structures
+//
+// A proof is the CBOR array
+//
+//	[curve: uint, proof: bstr, inputs: [* bstr]]
+//
+// where curve is 1 (BN254), 2 (BLS12-377), 3 (BLS12-381) or 4 (BW6-761), proof is the compressed
+// gnark encoding (Proof.WriteTo) and inputs are the values of the public inputs in the order of the
+// verifying key (without backend.OneWire), in big endian on the size of the scalar field. The
+// encoding is deterministic (RFC 8949 core deterministic encoding), so that the bytes can be signed.
+//
+// The encoded proof is meant to be the payload of a COSE_Sign1 or COSE_Mac0 message, with the
+// content type ContentType, or the value of a CWT claim.
+package cose
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	groth16_bls377 "github.com/consensys/gnark/internal/backend/bls377/groth16"
+	groth16_bls381 "github.com/consensys/gnark/internal/backend/bls381/groth16"
+	groth16_bn256 "github.com/consensys/gnark/internal/backend/bn256/groth16"
+	groth16_bw761 "github.com/consensys/gnark/internal/backend/bw761/groth16"
+	"github.com/consensys/gurvy"
+	fr_bls377 "github.com/consensys/gurvy/bls377/fr"
+	fr_bls381 "github.com/consensys/gurvy/bls381/fr"
+	fr_bn256 "github.com/consensys/gurvy/bn256/fr"
+	fr_bw761 "github.com/consensys/gurvy/bw761/fr"
+	"github.com/fxamacker/cbor/v2"
+)
+
+// ContentType is the media type of an encoded proof, for the COSE content type header
+const ContentType = "application/gnark-groth16+cbor"
+
+var (
+	errCurve    = errors.New("cose: unsupported curve")
+	errNbInputs = errors.New("cose: number of public inputs doesn't match the verifying key")
+	errInput    = errors.New("cose: invalid public input")
+	errTrailing = errors.New("cose: trailing bytes after the proof")
+)
+
+// Proof is a proof and its public inputs
+type Proof struct {
+	_      struct{} `cbor:",toarray"`
+	Curve  uint64
+	Proof  []byte
+	Inputs [][]byte
+}
+
+type curveParams struct {
+	id      gurvy.ID
+	modulus *big.Int // of the scalar field
+	n       int      // size of an encoded scalar
+}
+
+var curves = map[uint64]curveParams{
+	1: {gurvy.BN256, fr_bn256.Modulus(), fr_bn256.Limbs * 8},
+	2: {gurvy.BLS377, fr_bls377.Modulus(), fr_bls377.Limbs * 8},
+	3: {gurvy.BLS381, fr_bls381.Modulus(), fr_bls381.Limbs * 8},
+	4: {gurvy.BW761, fr_bw761.Modulus(), fr_bw761.Limbs * 8},
+}
+
+var encMode, decMode = func() (cbor.EncMode, cbor.DecMode) {
+	enc, err := cbor.CoreDetEncOptions().EncMode()
+	if err != nil {
+		panic(err)
+	}
+	dec, err := cbor.DecOptions{DupMapKey: cbor.DupMapKeyEnforcedAPF}.DecMode()
+	if err != nil {
+		panic(err)
+	}
+	return enc, dec
+}()
+
+// Marshal returns the CBOR encoding of the proof and the public inputs of publicWitness
+func Marshal(proof groth16.Proof, vk groth16.VerifyingKey, publicWitness interface{}) ([]byte, error) {
+	curve, names, err := publicInputs(vk)
+	if err != nil {
+		return nil, err
+	}
+	witness, err := frontend.ParseWitness(publicWitness)
+	if err != nil {
+		return nil, err
+	}
+	c := curves[curve]
+
+	res := Proof{Curve: curve}
+	var buf bytes.Buffer
+	if _, err := proof.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	res.Proof = buf.Bytes()
+	res.Inputs = make([][]byte, 0, len(names))
+	for _, name := range names {
+		v, ok := witness[name]
+		if !ok {
+			return nil, backend.ErrInputNotSet
+		}
+		value := backend.FromInterface(v)
+		value.Mod(&value, c.modulus)
+		res.Inputs = append(res.Inputs, value.FillBytes(make([]byte, c.n)))
+	}
+	return encMode.Marshal(res)
+}
+
+// Unmarshal decodes a proof encoded by Marshal, and returns it with its public witness
+//
+// vk gives the curve and the names of the public inputs; the proof points are checked by groth16.Verify
+func Unmarshal(data []byte, vk groth16.VerifyingKey) (groth16.Proof, map[string]interface{}, error) {
+	curve, names, err := publicInputs(vk)
+	if err != nil {
+		return nil, nil, err
+	}
+	var p Proof
+	if err := decMode.Unmarshal(data, &p); err != nil {
+		return nil, nil, err
+	}
+	if p.Curve != curve {
+		return nil, nil, errCurve
+	}
+	if len(p.Inputs) != len(names) {
+		return nil, nil, errNbInputs
+	}
+	c := curves[curve]
+
+	proof := groth16.NewProof(c.id)
+	r := bytes.NewReader(p.Proof)
+	if _, err := proof.ReadFrom(r); err != nil {
+		return nil, nil, err
+	}
+	if r.Len() != 0 {
+		return nil, nil, errTrailing
+	}
+
+	witness := make(map[string]interface{}, len(names))
+	for i, name := range names {
+		if len(p.Inputs[i]) != c.n {
+			return nil, nil, errInput
+		}
+		var v big.Int
+		v.SetBytes(p.Inputs[i])
+		if v.Cmp(c.modulus) >= 0 {
+			return nil, nil, errInput
+		}
+		witness[name] = v
+	}
+	return proof, witness, nil
+}
+
+// Verify decodes a proof encoded by Marshal and verifies it
+func Verify(data []byte, vk groth16.VerifyingKey, opts ...backend.Option) error {
+	proof, witness, err := Unmarshal(data, vk)
+	if err != nil {
+		return err
+	}
+	return groth16.Verify(proof, vk, witness, opts...)
+}
+
+// publicInputs returns the curve of vk and the names of its public inputs, without backend.OneWire
+func publicInputs(vk groth16.VerifyingKey) (uint64, []string, error) {
+	var curve uint64
+	var names []string
+	switch _vk := vk.(type) {
+	case *groth16_bn256.VerifyingKey:
+		curve, names = 1, _vk.PublicInputs
+	case *groth16_bls377.VerifyingKey:
+		curve, names = 2, _vk.PublicInputs
+	case *groth16_bls381.VerifyingKey:
+		curve, names = 3, _vk.PublicInputs
+	case *groth16_bw761.VerifyingKey:
+		curve, names = 4, _vk.PublicInputs
+	default:
+		return 0, nil, errCurve
+	}
+	res := make([]string, 0, len(names))
+	for _, name := range names {
+		if name != backend.OneWire {
+			res = append(res, name)
+		}
+	}
+	return curve, res, nil
+}
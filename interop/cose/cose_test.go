@@ -0,0 +1,149 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cose
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gurvy"
+	"github.com/fxamacker/cbor/v2"
+)
+
+type cubicCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+// x**3 + x + 5 == y
+func (circuit *cubicCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	x3 := cs.Mul(circuit.X, circuit.X, circuit.X)
+	cs.AssertIsEqual(circuit.Y, cs.Add(x3, circuit.X, 5))
+	return nil
+}
+
+// coseSign1 is a COSE_Sign1 message (RFC 8152, section 4.2)
+type coseSign1 struct {
+	_           struct{} `cbor:",toarray"`
+	Protected   []byte
+	Unprotected map[int]interface{}
+	Payload     []byte
+	Signature   []byte
+}
+
+func TestCOSE(t *testing.T) {
+	for _, curveID := range []gurvy.ID{gurvy.BN256, gurvy.BLS377, gurvy.BLS381, gurvy.BW761} {
+		var circuit, witness cubicCircuit
+		r1cs, err := frontend.Compile(curveID, &circuit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pk, vk, err := groth16.Setup(r1cs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		witness.X.Assign(3)
+		witness.Y.Assign(35)
+		proof, err := groth16.Prove(r1cs, pk, &witness)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		payload, err := Marshal(proof, vk, &witness)
+		if err != nil {
+			t.Fatal(err)
+		}
+		again, _ := Marshal(proof, vk, map[string]interface{}{"Y": 35})
+		if !bytes.Equal(payload, again) {
+			t.Fatal("encoding is not deterministic")
+		}
+
+		// embed the proof in a (unsigned) COSE_Sign1 message, with its content type
+		protected, err := cbor.Marshal(map[int]interface{}{3: ContentType})
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg, err := cbor.Marshal(cbor.Tag{Number: 18, Content: coseSign1{Protected: protected, Payload: payload}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var tag cbor.RawTag
+		var decoded coseSign1
+		if err := cbor.Unmarshal(msg, &tag); err != nil || tag.Number != 18 {
+			t.Fatal("expected a COSE_Sign1 message", err)
+		}
+		if err := cbor.Unmarshal(tag.Content, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		if err := Verify(decoded.Payload, vk); err != nil {
+			t.Fatal(curveID, err)
+		}
+
+		// wrong public input
+		invalid, _ := Marshal(proof, vk, map[string]interface{}{"Y": 36})
+		if err := Verify(invalid, vk); err == nil {
+			t.Fatal("expected verification to fail")
+		}
+	}
+}
+
+func TestInvalid(t *testing.T) {
+	var circuit, witness cubicCircuit
+	r1cs, err := frontend.Compile(gurvy.BN256, &circuit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, vk, err := groth16.Setup(r1cs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	witness.X.Assign(3)
+	witness.Y.Assign(35)
+	proof, err := groth16.Prove(r1cs, pk, &witness)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := Marshal(proof, vk, &witness)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var valid Proof
+	if err := cbor.Unmarshal(payload, &valid); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, tamper := range map[string]func(p *Proof){
+		"curve":          func(p *Proof) { p.Curve = 3 },
+		"nb inputs":      func(p *Proof) { p.Inputs = nil },
+		"short input":    func(p *Proof) { p.Inputs = [][]byte{{35}} },
+		"input too big":  func(p *Proof) { p.Inputs = [][]byte{bytes.Repeat([]byte{0xff}, 32)} },
+		"trailing bytes": func(p *Proof) { p.Proof = append(append([]byte(nil), p.Proof...), 0) },
+	} {
+		p := valid
+		tamper(&p)
+		data, err := cbor.Marshal(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := Unmarshal(data, vk); err == nil {
+			t.Fatal("expected error for", name)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cosmos verifies gnark Groth16 proofs in the state machine of a Cosmos SDK (Tendermint)
+// application
+//
+// The package doesn't depend on the Cosmos SDK: GasMeter and KVStore are the subsets of the SDK
+// types.GasMeter and types.KVStore it uses, so that a module keeper passes ctx.GasMeter() and
+// ctx.KVStore(key) directly.
+//
+// Verification is deterministic: it doesn't depend on the machine, on maps iteration or on time, and
+// it charges its gas before doing the work, from the sizes of its inputs. Public inputs are given in the
+// order of the verifying key, and the verifying keys are decoded once (and cached by content), so that
+// verification doesn't use reflection. Errors and panics of the verifier are returned as errors.
+package cosmos
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
+	groth16_bls377 "github.com/consensys/gnark/internal/backend/bls377/groth16"
+	groth16_bls381 "github.com/consensys/gnark/internal/backend/bls381/groth16"
+	groth16_bn256 "github.com/consensys/gnark/internal/backend/bn256/groth16"
+	groth16_bw761 "github.com/consensys/gnark/internal/backend/bw761/groth16"
+	"github.com/consensys/gurvy"
+	fr_bls377 "github.com/consensys/gurvy/bls377/fr"
+	fr_bls381 "github.com/consensys/gurvy/bls381/fr"
+	fr_bn256 "github.com/consensys/gurvy/bn256/fr"
+	fr_bw761 "github.com/consensys/gurvy/bw761/fr"
+)
+
+var (
+	// ErrUnknownKey is returned for a verifying key that is not registered
+	ErrUnknownKey = errors.New("cosmos: unknown verifying key")
+	// ErrKeyExists is returned when a verifying key is registered twice
+	ErrKeyExists = errors.New("cosmos: verifying key already registered")
+	// ErrInvalidProof is returned for a proof that doesn't verify
+	ErrInvalidProof = errors.New("cosmos: invalid proof")
+
+	errCurve    = errors.New("cosmos: unsupported curve")
+	errNbInputs = errors.New("cosmos: number of public inputs doesn't match the verifying key")
+	errInput    = errors.New("cosmos: invalid public input")
+	errTrailing = errors.New("cosmos: trailing bytes")
+)
+
+// GasMeter is the subset of the Cosmos SDK types.GasMeter used by the verifier
+type GasMeter interface {
+	ConsumeGas(amount uint64, descriptor string)
+}
+
+// KVStore is the subset of the Cosmos SDK types.KVStore used by the Keeper
+type KVStore interface {
+	Get(key []byte) []byte
+	Set(key, value []byte)
+}
+
+// GasConfig is the gas charged by a verification
+type GasConfig struct {
+	Verify      uint64 // pairing check
+	PublicInput uint64 // per public input (a scalar multiplication)
+	Byte        uint64 // per byte of the proof and public inputs
+}
+
+// DefaultGasConfig mirrors the cost of a BN254 verification with the Ethereum precompiles (EIP-1108):
+// 4 pairings, and a scalar multiplication and an addition per public input
+//
+// the costs of the other curves should be calibrated by the application
+var DefaultGasConfig = GasConfig{
+	Verify:      45000 + 4*34000,
+	PublicInput: 6000 + 150,
+	Byte:        3,
+}
+
+// Verifier verifies the proofs of a verifying key
+type Verifier struct {
+	vk      groth16.VerifyingKey
+	curveID gurvy.ID
+	names   []string // public inputs, without backend.OneWire
+	modulus *big.Int // of the scalar field
+	gas     GasConfig
+}
+
+// NewVerifier decodes the verifying key (serialized with VerifyingKey.WriteTo)
+func NewVerifier(curveID gurvy.ID, vk []byte, gas GasConfig) (*Verifier, error) {
+	v := &Verifier{curveID: curveID, gas: gas}
+	switch curveID {
+	case gurvy.BN256:
+		v.vk, v.modulus = &groth16_bn256.VerifyingKey{}, fr_bn256.Modulus()
+	case gurvy.BLS377:
+		v.vk, v.modulus = &groth16_bls377.VerifyingKey{}, fr_bls377.Modulus()
+	case gurvy.BLS381:
+		v.vk, v.modulus = &groth16_bls381.VerifyingKey{}, fr_bls381.Modulus()
+	case gurvy.BW761:
+		v.vk, v.modulus = &groth16_bw761.VerifyingKey{}, fr_bw761.Modulus()
+	default:
+		return nil, errCurve
+	}
+	r := bytes.NewReader(vk)
+	if _, err := v.vk.ReadFrom(r); err != nil {
+		return nil, err
+	}
+	if r.Len() != 0 {
+		return nil, errTrailing
+	}
+
+	var publicInputs []string
+	switch _vk := v.vk.(type) {
+	case *groth16_bn256.VerifyingKey:
+		publicInputs = _vk.PublicInputs
+	case *groth16_bls377.VerifyingKey:
+		publicInputs = _vk.PublicInputs
+	case *groth16_bls381.VerifyingKey:
+		publicInputs = _vk.PublicInputs
+	case *groth16_bw761.VerifyingKey:
+		publicInputs = _vk.PublicInputs
+	}
+	for _, name := range publicInputs {
+		if name != backend.OneWire {
+			v.names = append(v.names, name)
+		}
+	}
+	return v, nil
+}
+
+// NbPublicInputs returns the number of public inputs of the verifying key
+func (v *Verifier) NbPublicInputs() int {
+	return len(v.names)
+}
+
+// Verify charges the gas of the verification to meter, then verifies the proof (serialized with
+// Proof.WriteTo) against the public inputs (big endian integers, in the order of the verifying key)
+func (v *Verifier) Verify(meter GasMeter, proof []byte, publicInputs [][]byte) (err error) {
+	size := len(proof)
+	for _, input := range publicInputs {
+		size += len(input)
+	}
+	meter.ConsumeGas(v.gas.Verify, "groth16 verify")
+	meter.ConsumeGas(v.gas.PublicInput*uint64(len(publicInputs)), "groth16 public inputs")
+	meter.ConsumeGas(v.gas.Byte*uint64(size), "groth16 proof size")
+
+	if len(publicInputs) != len(v.names) {
+		return errNbInputs
+	}
+
+	// a panic must not halt the chain
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("cosmos: verifier panic: %v", r)
+		}
+	}()
+
+	_proof := groth16.NewProof(v.curveID)
+	r := bytes.NewReader(proof)
+	if _, err := _proof.ReadFrom(r); err != nil {
+		return err
+	}
+	if r.Len() != 0 {
+		return errTrailing
+	}
+	witness := make(map[string]interface{}, len(v.names))
+	for i, name := range v.names {
+		var value big.Int
+		value.SetBytes(publicInputs[i])
+		if value.Cmp(v.modulus) >= 0 {
+			return errInput
+		}
+		witness[name] = value
+	}
+	if err := groth16.Verify(_proof, v.vk, witness); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidProof, err)
+	}
+	return nil
+}
+
+// Keeper stores verifying keys in a KVStore, under an ID, and verifies the proofs of the stored keys
+//
+// the store is the source of truth, so that the state doesn't depend on the transactions a node saw;
+// the decoded keys are cached by content
+type Keeper struct {
+	gas GasConfig
+
+	lock      sync.Mutex
+	verifiers map[[sha256.Size]byte]*Verifier
+}
+
+// NewKeeper returns a Keeper charging gas from config
+func NewKeeper(gas GasConfig) *Keeper {
+	return &Keeper{gas: gas, verifiers: make(map[[sha256.Size]byte]*Verifier)}
+}
+
+// store layout: key prefix, then the ID; the value is the curve ID (1 byte) followed by the verifying key
+var keyPrefix = []byte("gnark/vk/")
+
+func storeKey(id string) []byte {
+	return append(append([]byte(nil), keyPrefix...), id...)
+}
+
+// RegisterVerifyingKey checks and stores the verifying key (serialized with VerifyingKey.WriteTo)
+func (k *Keeper) RegisterVerifyingKey(store KVStore, id string, curveID gurvy.ID, vk []byte) error {
+	if store.Get(storeKey(id)) != nil {
+		return ErrKeyExists
+	}
+	if curveID > 0xff {
+		return errCurve
+	}
+	value := append([]byte{byte(curveID)}, vk...)
+	if _, err := k.verifier(value); err != nil {
+		return err
+	}
+	store.Set(storeKey(id), value)
+	return nil
+}
+
+// Verify verifies a proof of the verifying key id, see Verifier.Verify
+func (k *Keeper) Verify(store KVStore, meter GasMeter, id string, proof []byte, publicInputs [][]byte) error {
+	value := store.Get(storeKey(id))
+	if len(value) == 0 {
+		return ErrUnknownKey
+	}
+	v, err := k.verifier(value)
+	if err != nil {
+		return err
+	}
+	return v.Verify(meter, proof, publicInputs)
+}
+
+// verifier returns the Verifier of a stored value
+func (k *Keeper) verifier(value []byte) (*Verifier, error) {
+	h := sha256.Sum256(value)
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	if v, ok := k.verifiers[h]; ok {
+		return v, nil
+	}
+	v, err := NewVerifier(gurvy.ID(value[0]), value[1:], k.gas)
+	if err != nil {
+		return nil, err
+	}
+	k.verifiers[h] = v
+	return v, nil
+}
@@ -0,0 +1,111 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cosmos
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gurvy"
+)
+
+type cubicCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+// x**3 + x + 5 == y
+func (circuit *cubicCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	x3 := cs.Mul(circuit.X, circuit.X, circuit.X)
+	cs.AssertIsEqual(circuit.Y, cs.Add(x3, circuit.X, 5))
+	return nil
+}
+
+type memStore map[string][]byte
+
+func (s memStore) Get(key []byte) []byte { return s[string(key)] }
+func (s memStore) Set(key, value []byte) { s[string(key)] = value }
+
+type gasMeter uint64
+
+func (m *gasMeter) ConsumeGas(amount uint64, descriptor string) { *m += gasMeter(amount) }
+
+func TestKeeper(t *testing.T) {
+	var circuit, witness cubicCircuit
+	r1cs, err := frontend.Compile(gurvy.BN256, &circuit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, vk, err := groth16.Setup(r1cs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	witness.X.Assign(3)
+	witness.Y.Assign(35)
+	proof, err := groth16.Prove(r1cs, pk, &witness)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var bVK, bProof bytes.Buffer
+	if _, err := vk.WriteTo(&bVK); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := proof.WriteTo(&bProof); err != nil {
+		t.Fatal(err)
+	}
+	inputs := [][]byte{big.NewInt(35).Bytes()}
+
+	store := memStore{}
+	keeper := NewKeeper(DefaultGasConfig)
+	if err := keeper.RegisterVerifyingKey(store, "cubic", gurvy.ID(0xff), bVK.Bytes()); err == nil {
+		t.Fatal("unknown curve should be rejected")
+	}
+	if err := keeper.RegisterVerifyingKey(store, "cubic", gurvy.BN256, bVK.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	if err := keeper.RegisterVerifyingKey(store, "cubic", gurvy.BN256, bVK.Bytes()); err != ErrKeyExists {
+		t.Fatal("expected ErrKeyExists, got", err)
+	}
+
+	var meter gasMeter
+	if err := keeper.Verify(store, &meter, "cubic", bProof.Bytes(), inputs); err != nil {
+		t.Fatal(err)
+	}
+	expected := DefaultGasConfig.Verify + DefaultGasConfig.PublicInput + DefaultGasConfig.Byte*uint64(bProof.Len()+1)
+	if uint64(meter) != expected {
+		t.Fatalf("gas: expected %d, got %d", expected, meter)
+	}
+
+	if err := keeper.Verify(store, &meter, "cubic", bProof.Bytes(), [][]byte{{42}}); !errors.Is(err, ErrInvalidProof) {
+		t.Fatal("expected ErrInvalidProof, got", err)
+	}
+	if err := keeper.Verify(store, &meter, "cubic", bProof.Bytes(), nil); err == nil {
+		t.Fatal("wrong number of public inputs should be rejected")
+	}
+	if err := keeper.Verify(store, &meter, "square", bProof.Bytes(), inputs); err != ErrUnknownKey {
+		t.Fatal("expected ErrUnknownKey, got", err)
+	}
+
+	// a new keeper (a restarted node) reads the keys from the store
+	if err := NewKeeper(DefaultGasConfig).Verify(store, &meter, "cubic", bProof.Bytes(), inputs); err != nil {
+		t.Fatal(err)
+	}
+}
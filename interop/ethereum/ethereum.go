@@ -0,0 +1,174 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ethereum lays out BN256 Groth16 proofs and public inputs as expected by the EIP-197
+// pairing precompile and the verifier contracts built on it
+//
+// Every value is a uint256 word, in big endian. A G1 point is encoded as (x, y), and a G2 point as
+// (x.A1, x.A0, y.A1, y.A0): the imaginary part of each coordinate comes first. The point at infinity
+// is encoded with zeros. Swapping the parts of the G2 coordinates is the most common integration
+// bug: the point is then not on the curve, and the precompile fails.
+//
+// ExportSolidity writes a verifier contract in this layout; ExportSolidityTest and WriteFixture write a
+// Foundry test and a JSON fixture, with a proof produced by gnark, to check the deployed contract.
+// EstimateGas estimates the gas used by the verifier contract, to check a circuit against an on-chain
+// budget before deploying it.
+package ethereum
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	groth16_bn256 "github.com/consensys/gnark/internal/backend/bn256/groth16"
+	"github.com/consensys/gurvy/bn256"
+	"github.com/consensys/gurvy/bn256/fr"
+)
+
+const (
+	// SizeOfG1 is the size of an encoded G1 point
+	SizeOfG1 = 2 * 32
+	// SizeOfG2 is the size of an encoded G2 point
+	SizeOfG2 = 4 * 32
+	// SizeOfPair is the size of a (G1, G2) pair in the input of the pairing precompile
+	SizeOfPair = SizeOfG1 + SizeOfG2
+)
+
+var (
+	errCurve    = errors.New("ethereum: only bn256 is supported")
+	errNbPoints = errors.New("ethereum: pairing input needs as many G1 and G2 points")
+)
+
+// Proof is a Groth16 proof, in the argument layout of the usual verifier contracts
+//
+//	function verifyProof(uint[2] memory a, uint[2][2] memory b, uint[2] memory c, uint[N] memory input)
+type Proof struct {
+	A [2]*big.Int
+	B [2][2]*big.Int // [[x.A1, x.A0], [y.A1, y.A0]]
+	C [2]*big.Int
+}
+
+// NewProof converts a gnark proof
+func NewProof(proof groth16.Proof) (*Proof, error) {
+	_proof, ok := proof.(*groth16_bn256.Proof)
+	if !ok {
+		return nil, errCurve
+	}
+	var res Proof
+	res.A = g1Words(&_proof.Ar)
+	w := g2Words(&_proof.Bs)
+	res.B = [2][2]*big.Int{{w[0], w[1]}, {w[2], w[3]}}
+	res.C = g1Words(&_proof.Krs)
+	return &res, nil
+}
+
+// Bytes returns the ABI encoding of (a, b, c), 8 words
+func (proof *Proof) Bytes() []byte {
+	words := []*big.Int{
+		proof.A[0], proof.A[1],
+		proof.B[0][0], proof.B[0][1], proof.B[1][0], proof.B[1][1],
+		proof.C[0], proof.C[1],
+	}
+	res := make([]byte, 0, 32*len(words))
+	for _, w := range words {
+		res = appendWord(res, w)
+	}
+	return res
+}
+
+// PublicInputs returns the public inputs of the solution in the order of the verifying key, without
+// the constant wire backend.OneWire
+func PublicInputs(vk groth16.VerifyingKey, solution interface{}) ([]*big.Int, error) {
+	_vk, ok := vk.(*groth16_bn256.VerifyingKey)
+	if !ok {
+		return nil, errCurve
+	}
+	_solution, err := frontend.ParseWitness(solution)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*big.Int, 0, len(_vk.PublicInputs)-1)
+	for _, name := range _vk.PublicInputs {
+		if name == backend.OneWire {
+			continue
+		}
+		val, ok := _solution[name]
+		if !ok {
+			return nil, backend.ErrInputNotSet
+		}
+		var v fr.Element
+		v.SetInterface(val)
+		res = append(res, v.ToBigIntRegular(new(big.Int)))
+	}
+	return res, nil
+}
+
+// EncodeG1 returns the precompile encoding of p
+func EncodeG1(p *bn256.G1Affine) []byte {
+	res := make([]byte, 0, SizeOfG1)
+	for _, w := range g1Words(p) {
+		res = appendWord(res, w)
+	}
+	return res
+}
+
+// EncodeG2 returns the precompile encoding of p
+func EncodeG2(p *bn256.G2Affine) []byte {
+	res := make([]byte, 0, SizeOfG2)
+	for _, w := range g2Words(p) {
+		res = appendWord(res, w)
+	}
+	return res
+}
+
+// PairingInput returns the input of the pairing precompile (address 0x08), that checks
+// e(P[0], Q[0]) * ... * e(P[n-1], Q[n-1]) == 1
+func PairingInput(P []bn256.G1Affine, Q []bn256.G2Affine) ([]byte, error) {
+	if len(P) != len(Q) {
+		return nil, errNbPoints
+	}
+	res := make([]byte, 0, SizeOfPair*len(P))
+	for i := 0; i < len(P); i++ {
+		res = append(res, EncodeG1(&P[i])...)
+		res = append(res, EncodeG2(&Q[i])...)
+	}
+	return res, nil
+}
+
+func g1Words(p *bn256.G1Affine) [2]*big.Int {
+	var res [2]*big.Int
+	res[0] = p.X.ToBigIntRegular(new(big.Int))
+	res[1] = p.Y.ToBigIntRegular(new(big.Int))
+	return res
+}
+
+func g2Words(p *bn256.G2Affine) [4]*big.Int {
+	var res [4]*big.Int
+	res[0] = p.X.A1.ToBigIntRegular(new(big.Int))
+	res[1] = p.X.A0.ToBigIntRegular(new(big.Int))
+	res[2] = p.Y.A1.ToBigIntRegular(new(big.Int))
+	res[3] = p.Y.A0.ToBigIntRegular(new(big.Int))
+	return res
+}
+
+// appendWord appends v to buf, as a big endian uint256
+func appendWord(buf []byte, v *big.Int) []byte {
+	var w [32]byte
+	v.FillBytes(w[:])
+	return append(buf, w[:]...)
+}
@@ -0,0 +1,186 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ethereum
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	groth16_bn256 "github.com/consensys/gnark/internal/backend/bn256/groth16"
+	"github.com/consensys/gurvy"
+	"github.com/consensys/gurvy/bn256"
+)
+
+type cubicCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+	Z frontend.Variable `gnark:",public"`
+}
+
+// x**3 + x + 5 == y, z == 2*x
+func (circuit *cubicCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	x3 := cs.Mul(circuit.X, circuit.X, circuit.X)
+	cs.AssertIsEqual(circuit.Y, cs.Add(x3, circuit.X, 5))
+	cs.AssertIsEqual(circuit.Z, cs.Mul(circuit.X, 2))
+	return nil
+}
+
+func word(s string) []byte {
+	v, _ := new(big.Int).SetString(s, 10)
+	return appendWord(nil, v)
+}
+
+// precompile mimics the pairing precompile: it decodes its input, checks the points are on the curve,
+// and returns the result of the pairing check
+func precompile(t *testing.T, input []byte) bool {
+	if len(input)%SizeOfPair != 0 {
+		t.Fatal("invalid input length")
+	}
+	w := func(i int) *big.Int { return new(big.Int).SetBytes(input[32*i : 32*(i+1)]) }
+	var P []bn256.G1Affine
+	var Q []bn256.G2Affine
+	for i := 0; i < len(input)/32; i += 6 {
+		var p bn256.G1Affine
+		var q bn256.G2Affine
+		p.X.SetBigInt(w(i))
+		p.Y.SetBigInt(w(i + 1))
+		q.X.A1.SetBigInt(w(i + 2))
+		q.X.A0.SetBigInt(w(i + 3))
+		q.Y.A1.SetBigInt(w(i + 4))
+		q.Y.A0.SetBigInt(w(i + 5))
+		if !p.IsOnCurve() || !q.IsOnCurve() {
+			return false
+		}
+		P = append(P, p)
+		Q = append(Q, q)
+	}
+	ok, err := bn256.PairingCheck(P, Q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ok
+}
+
+func TestGenerators(t *testing.T) {
+	// generators as specified by EIP-197
+	eipG2 := bytes.Join([][]byte{
+		word("11559732032986387107991004021392285783925812861821192530917403151452391805634"),
+		word("10857046999023057135944570762232829481370756359578518086990519993285655852781"),
+		word("4082367875863433681332203403145435568316851327593401208105741076214120093531"),
+		word("8495653923123431417604973247489272438418190587263600148770280649306958101930"),
+	}, nil)
+
+	var g1 bn256.G1Affine
+	var g2 bn256.G2Affine
+	g1.X.SetOne()
+	g1.Y.SetUint64(2)
+	g2.X.A1.SetString("11559732032986387107991004021392285783925812861821192530917403151452391805634")
+	g2.X.A0.SetString("10857046999023057135944570762232829481370756359578518086990519993285655852781")
+	g2.Y.A1.SetString("4082367875863433681332203403145435568316851327593401208105741076214120093531")
+	g2.Y.A0.SetString("8495653923123431417604973247489272438418190587263600148770280649306958101930")
+
+	if !bytes.Equal(EncodeG1(&g1), append(word("1"), word("2")...)) {
+		t.Fatal("wrong G1 encoding")
+	}
+	if !bytes.Equal(EncodeG2(&g2), eipG2) {
+		t.Fatal("wrong G2 encoding")
+	}
+
+	// e(g1, g2) * e(-g1, g2) == 1
+	var minusG1 bn256.G1Affine
+	minusG1.Neg(&g1)
+	input, err := PairingInput([]bn256.G1Affine{g1, minusG1}, []bn256.G2Affine{g2, g2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !precompile(t, input) {
+		t.Fatal("pairing check failed")
+	}
+
+	if _, err := PairingInput([]bn256.G1Affine{g1}, nil); err != errNbPoints {
+		t.Fatal("expected errNbPoints")
+	}
+}
+
+func TestProof(t *testing.T) {
+	var circuit cubicCircuit
+	r1cs, err := frontend.Compile(gurvy.BN256, &circuit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, vk, err := groth16.Setup(r1cs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var witness cubicCircuit
+	witness.X.Assign(3)
+	witness.Y.Assign(35)
+	witness.Z.Assign(6)
+	proof, err := groth16.Prove(r1cs, pk, &witness)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := NewProof(proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_proof := proof.(*groth16_bn256.Proof)
+	if !bytes.Equal(p.Bytes(), bytes.Join([][]byte{EncodeG1(&_proof.Ar), EncodeG2(&_proof.Bs), EncodeG1(&_proof.Krs)}, nil)) {
+		t.Fatal("proof words don't match the precompile encoding")
+	}
+
+	inputs, err := PublicInputs(vk, &witness)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) != 2 || inputs[0].Int64() != 35 || inputs[1].Int64() != 6 {
+		t.Fatal("unexpected public inputs", inputs)
+	}
+
+	// the verifier contract computes vk_x = K[0] + Σ input_i * K[i+1] and checks
+	// e(A, B) * e(vk_x, -γ) * e(C, -δ) * e(-α, β) == 1
+	_vk := vk.(*groth16_bn256.VerifyingKey)
+	var vkX, tmp bn256.G1Jac
+	vkX.FromAffine(&_vk.G1.K[0])
+	for i, x := range inputs {
+		tmp.ScalarMultiplication(new(bn256.G1Jac).FromAffine(&_vk.G1.K[i+1]), x)
+		vkX.AddAssign(&tmp)
+	}
+	var vkXAff, minusAlpha bn256.G1Affine
+	vkXAff.FromJacobian(&vkX)
+	minusAlpha.Neg(&_vk.G1.Alpha)
+	input, err := PairingInput(
+		[]bn256.G1Affine{_proof.Ar, vkXAff, _proof.Krs, minusAlpha},
+		[]bn256.G2Affine{_proof.Bs, _vk.G2.GammaNeg, _vk.G2.DeltaNeg, _vk.G2.Beta},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !precompile(t, input) {
+		t.Fatal("verification equation doesn't hold")
+	}
+
+	// swapping the parts of a G2 coordinate gives a point that is not on the curve
+	copy(input[SizeOfG1:], bytes.Join([][]byte{input[SizeOfG1+32 : SizeOfG1+64], input[SizeOfG1 : SizeOfG1+32]}, nil))
+	if precompile(t, input) {
+		t.Fatal("swapped coordinates should be rejected")
+	}
+}
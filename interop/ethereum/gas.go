@@ -0,0 +1,159 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ethereum
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/consensys/gnark/backend/groth16"
+	groth16_bn256 "github.com/consensys/gnark/internal/backend/bn256/groth16"
+)
+
+// gas schedule of the calls to verifyProof (Berlin)
+const (
+	gasTransaction     = 21000 // base cost of a transaction
+	gasCalldataZero    = 4     // per zero byte of calldata (EIP-2028)
+	gasCalldataNonZero = 16    // per non zero byte of calldata (EIP-2028)
+	gasECAdd           = 150   // precompile 0x06 (EIP-1108)
+	gasECMul           = 6000  // precompile 0x07 (EIP-1108)
+	gasPairingBase     = 45000 // precompile 0x08 (EIP-1108)
+	gasPairingPerPair  = 34000
+	gasStaticCall      = 100 // STATICCALL to a warm address, the precompiles are warm (EIP-2929)
+	gasMemoryWord      = 3   // memory expansion: 3 per word + words²/512
+
+	// opcodes of the contract besides the calls and the memory (ABI decoding, range checks, copies
+	// of the pairing input), approximated from the contract of ExportSolidity
+	gasExecutionBase     = 3000
+	gasExecutionPerInput = 300
+
+	// memory words of verifyProof: the scratch space and free memory pointer, the arguments (b is an
+	// array of 2 pointers to arrays), k, the pairing input and its output; and for each public input,
+	// the point [Kvk(i)]1 and the input and output arrays of ecMul and ecAdd (Solidity never frees
+	// memory)
+	memoryWordsBase     = 4 + 2 + 6 + 2 + 2 + 24 + 1
+	memoryWordsPerInput = 1 + 2 + 3 + 2 + 4 + 2
+)
+
+// GasCost is an estimate of the gas used by a call to verifyProof, on the contract of ExportSolidity
+//
+// The precompiles are most of the cost: 181000 gas for the pairing check, and 6150 gas per public input
+// for vk_x = Σ input[i].[Kvk(i)]1. The other costs are estimated from the gas schedule and the code of
+// the contract; to measure the gas used, run the test of ExportSolidityTest with forge test --gas-report.
+type GasCost struct {
+	Transaction uint64 // base cost of the transaction, if verifyProof is called by a transaction
+	Calldata    uint64 // the arguments of the transaction
+	Pairing     uint64
+	ECMul       uint64
+	ECAdd       uint64
+	Calls       uint64 // the STATICCALLs to the precompiles
+	Memory      uint64
+	Execution   uint64 // the other opcodes
+}
+
+// Total returns the gas used by a transaction calling verifyProof
+func (c GasCost) Total() uint64 {
+	return c.Transaction + c.Calldata + c.Call()
+}
+
+// Call returns the gas used by verifyProof, when it is called by another contract (without the
+// transaction and its calldata)
+func (c GasCost) Call() uint64 {
+	return c.Pairing + c.ECMul + c.ECAdd + c.Calls + c.Memory + c.Execution
+}
+
+func (c GasCost) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "total: %d gas\n", c.Total())
+	for _, line := range []struct {
+		name string
+		gas  uint64
+	}{
+		{"transaction", c.Transaction},
+		{"calldata", c.Calldata},
+		{"pairing", c.Pairing},
+		{"ecMul", c.ECMul},
+		{"ecAdd", c.ECAdd},
+		{"calls", c.Calls},
+		{"memory", c.Memory},
+		{"execution", c.Execution},
+	} {
+		fmt.Fprintf(&sb, "  %-12s %d\n", line.name, line.gas)
+	}
+	return sb.String()
+}
+
+// EstimateGas returns the gas used by verifyProof for the public inputs of vk, with calldata of
+// random field elements (non zero bytes)
+func EstimateGas(vk groth16.VerifyingKey) (GasCost, error) {
+	_vk, ok := vk.(*groth16_bn256.VerifyingKey)
+	if !ok {
+		return GasCost{}, errCurve
+	}
+	return EstimateGasInputs(len(_vk.PublicInputs) - 1), nil
+}
+
+// EstimateGasInputs returns the gas used by verifyProof for a number of public inputs (without the
+// constant wire), with calldata of random field elements (non zero bytes)
+func EstimateGasInputs(nbPublicInputs int) GasCost {
+	calldata := make([]byte, 4+32*(8+nbPublicInputs))
+	for i := range calldata {
+		calldata[i] = 0xff
+	}
+	return estimateGas(nbPublicInputs, calldata)
+}
+
+// EstimateGasOfCall returns the gas used by verifyProof for a proof and its public inputs: small
+// public inputs have cheaper calldata (zero bytes)
+func EstimateGasOfCall(vk groth16.VerifyingKey, proof groth16.Proof, publicWitness interface{}) (GasCost, error) {
+	_proof, err := NewProof(proof)
+	if err != nil {
+		return GasCost{}, err
+	}
+	inputs, err := PublicInputs(vk, publicWitness)
+	if err != nil {
+		return GasCost{}, err
+	}
+	// the selector is 4 non zero bytes
+	calldata := append([]byte{0xff, 0xff, 0xff, 0xff}, _proof.Bytes()...)
+	for _, v := range inputs {
+		calldata = appendWord(calldata, v)
+	}
+	return estimateGas(len(inputs), calldata), nil
+}
+
+func estimateGas(nbPublicInputs int, calldata []byte) GasCost {
+	n := uint64(nbPublicInputs)
+	c := GasCost{
+		Transaction: gasTransaction,
+		Pairing:     gasPairingBase + 4*gasPairingPerPair,
+		ECMul:       n * gasECMul,
+		ECAdd:       n * gasECAdd,
+		Calls:       (2*n + 1) * gasStaticCall,
+		Execution:   gasExecutionBase + n*gasExecutionPerInput,
+	}
+	for _, b := range calldata {
+		if b == 0 {
+			c.Calldata += gasCalldataZero
+		} else {
+			c.Calldata += gasCalldataNonZero
+		}
+	}
+	words := memoryWordsBase + n*memoryWordsPerInput
+	c.Memory = gasMemoryWord*words + words*words/512
+	return c
+}
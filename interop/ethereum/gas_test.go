@@ -0,0 +1,75 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ethereum
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gurvy"
+)
+
+func TestEstimateGas(t *testing.T) {
+	var circuit cubicCircuit
+	r1cs, err := frontend.Compile(gurvy.BN256, &circuit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, vk, err := groth16.Setup(r1cs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var witness cubicCircuit
+	witness.X.Assign(3)
+	witness.Y.Assign(35)
+	witness.Z.Assign(6)
+	proof, err := groth16.Prove(r1cs, pk, &witness)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cost, err := EstimateGas(vk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cost != EstimateGasInputs(2) {
+		t.Fatal("the verifying key has 2 public inputs")
+	}
+	if cost.Pairing != 181000 || cost.ECMul != 12000 || cost.ECAdd != 300 || cost.Calldata != 16*(4+32*10) {
+		t.Fatal("unexpected cost of the precompiles or the calldata", cost)
+	}
+	if cost.Total() != cost.Transaction+cost.Calldata+cost.Call() {
+		t.Fatal("total doesn't add up", cost)
+	}
+
+	// the public inputs 35 and 6 are mostly zero bytes
+	call, err := EstimateGasOfCall(vk, proof, &witness)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if call.Call() != cost.Call() || call.Calldata >= cost.Calldata {
+		t.Fatal("only the calldata depends on the values", call, cost)
+	}
+
+	// the cost of a public input grows with the memory of the contract
+	zero, one, many := EstimateGasInputs(0), EstimateGasInputs(1), EstimateGasInputs(1000)
+	perInput := one.Total() - zero.Total()
+	if perInput < 6150 || many.Total() <= zero.Total()+1000*perInput {
+		t.Fatal("unexpected cost of the public inputs", zero, one, many)
+	}
+}
@@ -0,0 +1,272 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ethereum
+
+import (
+	"encoding/json"
+	"io"
+	"math/big"
+	"text/template"
+
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
+	groth16_bn256 "github.com/consensys/gnark/internal/backend/bn256/groth16"
+	"github.com/consensys/gurvy/bn256"
+	"github.com/consensys/gurvy/bn256/fp"
+	"github.com/consensys/gurvy/bn256/fr"
+)
+
+// ExportSolidity writes a Solidity verifier contract for vk
+//
+// The contract exposes
+//
+//	function verifyProof(uint256[2] memory a, uint256[2][2] memory b, uint256[2] memory c, uint256[N] memory input) public view returns (bool)
+//
+// where (a, b, c) are the fields of Proof and input is the output of PublicInputs (the input parameter
+// is omitted for circuits without public inputs). It checks
+// e(a, b) * e(c, -[δ]2) * e(Σ input[i].[Kvk(i)]1, -[γ]2) * e(-[α]1, [β]2) == 1 with the pairing precompile.
+func ExportSolidity(w io.Writer, vk groth16.VerifyingKey) error {
+	data, err := newSolidityData(vk)
+	if err != nil {
+		return err
+	}
+	return tmplVerifier.Execute(w, data)
+}
+
+// ExportSolidityTest writes a Foundry test of the contract written by ExportSolidity, with a proof and
+// its public inputs as fixture
+//
+// The test imports the verifier from "./Verifier.sol". It checks the proof is accepted, and rejected
+// once a public input is modified.
+func ExportSolidityTest(w io.Writer, vk groth16.VerifyingKey, proof groth16.Proof, publicWitness interface{}) error {
+	data, err := newSolidityData(vk)
+	if err != nil {
+		return err
+	}
+	if data.Proof, err = NewProof(proof); err != nil {
+		return err
+	}
+	if data.Inputs, err = PublicInputs(vk, publicWitness); err != nil {
+		return err
+	}
+	return tmplTest.Execute(w, data)
+}
+
+// Fixture is a proof and its public inputs, as decimal strings in the argument layout of the verifier
+// contract; it is meant to be loaded by JavaScript tests (Hardhat, Truffle)
+type Fixture struct {
+	A     [2]string    `json:"a"`
+	B     [2][2]string `json:"b"`
+	C     [2]string    `json:"c"`
+	Input []string     `json:"input"`
+}
+
+// WriteFixture writes the JSON Fixture of the proof and its public inputs
+func WriteFixture(w io.Writer, vk groth16.VerifyingKey, proof groth16.Proof, publicWitness interface{}) error {
+	_proof, err := NewProof(proof)
+	if err != nil {
+		return err
+	}
+	inputs, err := PublicInputs(vk, publicWitness)
+	if err != nil {
+		return err
+	}
+	var fixture Fixture
+	for i := 0; i < 2; i++ {
+		fixture.A[i] = _proof.A[i].String()
+		fixture.C[i] = _proof.C[i].String()
+		for j := 0; j < 2; j++ {
+			fixture.B[i][j] = _proof.B[i][j].String()
+		}
+	}
+	fixture.Input = make([]string, len(inputs))
+	for i, v := range inputs {
+		fixture.Input[i] = v.String()
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(fixture)
+}
+
+type solidityData struct {
+	AlphaNeg          [2]*big.Int
+	Beta              [4]*big.Int
+	GammaNeg          [4]*big.Int
+	DeltaNeg          [4]*big.Int
+	K0                [2]*big.Int   // constant term, coefficient of backend.OneWire
+	K                 [][2]*big.Int // coefficients of the public inputs
+	Proof             *Proof
+	Inputs            []*big.Int
+	ScalarField, Base string
+}
+
+func newSolidityData(vk groth16.VerifyingKey) (*solidityData, error) {
+	_vk, ok := vk.(*groth16_bn256.VerifyingKey)
+	if !ok {
+		return nil, errCurve
+	}
+	if groth16.HasCommitment(vk) {
+		return nil, groth16.ErrCommitment
+	}
+	data := &solidityData{
+		Beta:        g2Words(&_vk.G2.Beta),
+		GammaNeg:    g2Words(&_vk.G2.GammaNeg),
+		DeltaNeg:    g2Words(&_vk.G2.DeltaNeg),
+		ScalarField: fr.Modulus().String(),
+		Base:        fp.Modulus().String(),
+	}
+	var alphaNeg bn256.G1Affine
+	alphaNeg.Neg(&_vk.G1.Alpha)
+	data.AlphaNeg = g1Words(&alphaNeg)
+	for i, name := range _vk.PublicInputs {
+		if name == backend.OneWire {
+			data.K0 = g1Words(&_vk.G1.K[i])
+		} else {
+			data.K = append(data.K, g1Words(&_vk.G1.K[i]))
+		}
+	}
+	return data, nil
+}
+
+const solidityHeader = `// SPDX-License-Identifier: Apache-2.0
+// Code generated by gnark. DO NOT EDIT.
+
+pragma solidity ^0.8.0;
+`
+
+var tmplVerifier = template.Must(template.New("verifier").Funcs(template.FuncMap{"add": add}).Parse(solidityHeader + `
+/// @title Groth16 verifier over BN254, generated by gnark
+contract Verifier {
+    uint256 constant SCALAR_FIELD = {{.ScalarField}};
+
+    // -[α]1
+    uint256 constant ALPHA_NEG_X = {{index .AlphaNeg 0}};
+    uint256 constant ALPHA_NEG_Y = {{index .AlphaNeg 1}};
+
+    // [β]2, -[γ]2, -[δ]2 as (x.A1, x.A0, y.A1, y.A0)
+    uint256 constant BETA_X1 = {{index .Beta 0}};
+    uint256 constant BETA_X0 = {{index .Beta 1}};
+    uint256 constant BETA_Y1 = {{index .Beta 2}};
+    uint256 constant BETA_Y0 = {{index .Beta 3}};
+    uint256 constant GAMMA_NEG_X1 = {{index .GammaNeg 0}};
+    uint256 constant GAMMA_NEG_X0 = {{index .GammaNeg 1}};
+    uint256 constant GAMMA_NEG_Y1 = {{index .GammaNeg 2}};
+    uint256 constant GAMMA_NEG_Y0 = {{index .GammaNeg 3}};
+    uint256 constant DELTA_NEG_X1 = {{index .DeltaNeg 0}};
+    uint256 constant DELTA_NEG_X0 = {{index .DeltaNeg 1}};
+    uint256 constant DELTA_NEG_Y1 = {{index .DeltaNeg 2}};
+    uint256 constant DELTA_NEG_Y0 = {{index .DeltaNeg 3}};
+
+    // [Kvk]1
+    uint256 constant K0_X = {{index .K0 0}};
+    uint256 constant K0_Y = {{index .K0 1}};
+{{- range $i, $k := .K}}
+    uint256 constant K{{add $i 1}}_X = {{index $k 0}};
+    uint256 constant K{{add $i 1}}_Y = {{index $k 1}};
+{{- end}}
+
+    function verifyProof(
+        uint256[2] memory a,
+        uint256[2][2] memory b,
+        uint256[2] memory c{{if .K}},
+        uint256[{{len .K}}] memory input{{end}}
+    ) public view returns (bool) {
+        // Σ input[i].[Kvk(i)]1
+        uint256[2] memory k = [K0_X, K0_Y];
+{{- range $i, $k := .K}}
+        require(input[{{$i}}] < SCALAR_FIELD, "verifier: input out of field");
+        k = ecAdd(k, ecMul([K{{add $i 1}}_X, K{{add $i 1}}_Y], input[{{$i}}]));
+{{- end}}
+
+        uint256[24] memory p;
+        p[0] = a[0]; p[1] = a[1]; p[2] = b[0][0]; p[3] = b[0][1]; p[4] = b[1][0]; p[5] = b[1][1];
+        p[6] = c[0]; p[7] = c[1]; p[8] = DELTA_NEG_X1; p[9] = DELTA_NEG_X0; p[10] = DELTA_NEG_Y1; p[11] = DELTA_NEG_Y0;
+        p[12] = k[0]; p[13] = k[1]; p[14] = GAMMA_NEG_X1; p[15] = GAMMA_NEG_X0; p[16] = GAMMA_NEG_Y1; p[17] = GAMMA_NEG_Y0;
+        p[18] = ALPHA_NEG_X; p[19] = ALPHA_NEG_Y; p[20] = BETA_X1; p[21] = BETA_X0; p[22] = BETA_Y1; p[23] = BETA_Y0;
+        uint256[1] memory out;
+        bool success;
+        assembly {
+            success := staticcall(gas(), 0x08, p, 768, out, 0x20)
+        }
+        return success && out[0] == 1;
+    }
+
+    function ecAdd(uint256[2] memory p, uint256[2] memory q) internal view returns (uint256[2] memory r) {
+        uint256[4] memory input = [p[0], p[1], q[0], q[1]];
+        bool success;
+        assembly {
+            success := staticcall(gas(), 0x06, input, 0x80, r, 0x40)
+        }
+        require(success, "verifier: ecAdd failed");
+    }
+
+    function ecMul(uint256[2] memory p, uint256 s) internal view returns (uint256[2] memory r) {
+        uint256[3] memory input = [p[0], p[1], s];
+        bool success;
+        assembly {
+            success := staticcall(gas(), 0x07, input, 0x60, r, 0x40)
+        }
+        require(success, "verifier: ecMul failed");
+    }
+}
+`))
+
+var tmplTest = template.Must(template.New("test").Parse(solidityHeader + `
+import "forge-std/Test.sol";
+import "./Verifier.sol";
+
+contract VerifierTest is Test {
+    Verifier verifier;
+
+    function setUp() public {
+        verifier = new Verifier();
+    }
+
+    function fixture() internal pure returns (uint256[2] memory a, uint256[2][2] memory b, uint256[2] memory c{{if .Inputs}}, uint256[{{len .Inputs}}] memory input{{end}}) {
+        a = [uint256({{index .Proof.A 0}}), {{index .Proof.A 1}}];
+        b = [
+            [uint256({{index .Proof.B 0 0}}), {{index .Proof.B 0 1}}],
+            [uint256({{index .Proof.B 1 0}}), {{index .Proof.B 1 1}}]
+        ];
+        c = [uint256({{index .Proof.C 0}}), {{index .Proof.C 1}}];
+{{- range $i, $v := .Inputs}}
+        input[{{$i}}] = {{$v}};
+{{- end}}
+    }
+
+    function testValidProof() public {
+        (uint256[2] memory a, uint256[2][2] memory b, uint256[2] memory c{{if .Inputs}}, uint256[{{len .Inputs}}] memory input{{end}}) = fixture();
+        assertTrue(verifier.verifyProof(a, b, c{{if .Inputs}}, input{{end}}));
+    }
+
+    function testInvalidProof() public {
+        (uint256[2] memory a, uint256[2][2] memory b, uint256[2] memory c{{if .Inputs}}, uint256[{{len .Inputs}}] memory input{{end}}) = fixture();
+{{- if .Inputs}}
+        input[0] = addmod(input[0], 1, {{.ScalarField}});
+        assertFalse(verifier.verifyProof(a, b, c, input));
+{{- else}}
+        // -c is on the curve, the pairing check must fail
+        c[1] = {{.Base}} - c[1];
+        assertFalse(verifier.verifyProof(a, b, c));
+{{- end}}
+    }
+}
+`))
+
+func add(a, b int) int {
+	return a + b
+}
@@ -0,0 +1,145 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ethereum
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gurvy"
+	"github.com/consensys/gurvy/bn256"
+)
+
+var reConstant = regexp.MustCompile(`uint256 constant (\w+) = (\d+);`)
+
+// verifyContract mirrors verifyProof on the constants of the contract
+func verifyContract(t *testing.T, contract string, fixture Fixture) bool {
+	constants := make(map[string]*big.Int)
+	for _, m := range reConstant.FindAllStringSubmatch(contract, -1) {
+		constants[m[1]], _ = new(big.Int).SetString(m[2], 10)
+	}
+	g1 := func(x, y *big.Int) bn256.G1Affine {
+		var p bn256.G1Affine
+		p.X.SetBigInt(x)
+		p.Y.SetBigInt(y)
+		return p
+	}
+	c := func(name string) *big.Int {
+		v, ok := constants[name]
+		if !ok {
+			t.Fatal("missing constant", name)
+		}
+		return v
+	}
+	n := func(s string) *big.Int {
+		v, _ := new(big.Int).SetString(s, 10)
+		return v
+	}
+
+	k0 := g1(c("K0_X"), c("K0_Y"))
+	var k, tmp bn256.G1Jac
+	k.FromAffine(&k0)
+	for i, v := range fixture.Input {
+		ki := g1(c(fmt.Sprintf("K%d_X", i+1)), c(fmt.Sprintf("K%d_Y", i+1)))
+		tmp.ScalarMultiplication(new(bn256.G1Jac).FromAffine(&ki), n(v))
+		k.AddAssign(&tmp)
+	}
+	var kAff bn256.G1Affine
+	kAff.FromJacobian(&k)
+
+	input := bytes.Join([][]byte{
+		appendWord(nil, n(fixture.A[0])), appendWord(nil, n(fixture.A[1])),
+		appendWord(nil, n(fixture.B[0][0])), appendWord(nil, n(fixture.B[0][1])),
+		appendWord(nil, n(fixture.B[1][0])), appendWord(nil, n(fixture.B[1][1])),
+		appendWord(nil, n(fixture.C[0])), appendWord(nil, n(fixture.C[1])),
+	}, nil)
+	for _, name := range []string{
+		"DELTA_NEG_X1", "DELTA_NEG_X0", "DELTA_NEG_Y1", "DELTA_NEG_Y0",
+	} {
+		input = appendWord(input, c(name))
+	}
+	input = append(input, EncodeG1(&kAff)...)
+	for _, name := range []string{
+		"GAMMA_NEG_X1", "GAMMA_NEG_X0", "GAMMA_NEG_Y1", "GAMMA_NEG_Y0",
+		"ALPHA_NEG_X", "ALPHA_NEG_Y", "BETA_X1", "BETA_X0", "BETA_Y1", "BETA_Y0",
+	} {
+		input = appendWord(input, c(name))
+	}
+	return precompile(t, input)
+}
+
+func TestExportSolidity(t *testing.T) {
+	var circuit cubicCircuit
+	r1cs, err := frontend.Compile(gurvy.BN256, &circuit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, vk, err := groth16.Setup(r1cs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var witness cubicCircuit
+	witness.X.Assign(3)
+	witness.Y.Assign(35)
+	witness.Z.Assign(6)
+	proof, err := groth16.Prove(r1cs, pk, &witness)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var contract, test, fixtureJSON bytes.Buffer
+	if err := ExportSolidity(&contract, vk); err != nil {
+		t.Fatal(err)
+	}
+	if err := ExportSolidityTest(&test, vk, proof, &witness); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFixture(&fixtureJSON, vk, proof, &witness); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(contract.String(), "uint256[2] memory input") {
+		t.Fatal("expected 2 public inputs in verifyProof")
+	}
+
+	var fixture Fixture
+	if err := json.Unmarshal(fixtureJSON.Bytes(), &fixture); err != nil {
+		t.Fatal(err)
+	}
+	if len(fixture.Input) != 2 || fixture.Input[0] != "35" || fixture.Input[1] != "6" {
+		t.Fatal("unexpected public inputs", fixture.Input)
+	}
+	for _, v := range []string{fixture.A[0], fixture.B[1][1], fixture.C[1], "input[1] = 6;"} {
+		if !strings.Contains(test.String(), v) {
+			t.Fatal("test fixture doesn't match the proof")
+		}
+	}
+
+	if !verifyContract(t, contract.String(), fixture) {
+		t.Fatal("the contract rejects a valid proof")
+	}
+	fixture.Input[0] = "36"
+	if verifyContract(t, contract.String(), fixture) {
+		t.Fatal("the contract accepts an invalid proof")
+	}
+}
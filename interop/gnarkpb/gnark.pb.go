@@ -0,0 +1,926 @@
+// Copyright © 2020 ConsenSys
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Wire format of gnark Groth16 proofs, verifying keys, public inputs and witness schemas.
+//
+// Field elements are encoded in big endian, in regular (non Montgomery) form, on a fixed number of
+// bytes: 32 for BN254, 48 for BLS12-377 and BLS12-381, 96 for BW6-761. Base field elements must be
+// smaller than the modulus. Scalars (public input values) are encoded on the size of the scalar
+// field: 32 bytes for BN254, BLS12-377 and BLS12-381, 48 for BW6-761.
+//
+// Points are affine. The point at infinity has all its coordinates set to zero.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.25.0
+// 	protoc        (unknown)
+// source: gnark.proto
+
+package gnarkpb
+
+import (
+	proto "github.com/golang/protobuf/proto"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// This is a compile-time assertion that a sufficiently up-to-date version
+// of the legacy proto package is being used.
+const _ = proto.ProtoPackageIsVersion4
+
+type Curve int32
+
+const (
+	Curve_CURVE_UNSPECIFIED Curve = 0
+	Curve_BN254             Curve = 1 // gurvy.BN256
+	Curve_BLS12_377         Curve = 2 // gurvy.BLS377
+	Curve_BLS12_381         Curve = 3 // gurvy.BLS381
+	Curve_BW6_761           Curve = 4 // gurvy.BW761
+)
+
+// Enum value maps for Curve.
+var (
+	Curve_name = map[int32]string{
+		0: "CURVE_UNSPECIFIED",
+		1: "BN254",
+		2: "BLS12_377",
+		3: "BLS12_381",
+		4: "BW6_761",
+	}
+	Curve_value = map[string]int32{
+		"CURVE_UNSPECIFIED": 0,
+		"BN254":             1,
+		"BLS12_377":         2,
+		"BLS12_381":         3,
+		"BW6_761":           4,
+	}
+)
+
+func (x Curve) Enum() *Curve {
+	p := new(Curve)
+	*p = x
+	return p
+}
+
+func (x Curve) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Curve) Descriptor() protoreflect.EnumDescriptor {
+	return file_gnark_proto_enumTypes[0].Descriptor()
+}
+
+func (Curve) Type() protoreflect.EnumType {
+	return &file_gnark_proto_enumTypes[0]
+}
+
+func (x Curve) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Curve.Descriptor instead.
+func (Curve) EnumDescriptor() ([]byte, []int) {
+	return file_gnark_proto_rawDescGZIP(), []int{0}
+}
+
+type Visibility int32
+
+const (
+	Visibility_VISIBILITY_UNSPECIFIED Visibility = 0
+	Visibility_SECRET                 Visibility = 1
+	Visibility_PUBLIC                 Visibility = 2
+)
+
+// Enum value maps for Visibility.
+var (
+	Visibility_name = map[int32]string{
+		0: "VISIBILITY_UNSPECIFIED",
+		1: "SECRET",
+		2: "PUBLIC",
+	}
+	Visibility_value = map[string]int32{
+		"VISIBILITY_UNSPECIFIED": 0,
+		"SECRET":                 1,
+		"PUBLIC":                 2,
+	}
+)
+
+func (x Visibility) Enum() *Visibility {
+	p := new(Visibility)
+	*p = x
+	return p
+}
+
+func (x Visibility) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Visibility) Descriptor() protoreflect.EnumDescriptor {
+	return file_gnark_proto_enumTypes[1].Descriptor()
+}
+
+func (Visibility) Type() protoreflect.EnumType {
+	return &file_gnark_proto_enumTypes[1]
+}
+
+func (x Visibility) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Visibility.Descriptor instead.
+func (Visibility) EnumDescriptor() ([]byte, []int) {
+	return file_gnark_proto_rawDescGZIP(), []int{1}
+}
+
+// G1Point is a point of the G1 group, over the base field
+type G1Point struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	X []byte `protobuf:"bytes,1,opt,name=x,proto3" json:"x,omitempty"`
+	Y []byte `protobuf:"bytes,2,opt,name=y,proto3" json:"y,omitempty"`
+}
+
+func (x *G1Point) Reset() {
+	*x = G1Point{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gnark_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *G1Point) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*G1Point) ProtoMessage() {}
+
+func (x *G1Point) ProtoReflect() protoreflect.Message {
+	mi := &file_gnark_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use G1Point.ProtoReflect.Descriptor instead.
+func (*G1Point) Descriptor() ([]byte, []int) {
+	return file_gnark_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *G1Point) GetX() []byte {
+	if x != nil {
+		return x.X
+	}
+	return nil
+}
+
+func (x *G1Point) GetY() []byte {
+	if x != nil {
+		return x.Y
+	}
+	return nil
+}
+
+// G2Point is a point of the G2 group, over a quadratic extension of the base field for BN254,
+// BLS12-377 and BLS12-381 (x = x0 + x1*u), and over the base field for BW6-761 (x1 and y1 are empty)
+type G2Point struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	X0 []byte `protobuf:"bytes,1,opt,name=x0,proto3" json:"x0,omitempty"`
+	X1 []byte `protobuf:"bytes,2,opt,name=x1,proto3" json:"x1,omitempty"`
+	Y0 []byte `protobuf:"bytes,3,opt,name=y0,proto3" json:"y0,omitempty"`
+	Y1 []byte `protobuf:"bytes,4,opt,name=y1,proto3" json:"y1,omitempty"`
+}
+
+func (x *G2Point) Reset() {
+	*x = G2Point{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gnark_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *G2Point) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*G2Point) ProtoMessage() {}
+
+func (x *G2Point) ProtoReflect() protoreflect.Message {
+	mi := &file_gnark_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use G2Point.ProtoReflect.Descriptor instead.
+func (*G2Point) Descriptor() ([]byte, []int) {
+	return file_gnark_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *G2Point) GetX0() []byte {
+	if x != nil {
+		return x.X0
+	}
+	return nil
+}
+
+func (x *G2Point) GetX1() []byte {
+	if x != nil {
+		return x.X1
+	}
+	return nil
+}
+
+func (x *G2Point) GetY0() []byte {
+	if x != nil {
+		return x.Y0
+	}
+	return nil
+}
+
+func (x *G2Point) GetY1() []byte {
+	if x != nil {
+		return x.Y1
+	}
+	return nil
+}
+
+// Proof is a Groth16 proof
+type Proof struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Curve Curve    `protobuf:"varint,1,opt,name=curve,proto3,enum=gnark.v1.Curve" json:"curve,omitempty"`
+	A     *G1Point `protobuf:"bytes,2,opt,name=a,proto3" json:"a,omitempty"`
+	B     *G2Point `protobuf:"bytes,3,opt,name=b,proto3" json:"b,omitempty"`
+	C     *G1Point `protobuf:"bytes,4,opt,name=c,proto3" json:"c,omitempty"`
+}
+
+func (x *Proof) Reset() {
+	*x = Proof{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gnark_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Proof) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Proof) ProtoMessage() {}
+
+func (x *Proof) ProtoReflect() protoreflect.Message {
+	mi := &file_gnark_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Proof.ProtoReflect.Descriptor instead.
+func (*Proof) Descriptor() ([]byte, []int) {
+	return file_gnark_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Proof) GetCurve() Curve {
+	if x != nil {
+		return x.Curve
+	}
+	return Curve_CURVE_UNSPECIFIED
+}
+
+func (x *Proof) GetA() *G1Point {
+	if x != nil {
+		return x.A
+	}
+	return nil
+}
+
+func (x *Proof) GetB() *G2Point {
+	if x != nil {
+		return x.B
+	}
+	return nil
+}
+
+func (x *Proof) GetC() *G1Point {
+	if x != nil {
+		return x.C
+	}
+	return nil
+}
+
+// VerifyingKey is a Groth16 verifying key
+//
+// A proof is valid if e(a, b) * e(c, delta_neg) * e(Σ x_i.k_i, gamma_neg) == e(alpha, beta), where the
+// x_i are the values of public_inputs, the constant input "ONE_WIRE" being 1
+type VerifyingKey struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Curve        Curve      `protobuf:"varint,1,opt,name=curve,proto3,enum=gnark.v1.Curve" json:"curve,omitempty"`
+	PublicInputs []string   `protobuf:"bytes,2,rep,name=public_inputs,json=publicInputs,proto3" json:"public_inputs,omitempty"`
+	Alpha        *G1Point   `protobuf:"bytes,3,opt,name=alpha,proto3" json:"alpha,omitempty"`
+	Beta         *G2Point   `protobuf:"bytes,4,opt,name=beta,proto3" json:"beta,omitempty"`
+	GammaNeg     *G2Point   `protobuf:"bytes,5,opt,name=gamma_neg,json=gammaNeg,proto3" json:"gamma_neg,omitempty"`
+	DeltaNeg     *G2Point   `protobuf:"bytes,6,opt,name=delta_neg,json=deltaNeg,proto3" json:"delta_neg,omitempty"`
+	K            []*G1Point `protobuf:"bytes,7,rep,name=k,proto3" json:"k,omitempty"` // k[i] is the point of public_inputs[i]
+}
+
+func (x *VerifyingKey) Reset() {
+	*x = VerifyingKey{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gnark_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *VerifyingKey) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyingKey) ProtoMessage() {}
+
+func (x *VerifyingKey) ProtoReflect() protoreflect.Message {
+	mi := &file_gnark_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyingKey.ProtoReflect.Descriptor instead.
+func (*VerifyingKey) Descriptor() ([]byte, []int) {
+	return file_gnark_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *VerifyingKey) GetCurve() Curve {
+	if x != nil {
+		return x.Curve
+	}
+	return Curve_CURVE_UNSPECIFIED
+}
+
+func (x *VerifyingKey) GetPublicInputs() []string {
+	if x != nil {
+		return x.PublicInputs
+	}
+	return nil
+}
+
+func (x *VerifyingKey) GetAlpha() *G1Point {
+	if x != nil {
+		return x.Alpha
+	}
+	return nil
+}
+
+func (x *VerifyingKey) GetBeta() *G2Point {
+	if x != nil {
+		return x.Beta
+	}
+	return nil
+}
+
+func (x *VerifyingKey) GetGammaNeg() *G2Point {
+	if x != nil {
+		return x.GammaNeg
+	}
+	return nil
+}
+
+func (x *VerifyingKey) GetDeltaNeg() *G2Point {
+	if x != nil {
+		return x.DeltaNeg
+	}
+	return nil
+}
+
+func (x *VerifyingKey) GetK() []*G1Point {
+	if x != nil {
+		return x.K
+	}
+	return nil
+}
+
+// Assignment is the value of an input of a circuit
+type Assignment struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name  string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Value []byte `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+func (x *Assignment) Reset() {
+	*x = Assignment{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gnark_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Assignment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Assignment) ProtoMessage() {}
+
+func (x *Assignment) ProtoReflect() protoreflect.Message {
+	mi := &file_gnark_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Assignment.ProtoReflect.Descriptor instead.
+func (*Assignment) Descriptor() ([]byte, []int) {
+	return file_gnark_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Assignment) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Assignment) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+// PublicInputs are the public inputs of a proof, without the constant input "ONE_WIRE"
+type PublicInputs struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Curve  Curve         `protobuf:"varint,1,opt,name=curve,proto3,enum=gnark.v1.Curve" json:"curve,omitempty"`
+	Inputs []*Assignment `protobuf:"bytes,2,rep,name=inputs,proto3" json:"inputs,omitempty"`
+}
+
+func (x *PublicInputs) Reset() {
+	*x = PublicInputs{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gnark_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PublicInputs) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PublicInputs) ProtoMessage() {}
+
+func (x *PublicInputs) ProtoReflect() protoreflect.Message {
+	mi := &file_gnark_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PublicInputs.ProtoReflect.Descriptor instead.
+func (*PublicInputs) Descriptor() ([]byte, []int) {
+	return file_gnark_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *PublicInputs) GetCurve() Curve {
+	if x != nil {
+		return x.Curve
+	}
+	return Curve_CURVE_UNSPECIFIED
+}
+
+func (x *PublicInputs) GetInputs() []*Assignment {
+	if x != nil {
+		return x.Inputs
+	}
+	return nil
+}
+
+// WitnessSchema lists the inputs of a circuit, that a witness assigns
+type WitnessSchema struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Curve  Curve                  `protobuf:"varint,1,opt,name=curve,proto3,enum=gnark.v1.Curve" json:"curve,omitempty"`
+	Inputs []*WitnessSchema_Input `protobuf:"bytes,2,rep,name=inputs,proto3" json:"inputs,omitempty"`
+}
+
+func (x *WitnessSchema) Reset() {
+	*x = WitnessSchema{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gnark_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WitnessSchema) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WitnessSchema) ProtoMessage() {}
+
+func (x *WitnessSchema) ProtoReflect() protoreflect.Message {
+	mi := &file_gnark_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WitnessSchema.ProtoReflect.Descriptor instead.
+func (*WitnessSchema) Descriptor() ([]byte, []int) {
+	return file_gnark_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *WitnessSchema) GetCurve() Curve {
+	if x != nil {
+		return x.Curve
+	}
+	return Curve_CURVE_UNSPECIFIED
+}
+
+func (x *WitnessSchema) GetInputs() []*WitnessSchema_Input {
+	if x != nil {
+		return x.Inputs
+	}
+	return nil
+}
+
+type WitnessSchema_Input struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name       string     `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Visibility Visibility `protobuf:"varint,2,opt,name=visibility,proto3,enum=gnark.v1.Visibility" json:"visibility,omitempty"`
+}
+
+func (x *WitnessSchema_Input) Reset() {
+	*x = WitnessSchema_Input{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gnark_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WitnessSchema_Input) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WitnessSchema_Input) ProtoMessage() {}
+
+func (x *WitnessSchema_Input) ProtoReflect() protoreflect.Message {
+	mi := &file_gnark_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WitnessSchema_Input.ProtoReflect.Descriptor instead.
+func (*WitnessSchema_Input) Descriptor() ([]byte, []int) {
+	return file_gnark_proto_rawDescGZIP(), []int{6, 0}
+}
+
+func (x *WitnessSchema_Input) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *WitnessSchema_Input) GetVisibility() Visibility {
+	if x != nil {
+		return x.Visibility
+	}
+	return Visibility_VISIBILITY_UNSPECIFIED
+}
+
+var File_gnark_proto protoreflect.FileDescriptor
+
+var file_gnark_proto_rawDesc = []byte{
+	0x0a, 0x0b, 0x67, 0x6e, 0x61, 0x72, 0x6b, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x08, 0x67,
+	0x6e, 0x61, 0x72, 0x6b, 0x2e, 0x76, 0x31, 0x22, 0x25, 0x0a, 0x07, 0x47, 0x31, 0x50, 0x6f, 0x69,
+	0x6e, 0x74, 0x12, 0x0c, 0x0a, 0x01, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x01, 0x78,
+	0x12, 0x0c, 0x0a, 0x01, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x01, 0x79, 0x22, 0x49,
+	0x0a, 0x07, 0x47, 0x32, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x78, 0x30, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x02, 0x78, 0x30, 0x12, 0x0e, 0x0a, 0x02, 0x78, 0x31, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x02, 0x78, 0x31, 0x12, 0x0e, 0x0a, 0x02, 0x79, 0x30, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x02, 0x79, 0x30, 0x12, 0x0e, 0x0a, 0x02, 0x79, 0x31, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x02, 0x79, 0x31, 0x22, 0x91, 0x01, 0x0a, 0x05, 0x50, 0x72,
+	0x6f, 0x6f, 0x66, 0x12, 0x25, 0x0a, 0x05, 0x63, 0x75, 0x72, 0x76, 0x65, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0e, 0x32, 0x0f, 0x2e, 0x67, 0x6e, 0x61, 0x72, 0x6b, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x75,
+	0x72, 0x76, 0x65, 0x52, 0x05, 0x63, 0x75, 0x72, 0x76, 0x65, 0x12, 0x1f, 0x0a, 0x01, 0x61, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x67, 0x6e, 0x61, 0x72, 0x6b, 0x2e, 0x76, 0x31,
+	0x2e, 0x47, 0x31, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x52, 0x01, 0x61, 0x12, 0x1f, 0x0a, 0x01, 0x62,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x67, 0x6e, 0x61, 0x72, 0x6b, 0x2e, 0x76,
+	0x31, 0x2e, 0x47, 0x32, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x52, 0x01, 0x62, 0x12, 0x1f, 0x0a, 0x01,
+	0x63, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x67, 0x6e, 0x61, 0x72, 0x6b, 0x2e,
+	0x76, 0x31, 0x2e, 0x47, 0x31, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x52, 0x01, 0x63, 0x22, 0xab, 0x02,
+	0x0a, 0x0c, 0x56, 0x65, 0x72, 0x69, 0x66, 0x79, 0x69, 0x6e, 0x67, 0x4b, 0x65, 0x79, 0x12, 0x25,
+	0x0a, 0x05, 0x63, 0x75, 0x72, 0x76, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0f, 0x2e,
+	0x67, 0x6e, 0x61, 0x72, 0x6b, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x75, 0x72, 0x76, 0x65, 0x52, 0x05,
+	0x63, 0x75, 0x72, 0x76, 0x65, 0x12, 0x23, 0x0a, 0x0d, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x5f,
+	0x69, 0x6e, 0x70, 0x75, 0x74, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0c, 0x70, 0x75,
+	0x62, 0x6c, 0x69, 0x63, 0x49, 0x6e, 0x70, 0x75, 0x74, 0x73, 0x12, 0x27, 0x0a, 0x05, 0x61, 0x6c,
+	0x70, 0x68, 0x61, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x67, 0x6e, 0x61, 0x72,
+	0x6b, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x31, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x52, 0x05, 0x61, 0x6c,
+	0x70, 0x68, 0x61, 0x12, 0x25, 0x0a, 0x04, 0x62, 0x65, 0x74, 0x61, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x11, 0x2e, 0x67, 0x6e, 0x61, 0x72, 0x6b, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x32, 0x50,
+	0x6f, 0x69, 0x6e, 0x74, 0x52, 0x04, 0x62, 0x65, 0x74, 0x61, 0x12, 0x2e, 0x0a, 0x09, 0x67, 0x61,
+	0x6d, 0x6d, 0x61, 0x5f, 0x6e, 0x65, 0x67, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e,
+	0x67, 0x6e, 0x61, 0x72, 0x6b, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x32, 0x50, 0x6f, 0x69, 0x6e, 0x74,
+	0x52, 0x08, 0x67, 0x61, 0x6d, 0x6d, 0x61, 0x4e, 0x65, 0x67, 0x12, 0x2e, 0x0a, 0x09, 0x64, 0x65,
+	0x6c, 0x74, 0x61, 0x5f, 0x6e, 0x65, 0x67, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e,
+	0x67, 0x6e, 0x61, 0x72, 0x6b, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x32, 0x50, 0x6f, 0x69, 0x6e, 0x74,
+	0x52, 0x08, 0x64, 0x65, 0x6c, 0x74, 0x61, 0x4e, 0x65, 0x67, 0x12, 0x1f, 0x0a, 0x01, 0x6b, 0x18,
+	0x07, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x67, 0x6e, 0x61, 0x72, 0x6b, 0x2e, 0x76, 0x31,
+	0x2e, 0x47, 0x31, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x52, 0x01, 0x6b, 0x22, 0x36, 0x0a, 0x0a, 0x41,
+	0x73, 0x73, 0x69, 0x67, 0x6e, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x14, 0x0a,
+	0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x76, 0x61,
+	0x6c, 0x75, 0x65, 0x22, 0x63, 0x0a, 0x0c, 0x50, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x49, 0x6e, 0x70,
+	0x75, 0x74, 0x73, 0x12, 0x25, 0x0a, 0x05, 0x63, 0x75, 0x72, 0x76, 0x65, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0e, 0x32, 0x0f, 0x2e, 0x67, 0x6e, 0x61, 0x72, 0x6b, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x75,
+	0x72, 0x76, 0x65, 0x52, 0x05, 0x63, 0x75, 0x72, 0x76, 0x65, 0x12, 0x2c, 0x0a, 0x06, 0x69, 0x6e,
+	0x70, 0x75, 0x74, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x67, 0x6e, 0x61,
+	0x72, 0x6b, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x73, 0x73, 0x69, 0x67, 0x6e, 0x6d, 0x65, 0x6e, 0x74,
+	0x52, 0x06, 0x69, 0x6e, 0x70, 0x75, 0x74, 0x73, 0x22, 0xc0, 0x01, 0x0a, 0x0d, 0x57, 0x69, 0x74,
+	0x6e, 0x65, 0x73, 0x73, 0x53, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x12, 0x25, 0x0a, 0x05, 0x63, 0x75,
+	0x72, 0x76, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0f, 0x2e, 0x67, 0x6e, 0x61, 0x72,
+	0x6b, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x75, 0x72, 0x76, 0x65, 0x52, 0x05, 0x63, 0x75, 0x72, 0x76,
+	0x65, 0x12, 0x35, 0x0a, 0x06, 0x69, 0x6e, 0x70, 0x75, 0x74, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x1d, 0x2e, 0x67, 0x6e, 0x61, 0x72, 0x6b, 0x2e, 0x76, 0x31, 0x2e, 0x57, 0x69, 0x74,
+	0x6e, 0x65, 0x73, 0x73, 0x53, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x49, 0x6e, 0x70, 0x75, 0x74,
+	0x52, 0x06, 0x69, 0x6e, 0x70, 0x75, 0x74, 0x73, 0x1a, 0x51, 0x0a, 0x05, 0x49, 0x6e, 0x70, 0x75,
+	0x74, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x34, 0x0a, 0x0a, 0x76, 0x69, 0x73, 0x69, 0x62, 0x69, 0x6c,
+	0x69, 0x74, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x14, 0x2e, 0x67, 0x6e, 0x61, 0x72,
+	0x6b, 0x2e, 0x76, 0x31, 0x2e, 0x56, 0x69, 0x73, 0x69, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x79, 0x52,
+	0x0a, 0x76, 0x69, 0x73, 0x69, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x79, 0x2a, 0x54, 0x0a, 0x05, 0x43,
+	0x75, 0x72, 0x76, 0x65, 0x12, 0x15, 0x0a, 0x11, 0x43, 0x55, 0x52, 0x56, 0x45, 0x5f, 0x55, 0x4e,
+	0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x09, 0x0a, 0x05, 0x42,
+	0x4e, 0x32, 0x35, 0x34, 0x10, 0x01, 0x12, 0x0d, 0x0a, 0x09, 0x42, 0x4c, 0x53, 0x31, 0x32, 0x5f,
+	0x33, 0x37, 0x37, 0x10, 0x02, 0x12, 0x0d, 0x0a, 0x09, 0x42, 0x4c, 0x53, 0x31, 0x32, 0x5f, 0x33,
+	0x38, 0x31, 0x10, 0x03, 0x12, 0x0b, 0x0a, 0x07, 0x42, 0x57, 0x36, 0x5f, 0x37, 0x36, 0x31, 0x10,
+	0x04, 0x2a, 0x40, 0x0a, 0x0a, 0x56, 0x69, 0x73, 0x69, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x79, 0x12,
+	0x1a, 0x0a, 0x16, 0x56, 0x49, 0x53, 0x49, 0x42, 0x49, 0x4c, 0x49, 0x54, 0x59, 0x5f, 0x55, 0x4e,
+	0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x0a, 0x0a, 0x06, 0x53,
+	0x45, 0x43, 0x52, 0x45, 0x54, 0x10, 0x01, 0x12, 0x0a, 0x0a, 0x06, 0x50, 0x55, 0x42, 0x4c, 0x49,
+	0x43, 0x10, 0x02, 0x42, 0x2c, 0x5a, 0x2a, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f,
+	0x6d, 0x2f, 0x63, 0x6f, 0x6e, 0x73, 0x65, 0x6e, 0x73, 0x79, 0x73, 0x2f, 0x67, 0x6e, 0x61, 0x72,
+	0x6b, 0x2f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6f, 0x70, 0x2f, 0x67, 0x6e, 0x61, 0x72, 0x6b, 0x70,
+	0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_gnark_proto_rawDescOnce sync.Once
+	file_gnark_proto_rawDescData = file_gnark_proto_rawDesc
+)
+
+func file_gnark_proto_rawDescGZIP() []byte {
+	file_gnark_proto_rawDescOnce.Do(func() {
+		file_gnark_proto_rawDescData = protoimpl.X.CompressGZIP(file_gnark_proto_rawDescData)
+	})
+	return file_gnark_proto_rawDescData
+}
+
+var file_gnark_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_gnark_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_gnark_proto_goTypes = []interface{}{
+	(Curve)(0),                  // 0: gnark.v1.Curve
+	(Visibility)(0),             // 1: gnark.v1.Visibility
+	(*G1Point)(nil),             // 2: gnark.v1.G1Point
+	(*G2Point)(nil),             // 3: gnark.v1.G2Point
+	(*Proof)(nil),               // 4: gnark.v1.Proof
+	(*VerifyingKey)(nil),        // 5: gnark.v1.VerifyingKey
+	(*Assignment)(nil),          // 6: gnark.v1.Assignment
+	(*PublicInputs)(nil),        // 7: gnark.v1.PublicInputs
+	(*WitnessSchema)(nil),       // 8: gnark.v1.WitnessSchema
+	(*WitnessSchema_Input)(nil), // 9: gnark.v1.WitnessSchema.Input
+}
+var file_gnark_proto_depIdxs = []int32{
+	0,  // 0: gnark.v1.Proof.curve:type_name -> gnark.v1.Curve
+	2,  // 1: gnark.v1.Proof.a:type_name -> gnark.v1.G1Point
+	3,  // 2: gnark.v1.Proof.b:type_name -> gnark.v1.G2Point
+	2,  // 3: gnark.v1.Proof.c:type_name -> gnark.v1.G1Point
+	0,  // 4: gnark.v1.VerifyingKey.curve:type_name -> gnark.v1.Curve
+	2,  // 5: gnark.v1.VerifyingKey.alpha:type_name -> gnark.v1.G1Point
+	3,  // 6: gnark.v1.VerifyingKey.beta:type_name -> gnark.v1.G2Point
+	3,  // 7: gnark.v1.VerifyingKey.gamma_neg:type_name -> gnark.v1.G2Point
+	3,  // 8: gnark.v1.VerifyingKey.delta_neg:type_name -> gnark.v1.G2Point
+	2,  // 9: gnark.v1.VerifyingKey.k:type_name -> gnark.v1.G1Point
+	0,  // 10: gnark.v1.PublicInputs.curve:type_name -> gnark.v1.Curve
+	6,  // 11: gnark.v1.PublicInputs.inputs:type_name -> gnark.v1.Assignment
+	0,  // 12: gnark.v1.WitnessSchema.curve:type_name -> gnark.v1.Curve
+	9,  // 13: gnark.v1.WitnessSchema.inputs:type_name -> gnark.v1.WitnessSchema.Input
+	1,  // 14: gnark.v1.WitnessSchema.Input.visibility:type_name -> gnark.v1.Visibility
+	15, // [15:15] is the sub-list for method output_type
+	15, // [15:15] is the sub-list for method input_type
+	15, // [15:15] is the sub-list for extension type_name
+	15, // [15:15] is the sub-list for extension extendee
+	0,  // [0:15] is the sub-list for field type_name
+}
+
+func init() { file_gnark_proto_init() }
+func file_gnark_proto_init() {
+	if File_gnark_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_gnark_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*G1Point); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_gnark_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*G2Point); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_gnark_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Proof); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_gnark_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*VerifyingKey); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_gnark_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Assignment); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_gnark_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PublicInputs); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_gnark_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*WitnessSchema); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_gnark_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*WitnessSchema_Input); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_gnark_proto_rawDesc,
+			NumEnums:      2,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_gnark_proto_goTypes,
+		DependencyIndexes: file_gnark_proto_depIdxs,
+		EnumInfos:         file_gnark_proto_enumTypes,
+		MessageInfos:      file_gnark_proto_msgTypes,
+	}.Build()
+	File_gnark_proto = out.File
+	file_gnark_proto_rawDesc = nil
+	file_gnark_proto_goTypes = nil
+	file_gnark_proto_depIdxs = nil
+}
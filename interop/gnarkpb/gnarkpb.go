@@ -0,0 +1,440 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package gnarkpb is the protocol buffers wire format of gnark Groth16 proofs, verifying keys,
+// public inputs and witness schemas
+//
+// The schema is specified in gnark.proto, from which gnark.pb.go is generated:
+//
+//	protoc --go_out=. --go_opt=paths=source_relative gnark.proto
+//
+// This file converts the messages from and to the gnark objects. Decoding checks the field elements
+// are canonical and the points are on the curve and in the correct subgroup.
+//
+// The Proof, VerifyingKey and PublicInputs messages also have a canonical JSON encoding, with named
+// fields and hex field elements, for the verifiers which don't use protocol buffers (see json.go).
+package gnarkpb
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/r1cs"
+	"github.com/consensys/gnark/frontend"
+	backend_bls377 "github.com/consensys/gnark/internal/backend/bls377"
+	groth16_bls377 "github.com/consensys/gnark/internal/backend/bls377/groth16"
+	backend_bls381 "github.com/consensys/gnark/internal/backend/bls381"
+	groth16_bls381 "github.com/consensys/gnark/internal/backend/bls381/groth16"
+	backend_bn256 "github.com/consensys/gnark/internal/backend/bn256"
+	groth16_bn256 "github.com/consensys/gnark/internal/backend/bn256/groth16"
+	backend_bw761 "github.com/consensys/gnark/internal/backend/bw761"
+	groth16_bw761 "github.com/consensys/gnark/internal/backend/bw761/groth16"
+	"github.com/consensys/gurvy"
+	"github.com/consensys/gurvy/bls377"
+	fp_bls377 "github.com/consensys/gurvy/bls377/fp"
+	fr_bls377 "github.com/consensys/gurvy/bls377/fr"
+	"github.com/consensys/gurvy/bls381"
+	fp_bls381 "github.com/consensys/gurvy/bls381/fp"
+	fr_bls381 "github.com/consensys/gurvy/bls381/fr"
+	"github.com/consensys/gurvy/bn256"
+	fp_bn256 "github.com/consensys/gurvy/bn256/fp"
+	fr_bn256 "github.com/consensys/gurvy/bn256/fr"
+	"github.com/consensys/gurvy/bw761"
+	fp_bw761 "github.com/consensys/gurvy/bw761/fp"
+	fr_bw761 "github.com/consensys/gurvy/bw761/fr"
+)
+
+var (
+	errCurve   = errors.New("gnarkpb: unsupported curve")
+	errElement = errors.New("gnarkpb: invalid field element")
+	errPoint   = errors.New("gnarkpb: missing point")
+	errNbK     = errors.New("gnarkpb: number of points doesn't match the public inputs")
+)
+
+// point is implemented by the G1 and G2 affine points of all curves
+type point interface {
+	Marshal() []byte
+	Unmarshal([]byte) error
+	IsInfinity() bool
+}
+
+// curveParams describes the gurvy encoding of the points of a curve
+//
+// gurvy encodes the coordinates in big endian, a coordinate over a quadratic extension as A1 then A0,
+// and sets its flags in the most significant bits of the first byte
+type curveParams struct {
+	id                gurvy.ID
+	fp, fr            *big.Int
+	nFp, nFr          int // sizes of the encoded base and scalar field elements
+	mFlags, mInfinity byte
+}
+
+var curves = map[Curve]curveParams{
+	Curve_BN254:     {gurvy.BN256, fp_bn256.Modulus(), fr_bn256.Modulus(), fp_bn256.Limbs * 8, fr_bn256.Limbs * 8, 0b11 << 6, 0b01 << 6},
+	Curve_BLS12_377: {gurvy.BLS377, fp_bls377.Modulus(), fr_bls377.Modulus(), fp_bls377.Limbs * 8, fr_bls377.Limbs * 8, 0b111 << 5, 0b110 << 5},
+	Curve_BLS12_381: {gurvy.BLS381, fp_bls381.Modulus(), fr_bls381.Modulus(), fp_bls381.Limbs * 8, fr_bls381.Limbs * 8, 0b111 << 5, 0b110 << 5},
+	Curve_BW6_761:   {gurvy.BW761, fp_bw761.Modulus(), fr_bw761.Modulus(), fp_bw761.Limbs * 8, fr_bw761.Limbs * 8, 0b111 << 5, 0b110 << 5},
+}
+
+// CurveOf returns the Curve of a gurvy curve ID
+func CurveOf(curveID gurvy.ID) (Curve, error) {
+	for c, params := range curves {
+		if params.id == curveID {
+			return c, nil
+		}
+	}
+	return Curve_CURVE_UNSPECIFIED, errCurve
+}
+
+// ID returns the gurvy curve ID of c
+func (c Curve) ID() (gurvy.ID, error) {
+	params, ok := curves[c]
+	if !ok {
+		return gurvy.UNKNOWN, errCurve
+	}
+	return params.id, nil
+}
+
+// NewProof converts a gnark proof
+func NewProof(proof groth16.Proof) (*Proof, error) {
+	curve, points, err := proofPoints(proof)
+	if err != nil {
+		return nil, err
+	}
+	c := curves[curve]
+	return &Proof{Curve: curve, A: c.g1(points[0]), B: c.g2(points[1]), C: c.g1(points[2])}, nil
+}
+
+// Groth16 converts p to a gnark proof
+func (p *Proof) Groth16() (groth16.Proof, error) {
+	curveID, err := p.Curve.ID()
+	if err != nil {
+		return nil, err
+	}
+	proof := groth16.NewProof(curveID)
+	_, points, err := proofPoints(proof)
+	if err != nil {
+		return nil, err
+	}
+	c := curves[p.Curve]
+	if err := c.setG1(points[0], p.A); err != nil {
+		return nil, err
+	}
+	if err := c.setG2(points[1], p.B); err != nil {
+		return nil, err
+	}
+	if err := c.setG1(points[2], p.C); err != nil {
+		return nil, err
+	}
+	return proof, nil
+}
+
+func proofPoints(proof groth16.Proof) (Curve, []point, error) {
+	switch p := proof.(type) {
+	case *groth16_bn256.Proof:
+		return Curve_BN254, []point{&p.Ar, &p.Bs, &p.Krs}, nil
+	case *groth16_bls377.Proof:
+		return Curve_BLS12_377, []point{&p.Ar, &p.Bs, &p.Krs}, nil
+	case *groth16_bls381.Proof:
+		return Curve_BLS12_381, []point{&p.Ar, &p.Bs, &p.Krs}, nil
+	case *groth16_bw761.Proof:
+		return Curve_BW6_761, []point{&p.Ar, &p.Bs, &p.Krs}, nil
+	default:
+		return Curve_CURVE_UNSPECIFIED, nil, errCurve
+	}
+}
+
+// vkPoints returns the curve, the public inputs, the points alpha, beta, gamma_neg, delta_neg and
+// the points k of vk
+func vkPoints(vk groth16.VerifyingKey) (Curve, []string, []point, []point, error) {
+	if groth16.HasCommitment(vk) {
+		return 0, nil, nil, nil, groth16.ErrCommitment
+	}
+	var k []point
+	switch _vk := vk.(type) {
+	case *groth16_bn256.VerifyingKey:
+		for i := 0; i < len(_vk.G1.K); i++ {
+			k = append(k, &_vk.G1.K[i])
+		}
+		return Curve_BN254, _vk.PublicInputs, []point{&_vk.G1.Alpha, &_vk.G2.Beta, &_vk.G2.GammaNeg, &_vk.G2.DeltaNeg}, k, nil
+	case *groth16_bls377.VerifyingKey:
+		for i := 0; i < len(_vk.G1.K); i++ {
+			k = append(k, &_vk.G1.K[i])
+		}
+		return Curve_BLS12_377, _vk.PublicInputs, []point{&_vk.G1.Alpha, &_vk.G2.Beta, &_vk.G2.GammaNeg, &_vk.G2.DeltaNeg}, k, nil
+	case *groth16_bls381.VerifyingKey:
+		for i := 0; i < len(_vk.G1.K); i++ {
+			k = append(k, &_vk.G1.K[i])
+		}
+		return Curve_BLS12_381, _vk.PublicInputs, []point{&_vk.G1.Alpha, &_vk.G2.Beta, &_vk.G2.GammaNeg, &_vk.G2.DeltaNeg}, k, nil
+	case *groth16_bw761.VerifyingKey:
+		for i := 0; i < len(_vk.G1.K); i++ {
+			k = append(k, &_vk.G1.K[i])
+		}
+		return Curve_BW6_761, _vk.PublicInputs, []point{&_vk.G1.Alpha, &_vk.G2.Beta, &_vk.G2.GammaNeg, &_vk.G2.DeltaNeg}, k, nil
+	default:
+		return Curve_CURVE_UNSPECIFIED, nil, nil, nil, errCurve
+	}
+}
+
+// NewVerifyingKey converts a gnark verifying key
+func NewVerifyingKey(vk groth16.VerifyingKey) (*VerifyingKey, error) {
+	curve, publicInputs, points, k, err := vkPoints(vk)
+	if err != nil {
+		return nil, err
+	}
+	c := curves[curve]
+	res := &VerifyingKey{
+		Curve:        curve,
+		PublicInputs: append([]string(nil), publicInputs...),
+		Alpha:        c.g1(points[0]),
+		Beta:         c.g2(points[1]),
+		GammaNeg:     c.g2(points[2]),
+		DeltaNeg:     c.g2(points[3]),
+	}
+	for _, p := range k {
+		res.K = append(res.K, c.g1(p))
+	}
+	return res, nil
+}
+
+// Groth16 converts vk to a gnark verifying key
+func (vk *VerifyingKey) Groth16() (groth16.VerifyingKey, error) {
+	curveID, err := vk.Curve.ID()
+	if err != nil {
+		return nil, err
+	}
+	if len(vk.K) != len(vk.PublicInputs) {
+		return nil, errNbK
+	}
+
+	// allocate the points k before decoding them
+	var res groth16.VerifyingKey
+	switch curveID {
+	case gurvy.BN256:
+		_vk := &groth16_bn256.VerifyingKey{}
+		_vk.G1.K = make([]bn256.G1Affine, len(vk.K))
+		res = _vk
+	case gurvy.BLS377:
+		_vk := &groth16_bls377.VerifyingKey{}
+		_vk.G1.K = make([]bls377.G1Affine, len(vk.K))
+		res = _vk
+	case gurvy.BLS381:
+		_vk := &groth16_bls381.VerifyingKey{}
+		_vk.G1.K = make([]bls381.G1Affine, len(vk.K))
+		res = _vk
+	case gurvy.BW761:
+		_vk := &groth16_bw761.VerifyingKey{}
+		_vk.G1.K = make([]bw761.G1Affine, len(vk.K))
+		res = _vk
+	}
+
+	_, _, points, k, _ := vkPoints(res)
+	c := curves[vk.Curve]
+	if err := c.setG1(points[0], vk.Alpha); err != nil {
+		return nil, err
+	}
+	for i, p := range []*G2Point{vk.Beta, vk.GammaNeg, vk.DeltaNeg} {
+		if err := c.setG2(points[i+1], p); err != nil {
+			return nil, err
+		}
+	}
+	for i := range k {
+		if err := c.setG1(k[i], vk.K[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	// e(α, β)
+	switch _vk := res.(type) {
+	case *groth16_bn256.VerifyingKey:
+		_vk.PublicInputs = append([]string(nil), vk.PublicInputs...)
+		_vk.E, err = bn256.Pair([]bn256.G1Affine{_vk.G1.Alpha}, []bn256.G2Affine{_vk.G2.Beta})
+	case *groth16_bls377.VerifyingKey:
+		_vk.PublicInputs = append([]string(nil), vk.PublicInputs...)
+		_vk.E, err = bls377.Pair([]bls377.G1Affine{_vk.G1.Alpha}, []bls377.G2Affine{_vk.G2.Beta})
+	case *groth16_bls381.VerifyingKey:
+		_vk.PublicInputs = append([]string(nil), vk.PublicInputs...)
+		_vk.E, err = bls381.Pair([]bls381.G1Affine{_vk.G1.Alpha}, []bls381.G2Affine{_vk.G2.Beta})
+	case *groth16_bw761.VerifyingKey:
+		_vk.PublicInputs = append([]string(nil), vk.PublicInputs...)
+		_vk.E, err = bw761.Pair([]bw761.G1Affine{_vk.G1.Alpha}, []bw761.G2Affine{_vk.G2.Beta})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// NewPublicInputs returns the public inputs of the witness, in the order of the verifying key
+func NewPublicInputs(vk groth16.VerifyingKey, publicWitness interface{}) (*PublicInputs, error) {
+	curve, publicInputs, _, _, err := vkPoints(vk)
+	if err != nil {
+		return nil, err
+	}
+	witness, err := frontend.ParseWitness(publicWitness)
+	if err != nil {
+		return nil, err
+	}
+	c := curves[curve]
+	res := &PublicInputs{Curve: curve}
+	for _, name := range publicInputs {
+		if name == backend.OneWire {
+			continue
+		}
+		v, ok := witness[name]
+		if !ok {
+			return nil, backend.ErrInputNotSet
+		}
+		value := backend.FromInterface(v)
+		value.Mod(&value, c.fr)
+		res.Inputs = append(res.Inputs, &Assignment{Name: name, Value: value.FillBytes(make([]byte, c.nFr))})
+	}
+	return res, nil
+}
+
+// Map returns the public inputs as a witness of groth16.Verify
+func (p *PublicInputs) Map() (map[string]interface{}, error) {
+	c, ok := curves[p.Curve]
+	if !ok {
+		return nil, errCurve
+	}
+	res := make(map[string]interface{}, len(p.Inputs))
+	for _, input := range p.Inputs {
+		v, err := element(input.GetValue(), c.nFr, c.fr)
+		if err != nil {
+			return nil, err
+		}
+		res[input.GetName()] = *v
+	}
+	return res, nil
+}
+
+// NewWitnessSchema returns the inputs of the compiled circuit, secret inputs first; the constant
+// input backend.OneWire is omitted
+func NewWitnessSchema(_r1cs r1cs.R1CS) (*WitnessSchema, error) {
+	var secret, public []string
+	var curve Curve
+	switch r := _r1cs.(type) {
+	case *backend_bn256.R1CS:
+		curve, secret, public = Curve_BN254, r.SecretWires, r.PublicWires
+	case *backend_bls377.R1CS:
+		curve, secret, public = Curve_BLS12_377, r.SecretWires, r.PublicWires
+	case *backend_bls381.R1CS:
+		curve, secret, public = Curve_BLS12_381, r.SecretWires, r.PublicWires
+	case *backend_bw761.R1CS:
+		curve, secret, public = Curve_BW6_761, r.SecretWires, r.PublicWires
+	default:
+		return nil, errCurve
+	}
+	res := &WitnessSchema{Curve: curve}
+	for _, name := range secret {
+		res.Inputs = append(res.Inputs, &WitnessSchema_Input{Name: name, Visibility: Visibility_SECRET})
+	}
+	for _, name := range public {
+		if name != backend.OneWire {
+			res.Inputs = append(res.Inputs, &WitnessSchema_Input{Name: name, Visibility: Visibility_PUBLIC})
+		}
+	}
+	return res, nil
+}
+
+// degree returns the number of base field elements of a coordinate of p
+func (c curveParams) degree(p point) int {
+	return len(p.Marshal()) / (2 * c.nFp)
+}
+
+// coordinates returns the coordinates of p, in the order of gurvy
+func (c curveParams) coordinates(p point) [][]byte {
+	raw := p.Marshal()
+	raw[0] &^= c.mFlags
+	res := make([][]byte, len(raw)/c.nFp)
+	for i := range res {
+		if !p.IsInfinity() {
+			res[i] = raw[i*c.nFp : (i+1)*c.nFp]
+		} else {
+			res[i] = make([]byte, c.nFp)
+		}
+	}
+	return res
+}
+
+func (c curveParams) g1(p point) *G1Point {
+	coords := c.coordinates(p)
+	return &G1Point{X: coords[0], Y: coords[1]}
+}
+
+func (c curveParams) g2(p point) *G2Point {
+	coords := c.coordinates(p)
+	if c.degree(p) == 1 {
+		return &G2Point{X0: coords[0], Y0: coords[1]}
+	}
+	return &G2Point{X0: coords[1], X1: coords[0], Y0: coords[3], Y1: coords[2]}
+}
+
+func (c curveParams) setG1(p point, g1 *G1Point) error {
+	if g1 == nil {
+		return errPoint
+	}
+	return c.setPoint(p, [][]byte{g1.GetX(), g1.GetY()})
+}
+
+func (c curveParams) setG2(p point, g2 *G2Point) error {
+	if g2 == nil {
+		return errPoint
+	}
+	if c.degree(p) == 1 {
+		if len(g2.GetX1()) != 0 || len(g2.GetY1()) != 0 {
+			return errElement
+		}
+		return c.setPoint(p, [][]byte{g2.GetX0(), g2.GetY0()})
+	}
+	return c.setPoint(p, [][]byte{g2.GetX1(), g2.GetX0(), g2.GetY1(), g2.GetY0()})
+}
+
+// setPoint sets p from its coordinates, in the order of gurvy; gurvy checks the point is on the curve
+// and in the subgroup
+func (c curveParams) setPoint(p point, coords [][]byte) error {
+	raw := make([]byte, 0, len(coords)*c.nFp)
+	infinity := true
+	for _, coord := range coords {
+		v, err := element(coord, c.nFp, c.fp)
+		if err != nil {
+			return err
+		}
+		infinity = infinity && v.Sign() == 0
+		raw = append(raw, coord...)
+	}
+	if infinity {
+		raw = raw[:len(raw)/2]
+		raw[0] = c.mInfinity
+	}
+	return p.Unmarshal(raw)
+}
+
+// element decodes a field element of n bytes, smaller than modulus
+func element(buf []byte, n int, modulus *big.Int) (*big.Int, error) {
+	if len(buf) != n {
+		return nil, errElement
+	}
+	v := new(big.Int).SetBytes(buf)
+	if v.Cmp(modulus) >= 0 {
+		return nil, errElement
+	}
+	return v, nil
+}
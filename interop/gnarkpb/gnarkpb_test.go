@@ -0,0 +1,256 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gnarkpb
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gurvy"
+	"google.golang.org/protobuf/proto"
+)
+
+type cubicCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+// x**3 + x + 5 == y
+func (circuit *cubicCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	x3 := cs.Mul(circuit.X, circuit.X, circuit.X)
+	cs.AssertIsEqual(circuit.Y, cs.Add(x3, circuit.X, 5))
+	return nil
+}
+
+// roundTrip marshals and unmarshals m into res
+func roundTrip(t *testing.T, m, res proto.Message) {
+	buf, err := proto.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := proto.Unmarshal(buf, res); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, curveID := range []gurvy.ID{gurvy.BN256, gurvy.BLS377, gurvy.BLS381, gurvy.BW761} {
+		var circuit, witness cubicCircuit
+		r1cs, err := frontend.Compile(curveID, &circuit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pk, vk, err := groth16.Setup(r1cs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		witness.X.Assign(3)
+		witness.Y.Assign(35)
+		proof, err := groth16.Prove(r1cs, pk, &witness)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		pbProof, err := NewProof(proof)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pbVK, err := NewVerifyingKey(vk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pbInputs, err := NewPublicInputs(vk, &witness)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var _pbProof Proof
+		var _pbVK VerifyingKey
+		var _pbInputs PublicInputs
+		roundTrip(t, pbProof, &_pbProof)
+		roundTrip(t, pbVK, &_pbVK)
+		roundTrip(t, pbInputs, &_pbInputs)
+
+		_proof, err := _pbProof.Groth16()
+		if err != nil {
+			t.Fatal(err)
+		}
+		_vk, err := _pbVK.Groth16()
+		if err != nil {
+			t.Fatal(err)
+		}
+		inputs, err := _pbInputs.Map()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := groth16.Verify(_proof, _vk, inputs); err != nil {
+			t.Fatal(curveID, err)
+		}
+
+		schema, err := NewWitnessSchema(r1cs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(schema.Inputs) != 2 ||
+			schema.Inputs[0].Name != "X" || schema.Inputs[0].Visibility != Visibility_SECRET ||
+			schema.Inputs[1].Name != "Y" || schema.Inputs[1].Visibility != Visibility_PUBLIC {
+			t.Fatal("unexpected witness schema", schema.Inputs)
+		}
+	}
+}
+
+func TestInvalid(t *testing.T) {
+	var circuit, witness cubicCircuit
+	r1cs, err := frontend.Compile(gurvy.BN256, &circuit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, _, err := groth16.Setup(r1cs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	witness.X.Assign(3)
+	witness.Y.Assign(35)
+	proof, err := groth16.Prove(r1cs, pk, &witness)
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid, err := NewProof(proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, tamper := range map[string]func(p *Proof){
+		"curve":           func(p *Proof) { p.Curve = Curve_CURVE_UNSPECIFIED },
+		"missing point":   func(p *Proof) { p.C = nil },
+		"short element":   func(p *Proof) { p.A.X = p.A.X[1:] },
+		"non canonical":   func(p *Proof) { p.A.X[0] |= 0x80 },
+		"not on curve":    func(p *Proof) { p.A.Y[31] ^= 1 },
+		"swapped G2 part": func(p *Proof) { p.B.X0, p.B.X1 = p.B.X1, p.B.X0 },
+	} {
+		p := proto.Clone(valid).(*Proof)
+		tamper(p)
+		if _, err := p.Groth16(); err == nil {
+			t.Fatal("expected error for", name)
+		}
+	}
+
+	// the point at infinity has zero coordinates
+	p := proto.Clone(valid).(*Proof)
+	p.C = &G1Point{X: make([]byte, 32), Y: make([]byte, 32)}
+	if _, err := p.Groth16(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	for _, curveID := range []gurvy.ID{gurvy.BN256, gurvy.BLS377, gurvy.BLS381, gurvy.BW761} {
+		var circuit, witness cubicCircuit
+		r1cs, err := frontend.Compile(curveID, &circuit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pk, vk, err := groth16.Setup(r1cs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		witness.X.Assign(3)
+		witness.Y.Assign(35)
+		proof, err := groth16.Prove(r1cs, pk, &witness)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		pbProof, err := NewProof(proof)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pbVK, err := NewVerifyingKey(vk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pbInputs, err := NewPublicInputs(vk, &witness)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var _pbProof Proof
+		var _pbVK VerifyingKey
+		var _pbInputs PublicInputs
+		for _, m := range []struct{ src, dst interface{} }{{pbProof, &_pbProof}, {pbVK, &_pbVK}, {pbInputs, &_pbInputs}} {
+			data, err := json.Marshal(m.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal(data, m.dst); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// the field elements are padded to the size of the field
+		var fields map[string]interface{}
+		data, _ := json.Marshal(pbProof)
+		if err := json.Unmarshal(data, &fields); err != nil {
+			t.Fatal(err)
+		}
+		x := fields["a"].(map[string]interface{})["x"].(string)
+		if !strings.HasPrefix(x, "0x") || len(x) != 2+2*curves[pbProof.Curve].nFp {
+			t.Fatal("unexpected encoding of a field element", x)
+		}
+		if fields["curve"] != pbProof.Curve.String() {
+			t.Fatal("unexpected curve", fields["curve"])
+		}
+
+		_proof, err := _pbProof.Groth16()
+		if err != nil {
+			t.Fatal(err)
+		}
+		_vk, err := _pbVK.Groth16()
+		if err != nil {
+			t.Fatal(err)
+		}
+		inputs, err := _pbInputs.Map()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := groth16.Verify(_proof, _vk, inputs); err != nil {
+			t.Fatal(curveID, err)
+		}
+	}
+
+	for name, data := range map[string]string{
+		"curve":       `{"curve":"BN256","a":null,"b":null,"c":null}`,
+		"unspecified": `{"curve":"CURVE_UNSPECIFIED","a":null,"b":null,"c":null}`,
+		"no prefix":   `{"curve":"BN254","a":{"x":"01","y":"0x01"},"b":null,"c":null}`,
+		"not hex":     `{"curve":"BN254","a":{"x":"0xzz","y":"0x01"},"b":null,"c":null}`,
+	} {
+		var p Proof
+		if err := json.Unmarshal([]byte(data), &p); err == nil {
+			t.Fatal("expected error for", name)
+		}
+	}
+	var p Proof
+	if err := json.Unmarshal([]byte(`{"curve":"BN254","a":null,"b":null,"c":null}`), &p); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Groth16(); err != errPoint {
+		t.Fatal("expected errPoint, got", err)
+	}
+}
@@ -0,0 +1,270 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gnarkpb
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+)
+
+// The JSON encoding of the messages has the field names of gnark.proto, the curve names of the Curve
+// enum, and the field elements in big-endian hex with a 0x prefix, padded to the size of the field:
+//
+//	{
+//	  "curve": "BN254",
+//	  "a": {"x": "0x0f1e...", "y": "0x2a3b..."},
+//	  "b": {"x0": "0x...", "x1": "0x...", "y0": "0x...", "y1": "0x..."},
+//	  "c": {"x": "0x...", "y": "0x..."}
+//	}
+//
+// x1 and y1 are omitted on BW6_761, whose G2 is defined over the base field. As with the protocol
+// buffers encoding, the elements and the points are checked by Groth16 and Map, not by UnmarshalJSON.
+
+type jsonG1 struct {
+	X string `json:"x"`
+	Y string `json:"y"`
+}
+
+type jsonG2 struct {
+	X0 string `json:"x0"`
+	X1 string `json:"x1,omitempty"`
+	Y0 string `json:"y0"`
+	Y1 string `json:"y1,omitempty"`
+}
+
+type jsonProof struct {
+	Curve string  `json:"curve"`
+	A     *jsonG1 `json:"a"`
+	B     *jsonG2 `json:"b"`
+	C     *jsonG1 `json:"c"`
+}
+
+type jsonVerifyingKey struct {
+	Curve        string    `json:"curve"`
+	PublicInputs []string  `json:"public_inputs"`
+	Alpha        *jsonG1   `json:"alpha"`
+	Beta         *jsonG2   `json:"beta"`
+	GammaNeg     *jsonG2   `json:"gamma_neg"`
+	DeltaNeg     *jsonG2   `json:"delta_neg"`
+	K            []*jsonG1 `json:"k"`
+}
+
+type jsonAssignment struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type jsonPublicInputs struct {
+	Curve  string           `json:"curve"`
+	Inputs []jsonAssignment `json:"inputs"`
+}
+
+// MarshalJSON implements json.Marshaler
+func (p *Proof) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonProof{
+		Curve: p.GetCurve().String(),
+		A:     toJSONG1(p.GetA()),
+		B:     toJSONG2(p.GetB()),
+		C:     toJSONG1(p.GetC()),
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (p *Proof) UnmarshalJSON(data []byte) error {
+	var v jsonProof
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	curve, err := curveFromJSON(v.Curve)
+	if err != nil {
+		return err
+	}
+	res := Proof{Curve: curve}
+	if res.A, err = fromJSONG1(v.A); err != nil {
+		return err
+	}
+	if res.B, err = fromJSONG2(v.B); err != nil {
+		return err
+	}
+	if res.C, err = fromJSONG1(v.C); err != nil {
+		return err
+	}
+	p.Curve, p.A, p.B, p.C = res.Curve, res.A, res.B, res.C
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler
+func (vk *VerifyingKey) MarshalJSON() ([]byte, error) {
+	v := jsonVerifyingKey{
+		Curve:        vk.GetCurve().String(),
+		PublicInputs: vk.GetPublicInputs(),
+		Alpha:        toJSONG1(vk.GetAlpha()),
+		Beta:         toJSONG2(vk.GetBeta()),
+		GammaNeg:     toJSONG2(vk.GetGammaNeg()),
+		DeltaNeg:     toJSONG2(vk.GetDeltaNeg()),
+	}
+	if v.PublicInputs == nil {
+		v.PublicInputs = []string{}
+	}
+	v.K = make([]*jsonG1, len(vk.GetK()))
+	for i, k := range vk.GetK() {
+		v.K[i] = toJSONG1(k)
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (vk *VerifyingKey) UnmarshalJSON(data []byte) error {
+	var v jsonVerifyingKey
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	curve, err := curveFromJSON(v.Curve)
+	if err != nil {
+		return err
+	}
+	res := VerifyingKey{Curve: curve, PublicInputs: v.PublicInputs}
+	if res.Alpha, err = fromJSONG1(v.Alpha); err != nil {
+		return err
+	}
+	for _, p := range []struct {
+		dst **G2Point
+		src *jsonG2
+	}{{&res.Beta, v.Beta}, {&res.GammaNeg, v.GammaNeg}, {&res.DeltaNeg, v.DeltaNeg}} {
+		if *p.dst, err = fromJSONG2(p.src); err != nil {
+			return err
+		}
+	}
+	res.K = make([]*G1Point, len(v.K))
+	for i := range v.K {
+		if res.K[i], err = fromJSONG1(v.K[i]); err != nil {
+			return err
+		}
+	}
+	vk.Curve, vk.PublicInputs, vk.Alpha, vk.Beta, vk.GammaNeg, vk.DeltaNeg, vk.K =
+		res.Curve, res.PublicInputs, res.Alpha, res.Beta, res.GammaNeg, res.DeltaNeg, res.K
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler
+func (p *PublicInputs) MarshalJSON() ([]byte, error) {
+	v := jsonPublicInputs{Curve: p.GetCurve().String(), Inputs: []jsonAssignment{}}
+	for _, input := range p.GetInputs() {
+		v.Inputs = append(v.Inputs, jsonAssignment{Name: input.GetName(), Value: toHex(input.GetValue())})
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (p *PublicInputs) UnmarshalJSON(data []byte) error {
+	var v jsonPublicInputs
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	curve, err := curveFromJSON(v.Curve)
+	if err != nil {
+		return err
+	}
+	inputs := make([]*Assignment, len(v.Inputs))
+	for i, input := range v.Inputs {
+		value, err := fromHex(input.Value)
+		if err != nil {
+			return err
+		}
+		inputs[i] = &Assignment{Name: input.Name, Value: value}
+	}
+	p.Curve, p.Inputs = curve, inputs
+	return nil
+}
+
+func curveFromJSON(name string) (Curve, error) {
+	c, ok := Curve_value[name]
+	if !ok || Curve(c) == Curve_CURVE_UNSPECIFIED {
+		return Curve_CURVE_UNSPECIFIED, errCurve
+	}
+	return Curve(c), nil
+}
+
+func toJSONG1(p *G1Point) *jsonG1 {
+	if p == nil {
+		return nil
+	}
+	return &jsonG1{X: toHex(p.X), Y: toHex(p.Y)}
+}
+
+func toJSONG2(p *G2Point) *jsonG2 {
+	if p == nil {
+		return nil
+	}
+	return &jsonG2{X0: toHex(p.X0), X1: toHex(p.X1), Y0: toHex(p.Y0), Y1: toHex(p.Y1)}
+}
+
+func fromJSONG1(p *jsonG1) (*G1Point, error) {
+	if p == nil {
+		return nil, nil
+	}
+	var res G1Point
+	var err error
+	if res.X, err = fromHex(p.X); err != nil {
+		return nil, err
+	}
+	if res.Y, err = fromHex(p.Y); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func fromJSONG2(p *jsonG2) (*G2Point, error) {
+	if p == nil {
+		return nil, nil
+	}
+	var res G2Point
+	var err error
+	for _, c := range []struct {
+		dst *[]byte
+		src string
+	}{{&res.X0, p.X0}, {&res.X1, p.X1}, {&res.Y0, p.Y0}, {&res.Y1, p.Y1}} {
+		if *c.dst, err = fromHex(c.src); err != nil {
+			return nil, err
+		}
+	}
+	return &res, nil
+}
+
+// toHex returns the 0x prefixed hex encoding of b, or "" if b is empty
+func toHex(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	return "0x" + hex.EncodeToString(b)
+}
+
+// fromHex decodes a string returned by toHex
+func fromHex(s string) ([]byte, error) {
+	if s == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(s, "0x") {
+		return nil, errElement
+	}
+	b, err := hex.DecodeString(s[2:])
+	if err != nil {
+		return nil, errElement
+	}
+	return b, nil
+}
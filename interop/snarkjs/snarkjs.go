@@ -0,0 +1,343 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package snarkjs converts gnark Groth16 objects to and from the JSON files of snarkjs
+// (proof.json, verification_key.json and public.json)
+//
+// snarkjs only supports Groth16 on BN256 (named bn128 in snarkjs). Both libraries check the same equation
+//
+//	e(A, B) == e(α, β) * e(Σ x_i * IC_i, γ) * e(C, δ)
+//
+// where gnark names the proof points Ar, Bs and Krs, and stores the IC points in VerifyingKey.G1.K.
+// snarkjs has no public input names: public signals are ordered as the public inputs of the gnark
+// VerifyingKey, without the constant wire backend.OneWire (that corresponds to IC[0]).
+package snarkjs
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	groth16_bn256 "github.com/consensys/gnark/internal/backend/bn256/groth16"
+	"github.com/consensys/gurvy/bn256"
+	"github.com/consensys/gurvy/bn256/fp"
+	"github.com/consensys/gurvy/bn256/fr"
+)
+
+const (
+	protocol = "groth16"
+	curve    = "bn128"
+)
+
+var (
+	errCurve        = errors.New("snarkjs: only bn256 is supported")
+	errProtocol     = errors.New("snarkjs: unsupported protocol or curve")
+	errNbPublic     = errors.New("snarkjs: number of public inputs doesn't match the verifying key")
+	errInvalidPoint = errors.New("snarkjs: point is not on the curve")
+	errNoAlphaBeta  = errors.New("snarkjs: the verifying key predates the encoding of alpha and beta")
+)
+
+// G1 is a G1 point in projective coordinates, as decimal strings [x, y, z]
+type G1 [3]string
+
+// G2 is a G2 point in projective coordinates, as decimal strings [[x.A0, x.A1], [y.A0, y.A1], [z.A0, z.A1]]
+type G2 [3][2]string
+
+// Proof is the content of a snarkjs proof.json
+type Proof struct {
+	PiA      G1     `json:"pi_a"`
+	PiB      G2     `json:"pi_b"`
+	PiC      G1     `json:"pi_c"`
+	Protocol string `json:"protocol"`
+	Curve    string `json:"curve"`
+}
+
+// VerifyingKey is the content of a snarkjs verification_key.json
+type VerifyingKey struct {
+	Protocol    string       `json:"protocol"`
+	Curve       string       `json:"curve"`
+	NPublic     int          `json:"nPublic"`
+	Alpha1      G1           `json:"vk_alpha_1"`
+	Beta2       G2           `json:"vk_beta_2"`
+	Gamma2      G2           `json:"vk_gamma_2"`
+	Delta2      G2           `json:"vk_delta_2"`
+	AlphaBeta12 [][][]string `json:"vk_alphabeta_12,omitempty"` // ignored, see ImportVerifyingKey
+	IC          []G1         `json:"IC"`
+}
+
+// ExportProof converts a gnark proof to a snarkjs proof
+func ExportProof(proof groth16.Proof) (*Proof, error) {
+	_proof, ok := proof.(*groth16_bn256.Proof)
+	if !ok {
+		return nil, errCurve
+	}
+	return &Proof{
+		PiA:      exportG1(&_proof.Ar),
+		PiB:      exportG2(&_proof.Bs),
+		PiC:      exportG1(&_proof.Krs),
+		Protocol: protocol,
+		Curve:    curve,
+	}, nil
+}
+
+// ImportProof converts a snarkjs proof to a gnark proof
+func ImportProof(proof *Proof) (groth16.Proof, error) {
+	if proof.Protocol != protocol || proof.Curve != curve {
+		return nil, errProtocol
+	}
+	var res groth16_bn256.Proof
+	var err error
+	if res.Ar, err = importG1(proof.PiA); err != nil {
+		return nil, err
+	}
+	if res.Bs, err = importG2(proof.PiB); err != nil {
+		return nil, err
+	}
+	if res.Krs, err = importG1(proof.PiC); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// ExportVerifyingKey converts a gnark verifying key to a snarkjs verifying key
+func ExportVerifyingKey(vk groth16.VerifyingKey) (*VerifyingKey, error) {
+	_vk, ok := vk.(*groth16_bn256.VerifyingKey)
+	if !ok {
+		return nil, errCurve
+	}
+	if groth16.HasCommitment(vk) {
+		return nil, groth16.ErrCommitment
+	}
+	if _vk.G1.Alpha.IsInfinity() || _vk.G2.Beta.IsInfinity() {
+		return nil, errNoAlphaBeta
+	}
+
+	// snarkjs stores γ and δ, gnark their opposites
+	var gamma, delta bn256.G2Affine
+	gamma.Neg(&_vk.G2.GammaNeg)
+	delta.Neg(&_vk.G2.DeltaNeg)
+
+	res := &VerifyingKey{
+		Protocol: protocol,
+		Curve:    curve,
+		NPublic:  len(_vk.PublicInputs) - 1,
+		Alpha1:   exportG1(&_vk.G1.Alpha),
+		Beta2:    exportG2(&_vk.G2.Beta),
+		Gamma2:   exportG2(&gamma),
+		Delta2:   exportG2(&delta),
+	}
+
+	// IC[0] is the constant wire
+	oneWire := -1
+	for i, name := range _vk.PublicInputs {
+		if name == backend.OneWire {
+			oneWire = i
+			break
+		}
+	}
+	if oneWire == -1 || len(_vk.G1.K) != len(_vk.PublicInputs) {
+		return nil, errNbPublic
+	}
+	res.IC = append(res.IC, exportG1(&_vk.G1.K[oneWire]))
+	for i := 0; i < len(_vk.G1.K); i++ {
+		if i != oneWire {
+			res.IC = append(res.IC, exportG1(&_vk.G1.K[i]))
+		}
+	}
+
+	return res, nil
+}
+
+// ImportVerifyingKey converts a snarkjs verifying key to a gnark verifying key
+//
+// publicInputs are the names of the public inputs, in the order of the snarkjs public signals
+func ImportVerifyingKey(vk *VerifyingKey, publicInputs []string) (groth16.VerifyingKey, error) {
+	if vk.Protocol != protocol || vk.Curve != curve {
+		return nil, errProtocol
+	}
+	if len(publicInputs) != vk.NPublic || len(vk.IC) != vk.NPublic+1 {
+		return nil, errNbPublic
+	}
+
+	var res groth16_bn256.VerifyingKey
+	var err error
+	res.PublicInputs = append([]string{backend.OneWire}, publicInputs...)
+	if res.G1.Alpha, err = importG1(vk.Alpha1); err != nil {
+		return nil, err
+	}
+	if res.G2.Beta, err = importG2(vk.Beta2); err != nil {
+		return nil, err
+	}
+	if res.G2.GammaNeg, err = importG2(vk.Gamma2); err != nil {
+		return nil, err
+	}
+	if res.G2.DeltaNeg, err = importG2(vk.Delta2); err != nil {
+		return nil, err
+	}
+	res.G2.GammaNeg.Neg(&res.G2.GammaNeg)
+	res.G2.DeltaNeg.Neg(&res.G2.DeltaNeg)
+
+	res.G1.K = make([]bn256.G1Affine, len(vk.IC))
+	for i := 0; i < len(vk.IC); i++ {
+		if res.G1.K[i], err = importG1(vk.IC[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	// vk_alphabeta_12 is not used by the snarkjs verifier (and not written by ExportVerifyingKey); we recompute e(α, β)
+	if res.E, err = bn256.Pair([]bn256.G1Affine{res.G1.Alpha}, []bn256.G2Affine{res.G2.Beta}); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// ExportPublicSignals returns the public inputs of the solution, in the order of the snarkjs public signals
+func ExportPublicSignals(vk groth16.VerifyingKey, solution interface{}) ([]string, error) {
+	_vk, ok := vk.(*groth16_bn256.VerifyingKey)
+	if !ok {
+		return nil, errCurve
+	}
+	_solution, err := frontend.ParseWitness(solution)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]string, 0, len(_vk.PublicInputs)-1)
+	for _, name := range _vk.PublicInputs {
+		if name == backend.OneWire {
+			continue
+		}
+		val, ok := _solution[name]
+		if !ok {
+			return nil, backend.ErrInputNotSet
+		}
+		var v fr.Element
+		v.SetInterface(val)
+		res = append(res, v.String())
+	}
+	return res, nil
+}
+
+// ImportPublicSignals returns the assignment of the public inputs of vk from snarkjs public signals
+func ImportPublicSignals(vk groth16.VerifyingKey, signals []string) (map[string]interface{}, error) {
+	_vk, ok := vk.(*groth16_bn256.VerifyingKey)
+	if !ok {
+		return nil, errCurve
+	}
+	if len(signals) != len(_vk.PublicInputs)-1 {
+		return nil, errNbPublic
+	}
+	res := make(map[string]interface{}, len(signals))
+	i := 0
+	for _, name := range _vk.PublicInputs {
+		if name == backend.OneWire {
+			continue
+		}
+		v, err := parseElement(signals[i], fr.Modulus())
+		if err != nil {
+			return nil, err
+		}
+		var e fr.Element
+		e.SetBigInt(v)
+		res[name] = e
+		i++
+	}
+	return res, nil
+}
+
+func exportG1(p *bn256.G1Affine) G1 {
+	if p.IsInfinity() {
+		return G1{"0", "1", "0"}
+	}
+	return G1{p.X.String(), p.Y.String(), "1"}
+}
+
+func exportG2(p *bn256.G2Affine) G2 {
+	if p.IsInfinity() {
+		return G2{{"0", "0"}, {"1", "0"}, {"0", "0"}}
+	}
+	return G2{
+		{p.X.A0.String(), p.X.A1.String()},
+		{p.Y.A0.String(), p.Y.A1.String()},
+		{"1", "0"},
+	}
+}
+
+// importG1 converts the projective coordinates of snarkjs to an affine point, and checks it is on the curve
+func importG1(p G1) (bn256.G1Affine, error) {
+	var res bn256.G1Affine
+	var c [3]fp.Element
+	for i := 0; i < 3; i++ {
+		v, err := parseElement(p[i], fp.Modulus())
+		if err != nil {
+			return res, err
+		}
+		c[i].SetBigInt(v)
+	}
+
+	// snarkjs uses projective coordinates (x = X/Z, y = Y/Z), gurvy Jacobian coordinates (x = X/Z², y = Y/Z³)
+	var jac bn256.G1Jac
+	jac.Z = c[2]
+	jac.X.Mul(&c[0], &c[2])
+	jac.Y.Square(&c[2]).Mul(&jac.Y, &c[1])
+	res.FromJacobian(&jac)
+	if !res.IsOnCurve() {
+		return res, errInvalidPoint
+	}
+	return res, nil
+}
+
+// importG2 converts the projective coordinates of snarkjs to an affine point, and checks it is on the curve
+func importG2(p G2) (bn256.G2Affine, error) {
+	var res bn256.G2Affine
+	var c [3][2]fp.Element
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 2; j++ {
+			v, err := parseElement(p[i][j], fp.Modulus())
+			if err != nil {
+				return res, err
+			}
+			c[i][j].SetBigInt(v)
+		}
+	}
+
+	// see importG1
+	var jac bn256.G2Jac
+	jac.X.A0, jac.X.A1 = c[0][0], c[0][1]
+	jac.Y.A0, jac.Y.A1 = c[1][0], c[1][1]
+	jac.Z.A0, jac.Z.A1 = c[2][0], c[2][1]
+	zz := jac.Z
+	zz.Square(&jac.Z)
+	jac.X.Mul(&jac.X, &jac.Z)
+	jac.Y.Mul(&jac.Y, &zz)
+	res.FromJacobian(&jac)
+	if !res.IsOnCurve() {
+		return res, errInvalidPoint
+	}
+	return res, nil
+}
+
+// parseElement parses a decimal string, that must be smaller than modulus
+func parseElement(s string, modulus *big.Int) (*big.Int, error) {
+	v, ok := new(big.Int).SetString(s, 10)
+	if !ok || v.Sign() < 0 || v.Cmp(modulus) >= 0 {
+		return nil, fmt.Errorf("snarkjs: invalid field element %q", s)
+	}
+	return v, nil
+}
@@ -0,0 +1,293 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snarkjs
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gurvy"
+	"github.com/consensys/gurvy/bn256"
+	"github.com/consensys/gurvy/bn256/fp"
+	"github.com/consensys/gurvy/bn256/fr"
+)
+
+type cubicCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+	Z frontend.Variable `gnark:",public"`
+}
+
+// x**3 + x + 5 == y, z == 2*x
+func (circuit *cubicCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	x3 := cs.Mul(circuit.X, circuit.X, circuit.X)
+	cs.AssertIsEqual(circuit.Y, cs.Add(x3, circuit.X, 5))
+	cs.AssertIsEqual(circuit.Z, cs.Mul(circuit.X, 2))
+	return nil
+}
+
+func setup(t *testing.T) (groth16.Proof, groth16.VerifyingKey, *cubicCircuit) {
+	var circuit cubicCircuit
+	r1cs, err := frontend.Compile(gurvy.BN256, &circuit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, vk, err := groth16.Setup(r1cs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var witness cubicCircuit
+	witness.X.Assign(3)
+	witness.Y.Assign(35)
+	witness.Z.Assign(6)
+	proof, err := groth16.Prove(r1cs, pk, &witness)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return proof, vk, &witness
+}
+
+// jsonRoundTrip encodes and decodes v, as written and read by snarkjs
+func jsonRoundTrip(t *testing.T, v, res interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, res); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	proof, vk, witness := setup(t)
+
+	sProof, err := ExportProof(proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sVK, err := ExportVerifyingKey(vk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signals, err := ExportPublicSignals(vk, witness)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(signals) != 2 || signals[0] != "35" || signals[1] != "6" {
+		t.Fatal("unexpected public signals", signals)
+	}
+
+	var _sProof Proof
+	var _sVK VerifyingKey
+	var _signals []string
+	jsonRoundTrip(t, sProof, &_sProof)
+	jsonRoundTrip(t, sVK, &_sVK)
+	jsonRoundTrip(t, signals, &_signals)
+
+	proof2, err := ImportProof(&_sProof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vk2, err := ImportVerifyingKey(&_sVK, []string{"Y", "Z"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assignment, err := ImportPublicSignals(vk2, _signals)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := groth16.Verify(proof2, vk2, assignment); err != nil {
+		t.Fatal(err)
+	}
+
+	// the verifying key doesn't accept other public inputs
+	_signals[0] = "36"
+	assignment, err = ImportPublicSignals(vk2, _signals)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := groth16.Verify(proof2, vk2, assignment); err == nil {
+		t.Fatal("verification should fail with wrong public signals")
+	}
+
+	if _, err := ImportVerifyingKey(&_sVK, []string{"Y"}); err == nil {
+		t.Fatal("expected error with a wrong number of public inputs")
+	}
+}
+
+// TestSnarkjsEquation checks the exported values with the verification equation of snarkjs
+// e(-A, B) * e(α, β) * e(Σ x_i * IC_i, γ) * e(C, δ) == 1
+func TestSnarkjsEquation(t *testing.T) {
+	proof, vk, witness := setup(t)
+
+	sProof, err := ExportProof(proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sVK, err := ExportVerifyingKey(vk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signals, err := ExportPublicSignals(vk, witness)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g1 := func(p G1) bn256.G1Affine {
+		res, err := importG1(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+	g2 := func(p G2) bn256.G2Affine {
+		res, err := importG2(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	var vkX, p bn256.G1Jac
+	ic0 := g1(sVK.IC[0])
+	vkX.FromAffine(&ic0)
+	for i, s := range signals {
+		var x big.Int
+		x.SetString(s, 10)
+		ic := g1(sVK.IC[i+1])
+		p.ScalarMultiplication(new(bn256.G1Jac).FromAffine(&ic), &x)
+		vkX.AddAssign(&p)
+	}
+	var vkXAff, minusA bn256.G1Affine
+	vkXAff.FromJacobian(&vkX)
+	minusA = g1(sProof.PiA)
+	minusA.Neg(&minusA)
+
+	ok, err := bn256.PairingCheck(
+		[]bn256.G1Affine{minusA, g1(sVK.Alpha1), vkXAff, g1(sProof.PiC)},
+		[]bn256.G2Affine{g2(sProof.PiB), g2(sVK.Beta2), g2(sVK.Gamma2), g2(sVK.Delta2)},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Fatal("snarkjs verification equation doesn't hold")
+	}
+}
+
+func TestPointEncoding(t *testing.T) {
+	// generators of snarkjs (and of the EIP-197 precompile); a swap of the G2 coordinates
+	// gives a point that is not on the curve
+	sG1 := G1{"1", "2", "1"}
+	sG2 := G2{
+		{"10857046999023057135944570762232829481370756359578518086990519993285655852781", "11559732032986387107991004021392285783925812861821192530917403151452391805634"},
+		{"8495653923123431417604973247489272438418190587263600148770280649306958101930", "4082367875863433681332203403145435568316851327593401208105741076214120093531"},
+		{"1", "0"},
+	}
+	g1, err := importG1(sG1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g2, err := importG2(sG2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exportG1(&g1) != sG1 || exportG2(&g2) != sG2 {
+		t.Fatal("generators encoding not preserved")
+	}
+	sG2[0][0], sG2[0][1] = sG2[0][1], sG2[0][0]
+	sG2[1][0], sG2[1][1] = sG2[1][1], sG2[1][0]
+	if _, err := importG2(sG2); err == nil {
+		t.Fatal("expected error with swapped G2 coordinates")
+	}
+
+	// projective coordinates (x*z, y*z, z)
+	var z, x, y fp.Element
+	z.SetUint64(42)
+	x.SetUint64(1).Mul(&x, &z)
+	y.SetUint64(2).Mul(&y, &z)
+	p, err := importG1(G1{x.String(), y.String(), z.String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.Equal(&g1) {
+		t.Fatal("projective coordinates not converted")
+	}
+
+	// infinity
+	var inf bn256.G1Affine
+	if p, err := importG1(exportG1(&inf)); err != nil || !p.IsInfinity() {
+		t.Fatal("infinity not preserved")
+	}
+
+	// not on curve, or not a field element
+	if _, err := importG1(G1{"1", "3", "1"}); err == nil {
+		t.Fatal("expected error for a point not on the curve")
+	}
+	if _, err := importG1(G1{fp.Modulus().String(), "2", "1"}); err == nil {
+		t.Fatal("expected error for a coordinate larger than the modulus")
+	}
+	if _, err := parseElement(fr.Modulus().String(), fr.Modulus()); err == nil {
+		t.Fatal("expected error for a public signal larger than the modulus")
+	}
+}
+
+// TestSnarkjsVerify runs snarkjs on the exported files, when snarkjs is installed
+func TestSnarkjsVerify(t *testing.T) {
+	snarkjs, err := exec.LookPath("snarkjs")
+	if err != nil {
+		t.Skip("snarkjs not found in PATH")
+	}
+	proof, vk, witness := setup(t)
+
+	sProof, err := ExportProof(proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sVK, err := ExportVerifyingKey(vk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signals, err := ExportPublicSignals(vk, witness)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	files := []string{"verification_key.json", "public.json", "proof.json"}
+	for i, v := range []interface{}{sVK, signals, sProof} {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, files[i]), data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cmd := exec.Command(snarkjs, "groth16", "verify", files[0], files[1], files[2])
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatal(string(out), err)
+	}
+}
@@ -0,0 +1,85 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vc
+
+import (
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra/twistededwards"
+	"github.com/consensys/gnark/std/hash/mimc"
+	"github.com/consensys/gnark/std/signature/eddsa"
+	"github.com/consensys/gurvy"
+)
+
+// mimcSeed is the seed of the MiMC hash of the credentials, the one of the eddsa gadget
+const mimcSeed = "seed"
+
+// Circuit proves the knowledge of a credential signed by the issuer, bound to the holder, and
+// discloses some of its attributes
+//
+// The issuer signs the commitment MiMC(Attributes[0], ..., Attributes[n-1], Holder.X, Holder.Y). The
+// holder signs the challenge of the verifier with the key of the credential. An attribute is
+// disclosed if Disclosed[i] is 1, in which case Values[i] is its value; otherwise Values[i] is 0.
+type Circuit struct {
+	IssuerKey  eddsa.PublicKey     `gnark:",public"`
+	Challenge  frontend.Variable   `gnark:",public"`
+	Disclosed  []frontend.Variable `gnark:",public"`
+	Values     []frontend.Variable `gnark:",public"`
+	Attributes []frontend.Variable
+	Holder     eddsa.PublicKey
+
+	IssuerSignature eddsa.Signature
+	HolderSignature eddsa.Signature
+}
+
+// NewCircuit returns a Circuit for credentials of nbAttributes attributes
+func NewCircuit(nbAttributes int) *Circuit {
+	return &Circuit{
+		Disclosed:  make([]frontend.Variable, nbAttributes),
+		Values:     make([]frontend.Variable, nbAttributes),
+		Attributes: make([]frontend.Variable, nbAttributes),
+	}
+}
+
+// Define declares the circuit constraints
+func (circuit *Circuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	params, err := twistededwards.NewEdCurve(curveID)
+	if err != nil {
+		return err
+	}
+	circuit.IssuerKey.Curve = params
+	circuit.Holder.Curve = params
+
+	// selective disclosure
+	for i := 0; i < len(circuit.Attributes); i++ {
+		cs.AssertIsBoolean(circuit.Disclosed[i])
+		cs.AssertIsEqual(circuit.Values[i], cs.Mul(circuit.Disclosed[i], circuit.Attributes[i]))
+	}
+
+	// issuer signature
+	hash, err := mimc.NewMiMC(mimcSeed, curveID)
+	if err != nil {
+		return err
+	}
+	data := append(append([]frontend.Variable{}, circuit.Attributes...), circuit.Holder.A.X, circuit.Holder.A.Y)
+	commitment := hash.Hash(cs, data...)
+	if err := eddsa.Verify(cs, circuit.IssuerSignature, commitment, circuit.IssuerKey); err != nil {
+		return err
+	}
+
+	// holder binding
+	return eddsa.Verify(cs, circuit.HolderSignature, circuit.Challenge, circuit.Holder)
+}
@@ -0,0 +1,304 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package vc issues credentials and proves selective disclosures of their attributes, in the shape
+// of W3C Verifiable Credentials (https://www.w3.org/TR/vc-data-model/) proof suites
+//
+// The issuer signs the attributes of a credential and the public key of its holder (Issue). To present
+// the credential, the holder proves with Groth16 (Suite.Present) that they know a credential signed by
+// the issuer, that they own its key (by signing the challenge of the verifier), and that the disclosed
+// attributes have the given values. The verifier learns nothing about the other attributes.
+//
+// Credentials use BN256: the keys are eddsa keys on its twisted Edwards curve, and the attributes are
+// elements of its scalar field (see EncodeString for text attributes).
+package vc
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/r1cs"
+	mimc_bn256 "github.com/consensys/gnark/crypto/hash/mimc/bn256"
+	eddsa_bn256 "github.com/consensys/gnark/crypto/signature/eddsa/bn256"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gurvy"
+	"github.com/consensys/gurvy/bn256/fr"
+	"github.com/consensys/gurvy/bn256/twistededwards"
+)
+
+// ProofType is the type of the proofs of the suite
+const ProofType = "GnarkGroth16BN254SelectiveDisclosure"
+
+// Contexts of the derived credentials
+var Contexts = []string{"https://www.w3.org/2018/credentials/v1"}
+
+var (
+	errAttribute  = errors.New("vc: unknown attribute")
+	errMissing    = errors.New("vc: missing attribute")
+	errProofType  = errors.New("vc: unsupported proof type")
+	errChallenge  = errors.New("vc: challenge doesn't match")
+	errNoProof    = errors.New("vc: credential has no proof")
+	errBadValue   = errors.New("vc: invalid attribute value")
+	errInvalidSig = errors.New("vc: invalid issuer signature")
+)
+
+// Schema is the type of a credential and the ordered names of its attributes
+type Schema struct {
+	Type       string
+	Attributes []string
+}
+
+// Credential is a credential signed by its issuer, kept by its holder
+type Credential struct {
+	Issuer     string // issuer ID, for example a DID
+	Attributes map[string]fr.Element
+	Holder     twistededwards.Point
+	Signature  eddsa_bn256.Signature
+}
+
+// DerivedCredential is a credential presented by its holder, with the disclosed attributes only
+type DerivedCredential struct {
+	Context           []string          `json:"@context"`
+	Type              []string          `json:"type"`
+	Issuer            string            `json:"issuer"`
+	CredentialSubject map[string]string `json:"credentialSubject"` // disclosed attributes, in decimal
+	Proof             *Proof            `json:"proof"`
+}
+
+// Proof is the proof of a DerivedCredential
+type Proof struct {
+	Type               string `json:"type"`
+	Created            string `json:"created"`
+	VerificationMethod string `json:"verificationMethod"` // the issuer key ID
+	ProofPurpose       string `json:"proofPurpose"`
+	Challenge          string `json:"challenge"`  // in decimal
+	ProofValue         string `json:"proofValue"` // base64url encoding of Proof.WriteTo
+}
+
+// EncodeString returns the attribute value of a text, its MiMC hash
+func EncodeString(s string) fr.Element {
+	var res fr.Element
+	h := mimc_bn256.NewMiMC(mimcSeed)
+	h.Write([]byte(s))
+	res.SetBytes(h.Sum(nil))
+	return res
+}
+
+// Suite proves and verifies the presentations of the credentials of a Schema
+type Suite struct {
+	schema Schema
+	r1cs   r1cs.R1CS
+}
+
+// NewSuite compiles the Circuit of the schema; the keys are generated with groth16.Setup(suite.R1CS())
+func NewSuite(schema Schema) (*Suite, error) {
+	_r1cs, err := frontend.Compile(gurvy.BN256, NewCircuit(len(schema.Attributes)))
+	if err != nil {
+		return nil, err
+	}
+	return &Suite{schema: schema, r1cs: _r1cs}, nil
+}
+
+// R1CS returns the compiled Circuit
+func (s *Suite) R1CS() r1cs.R1CS {
+	return s.r1cs
+}
+
+// Issue signs the attributes and the holder key, every attribute of the schema must be set
+func (s *Suite) Issue(issuer string, attributes map[string]fr.Element, holder eddsa_bn256.PublicKey,
+	issuerPub eddsa_bn256.PublicKey, issuerPriv eddsa_bn256.PrivateKey) (*Credential, error) {
+	if len(attributes) != len(s.schema.Attributes) {
+		return nil, errAttribute
+	}
+	cred := &Credential{Issuer: issuer, Attributes: attributes, Holder: holder.A}
+	commitment, err := s.commitment(cred)
+	if err != nil {
+		return nil, err
+	}
+	cred.Signature, err = eddsa_bn256.Sign(commitment, issuerPub, issuerPriv)
+	return cred, err
+}
+
+// commitment returns the message signed by the issuer
+func (s *Suite) commitment(cred *Credential) ([]byte, error) {
+	h := mimc_bn256.NewMiMC(mimcSeed)
+	for _, name := range s.schema.Attributes {
+		v, ok := cred.Attributes[name]
+		if !ok {
+			return nil, errMissing
+		}
+		b := v.Bytes()
+		h.Write(b[:])
+	}
+	x, y := cred.Holder.X.Bytes(), cred.Holder.Y.Bytes()
+	h.Write(x[:])
+	h.Write(y[:])
+	return h.Sum(nil), nil
+}
+
+// Present proves the credential, disclosing the given attributes, for the challenge of the verifier
+//
+// issuerKey is the ID of the issuer key (the verification method of the proof)
+func (s *Suite) Present(pk groth16.ProvingKey, cred *Credential, issuerPub eddsa_bn256.PublicKey, issuerKey string,
+	holder eddsa_bn256.PublicKey, holderPriv eddsa_bn256.PrivateKey, disclose []string, challenge fr.Element) (*DerivedCredential, error) {
+
+	disclosed := make(map[string]bool)
+	for _, name := range disclose {
+		if _, ok := cred.Attributes[name]; !ok {
+			return nil, errAttribute
+		}
+		disclosed[name] = true
+	}
+	c := challenge.Bytes()
+	holderSignature, err := eddsa_bn256.Sign(c[:], holder, holderPriv)
+	if err != nil {
+		return nil, err
+	}
+
+	witness := s.publicWitness(issuerPub, challenge, disclosed, cred.Attributes)
+	for i, name := range s.schema.Attributes {
+		witness.Attributes[i].Assign(cred.Attributes[name])
+	}
+	witness.Holder.A.X.Assign(cred.Holder.X)
+	witness.Holder.A.Y.Assign(cred.Holder.Y)
+	assignSignature(&witness.IssuerSignature.R.A.X, &witness.IssuerSignature.R.A.Y, &witness.IssuerSignature.S, &cred.Signature)
+	assignSignature(&witness.HolderSignature.R.A.X, &witness.HolderSignature.R.A.Y, &witness.HolderSignature.S, &holderSignature)
+
+	proof, err := groth16.Prove(s.r1cs, pk, witness)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if _, err := proof.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+
+	res := &DerivedCredential{
+		Context:           Contexts,
+		Type:              []string{"VerifiableCredential", s.schema.Type},
+		Issuer:            cred.Issuer,
+		CredentialSubject: make(map[string]string),
+		Proof: &Proof{
+			Type:               ProofType,
+			Created:            time.Now().UTC().Format(time.RFC3339),
+			VerificationMethod: issuerKey,
+			ProofPurpose:       "authentication",
+			Challenge:          challenge.String(),
+			ProofValue:         base64.RawURLEncoding.EncodeToString(buf.Bytes()),
+		},
+	}
+	for name := range disclosed {
+		v := cred.Attributes[name]
+		res.CredentialSubject[name] = v.String()
+	}
+	return res, nil
+}
+
+// Verify verifies the proof of a derived credential, for the issuer key and the challenge of the
+// verifier; the trust in issuerPub (the key of the issuer and of the verification method) is the
+// concern of the caller
+func (s *Suite) Verify(vk groth16.VerifyingKey, dc *DerivedCredential, issuerPub eddsa_bn256.PublicKey, challenge fr.Element) error {
+	if dc.Proof == nil {
+		return errNoProof
+	}
+	if dc.Proof.Type != ProofType {
+		return errProofType
+	}
+	if dc.Proof.Challenge != challenge.String() {
+		return errChallenge
+	}
+
+	disclosed := make(map[string]bool)
+	values := make(map[string]fr.Element)
+	for name, value := range dc.CredentialSubject {
+		var v big.Int
+		if _, ok := v.SetString(value, 10); !ok || v.Cmp(fr.Modulus()) >= 0 {
+			return errBadValue
+		}
+		var e fr.Element
+		e.SetBigInt(&v)
+		disclosed[name] = true
+		values[name] = e
+	}
+	for name := range disclosed {
+		if !s.hasAttribute(name) {
+			return errAttribute
+		}
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(dc.Proof.ProofValue)
+	if err != nil {
+		return err
+	}
+	proof := groth16.NewProof(gurvy.BN256)
+	if _, err := proof.ReadFrom(bytes.NewReader(data)); err != nil {
+		return err
+	}
+	return groth16.Verify(proof, vk, s.publicWitness(issuerPub, challenge, disclosed, values))
+}
+
+// VerifySignature checks the issuer signature of a credential, for example when the holder receives it
+func (s *Suite) VerifySignature(cred *Credential, issuerPub eddsa_bn256.PublicKey) error {
+	commitment, err := s.commitment(cred)
+	if err != nil {
+		return err
+	}
+	ok, err := eddsa_bn256.Verify(cred.Signature, commitment, issuerPub)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errInvalidSig
+	}
+	return nil
+}
+
+// publicWitness returns a Circuit with its public inputs assigned
+func (s *Suite) publicWitness(issuerPub eddsa_bn256.PublicKey, challenge fr.Element, disclosed map[string]bool, values map[string]fr.Element) *Circuit {
+	witness := NewCircuit(len(s.schema.Attributes))
+	witness.IssuerKey.A.X.Assign(issuerPub.A.X)
+	witness.IssuerKey.A.Y.Assign(issuerPub.A.Y)
+	witness.Challenge.Assign(challenge)
+	for i, name := range s.schema.Attributes {
+		if disclosed[name] {
+			witness.Disclosed[i].Assign(1)
+			witness.Values[i].Assign(values[name])
+		} else {
+			witness.Disclosed[i].Assign(0)
+			witness.Values[i].Assign(0)
+		}
+	}
+	return witness
+}
+
+func (s *Suite) hasAttribute(name string) bool {
+	for _, a := range s.schema.Attributes {
+		if a == name {
+			return true
+		}
+	}
+	return false
+}
+
+func assignSignature(rx, ry, S *frontend.Variable, sig *eddsa_bn256.Signature) {
+	rx.Assign(sig.R.X)
+	ry.Assign(sig.R.Y)
+	S.Assign(sig.S)
+}
@@ -0,0 +1,119 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vc
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/consensys/gnark/backend/groth16"
+	mimc_bn256 "github.com/consensys/gnark/crypto/hash/mimc/bn256"
+	eddsa_bn256 "github.com/consensys/gnark/crypto/signature/eddsa/bn256"
+	"github.com/consensys/gurvy/bn256/fr"
+)
+
+func newKeys(seed string) (eddsa_bn256.PublicKey, eddsa_bn256.PrivateKey) {
+	var s [32]byte
+	copy(s[:], seed)
+	return eddsa_bn256.New(s, mimc_bn256.NewMiMC("seed"))
+}
+
+func TestPresentation(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping the selective disclosure proof in short mode")
+	}
+	schema := Schema{Type: "DriverLicense", Attributes: []string{"name", "birthYear", "licenseClass"}}
+	suite, err := NewSuite(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, vk, err := groth16.Setup(suite.R1CS())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issuerPub, issuerPriv := newKeys("issuer")
+	holderPub, holderPriv := newKeys("holder")
+	var birthYear fr.Element
+	birthYear.SetUint64(1990)
+	attributes := map[string]fr.Element{
+		"name":         EncodeString("Alice"),
+		"birthYear":    birthYear,
+		"licenseClass": EncodeString("B"),
+	}
+	cred, err := suite.Issue("did:example:issuer", attributes, holderPub, issuerPub, issuerPriv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := suite.VerifySignature(cred, issuerPub); err != nil {
+		t.Fatal(err)
+	}
+
+	var challenge fr.Element
+	challenge.SetUint64(42)
+	dc, err := suite.Present(pk, cred, issuerPub, "did:example:issuer#key-1", holderPub, holderPriv, []string{"birthYear"}, challenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the derived credential goes through JSON
+	data, err := json.Marshal(dc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var received DerivedCredential
+	if err := json.Unmarshal(data, &received); err != nil {
+		t.Fatal(err)
+	}
+	if len(received.CredentialSubject) != 1 || received.CredentialSubject["birthYear"] != "1990" {
+		t.Fatal("unexpected disclosed attributes", received.CredentialSubject)
+	}
+	if err := suite.Verify(vk, &received, issuerPub, challenge); err != nil {
+		t.Fatal(err)
+	}
+
+	// replayed presentation
+	var other fr.Element
+	other.SetUint64(43)
+	if err := suite.Verify(vk, &received, issuerPub, other); err == nil {
+		t.Fatal("expected verification to fail for another challenge")
+	}
+	received.Proof.Challenge = other.String()
+	if err := suite.Verify(vk, &received, issuerPub, other); err == nil {
+		t.Fatal("expected verification to fail for a forged challenge")
+	}
+	received.Proof.Challenge = challenge.String()
+
+	// forged attribute
+	received.CredentialSubject["birthYear"] = "1980"
+	if err := suite.Verify(vk, &received, issuerPub, challenge); err == nil {
+		t.Fatal("expected verification to fail for a forged attribute")
+	}
+	received.CredentialSubject["birthYear"] = "1990"
+
+	// other issuer
+	otherPub, _ := newKeys("other issuer")
+	if err := suite.Verify(vk, &received, otherPub, challenge); err == nil {
+		t.Fatal("expected verification to fail for another issuer")
+	}
+
+	// the holder must own the key of the credential
+	thiefPub, thiefPriv := newKeys("thief")
+	if _, err := suite.Present(pk, cred, issuerPub, "did:example:issuer#key-1", thiefPub, thiefPriv, nil, challenge); err == nil {
+		t.Fatal("expected proving to fail with another holder key")
+	}
+}
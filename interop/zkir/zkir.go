@@ -0,0 +1,454 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package zkir exports compiled circuits to a neutral intermediate representation, and imports them back
+//
+// The IR describes a rank-1 constraint system over a prime field, independently of gnark's internal
+// encodings, so that other proving backends can consume circuits written with the gnark frontend, and
+// gnark can prove circuits produced by other frontends. It is a JSON document:
+//
+//	{
+//	  "version": "zkir/1",
+//	  "field": "21888...617",           // modulus of the field, in decimal
+//	  "nbWires": 4,
+//	  "public": ["Y"],                  // names of wires 1..len(public)
+//	  "secret": ["X"],                  // names of the next wires
+//	  "constraints": [
+//	    {"a": [[2, "1"]], "b": [[2, "1"]], "c": [[3, "1"]], "solve": "linear"},
+//	    {"a": [[3, "1"]], "b": [[2, "1"]], "c": [[1, "1"], [2, "21888...616"], [0, "21888...612"]]}
+//	  ],
+//	  "logs": [{"format": "x = %s", "wires": [2]}]
+//	}
+//
+// Wires are numbered: 0 is the constant 1, then the public inputs, then the secret inputs, then the
+// internal wires (up to nbWires). A constraint is a·b == c where a, b and c are linear combinations of
+// wires, lists of [wire, coefficient] with coefficients in decimal, reduced modulo the field.
+//
+// The "solve" field is a hint for the witness generation. A constraint with a hint computes the only
+// internal wire it uses that isn't known yet, from the constraints before it: "linear" solves the
+// equation for the wire, and "bits" sets the wires of a with the bits of the value of c (the
+// coefficient of a wire of a is 2^i for the i-th bit). The other constraints are assertions. Backends
+// which only prove can ignore the hints. A circuit without hints must have no internal wire (every
+// wire is an input, like in a circom witness).
+//
+// Constraints can have a debug message, and logs print values at solving time: "format" is a Go
+// format string with a %s per wire of "wires".
+package zkir
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/r1cs"
+	"github.com/consensys/gnark/backend/r1cs/r1c"
+	backend_bls377 "github.com/consensys/gnark/internal/backend/bls377"
+	backend_bls381 "github.com/consensys/gnark/internal/backend/bls381"
+	backend_bn256 "github.com/consensys/gnark/internal/backend/bn256"
+	backend_bw761 "github.com/consensys/gnark/internal/backend/bw761"
+	"github.com/consensys/gurvy"
+	fr_bls377 "github.com/consensys/gurvy/bls377/fr"
+	fr_bls381 "github.com/consensys/gurvy/bls381/fr"
+	fr_bn256 "github.com/consensys/gurvy/bn256/fr"
+	fr_bw761 "github.com/consensys/gurvy/bw761/fr"
+)
+
+// Version of the IR written by Export
+const Version = "zkir/1"
+
+// solving hints
+const (
+	SolveLinear = "linear"
+	SolveBits   = "bits"
+)
+
+var (
+	errVersion = errors.New("zkir: unsupported version")
+	errField   = errors.New("zkir: unsupported field")
+	errCurve   = errors.New("zkir: unsupported constraint system")
+	errHints   = errors.New("zkir: internal wires need solving hints")
+)
+
+// Circuit is the IR of a rank-1 constraint system
+type Circuit struct {
+	Version     string       `json:"version"`
+	Field       string       `json:"field"`
+	NbWires     int          `json:"nbWires"`
+	Public      []string     `json:"public"`
+	Secret      []string     `json:"secret"`
+	Constraints []Constraint `json:"constraints"`
+	Logs        []Log        `json:"logs,omitempty"`
+}
+
+// Constraint is the constraint A·B == C
+type Constraint struct {
+	A     []Term `json:"a"`
+	B     []Term `json:"b"`
+	C     []Term `json:"c"`
+	Solve string `json:"solve,omitempty"`
+	Debug *Log   `json:"debug,omitempty"`
+}
+
+// Term is a wire multiplied by a coefficient, encoded as [wire, "coefficient"]
+type Term struct {
+	Wire  int
+	Coeff big.Int
+}
+
+// Log is a message with the values of wires
+type Log struct {
+	Format string `json:"format"`
+	Wires  []int  `json:"wires,omitempty"`
+}
+
+// MarshalJSON encodes t as [wire, "coefficient"]
+func (t Term) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{t.Wire, t.Coeff.String()})
+}
+
+// UnmarshalJSON decodes [wire, "coefficient"]
+func (t *Term) UnmarshalJSON(data []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if len(raw) != 2 {
+		return errors.New("zkir: a term is [wire, coefficient]")
+	}
+	if err := json.Unmarshal(raw[0], &t.Wire); err != nil {
+		return err
+	}
+	var coeff string
+	if err := json.Unmarshal(raw[1], &coeff); err != nil {
+		return err
+	}
+	if _, ok := t.Coeff.SetString(coeff, 10); !ok {
+		return fmt.Errorf("zkir: invalid coefficient %q", coeff)
+	}
+	return nil
+}
+
+// compiled is the curve independent content of a typed R1CS
+type compiled struct {
+	curveID         gurvy.ID
+	nbWires         int
+	nbPublicWires   int
+	nbSecretWires   int
+	publicWires     []string
+	secretWires     []string
+	nbCOConstraints int
+	constraints     []r1c.R1C
+	coefficients    []big.Int
+	logs, debugInfo []backend.LogEntry
+	nbHints         int
+}
+
+// curves supported by the importer
+var curves = []struct {
+	id      gurvy.ID
+	modulus *big.Int
+}{
+	{gurvy.BN256, fr_bn256.Modulus()},
+	{gurvy.BLS377, fr_bls377.Modulus()},
+	{gurvy.BLS381, fr_bls381.Modulus()},
+	{gurvy.BW761, fr_bw761.Modulus()},
+}
+
+// Export lowers a compiled R1CS to the IR
+func Export(_r1cs r1cs.R1CS) (*Circuit, error) {
+	var c compiled
+	switch _r1cs := _r1cs.(type) {
+	case *backend_bn256.R1CS:
+		c = compiled{gurvy.BN256, int(_r1cs.NbWires), int(_r1cs.NbPublicWires), int(_r1cs.NbSecretWires), _r1cs.PublicWires, _r1cs.SecretWires,
+			int(_r1cs.NbCOConstraints), _r1cs.Constraints, make([]big.Int, len(_r1cs.Coefficients)), _r1cs.Logs, _r1cs.DebugInfo, len(_r1cs.Hints)}
+		for i := 0; i < len(_r1cs.Coefficients); i++ {
+			_r1cs.Coefficients[i].ToBigIntRegular(&c.coefficients[i])
+		}
+	case *backend_bls377.R1CS:
+		c = compiled{gurvy.BLS377, int(_r1cs.NbWires), int(_r1cs.NbPublicWires), int(_r1cs.NbSecretWires), _r1cs.PublicWires, _r1cs.SecretWires,
+			int(_r1cs.NbCOConstraints), _r1cs.Constraints, make([]big.Int, len(_r1cs.Coefficients)), _r1cs.Logs, _r1cs.DebugInfo, len(_r1cs.Hints)}
+		for i := 0; i < len(_r1cs.Coefficients); i++ {
+			_r1cs.Coefficients[i].ToBigIntRegular(&c.coefficients[i])
+		}
+	case *backend_bls381.R1CS:
+		c = compiled{gurvy.BLS381, int(_r1cs.NbWires), int(_r1cs.NbPublicWires), int(_r1cs.NbSecretWires), _r1cs.PublicWires, _r1cs.SecretWires,
+			int(_r1cs.NbCOConstraints), _r1cs.Constraints, make([]big.Int, len(_r1cs.Coefficients)), _r1cs.Logs, _r1cs.DebugInfo, len(_r1cs.Hints)}
+		for i := 0; i < len(_r1cs.Coefficients); i++ {
+			_r1cs.Coefficients[i].ToBigIntRegular(&c.coefficients[i])
+		}
+	case *backend_bw761.R1CS:
+		c = compiled{gurvy.BW761, int(_r1cs.NbWires), int(_r1cs.NbPublicWires), int(_r1cs.NbSecretWires), _r1cs.PublicWires, _r1cs.SecretWires,
+			int(_r1cs.NbCOConstraints), _r1cs.Constraints, make([]big.Int, len(_r1cs.Coefficients)), _r1cs.Logs, _r1cs.DebugInfo, len(_r1cs.Hints)}
+		for i := 0; i < len(_r1cs.Coefficients); i++ {
+			_r1cs.Coefficients[i].ToBigIntRegular(&c.coefficients[i])
+		}
+	default:
+		return nil, errCurve
+	}
+	return c.export()
+}
+
+func (c *compiled) export() (*Circuit, error) {
+	if c.nbHints != 0 {
+		return nil, errors.New("zkir: the IR has no hint functions")
+	}
+	var modulus *big.Int
+	for _, curve := range curves {
+		if curve.id == c.curveID {
+			modulus = curve.modulus
+		}
+	}
+
+	// gnark wires are [internal | secret | public], with backend.OneWire in public;
+	// IR wires are [one | public | secret | internal]
+	nbInternal := c.nbWires - c.nbPublicWires - c.nbSecretWires
+	wires := make([]int, c.nbWires) // gnark wire -> IR wire
+	res := &Circuit{
+		Version: Version,
+		Field:   modulus.String(),
+		NbWires: c.nbWires,
+		Public:  make([]string, 0, c.nbPublicWires-1),
+		Secret:  append([]string(nil), c.secretWires...),
+	}
+	oneFound := false
+	for i, name := range c.publicWires {
+		if name == backend.OneWire {
+			oneFound = true
+			wires[nbInternal+c.nbSecretWires+i] = 0
+			continue
+		}
+		res.Public = append(res.Public, name)
+		wires[nbInternal+c.nbSecretWires+i] = len(res.Public)
+	}
+	if !oneFound {
+		return nil, fmt.Errorf("zkir: missing %s wire", backend.OneWire)
+	}
+	for i := 0; i < c.nbSecretWires; i++ {
+		wires[nbInternal+i] = c.nbPublicWires + i
+	}
+	for i := 0; i < nbInternal; i++ {
+		wires[i] = c.nbPublicWires + c.nbSecretWires + i
+	}
+
+	linExp := func(l r1c.LinearExpression) []Term {
+		res := make([]Term, len(l))
+		for i, t := range l {
+			res[i].Wire = wires[t.VariableID()]
+			switch v := t.CoeffValue(); v {
+			case -1:
+				res[i].Coeff.Sub(modulus, big.NewInt(1))
+			case 0, 1, 2:
+				res[i].Coeff.SetInt64(int64(v))
+			default:
+				res[i].Coeff.Mod(&c.coefficients[t.CoeffID()], modulus)
+			}
+		}
+		return res
+	}
+	log := func(entry backend.LogEntry) Log {
+		res := Log{Format: entry.Format}
+		for _, w := range entry.ToResolve {
+			res.Wires = append(res.Wires, wires[w])
+		}
+		return res
+	}
+
+	res.Constraints = make([]Constraint, len(c.constraints))
+	for i := range c.constraints {
+		r := &c.constraints[i]
+		res.Constraints[i] = Constraint{A: linExp(r.L), B: linExp(r.R), C: linExp(r.O)}
+		if i < c.nbCOConstraints {
+			switch r.Solver {
+			case r1c.SingleOutput:
+				res.Constraints[i].Solve = SolveLinear
+			case r1c.BinaryDec:
+				res.Constraints[i].Solve = SolveBits
+			default:
+				return nil, fmt.Errorf("zkir: unknown solving method %d", r.Solver)
+			}
+		} else if j := i - c.nbCOConstraints; j < len(c.debugInfo) {
+			debug := log(c.debugInfo[j])
+			res.Constraints[i].Debug = &debug
+		}
+	}
+	for _, entry := range c.logs {
+		res.Logs = append(res.Logs, log(entry))
+	}
+	return res, nil
+}
+
+// Import returns the R1CS of the IR, on the curve whose scalar field is the field of the IR
+//
+// constraints with a solving hint are moved before the assertions (the order of the hints is kept)
+func Import(c *Circuit) (r1cs.R1CS, error) {
+	if c.Version != Version {
+		return nil, errVersion
+	}
+	var modulus big.Int
+	if _, ok := modulus.SetString(c.Field, 10); !ok {
+		return nil, errField
+	}
+	curveID := gurvy.UNKNOWN
+	for _, curve := range curves {
+		if curve.modulus.Cmp(&modulus) == 0 {
+			curveID = curve.id
+		}
+	}
+	if curveID == gurvy.UNKNOWN {
+		return nil, errField
+	}
+
+	nbPublic := len(c.Public) + 1
+	nbSecret := len(c.Secret)
+	nbInternal := c.NbWires - nbPublic - nbSecret
+	if nbInternal < 0 {
+		return nil, errors.New("zkir: more inputs than wires")
+	}
+
+	// IR wires are [one | public | secret | internal]; gnark wires are [internal | secret | public]
+	wire := func(w int) (int, backend.Visibility, error) {
+		switch {
+		case w < 0 || w >= c.NbWires:
+			return 0, backend.Unset, fmt.Errorf("zkir: wire %d out of range", w)
+		case w < nbPublic:
+			return nbInternal + nbSecret + w, backend.Public, nil
+		case w < nbPublic+nbSecret:
+			return nbInternal + w - nbPublic, backend.Secret, nil
+		default:
+			return w - nbPublic - nbSecret, backend.Internal, nil
+		}
+	}
+
+	res := &r1cs.UntypedR1CS{
+		NbWires:       uint64(c.NbWires),
+		NbPublicWires: uint64(nbPublic),
+		NbSecretWires: uint64(nbSecret),
+		PublicWires:   append([]string{backend.OneWire}, c.Public...),
+		SecretWires:   append([]string(nil), c.Secret...),
+		NbConstraints: uint64(len(c.Constraints)),
+		Coefficients:  r1cs.NewCoeffArena(0, 0),
+	}
+
+	coeffIDs := make(map[string]int)
+	var minusOne big.Int
+	minusOne.Sub(&modulus, big.NewInt(1))
+	linExp := func(terms []Term) (r1c.LinearExpression, error) {
+		l := make(r1c.LinearExpression, len(terms))
+		for i, t := range terms {
+			id, visibility, err := wire(t.Wire)
+			if err != nil {
+				return nil, err
+			}
+			var coeff big.Int
+			coeff.Mod(&t.Coeff, &modulus)
+			key := coeff.String()
+			coeffID, ok := coeffIDs[key]
+			if !ok {
+				coeffID = res.Coefficients.Append(&coeff)
+				coeffIDs[key] = coeffID
+			}
+			l[i] = r1c.Pack(id, coeffID, visibility)
+			switch {
+			case coeff.Cmp(&minusOne) == 0:
+				l[i].SetCoeffValue(-1)
+			case coeff.IsInt64() && coeff.Int64() <= 2:
+				l[i].SetCoeffValue(int(coeff.Int64()))
+			}
+		}
+		return l, nil
+	}
+	log := func(l *Log) (backend.LogEntry, error) {
+		entry := backend.LogEntry{Format: l.Format}
+		for _, w := range l.Wires {
+			id, _, err := wire(w)
+			if err != nil {
+				return entry, err
+			}
+			entry.ToResolve = append(entry.ToResolve, id)
+		}
+		return entry, nil
+	}
+
+	var assertions []r1c.R1C
+	for i := range c.Constraints {
+		constraint := &c.Constraints[i]
+		var r r1c.R1C
+		var err error
+		if r.L, err = linExp(constraint.A); err != nil {
+			return nil, err
+		}
+		if r.R, err = linExp(constraint.B); err != nil {
+			return nil, err
+		}
+		if r.O, err = linExp(constraint.C); err != nil {
+			return nil, err
+		}
+		switch constraint.Solve {
+		case SolveLinear, SolveBits:
+			if constraint.Solve == SolveBits {
+				r.Solver = r1c.BinaryDec
+			}
+			res.Constraints = append(res.Constraints, r)
+		case "":
+			assertions = append(assertions, r)
+			debug := backend.LogEntry{Format: fmt.Sprintf("constraint #%d", i)}
+			if constraint.Debug != nil {
+				if debug, err = log(constraint.Debug); err != nil {
+					return nil, err
+				}
+			}
+			res.DebugInfo = append(res.DebugInfo, debug)
+		default:
+			return nil, fmt.Errorf("zkir: unknown solving hint %q", constraint.Solve)
+		}
+	}
+	if nbInternal > 0 && len(res.Constraints) == 0 {
+		return nil, errHints
+	}
+	res.NbCOConstraints = uint64(len(res.Constraints))
+	res.Constraints = append(res.Constraints, assertions...)
+
+	for i := range c.Logs {
+		entry, err := log(&c.Logs[i])
+		if err != nil {
+			return nil, err
+		}
+		res.Logs = append(res.Logs, entry)
+	}
+
+	return res.ToR1CS(curveID), nil
+}
+
+// Write exports the R1CS and writes its IR to w, in JSON
+func Write(w io.Writer, _r1cs r1cs.R1CS) error {
+	c, err := Export(_r1cs)
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(w).Encode(c)
+}
+
+// Read reads an IR in JSON from r and imports it
+func Read(r io.Reader) (r1cs.R1CS, error) {
+	var c Circuit
+	if err := json.NewDecoder(r).Decode(&c); err != nil {
+		return nil, err
+	}
+	return Import(&c)
+}
@@ -0,0 +1,165 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zkir
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gurvy"
+	"github.com/consensys/gurvy/bn256/fr"
+)
+
+type circuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+	Z frontend.Variable `gnark:",public"`
+}
+
+// x**3 + x + 5 == y, and z is x on 8 bits
+func (circuit *circuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	x3 := cs.Mul(circuit.X, circuit.X, circuit.X)
+	cs.AssertIsEqual(circuit.Y, cs.Add(x3, circuit.X, 5))
+	bits := cs.ToBinary(circuit.X, 8)
+	cs.AssertIsEqual(circuit.Z, cs.FromBinary(bits...))
+	cs.AssertIsLessOrEqual(circuit.X, 200)
+	return nil
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, curveID := range []gurvy.ID{gurvy.BN256, gurvy.BLS377, gurvy.BLS381, gurvy.BW761} {
+		var c circuit
+		r1cs, err := frontend.Compile(curveID, &c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := Write(&buf, r1cs); err != nil {
+			t.Fatal(err)
+		}
+		imported, err := Read(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if imported.GetCurveID() != curveID || imported.GetNbConstraints() != r1cs.GetNbConstraints() || imported.GetNbWires() != r1cs.GetNbWires() {
+			t.Fatal("imported R1CS doesn't match the compiled one")
+		}
+
+		good := map[string]interface{}{"X": 3, "Y": 35, "Z": 3}
+		if err := imported.IsSolved(good); err != nil {
+			t.Fatal(err)
+		}
+		for _, bad := range []map[string]interface{}{
+			{"X": 3, "Y": 36, "Z": 3},
+			{"X": 3, "Y": 35, "Z": 4},
+			{"X": 250, "Y": 15625255, "Z": 250},
+		} {
+			if err := imported.IsSolved(bad); !errors.Is(err, backend.ErrUnsatisfiedConstraint) {
+				t.Fatal("expected unsatisfied constraint, got", err)
+			}
+		}
+
+		// the export of the imported R1CS is the same IR, up to the order of the constraints
+		c1, err := Export(r1cs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c2, err := Export(imported)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(c1.Constraints) != len(c2.Constraints) || len(c1.Public) != 2 || c1.Public[0] != "Y" || c1.Secret[0] != "X" {
+			t.Fatal("unexpected IR")
+		}
+	}
+}
+
+func TestProveImported(t *testing.T) {
+	var c circuit
+	r1cs, err := frontend.Compile(gurvy.BN256, &c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ir, err := Export(r1cs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	imported, err := Import(ir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, vk, err := groth16.Setup(imported)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := groth16.Prove(imported, pk, map[string]interface{}{"X": 3, "Y": 35, "Z": 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := groth16.Verify(proof, vk, map[string]interface{}{"Y": 35, "Z": 3}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// an IR written by hand, without solving hints: every wire is an input
+func TestImportWithoutHints(t *testing.T) {
+	var minusOne big.Int
+	minusOne.Sub(fr.Modulus(), big.NewInt(1))
+	term := func(wire int, coeff int64) Term {
+		t := Term{Wire: wire}
+		t.Coeff.SetInt64(coeff)
+		return t
+	}
+
+	// x * x == y, y + 1 == out
+	ir := &Circuit{
+		Version: Version,
+		Field:   fr.Modulus().String(),
+		NbWires: 4,
+		Public:  []string{"out"},
+		Secret:  []string{"x", "y"},
+		Constraints: []Constraint{
+			{A: []Term{term(2, 1)}, B: []Term{term(2, 1)}, C: []Term{term(3, 1)}},
+			{A: []Term{term(3, 1), term(0, 1)}, B: []Term{term(0, 1)}, C: []Term{term(1, 1)}, Debug: &Log{Format: "out == %s + 1", Wires: []int{3}}},
+		},
+	}
+	imported, err := Import(ir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := imported.IsSolved(map[string]interface{}{"x": 3, "y": 9, "out": 10}); err != nil {
+		t.Fatal(err)
+	}
+	if err := imported.IsSolved(map[string]interface{}{"x": 3, "y": 9, "out": 11}); !errors.Is(err, backend.ErrUnsatisfiedConstraint) {
+		t.Fatal("expected unsatisfied constraint, got", err)
+	}
+
+	ir.NbWires = 5
+	if _, err := Import(ir); err != errHints {
+		t.Fatal("expected errHints, got", err)
+	}
+	ir.NbWires = 4
+	ir.Field = "7"
+	if _, err := Import(ir); err != errField {
+		t.Fatal("expected errField, got", err)
+	}
+}
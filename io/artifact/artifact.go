@@ -0,0 +1,370 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package artifact stores the R1CS and keys of circuits, on the filesystem, in S3 or in GCS
+//
+// Artifacts are content addressed: a Repository names them after the hash of the serialized R1CS
+// (CircuitHash), so that a prover fetches the keys of the circuit it compiled, without managing file
+// paths, and a stale key can't be used with a modified circuit. A CachedStore keeps a local copy of
+// the artifacts of a remote store:
+//
+//	remote := &artifact.S3Store{Bucket: "keys", Region: "eu-west-1", AccessKeyID: id, SecretAccessKey: secret}
+//	repo := artifact.Repository{Store: &artifact.CachedStore{Remote: remote, Cache: artifact.FileStore{Dir: "/var/cache/gnark"}}}
+//	hash, pk, vk, err := repo.Setup(r1cs) // fetches the keys, or runs the setup and uploads them
+//
+// The stores implement server.Store.
+package artifact
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/r1cs"
+	"github.com/consensys/gurvy"
+)
+
+var (
+	// ErrNotFound is returned by a Store for a missing artifact
+	ErrNotFound = errors.New("artifact not found")
+	// ErrExists is returned by Store.PutIfAbsent for an artifact which is already stored
+	ErrExists = errors.New("artifact already exists")
+	// ErrHashMismatch is returned when a R1CS doesn't match its hash
+	ErrHashMismatch = errors.New("artifact: R1CS doesn't match its hash")
+
+	errKey = errors.New("artifact: invalid key")
+)
+
+// extensions of the artifacts of a circuit
+const (
+	ExtR1CS         = ".r1cs"
+	ExtProvingKey   = ".pk"
+	ExtVerifyingKey = ".vk"
+)
+
+// Store persists artifacts
+//
+// keys are made of ASCII letters, digits, '-', '_', '.' and '/' (as a separator)
+type Store interface {
+	Put(key string, data []byte) error
+	PutIfAbsent(key string, data []byte) error // returns ErrExists if key is stored, and keeps its data
+	Get(key string) ([]byte, error)            // returns ErrNotFound if key is missing
+}
+
+// checkKey rejects the keys which are not portable across stores (or would escape a FileStore directory)
+func checkKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.HasSuffix(key, "/") {
+		return errKey
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return errKey
+		}
+	}
+	for _, c := range key {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.ContainsRune("-_./", c)) {
+			return errKey
+		}
+	}
+	return nil
+}
+
+// FileStore is a Store keeping the artifacts in the files of a directory
+type FileStore struct {
+	Dir string
+}
+
+// Put writes data to the file of key; the file is replaced atomically, so that concurrent readers
+// never see a partial artifact
+func (s FileStore) Put(key string, data []byte) error {
+	path, tmp, err := s.writeTemp(key, data)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// PutIfAbsent writes data to the file of key, unless it exists; the file is created atomically
+// (hard link of a complete temporary file), so that concurrent writers can't both succeed
+func (s FileStore) PutIfAbsent(key string, data []byte) error {
+	path, tmp, err := s.writeTemp(key, data)
+	if err != nil {
+		return err
+	}
+	err = os.Link(tmp, path)
+	os.Remove(tmp)
+	if os.IsExist(err) {
+		return ErrExists
+	}
+	return err
+}
+
+// writeTemp writes data to a temporary file, next to the file of key
+func (s FileStore) writeTemp(key string, data []byte) (path, tmp string, err error) {
+	if err := checkKey(key); err != nil {
+		return "", "", err
+	}
+	path = filepath.Join(s.Dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", "", err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(path), ".tmp-")
+	if err != nil {
+		return "", "", err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", "", err
+	}
+	return path, f.Name(), nil
+}
+
+// Get reads the file of key, or returns ErrNotFound
+func (s FileStore) Get(key string) ([]byte, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadFile(filepath.Join(s.Dir, filepath.FromSlash(key)))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+// CachedStore reads through a local cache of a remote Store
+//
+// the artifacts of a Repository are immutable (content addressed, or written once with PutIfAbsent),
+// so the cache is never invalidated
+type CachedStore struct {
+	Remote Store
+	Cache  Store
+}
+
+// Put writes data to the remote store, then to the cache
+func (s *CachedStore) Put(key string, data []byte) error {
+	if err := s.Remote.Put(key, data); err != nil {
+		return err
+	}
+	return s.Cache.Put(key, data)
+}
+
+// PutIfAbsent writes data to the remote store if key is missing, then to the cache; the cache is left
+// untouched if the remote store returns ErrExists
+func (s *CachedStore) PutIfAbsent(key string, data []byte) error {
+	if err := s.Remote.PutIfAbsent(key, data); err != nil {
+		return err
+	}
+	return s.Cache.Put(key, data)
+}
+
+// Get reads data from the cache, or from the remote store (and caches it)
+func (s *CachedStore) Get(key string) ([]byte, error) {
+	data, err := s.Cache.Get(key)
+	if err == nil {
+		return data, nil
+	}
+	if !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	if data, err = s.Remote.Get(key); err != nil {
+		return nil, err
+	}
+	return data, s.Cache.Put(key, data)
+}
+
+// CircuitHash returns the hex encoded SHA-256 of the serialized R1CS (R1CS.WriteTo)
+func CircuitHash(_r1cs r1cs.R1CS) (string, error) {
+	h := sha256.New()
+	if _, err := _r1cs.WriteTo(h); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Key returns the key of an artifact (ExtR1CS, ExtProvingKey or ExtVerifyingKey) of a circuit
+//
+// the curve prefixes the key, to list the artifacts of a curve
+func Key(curveID gurvy.ID, hash, ext string) string {
+	return curveID.String() + "/" + hash + ext
+}
+
+// Repository stores the artifacts of circuits, named after the hash of their R1CS
+type Repository struct {
+	Store Store
+}
+
+// PutR1CS stores the R1CS and returns its hash
+func (r Repository) PutR1CS(_r1cs r1cs.R1CS) (string, error) {
+	var buf bytes.Buffer
+	if _, err := _r1cs.WriteTo(&buf); err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	hash := hex.EncodeToString(sum[:])
+	return hash, r.Store.Put(Key(_r1cs.GetCurveID(), hash, ExtR1CS), buf.Bytes())
+}
+
+// R1CS returns the stored R1CS of hash, after checking its hash
+func (r Repository) R1CS(curveID gurvy.ID, hash string) (r1cs.R1CS, error) {
+	data, err := r.Store.Get(Key(curveID, hash, ExtR1CS))
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != hash {
+		return nil, ErrHashMismatch
+	}
+	res := r1cs.New(curveID)
+	if err := decode(data, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// PutKeys stores the keys of the circuit of hash, or returns ErrExists if other keys are stored
+//
+// the proving key is named after the hash of the verifying key, and the verifying key is written
+// last, only if it is absent: its presence means that the keys are complete, and the first keys
+// stored are the keys of the circuit
+func (r Repository) PutKeys(curveID gurvy.ID, hash string, pk groth16.ProvingKey, vk groth16.VerifyingKey) error {
+	vkData, err := encode(vk)
+	if err != nil {
+		return err
+	}
+	pkData, err := encode(pk)
+	if err != nil {
+		return err
+	}
+	if err := r.Store.Put(provingKeyKey(curveID, hash, vkData), pkData); err != nil {
+		return err
+	}
+	return r.Store.PutIfAbsent(Key(curveID, hash, ExtVerifyingKey), vkData)
+}
+
+// ProvingKey returns the stored proving key of the circuit of hash
+func (r Repository) ProvingKey(curveID gurvy.ID, hash string) (groth16.ProvingKey, error) {
+	key := Key(curveID, hash, ExtVerifyingKey)
+	vkData, err := r.Store.Get(key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", key, err)
+	}
+	pk := groth16.NewProvingKey(curveID)
+	if err := r.get(provingKeyKey(curveID, hash, vkData), pk); err != nil {
+		return nil, err
+	}
+	return pk, nil
+}
+
+// provingKeyKey returns the key of the proving key matching the encoded verifying key vkData
+func provingKeyKey(curveID gurvy.ID, hash string, vkData []byte) string {
+	sum := sha256.Sum256(vkData)
+	return Key(curveID, hash+"-"+hex.EncodeToString(sum[:]), ExtProvingKey)
+}
+
+// VerifyingKey returns the stored verifying key of the circuit of hash
+func (r Repository) VerifyingKey(curveID gurvy.ID, hash string) (groth16.VerifyingKey, error) {
+	vk := groth16.NewVerifyingKey(curveID)
+	if err := r.get(Key(curveID, hash, ExtVerifyingKey), vk); err != nil {
+		return nil, err
+	}
+	return vk, nil
+}
+
+// Setup returns the hash of the R1CS and its stored keys; if the keys are not stored, it runs
+// groth16.Setup and stores the R1CS and the keys
+//
+// concurrent Setup of the same circuit on several machines generate different keys, but only the
+// first keys stored are kept (see PutKeys): Setup reads back the stored verifying key, and returns
+// the stored keys if they are not the ones it generated
+func (r Repository) Setup(_r1cs r1cs.R1CS, opts ...backend.Option) (string, groth16.ProvingKey, groth16.VerifyingKey, error) {
+	curveID := _r1cs.GetCurveID()
+	hash, err := CircuitHash(_r1cs)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	vk, err := r.VerifyingKey(curveID, hash)
+	if err == nil {
+		pk, err := r.ProvingKey(curveID, hash)
+		return hash, pk, vk, err
+	}
+	if !errors.Is(err, ErrNotFound) {
+		return "", nil, nil, err
+	}
+
+	pk, vk, err := groth16.Setup(_r1cs, opts...)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	if _, err := r.PutR1CS(_r1cs); err != nil {
+		return "", nil, nil, err
+	}
+	if err := r.PutKeys(curveID, hash, pk, vk); err != nil && !errors.Is(err, ErrExists) {
+		return "", nil, nil, err
+	}
+
+	vkData, err := encode(vk)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	stored, err := r.Store.Get(Key(curveID, hash, ExtVerifyingKey))
+	if err != nil {
+		return "", nil, nil, err
+	}
+	if !bytes.Equal(stored, vkData) {
+		if vk, err = r.VerifyingKey(curveID, hash); err != nil {
+			return "", nil, nil, err
+		}
+		if pk, err = r.ProvingKey(curveID, hash); err != nil {
+			return "", nil, nil, err
+		}
+	}
+	return hash, pk, vk, nil
+}
+
+func encode(v io.WriterTo) ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := v.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (r Repository) get(key string, v io.ReaderFrom) error {
+	data, err := r.Store.Get(key)
+	if err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	return decode(data, v)
+}
+
+func decode(data []byte, v io.ReaderFrom) error {
+	_, err := v.ReadFrom(bytes.NewReader(data))
+	return err
+}
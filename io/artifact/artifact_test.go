@@ -0,0 +1,345 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package artifact
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gurvy"
+)
+
+type cubicCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+// x**3 + x + 5 == y
+func (circuit *cubicCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	x3 := cs.Mul(circuit.X, circuit.X, circuit.X)
+	cs.AssertIsEqual(circuit.Y, cs.Add(x3, circuit.X, 5))
+	return nil
+}
+
+// memStore is a Store counting its reads
+type memStore struct {
+	lock    sync.Mutex
+	data    map[string][]byte
+	nbReads int
+}
+
+func (s *memStore) Put(key string, data []byte) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.data == nil {
+		s.data = make(map[string][]byte)
+	}
+	s.data[key] = append([]byte(nil), data...)
+	return nil
+}
+
+func (s *memStore) PutIfAbsent(key string, data []byte) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, ok := s.data[key]; ok {
+		return ErrExists
+	}
+	if s.data == nil {
+		s.data = make(map[string][]byte)
+	}
+	s.data[key] = append([]byte(nil), data...)
+	return nil
+}
+
+func (s *memStore) Get(key string) ([]byte, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.nbReads++
+	data, ok := s.data[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return data, nil
+}
+
+func testStore(t *testing.T, s Store) {
+	t.Helper()
+	if _, err := s.Get("bn256/missing.vk"); !errors.Is(err, ErrNotFound) {
+		t.Fatal("expected ErrNotFound, got", err)
+	}
+	if err := s.Put("bn256/abc.vk", []byte("vk")); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put("bn256/abc.vk", []byte("vk2")); err != nil {
+		t.Fatal(err)
+	}
+	data, err := s.Get("bn256/abc.vk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "vk2" {
+		t.Fatalf("got %q", data)
+	}
+	if err := s.PutIfAbsent("bn256/abc.vk", []byte("vk3")); !errors.Is(err, ErrExists) {
+		t.Fatal("expected ErrExists, got", err)
+	}
+	if err := s.PutIfAbsent("bn256/def.vk", []byte("vk")); err != nil {
+		t.Fatal(err)
+	}
+	for key, expected := range map[string]string{"bn256/abc.vk": "vk2", "bn256/def.vk": "vk"} {
+		if data, err := s.Get(key); err != nil || string(data) != expected {
+			t.Fatalf("%s: got %q, %v", key, data, err)
+		}
+	}
+	for _, key := range []string{"", "/abc", "abc/", "../abc", "a//b", "a b", "a?b"} {
+		if err := s.Put(key, nil); err != errKey {
+			t.Fatalf("%q: expected errKey, got %v", key, err)
+		}
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "artifact")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	testStore(t, FileStore{Dir: dir})
+}
+
+// AWS Signature Version 4 test suite, get-vanilla
+func TestSignV4(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	payloadHash := sha256.Sum256(nil)
+	signV4(req, payloadHash[:], "AKIDEXAMPLE", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "us-east-1", "service",
+		time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+	expected := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != expected {
+		t.Fatalf("expected %s\ngot %s", expected, got)
+	}
+}
+
+func TestS3Store(t *testing.T) {
+	objects := &memStore{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		payloadHash := sha256.Sum256(body)
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=id/") ||
+			r.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(payloadHash[:]) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		key := strings.TrimPrefix(r.URL.Path, "/bucket/")
+		switch r.Method {
+		case http.MethodPut:
+			if r.Header.Get("If-None-Match") == "*" {
+				if err := objects.PutIfAbsent(key, body); err != nil {
+					w.WriteHeader(http.StatusPreconditionFailed)
+				}
+				return
+			}
+			objects.Put(key, body)
+		case http.MethodGet:
+			data, err := objects.Get(key)
+			if err != nil {
+				http.Error(w, "<Error><Code>NoSuchKey</Code></Error>", http.StatusNotFound)
+				return
+			}
+			w.Write(data)
+		}
+	}))
+	defer server.Close()
+
+	testStore(t, &S3Store{Bucket: "bucket", Region: "us-east-1", Endpoint: server.URL, Prefix: "gnark/", AccessKeyID: "id", SecretAccessKey: "secret"})
+	if _, ok := objects.data["gnark/bn256/abc.vk"]; !ok {
+		t.Fatal("prefix not applied")
+	}
+
+	_, err := (&S3Store{Bucket: "bucket", Endpoint: server.URL, AccessKeyID: "other"}).Get("bn256/abc.vk")
+	if err == nil || errors.Is(err, ErrNotFound) || !strings.Contains(err.Error(), "403") {
+		t.Fatal("expected a 403 error, got", err)
+	}
+}
+
+func TestGCSStore(t *testing.T) {
+	objects := &memStore{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/upload/storage/v1/b/bucket/o" && r.URL.Query().Get("uploadType") == "media":
+			body, _ := ioutil.ReadAll(r.Body)
+			if r.URL.Query().Get("ifGenerationMatch") == "0" {
+				if err := objects.PutIfAbsent(r.URL.Query().Get("name"), body); err != nil {
+					w.WriteHeader(http.StatusPreconditionFailed)
+					return
+				}
+			} else {
+				objects.Put(r.URL.Query().Get("name"), body)
+			}
+			w.Write([]byte("{}"))
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/storage/v1/b/bucket/o/") && r.URL.Query().Get("alt") == "media":
+			data, err := objects.Get(strings.TrimPrefix(r.URL.Path, "/storage/v1/b/bucket/o/"))
+			if err != nil {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write(data)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	token := func() (string, error) { return "token", nil }
+	testStore(t, &GCSStore{Bucket: "bucket", Endpoint: server.URL, Token: token})
+}
+
+func TestRepository(t *testing.T) {
+	dir, err := ioutil.TempDir("", "artifact")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var circuit cubicCircuit
+	r1cs, err := frontend.Compile(gurvy.BN256, &circuit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	remote := &memStore{}
+	repo := Repository{Store: &CachedStore{Remote: remote, Cache: FileStore{Dir: dir}}}
+	hash, _, vk, err := repo.Setup(r1cs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := remote.data[Key(gurvy.BN256, hash, ExtVerifyingKey)]; !ok || len(remote.data) != 3 {
+		t.Fatal("keys not uploaded")
+	}
+
+	// another prover, with an empty cache, fetches the keys instead of running the setup
+	dir2, err := ioutil.TempDir("", "artifact")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir2)
+	repo2 := Repository{Store: &CachedStore{Remote: remote, Cache: FileStore{Dir: dir2}}}
+	hash2, _, vk2, err := repo2.Setup(r1cs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b1, b2 bytes.Buffer
+	vk.WriteTo(&b1)
+	vk2.WriteTo(&b2)
+	if hash2 != hash || !bytes.Equal(b1.Bytes(), b2.Bytes()) {
+		t.Fatal("keys were generated again")
+	}
+	nbReads := remote.nbReads
+	if _, err := repo2.ProvingKey(gurvy.BN256, hash); err != nil {
+		t.Fatal(err)
+	}
+	if remote.nbReads != nbReads {
+		t.Fatal("proving key not cached")
+	}
+
+	// the R1CS is checked against its hash
+	stored, err := repo2.R1CS(gurvy.BN256, hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.GetNbConstraints() != r1cs.GetNbConstraints() {
+		t.Fatal("R1CS doesn't match")
+	}
+	remote.data[Key(gurvy.BN256, hash, ExtR1CS)] = []byte("tampered")
+	if _, err := (Repository{Store: remote}).R1CS(gurvy.BN256, hash); err != ErrHashMismatch {
+		t.Fatal("expected ErrHashMismatch, got", err)
+	}
+}
+
+// lateStore runs f after the first lookup of a missing artifact
+type lateStore struct {
+	Store
+	f func()
+}
+
+func (s *lateStore) Get(key string) ([]byte, error) {
+	data, err := s.Store.Get(key)
+	if errors.Is(err, ErrNotFound) && s.f != nil {
+		f := s.f
+		s.f = nil
+		f()
+	}
+	return data, err
+}
+
+func TestConcurrentSetup(t *testing.T) {
+	var circuit cubicCircuit
+	r1cs, err := frontend.Compile(gurvy.BN256, &circuit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// another Setup stores its keys after the lookup of the verifying key, before the keys are written
+	remote := &memStore{}
+	var first [2][]byte
+	late := &lateStore{Store: remote, f: func() {
+		_, pk, vk, err := Repository{Store: remote}.Setup(r1cs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if first[0], err = encode(pk); err != nil {
+			t.Fatal(err)
+		}
+		if first[1], err = encode(vk); err != nil {
+			t.Fatal(err)
+		}
+	}}
+	hash, pk, vk, err := Repository{Store: late}.Setup(r1cs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the first keys stored are kept, and returned by both setups
+	for i, v := range []io.WriterTo{pk, vk} {
+		data, err := encode(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, first[i]) {
+			t.Fatal("expected the keys of the first setup")
+		}
+	}
+	if stored := remote.data[Key(gurvy.BN256, hash, ExtVerifyingKey)]; !bytes.Equal(stored, first[1]) {
+		t.Fatal("the verifying key was overwritten")
+	}
+}
@@ -0,0 +1,93 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package artifact
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// GCSStore is a Store keeping the artifacts in the objects of a Google Cloud Storage bucket, with the
+// JSON API
+//
+// Token returns the OAuth 2.0 access token of the requests (for example from
+// golang.org/x/oauth2/google.DefaultTokenSource); a nil Token sends unauthenticated requests, for public
+// buckets or emulators
+type GCSStore struct {
+	Bucket   string
+	Endpoint string // default: https://storage.googleapis.com
+	Prefix   string // prepended to the keys, for example "gnark/"
+	Token    func() (string, error)
+	Client   *http.Client // default: http.DefaultClient
+}
+
+// Put uploads data to the object of key
+func (s *GCSStore) Put(key string, data []byte) error {
+	return s.upload(key, data, url.Values{})
+}
+
+// PutIfAbsent uploads data to the object of key, unless it exists (ifGenerationMatch=0)
+func (s *GCSStore) PutIfAbsent(key string, data []byte) error {
+	return s.upload(key, data, url.Values{"ifGenerationMatch": {"0"}})
+}
+
+func (s *GCSStore) upload(key string, data []byte, query url.Values) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	query.Set("uploadType", "media")
+	query.Set("name", s.Prefix+key)
+	req, err := http.NewRequest(http.MethodPost, s.endpoint()+"/upload/storage/v1/b/"+url.PathEscape(s.Bucket)+"/o?"+query.Encode(), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	_, err = s.do(req)
+	return err
+}
+
+// Get downloads the object of key, or returns ErrNotFound
+func (s *GCSStore) Get(key string) ([]byte, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodGet, s.endpoint()+"/storage/v1/b/"+url.PathEscape(s.Bucket)+"/o/"+url.PathEscape(s.Prefix+key)+"?alt=media", nil)
+	if err != nil {
+		return nil, err
+	}
+	return s.do(req)
+}
+
+func (s *GCSStore) endpoint() string {
+	if s.Endpoint == "" {
+		return "https://storage.googleapis.com"
+	}
+	return strings.TrimSuffix(s.Endpoint, "/")
+}
+
+func (s *GCSStore) do(req *http.Request) ([]byte, error) {
+	if s.Token != nil {
+		token, err := s.Token()
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return doRequest(s.Client, req, "gcs")
+}
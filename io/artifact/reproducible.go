@@ -0,0 +1,134 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package artifact
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+
+	"github.com/consensys/gnark/backend/r1cs"
+	"github.com/consensys/gnark/frontend"
+	backend_bls377 "github.com/consensys/gnark/internal/backend/bls377"
+	backend_bls381 "github.com/consensys/gnark/internal/backend/bls381"
+	backend_bn256 "github.com/consensys/gnark/internal/backend/bn256"
+	backend_bw761 "github.com/consensys/gnark/internal/backend/bw761"
+	"github.com/consensys/gurvy"
+)
+
+// ErrNotReproducible is returned when two compilations of a circuit give different R1CS
+var ErrNotReproducible = errors.New("artifact: compiling the circuit is not reproducible")
+
+var errR1CS = errors.New("artifact: unsupported R1CS")
+
+// ConstraintsHash returns the hex encoded SHA-256 of the serialized R1CS, without its logs, debug
+// information and source locations
+//
+// unlike CircuitHash, it doesn't depend on the paths of the source files (the debug information has
+// the call stacks of the assertions, see also frontend.WithSourceLocations), so it identifies a circuit across machines: it is the hash to
+// publish with the keys of a circuit, and to check with VerifyHash
+func ConstraintsHash(_r1cs r1cs.R1CS) (string, error) {
+	var stripped r1cs.R1CS
+	switch _r1cs := _r1cs.(type) {
+	case *backend_bn256.R1CS:
+		c := *_r1cs
+		c.Logs, c.DebugInfo, c.Locations = nil, nil, nil
+		stripped = &c
+	case *backend_bls377.R1CS:
+		c := *_r1cs
+		c.Logs, c.DebugInfo, c.Locations = nil, nil, nil
+		stripped = &c
+	case *backend_bls381.R1CS:
+		c := *_r1cs
+		c.Logs, c.DebugInfo, c.Locations = nil, nil, nil
+		stripped = &c
+	case *backend_bw761.R1CS:
+		c := *_r1cs
+		c.Logs, c.DebugInfo, c.Locations = nil, nil, nil
+		stripped = &c
+	default:
+		return "", errR1CS
+	}
+	return CircuitHash(stripped)
+}
+
+// VerifyHash compiles the circuit from the local source, and checks its ConstraintsHash against a
+// published hash (ErrHashMismatch)
+func VerifyHash(curveID gurvy.ID, circuit frontend.Circuit, hash string) error {
+	_r1cs, err := frontend.Compile(curveID, circuit)
+	if err != nil {
+		return err
+	}
+	h, err := ConstraintsHash(_r1cs)
+	if err != nil {
+		return err
+	}
+	if h != hash {
+		return fmt.Errorf("%w: %s, expected %s", ErrHashMismatch, h, hash)
+	}
+	return nil
+}
+
+// CheckReproducible compiles twice the circuits returned by newCircuit (a new, not compiled, circuit
+// on each call), and returns their ConstraintsHash, or ErrNotReproducible if the R1CS differ
+func CheckReproducible(curveID gurvy.ID, newCircuit func() frontend.Circuit) (string, error) {
+	var hashes [2]string
+	for i := range hashes {
+		_r1cs, err := frontend.Compile(curveID, newCircuit())
+		if err != nil {
+			return "", err
+		}
+		if hashes[i], err = ConstraintsHash(_r1cs); err != nil {
+			return "", err
+		}
+	}
+	if hashes[0] != hashes[1] {
+		return "", fmt.Errorf("%w: %s != %s", ErrNotReproducible, hashes[0], hashes[1])
+	}
+	return hashes[0], nil
+}
+
+// CheckReproducibleCommand runs twice a command writing a serialized R1CS (R1CS.WriteTo) on its
+// standard output, for example "go run ./cmd/export", and returns their ConstraintsHash, or
+// ErrNotReproducible if the R1CS differ
+//
+// the circuit is compiled in two processes, so that a compilation depending on the state of the
+// process (the iteration order of a map, a random value) is detected
+func CheckReproducibleCommand(curveID gurvy.ID, name string, arg ...string) (string, error) {
+	var hashes [2]string
+	for i := range hashes {
+		cmd := exec.Command(name, arg...)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return "", fmt.Errorf("%s: %w: %s", name, err, bytes.TrimSpace(stderr.Bytes()))
+		}
+		_r1cs := r1cs.New(curveID)
+		if err := decode(out, _r1cs); err != nil {
+			return "", err
+		}
+		if hashes[i], err = ConstraintsHash(_r1cs); err != nil {
+			return "", err
+		}
+	}
+	if hashes[0] != hashes[1] {
+		return "", fmt.Errorf("%w: %s != %s", ErrNotReproducible, hashes[0], hashes[1])
+	}
+	return hashes[0], nil
+}
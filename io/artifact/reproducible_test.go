@@ -0,0 +1,112 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package artifact
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/consensys/gnark/frontend"
+	backend_bn256 "github.com/consensys/gnark/internal/backend/bn256"
+	"github.com/consensys/gurvy"
+	"github.com/stretchr/testify/require"
+)
+
+// sumCircuit has linear expressions with many terms
+type sumCircuit struct {
+	X [16]frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (circuit *sumCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	sum := cs.Constant(0)
+	for i := range circuit.X {
+		sum = cs.Add(sum, cs.Mul(circuit.X[i], i+3), circuit.X[(i+5)%len(circuit.X)])
+	}
+	cs.AssertIsLessOrEqual(circuit.X[0], 42)
+	cs.AssertIsEqual(circuit.Y, sum)
+	return nil
+}
+
+func TestCheckReproducible(t *testing.T) {
+	assert := require.New(t)
+	newCircuit := func() frontend.Circuit { return &sumCircuit{} }
+	hash, err := CheckReproducible(gurvy.BN256, newCircuit)
+	assert.NoError(err)
+	for i := 0; i < 10; i++ {
+		h, err := CheckReproducible(gurvy.BN256, newCircuit)
+		assert.NoError(err)
+		assert.Equal(hash, h)
+	}
+
+	assert.NoError(VerifyHash(gurvy.BN256, &sumCircuit{}, hash))
+	err = VerifyHash(gurvy.BN256, &cubicCircuit{}, hash)
+	assert.True(errors.Is(err, ErrHashMismatch), "%v", err)
+}
+
+// the constraints hash doesn't depend on the debug information
+func TestConstraintsHash(t *testing.T) {
+	assert := require.New(t)
+	_r1cs, err := frontend.Compile(gurvy.BN256, &sumCircuit{})
+	assert.NoError(err)
+	constraintsHash, err := ConstraintsHash(_r1cs)
+	assert.NoError(err)
+	circuitHash, err := CircuitHash(_r1cs)
+	assert.NoError(err)
+
+	moved := *_r1cs.(*backend_bn256.R1CS)
+	moved.DebugInfo = append(moved.DebugInfo[:0:0], moved.DebugInfo...)
+	moved.DebugInfo[0].Format = "/another/path/circuit.go:42"
+	h, err := ConstraintsHash(&moved)
+	assert.NoError(err)
+	assert.Equal(constraintsHash, h)
+	h, err = CircuitHash(&moved)
+	assert.NoError(err)
+	assert.NotEqual(circuitHash, h)
+
+	untyped, err := frontend.Compile(gurvy.UNKNOWN, &sumCircuit{})
+	assert.NoError(err)
+	_, err = ConstraintsHash(untyped)
+	assert.Equal(errR1CS, err)
+}
+
+// TestWriteR1CS is run by TestCheckReproducibleCommand in a child process
+func TestWriteR1CS(t *testing.T) {
+	if os.Getenv("GNARK_TEST_WRITE_R1CS") == "" {
+		t.Skip("only run by TestCheckReproducibleCommand")
+	}
+	_r1cs, err := frontend.Compile(gurvy.BN256, &sumCircuit{})
+	if err != nil {
+		os.Exit(1)
+	}
+	_r1cs.WriteTo(os.Stdout)
+	os.Exit(0)
+}
+
+func TestCheckReproducibleCommand(t *testing.T) {
+	assert := require.New(t)
+	os.Setenv("GNARK_TEST_WRITE_R1CS", "1")
+	defer os.Unsetenv("GNARK_TEST_WRITE_R1CS")
+
+	hash, err := CheckReproducibleCommand(gurvy.BN256, os.Args[0], "-test.run=^TestWriteR1CS$")
+	assert.NoError(err)
+	assert.NoError(VerifyHash(gurvy.BN256, &sumCircuit{}, hash))
+
+	_, err = CheckReproducibleCommand(gurvy.BN256, os.Args[0], "-test.run=^TestWriteR1CS$", "-test.unknownflag")
+	assert.Error(err)
+}
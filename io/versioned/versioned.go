@@ -0,0 +1,270 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package versioned serializes the compiled R1CS and the Groth16 proving keys, verifying keys and
+// proofs with a header identifying them
+//
+// the header has the version of the format, the kind of the object, its curve and the encoding of
+// its points, so that a file can be read without knowing what it contains, and a file written by a
+// newer version of gnark is rejected instead of being misread:
+//
+//	versioned.Write(f, pk, versioned.Raw) // fast to reload locally
+//	versioned.Write(f, vk, versioned.Compressed) // half the size, to distribute
+//	obj, _, err := versioned.Read(f) // obj is a groth16.VerifyingKey
+//
+// the payload after the header is the object as written by WriteTo (compressed points) or
+// WriteRawTo (uncompressed points); a R1CS has no points and is always written by WriteTo.
+//
+// the version changes with the encoding of the payloads: Read converts the payloads of the older
+// versions it supports, or rejects them.
+package versioned
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/r1cs"
+	backend_bls377 "github.com/consensys/gnark/internal/backend/bls377"
+	groth16_bls377 "github.com/consensys/gnark/internal/backend/bls377/groth16"
+	backend_bls381 "github.com/consensys/gnark/internal/backend/bls381"
+	groth16_bls381 "github.com/consensys/gnark/internal/backend/bls381/groth16"
+	backend_bn256 "github.com/consensys/gnark/internal/backend/bn256"
+	groth16_bn256 "github.com/consensys/gnark/internal/backend/bn256/groth16"
+	backend_bw761 "github.com/consensys/gnark/internal/backend/bw761"
+	groth16_bw761 "github.com/consensys/gnark/internal/backend/bw761/groth16"
+	"github.com/consensys/gurvy"
+)
+
+// Version is the version of the format written by Write
+//
+// in version 1, the proofs and the proving keys have no number of commitments: their commitment, if
+// any, follows Krs (resp. pk.G2.B) up to the end of the payload. Read inserts the number of commitments
+// of the proofs, and rejects the proving keys with a commitment, which is not blinded (the setup must
+// be run again).
+const Version = 2
+
+// magic starts the header
+const magic = "gnark"
+
+// headerSize is the size of the header: magic | version | kind | curve | encoding
+const headerSize = len(magic) + 2 + 1 + 2 + 1
+
+// Kind is the kind of object following a header
+type Kind uint8
+
+// kinds of objects
+const (
+	KindR1CS Kind = iota + 1
+	KindProvingKey
+	KindVerifyingKey
+	KindProof
+)
+
+// Encoding of the curve points
+type Encoding uint8
+
+// encodings of the curve points
+const (
+	Compressed Encoding = iota // WriteTo
+	Raw                        // WriteRawTo
+)
+
+// Header precedes the objects written by Write
+type Header struct {
+	Version  uint16
+	Kind     Kind
+	CurveID  gurvy.ID
+	Encoding Encoding
+}
+
+var (
+	// ErrNotVersioned is returned by Read for data which doesn't start with a header
+	ErrNotVersioned = errors.New("versioned: missing header")
+	// ErrVersion is returned by Read for data written with a newer version of the format
+	ErrVersion = errors.New("versioned: unsupported version")
+
+	errUnsupported  = errors.New("versioned: unsupported object")
+	errV1Commitment = errors.New("versioned: version 1 proving keys with a commitment are not supported, run the setup again")
+	errCurve        = errors.New("versioned: unsupported curve")
+	errEncoding     = errors.New("versioned: invalid encoding")
+)
+
+// Write writes the header of v, then v with the points in the given encoding
+//
+// v is a r1cs.R1CS, a groth16.ProvingKey, a groth16.VerifyingKey or a groth16.Proof
+func Write(w io.Writer, v interface{}, encoding Encoding) (int64, error) {
+	if encoding != Compressed && encoding != Raw {
+		return 0, errEncoding
+	}
+	kind, curveID, err := kindOf(v)
+	if err != nil {
+		return 0, err
+	}
+
+	var header [headerSize]byte
+	copy(header[:], magic)
+	binary.BigEndian.PutUint16(header[len(magic):], Version)
+	header[len(magic)+2] = byte(kind)
+	binary.BigEndian.PutUint16(header[len(magic)+3:], uint16(curveID))
+	header[len(magic)+5] = byte(encoding)
+	n, err := w.Write(header[:])
+	if err != nil {
+		return int64(n), err
+	}
+
+	var m int64
+	if encoding == Raw && kind != KindR1CS {
+		m, err = v.(interface {
+			WriteRawTo(io.Writer) (int64, error)
+		}).WriteRawTo(w)
+	} else {
+		m, err = v.(io.WriterTo).WriteTo(w)
+	}
+	return int64(n) + m, err
+}
+
+// ReadHeader reads and checks the header written by Write
+func ReadHeader(r io.Reader) (Header, error) {
+	var b [headerSize]byte
+	if _, err := io.ReadFull(r, b[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return Header{}, ErrNotVersioned
+		}
+		return Header{}, err
+	}
+	if string(b[:len(magic)]) != magic {
+		return Header{}, ErrNotVersioned
+	}
+	h := Header{
+		Version:  binary.BigEndian.Uint16(b[len(magic):]),
+		Kind:     Kind(b[len(magic)+2]),
+		CurveID:  gurvy.ID(binary.BigEndian.Uint16(b[len(magic)+3:])),
+		Encoding: Encoding(b[len(magic)+5]),
+	}
+	if h.Version == 0 || h.Version > Version {
+		return Header{}, ErrVersion
+	}
+	if h.Kind < KindR1CS || h.Kind > KindProof {
+		return Header{}, errUnsupported
+	}
+	switch h.CurveID {
+	case gurvy.BN256, gurvy.BLS377, gurvy.BLS381, gurvy.BW761:
+	default:
+		return Header{}, errCurve
+	}
+	if h.Encoding != Compressed && h.Encoding != Raw {
+		return Header{}, errEncoding
+	}
+	return h, nil
+}
+
+// Read reads an object written by Write: a r1cs.R1CS, a groth16.ProvingKey, a groth16.VerifyingKey
+// or a groth16.Proof, as given by the Kind of its header
+func Read(r io.Reader) (interface{}, int64, error) {
+	h, err := ReadHeader(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	var v io.ReaderFrom
+	switch h.Kind {
+	case KindR1CS:
+		v = r1cs.New(h.CurveID)
+	case KindProvingKey:
+		v = groth16.NewProvingKey(h.CurveID)
+	case KindVerifyingKey:
+		v = groth16.NewVerifyingKey(h.CurveID)
+	case KindProof:
+		v = groth16.NewProof(h.CurveID)
+	}
+	var inserted int64
+	if h.Version == 1 && h.Kind == KindProof {
+		if r, inserted, err = upgradeProofV1(h, r); err != nil {
+			return nil, int64(headerSize), err
+		}
+	}
+	n, err := v.ReadFrom(r)
+	n -= inserted
+	if h.Version == 1 && h.Kind == KindProvingKey && (err != nil || hasCommitment(v)) {
+		return nil, int64(headerSize) + n, errV1Commitment
+	}
+	if err != nil {
+		return nil, int64(headerSize) + n, err
+	}
+	return v, int64(headerSize) + n, nil
+}
+
+// upgradeProofV1 returns the payload of a version 1 proof with the number of commitments after Krs, and
+// the number of bytes inserted
+func upgradeProofV1(h Header, r io.Reader) (io.Reader, int64, error) {
+	payload, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// the size of Ar | Bs | Krs is the size of a proof without commitment, less its number of commitments
+	var empty bytes.Buffer
+	if _, err := Write(&empty, groth16.NewProof(h.CurveID), h.Encoding); err != nil {
+		return nil, 0, err
+	}
+	end := empty.Len() - headerSize - 8
+	if len(payload) <= end {
+		// no commitment: Proof.ReadFrom reads the proofs ending after Krs
+		return bytes.NewReader(payload), 0, nil
+	}
+	upgraded := make([]byte, 0, len(payload)+8)
+	upgraded = append(upgraded, payload[:end]...)
+	upgraded = append(upgraded, 0, 0, 0, 0, 0, 0, 0, 1)
+	upgraded = append(upgraded, payload[end:]...)
+	return bytes.NewReader(upgraded), 8, nil
+}
+
+// hasCommitment returns true if the proving key pk has a commitment key
+func hasCommitment(pk io.ReaderFrom) bool {
+	switch pk := pk.(type) {
+	case *groth16_bn256.ProvingKey:
+		return pk.Commitment != nil
+	case *groth16_bls377.ProvingKey:
+		return pk.Commitment != nil
+	case *groth16_bls381.ProvingKey:
+		return pk.Commitment != nil
+	case *groth16_bw761.ProvingKey:
+		return pk.Commitment != nil
+	default:
+		return false
+	}
+}
+
+// kindOf returns the kind and the curve of v
+func kindOf(v interface{}) (Kind, gurvy.ID, error) {
+	var kind Kind
+	switch v.(type) {
+	case *backend_bn256.R1CS, *backend_bls377.R1CS, *backend_bls381.R1CS, *backend_bw761.R1CS:
+		kind = KindR1CS
+	case *groth16_bn256.ProvingKey, *groth16_bls377.ProvingKey, *groth16_bls381.ProvingKey, *groth16_bw761.ProvingKey:
+		kind = KindProvingKey
+	case *groth16_bn256.VerifyingKey, *groth16_bls377.VerifyingKey, *groth16_bls381.VerifyingKey, *groth16_bw761.VerifyingKey:
+		kind = KindVerifyingKey
+	case *groth16_bn256.Proof, *groth16_bls377.Proof, *groth16_bls381.Proof, *groth16_bw761.Proof:
+		kind = KindProof
+	default:
+		return 0, gurvy.UNKNOWN, errUnsupported
+	}
+	return kind, v.(interface{ GetCurveID() gurvy.ID }).GetCurveID(), nil
+}
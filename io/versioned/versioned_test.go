@@ -0,0 +1,265 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package versioned
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/r1cs"
+	"github.com/consensys/gnark/frontend"
+	groth16_bn256 "github.com/consensys/gnark/internal/backend/bn256/groth16"
+	"github.com/consensys/gurvy"
+	"github.com/consensys/gurvy/bn256"
+)
+
+type cubicCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+// x**3 + x + 5 == y
+func (circuit *cubicCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	x3 := cs.Mul(circuit.X, circuit.X, circuit.X)
+	cs.AssertIsEqual(circuit.Y, cs.Add(x3, circuit.X, 5))
+	return nil
+}
+
+func TestWriteRead(t *testing.T) {
+	for _, curveID := range []gurvy.ID{gurvy.BN256, gurvy.BLS381} {
+		_r1cs, err := frontend.Compile(curveID, &cubicCircuit{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		pk, vk, err := groth16.Setup(_r1cs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var solution cubicCircuit
+		solution.X.Assign(3)
+		solution.Y.Assign(35)
+		proof, err := groth16.Prove(_r1cs, pk, &solution)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		objects := []struct {
+			v    interface{}
+			kind Kind
+		}{{_r1cs, KindR1CS}, {pk, KindProvingKey}, {vk, KindVerifyingKey}, {proof, KindProof}}
+		for _, o := range objects {
+			sizes := make(map[Encoding]int)
+			for _, encoding := range []Encoding{Compressed, Raw} {
+				var buf bytes.Buffer
+				written, err := Write(&buf, o.v, encoding)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if written != int64(buf.Len()) {
+					t.Fatal(o.kind, encoding, "Write returned", written, "bytes, wrote", buf.Len())
+				}
+				sizes[encoding] = buf.Len()
+
+				h, err := ReadHeader(bytes.NewReader(buf.Bytes()))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if h != (Header{Version: Version, Kind: o.kind, CurveID: curveID, Encoding: encoding}) {
+					t.Fatal("unexpected header", h)
+				}
+
+				v, read, err := Read(&buf)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if read != written {
+					t.Fatal("Read returned", read, "bytes, expected", written)
+				}
+				switch v := v.(type) {
+				case r1cs.R1CS:
+					if o.kind != KindR1CS || v.GetNbConstraints() != _r1cs.GetNbConstraints() {
+						t.Fatal("the R1CS doesn't match")
+					}
+				case groth16.Proof:
+					// the interfaces of the keys and of the proof have the same methods
+					switch o.kind {
+					case KindProvingKey:
+						if pk.IsDifferent(v) {
+							t.Fatal("the proving key doesn't match")
+						}
+					case KindVerifyingKey:
+						if vk.IsDifferent(v) {
+							t.Fatal("the verifying key doesn't match")
+						}
+					case KindProof:
+						if err := groth16.Verify(v, vk, &solution); err != nil {
+							t.Fatal(err)
+						}
+					default:
+						t.Fatal("unexpected object")
+					}
+				}
+			}
+			if o.kind != KindR1CS && sizes[Compressed] >= sizes[Raw] {
+				t.Fatal("compressed encoding isn't smaller than the raw one")
+			}
+		}
+	}
+}
+
+func TestReadInvalid(t *testing.T) {
+	_r1cs, err := frontend.Compile(gurvy.BN256, &cubicCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, vk, err := groth16.Setup(_r1cs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if _, err := Write(&buf, vk, Compressed); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	// the format of WriteTo, without header
+	var unversioned bytes.Buffer
+	if _, err := vk.WriteTo(&unversioned); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := Read(&unversioned); err != ErrNotVersioned {
+		t.Fatal("expected ErrNotVersioned, got", err)
+	}
+	if _, _, err := Read(bytes.NewReader(data[:3])); err != ErrNotVersioned {
+		t.Fatal("expected ErrNotVersioned, got", err)
+	}
+
+	tampered := func(offset int, b ...byte) []byte {
+		res := append([]byte(nil), data...)
+		copy(res[offset:], b)
+		return res
+	}
+	newer := make([]byte, 2)
+	binary.BigEndian.PutUint16(newer, Version+1)
+	if _, _, err := Read(bytes.NewReader(tampered(len(magic), newer...))); err != ErrVersion {
+		t.Fatal("expected ErrVersion, got", err)
+	}
+	if _, _, err := Read(bytes.NewReader(tampered(len(magic)+2, 42))); err != errUnsupported {
+		t.Fatal("expected errUnsupported, got", err)
+	}
+	if _, _, err := Read(bytes.NewReader(tampered(len(magic)+3, 0, byte(gurvy.UNKNOWN)))); err != errCurve {
+		t.Fatal("expected errCurve, got", err)
+	}
+	if _, _, err := Read(bytes.NewReader(tampered(len(magic)+5, 2))); err != errEncoding {
+		t.Fatal("expected errEncoding, got", err)
+	}
+	if _, _, err := Read(bytes.NewReader(data[:len(data)-1])); err == nil {
+		t.Fatal("expected an error for a truncated verifying key")
+	}
+
+	if _, err := Write(&buf, struct{}{}, Compressed); err != errUnsupported {
+		t.Fatal("expected errUnsupported, got", err)
+	}
+	if _, err := Write(&buf, vk, Raw+1); err != errEncoding {
+		t.Fatal("expected errEncoding, got", err)
+	}
+}
+
+type commitCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+// x**3 + x + 5 == y, with a commitment to x
+func (circuit *commitCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	cs.Commit(circuit.X)
+	x3 := cs.Mul(circuit.X, circuit.X, circuit.X)
+	cs.AssertIsEqual(circuit.Y, cs.Add(x3, circuit.X, 5))
+	return nil
+}
+
+// TestReadV1 checks that the version 1 proofs, without number of commitments, are read, and that the
+// version 1 proving keys with a commitment are rejected
+func TestReadV1(t *testing.T) {
+	for _, circuit := range []frontend.Circuit{&cubicCircuit{}, &commitCircuit{}} {
+		_r1cs, err := frontend.Compile(gurvy.BN256, circuit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pk, vk, err := groth16.Setup(_r1cs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		solution := map[string]interface{}{"X": 3, "Y": 35}
+		proof, err := groth16.Prove(_r1cs, pk, solution)
+		if err != nil {
+			t.Fatal(err)
+		}
+		withCommitment := groth16.HasCommitment(vk)
+
+		// the version 1 encoding of v has no number of commitments, which follows the points of end
+		v1 := func(v, end interface{}, encoding Encoding) []byte {
+			var buf, bufEnd bytes.Buffer
+			if _, err := Write(&buf, v, encoding); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Write(&bufEnd, end, encoding); err != nil {
+				t.Fatal(err)
+			}
+			at := bufEnd.Len() - 8
+			data := append(append([]byte(nil), buf.Bytes()[:at]...), buf.Bytes()[at+8:]...)
+			binary.BigEndian.PutUint16(data[len(magic):], 1)
+			return data
+		}
+
+		for _, encoding := range []Encoding{Compressed, Raw} {
+			data := v1(proof, groth16.NewProof(gurvy.BN256), encoding)
+			v, read, err := Read(bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if read != int64(len(data)) {
+				t.Fatal("Read returned", read, "bytes, expected", len(data))
+			}
+			if err := groth16.Verify(v.(groth16.Proof), vk, solution); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// the number of commitments of the proving key follows pk.G2.B; the commitment keys of version 1
+		// had no blinding points
+		without := *pk.(*groth16_bn256.ProvingKey)
+		without.Commitment = nil
+		data := v1(pk, &without, Compressed)
+		if withCommitment {
+			data = data[:len(data)-3*bn256.SizeOfG1AffineCompressed]
+			if _, _, err := Read(bytes.NewReader(data)); err != errV1Commitment {
+				t.Fatal("expected errV1Commitment, got", err)
+			}
+			continue
+		}
+		v, _, err := Read(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pk.IsDifferent(v) {
+			t.Fatal("the proving key doesn't match")
+		}
+	}
+}
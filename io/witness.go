@@ -11,7 +11,7 @@ import (
 	"github.com/consensys/gnark/backend"
 )
 
-// TODO this is deprecated, we might need a type Witness = map[string]interface{}
+// see package witness for a witness keyed by the names of the inputs of a circuit, serialized in this format
 
 // WriteWitness serialize variable map[name]value into writer
 //
@@ -55,7 +55,14 @@ func ReadWitness(reader io.Reader, into map[string]interface{}) error {
 		return err
 	}
 
-	for k, v := range toRead {
+	return ParseWitness(toRead, into)
+}
+
+// ParseWitness converts the base10 or base16 (0x prefixed) strings of values to big.Int
+//
+// it is used by ReadWitness, and by transports which don't encode the witnesses in JSON
+func ParseWitness(values map[string]string, into map[string]interface{}) error {
+	for k, v := range values {
 		if strings.HasPrefix(v, "0x") {
 			bytes, err := hex.DecodeString(v[2:])
 			if err != nil {
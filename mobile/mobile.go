@@ -0,0 +1,169 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package mobile is the gnark API for iOS and Android apps, bound with gomobile
+//
+//	gomobile bind -target=android github.com/consensys/gnark/mobile
+//	gomobile bind -target=ios github.com/consensys/gnark/mobile
+//
+// It only uses the types supported by gomobile (strings, byte slices, integers, errors and the types
+// of this package). The circuits are compiled in Go: the R1CS and the proving key are serialized with
+// their WriteTo methods and shipped as files, read without copying them through the language boundary.
+// Witnesses are built input by input, with decimal or hexadecimal ("0x") values.
+//
+// Curves are named "bn256", "bls377", "bls381" or "bw761".
+package mobile
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"runtime/debug"
+	"strings"
+
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/r1cs"
+	"github.com/consensys/gnark/internal/bindings"
+)
+
+// Witness is an assignment of the inputs of a circuit
+type Witness struct {
+	values map[string]interface{}
+}
+
+// NewWitness returns an empty witness
+func NewWitness() *Witness {
+	return &Witness{values: make(map[string]interface{})}
+}
+
+// Set assigns the input name, value is a decimal or hexadecimal ("0x") integer
+func (w *Witness) Set(name, value string) error {
+	var v big.Int
+	ok := false
+	if strings.HasPrefix(value, "0x") {
+		_, ok = v.SetString(value[2:], 16)
+	} else {
+		_, ok = v.SetString(value, 10)
+	}
+	if !ok {
+		return errors.New("invalid value for input " + name)
+	}
+	w.values[name] = v
+	return nil
+}
+
+// Prover proves a circuit; it holds the R1CS and the proving key in memory
+//
+// a Prover can be used by one goroutine (thread) at a time
+type Prover struct {
+	r1cs r1cs.R1CS
+	pk   groth16.ProvingKey
+	opts []backend.Option
+}
+
+// NewProver reads the R1CS and proving key files
+func NewProver(curve, r1csPath, pkPath string) (*Prover, error) {
+	curveID, err := bindings.ParseCurve(curve)
+	if err != nil {
+		return nil, err
+	}
+	p := &Prover{r1cs: r1cs.New(curveID), pk: groth16.NewProvingKey(curveID)}
+	if err := readFile(r1csPath, p.r1cs); err != nil {
+		return nil, err
+	}
+	if err := readFile(pkPath, p.pk); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// SetMaxWorkers caps the number of CPUs used by the prover (see backend.WithMaxWorkers)
+func (p *Prover) SetMaxWorkers(n int) {
+	p.opts = append(p.opts, backend.WithMaxWorkers(n))
+}
+
+// SetMaxFFTElements bounds the memory used by the prover FFTs, the polynomials are stored in
+// temporary files in dir (see backend.WithOutOfCoreFFT)
+func (p *Prover) SetMaxFFTElements(dir string, maxElements int) {
+	p.opts = append(p.opts, backend.WithOutOfCoreFFT(dir, maxElements))
+}
+
+// IsSolved returns nil if the witness solves the R1CS
+func (p *Prover) IsSolved(w *Witness) error {
+	return p.r1cs.IsSolved(w.values)
+}
+
+// Prove returns the proof of the witness, serialized with Proof.WriteTo
+func (p *Prover) Prove(w *Witness) (res []byte, err error) {
+	// a panic would abort the app
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("prover panic: %v", r)
+		}
+	}()
+	proof, err := groth16.ProveWithOptions(p.r1cs, p.pk, w.values, p.opts...)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if _, err := proof.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Verify checks the proof against the public inputs of w; vk and proof are serialized with WriteTo
+func Verify(curve string, proof, vk []byte, w *Witness) error {
+	curveID, err := bindings.ParseCurve(curve)
+	if err != nil {
+		return err
+	}
+	_proof := groth16.NewProof(curveID)
+	if _, err := _proof.ReadFrom(bytes.NewReader(proof)); err != nil {
+		return err
+	}
+	_vk := groth16.NewVerifyingKey(curveID)
+	if _, err := _vk.ReadFrom(bytes.NewReader(vk)); err != nil {
+		return err
+	}
+	return groth16.Verify(_proof, _vk, w.values)
+}
+
+// SetGCPercent sets the garbage collection target percentage (see debug.SetGCPercent) and returns the
+// previous one; a low value trades CPU time for a lower memory peak while proving
+func SetGCPercent(percent int) int {
+	return debug.SetGCPercent(percent)
+}
+
+// FreeOSMemory returns as much memory to the operating system as possible (see debug.FreeOSMemory)
+func FreeOSMemory() {
+	debug.FreeOSMemory()
+}
+
+func readFile(path string, v io.ReaderFrom) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = v.ReadFrom(bufio.NewReader(f))
+	return err
+}
@@ -0,0 +1,115 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mobile
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gurvy"
+)
+
+type cubicCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+// x**3 + x + 5 == y
+func (circuit *cubicCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	x3 := cs.Mul(circuit.X, circuit.X, circuit.X)
+	cs.AssertIsEqual(circuit.Y, cs.Add(x3, circuit.X, 5))
+	return nil
+}
+
+func writeFile(t *testing.T, path string, v io.WriterTo) {
+	var buf bytes.Buffer
+	if _, err := v.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProver(t *testing.T) {
+	var circuit cubicCircuit
+	r1cs, err := frontend.Compile(gurvy.BN256, &circuit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, vk, err := groth16.Setup(r1cs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "cubic.r1cs"), r1cs)
+	writeFile(t, filepath.Join(dir, "cubic.pk"), pk)
+	var vkBuf bytes.Buffer
+	if _, err := vk.WriteTo(&vkBuf); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewProver("bn256", filepath.Join(dir, "missing.r1cs"), filepath.Join(dir, "cubic.pk")); err == nil {
+		t.Fatal("expected error for missing R1CS")
+	}
+	prover, err := NewProver("bn256", filepath.Join(dir, "cubic.r1cs"), filepath.Join(dir, "cubic.pk"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	prover.SetMaxWorkers(1)
+	prover.SetMaxFFTElements(dir, 2)
+
+	witness := NewWitness()
+	if err := witness.Set("X", "3"); err != nil {
+		t.Fatal(err)
+	}
+	if err := witness.Set("Y", "0x23"); err != nil {
+		t.Fatal(err)
+	}
+	if err := witness.Set("Y", "thirty-five"); err == nil {
+		t.Fatal("expected error for invalid value")
+	}
+	if err := prover.IsSolved(witness); err != nil {
+		t.Fatal(err)
+	}
+	proof, err := prover.Prove(witness)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	public := NewWitness()
+	_ = public.Set("Y", "35")
+	if err := Verify("bn256", proof, vkBuf.Bytes(), public); err != nil {
+		t.Fatal(err)
+	}
+	_ = public.Set("Y", "36")
+	if err := Verify("bn256", proof, vkBuf.Bytes(), public); err == nil {
+		t.Fatal("expected verification to fail")
+	}
+
+	invalid := NewWitness()
+	_ = invalid.Set("X", "4")
+	_ = invalid.Set("Y", "35")
+	if _, err := prover.Prove(invalid); err == nil {
+		t.Fatal("expected proving to fail")
+	}
+}
@@ -0,0 +1,129 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+
+	gnarkio "github.com/consensys/gnark/io"
+	"github.com/consensys/gnark/server/serverpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// NewGRPCServer returns a grpc.Server serving the gRPC API of s (see serverpb.ProverServer)
+//
+// the size of the received messages is limited by WithMaxRequestSize; opts are appended to this
+// limit, and can override it.
+func (s *Service) NewGRPCServer(opts ...grpc.ServerOption) *grpc.Server {
+	opts = append([]grpc.ServerOption{grpc.MaxRecvMsgSize(int(s.maxRequestSize))}, opts...)
+	server := grpc.NewServer(opts...)
+	serverpb.RegisterProverServer(server, grpcService{s})
+	return server
+}
+
+// grpcService implements serverpb.ProverServer
+type grpcService struct {
+	s *Service
+}
+
+var jobStatus = map[JobStatus]serverpb.JobStatus{
+	Queued:  serverpb.JobStatus_QUEUED,
+	Running: serverpb.JobStatus_RUNNING,
+	Done:    serverpb.JobStatus_DONE,
+	Failed:  serverpb.JobStatus_FAILED,
+}
+
+func (g grpcService) Compile(ctx context.Context, req *serverpb.CompileRequest) (*serverpb.CompileResponse, error) {
+	if err := g.s.Compile(req.Circuit); err != nil {
+		return nil, grpcError(err)
+	}
+	return &serverpb.CompileResponse{}, nil
+}
+
+func (g grpcService) Setup(ctx context.Context, req *serverpb.SetupRequest) (*serverpb.SetupResponse, error) {
+	if err := g.s.Setup(req.Circuit); err != nil {
+		return nil, grpcError(err)
+	}
+	return &serverpb.SetupResponse{}, nil
+}
+
+func (g grpcService) GetVerifyingKey(ctx context.Context, req *serverpb.GetVerifyingKeyRequest) (*serverpb.GetVerifyingKeyResponse, error) {
+	vk, err := g.s.VerifyingKey(req.Circuit)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	var buf bytes.Buffer
+	if _, err := vk.WriteTo(&buf); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &serverpb.GetVerifyingKeyResponse{VerifyingKey: buf.Bytes()}, nil
+}
+
+func (g grpcService) Prove(ctx context.Context, req *serverpb.ProveRequest) (*serverpb.ProveResponse, error) {
+	witness := make(map[string]interface{})
+	if err := gnarkio.ParseWitness(req.Witness, witness); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	id, err := g.s.Submit(req.Circuit, witness)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &serverpb.ProveResponse{JobId: id}, nil
+}
+
+func (g grpcService) GetJob(ctx context.Context, req *serverpb.GetJobRequest) (*serverpb.Job, error) {
+	job, ok := g.s.Job(req.JobId)
+	if !ok {
+		return nil, status.Error(codes.NotFound, "unknown job")
+	}
+	return &serverpb.Job{
+		Id:      job.ID,
+		Circuit: job.Circuit,
+		Status:  jobStatus[job.Status],
+		Error:   job.Error,
+		Proof:   job.Proof,
+	}, nil
+}
+
+func (g grpcService) Verify(ctx context.Context, req *serverpb.VerifyRequest) (*serverpb.VerifyResponse, error) {
+	publicWitness := make(map[string]interface{})
+	if err := gnarkio.ParseWitness(req.PublicWitness, publicWitness); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := g.s.Verify(req.Circuit, req.Proof, publicWitness); err != nil {
+		return nil, grpcError(err)
+	}
+	return &serverpb.VerifyResponse{}, nil
+}
+
+// grpcError returns the gRPC status of an error returned by the Service (see statusOf for HTTP)
+func grpcError(err error) error {
+	code := codes.InvalidArgument // invalid proofs or witnesses
+	switch {
+	case errors.Is(err, ErrUnknownCircuit), errors.Is(err, ErrNotFound):
+		code = codes.NotFound
+	case errors.Is(err, ErrQueueFull):
+		code = codes.ResourceExhausted
+	case errors.Is(err, ErrClosed):
+		code = codes.Unavailable
+	}
+	return status.Error(code, err.Error())
+}
@@ -0,0 +1,160 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/server/serverpb"
+	"github.com/consensys/gurvy"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// grpcClient serves s on an in memory connection, and returns a client connected to it
+func grpcClient(t *testing.T, s *Service) serverpb.ProverClient {
+	lis := bufconn.Listen(1 << 20)
+	server := s.NewGRPCServer()
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("bufconn", grpc.WithInsecure(), grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return lis.Dial()
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return serverpb.NewProverClient(conn)
+}
+
+func expectCode(t *testing.T, err error, code codes.Code) {
+	t.Helper()
+	if status.Code(err) != code {
+		t.Fatalf("expected status %s, got %v", code, err)
+	}
+}
+
+// waitGRPCJob polls the job until it is finished
+func waitGRPCJob(t *testing.T, client serverpb.ProverClient, id string) *serverpb.Job {
+	for i := 0; i < 100; i++ {
+		job, err := client.GetJob(context.Background(), &serverpb.GetJobRequest{JobId: id})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.Status == serverpb.JobStatus_DONE || job.Status == serverpb.JobStatus_FAILED {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("job didn't finish")
+	return nil
+}
+
+func TestGRPC(t *testing.T) {
+	s := New(WithWorkers(2), WithProverOptions(backend.WithMaxWorkers(1)))
+	defer s.Close()
+	s.RegisterCircuit("cubic", gurvy.BN256, &cubicCircuit{})
+	client := grpcClient(t, s)
+	ctx := context.Background()
+
+	// setup needs a compiled circuit
+	_, err := client.Setup(ctx, &serverpb.SetupRequest{Circuit: "cubic"})
+	expectCode(t, err, codes.NotFound)
+	if _, err := client.Compile(ctx, &serverpb.CompileRequest{Circuit: "cubic"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Setup(ctx, &serverpb.SetupRequest{Circuit: "cubic"}); err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.Compile(ctx, &serverpb.CompileRequest{Circuit: "square"})
+	expectCode(t, err, codes.NotFound)
+
+	vk, err := client.GetVerifyingKey(ctx, &serverpb.GetVerifyingKeyRequest{Circuit: "cubic"})
+	if err != nil || len(vk.VerifyingKey) == 0 {
+		t.Fatal("couldn't get the verifying key", err)
+	}
+
+	// valid witness
+	submitted, err := client.Prove(ctx, &serverpb.ProveRequest{Circuit: "cubic", Witness: map[string]string{"X": "3", "Y": "0x23"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	job := waitGRPCJob(t, client, submitted.JobId)
+	if job.Status != serverpb.JobStatus_DONE || len(job.Proof) == 0 {
+		t.Fatal("proving job failed", job.Error)
+	}
+
+	// finished jobs are forgotten once returned
+	_, err = client.GetJob(ctx, &serverpb.GetJobRequest{JobId: submitted.JobId})
+	expectCode(t, err, codes.NotFound)
+
+	verify := func(y string) error {
+		_, err := client.Verify(ctx, &serverpb.VerifyRequest{Circuit: "cubic", Proof: job.Proof, PublicWitness: map[string]string{"Y": y}})
+		return err
+	}
+	if err := verify("35"); err != nil {
+		t.Fatal(err)
+	}
+	expectCode(t, verify("36"), codes.InvalidArgument)
+
+	// invalid witness
+	submitted, err = client.Prove(ctx, &serverpb.ProveRequest{Circuit: "cubic", Witness: map[string]string{"X": "4", "Y": "35"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job := waitGRPCJob(t, client, submitted.JobId); job.Status != serverpb.JobStatus_FAILED || job.Error == "" {
+		t.Fatal("expected proving job to fail")
+	}
+	_, err = client.Prove(ctx, &serverpb.ProveRequest{Circuit: "cubic", Witness: map[string]string{"X": "three"}})
+	expectCode(t, err, codes.InvalidArgument)
+}
+
+func TestGRPCQueue(t *testing.T) {
+	// no worker: the jobs stay in the queue
+	s := New(WithWorkers(0), WithQueueSize(1))
+	defer s.Close()
+	s.RegisterCircuit("cubic", gurvy.BN256, &cubicCircuit{})
+	client := grpcClient(t, s)
+	ctx := context.Background()
+
+	submitted, err := client.Prove(ctx, &serverpb.ProveRequest{Circuit: "cubic"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job, err := client.GetJob(ctx, &serverpb.GetJobRequest{JobId: submitted.JobId}); err != nil || job.Status != serverpb.JobStatus_QUEUED {
+		t.Fatal("expected queued job", err)
+	}
+	_, err = client.Prove(ctx, &serverpb.ProveRequest{Circuit: "cubic"})
+	expectCode(t, err, codes.ResourceExhausted)
+}
+
+func TestGRPCMaxRequestSize(t *testing.T) {
+	s := New(WithMaxRequestSize(16))
+	defer s.Close()
+	s.RegisterCircuit("cubic", gurvy.BN256, &cubicCircuit{})
+	client := grpcClient(t, s)
+
+	_, err := client.Prove(context.Background(), &serverpb.ProveRequest{Circuit: "cubic", Witness: map[string]string{"X": "3", "Y": "35"}})
+	expectCode(t, err, codes.ResourceExhausted)
+}
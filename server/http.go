@@ -0,0 +1,157 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	gnarkio "github.com/consensys/gnark/io"
+)
+
+type verifyRequest struct {
+	Proof         []byte          `json:"proof"`
+	PublicWitness json.RawMessage `json:"publicWitness"`
+}
+
+// ServeHTTP implements the JSON API described in the package documentation
+func (s *Service) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, s.maxRequestSize)
+	path := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	switch {
+	case len(path) == 2 && path[0] == "jobs" && r.Method == http.MethodGet:
+		job, ok := s.Job(path[1])
+		if !ok {
+			writeError(w, http.StatusNotFound, errors.New("unknown job"))
+			return
+		}
+		writeJSON(w, http.StatusOK, job)
+
+	case len(path) == 3 && path[0] == "circuits":
+		s.serveCircuit(w, r, path[1], path[2])
+
+	default:
+		writeError(w, http.StatusNotFound, errors.New("not found"))
+	}
+}
+
+func (s *Service) serveCircuit(w http.ResponseWriter, r *http.Request, name, action string) {
+	method := http.MethodPost
+	if action == "vk" {
+		method = http.MethodGet
+	}
+	if r.Method != method {
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+
+	switch action {
+	case "compile":
+		if err := s.Compile(name); err != nil {
+			writeError(w, statusOf(err), err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	case "setup":
+		if err := s.Setup(name); err != nil {
+			writeError(w, statusOf(err), err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	case "vk":
+		vk, err := s.VerifyingKey(name)
+		if err != nil {
+			writeError(w, statusOf(err), err)
+			return
+		}
+		var buf bytes.Buffer
+		if _, err := vk.WriteTo(&buf); err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		_, _ = w.Write(buf.Bytes())
+
+	case "prove":
+		witness := make(map[string]interface{})
+		if err := gnarkio.ReadWitness(r.Body, witness); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		id, err := s.Submit(name, witness)
+		if err != nil {
+			writeError(w, statusOf(err), err)
+			return
+		}
+		writeJSON(w, http.StatusAccepted, map[string]string{"id": id})
+
+	case "verify":
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, http.StatusRequestEntityTooLarge, err)
+			return
+		}
+		var req verifyRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		publicWitness := make(map[string]interface{})
+		if err := gnarkio.ReadWitness(bytes.NewReader(req.PublicWitness), publicWitness); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		if err := s.Verify(name, req.Proof, publicWitness); err != nil {
+			writeError(w, statusOf(err), err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeError(w, http.StatusNotFound, errors.New("not found"))
+	}
+}
+
+// statusOf returns the HTTP status of an error returned by the Service
+func statusOf(err error) int {
+	switch {
+	case errors.Is(err, ErrUnknownCircuit), errors.Is(err, ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrQueueFull), errors.Is(err, ErrClosed):
+		return http.StatusServiceUnavailable
+	default:
+		// invalid proofs or witnesses
+		return http.StatusBadRequest
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,410 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package server runs gnark as a proving service
+//
+// Circuits are Go code: they are registered in the Service by name, then compiled and set up on
+// demand. The R1CS and keys are kept in a Store (in memory by default), so that they can be shared
+// between instances or persisted. Proofs are computed asynchronously by a fixed number of workers,
+// from a bounded queue of jobs.
+//
+// The service is exposed over gRPC, with the Prover service of package serverpb (see server.proto):
+//
+//	lis, _ := net.Listen("tcp", ":9090")
+//	s.NewGRPCServer().Serve(lis)
+//
+// Service also implements http.Handler, for clients without gRPC support, with the following JSON
+// API (witnesses map input names to decimal or hexadecimal strings, binary objects are base64 encoded):
+//
+//	POST /circuits/{name}/compile   compiles the circuit
+//	POST /circuits/{name}/setup     runs the groth16 setup
+//	GET  /circuits/{name}/vk        returns the verifying key (binary)
+//	POST /circuits/{name}/prove     {witness} submits a proving job and returns {"id"}
+//	GET  /jobs/{id}                 returns the job {"id", "status", "error", "proof"}
+//	POST /circuits/{name}/verify    {"proof", "publicWitness"} verifies a proof
+package server
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/r1cs"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/io/artifact"
+	"github.com/consensys/gurvy"
+)
+
+var (
+	// ErrUnknownCircuit is returned for a circuit that is not registered
+	ErrUnknownCircuit = errors.New("unknown circuit")
+	// ErrNotFound is returned by a Store for a missing artifact, the stores of package artifact
+	// implement Store
+	ErrNotFound = artifact.ErrNotFound
+	// ErrQueueFull is returned when a job is submitted and the queue is full
+	ErrQueueFull = errors.New("job queue is full")
+	// ErrClosed is returned when a job is submitted after Close
+	ErrClosed = errors.New("service is closed")
+)
+
+// Store persists the artifacts of the circuits (R1CS, proving and verifying keys)
+//
+// keys are made of the circuit name and an extension (.r1cs, .pk, .vk)
+type Store interface {
+	Put(key string, data []byte) error
+	Get(key string) ([]byte, error) // returns ErrNotFound if key is missing
+}
+
+// MemoryStore is a Store keeping the artifacts in memory
+type MemoryStore struct {
+	lock sync.RWMutex
+	data map[string][]byte
+}
+
+// Put stores data
+func (s *MemoryStore) Put(key string, data []byte) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.data == nil {
+		s.data = make(map[string][]byte)
+	}
+	s.data[key] = data
+	return nil
+}
+
+// Get returns the stored data, or ErrNotFound
+func (s *MemoryStore) Get(key string) ([]byte, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	data, ok := s.data[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return data, nil
+}
+
+// JobStatus is the state of a proving job
+type JobStatus string
+
+// job states
+const (
+	Queued  JobStatus = "queued"
+	Running JobStatus = "running"
+	Done    JobStatus = "done"
+	Failed  JobStatus = "failed"
+)
+
+// Job is a proving job
+type Job struct {
+	ID      string    `json:"id"`
+	Circuit string    `json:"circuit"`
+	Status  JobStatus `json:"status"`
+	Error   string    `json:"error,omitempty"`
+	Proof   []byte    `json:"proof,omitempty"` // serialized with Proof.WriteTo
+
+	witness map[string]interface{}
+}
+
+// Option configures a Service
+type Option func(*Service)
+
+// WithStore sets the Store of the artifacts (default: a MemoryStore)
+func WithStore(store Store) Option {
+	return func(s *Service) {
+		s.store = store
+	}
+}
+
+// WithWorkers sets the number of proofs computed concurrently (default: 1)
+func WithWorkers(n int) Option {
+	return func(s *Service) {
+		s.nbWorkers = n
+	}
+}
+
+// WithQueueSize sets the number of jobs waiting for a worker, beyond which submissions fail
+// with ErrQueueFull (default: 64)
+func WithQueueSize(n int) Option {
+	return func(s *Service) {
+		s.queueSize = n
+	}
+}
+
+// WithMaxRequestSize sets the maximum size of an HTTP request body or of a gRPC message (default: 16MB)
+func WithMaxRequestSize(n int64) Option {
+	return func(s *Service) {
+		s.maxRequestSize = n
+	}
+}
+
+// WithProverOptions sets the options of setup and prove, for example backend.WithMaxWorkers to limit
+// the CPUs used by a job
+func WithProverOptions(opts ...backend.Option) Option {
+	return func(s *Service) {
+		s.proverOpts = opts
+	}
+}
+
+// Service compiles, sets up, proves and verifies registered circuits
+type Service struct {
+	store          Store
+	nbWorkers      int
+	queueSize      int
+	maxRequestSize int64
+	proverOpts     []backend.Option
+
+	lock     sync.Mutex
+	circuits map[string]registeredCircuit
+	jobs     map[string]*Job
+	nextID   uint64
+	closed   bool
+
+	queue chan *Job
+	wg    sync.WaitGroup
+}
+
+type registeredCircuit struct {
+	curveID gurvy.ID
+	circuit frontend.Circuit
+}
+
+// New returns a Service and starts its workers
+func New(opts ...Option) *Service {
+	s := &Service{
+		store:          &MemoryStore{},
+		nbWorkers:      1,
+		queueSize:      64,
+		maxRequestSize: 16 << 20,
+		circuits:       make(map[string]registeredCircuit),
+		jobs:           make(map[string]*Job),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.queue = make(chan *Job, s.queueSize)
+	for i := 0; i < s.nbWorkers; i++ {
+		s.wg.Add(1)
+		go s.worker()
+	}
+	return s
+}
+
+// Close stops accepting jobs and waits for the queued jobs to complete
+func (s *Service) Close() {
+	s.lock.Lock()
+	if s.closed {
+		s.lock.Unlock()
+		return
+	}
+	s.closed = true
+	close(s.queue)
+	s.lock.Unlock()
+	s.wg.Wait()
+}
+
+// RegisterCircuit registers circuit under name
+func (s *Service) RegisterCircuit(name string, curveID gurvy.ID, circuit frontend.Circuit) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.circuits[name] = registeredCircuit{curveID: curveID, circuit: circuit}
+}
+
+// Compile compiles the circuit and stores its R1CS
+func (s *Service) Compile(name string) error {
+	c, err := s.circuit(name)
+	if err != nil {
+		return err
+	}
+	_r1cs, err := frontend.Compile(c.curveID, c.circuit)
+	if err != nil {
+		return err
+	}
+	return s.put(name+".r1cs", _r1cs)
+}
+
+// Setup runs the groth16 setup of the compiled circuit and stores the keys
+func (s *Service) Setup(name string) error {
+	c, err := s.circuit(name)
+	if err != nil {
+		return err
+	}
+	_r1cs := r1cs.New(c.curveID)
+	if err := s.get(name+".r1cs", _r1cs); err != nil {
+		return err
+	}
+	pk, vk, err := groth16.Setup(_r1cs, s.proverOpts...)
+	if err != nil {
+		return err
+	}
+	if err := s.put(name+".pk", pk); err != nil {
+		return err
+	}
+	return s.put(name+".vk", vk)
+}
+
+// VerifyingKey returns the verifying key of the circuit
+func (s *Service) VerifyingKey(name string) (groth16.VerifyingKey, error) {
+	c, err := s.circuit(name)
+	if err != nil {
+		return nil, err
+	}
+	vk := groth16.NewVerifyingKey(c.curveID)
+	if err := s.get(name+".vk", vk); err != nil {
+		return nil, err
+	}
+	return vk, nil
+}
+
+// Submit queues a proving job for the witness, and returns its ID
+func (s *Service) Submit(name string, witness map[string]interface{}) (string, error) {
+	if _, err := s.circuit(name); err != nil {
+		return "", err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.closed {
+		return "", ErrClosed
+	}
+	s.nextID++
+	job := &Job{ID: strconv.FormatUint(s.nextID, 10), Circuit: name, Status: Queued, witness: witness}
+	select {
+	case s.queue <- job:
+	default:
+		return "", ErrQueueFull
+	}
+	s.jobs[job.ID] = job
+	return job.ID, nil
+}
+
+// Job returns a copy of the job; a finished (Done or Failed) job is forgotten once returned
+func (s *Service) Job(id string) (Job, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	if job.Status == Done || job.Status == Failed {
+		delete(s.jobs, id)
+	}
+	return *job, true
+}
+
+// Verify verifies the proof (serialized with Proof.WriteTo) against the public witness
+func (s *Service) Verify(name string, proof []byte, publicWitness map[string]interface{}) error {
+	c, err := s.circuit(name)
+	if err != nil {
+		return err
+	}
+	vk, err := s.VerifyingKey(name)
+	if err != nil {
+		return err
+	}
+	_proof := groth16.NewProof(c.curveID)
+	if _, err := _proof.ReadFrom(bytes.NewReader(proof)); err != nil {
+		return err
+	}
+	return groth16.Verify(_proof, vk, publicWitness)
+}
+
+func (s *Service) worker() {
+	defer s.wg.Done()
+	for job := range s.queue {
+		s.setStatus(job, Running, nil, nil)
+		proof, err := s.prove(job.Circuit, job.witness)
+		if err != nil {
+			s.setStatus(job, Failed, nil, err)
+		} else {
+			s.setStatus(job, Done, proof, nil)
+		}
+	}
+}
+
+func (s *Service) prove(name string, witness map[string]interface{}) (res []byte, err error) {
+	// the solver panics on some invalid inputs (for example unsupported types), the worker must survive
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("prover panic: %v", r)
+		}
+	}()
+	c, err := s.circuit(name)
+	if err != nil {
+		return nil, err
+	}
+	_r1cs := r1cs.New(c.curveID)
+	if err := s.get(name+".r1cs", _r1cs); err != nil {
+		return nil, err
+	}
+	pk := groth16.NewProvingKey(c.curveID)
+	if err := s.get(name+".pk", pk); err != nil {
+		return nil, err
+	}
+	proof, err := groth16.ProveWithOptions(_r1cs, pk, witness, s.proverOpts...)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if _, err := proof.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *Service) setStatus(job *Job, status JobStatus, proof []byte, err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	job.Status = status
+	job.Proof = proof
+	if err != nil {
+		job.Error = err.Error()
+	}
+	if status == Done || status == Failed {
+		job.witness = nil
+	}
+}
+
+func (s *Service) circuit(name string) (registeredCircuit, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	c, ok := s.circuits[name]
+	if !ok {
+		return c, fmt.Errorf("%q: %w", name, ErrUnknownCircuit)
+	}
+	return c, nil
+}
+
+func (s *Service) put(key string, v io.WriterTo) error {
+	var buf bytes.Buffer
+	if _, err := v.WriteTo(&buf); err != nil {
+		return err
+	}
+	return s.store.Put(key, buf.Bytes())
+}
+
+func (s *Service) get(key string, v io.ReaderFrom) error {
+	data, err := s.store.Get(key)
+	if err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	_, err = v.ReadFrom(bytes.NewReader(data))
+	return err
+}
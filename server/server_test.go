@@ -0,0 +1,171 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gurvy"
+)
+
+type cubicCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+// x**3 + x + 5 == y
+func (circuit *cubicCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	x3 := cs.Mul(circuit.X, circuit.X, circuit.X)
+	cs.AssertIsEqual(circuit.Y, cs.Add(x3, circuit.X, 5))
+	return nil
+}
+
+func post(t *testing.T, url, body string, expected int) []byte {
+	resp, err := http.Post(url, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var buf bytes.Buffer
+	_, _ = buf.ReadFrom(resp.Body)
+	if resp.StatusCode != expected {
+		t.Fatalf("POST %s: expected status %d, got %d (%s)", url, expected, resp.StatusCode, buf.String())
+	}
+	return buf.Bytes()
+}
+
+// waitJob polls the job until it is finished
+func waitJob(t *testing.T, url string) Job {
+	for i := 0; i < 100; i++ {
+		resp, err := http.Get(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var job Job
+		err = json.NewDecoder(resp.Body).Decode(&job)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.Status == Done || job.Status == Failed {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("job didn't finish")
+	return Job{}
+}
+
+func TestHTTP(t *testing.T) {
+	s := New(WithWorkers(2), WithProverOptions(backend.WithMaxWorkers(1)))
+	defer s.Close()
+	s.RegisterCircuit("cubic", gurvy.BN256, &cubicCircuit{})
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	// setup needs a compiled circuit
+	post(t, ts.URL+"/circuits/cubic/setup", "", http.StatusNotFound)
+	post(t, ts.URL+"/circuits/cubic/compile", "", http.StatusNoContent)
+	post(t, ts.URL+"/circuits/cubic/setup", "", http.StatusNoContent)
+	post(t, ts.URL+"/circuits/square/compile", "", http.StatusNotFound)
+
+	resp, err := http.Get(ts.URL + "/circuits/cubic/vk")
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatal("couldn't get the verifying key", err)
+	}
+	resp.Body.Close()
+
+	// valid witness
+	var submitted struct{ ID string }
+	if err := json.Unmarshal(post(t, ts.URL+"/circuits/cubic/prove", `{"X": "3", "Y": "35"}`, http.StatusAccepted), &submitted); err != nil {
+		t.Fatal(err)
+	}
+	job := waitJob(t, ts.URL+"/jobs/"+submitted.ID)
+	if job.Status != Done || len(job.Proof) == 0 {
+		t.Fatal("proving job failed", job.Error)
+	}
+
+	// finished jobs are forgotten once returned
+	resp, err = http.Get(ts.URL + "/jobs/" + submitted.ID)
+	if err != nil || resp.StatusCode != http.StatusNotFound {
+		t.Fatal("expected finished job to be forgotten")
+	}
+	resp.Body.Close()
+
+	verify := func(y string) string {
+		body, _ := json.Marshal(map[string]interface{}{
+			"proof":         job.Proof,
+			"publicWitness": map[string]string{"Y": y},
+		})
+		return string(body)
+	}
+	post(t, ts.URL+"/circuits/cubic/verify", verify("35"), http.StatusNoContent)
+	post(t, ts.URL+"/circuits/cubic/verify", verify("36"), http.StatusBadRequest)
+
+	// invalid witness
+	if err := json.Unmarshal(post(t, ts.URL+"/circuits/cubic/prove", `{"X": "4", "Y": "35"}`, http.StatusAccepted), &submitted); err != nil {
+		t.Fatal(err)
+	}
+	if job := waitJob(t, ts.URL+"/jobs/"+submitted.ID); job.Status != Failed || job.Error == "" {
+		t.Fatal("expected proving job to fail")
+	}
+	post(t, ts.URL+"/circuits/cubic/prove", `{"X": 3}`, http.StatusBadRequest)
+}
+
+func TestQueue(t *testing.T) {
+	// no worker: the jobs stay in the queue
+	s := New(WithWorkers(0), WithQueueSize(1))
+	s.RegisterCircuit("cubic", gurvy.BN256, &cubicCircuit{})
+
+	id, err := s.Submit("cubic", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job, ok := s.Job(id); !ok || job.Status != Queued {
+		t.Fatal("expected queued job")
+	}
+	if _, err := s.Submit("cubic", nil); !errors.Is(err, ErrQueueFull) {
+		t.Fatal("expected ErrQueueFull, got", err)
+	}
+	if _, err := s.Submit("square", nil); !errors.Is(err, ErrUnknownCircuit) {
+		t.Fatal("expected ErrUnknownCircuit, got", err)
+	}
+
+	s.Close()
+	if _, err := s.Submit("cubic", nil); !errors.Is(err, ErrClosed) {
+		t.Fatal("expected ErrClosed, got", err)
+	}
+}
+
+func TestMaxRequestSize(t *testing.T) {
+	s := New(WithMaxRequestSize(16))
+	defer s.Close()
+	s.RegisterCircuit("cubic", gurvy.BN256, &cubicCircuit{})
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	post(t, ts.URL+"/circuits/cubic/prove", `{"X": "3", "Y": "35"}`, http.StatusBadRequest)
+}
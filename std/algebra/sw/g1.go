@@ -57,7 +57,7 @@ func (p *G1Affine) AddAssign(cs *frontend.ConstraintSystem, p1 *G1Affine) *G1Aff
 
 	l1 := cs.Sub(p1.Y, p.Y)
 	l2 := cs.Sub(p1.X, p.X)
-	l := cs.Div(l1, l2)
+	l := cs.DivUnchecked(l1, l2)
 
 	// xr = lambda**2-p.x-p1.x
 	_x1 := cs.Mul(l, l)
@@ -190,8 +190,8 @@ func (p *G1Affine) Select(cs *frontend.ConstraintSystem, b frontend.Variable, p1
 // FromJac sets p to p1 in affine and returns it
 func (p *G1Affine) FromJac(cs *frontend.ConstraintSystem, p1 *G1Jac) *G1Affine {
 	s := cs.Mul(p1.Z, p1.Z)
-	p.X = cs.Div(p1.X, s)
-	p.Y = cs.Div(p1.Y, cs.Mul(s, p1.Z))
+	p.X = cs.DivUnchecked(p1.X, s)
+	p.Y = cs.DivUnchecked(p1.Y, cs.Mul(s, p1.Z))
 	return p
 }
 
@@ -210,7 +210,7 @@ func (p *G1Affine) Double(cs *frontend.ConstraintSystem, p1 *G1Affine) *G1Affine
 	cs.Mul(p1.X, p1.X)
 	l1 := cs.Mul(x2, t)
 	l2 := cs.Mul(p1.Y, d)
-	l := cs.Div(l1, l2)
+	l := cs.DivUnchecked(l1, l2)
 
 	// xr = lambda**2-p.x-p1.x
 	_x1 := cs.Mul(l, l, c1)
@@ -65,8 +65,9 @@ func (p *Point) AddFixedPoint(cs *frontend.ConstraintSystem, p1 *Point /*basex*/
 	d1 := cs.Add(1, d11)
 	d2 := cs.Sub(1, d11)
 
-	p.X = cs.Div(n1, d1)
-	p.Y = cs.Div(n2, d2)
+	// the denominators of the complete addition law are never 0
+	p.X = cs.DivUnchecked(n1, d1)
+	p.Y = cs.DivUnchecked(n2, d2)
 
 	return p
 }
@@ -90,8 +91,9 @@ func (p *Point) AddGeneric(cs *frontend.ConstraintSystem, p1, p2 *Point, curve E
 
 	d2 := cs.Sub(1, d11)
 
-	p.X = cs.Div(n1, d1)
-	p.Y = cs.Div(n2, d2)
+	// the denominators of the complete addition law are never 0
+	p.X = cs.DivUnchecked(n1, d1)
+	p.Y = cs.DivUnchecked(n2, d2)
 
 	return p
 }
@@ -0,0 +1,114 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package benchmark measures the cost of a gadget: size of the constraint system, compile, solve and prove times
+//
+// the gadget is wrapped in a small circuit, compiled in isolation for each curve:
+//
+//	results, err := benchmark.Run(func() frontend.Circuit { return &mimcCircuit{} }, witness)
+//	benchmark.Print(os.Stdout, results)
+package benchmark
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gurvy"
+)
+
+// Curves is the list of curves used by Run when none is provided
+var Curves = []gurvy.ID{gurvy.BN256, gurvy.BLS377, gurvy.BLS381, gurvy.BW761}
+
+// Result of a gadget benchmark on a given curve
+type Result struct {
+	CurveID        gurvy.ID
+	NbConstraints  uint64
+	NbWires        uint64
+	NbCoefficients int
+	Compile        time.Duration
+	Solve          time.Duration
+	Prove          time.Duration // includes solving; the proving key is generated with groth16.DummySetup
+}
+
+func (r Result) String() string {
+	return fmt.Sprintf("%s: %d constraints, %d wires, %d coefficients, compile %s, solve %s, prove %s",
+		r.CurveID.String(), r.NbConstraints, r.NbWires, r.NbCoefficients, r.Compile, r.Solve, r.Prove)
+}
+
+// Run compiles, solves and proves the circuit returned by newCircuit on each curve (Curves if none is provided)
+//
+// newCircuit must return a new, unassigned circuit at each call; witness returns a valid assignment
+// of the circuit for a given curve (a frontend.Circuit or a map[string]interface{}, see frontend.ParseWitness)
+func Run(newCircuit func() frontend.Circuit, witness func(curveID gurvy.ID) interface{}, curveIDs ...gurvy.ID) ([]Result, error) {
+	if len(curveIDs) == 0 {
+		curveIDs = Curves
+	}
+
+	results := make([]Result, 0, len(curveIDs))
+	for _, curveID := range curveIDs {
+		res := Result{CurveID: curveID}
+
+		start := time.Now()
+		r1cs, err := frontend.Compile(curveID, newCircuit())
+		if err != nil {
+			return results, fmt.Errorf("%s: compile: %w", curveID.String(), err)
+		}
+		res.Compile = time.Since(start)
+		res.NbConstraints = r1cs.GetNbConstraints()
+		res.NbWires = r1cs.GetNbWires()
+		res.NbCoefficients = r1cs.GetNbCoefficients()
+
+		assignment, err := frontend.ParseWitness(witness(curveID))
+		if err != nil {
+			return results, fmt.Errorf("%s: witness: %w", curveID.String(), err)
+		}
+
+		start = time.Now()
+		if err := r1cs.IsSolved(assignment); err != nil {
+			return results, fmt.Errorf("%s: solve: %w", curveID.String(), err)
+		}
+		res.Solve = time.Since(start)
+
+		pk, err := groth16.DummySetup(r1cs)
+		if err != nil {
+			return results, fmt.Errorf("%s: setup: %w", curveID.String(), err)
+		}
+		start = time.Now()
+		if _, err := groth16.Prove(r1cs, pk, assignment); err != nil {
+			return results, fmt.Errorf("%s: prove: %w", curveID.String(), err)
+		}
+		res.Prove = time.Since(start)
+
+		results = append(results, res)
+	}
+
+	return results, nil
+}
+
+// Print writes the results in w, as a table
+func Print(w io.Writer, results []Result) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "curve\tconstraints\twires\tcoefficients\tcompile\tsolve\tprove")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%s\t%s\t%s\n",
+			r.CurveID.String(), r.NbConstraints, r.NbWires, r.NbCoefficients, r.Compile, r.Solve, r.Prove)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,75 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package benchmark
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gurvy"
+)
+
+type cubeCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (circuit *cubeCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	cs.AssertIsEqual(cs.Mul(circuit.X, circuit.X, circuit.X), circuit.Y)
+	return nil
+}
+
+func TestRun(t *testing.T) {
+	newCircuit := func() frontend.Circuit { return &cubeCircuit{} }
+	witness := func(gurvy.ID) interface{} {
+		var w cubeCircuit
+		w.X.Assign(3)
+		w.Y.Assign(27)
+		return &w
+	}
+
+	results, err := Run(newCircuit, witness, gurvy.BN256, gurvy.BLS381)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatal("expected one result per curve")
+	}
+	for _, r := range results {
+		if r.NbConstraints != 3 {
+			t.Fatalf("%s: expected 3 constraints, got %d", r.CurveID.String(), r.NbConstraints)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := Print(&buf, results); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(buf.String(), "\n") != 3 {
+		t.Fatal("expected a header and a line per curve")
+	}
+
+	// invalid witness
+	badWitness := func(gurvy.ID) interface{} {
+		return map[string]interface{}{"X": 3, "Y": 28}
+	}
+	if _, err := Run(newCircuit, badWitness, gurvy.BN256); err == nil {
+		t.Fatal("expected error with invalid witness")
+	}
+}
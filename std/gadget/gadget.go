@@ -0,0 +1,122 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package gadget defines the interface of a reusable gadget, and a registry of gadgets by name
+//
+// A gadget library registers its gadgets in an init function, and circuits compose them by name:
+//
+//	func init() {
+//		gadget.Register("mylib/square", func(curveID gurvy.ID) (gadget.Gadget, error) {
+//			return gadget.Func(func(cs *frontend.ConstraintSystem, inputs ...frontend.Variable) ([]frontend.Variable, error) {
+//				return []frontend.Variable{cs.Mul(inputs[0], inputs[0])}, nil
+//			}), nil
+//		})
+//	}
+//
+// in Define:
+//
+//	square, err := gadget.New("mylib/square", curveID)
+//	outputs, nbConstraints, err := gadget.Call(cs, "square", square, circuit.X)
+//
+// Call records the constraints of an instance in a namespace (see frontend.ConstraintSystem.Namespace),
+// and returns their number.
+package gadget
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gurvy"
+)
+
+// ErrUnknownGadget is returned by New when no gadget is registered with a name
+var ErrUnknownGadget = errors.New("gadget is not registered")
+
+// Gadget is a reusable part of a circuit
+type Gadget interface {
+	// Define records the constraints of the gadget on inputs, and returns its outputs
+	Define(cs *frontend.ConstraintSystem, inputs ...frontend.Variable) ([]frontend.Variable, error)
+}
+
+// Func is a function used as a Gadget
+type Func func(cs *frontend.ConstraintSystem, inputs ...frontend.Variable) ([]frontend.Variable, error)
+
+// Define calls f
+func (f Func) Define(cs *frontend.ConstraintSystem, inputs ...frontend.Variable) ([]frontend.Variable, error) {
+	return f(cs, inputs...)
+}
+
+// Constructor returns the gadget for a curve (the parameters of a gadget, as the constants of a hash
+// function, may depend on the scalar field)
+type Constructor func(curveID gurvy.ID) (Gadget, error)
+
+var (
+	registry = make(map[string]Constructor)
+	lock     sync.RWMutex
+)
+
+// Register makes a gadget available by name
+//
+// it panics if a gadget is already registered with this name: a library should prefix the names of its
+// gadgets, as "mylib/square"
+func Register(name string, constructor Constructor) {
+	lock.Lock()
+	defer lock.Unlock()
+	if _, ok := registry[name]; ok {
+		panic("gadget: " + name + " is already registered")
+	}
+	registry[name] = constructor
+}
+
+// New returns the gadget registered with a name, for a curve
+func New(name string, curveID gurvy.ID) (Gadget, error) {
+	lock.RLock()
+	constructor, ok := registry[name]
+	lock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownGadget, name)
+	}
+	return constructor(curveID)
+}
+
+// Names returns the names of the registered gadgets, sorted
+func Names() []string {
+	lock.RLock()
+	res := make([]string, 0, len(registry))
+	for name := range registry {
+		res = append(res, name)
+	}
+	lock.RUnlock()
+	sort.Strings(res)
+	return res
+}
+
+// Call records an instance of g on inputs in the namespace name, and returns its outputs and the number
+// of constraints it recorded
+//
+// the constraints of the instance are attributed to the namespace in the R1CS (by dot, diff and debug);
+// an expression already computed outside of the instance is reused, and not counted
+func Call(cs *frontend.ConstraintSystem, name string, g Gadget, inputs ...frontend.Variable) (outputs []frontend.Variable, nbConstraints int, err error) {
+	cs.Namespace(name, func(cs *frontend.ConstraintSystem) {
+		before := cs.NbConstraints()
+		outputs, err = g.Define(cs, inputs...)
+		nbConstraints = cs.NbConstraints() - before
+	})
+	return outputs, nbConstraints, err
+}
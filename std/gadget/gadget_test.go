@@ -0,0 +1,105 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gadget
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gurvy"
+)
+
+func init() {
+	Register("test/square", func(curveID gurvy.ID) (Gadget, error) {
+		return Func(func(cs *frontend.ConstraintSystem, inputs ...frontend.Variable) ([]frontend.Variable, error) {
+			if len(inputs) != 1 {
+				return nil, errors.New("square expects one input")
+			}
+			return []frontend.Variable{cs.Mul(inputs[0], inputs[0])}, nil
+		}), nil
+	})
+}
+
+type squareCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (circuit *squareCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	square, err := New("test/square", curveID)
+	if err != nil {
+		return err
+	}
+	x2, nbConstraints, err := Call(cs, "x2", square, circuit.X)
+	if err != nil {
+		return err
+	}
+	if nbConstraints != 1 {
+		return errors.New("square should record one constraint")
+	}
+	x4, _, err := Call(cs, "x4", square, x2[0])
+	if err != nil {
+		return err
+	}
+	cs.AssertIsEqual(x4[0], circuit.Y)
+	return nil
+}
+
+func TestGadget(t *testing.T) {
+	assert := groth16.NewAssert(t)
+
+	r1cs, err := frontend.Compile(gurvy.BN256, &squareCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	{
+		var witness squareCircuit
+		witness.X.Assign(3)
+		witness.Y.Assign(81)
+		assert.ProverSucceeded(r1cs, &witness)
+	}
+	{
+		var witness squareCircuit
+		witness.X.Assign(3)
+		witness.Y.Assign(9)
+		assert.SolvingFailed(r1cs, &witness)
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	if _, err := New("test/cube", gurvy.BN256); !errors.Is(err, ErrUnknownGadget) {
+		t.Fatal("expected ErrUnknownGadget, got", err)
+	}
+
+	found := false
+	for _, name := range Names() {
+		found = found || name == "test/square"
+	}
+	if !found {
+		t.Fatal("test/square should be registered")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("registering a gadget twice should panic")
+		}
+	}()
+	Register("test/square", nil)
+}
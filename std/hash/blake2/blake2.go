@@ -0,0 +1,275 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package blake2 implements the Blake2b and Blake2s hash functions in a circuit, with a configurable
+// digest size and personalization (as the hashes of Zcash)
+//
+// the words are in bits: a block of data costs about 52k constraints with Blake2b (128 bytes) and
+// 22k with Blake2s (64 bytes). Keyed hashing and salts are not supported.
+// cf https://tools.ietf.org/html/rfc7693
+package blake2
+
+import (
+	"errors"
+
+	"github.com/consensys/gnark/frontend"
+)
+
+// params of a member of the Blake2 family
+type params struct {
+	wordSize        int // in bits
+	rounds          int
+	blockSize       int // in bytes
+	maxSize         int // max digest size, in bytes
+	personalization int // size of the personalization, in bytes
+	rotations       [4]int
+	iv              [8]uint64
+}
+
+var blake2bParams = params{
+	wordSize:        64,
+	rounds:          12,
+	blockSize:       128,
+	maxSize:         64,
+	personalization: 16,
+	rotations:       [4]int{32, 24, 16, 63},
+	iv: [8]uint64{
+		0x6a09e667f3bcc908, 0xbb67ae8584caa73b, 0x3c6ef372fe94f82b, 0xa54ff53a5f1d36f1,
+		0x510e527fade682d1, 0x9b05688c2b3e6c1f, 0x1f83d9abfb41bd6b, 0x5be0cd19137e2179,
+	},
+}
+
+var blake2sParams = params{
+	wordSize:        32,
+	rounds:          10,
+	blockSize:       64,
+	maxSize:         32,
+	personalization: 8,
+	rotations:       [4]int{16, 12, 8, 7},
+	iv: [8]uint64{
+		0x6a09e667, 0xbb67ae85, 0x3c6ef372, 0xa54ff53a,
+		0x510e527f, 0x9b05688c, 0x1f83d9ab, 0x5be0cd19,
+	},
+}
+
+// sigma are the permutations of the message words, sigma[round%10] for a round
+var sigma = [10][16]int{
+	{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15},
+	{14, 10, 4, 8, 9, 15, 13, 6, 1, 12, 0, 2, 11, 7, 5, 3},
+	{11, 8, 12, 0, 5, 2, 15, 13, 10, 14, 3, 6, 7, 1, 9, 4},
+	{7, 9, 3, 1, 13, 12, 11, 14, 2, 6, 5, 10, 4, 0, 15, 8},
+	{9, 0, 5, 7, 2, 4, 10, 15, 14, 1, 11, 12, 6, 8, 3, 13},
+	{2, 12, 6, 10, 0, 11, 8, 3, 4, 13, 7, 5, 15, 14, 1, 9},
+	{12, 5, 1, 15, 14, 13, 4, 10, 0, 7, 6, 3, 9, 2, 8, 11},
+	{13, 11, 7, 14, 12, 1, 3, 9, 5, 0, 15, 4, 8, 6, 2, 10},
+	{6, 15, 14, 9, 11, 3, 0, 8, 12, 2, 13, 7, 1, 4, 10, 5},
+	{10, 2, 8, 4, 7, 6, 1, 5, 15, 11, 9, 14, 3, 12, 13, 0},
+}
+
+var (
+	errInvalidSize            = errors.New("invalid blake2 digest size")
+	errInvalidPersonalization = errors.New("blake2 personalization too long")
+)
+
+// Blake2 is a Blake2b or a Blake2s hash function, with its digest size and personalization
+type Blake2 struct {
+	params          *params
+	size            int
+	personalization []byte
+}
+
+// NewBlake2b returns a Blake2b hash function with a digest of size bytes (1 to 64), that can be used in a
+// gnark circuit
+//
+// personalization is at most 16 bytes long, padded with zeros (none if empty)
+func NewBlake2b(size int, personalization []byte) (Blake2, error) {
+	return newBlake2(&blake2bParams, size, personalization)
+}
+
+// NewBlake2s returns a Blake2s hash function with a digest of size bytes (1 to 32), that can be used in a
+// gnark circuit
+//
+// personalization is at most 8 bytes long, padded with zeros (none if empty)
+func NewBlake2s(size int, personalization []byte) (Blake2, error) {
+	return newBlake2(&blake2sParams, size, personalization)
+}
+
+func newBlake2(p *params, size int, personalization []byte) (Blake2, error) {
+	if size < 1 || size > p.maxSize {
+		return Blake2{}, errInvalidSize
+	}
+	if len(personalization) > p.personalization {
+		return Blake2{}, errInvalidPersonalization
+	}
+	personal := make([]byte, p.personalization)
+	copy(personal, personalization)
+	return Blake2{params: p, size: size, personalization: personal}, nil
+}
+
+// Hash returns the digest of data, in bytes
+//
+// data is given in bytes, one variable per byte: the bytes are constrained to 8 bits.
+func (h Blake2) Hash(cs *frontend.ConstraintSystem, data ...frontend.Variable) []frontend.Variable {
+	p := h.params
+	w := p.wordSize
+	wordBytes := w / 8
+
+	// parameter block: digest size, key size (0), fanout and depth (1), then the personalization
+	var state [8][]frontend.Variable
+	initial := p.iv
+	initial[0] ^= 0x01010000 ^ uint64(h.size)
+	for i := 0; i < 2; i++ {
+		initial[6+i] ^= leUint(h.personalization[i*wordBytes : (i+1)*wordBytes])
+	}
+	for i := range state {
+		state[i] = constantWord(cs, initial[i], w)
+	}
+
+	// the bits of the data, the last block being padded with zeros
+	nbBlocks := (len(data) + p.blockSize - 1) / p.blockSize
+	if nbBlocks == 0 {
+		nbBlocks = 1
+	}
+	bits := make([]frontend.Variable, 0, nbBlocks*p.blockSize*8)
+	for i := range data {
+		bits = append(bits, cs.ToBinary(data[i], 8)...)
+	}
+	for len(bits) < cap(bits) {
+		bits = append(bits, cs.Constant(0))
+	}
+
+	for b := 0; b < nbBlocks; b++ {
+		// the message words, in little endian
+		var m [16][]frontend.Variable
+		for i := range m {
+			start := (b*p.blockSize + i*wordBytes) * 8
+			m[i] = bits[start : start+w]
+		}
+		counter := uint64((b + 1) * p.blockSize)
+		final := b == nbBlocks-1
+		if final {
+			counter = uint64(len(data))
+		}
+		h.compress(cs, &state, &m, counter, final)
+	}
+
+	res := make([]frontend.Variable, h.size)
+	for i := range res {
+		word := state[i/wordBytes]
+		j := i % wordBytes
+		res[i] = cs.FromBinary(word[j*8 : j*8+8]...)
+	}
+	return res
+}
+
+// Define returns the digest of data, as Hash: a Blake2 is a gadget.Gadget
+func (h Blake2) Define(cs *frontend.ConstraintSystem, data ...frontend.Variable) ([]frontend.Variable, error) {
+	return h.Hash(cs, data...), nil
+}
+
+// compress is the compression function F, counter is the number of bytes hashed at the end of the block
+// (lower than 2^64, its high word is 0)
+func (h Blake2) compress(cs *frontend.ConstraintSystem, state *[8][]frontend.Variable, m *[16][]frontend.Variable, counter uint64, final bool) {
+	p := h.params
+	w := p.wordSize
+
+	var v [16][]frontend.Variable
+	copy(v[:8], state[:])
+	for i := 0; i < 8; i++ {
+		iv := p.iv[i]
+		switch {
+		case i == 4:
+			iv ^= counter & (1<<uint(w) - 1)
+		case i == 5 && w < 64:
+			iv ^= counter >> uint(w)
+		case i == 6 && final:
+			iv = ^iv
+		}
+		v[8+i] = constantWord(cs, iv, w)
+	}
+
+	g := func(a, b, c, d int, x, y []frontend.Variable) {
+		v[a] = add(cs, w, v[a], v[b], x)
+		v[d] = rotr(xor(cs, v[d], v[a]), p.rotations[0])
+		v[c] = add(cs, w, v[c], v[d])
+		v[b] = rotr(xor(cs, v[b], v[c]), p.rotations[1])
+		v[a] = add(cs, w, v[a], v[b], y)
+		v[d] = rotr(xor(cs, v[d], v[a]), p.rotations[2])
+		v[c] = add(cs, w, v[c], v[d])
+		v[b] = rotr(xor(cs, v[b], v[c]), p.rotations[3])
+	}
+	for r := 0; r < p.rounds; r++ {
+		s := &sigma[r%10]
+		g(0, 4, 8, 12, m[s[0]], m[s[1]])
+		g(1, 5, 9, 13, m[s[2]], m[s[3]])
+		g(2, 6, 10, 14, m[s[4]], m[s[5]])
+		g(3, 7, 11, 15, m[s[6]], m[s[7]])
+		g(0, 5, 10, 15, m[s[8]], m[s[9]])
+		g(1, 6, 11, 12, m[s[10]], m[s[11]])
+		g(2, 7, 8, 13, m[s[12]], m[s[13]])
+		g(3, 4, 9, 14, m[s[14]], m[s[15]])
+	}
+
+	for i := range state {
+		state[i] = xor(cs, state[i], xor(cs, v[i], v[i+8]))
+	}
+}
+
+// add returns the sum of the words modulo 2^w
+func add(cs *frontend.ConstraintSystem, w int, words ...[]frontend.Variable) []frontend.Variable {
+	sum := cs.FromBinary(words[0]...)
+	for _, word := range words[1:] {
+		sum = cs.Add(sum, cs.FromBinary(word...))
+	}
+	// the sum of 3 words fits in w+2 bits
+	return cs.ToBinary(sum, w+2)[:w]
+}
+
+// xor returns the bitwise xor of the words
+func xor(cs *frontend.ConstraintSystem, a, b []frontend.Variable) []frontend.Variable {
+	res := make([]frontend.Variable, len(a))
+	for i := range a {
+		res[i] = cs.Xor(a[i], b[i])
+	}
+	return res
+}
+
+// rotr returns the word rotated right by n bits
+func rotr(a []frontend.Variable, n int) []frontend.Variable {
+	res := make([]frontend.Variable, len(a))
+	for i := range a {
+		res[i] = a[(i+n)%len(a)]
+	}
+	return res
+}
+
+// constantWord returns the w bits of c, least significant bit first
+func constantWord(cs *frontend.ConstraintSystem, c uint64, w int) []frontend.Variable {
+	res := make([]frontend.Variable, w)
+	for i := range res {
+		res[i] = cs.Constant(int(c >> uint(i) & 1))
+	}
+	return res
+}
+
+// leUint returns the little endian integer encoded in b
+func leUint(b []byte) uint64 {
+	var res uint64
+	for i := len(b) - 1; i >= 0; i-- {
+		res = res<<8 | uint64(b[i])
+	}
+	return res
+}
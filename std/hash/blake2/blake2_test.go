@@ -0,0 +1,130 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blake2
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+	"github.com/consensys/gurvy"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/blake2s"
+)
+
+type blake2Circuit struct {
+	hash   Blake2
+	Digest []frontend.Variable `gnark:",public"`
+	Data   []frontend.Variable
+}
+
+func (circuit *blake2Circuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	digest := circuit.hash.Hash(cs, circuit.Data...)
+	for i := range digest {
+		cs.AssertIsEqual(digest[i], circuit.Digest[i])
+	}
+	return nil
+}
+
+func blake2Witness(h Blake2, data, digest []byte) *blake2Circuit {
+	witness := blake2Circuit{
+		hash:   h,
+		Data:   make([]frontend.Variable, len(data)),
+		Digest: make([]frontend.Variable, len(digest)),
+	}
+	for i := range data {
+		witness.Data[i].Assign(int(data[i]))
+	}
+	for i := range digest {
+		witness.Digest[i].Assign(int(digest[i]))
+	}
+	return &witness
+}
+
+func testData(size int) []byte {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	return data
+}
+
+func TestBlake2b(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	for _, size := range []int{0, 1, 127, 128, 200} {
+		for _, digestSize := range []int{32, 64} {
+			h, err := NewBlake2b(digestSize, nil)
+			assert.NoError(err)
+			ref, err := blake2b.New(digestSize, nil)
+			assert.NoError(err)
+			data := testData(size)
+			ref.Write(data)
+			assert.SolvingSucceeded(blake2Witness(h, data, ref.Sum(nil)), gurvy.BN256)
+		}
+	}
+}
+
+func TestBlake2s(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	for _, size := range []int{0, 1, 63, 64, 100} {
+		h, err := NewBlake2s(32, nil)
+		assert.NoError(err)
+		digest := blake2s.Sum256(testData(size))
+		assert.SolvingSucceeded(blake2Witness(h, testData(size), digest[:]), gurvy.BN256)
+	}
+}
+
+func TestBlake2Personalization(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	// digests computed with python's hashlib
+	vectors := []struct {
+		new             func(int, []byte) (Blake2, error)
+		personalization string
+		data            []byte
+		digest          string
+	}{
+		{NewBlake2b, "ZcashPRFExpand", []byte("gnark"), "4fccf5de052ddcdd594b4cff1c7215cabb4f4f67be27ca639aa31fb9aa1a1706"},
+		{NewBlake2b, "Zcash_RedJubjubH", testData(200), "c6898263233689be170df510c6d50b9edcd129115b710bff3515424dce5d2934ed32e926cb655c9b7c1d6740390286c33bfd3643845a1f9b4274dfc92de90983"},
+		{NewBlake2s, "Zcash_nf", []byte("gnark"), "69962c76f931b882fc585e1ee4dbde0b7a62eab90cbc787e1f7e925272311210"},
+		{NewBlake2s, "Zcash_", testData(100), "291df39432de77ee080d281bf43880b4"},
+	}
+	for _, v := range vectors {
+		digest, err := hex.DecodeString(v.digest)
+		assert.NoError(err)
+		h, err := v.new(len(digest), []byte(v.personalization))
+		assert.NoError(err)
+		assert.SolvingSucceeded(blake2Witness(h, v.data, digest), gurvy.BN256)
+
+		digest[0] ^= 1
+		assert.SolvingFailed(blake2Witness(h, v.data, digest), gurvy.BN256)
+	}
+}
+
+func TestBlake2Params(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	_, err := NewBlake2b(65, nil)
+	assert.Equal(errInvalidSize, err)
+	_, err = NewBlake2s(0, nil)
+	assert.Equal(errInvalidSize, err)
+	_, err = NewBlake2s(32, make([]byte, 9))
+	assert.Equal(errInvalidPersonalization, err)
+}
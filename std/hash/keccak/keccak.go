@@ -0,0 +1,149 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package keccak implements the Keccak-f[1600] permutation and Ethereum's Keccak-256 in a circuit
+//
+// the state of the permutation is in bits: a permutation costs about 154k constraints, and Keccak256
+// one permutation per block of 136 bytes of data.
+// cf https://keccak.team/files/Keccak-reference-3.0.pdf
+package keccak
+
+import (
+	"github.com/consensys/gnark/frontend"
+)
+
+// rate of Keccak-256, in bytes
+const rate256 = 136
+
+// roundConstants are the constants of the ι step of the 24 rounds
+var roundConstants = [24]uint64{
+	0x0000000000000001, 0x0000000000008082, 0x800000000000808A, 0x8000000080008000,
+	0x000000000000808B, 0x0000000080000001, 0x8000000080008081, 0x8000000000008009,
+	0x000000000000008A, 0x0000000000000088, 0x0000000080008009, 0x000000008000000A,
+	0x000000008000808B, 0x800000000000008B, 0x8000000000008089, 0x8000000000008003,
+	0x8000000000008002, 0x8000000000000080, 0x000000000000800A, 0x800000008000000A,
+	0x8000000080008081, 0x8000000000008080, 0x0000000080000001, 0x8000000080008008,
+}
+
+// rotations are the offsets of the ρ step, rotations[x+5y] for the lane (x, y)
+var rotations = [25]int{
+	0, 1, 62, 28, 27,
+	36, 44, 6, 55, 20,
+	3, 10, 43, 25, 39,
+	41, 45, 15, 21, 8,
+	18, 2, 61, 56, 14,
+}
+
+// Permute applies the Keccak-f[1600] permutation to the state: 25 lanes of 64 bits, the lane (x, y)
+// being state[x+5y], least significant bit first
+//
+// the bits are constrained to be boolean
+func Permute(cs *frontend.ConstraintSystem, state *[25][64]frontend.Variable) {
+	for round := 0; round < len(roundConstants); round++ {
+		// θ
+		var c, d [5][64]frontend.Variable
+		for x := 0; x < 5; x++ {
+			for i := 0; i < 64; i++ {
+				c[x][i] = cs.Xor(state[x][i], state[x+5][i], state[x+10][i], state[x+15][i], state[x+20][i])
+			}
+		}
+		for x := 0; x < 5; x++ {
+			for i := 0; i < 64; i++ {
+				d[x][i] = cs.Xor(c[(x+4)%5][i], c[(x+1)%5][(i+63)%64])
+			}
+		}
+		for j := 0; j < 25; j++ {
+			for i := 0; i < 64; i++ {
+				state[j][i] = cs.Xor(state[j][i], d[j%5][i])
+			}
+		}
+
+		// ρ and π: the lane (x, y), rotated, moves to (y, 2x+3y)
+		var b [25][64]frontend.Variable
+		for x := 0; x < 5; x++ {
+			for y := 0; y < 5; y++ {
+				r := rotations[x+5*y]
+				to := y + 5*((2*x+3*y)%5)
+				for i := 0; i < 64; i++ {
+					b[to][i] = state[x+5*y][(i+64-r)%64]
+				}
+			}
+		}
+
+		// χ
+		for x := 0; x < 5; x++ {
+			for y := 0; y < 5; y++ {
+				for i := 0; i < 64; i++ {
+					state[x+5*y][i] = cs.Xor(b[x+5*y][i], cs.And(cs.Not(b[(x+1)%5+5*y][i]), b[(x+2)%5+5*y][i]))
+				}
+			}
+		}
+
+		// ι
+		for i := 0; i < 64; i++ {
+			if roundConstants[round]>>i&1 == 1 {
+				state[0][i] = cs.Xor(state[0][i], cs.Constant(1))
+			}
+		}
+	}
+}
+
+// Keccak256 returns the Keccak-256 digest of data as computed by Ethereum (with the padding of the
+// Keccak submission, not the one of SHA3-256)
+//
+// data and the 32 bytes of the digest are given as bytes, one variable per byte: the bytes of data are
+// constrained to 8 bits.
+func Keccak256(cs *frontend.ConstraintSystem, data ...frontend.Variable) []frontend.Variable {
+	return sponge(cs, data, rate256, 0x01, 32)
+}
+
+// sponge absorbs data in blocks of rate bytes, padded with pad10*1 (the first byte of the padding
+// being dsByte), and squeezes outLen bytes, outLen <= rate
+func sponge(cs *frontend.ConstraintSystem, data []frontend.Variable, rate int, dsByte uint8, outLen int) []frontend.Variable {
+	// the bits of the padded data, least significant bit of each byte first
+	bits := make([]frontend.Variable, 0, (len(data)/rate+1)*rate*8)
+	for i := range data {
+		bits = append(bits, cs.ToBinary(data[i], 8)...)
+	}
+	padding := make([]uint8, rate-len(data)%rate)
+	padding[0] = dsByte
+	padding[len(padding)-1] |= 0x80
+	for _, p := range padding {
+		for i := 0; i < 8; i++ {
+			bits = append(bits, cs.Constant(int(p>>i&1)))
+		}
+	}
+
+	var state [25][64]frontend.Variable
+	for j := range state {
+		for i := range state[j] {
+			state[j][i] = cs.Constant(0)
+		}
+	}
+	for start := 0; start < len(bits); start += rate * 8 {
+		block := bits[start : start+rate*8]
+		for i := range block {
+			state[i/64][i%64] = cs.Xor(state[i/64][i%64], block[i])
+		}
+		Permute(cs, &state)
+	}
+
+	res := make([]frontend.Variable, outLen)
+	for i := range res {
+		res[i] = cs.FromBinary(state[i/8][i%8*8 : i%8*8+8]...)
+	}
+	return res
+}
@@ -0,0 +1,129 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keccak
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+	"github.com/consensys/gurvy"
+	"golang.org/x/crypto/sha3"
+)
+
+type keccakCircuit struct {
+	Digest [32]frontend.Variable `gnark:",public"`
+	Data   []frontend.Variable
+}
+
+func (circuit *keccakCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	digest := Keccak256(cs, circuit.Data...)
+	for i := range digest {
+		cs.AssertIsEqual(digest[i], circuit.Digest[i])
+	}
+	return nil
+}
+
+func keccakWitness(data []byte) *keccakCircuit {
+	h := sha3.NewLegacyKeccak256()
+	h.Write(data)
+	digest := h.Sum(nil)
+
+	var witness keccakCircuit
+	witness.Data = make([]frontend.Variable, len(data))
+	for i := range data {
+		witness.Data[i].Assign(int(data[i]))
+	}
+	for i := range digest {
+		witness.Digest[i].Assign(int(digest[i]))
+	}
+	return &witness
+}
+
+func TestKeccak256(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	sizes := []int{0, 1, rate256 - 1, rate256}
+	if !testing.Short() {
+		sizes = append(sizes, 200)
+	}
+	for _, size := range sizes {
+		data := make([]byte, size)
+		for i := range data {
+			data[i] = byte(31*i + 7)
+		}
+		assert.SolvingSucceeded(keccakWitness(data), gurvy.BN256)
+	}
+
+	// wrong digest
+	witness := keccakWitness([]byte("gnark"))
+	witness.Digest[0] = frontend.Variable{}
+	witness.Digest[0].Assign(0)
+	assert.SolvingFailed(witness, gurvy.BN256)
+
+	// data not in bytes
+	witness = keccakWitness([]byte("gnark"))
+	witness.Data[0] = frontend.Variable{}
+	witness.Data[0].Assign(256 + int('g'))
+	assert.SolvingFailed(witness, gurvy.BN256)
+}
+
+func TestKeccak256Compiled(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping the compiled keccak circuit in short mode")
+	}
+	assert := groth16.NewAssert(t)
+
+	var circuit keccakCircuit
+	circuit.Data = make([]frontend.Variable, 32)
+	r1cs, err := frontend.Compile(gurvy.BN256, &circuit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := make([]byte, 32)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	assert.SolvingSucceeded(r1cs, keccakWitness(data))
+}
+
+type permuteCircuit struct {
+	State [25][64]frontend.Variable
+}
+
+func (circuit *permuteCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	for x := range circuit.State {
+		for i := range circuit.State[x] {
+			cs.AssertIsBoolean(circuit.State[x][i])
+		}
+	}
+	Permute(cs, &circuit.State)
+	return nil
+}
+
+// TestPermuteNbConstraints checks that the χ step doesn't constrain the booleanity of 1 - b again
+func TestPermuteNbConstraints(t *testing.T) {
+	r1cs, err := frontend.Compile(gurvy.BN256, &permuteCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nbConstraints := int(r1cs.GetNbConstraints()) - 25*64; nbConstraints > 154000 {
+		t.Fatal("a permutation should cost less than 154k constraints, got", nbConstraints)
+	}
+}
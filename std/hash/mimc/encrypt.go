@@ -19,118 +19,119 @@ package mimc
 import (
 	"math/big"
 
-	"github.com/consensys/gnark/crypto/hash/mimc/bls377"
-	"github.com/consensys/gnark/crypto/hash/mimc/bls381"
-	"github.com/consensys/gnark/crypto/hash/mimc/bn256"
-
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gurvy"
+	"golang.org/x/crypto/sha3"
+
+	fr_bls377 "github.com/consensys/gurvy/bls377/fr"
+	fr_bls381 "github.com/consensys/gurvy/bls381/fr"
+	fr_bn256 "github.com/consensys/gurvy/bn256/fr"
 )
 
-var encryptFuncs map[gurvy.ID]func(*frontend.ConstraintSystem, MiMC, frontend.Variable, frontend.Variable) frontend.Variable
-var newMimc map[gurvy.ID]func(string) MiMC
+// defaultNbRounds is the number of rounds of crypto/hash/mimc
+const defaultNbRounds = 91
+
+// curve holds the default parameters of MiMC on a curve
+type curve struct {
+	modulus  *big.Int
+	exponent int // exponent of crypto/hash/mimc
+}
+
+var curves map[gurvy.ID]curve
 
 func init() {
-	encryptFuncs = make(map[gurvy.ID]func(*frontend.ConstraintSystem, MiMC, frontend.Variable, frontend.Variable) frontend.Variable)
-	encryptFuncs[gurvy.BN256] = encryptBN256
-	encryptFuncs[gurvy.BLS381] = encryptBLS381
-	encryptFuncs[gurvy.BLS377] = encryptBLS377
-
-	newMimc = make(map[gurvy.ID]func(string) MiMC)
-	newMimc[gurvy.BN256] = newMimcBN256
-	newMimc[gurvy.BLS381] = newMimcBLS381
-	newMimc[gurvy.BLS377] = newMimcBLS377
+	curves = make(map[gurvy.ID]curve)
+	curves[gurvy.BN256] = curve{modulus: fr_bn256.Modulus(), exponent: 7}
+	curves[gurvy.BLS381] = curve{modulus: fr_bls381.Modulus(), exponent: 5}
+	curves[gurvy.BLS377] = curve{modulus: fr_bls377.Modulus(), exponent: -1}
 }
 
-// -------------------------------------------------------------------------------------------------
-// constructors
-
-func newMimcBLS377(seed string) MiMC {
-	res := MiMC{}
-	params := bls377.NewParams(seed)
-	for _, v := range params {
-		var cpy big.Int
-		v.ToBigIntRegular(&cpy)
-		res.params = append(res.params, cpy)
+func roundsOrDefault(nbRounds int) int {
+	if nbRounds == 0 {
+		return defaultNbRounds
 	}
-	res.id = gurvy.BLS377
-	return res
+	return nbRounds
 }
 
-func newMimcBLS381(seed string) MiMC {
-	res := MiMC{}
-	params := bls381.NewParams(seed)
-	for _, v := range params {
-		var cpy big.Int
-		v.ToBigIntRegular(&cpy)
-		res.params = append(res.params, cpy)
+// -------------------------------------------------------------------------------------------------
+// round constants
+
+// sha3Constants returns the round constants of crypto/hash/mimc (NewParams): c_i = sha3^(i+2)(seed) mod r,
+// the hashes being chained on the big endian bytes of their value
+func sha3Constants(seed string, nbRounds int, modulus *big.Int) []big.Int {
+	res := make([]big.Int, nbRounds)
+
+	rnd := sha3.Sum256([]byte(seed))
+	value := new(big.Int).SetBytes(rnd[:])
+
+	for i := 0; i < nbRounds; i++ {
+		rnd = sha3.Sum256(value.Bytes())
+		value.SetBytes(rnd[:])
+		res[i].Mod(value, modulus)
 	}
-	res.id = gurvy.BLS381
 	return res
 }
 
-func newMimcBN256(seed string) MiMC {
-	res := MiMC{}
-	params := bn256.NewParams(seed)
-	for _, v := range params {
-		var cpy big.Int
-		v.ToBigIntRegular(&cpy)
-		res.params = append(res.params, cpy)
+// keccakConstants returns the round constants of circomlib (see WithKeccakConstants)
+func keccakConstants(seed string, nbRounds int, feistel bool, modulus *big.Int) []big.Int {
+	res := make([]big.Int, nbRounds)
+
+	h := sha3.NewLegacyKeccak256()
+	h.Write([]byte(seed))
+	rnd := h.Sum(nil)
+
+	for i := 1; i < nbRounds; i++ {
+		h.Reset()
+		h.Write(rnd)
+		rnd = h.Sum(nil)
+		res[i].SetBytes(rnd).Mod(&res[i], modulus)
+	}
+	if feistel {
+		res[nbRounds-1].SetUint64(0)
 	}
-	res.id = gurvy.BN256
 	return res
 }
 
 // -------------------------------------------------------------------------------------------------
-// encryptions functions
+// permutations
 
-// encryptBn256 of a mimc run expressed as r1cs
-func encryptBN256(cs *frontend.ConstraintSystem, h MiMC, message, key frontend.Variable) frontend.Variable {
-
-	res := message
-	// one := big.NewInt(1)
-	for i := 0; i < len(h.params); i++ {
-		//for i := 0; i < 1; i++ {
-		// tmp := cs.LinearExpression(cs.Term(res, one), cs.Term(key, one), cs.Term(cs.Constant(1), &h.params[i]))
-		tmp := cs.Add(res, key, h.params[i])
-		// res = (res+k+c)^7
-		res = cs.Mul(tmp, tmp)
-		res = cs.Mul(res, tmp)
+// pow returns x^h.exponent; x^7 is x², x³, x⁶, x⁷ (4 constraints), x^5 is x², x⁴, x⁵ (3 constraints), and
+// the inversion costs 1 constraint
+func (h MiMC) pow(cs *frontend.ConstraintSystem, x frontend.Variable) frontend.Variable {
+	if h.exponent == -1 {
+		return cs.Inverse(x)
+	}
+	e := big.NewInt(int64(h.exponent))
+	res := x
+	for i := e.BitLen() - 2; i >= 0; i-- {
 		res = cs.Mul(res, res)
-		res = cs.Mul(res, tmp)
+		if e.Bit(i) == 1 {
+			res = cs.Mul(res, x)
+		}
 	}
-	res = cs.Add(res, key)
 	return res
-
 }
 
-// execution of a mimc run expressed as r1cs
-func encryptBLS381(cs *frontend.ConstraintSystem, h MiMC, message frontend.Variable, key frontend.Variable) frontend.Variable {
-
+// encrypt of a mimc run expressed as r1cs: each round maps res to (res+key+c)^e, and the key is added
+// to the result
+func (h MiMC) encrypt(cs *frontend.ConstraintSystem, message, key frontend.Variable) frontend.Variable {
 	res := message
-
 	for i := 0; i < len(h.params); i++ {
-		//tmp := cs.LinearExpression(cs.Term(res, one), cs.Term(key, one), cs.Term(cs.Constant(1), &h.params[i]))
-		tmp := cs.Add(res, key, h.params[i])
-		// res = (res+k+c)^5
-		res = cs.Mul(tmp, tmp) // square
-		res = cs.Mul(res, res) // square
-		res = cs.Mul(res, tmp) // mul
+		res = h.pow(cs, cs.Add(res, key, h.params[i]))
 	}
 	res = cs.Add(res, key)
 	return res
-
 }
 
-// encryptBLS377 of a mimc run expressed as r1cs
-func encryptBLS377(cs *frontend.ConstraintSystem, h MiMC, message frontend.Variable, key frontend.Variable) frontend.Variable {
-	res := message
+// feistelPermutation returns the Feistel network of the rounds on (xL, xR), keyed with key (see WithFeistel)
+func (h MiMC) feistelPermutation(cs *frontend.ConstraintSystem, xL, xR, key frontend.Variable) (frontend.Variable, frontend.Variable) {
 	for i := 0; i < len(h.params); i++ {
-		tmp := cs.Add(res, h.params[i], key)
-		// res = (res+key+c)**-1
-		res = cs.Inverse(tmp)
+		t := cs.Add(xR, h.pow(cs, cs.Add(xL, key, h.params[i])))
+		if i < len(h.params)-1 {
+			xL, xR = t, xL
+		} else {
+			xR = t
+		}
 	}
-	res = cs.Add(res, key)
-	return res
-
+	return xL, xR
 }
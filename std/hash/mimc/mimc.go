@@ -27,31 +27,87 @@ import (
 
 // MiMC contains the params of the Mimc hash func and the curves on which it is implemented
 type MiMC struct {
-	params []big.Int
-	id     gurvy.ID
+	params   []big.Int // round constants
+	id       gurvy.ID
+	exponent int // -1 for the inversion
+	feistel  bool
 }
 
 // NewMiMC returns a MiMC instance, than can be used in a gnark circuit
-func NewMiMC(seed string, id gurvy.ID) (MiMC, error) {
-	if constructor, ok := newMimc[id]; ok {
-		return constructor(seed), nil
+//
+// without options, it is the MiMC of crypto/hash/mimc; the options set its parameters to match other
+// implementations, such as circomlib's (see WithKeccakConstants).
+func NewMiMC(seed string, id gurvy.ID, opts ...Option) (MiMC, error) {
+	curve, ok := curves[id]
+	if !ok {
+		return MiMC{}, errors.New("unknown curve id")
 	}
-	return MiMC{}, errors.New("unknown curve id")
+	var c config
+	for _, opt := range opts {
+		if err := opt(&c); err != nil {
+			return MiMC{}, err
+		}
+	}
+
+	res := MiMC{id: id, exponent: curve.exponent, feistel: c.feistel}
+	if c.exponent != 0 {
+		res.exponent = c.exponent
+	}
+	if res.exponent != -1 {
+		var e, gcd, rMinusOne big.Int
+		e.SetInt64(int64(res.exponent))
+		rMinusOne.Sub(curve.modulus, big.NewInt(1))
+		if gcd.GCD(nil, nil, &e, &rMinusOne).Cmp(big.NewInt(1)) != 0 {
+			return MiMC{}, errExponent
+		}
+	}
+
+	switch {
+	case c.constants != nil:
+		if c.nbRounds != 0 && c.nbRounds != len(c.constants) {
+			return MiMC{}, errRounds
+		}
+		res.params = c.constants
+		for i := range res.params {
+			res.params[i].Mod(&res.params[i], curve.modulus)
+		}
+	case c.keccak:
+		res.params = keccakConstants(seed, roundsOrDefault(c.nbRounds), c.feistel, curve.modulus)
+	default:
+		res.params = sha3Constants(seed, roundsOrDefault(c.nbRounds), curve.modulus)
+	}
+	return res, nil
 }
 
 // Hash hash (in r1cs form) using Miyaguchi–Preneel:
 // https://en.wikipedia.org/wiki/One-way_compression_function
 // The XOR operation is replaced by field addition
+//
+// with WithFeistel, the data is absorbed in a sponge on the Feistel permutation instead, the hash being
+// its first element after the last absorption.
 func (h MiMC) Hash(cs *frontend.ConstraintSystem, data ...frontend.Variable) frontend.Variable {
 
+	if h.feistel {
+		r, c := cs.Constant(0), cs.Constant(0)
+		for _, stream := range data {
+			r, c = h.feistelPermutation(cs, cs.Add(r, stream), c, cs.Constant(0))
+		}
+		return r
+	}
+
 	var digest frontend.Variable
 	digest = cs.Constant(0)
 
 	for _, stream := range data {
-		digest = encryptFuncs[h.id](cs, h, stream, digest)
+		digest = h.encrypt(cs, stream, digest)
 		digest = cs.Add(digest, stream)
 	}
 
 	return digest
 
 }
+
+// Define returns the hash of data, as Hash: a MiMC is a gadget.Gadget
+func (h MiMC) Define(cs *frontend.ConstraintSystem, data ...frontend.Variable) ([]frontend.Variable, error) {
+	return []frontend.Variable{h.Hash(cs, data...)}, nil
+}
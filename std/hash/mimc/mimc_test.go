@@ -17,9 +17,11 @@ limitations under the License.
 package mimc
 
 import (
+	"math/big"
 	"testing"
 
 	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/test"
 
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gurvy"
@@ -136,3 +138,169 @@ func TestMimcBLS377(t *testing.T) {
 	assert.SolvingSucceeded(r1cs, &witness)
 
 }
+
+func TestSha3Constants(t *testing.T) {
+	// the default constants are the ones of crypto/hash/mimc
+	check := func(id gurvy.ID, params []big.Int) {
+		constants := sha3Constants("seed", defaultNbRounds, curves[id].modulus)
+		if len(constants) != len(params) {
+			t.Fatal(id, "wrong number of constants")
+		}
+		for i := range params {
+			if constants[i].Cmp(&params[i]) != 0 {
+				t.Fatal(id, "wrong constant", i)
+			}
+		}
+	}
+	toBigInts := func(n int, f func(i int, b *big.Int)) []big.Int {
+		res := make([]big.Int, n)
+		for i := range res {
+			f(i, &res[i])
+		}
+		return res
+	}
+	bn256 := mimcbn256.NewParams("seed")
+	check(gurvy.BN256, toBigInts(len(bn256), func(i int, b *big.Int) { bn256[i].ToBigIntRegular(b) }))
+	bls381 := mimcbls381.NewParams("seed")
+	check(gurvy.BLS381, toBigInts(len(bls381), func(i int, b *big.Int) { bls381[i].ToBigIntRegular(b) }))
+	bls377 := mimcbls377.NewParams("seed")
+	check(gurvy.BLS377, toBigInts(len(bls377), func(i int, b *big.Int) { bls377[i].ToBigIntRegular(b) }))
+}
+
+// referenceHash computes MiMC.Hash on big integers
+func referenceHash(h MiMC, data []*big.Int) *big.Int {
+	modulus := curves[h.id].modulus
+	pow := func(x *big.Int) *big.Int {
+		if h.exponent == -1 {
+			return x.ModInverse(x, modulus)
+		}
+		return x.Exp(x, big.NewInt(int64(h.exponent)), modulus)
+	}
+	round := func(x, key *big.Int, i int) *big.Int {
+		t := new(big.Int).Add(x, key)
+		return pow(t.Add(t, &h.params[i]).Mod(t, modulus))
+	}
+
+	if h.feistel {
+		r, c := new(big.Int), new(big.Int)
+		for _, d := range data {
+			r.Add(r, d).Mod(r, modulus)
+			for i := range h.params {
+				t := round(r, new(big.Int), i)
+				t.Add(t, c).Mod(t, modulus)
+				if i < len(h.params)-1 {
+					r, c = t, r
+				} else {
+					c = t
+				}
+			}
+		}
+		return r
+	}
+
+	digest := new(big.Int)
+	for _, d := range data {
+		res := new(big.Int).Set(d)
+		for i := range h.params {
+			res = round(res, digest, i)
+		}
+		res.Add(res, digest).Add(res, d).Mod(res, modulus)
+		digest = res
+	}
+	return digest
+}
+
+type optionsCircuit struct {
+	Data []frontend.Variable
+	Hash frontend.Variable `gnark:",public"`
+	seed string
+	opts []Option
+}
+
+func (circuit *optionsCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	mimc, err := NewMiMC(circuit.seed, curveID, circuit.opts...)
+	if err != nil {
+		return err
+	}
+	cs.AssertIsEqual(mimc.Hash(cs, circuit.Data...), circuit.Hash)
+	return nil
+}
+
+func TestMiMCOptions(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	constants := make([]big.Int, 11)
+	for i := range constants {
+		constants[i].SetInt64(int64(i * i))
+	}
+
+	cases := []struct {
+		curveID gurvy.ID
+		seed    string
+		opts    []Option
+	}{
+		{gurvy.BN256, "seed", nil},
+		// circomlib's MiMC7 and MiMCSponge
+		{gurvy.BN256, "mimc", []Option{WithKeccakConstants()}},
+		{gurvy.BN256, "mimcsponge", []Option{WithFeistel(), WithRounds(220), WithExponent(5), WithKeccakConstants()}},
+		{gurvy.BLS381, "seed", []Option{WithRounds(13), WithExponent(7)}},
+		{gurvy.BLS377, "seed", []Option{WithFeistel(), WithRounds(20)}},
+		{gurvy.BLS381, "", []Option{WithConstants(constants), WithFeistel(), WithExponent(5)}},
+	}
+	for _, c := range cases {
+		h, err := NewMiMC(c.seed, c.curveID, c.opts...)
+		assert.NoError(err)
+
+		data := []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3)}
+		expected := referenceHash(h, data)
+
+		witness := optionsCircuit{Data: make([]frontend.Variable, len(data))}
+		for i := range data {
+			witness.Data[i].Assign(data[i])
+		}
+		witness.Hash.Assign(expected)
+		witness.seed, witness.opts = c.seed, c.opts
+		assert.SolvingSucceeded(&witness, c.curveID)
+
+		witness.Hash = frontend.Variable{}
+		witness.Hash.Assign(new(big.Int).Add(expected, big.NewInt(1)))
+		assert.SolvingFailed(&witness, c.curveID)
+	}
+}
+
+func TestMiMCOptionsConstants(t *testing.T) {
+	// the MiMCSponge constants start from the hash of the hash of the seed, and are 0 at both ends
+	h, err := NewMiMC("mimcsponge", gurvy.BN256, WithFeistel(), WithRounds(220), WithKeccakConstants())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(h.params) != 220 || h.params[0].Sign() != 0 || h.params[219].Sign() != 0 || h.params[1].Sign() == 0 {
+		t.Fatal("wrong MiMCSponge constants")
+	}
+	h, err = NewMiMC("mimc", gurvy.BN256, WithKeccakConstants())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(h.params) != 91 || h.params[0].Sign() != 0 || h.params[90].Sign() == 0 {
+		t.Fatal("wrong MiMC7 constants")
+	}
+}
+
+func TestNewMiMCErrors(t *testing.T) {
+	constants := make([]big.Int, 3)
+	for _, opts := range [][]Option{
+		{WithRounds(0)},
+		{WithExponent(2)},
+		{WithExponent(3)}, // 3 divides r-1
+		{WithExponent(9)},
+		{WithConstants(nil)},
+		{WithConstants(constants), WithRounds(4)},
+	} {
+		if _, err := NewMiMC("seed", gurvy.BN256, opts...); err == nil {
+			t.Fatal("the options should be rejected")
+		}
+	}
+	if _, err := NewMiMC("seed", gurvy.BW761); err == nil {
+		t.Fatal("BW761 is not supported")
+	}
+}
@@ -0,0 +1,106 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mimc
+
+import (
+	"errors"
+	"math/big"
+)
+
+var (
+	errRounds   = errors.New("mimc: invalid number of rounds")
+	errExponent = errors.New("mimc: the exponent must be -1, or at least 3 and coprime with r-1")
+)
+
+// Option configures a MiMC instance (see NewMiMC)
+type Option func(c *config) error
+
+// config holds the parameters of a MiMC instance; zero values stand for the defaults of the curve
+type config struct {
+	nbRounds  int
+	exponent  int
+	feistel   bool
+	keccak    bool
+	constants []big.Int
+}
+
+// WithRounds sets the number of rounds of the permutation (91 by default)
+func WithRounds(nbRounds int) Option {
+	return func(c *config) error {
+		if nbRounds <= 0 {
+			return errRounds
+		}
+		c.nbRounds = nbRounds
+		return nil
+	}
+}
+
+// WithExponent sets the exponent of the rounds, x -> x^exponent, -1 being the inversion; by default it
+// is 7 on BN256, 5 on BLS381 and -1 on BLS377
+//
+// NewMiMC checks that it is coprime with r-1, so that the rounds are permutations.
+func WithExponent(exponent int) Option {
+	return func(c *config) error {
+		if exponent != -1 && exponent < 3 {
+			return errExponent
+		}
+		c.exponent = exponent
+		return nil
+	}
+}
+
+// WithFeistel uses the permutation in a Feistel network on 2 field elements, (xL, xR) ->
+// (xR + (xL+k+c)^e, xL) for each round but the last one, which updates xR only, and Hash absorbs the data
+// in a sponge of rate 1 on it: this is MiMCSponge (MiMCFeistel) of circomlib.
+//
+// without it, MiMC is the plain block cipher x -> (x+k+c)^e of gnark and of circomlib's MiMC7, used in
+// Miyaguchi–Preneel mode by Hash.
+func WithFeistel() Option {
+	return func(c *config) error {
+		c.feistel = true
+		return nil
+	}
+}
+
+// WithKeccakConstants derives the round constants from the seed as circomlib does, instead of with
+// gnark's SHA3 chain: c_0 = 0, and c_i = keccak256^(i+1)(seed) mod r, the hashes being chained on 32
+// bytes; with WithFeistel the constant of the last round is 0 as well.
+//
+// NewMiMC("mimc", gurvy.BN256, WithKeccakConstants()) is then circomlib's MiMC7 (91 rounds, x^7), and
+// NewMiMC(seed, gurvy.BN256, WithFeistel(), WithRounds(220), WithExponent(5), WithKeccakConstants()) its
+// MiMCSponge, provided seed is the string circomlib hashes first.
+func WithKeccakConstants() Option {
+	return func(c *config) error {
+		c.keccak = true
+		return nil
+	}
+}
+
+// WithConstants sets the round constants explicitly, their number being the number of rounds; the seed
+// is then ignored
+func WithConstants(constants []big.Int) Option {
+	return func(c *config) error {
+		if len(constants) == 0 {
+			return errRounds
+		}
+		c.constants = make([]big.Int, len(constants))
+		for i := range constants {
+			c.constants[i].Set(&constants[i])
+		}
+		return nil
+	}
+}
@@ -0,0 +1,121 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pedersen
+
+import (
+	"math/big"
+
+	pedersenbls381 "github.com/consensys/gnark/crypto/hash/pedersen/bls381"
+	pedersenbn256 "github.com/consensys/gnark/crypto/hash/pedersen/bn256"
+	edbls381 "github.com/consensys/gurvy/bls381/twistededwards"
+	edbn256 "github.com/consensys/gurvy/bn256/twistededwards"
+
+	"github.com/consensys/gurvy"
+)
+
+// point of the twisted Edwards curve, in regular form
+type point struct {
+	x, y big.Int
+}
+
+// table holds the multiples [k*2^(4j)] G, k = 1..4, of the generator G of a segment, for its chunks j
+type table [chunksPerSegment][4]point
+
+var newTable map[gurvy.ID]func(personalization string, segment int) (table, error)
+var newRandomnessGenerator map[gurvy.ID]func(personalization string) (point, error)
+
+func init() {
+	newTable = make(map[gurvy.ID]func(string, int) (table, error))
+	newTable[gurvy.BN256] = newTableBN256
+	newTable[gurvy.BLS381] = newTableBLS381
+
+	newRandomnessGenerator = make(map[gurvy.ID]func(string) (point, error))
+	newRandomnessGenerator[gurvy.BN256] = newRandomnessGeneratorBN256
+	newRandomnessGenerator[gurvy.BLS381] = newRandomnessGeneratorBLS381
+}
+
+// -------------------------------------------------------------------------------------------------
+// BN256
+
+func newTableBN256(personalization string, segment int) (table, error) {
+	var res table
+	g, err := pedersenbn256.Generator(personalization, segment)
+	if err != nil {
+		return res, err
+	}
+	for j := range res {
+		var p edbn256.Point
+		p.Set(&g)
+		for k := range res[j] {
+			p.X.ToBigIntRegular(&res[j][k].x)
+			p.Y.ToBigIntRegular(&res[j][k].y)
+			p.Add(&p, &g)
+		}
+		// the generator of the next chunk is [16] g
+		for i := 0; i < 4; i++ {
+			g.Double(&g)
+		}
+	}
+	return res, nil
+}
+
+func newRandomnessGeneratorBN256(personalization string) (point, error) {
+	var res point
+	g, err := pedersenbn256.RandomnessGenerator(personalization)
+	if err != nil {
+		return res, err
+	}
+	g.X.ToBigIntRegular(&res.x)
+	g.Y.ToBigIntRegular(&res.y)
+	return res, nil
+}
+
+// -------------------------------------------------------------------------------------------------
+// BLS381
+
+func newTableBLS381(personalization string, segment int) (table, error) {
+	var res table
+	g, err := pedersenbls381.Generator(personalization, segment)
+	if err != nil {
+		return res, err
+	}
+	for j := range res {
+		var p edbls381.Point
+		p.Set(&g)
+		for k := range res[j] {
+			p.X.ToBigIntRegular(&res[j][k].x)
+			p.Y.ToBigIntRegular(&res[j][k].y)
+			p.Add(&p, &g)
+		}
+		// the generator of the next chunk is [16] g
+		for i := 0; i < 4; i++ {
+			g.Double(&g)
+		}
+	}
+	return res, nil
+}
+
+func newRandomnessGeneratorBLS381(personalization string) (point, error) {
+	var res point
+	g, err := pedersenbls381.RandomnessGenerator(personalization)
+	if err != nil {
+		return res, err
+	}
+	g.X.ToBigIntRegular(&res.x)
+	g.Y.ToBigIntRegular(&res.y)
+	return res, nil
+}
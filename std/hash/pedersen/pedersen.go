@@ -0,0 +1,133 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package pedersen implements the windowed Pedersen hashes and commitments of crypto/hash/pedersen in a
+// circuit, on the twisted Edwards curve embedded in BN256 or BLS381 (Jubjub, where they are the ones of
+// Zcash Sapling)
+//
+// each chunk of 3 bits selects one of 4 precomputed points and adds it to the hash: about 14 constraints
+// per chunk.
+package pedersen
+
+import (
+	"math/big"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra/twistededwards"
+	"github.com/consensys/gurvy"
+)
+
+// chunksPerSegment is the number of chunks of 3 bits hashed with the same generator
+const chunksPerSegment = 63
+
+// Pedersen is a windowed Pedersen hash function, its generators derived from a personalization
+type Pedersen struct {
+	curve           twistededwards.EdCurve
+	personalization string
+	randomness      point // generator of the randomness of the commitments
+}
+
+// NewPedersen returns a Pedersen instance on the twisted Edwards curve embedded in the curve id, that can
+// be used in a gnark circuit
+//
+// personalization is 8 bytes long: "Zcash_PH" gives the hashes and commitments of Zcash Sapling on BLS381.
+func NewPedersen(id gurvy.ID, personalization string) (Pedersen, error) {
+	curve, err := twistededwards.NewEdCurve(id)
+	if err != nil {
+		return Pedersen{}, err
+	}
+	res := Pedersen{curve: curve, personalization: personalization}
+	// checks the personalization
+	if res.randomness, err = newRandomnessGenerator[id](personalization); err != nil {
+		return Pedersen{}, err
+	}
+	return res, nil
+}
+
+// HashToPoint returns the Pedersen hash of the bits (padded with zeros to a multiple of 3 bits); the
+// bits are constrained to be boolean
+func (h Pedersen) HashToPoint(cs *frontend.ConstraintSystem, bits ...frontend.Variable) twistededwards.Point {
+	res := twistededwards.Point{X: cs.Constant(0), Y: cs.Constant(1)}
+	first := true
+
+	for segment := 0; segment*3*chunksPerSegment < len(bits); segment++ {
+		t, err := newTable[h.curve.ID](h.personalization, segment)
+		if err != nil {
+			panic(err)
+		}
+		start := segment * 3 * chunksPerSegment
+		for j := 0; j < chunksPerSegment && start+3*j < len(bits); j++ {
+			var chunk [3]frontend.Variable
+			for k := range chunk {
+				if start+3*j+k < len(bits) {
+					chunk[k] = bits[start+3*j+k]
+				} else {
+					chunk[k] = cs.Constant(0)
+				}
+			}
+			p := h.lookup(cs, &t[j], chunk)
+			if first {
+				res, first = p, false
+				continue
+			}
+			res.AddGeneric(cs, &res, &p, h.curve)
+		}
+	}
+	return res
+}
+
+// lookup returns [enc(chunk)] P, P being the generator of the chunk, from its multiples
+// [1]P, [2]P, [3]P, [4]P
+func (h Pedersen) lookup(cs *frontend.ConstraintSystem, multiples *[4]point, chunk [3]frontend.Variable) twistededwards.Point {
+	s01 := cs.And(chunk[0], chunk[1])
+	cs.AssertIsBoolean(chunk[2])
+
+	// c0 + s0(c1-c0) + s1(c2-c0) + s0s1(c3-c2-c1+c0), selecting [1 + s0 + 2*s1]P
+	coordinate := func(c0, c1, c2, c3 *big.Int) frontend.Variable {
+		var a, b, c big.Int
+		a.Sub(c1, c0).Mod(&a, &h.curve.Modulus)
+		b.Sub(c2, c0).Mod(&b, &h.curve.Modulus)
+		c.Sub(c3, c2).Sub(&c, c1).Add(&c, c0).Mod(&c, &h.curve.Modulus)
+		return cs.Add(cs.Mul(chunk[0], &a), cs.Mul(chunk[1], &b), cs.Mul(s01, &c), c0)
+	}
+	x := coordinate(&multiples[0].x, &multiples[1].x, &multiples[2].x, &multiples[3].x)
+	y := coordinate(&multiples[0].y, &multiples[1].y, &multiples[2].y, &multiples[3].y)
+
+	// s2 negates the point: x -> -x
+	x = cs.Sub(x, cs.Mul(cs.Mul(chunk[2], x), 2))
+
+	return twistededwards.Point{X: x, Y: y}
+}
+
+// Hash returns the X coordinate of HashToPoint
+func (h Pedersen) Hash(cs *frontend.ConstraintSystem, bits ...frontend.Variable) frontend.Variable {
+	return h.HashToPoint(cs, bits...).X
+}
+
+// Define returns the hash of the bits, as Hash: a Pedersen is a gadget.Gadget
+func (h Pedersen) Define(cs *frontend.ConstraintSystem, bits ...frontend.Variable) ([]frontend.Variable, error) {
+	return []frontend.Variable{h.Hash(cs, bits...)}, nil
+}
+
+// Commit returns the windowed Pedersen commitment to the bits: HashToPoint(bits) + [randomness] R, R being
+// the randomness generator of crypto/hash/pedersen
+func (h Pedersen) Commit(cs *frontend.ConstraintSystem, randomness frontend.Variable, bits ...frontend.Variable) twistededwards.Point {
+	res := h.HashToPoint(cs, bits...)
+	var r twistededwards.Point
+	r.ScalarMulFixedBase(cs, h.randomness.x, h.randomness.y, randomness, h.curve)
+	res.AddGeneric(cs, &res, &r, h.curve)
+	return res
+}
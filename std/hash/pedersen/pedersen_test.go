@@ -0,0 +1,156 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pedersen
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+	"github.com/consensys/gurvy"
+
+	pedersenbls381 "github.com/consensys/gnark/crypto/hash/pedersen/bls381"
+	pedersenbn256 "github.com/consensys/gnark/crypto/hash/pedersen/bn256"
+)
+
+const personalization = "Zcash_PH"
+
+type pedersenCircuit struct {
+	Hash       frontend.Variable    `gnark:",public"`
+	Commitment [2]frontend.Variable `gnark:",public"`
+	Randomness frontend.Variable
+	Bits       []frontend.Variable
+}
+
+func (circuit *pedersenCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	pedersen, err := NewPedersen(curveID, personalization)
+	if err != nil {
+		return err
+	}
+	cs.AssertIsEqual(pedersen.Hash(cs, circuit.Bits...), circuit.Hash)
+	c := pedersen.Commit(cs, circuit.Randomness, circuit.Bits...)
+	cs.AssertIsEqual(c.X, circuit.Commitment[0])
+	cs.AssertIsEqual(c.Y, circuit.Commitment[1])
+	return nil
+}
+
+// message returns n bits of a fixed pattern
+func message(n int) []bool {
+	res := make([]bool, n)
+	for i := range res {
+		res[i] = (i*i+i/3)%5 < 2
+	}
+	return res
+}
+
+// pedersenWitness returns the witness of the hash and the commitment of the message, computed by
+// crypto/hash/pedersen
+func pedersenWitness(t *testing.T, curveID gurvy.ID, msg []bool, randomness int64) *pedersenCircuit {
+	var witness pedersenCircuit
+	witness.Bits = make([]frontend.Variable, len(msg))
+	for i := range msg {
+		if msg[i] {
+			witness.Bits[i].Assign(1)
+		} else {
+			witness.Bits[i].Assign(0)
+		}
+	}
+	witness.Randomness.Assign(randomness)
+
+	var h, cx, cy big.Int
+	switch curveID {
+	case gurvy.BN256:
+		p, err := pedersenbn256.Hash(personalization, msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.ToBigIntRegular(&h)
+		c, err := pedersenbn256.Commit(personalization, msg, big.NewInt(randomness))
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.X.ToBigIntRegular(&cx)
+		c.Y.ToBigIntRegular(&cy)
+	case gurvy.BLS381:
+		p, err := pedersenbls381.Hash(personalization, msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.ToBigIntRegular(&h)
+		c, err := pedersenbls381.Commit(personalization, msg, big.NewInt(randomness))
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.X.ToBigIntRegular(&cx)
+		c.Y.ToBigIntRegular(&cy)
+	}
+	witness.Hash.Assign(h)
+	witness.Commitment[0].Assign(cx)
+	witness.Commitment[1].Assign(cy)
+	return &witness
+}
+
+func TestPedersen(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	for _, curveID := range []gurvy.ID{gurvy.BN256, gurvy.BLS381} {
+		// one chunk, a padded chunk, and a Sapling Merkle tree node (3 segments)
+		for _, n := range []int{3, 7, 6 + 2*255} {
+			msg := message(n)
+			assert.SolvingSucceeded(pedersenWitness(t, curveID, msg, 42), curveID)
+
+			witness := pedersenWitness(t, curveID, msg, 42)
+			witness.Bits[0] = frontend.Variable{}
+			if msg[0] {
+				witness.Bits[0].Assign(0)
+			} else {
+				witness.Bits[0].Assign(1)
+			}
+			assert.SolvingFailed(witness, curveID)
+		}
+
+		// the bits must be boolean
+		witness := pedersenWitness(t, curveID, message(3), 42)
+		witness.Bits[2] = frontend.Variable{}
+		witness.Bits[2].Assign(2)
+		assert.SolvingFailed(witness, curveID)
+	}
+}
+
+func TestPedersenCompiled(t *testing.T) {
+	assert := groth16.NewAssert(t)
+
+	msg := message(100)
+	var circuit pedersenCircuit
+	circuit.Bits = make([]frontend.Variable, len(msg))
+	r1cs, err := frontend.Compile(gurvy.BLS381, &circuit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.SolvingSucceeded(r1cs, pedersenWitness(t, gurvy.BLS381, msg, 1234567))
+}
+
+func TestNewPedersen(t *testing.T) {
+	if _, err := NewPedersen(gurvy.BN256, "Zcash"); err == nil {
+		t.Fatal("a personalization of 5 bytes should be rejected")
+	}
+	if _, err := NewPedersen(gurvy.BW761, personalization); err == nil {
+		t.Fatal("BW761 has no embedded twisted Edwards curve")
+	}
+}
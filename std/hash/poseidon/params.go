@@ -0,0 +1,128 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package poseidon
+
+import (
+	"math/big"
+
+	"github.com/consensys/gnark/crypto/hash/poseidon/bls377"
+	"github.com/consensys/gnark/crypto/hash/poseidon/bls381"
+	"github.com/consensys/gnark/crypto/hash/poseidon/bn256"
+	"github.com/consensys/gnark/crypto/hash/poseidon/bw761"
+
+	"github.com/consensys/gurvy"
+)
+
+// params of the permutation of a state of t elements, the constants in regular form
+type params struct {
+	t              int
+	alpha          uint64
+	fullRounds     int
+	partialRounds  int
+	roundConstants []big.Int
+	mds            [][]big.Int
+}
+
+var newParams map[gurvy.ID]func(t int) (params, error)
+
+func init() {
+	newParams = make(map[gurvy.ID]func(int) (params, error))
+	newParams[gurvy.BN256] = newParamsBN256
+	newParams[gurvy.BLS381] = newParamsBLS381
+	newParams[gurvy.BLS377] = newParamsBLS377
+	newParams[gurvy.BW761] = newParamsBW761
+}
+
+func newParamsBN256(t int) (params, error) {
+	p, err := bn256.NewParams(t)
+	if err != nil {
+		return params{}, err
+	}
+	res := params{t: p.T, alpha: p.Alpha, fullRounds: p.FullRounds, partialRounds: p.PartialRounds}
+	res.roundConstants = make([]big.Int, len(p.RoundConstants))
+	for i := range p.RoundConstants {
+		p.RoundConstants[i].ToBigIntRegular(&res.roundConstants[i])
+	}
+	res.mds = make([][]big.Int, len(p.MDS))
+	for i := range p.MDS {
+		res.mds[i] = make([]big.Int, len(p.MDS[i]))
+		for j := range p.MDS[i] {
+			p.MDS[i][j].ToBigIntRegular(&res.mds[i][j])
+		}
+	}
+	return res, nil
+}
+
+func newParamsBLS381(t int) (params, error) {
+	p, err := bls381.NewParams(t)
+	if err != nil {
+		return params{}, err
+	}
+	res := params{t: p.T, alpha: p.Alpha, fullRounds: p.FullRounds, partialRounds: p.PartialRounds}
+	res.roundConstants = make([]big.Int, len(p.RoundConstants))
+	for i := range p.RoundConstants {
+		p.RoundConstants[i].ToBigIntRegular(&res.roundConstants[i])
+	}
+	res.mds = make([][]big.Int, len(p.MDS))
+	for i := range p.MDS {
+		res.mds[i] = make([]big.Int, len(p.MDS[i]))
+		for j := range p.MDS[i] {
+			p.MDS[i][j].ToBigIntRegular(&res.mds[i][j])
+		}
+	}
+	return res, nil
+}
+
+func newParamsBLS377(t int) (params, error) {
+	p, err := bls377.NewParams(t)
+	if err != nil {
+		return params{}, err
+	}
+	res := params{t: p.T, alpha: p.Alpha, fullRounds: p.FullRounds, partialRounds: p.PartialRounds}
+	res.roundConstants = make([]big.Int, len(p.RoundConstants))
+	for i := range p.RoundConstants {
+		p.RoundConstants[i].ToBigIntRegular(&res.roundConstants[i])
+	}
+	res.mds = make([][]big.Int, len(p.MDS))
+	for i := range p.MDS {
+		res.mds[i] = make([]big.Int, len(p.MDS[i]))
+		for j := range p.MDS[i] {
+			p.MDS[i][j].ToBigIntRegular(&res.mds[i][j])
+		}
+	}
+	return res, nil
+}
+
+func newParamsBW761(t int) (params, error) {
+	p, err := bw761.NewParams(t)
+	if err != nil {
+		return params{}, err
+	}
+	res := params{t: p.T, alpha: p.Alpha, fullRounds: p.FullRounds, partialRounds: p.PartialRounds}
+	res.roundConstants = make([]big.Int, len(p.RoundConstants))
+	for i := range p.RoundConstants {
+		p.RoundConstants[i].ToBigIntRegular(&res.roundConstants[i])
+	}
+	res.mds = make([][]big.Int, len(p.MDS))
+	for i := range p.MDS {
+		res.mds[i] = make([]big.Int, len(p.MDS[i]))
+		for j := range p.MDS[i] {
+			p.MDS[i][j].ToBigIntRegular(&res.mds[i][j])
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,126 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package poseidon implements the Poseidon hash function in a circuit, with the parameters of
+// crypto/hash/poseidon
+//
+// a permutation of t elements costs (t*fullRounds + partialRounds) S-boxes of 3 constraints (5 on
+// BLS377, where the S-box is x^11): 240 constraints to hash 2 elements on BN256, when MiMC needs 4
+// constraints per round and per element.
+package poseidon
+
+import (
+	"errors"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/hash/sponge"
+	"github.com/consensys/gurvy"
+)
+
+var errEmpty = errors.New("poseidon: nothing to hash")
+
+// Poseidon is the Poseidon hash function on the scalar field of a curve
+type Poseidon struct {
+	id gurvy.ID
+}
+
+// NewPoseidon returns a Poseidon instance, that can be used in a gnark circuit
+func NewPoseidon(id gurvy.ID) (Poseidon, error) {
+	if _, ok := newParams[id]; !ok {
+		return Poseidon{}, errors.New("unknown curve id")
+	}
+	return Poseidon{id: id}, nil
+}
+
+// params returns the parameters of the permutation of t elements, t >= 2
+func (h Poseidon) params(t int) params {
+	p, err := newParams[h.id](t)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// Permute returns the Poseidon permutation of the state, of at least 2 elements
+func (h Poseidon) Permute(cs *frontend.ConstraintSystem, state ...frontend.Variable) []frontend.Variable {
+	p := h.params(len(state))
+	res := append([]frontend.Variable(nil), state...)
+	for r := 0; r < p.fullRounds+p.partialRounds; r++ {
+		for i := range res {
+			res[i] = cs.Add(res[i], p.roundConstants[r*p.t+i])
+		}
+		if r < p.fullRounds/2 || r >= p.fullRounds/2+p.partialRounds {
+			for i := range res {
+				res[i] = sbox(cs, res[i], p.alpha)
+			}
+		} else {
+			res[0] = sbox(cs, res[0], p.alpha)
+		}
+		// the mix is linear: no constraint is recorded
+		mixed := make([]frontend.Variable, p.t)
+		for i := range mixed {
+			mixed[i] = cs.Mul(res[0], p.mds[i][0])
+			for j := 1; j < p.t; j++ {
+				mixed[i] = cs.Add(mixed[i], cs.Mul(res[j], p.mds[i][j]))
+			}
+		}
+		res = mixed
+	}
+	return res
+}
+
+// sbox returns x^alpha
+func sbox(cs *frontend.ConstraintSystem, x frontend.Variable, alpha uint64) frontend.Variable {
+	res := x
+	i := 63
+	for alpha>>uint(i)&1 == 0 {
+		i--
+	}
+	for i--; i >= 0; i-- {
+		res = cs.Mul(res, res)
+		if alpha>>uint(i)&1 == 1 {
+			res = cs.Mul(res, x)
+		}
+	}
+	return res
+}
+
+// Hash returns the Poseidon hash of data, as crypto/hash/poseidon: the first element of the permutation
+// of 0 | data. data must not be empty.
+func (h Poseidon) Hash(cs *frontend.ConstraintSystem, data ...frontend.Variable) frontend.Variable {
+	if len(data) == 0 {
+		panic(errEmpty)
+	}
+	state := append([]frontend.Variable{cs.Constant(0)}, data...)
+	return h.Permute(cs, state...)[0]
+}
+
+// Define returns the hash of data, as Hash: a Poseidon is a gadget.Gadget
+func (h Poseidon) Define(cs *frontend.ConstraintSystem, data ...frontend.Variable) ([]frontend.Variable, error) {
+	if len(data) == 0 {
+		return nil, errEmpty
+	}
+	return []frontend.Variable{h.Hash(cs, data...)}, nil
+}
+
+// NewSponge returns the sponge of crypto/hash/poseidon over a state of t elements, t >= 2 (see
+// std/hash/sponge)
+func (h Poseidon) NewSponge(cs *frontend.ConstraintSystem, t int) (*sponge.Sponge, error) {
+	if _, err := newParams[h.id](t); err != nil {
+		return nil, err
+	}
+	return sponge.New(cs, h, t)
+}
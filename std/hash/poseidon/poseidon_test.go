@@ -0,0 +1,217 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package poseidon
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+	"github.com/consensys/gurvy"
+
+	poseidonbls377 "github.com/consensys/gnark/crypto/hash/poseidon/bls377"
+	poseidonbls381 "github.com/consensys/gnark/crypto/hash/poseidon/bls381"
+	poseidonbn256 "github.com/consensys/gnark/crypto/hash/poseidon/bn256"
+	poseidonbw761 "github.com/consensys/gnark/crypto/hash/poseidon/bw761"
+
+	fr_bls377 "github.com/consensys/gurvy/bls377/fr"
+	fr_bls381 "github.com/consensys/gurvy/bls381/fr"
+	fr_bn256 "github.com/consensys/gurvy/bn256/fr"
+	fr_bw761 "github.com/consensys/gurvy/bw761/fr"
+)
+
+var curves = []gurvy.ID{gurvy.BN256, gurvy.BLS381, gurvy.BLS377, gurvy.BW761}
+
+type poseidonCircuit struct {
+	Hash frontend.Variable `gnark:",public"`
+	Data []frontend.Variable
+}
+
+func (circuit *poseidonCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	poseidon, err := NewPoseidon(curveID)
+	if err != nil {
+		return err
+	}
+	cs.AssertIsEqual(poseidon.Hash(cs, circuit.Data...), circuit.Hash)
+	return nil
+}
+
+type spongeCircuit struct {
+	Outputs [3]frontend.Variable `gnark:",public"`
+	Data    [5]frontend.Variable
+}
+
+func (circuit *spongeCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	poseidon, err := NewPoseidon(curveID)
+	if err != nil {
+		return err
+	}
+	sponge, err := poseidon.NewSponge(cs, 3)
+	if err != nil {
+		return err
+	}
+	sponge.Absorb(circuit.Data[:2]...)
+	sponge.Absorb(circuit.Data[2:]...)
+	for i := range circuit.Outputs {
+		cs.AssertIsEqual(sponge.Squeeze(), circuit.Outputs[i])
+	}
+	return nil
+}
+
+// hash returns the hash of 1, 2, ... n and the outputs of a sponge of width 3 absorbing them, computed
+// by crypto/hash/poseidon
+func hash(t *testing.T, curveID gurvy.ID, n int) (big.Int, [3]big.Int) {
+	var h big.Int
+	var outputs [3]big.Int
+	switch curveID {
+	case gurvy.BN256:
+		data := make([]fr_bn256.Element, n)
+		for i := range data {
+			data[i].SetUint64(uint64(i + 1))
+		}
+		d, err := poseidonbn256.Hash(data...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d.ToBigIntRegular(&h)
+		s, err := poseidonbn256.NewSponge(3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.Absorb(data...)
+		for i := range outputs {
+			o := s.Squeeze()
+			o.ToBigIntRegular(&outputs[i])
+		}
+	case gurvy.BLS381:
+		data := make([]fr_bls381.Element, n)
+		for i := range data {
+			data[i].SetUint64(uint64(i + 1))
+		}
+		d, err := poseidonbls381.Hash(data...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d.ToBigIntRegular(&h)
+		s, err := poseidonbls381.NewSponge(3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.Absorb(data...)
+		for i := range outputs {
+			o := s.Squeeze()
+			o.ToBigIntRegular(&outputs[i])
+		}
+	case gurvy.BLS377:
+		data := make([]fr_bls377.Element, n)
+		for i := range data {
+			data[i].SetUint64(uint64(i + 1))
+		}
+		d, err := poseidonbls377.Hash(data...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d.ToBigIntRegular(&h)
+		s, err := poseidonbls377.NewSponge(3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.Absorb(data...)
+		for i := range outputs {
+			o := s.Squeeze()
+			o.ToBigIntRegular(&outputs[i])
+		}
+	case gurvy.BW761:
+		data := make([]fr_bw761.Element, n)
+		for i := range data {
+			data[i].SetUint64(uint64(i + 1))
+		}
+		d, err := poseidonbw761.Hash(data...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d.ToBigIntRegular(&h)
+		s, err := poseidonbw761.NewSponge(3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.Absorb(data...)
+		for i := range outputs {
+			o := s.Squeeze()
+			o.ToBigIntRegular(&outputs[i])
+		}
+	}
+	return h, outputs
+}
+
+func poseidonWitness(t *testing.T, curveID gurvy.ID, n int) *poseidonCircuit {
+	h, _ := hash(t, curveID, n)
+	var witness poseidonCircuit
+	witness.Data = make([]frontend.Variable, n)
+	for i := range witness.Data {
+		witness.Data[i].Assign(i + 1)
+	}
+	witness.Hash.Assign(h)
+	return &witness
+}
+
+func TestPoseidon(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	for _, curveID := range curves {
+		for _, n := range []int{1, 2, 5} {
+			assert.SolvingSucceeded(poseidonWitness(t, curveID, n), curveID)
+
+			witness := poseidonWitness(t, curveID, n)
+			witness.Data[0] = frontend.Variable{}
+			witness.Data[0].Assign(42)
+			assert.SolvingFailed(witness, curveID)
+		}
+	}
+}
+
+func TestPoseidonCompiled(t *testing.T) {
+	assert := groth16.NewAssert(t)
+
+	// 2 elements: 8 full rounds and 57 partial rounds on a state of 3 elements, the S-box of the
+	// constant first element of the state being folded in the first round, and the equality
+	var circuit poseidonCircuit
+	circuit.Data = make([]frontend.Variable, 2)
+	r1cs, err := frontend.Compile(gurvy.BN256, &circuit)
+	assert.NoError(err)
+	assert.Equal(uint64(3*(8*3+57-1)+1), r1cs.GetNbConstraints())
+
+	assert.SolvingSucceeded(r1cs, poseidonWitness(t, gurvy.BN256, 2))
+}
+
+func TestSponge(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	for _, curveID := range curves {
+		_, outputs := hash(t, curveID, 5)
+		var witness spongeCircuit
+		for i := range witness.Data {
+			witness.Data[i].Assign(i + 1)
+		}
+		for i := range witness.Outputs {
+			witness.Outputs[i].Assign(outputs[i])
+		}
+		assert.SolvingSucceeded(&witness, curveID)
+	}
+}
@@ -0,0 +1,152 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package rescue implements the Rescue-Prime hash function in a circuit, with the parameters of
+// crypto/hash/rescue
+//
+// each round applies to every element of the state the S-box x^alpha and the inverse S-box x^(1/alpha);
+// the inverse S-box is computed by a hint and checked by raising it to alpha. With alpha = 5, a round
+// costs 7 constraints per element: 294 constraints for a permutation of 3 elements (14 rounds), where
+// Poseidon needs 240. Rescue-Prime has fewer rounds, all of them full, and a larger security margin
+// against algebraic attacks, but its permutation is much slower to compute out of a circuit.
+package rescue
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/consensys/gnark/backend/hint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/hash/sponge"
+	"github.com/consensys/gurvy"
+)
+
+func init() {
+	hint.Register(pow)
+}
+
+// Rescue is the Rescue-Prime hash function on the scalar field of a curve
+type Rescue struct {
+	id gurvy.ID
+}
+
+// NewRescue returns a Rescue instance, that can be used in a gnark circuit
+func NewRescue(id gurvy.ID) (Rescue, error) {
+	if _, ok := newParams[id]; !ok {
+		return Rescue{}, errors.New("unknown curve id")
+	}
+	return Rescue{id: id}, nil
+}
+
+// params returns the parameters of the permutation of t elements, t >= 2
+func (h Rescue) params(t int) params {
+	p, err := newParams[h.id](t)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// Permute returns the Rescue-Prime permutation of the state, of at least 2 elements
+func (h Rescue) Permute(cs *frontend.ConstraintSystem, state ...frontend.Variable) []frontend.Variable {
+	p := h.params(len(state))
+	res := append([]frontend.Variable(nil), state...)
+
+	// the mix is linear: no constraint is recorded
+	mix := func(constants []big.Int) {
+		mixed := make([]frontend.Variable, p.t)
+		for i := range mixed {
+			mixed[i] = cs.Constant(constants[i])
+			for j := 0; j < p.t; j++ {
+				mixed[i] = cs.Add(mixed[i], cs.Mul(res[j], p.mds[i][j]))
+			}
+		}
+		res = mixed
+	}
+
+	for r := 0; r < p.rounds; r++ {
+		for i := range res {
+			res[i] = sbox(cs, res[i], p.alpha)
+		}
+		mix(p.roundConstants[2*r*p.t : (2*r+1)*p.t])
+		for i := range res {
+			res[i] = inverseSbox(cs, res[i], &p)
+		}
+		mix(p.roundConstants[(2*r+1)*p.t : (2*r+2)*p.t])
+	}
+	return res
+}
+
+// sbox returns x^alpha
+func sbox(cs *frontend.ConstraintSystem, x frontend.Variable, alpha uint64) frontend.Variable {
+	res := x
+	i := 63
+	for alpha>>uint(i)&1 == 0 {
+		i--
+	}
+	for i--; i >= 0; i-- {
+		res = cs.Mul(res, res)
+		if alpha>>uint(i)&1 == 1 {
+			res = cs.Mul(res, x)
+		}
+	}
+	return res
+}
+
+// inverseSbox returns x^(1/alpha), computed by a hint, and asserts that its alpha-th power is x
+func inverseSbox(cs *frontend.ConstraintSystem, x frontend.Variable, p *params) frontend.Variable {
+	res := cs.NewHint(pow, x, p.alphaInv)
+	cs.AssertIsEqual(sbox(cs, res, p.alpha), x)
+	return res
+}
+
+// pow sets result to inputs[0]^inputs[1] in the scalar field of curveID
+func pow(curveID gurvy.ID, inputs []*big.Int, result *big.Int) error {
+	if len(inputs) != 2 {
+		return errors.New("pow expects two inputs")
+	}
+	modulus, ok := moduli[curveID]
+	if !ok {
+		return errors.New("unknown curve id")
+	}
+	result.Exp(inputs[0], inputs[1], modulus)
+	return nil
+}
+
+// Hash returns the Rescue-Prime hash of data, as crypto/hash/rescue: the first output of a sponge over
+// 3 elements absorbing it
+func (h Rescue) Hash(cs *frontend.ConstraintSystem, data ...frontend.Variable) frontend.Variable {
+	s, err := h.NewSponge(cs, 3)
+	if err != nil {
+		panic(err)
+	}
+	s.Absorb(data...)
+	return s.Squeeze()
+}
+
+// Define returns the hash of data, as Hash: a Rescue is a gadget.Gadget
+func (h Rescue) Define(cs *frontend.ConstraintSystem, data ...frontend.Variable) ([]frontend.Variable, error) {
+	return []frontend.Variable{h.Hash(cs, data...)}, nil
+}
+
+// NewSponge returns the sponge of crypto/hash/rescue over a state of t elements, t >= 2 (see
+// std/hash/sponge)
+func (h Rescue) NewSponge(cs *frontend.ConstraintSystem, t int) (*sponge.Sponge, error) {
+	if _, err := newParams[h.id](t); err != nil {
+		return nil, err
+	}
+	return sponge.New(cs, h, t)
+}
@@ -0,0 +1,201 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rescue
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+	"github.com/consensys/gurvy"
+
+	rescuebls377 "github.com/consensys/gnark/crypto/hash/rescue/bls377"
+	rescuebls381 "github.com/consensys/gnark/crypto/hash/rescue/bls381"
+	rescuebn256 "github.com/consensys/gnark/crypto/hash/rescue/bn256"
+
+	fr_bls377 "github.com/consensys/gurvy/bls377/fr"
+	fr_bls381 "github.com/consensys/gurvy/bls381/fr"
+	fr_bn256 "github.com/consensys/gurvy/bn256/fr"
+)
+
+var curves = []gurvy.ID{gurvy.BN256, gurvy.BLS381, gurvy.BLS377}
+
+type rescueCircuit struct {
+	Hash frontend.Variable `gnark:",public"`
+	Data []frontend.Variable
+}
+
+func (circuit *rescueCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	rescue, err := NewRescue(curveID)
+	if err != nil {
+		return err
+	}
+	cs.AssertIsEqual(rescue.Hash(cs, circuit.Data...), circuit.Hash)
+	return nil
+}
+
+type spongeCircuit struct {
+	Outputs [3]frontend.Variable `gnark:",public"`
+	Data    [5]frontend.Variable
+}
+
+func (circuit *spongeCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	rescue, err := NewRescue(curveID)
+	if err != nil {
+		return err
+	}
+	sponge, err := rescue.NewSponge(cs, 4)
+	if err != nil {
+		return err
+	}
+	sponge.Absorb(circuit.Data[:2]...)
+	sponge.Absorb(circuit.Data[2:]...)
+	for i := range circuit.Outputs {
+		cs.AssertIsEqual(sponge.Squeeze(), circuit.Outputs[i])
+	}
+	return nil
+}
+
+// hash returns the hash of 1, 2, ... n and the outputs of a sponge of width 4 absorbing them, computed
+// by crypto/hash/rescue
+func hash(t *testing.T, curveID gurvy.ID, n int) (big.Int, [3]big.Int) {
+	var h big.Int
+	var outputs [3]big.Int
+	switch curveID {
+	case gurvy.BN256:
+		data := make([]fr_bn256.Element, n)
+		for i := range data {
+			data[i].SetUint64(uint64(i + 1))
+		}
+		d := rescuebn256.Hash(data...)
+		d.ToBigIntRegular(&h)
+		s, err := rescuebn256.NewSponge(4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.Absorb(data...)
+		for i := range outputs {
+			o := s.Squeeze()
+			o.ToBigIntRegular(&outputs[i])
+		}
+	case gurvy.BLS381:
+		data := make([]fr_bls381.Element, n)
+		for i := range data {
+			data[i].SetUint64(uint64(i + 1))
+		}
+		d := rescuebls381.Hash(data...)
+		d.ToBigIntRegular(&h)
+		s, err := rescuebls381.NewSponge(4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.Absorb(data...)
+		for i := range outputs {
+			o := s.Squeeze()
+			o.ToBigIntRegular(&outputs[i])
+		}
+	case gurvy.BLS377:
+		data := make([]fr_bls377.Element, n)
+		for i := range data {
+			data[i].SetUint64(uint64(i + 1))
+		}
+		d := rescuebls377.Hash(data...)
+		d.ToBigIntRegular(&h)
+		s, err := rescuebls377.NewSponge(4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.Absorb(data...)
+		for i := range outputs {
+			o := s.Squeeze()
+			o.ToBigIntRegular(&outputs[i])
+		}
+	}
+	return h, outputs
+}
+
+func rescueWitness(t *testing.T, curveID gurvy.ID, n int) *rescueCircuit {
+	h, _ := hash(t, curveID, n)
+	var witness rescueCircuit
+	witness.Data = make([]frontend.Variable, n)
+	for i := range witness.Data {
+		witness.Data[i].Assign(i + 1)
+	}
+	witness.Hash.Assign(h)
+	return &witness
+}
+
+func TestRescue(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	for _, curveID := range curves {
+		for _, n := range []int{1, 2, 5} {
+			assert.SolvingSucceeded(rescueWitness(t, curveID, n), curveID)
+
+			witness := rescueWitness(t, curveID, n)
+			witness.Data[0] = frontend.Variable{}
+			witness.Data[0].Assign(42)
+			assert.SolvingFailed(witness, curveID)
+		}
+	}
+}
+
+func TestRescueCompiled(t *testing.T) {
+	assert := groth16.NewAssert(t)
+
+	// 2 elements: a permutation absorbing them and a permutation of the padding, of 14 rounds of 3
+	// S-boxes and 3 inverse S-boxes; the S-box of the constant first element of the state is folded in
+	// the first round, and the equality is asserted
+	var circuit rescueCircuit
+	circuit.Data = make([]frontend.Variable, 2)
+	r1cs, err := frontend.Compile(gurvy.BN256, &circuit)
+	assert.NoError(err)
+	assert.Equal(uint64(2*14*3*(3+4)-3+1), r1cs.GetNbConstraints())
+
+	assert.SolvingSucceeded(r1cs, rescueWitness(t, gurvy.BN256, 2))
+}
+
+func TestSponge(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	for _, curveID := range curves {
+		_, outputs := hash(t, curveID, 5)
+		var witness spongeCircuit
+		for i := range witness.Data {
+			witness.Data[i].Assign(i + 1)
+		}
+		for i := range witness.Outputs {
+			witness.Outputs[i].Assign(outputs[i])
+		}
+		assert.SolvingSucceeded(&witness, curveID)
+	}
+}
+
+func TestNewRescue(t *testing.T) {
+	if _, err := NewRescue(gurvy.BW761); err == nil {
+		t.Fatal("BW761 is not supported")
+	}
+	rescue, err := NewRescue(gurvy.BN256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rescue.NewSponge(nil, 1); err == nil {
+		t.Fatal("a state of 1 element should be rejected")
+	}
+}
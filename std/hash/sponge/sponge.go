@@ -0,0 +1,86 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package sponge implements in a circuit the sponge construction shared by the algebraic hash functions
+// of std/hash (poseidon, rescue), over their permutation of field elements
+//
+// the sponge has a capacity of 1 element (the first one of the state) and a rate of t-1 elements;
+// squeezing pads the absorbed elements with 1 then zeros, as the sponges of crypto/hash/poseidon and
+// crypto/hash/rescue.
+package sponge
+
+import (
+	"errors"
+
+	"github.com/consensys/gnark/frontend"
+)
+
+var errWidth = errors.New("sponge: the state must have at least 2 elements")
+
+// Permutation is a permutation of a state of field elements, as poseidon.Poseidon and rescue.Rescue
+type Permutation interface {
+	Permute(cs *frontend.ConstraintSystem, state ...frontend.Variable) []frontend.Variable
+}
+
+// Sponge absorbs and squeezes field elements in a circuit
+type Sponge struct {
+	cs        *frontend.ConstraintSystem
+	p         Permutation
+	state     []frontend.Variable
+	pos       int // position in the rate
+	squeezing bool
+}
+
+// New returns a sponge over a state of t elements, t >= 2, permuted by p
+func New(cs *frontend.ConstraintSystem, p Permutation, t int) (*Sponge, error) {
+	if t < 2 {
+		return nil, errWidth
+	}
+	s := Sponge{cs: cs, p: p, state: make([]frontend.Variable, t)}
+	for i := range s.state {
+		s.state[i] = cs.Constant(0)
+	}
+	return &s, nil
+}
+
+// Absorb absorbs data; absorbing after squeezing starts a new absorption
+func (s *Sponge) Absorb(data ...frontend.Variable) {
+	if s.squeezing {
+		s.squeezing, s.pos = false, 0
+	}
+	for i := range data {
+		s.state[1+s.pos] = s.cs.Add(s.state[1+s.pos], data[i])
+		if s.pos++; s.pos == len(s.state)-1 {
+			s.state = s.p.Permute(s.cs, s.state...)
+			s.pos = 0
+		}
+	}
+}
+
+// Squeeze returns the next output element
+func (s *Sponge) Squeeze() frontend.Variable {
+	if !s.squeezing {
+		s.state[1+s.pos] = s.cs.Add(s.state[1+s.pos], 1)
+		s.state = s.p.Permute(s.cs, s.state...)
+		s.squeezing, s.pos = true, 0
+	}
+	if s.pos == len(s.state)-1 {
+		s.state = s.p.Permute(s.cs, s.state...)
+		s.pos = 0
+	}
+	s.pos++
+	return s.state[s.pos]
+}
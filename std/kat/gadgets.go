@@ -0,0 +1,338 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kat
+
+import (
+	"bytes"
+	"fmt"
+	"hash"
+	"math/big"
+	"math/rand"
+	"strconv"
+
+	"github.com/consensys/gnark/crypto/accumulator/merkletree"
+	mimc_bls377 "github.com/consensys/gnark/crypto/hash/mimc/bls377"
+	mimc_bls381 "github.com/consensys/gnark/crypto/hash/mimc/bls381"
+	mimc_bn256 "github.com/consensys/gnark/crypto/hash/mimc/bn256"
+	eddsa_bls381 "github.com/consensys/gnark/crypto/signature/eddsa/bls381"
+	eddsa_bn256 "github.com/consensys/gnark/crypto/signature/eddsa/bn256"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/accumulator/merkle"
+	"github.com/consensys/gnark/std/algebra/twistededwards"
+	"github.com/consensys/gnark/std/hash/mimc"
+	"github.com/consensys/gnark/std/signature/eddsa"
+	"github.com/consensys/gurvy"
+	fr_bls377 "github.com/consensys/gurvy/bls377/fr"
+	fr_bls381 "github.com/consensys/gurvy/bls381/fr"
+	fr_bn256 "github.com/consensys/gurvy/bn256/fr"
+)
+
+// seed of the MiMC parameters of the gadgets
+const mimcSeed = "seed"
+
+// mimcGadget is mimc.MiMC.Hash of 1 to 3 field elements
+type mimcGadget struct{}
+
+type mimcCircuit struct {
+	Data []frontend.Variable
+	Hash frontend.Variable `gnark:",public"`
+}
+
+func (circuit *mimcCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	h, err := mimc.NewMiMC(mimcSeed, curveID)
+	if err != nil {
+		return err
+	}
+	cs.AssertIsEqual(h.Hash(cs, circuit.Data...), circuit.Hash)
+	return nil
+}
+
+func (mimcGadget) Name() string {
+	return "mimc"
+}
+
+func (mimcGadget) Curves() []gurvy.ID {
+	return []gurvy.ID{gurvy.BN256, gurvy.BLS377, gurvy.BLS381}
+}
+
+func (mimcGadget) Inputs(curveID gurvy.ID, rnd *rand.Rand) map[string]string {
+	inputs := make(map[string]string)
+	n := 1 + rnd.Intn(3)
+	for i := 0; i < n; i++ {
+		inputs["data_"+strconv.Itoa(i)] = randElement(curveID, rnd)
+	}
+	return inputs
+}
+
+func (mimcGadget) Compute(curveID gurvy.ID, inputs map[string]string) (map[string]string, error) {
+	data, err := values(inputs, "data")
+	if err != nil {
+		return nil, err
+	}
+	h := newMiMC(curveID)
+	for _, v := range data {
+		h.Write(bytes32(v))
+	}
+	return map[string]string{"hash": new(big.Int).SetBytes(h.Sum(nil)).String()}, nil
+}
+
+func (mimcGadget) Circuit(curveID gurvy.ID, v Vector) (frontend.Circuit, map[string]interface{}, error) {
+	data, err := values(v.Inputs, "data")
+	if err != nil {
+		return nil, nil, err
+	}
+	assignment := map[string]interface{}{"Hash": v.Outputs["hash"]}
+	for i, d := range data {
+		assignment["Data_"+strconv.Itoa(i)] = d
+	}
+	return &mimcCircuit{Data: make([]frontend.Variable, len(data))}, assignment, nil
+}
+
+// merkleGadget is merkle.VerifyProof, on trees of 2 to 8 leaves hashed with MiMC
+type merkleGadget struct{}
+
+type merkleCircuit struct {
+	RootHash     frontend.Variable `gnark:",public"`
+	Path, Helper []frontend.Variable
+}
+
+func (circuit *merkleCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	h, err := mimc.NewMiMC(mimcSeed, curveID)
+	if err != nil {
+		return err
+	}
+	merkle.VerifyProof(cs, h, circuit.RootHash, circuit.Path, circuit.Helper)
+	return nil
+}
+
+func (merkleGadget) Name() string {
+	return "merkle"
+}
+
+func (merkleGadget) Curves() []gurvy.ID {
+	return mimcGadget{}.Curves()
+}
+
+func (merkleGadget) Inputs(curveID gurvy.ID, rnd *rand.Rand) map[string]string {
+	inputs := make(map[string]string)
+	nbLeaves := 2 + rnd.Intn(7)
+	for i := 0; i < nbLeaves; i++ {
+		inputs["leaf_"+strconv.Itoa(i)] = randElement(curveID, rnd)
+	}
+	inputs["index"] = strconv.Itoa(rnd.Intn(nbLeaves))
+	return inputs
+}
+
+func (merkleGadget) Compute(curveID gurvy.ID, inputs map[string]string) (map[string]string, error) {
+	leaves, err := values(inputs, "leaf")
+	if err != nil {
+		return nil, err
+	}
+	index, err := strconv.ParseUint(inputs["index"], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	for _, leaf := range leaves {
+		buf.Write(bytes32(leaf))
+	}
+	root, proof, nbLeaves, err := merkletree.BuildReaderProof(&buf, newMiMC(curveID), 32, index)
+	if err != nil {
+		return nil, err
+	}
+	outputs := map[string]string{"root": new(big.Int).SetBytes(root).String()}
+	for i, p := range proof {
+		outputs["path_"+strconv.Itoa(i)] = new(big.Int).SetBytes(p).String()
+	}
+	for i, h := range merkle.GenerateProofHelper(proof, index, nbLeaves) {
+		outputs["helper_"+strconv.Itoa(i)] = strconv.Itoa(h)
+	}
+	return outputs, nil
+}
+
+func (merkleGadget) Circuit(curveID gurvy.ID, v Vector) (frontend.Circuit, map[string]interface{}, error) {
+	path, err := values(v.Outputs, "path")
+	if err != nil {
+		return nil, nil, err
+	}
+	helper, err := values(v.Outputs, "helper")
+	if err != nil {
+		return nil, nil, err
+	}
+	assignment := map[string]interface{}{"RootHash": v.Outputs["root"]}
+	for i, p := range path {
+		assignment["Path_"+strconv.Itoa(i)] = p
+	}
+	for i, h := range helper {
+		assignment["Helper_"+strconv.Itoa(i)] = h
+	}
+	circuit := &merkleCircuit{Path: make([]frontend.Variable, len(path)), Helper: make([]frontend.Variable, len(helper))}
+	return circuit, assignment, nil
+}
+
+// eddsaGadget is eddsa.Verify, on the signature of a field element with a key generated from a seed
+type eddsaGadget struct{}
+
+type eddsaCircuit struct {
+	PublicKey eddsa.PublicKey   `gnark:",public"`
+	Signature eddsa.Signature   `gnark:",public"`
+	Message   frontend.Variable `gnark:",public"`
+}
+
+func (circuit *eddsaCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	params, err := twistededwards.NewEdCurve(curveID)
+	if err != nil {
+		return err
+	}
+	circuit.PublicKey.Curve = params
+	return eddsa.Verify(cs, circuit.Signature, circuit.Message, circuit.PublicKey)
+}
+
+func (eddsaGadget) Name() string {
+	return "eddsa"
+}
+
+func (eddsaGadget) Curves() []gurvy.ID {
+	return []gurvy.ID{gurvy.BN256, gurvy.BLS381}
+}
+
+func (eddsaGadget) Inputs(curveID gurvy.ID, rnd *rand.Rand) map[string]string {
+	var seed [32]byte
+	rnd.Read(seed[:])
+	return map[string]string{
+		"seed":    new(big.Int).SetBytes(seed[:]).String(),
+		"message": randElement(curveID, rnd),
+	}
+}
+
+func (eddsaGadget) Compute(curveID gurvy.ID, inputs map[string]string) (map[string]string, error) {
+	s, err := parse(inputs, "seed")
+	if err != nil {
+		return nil, err
+	}
+	m, err := parse(inputs, "message")
+	if err != nil {
+		return nil, err
+	}
+	var seed [32]byte
+	s.FillBytes(seed[:])
+	msg := bytes32(m)
+
+	var pk [2]*big.Int
+	var r [2]*big.Int
+	var sig *big.Int
+	switch curveID {
+	case gurvy.BN256:
+		pub, priv := eddsa_bn256.New(seed, newMiMC(curveID))
+		signature, err := eddsa_bn256.Sign(msg, pub, priv)
+		if err != nil {
+			return nil, err
+		}
+		pk = [2]*big.Int{pub.A.X.ToBigIntRegular(new(big.Int)), pub.A.Y.ToBigIntRegular(new(big.Int))}
+		r = [2]*big.Int{signature.R.X.ToBigIntRegular(new(big.Int)), signature.R.Y.ToBigIntRegular(new(big.Int))}
+		sig = &signature.S
+	case gurvy.BLS381:
+		pub, priv := eddsa_bls381.New(seed, newMiMC(curveID))
+		signature, err := eddsa_bls381.Sign(msg, pub, priv)
+		if err != nil {
+			return nil, err
+		}
+		pk = [2]*big.Int{pub.A.X.ToBigIntRegular(new(big.Int)), pub.A.Y.ToBigIntRegular(new(big.Int))}
+		r = [2]*big.Int{signature.R.X.ToBigIntRegular(new(big.Int)), signature.R.Y.ToBigIntRegular(new(big.Int))}
+		sig = &signature.S
+	default:
+		return nil, errCurve
+	}
+	return map[string]string{
+		"public_key_x": pk[0].String(),
+		"public_key_y": pk[1].String(),
+		"r_x":          r[0].String(),
+		"r_y":          r[1].String(),
+		"s":            sig.String(),
+	}, nil
+}
+
+func (eddsaGadget) Circuit(curveID gurvy.ID, v Vector) (frontend.Circuit, map[string]interface{}, error) {
+	assignment := map[string]interface{}{
+		"PublicKey_A_X":   v.Outputs["public_key_x"],
+		"PublicKey_A_Y":   v.Outputs["public_key_y"],
+		"Signature_R_A_X": v.Outputs["r_x"],
+		"Signature_R_A_Y": v.Outputs["r_y"],
+		"Signature_S":     v.Outputs["s"],
+		"Message":         v.Inputs["message"],
+	}
+	return &eddsaCircuit{}, assignment, nil
+}
+
+// newMiMC returns the native MiMC of a curve
+func newMiMC(curveID gurvy.ID) hash.Hash {
+	switch curveID {
+	case gurvy.BN256:
+		return mimc_bn256.NewMiMC(mimcSeed)
+	case gurvy.BLS377:
+		return mimc_bls377.NewMiMC(mimcSeed)
+	case gurvy.BLS381:
+		return mimc_bls381.NewMiMC(mimcSeed)
+	}
+	panic("not implemented")
+}
+
+// randElement returns a random element of the scalar field of a curve
+func randElement(curveID gurvy.ID, rnd *rand.Rand) string {
+	var modulus *big.Int
+	switch curveID {
+	case gurvy.BN256:
+		modulus = fr_bn256.Modulus()
+	case gurvy.BLS377:
+		modulus = fr_bls377.Modulus()
+	case gurvy.BLS381:
+		modulus = fr_bls381.Modulus()
+	default:
+		panic("not implemented")
+	}
+	return new(big.Int).Rand(rnd, modulus).String()
+}
+
+// bytes32 returns the big endian encoding of v on 32 bytes, as the field elements of the native
+// implementations
+func bytes32(v *big.Int) []byte {
+	return v.FillBytes(make([]byte, 32))
+}
+
+func parse(m map[string]string, name string) (*big.Int, error) {
+	v, ok := new(big.Int).SetString(m[name], 10)
+	if !ok {
+		return nil, fmt.Errorf("kat: invalid value %s = %q", name, m[name])
+	}
+	return v, nil
+}
+
+// values returns the values of prefix_0, prefix_1...
+func values(m map[string]string, prefix string) ([]*big.Int, error) {
+	var res []*big.Int
+	for i := 0; ; i++ {
+		name := prefix + "_" + strconv.Itoa(i)
+		if _, ok := m[name]; !ok {
+			return res, nil
+		}
+		v, err := parse(m, name)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, v)
+	}
+}
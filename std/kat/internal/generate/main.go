@@ -0,0 +1,68 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Command generate writes the fixtures of the known answer vectors of kat.Gadgets in a directory
+//
+//	go run ./internal/generate testdata
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/consensys/gnark/std/kat"
+)
+
+// number of vectors per fixture, and seed of their inputs
+const (
+	nbVectors = 4
+	seed      = 42
+)
+
+func main() {
+	if len(os.Args) != 2 {
+		fmt.Fprintln(os.Stderr, "usage: generate directory")
+		os.Exit(2)
+	}
+	dir := os.Args[1]
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	for _, g := range kat.Gadgets {
+		for _, curveID := range g.Curves() {
+			f, err := kat.Generate(g, curveID, nbVectors, seed)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			var buf bytes.Buffer
+			if err := kat.WriteFixture(&buf, f); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			path := filepath.Join(dir, kat.FileName(g, curveID))
+			if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			fmt.Println(path)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package kat generates and checks known answer test vectors of the std gadgets
+//
+// A vector is computed by the native implementation of a gadget (in gnark/crypto, which shares no code
+// with the circuits), then cross-checked on the circuit of the gadget: the compiled circuit must be
+// solved by the vector, and not by the vector with an output modified. The vectors of a gadget on a
+// curve are written as a JSON Fixture in testdata, and checked again by the tests of the package, so
+// that a regression of a gadget or of its native implementation, on any curve, is caught:
+//
+//	go generate ./std/kat   // writes testdata/<gadget>_<curve>.json
+//	go test ./std/kat       // checks the fixtures
+//
+// A new gadget implements Gadget, and is added to Gadgets.
+package kat
+
+//go:generate go run ./internal/generate testdata
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"math/rand"
+	"sort"
+
+	"github.com/consensys/gnark/backend/r1cs"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gurvy"
+)
+
+var (
+	// ErrMismatch is returned by Check when the native implementation doesn't compute the outputs of
+	// a vector
+	ErrMismatch = errors.New("kat: the native implementation doesn't match the vector")
+	// ErrRejected is returned when the circuit of a gadget is not solved by a vector
+	ErrRejected = errors.New("kat: the circuit rejects the vector")
+	// ErrAccepted is returned when the circuit of a gadget is solved by a vector with a wrong output
+	ErrAccepted = errors.New("kat: the circuit accepts a wrong output")
+
+	errCurve = errors.New("kat: unsupported curve")
+)
+
+// Vector is a known answer: the inputs and the outputs of the native implementation, as decimal strings
+type Vector struct {
+	Inputs  map[string]string `json:"inputs"`
+	Outputs map[string]string `json:"outputs"`
+}
+
+// Fixture is a list of vectors of a gadget on a curve
+type Fixture struct {
+	Gadget  string   `json:"gadget"`
+	Curve   string   `json:"curve"`
+	Vectors []Vector `json:"vectors"`
+}
+
+// Gadget computes the vectors of a std gadget, and the circuits checking them
+type Gadget interface {
+	// Name of the gadget, in the names of the fixtures
+	Name() string
+	// Curves the native implementation supports
+	Curves() []gurvy.ID
+	// Inputs returns random inputs
+	Inputs(curveID gurvy.ID, rnd *rand.Rand) map[string]string
+	// Compute returns the outputs of the native implementation
+	Compute(curveID gurvy.ID, inputs map[string]string) (map[string]string, error)
+	// Circuit returns the circuit of the gadget, not assigned, and its assignment for a vector
+	Circuit(curveID gurvy.ID, v Vector) (frontend.Circuit, map[string]interface{}, error)
+}
+
+// Gadgets are the gadgets of std with known answer vectors
+var Gadgets = []Gadget{mimcGadget{}, merkleGadget{}, eddsaGadget{}}
+
+// Lookup returns the gadget of Gadgets with a given name, or nil
+func Lookup(name string) Gadget {
+	for _, g := range Gadgets {
+		if g.Name() == name {
+			return g
+		}
+	}
+	return nil
+}
+
+// FileName returns the name of the fixture of a gadget on a curve, <gadget>_<curve>.json
+func FileName(g Gadget, curveID gurvy.ID) string {
+	return g.Name() + "_" + curveID.String() + ".json"
+}
+
+// Generate returns n vectors of a gadget, computed from random inputs (from seed), and cross-checked on
+// the circuit of the gadget
+func Generate(g Gadget, curveID gurvy.ID, n int, seed int64) (*Fixture, error) {
+	if !supports(g, curveID) {
+		return nil, errCurve
+	}
+	rnd := rand.New(rand.NewSource(seed))
+	f := &Fixture{Gadget: g.Name(), Curve: curveID.String()}
+	for i := 0; i < n; i++ {
+		v := Vector{Inputs: g.Inputs(curveID, rnd)}
+		var err error
+		if v.Outputs, err = g.Compute(curveID, v.Inputs); err != nil {
+			return nil, err
+		}
+		if err := checkCircuit(g, curveID, v); err != nil {
+			return nil, fmt.Errorf("%s on %s, vector %d: %w", g.Name(), curveID, i, err)
+		}
+		f.Vectors = append(f.Vectors, v)
+	}
+	return f, nil
+}
+
+// Check checks the vectors of a fixture against the native implementation and the circuit of the gadget
+func Check(f *Fixture) error {
+	g := Lookup(f.Gadget)
+	if g == nil {
+		return fmt.Errorf("kat: unknown gadget %q", f.Gadget)
+	}
+	curveID := gurvy.UNKNOWN
+	for _, c := range g.Curves() {
+		if c.String() == f.Curve {
+			curveID = c
+		}
+	}
+	if curveID == gurvy.UNKNOWN {
+		return fmt.Errorf("%s on %s: %w", f.Gadget, f.Curve, errCurve)
+	}
+	for i, v := range f.Vectors {
+		outputs, err := g.Compute(curveID, v.Inputs)
+		if err != nil {
+			return err
+		}
+		for _, name := range sortedKeys(v.Outputs) {
+			if outputs[name] != v.Outputs[name] {
+				return fmt.Errorf("%s on %s, vector %d: %w: %s = %s, expected %s", f.Gadget, f.Curve, i, ErrMismatch, name, outputs[name], v.Outputs[name])
+			}
+		}
+		if len(outputs) != len(v.Outputs) {
+			return fmt.Errorf("%s on %s, vector %d: %w: %d outputs, expected %d", f.Gadget, f.Curve, i, ErrMismatch, len(outputs), len(v.Outputs))
+		}
+		if err := checkCircuit(g, curveID, v); err != nil {
+			return fmt.Errorf("%s on %s, vector %d: %w", f.Gadget, f.Curve, i, err)
+		}
+	}
+	return nil
+}
+
+// checkCircuit checks that the circuit of a gadget is solved by a vector, and not by the vector with
+// an output incremented
+func checkCircuit(g Gadget, curveID gurvy.ID, v Vector) error {
+	circuit, assignment, err := g.Circuit(curveID, v)
+	if err != nil {
+		return err
+	}
+	_r1cs, err := frontend.Compile(curveID, circuit)
+	if err != nil {
+		return err
+	}
+	if err := isSolved(_r1cs, assignment); err != nil {
+		return fmt.Errorf("%w: %v", ErrRejected, err)
+	}
+	for _, name := range sortedKeys(v.Outputs) {
+		tampered := Vector{Inputs: v.Inputs, Outputs: make(map[string]string, len(v.Outputs))}
+		for k, value := range v.Outputs {
+			tampered.Outputs[k] = value
+		}
+		var value big.Int
+		if _, ok := value.SetString(v.Outputs[name], 10); !ok {
+			return fmt.Errorf("kat: invalid value %s = %q", name, v.Outputs[name])
+		}
+		tampered.Outputs[name] = value.Add(&value, big.NewInt(1)).String()
+		_, assignment, err := g.Circuit(curveID, tampered)
+		if err != nil {
+			return err
+		}
+		if isSolved(_r1cs, assignment) == nil {
+			return fmt.Errorf("%w: %s + 1", ErrAccepted, name)
+		}
+	}
+	return nil
+}
+
+// isSolved returns the result of the solver, or an error if it panics (on an invalid witness, the
+// solver may divide by zero)
+func isSolved(_r1cs r1cs.R1CS, assignment map[string]interface{}) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("solver panic: %v", r)
+		}
+	}()
+	return _r1cs.IsSolved(assignment)
+}
+
+// WriteFixture writes a fixture in JSON
+func WriteFixture(w io.Writer, f *Fixture) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(f)
+}
+
+// ReadFixture reads a fixture written by WriteFixture
+func ReadFixture(r io.Reader) (*Fixture, error) {
+	var f Fixture
+	if err := json.NewDecoder(r).Decode(&f); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+func supports(g Gadget, curveID gurvy.ID) bool {
+	for _, c := range g.Curves() {
+		if c == curveID {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
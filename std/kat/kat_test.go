@@ -0,0 +1,108 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kat
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gurvy"
+	"github.com/stretchr/testify/require"
+)
+
+// the fixtures of testdata match the gadgets and their native implementations, on every curve
+func TestFixtures(t *testing.T) {
+	for _, g := range Gadgets {
+		for _, curveID := range g.Curves() {
+			f, err := os.Open(filepath.Join("testdata", FileName(g, curveID)))
+			require.NoError(t, err, "run go generate to write the missing fixtures")
+			fixture, err := ReadFixture(f)
+			f.Close()
+			require.NoError(t, err)
+			require.NotEmpty(t, fixture.Vectors)
+			require.NoError(t, Check(fixture))
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	assert := require.New(t)
+	f, err := Generate(mimcGadget{}, gurvy.BN256, 2, 1)
+	assert.NoError(err)
+	assert.Len(f.Vectors, 2)
+
+	// the fixtures are reproducible
+	var b1, b2 bytes.Buffer
+	assert.NoError(WriteFixture(&b1, f))
+	f, err = Generate(mimcGadget{}, gurvy.BN256, 2, 1)
+	assert.NoError(err)
+	assert.NoError(WriteFixture(&b2, f))
+	assert.Equal(b1.String(), b2.String())
+
+	read, err := ReadFixture(&b1)
+	assert.NoError(err)
+	assert.Equal(f, read)
+
+	_, err = Generate(eddsaGadget{}, gurvy.BLS377, 1, 1)
+	assert.Equal(errCurve, err)
+}
+
+func TestCheck(t *testing.T) {
+	assert := require.New(t)
+	f, err := Generate(mimcGadget{}, gurvy.BLS381, 1, 1)
+	assert.NoError(err)
+	assert.NoError(Check(f))
+
+	f.Vectors[0].Outputs["hash"] = "42"
+	assert.True(errors.Is(Check(f), ErrMismatch))
+	f.Curve = "bw761"
+	assert.True(errors.Is(Check(f), errCurve))
+	f.Gadget = "sha256"
+	assert.Error(Check(f))
+}
+
+// brokenGadget is MiMC with a circuit which doesn't use its data
+type brokenGadget struct {
+	mimcGadget
+}
+
+type brokenCircuit struct {
+	Data []frontend.Variable
+	Hash frontend.Variable `gnark:",public"`
+}
+
+func (circuit *brokenCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	cs.AssertIsEqual(circuit.Hash, circuit.Hash)
+	return nil
+}
+
+func (g brokenGadget) Circuit(curveID gurvy.ID, v Vector) (frontend.Circuit, map[string]interface{}, error) {
+	_, assignment, err := g.mimcGadget.Circuit(curveID, v)
+	return &brokenCircuit{Data: make([]frontend.Variable, len(v.Inputs))}, assignment, err
+}
+
+// a circuit accepting a wrong output is caught
+func TestCheckCircuit(t *testing.T) {
+	f, err := Generate(mimcGadget{}, gurvy.BN256, 1, 1)
+	require.NoError(t, err)
+	err = checkCircuit(brokenGadget{}, gurvy.BN256, f.Vectors[0])
+	require.True(t, errors.Is(err, ErrAccepted), "%v", err)
+}
@@ -24,6 +24,7 @@ import (
 	eddsa_bn256 "github.com/consensys/gnark/crypto/signature/eddsa/bn256"
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/std/algebra/twistededwards"
+	"github.com/consensys/gnark/test"
 	"github.com/consensys/gurvy"
 	fr_bn256 "github.com/consensys/gurvy/bn256/fr"
 )
@@ -115,3 +116,35 @@ func TestEddsa(t *testing.T) {
 		assert.SolvingFailed(r1cs, &witness)
 	}
 }
+
+// TestEddsaEngine runs the signature verification with the test engine, without compiling the circuit
+func TestEddsaEngine(t *testing.T) {
+
+	assert := test.NewAssert(t)
+
+	var seed [32]byte
+	copy(seed[:], "eddsa")
+	pubKey, privKey := eddsa_bn256.New(seed, mimc_bn256.NewMiMC("seed"))
+
+	var frMsg fr_bn256.Element
+	frMsg.SetString("44717650746155748460101257525078853138837311576962212923649547644148297035978")
+	msgBin := frMsg.Bytes()
+	signature, err := eddsa_bn256.Sign(msgBin[:], pubKey, privKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var witness eddsaCircuit
+	witness.Message.Assign(frMsg)
+	witness.PublicKey.A.X.Assign(pubKey.A.X)
+	witness.PublicKey.A.Y.Assign(pubKey.A.Y)
+	witness.Signature.R.A.X.Assign(signature.R.X)
+	witness.Signature.R.A.Y.Assign(signature.R.Y)
+	witness.Signature.S.Assign(signature.S)
+	assert.SolvingSucceeded(&witness, gurvy.BN256)
+
+	// incorrect Message
+	witness.Message = frontend.Variable{}
+	witness.Message.Assign("44717650746155748460101257525078853138837311576962212923649547644148297035979")
+	assert.SolvingFailed(&witness, gurvy.BN256)
+}
@@ -0,0 +1,461 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/r1cs"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gurvy"
+	fr_bls377 "github.com/consensys/gurvy/bls377/fr"
+	fr_bls381 "github.com/consensys/gurvy/bls381/fr"
+	fr_bn256 "github.com/consensys/gurvy/bn256/fr"
+	fr_bw761 "github.com/consensys/gurvy/bw761/fr"
+)
+
+// Mismatch is a witness on which the test engine and the compiled circuit disagree
+//
+// if the engine accepts the witness and the solver (or the prover) rejects it, the constraints are
+// stricter than the circuit logic (completeness bug); if the engine rejects it and the solver accepts
+// it, a witness the circuit logic forbids can be proven (soundness bug)
+type Mismatch struct {
+	Witness map[string]interface{} // shrunk witness, values are *big.Int
+	Engine  error                  // result of the test engine
+	Solver  error                  // result of the solver, or of the prover (WithProver)
+}
+
+func (m *Mismatch) Error() string {
+	names := make([]string, 0, len(m.Witness))
+	for name := range m.Witness {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var sb strings.Builder
+	sb.WriteString("test engine and solver disagree on witness {")
+	for i, name := range names {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(fmt.Sprintf("%s: %s", name, m.Witness[name]))
+	}
+	sb.WriteString(fmt.Sprintf("}\n\tengine: %v\n\tsolver: %v", m.Engine, m.Solver))
+	return sb.String()
+}
+
+// FuzzOption configures Fuzz
+type FuzzOption func(*fuzzConfig)
+
+type fuzzConfig struct {
+	nbWitnesses int
+	witness     frontend.Circuit
+	randSeed    int64
+	prover      bool
+}
+
+// WithWitnesses sets the number of generated witnesses (default: 100)
+func WithWitnesses(n int) FuzzOption {
+	return func(c *fuzzConfig) {
+		c.nbWitnesses = n
+	}
+}
+
+// WithWitness sets a valid witness, half of the generated witnesses are mutations of it: random
+// witnesses rarely get past the first assertions of a circuit
+func WithWitness(witness frontend.Circuit) FuzzOption {
+	return func(c *fuzzConfig) {
+		c.witness = witness
+	}
+}
+
+// WithRandSeed sets the seed of the generator, to reproduce a run (default: 0)
+func WithRandSeed(seed int64) FuzzOption {
+	return func(c *fuzzConfig) {
+		c.randSeed = seed
+	}
+}
+
+// WithProver also runs groth16.Setup once, and groth16.Prove and groth16.Verify on the witnesses the
+// solver accepts
+func WithProver() FuzzOption {
+	return func(c *fuzzConfig) {
+		c.prover = true
+	}
+}
+
+// Fuzz generates random and boundary witnesses for the circuit, and checks that the test engine and
+// the solver of the compiled circuit accept the same witnesses
+//
+// circuit must not be compiled (it is copied; its values, if any, are ignored). Fuzz returns a *Mismatch, with a shrunk
+// witness (the inputs are made as small as possible), for the first witness on which they disagree.
+func Fuzz(circuit frontend.Circuit, curveID gurvy.ID, opts ...FuzzOption) error {
+	config := fuzzConfig{nbWitnesses: 100}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	f, err := newFuzzer(circuit, curveID, config)
+	if err != nil {
+		return err
+	}
+
+	_r1cs, err := frontend.Compile(curveID, copyCircuit(f.template))
+	if err != nil {
+		return err
+	}
+	var pk groth16.ProvingKey
+	var vk groth16.VerifyingKey
+	if config.prover {
+		if pk, vk, err = groth16.Setup(_r1cs); err != nil {
+			return err
+		}
+	}
+
+	f.engine = func(witness frontend.Circuit) error {
+		return IsSolved(witness, curveID)
+	}
+	f.solver = func(witness frontend.Circuit) error {
+		return solve(_r1cs, pk, vk, witness)
+	}
+	return f.run()
+}
+
+// solve returns the result of the solver, or of the prover if pk is set
+func solve(_r1cs r1cs.R1CS, pk groth16.ProvingKey, vk groth16.VerifyingKey, witness frontend.Circuit) (err error) {
+	// the solver panics on some invalid witnesses (for example an inverse of 0)
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("solver panic: %v", r)
+		}
+	}()
+	values, err := frontend.ParseWitness(witness)
+	if err != nil {
+		return err
+	}
+	if pk == nil {
+		return _r1cs.IsSolved(values)
+	}
+	proof, err := groth16.Prove(_r1cs, pk, values)
+	if err != nil {
+		return err
+	}
+	return groth16.Verify(proof, vk, values)
+}
+
+// fuzzer generates the values of the inputs of a circuit
+type fuzzer struct {
+	template frontend.Circuit
+	names    []string  // of the inputs, in the order of walkLeaves
+	seed     []big.Int // values of the WithWitness witness, if any
+	modulus  *big.Int
+	rand     *rand.Rand
+	config   fuzzConfig
+
+	engine, solver func(witness frontend.Circuit) error
+}
+
+func newFuzzer(circuit frontend.Circuit, curveID gurvy.ID, config fuzzConfig) (*fuzzer, error) {
+	f := &fuzzer{template: copyCircuit(circuit), rand: rand.New(rand.NewSource(config.randSeed)), config: config}
+	nbInputs := 0
+	walkLeaves(reflect.ValueOf(f.template), func(leaf *frontend.Variable) {
+		*leaf = frontend.Variable{}
+		nbInputs++
+	})
+	switch curveID {
+	case gurvy.BN256:
+		f.modulus = fr_bn256.Modulus()
+	case gurvy.BLS377:
+		f.modulus = fr_bls377.Modulus()
+	case gurvy.BLS381:
+		f.modulus = fr_bls381.Modulus()
+	case gurvy.BW761:
+		f.modulus = fr_bw761.Modulus()
+	default:
+		return nil, errors.New("unsupported curve")
+	}
+
+	// names of the inputs: assign offset+index to each leaf and read the witness back (the other
+	// values of the witness, as the padding and the length of the slices tagged with maxlen, are
+	// smaller than offset)
+	const offset = 1 << 40
+	indexes := make([]big.Int, nbInputs)
+	for i := range indexes {
+		indexes[i].SetInt64(offset + int64(i))
+	}
+	values, err := frontend.ParseWitness(f.witness(indexes))
+	if err != nil {
+		return nil, err
+	}
+	f.names = make([]string, nbInputs)
+	for name, v := range values {
+		i := toBigInt(v)
+		if i.Int64() >= offset {
+			f.names[i.Int64()-offset] = name
+		}
+	}
+
+	if config.witness != nil {
+		seed, err := frontend.ParseWitness(config.witness)
+		if err != nil {
+			return nil, err
+		}
+		f.seed = make([]big.Int, nbInputs)
+		for i, name := range f.names {
+			v, ok := seed[name]
+			if !ok {
+				return nil, fmt.Errorf("%q is not assigned in the witness", name)
+			}
+			value := toBigInt(v)
+			f.seed[i].Mod(&value, f.modulus)
+		}
+	}
+	return f, nil
+}
+
+// run checks nbWitnesses witnesses, and shrinks the first mismatch
+func (f *fuzzer) run() error {
+	for n := 0; n < f.config.nbWitnesses; n++ {
+		values := f.generate()
+		if m := f.check(values); m != nil {
+			return f.shrink(values, m)
+		}
+	}
+	return nil
+}
+
+// check returns a Mismatch if the engine and the solver disagree on values
+func (f *fuzzer) check(values []big.Int) *Mismatch {
+	engineErr := f.engine(f.witness(values))
+	solverErr := f.solver(f.witness(values))
+	if (engineErr == nil) == (solverErr == nil) {
+		return nil
+	}
+	return &Mismatch{Engine: engineErr, Solver: solverErr}
+}
+
+// shrink makes each value as small as possible, keeping a mismatch of the same kind
+func (f *fuzzer) shrink(values []big.Int, m *Mismatch) error {
+	engineAccepts := m.Engine == nil
+	stillFails := func(values []big.Int) *Mismatch {
+		if _m := f.check(values); _m != nil && (_m.Engine == nil) == engineAccepts {
+			return _m
+		}
+		return nil
+	}
+
+	for i := range values {
+		current := new(big.Int).Set(&values[i])
+		try := func(v *big.Int) bool {
+			values[i].Set(v)
+			if _m := stillFails(values); _m != nil {
+				m = _m
+				current.Set(v)
+				return true
+			}
+			values[i].Set(current)
+			return false
+		}
+		if current.Sign() == 0 || try(big.NewInt(0)) || current.Cmp(big.NewInt(1)) == 0 || try(big.NewInt(1)) {
+			continue
+		}
+		if f.seed != nil && try(&f.seed[i]) {
+			continue
+		}
+		// smallest failing value in [2, current], if failing is monotonic
+		lo, hi := big.NewInt(2), new(big.Int).Set(current)
+		for lo.Cmp(hi) < 0 {
+			mid := new(big.Int).Add(lo, hi)
+			mid.Rsh(mid, 1)
+			if try(mid) {
+				hi.Set(mid)
+			} else {
+				lo.Add(mid, big.NewInt(1))
+			}
+		}
+	}
+
+	m.Witness = make(map[string]interface{}, len(values))
+	for i, name := range f.names {
+		m.Witness[name] = new(big.Int).Set(&values[i])
+	}
+	return m
+}
+
+// generate returns random values for the inputs
+func (f *fuzzer) generate() []big.Int {
+	values := make([]big.Int, len(f.names))
+	mutate := f.seed != nil && f.rand.Intn(2) == 0
+	for i := range values {
+		if mutate {
+			// change one input out of 4, at least one
+			values[i].Set(&f.seed[i])
+			if f.rand.Intn(4) != 0 {
+				continue
+			}
+		}
+		f.randomValue(&values[i])
+	}
+	if mutate && len(values) > 0 {
+		f.randomValue(&values[f.rand.Intn(len(values))])
+	}
+	return values
+}
+
+// randomValue sets v to a boundary value, a small value, or a random field element
+func (f *fuzzer) randomValue(v *big.Int) {
+	switch f.rand.Intn(4) {
+	case 0:
+		// boundaries of the field
+		boundaries := []int64{0, 1, 2, -1, -2}
+		v.SetInt64(boundaries[f.rand.Intn(len(boundaries))])
+		if f.rand.Intn(4) == 0 {
+			v.Add(v, new(big.Int).Rsh(f.modulus, 1)) // (p-1)/2 and around
+		}
+	case 1:
+		// powers of 2 and around (bit sizes of range checks and binary decompositions)
+		v.Lsh(big.NewInt(1), uint(f.rand.Intn(f.modulus.BitLen())))
+		v.Add(v, big.NewInt(int64(f.rand.Intn(3)-1)))
+	case 2:
+		v.SetInt64(f.rand.Int63n(256))
+	default:
+		v.Rand(f.rand, f.modulus)
+	}
+	v.Mod(v, f.modulus)
+}
+
+// witness returns a copy of the (unassigned) template with the inputs assigned
+func (f *fuzzer) witness(values []big.Int) frontend.Circuit {
+	witness := copyCircuit(f.template)
+	i := 0
+	walkLeaves(reflect.ValueOf(witness), func(leaf *frontend.Variable) {
+		leaf.Assign(*new(big.Int).Set(&values[i]))
+		i++
+	})
+	return witness
+}
+
+// walkLeaves calls f on the inputs of a circuit, in the order of the fields (and of the sorted keys of
+// the maps, see frontend.Tag)
+func walkLeaves(v reflect.Value, f func(leaf *frontend.Variable)) {
+	tVariable := reflect.TypeOf(frontend.Variable{})
+	var walk func(v reflect.Value)
+	walk = func(v reflect.Value) {
+		switch v.Kind() {
+		case reflect.Ptr:
+			if !v.IsNil() {
+				walk(v.Elem())
+			}
+		case reflect.Struct:
+			if v.Type() == tVariable {
+				f(v.Addr().Interface().(*frontend.Variable))
+				return
+			}
+			for i := 0; i < v.NumField(); i++ {
+				field := v.Type().Field(i)
+				if field.PkgPath != "" || strings.Split(field.Tag.Get("gnark"), ",")[0] == "-" {
+					continue // unexported or omitted
+				}
+				walk(v.Field(i))
+			}
+		case reflect.Slice, reflect.Array:
+			for i := 0; i < v.Len(); i++ {
+				walk(v.Index(i))
+			}
+		case reflect.Map:
+			if v.Type().Key().Kind() != reflect.String {
+				return
+			}
+			keys := v.MapKeys()
+			sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+			for _, key := range keys {
+				// map values are not addressable: f is called on a copy, stored back
+				value := reflect.New(v.Type().Elem()).Elem()
+				value.Set(v.MapIndex(key))
+				walk(value)
+				v.SetMapIndex(key, value)
+			}
+		}
+	}
+	walk(v)
+}
+
+// copyCircuit returns a deep copy of the exported fields of circuit
+func copyCircuit(circuit frontend.Circuit) frontend.Circuit {
+	src := reflect.ValueOf(circuit)
+	dst := reflect.New(src.Elem().Type())
+	deepCopy(dst.Elem(), src.Elem())
+	return dst.Interface().(frontend.Circuit)
+}
+
+func deepCopy(dst, src reflect.Value) {
+	switch src.Kind() {
+	case reflect.Ptr:
+		if !src.IsNil() {
+			dst.Set(reflect.New(src.Elem().Type()))
+			deepCopy(dst.Elem(), src.Elem())
+		}
+	case reflect.Struct:
+		dst.Set(src)
+		for i := 0; i < src.NumField(); i++ {
+			if dst.Field(i).CanSet() {
+				deepCopy(dst.Field(i), src.Field(i))
+			}
+		}
+	case reflect.Slice:
+		if !src.IsNil() {
+			dst.Set(reflect.MakeSlice(src.Type(), src.Len(), src.Len()))
+			for i := 0; i < src.Len(); i++ {
+				deepCopy(dst.Index(i), src.Index(i))
+			}
+		}
+	case reflect.Array:
+		for i := 0; i < src.Len(); i++ {
+			deepCopy(dst.Index(i), src.Index(i))
+		}
+	case reflect.Map:
+		if !src.IsNil() {
+			dst.Set(reflect.MakeMapWithSize(src.Type(), src.Len()))
+			iter := src.MapRange()
+			for iter.Next() {
+				value := reflect.New(src.Type().Elem()).Elem()
+				deepCopy(value, iter.Value())
+				dst.SetMapIndex(iter.Key(), value)
+			}
+		}
+	default:
+		dst.Set(src)
+	}
+}
+
+func toBigInt(v interface{}) big.Int {
+	switch t := v.(type) {
+	case big.Int:
+		return t
+	case *big.Int:
+		return *t
+	}
+	var res big.Int
+	res.SetString(fmt.Sprint(v), 10)
+	return res
+}
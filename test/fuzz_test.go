@@ -0,0 +1,79 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/internal/backend/circuits"
+	"github.com/consensys/gurvy"
+)
+
+// the test engine agrees with the solver of the compiled circuits, on generated witnesses
+func TestFuzzCircuits(t *testing.T) {
+	assert := NewAssert(t)
+	for name, circuit := range circuits.Circuits {
+		t.Log(name)
+		assert.Fuzz(circuit.Good, []FuzzOption{WithWitness(circuit.Good), WithWitnesses(50)})
+	}
+}
+
+func TestFuzzProver(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping prover fuzzing in short mode")
+	}
+	var witness cubicCircuit
+	witness.X.Assign(3)
+	witness.Y.Assign(35)
+	NewAssert(t).Fuzz(&witness, []FuzzOption{WithWitness(&witness), WithWitnesses(20), WithProver()}, gurvy.BN256)
+}
+
+// the mismatches are shrunk
+func TestFuzzShrink(t *testing.T) {
+	assert := NewAssert(t)
+
+	var circuit cubicCircuit
+	f, err := newFuzzer(&circuit, gurvy.BN256, fuzzConfig{nbWitnesses: 100})
+	assert.NoError(err)
+	assert.Equal([]string{"X", "Y"}, f.names)
+
+	// a "solver" accepting everything but X > 1000
+	errTooLarge := errors.New("X > 1000")
+	f.engine = func(witness frontend.Circuit) error {
+		return nil
+	}
+	f.solver = func(witness frontend.Circuit) error {
+		values, err := frontend.ParseWitness(witness)
+		assert.NoError(err)
+		x := values["X"].(big.Int)
+		if x.Cmp(big.NewInt(1000)) > 0 {
+			return errTooLarge
+		}
+		return nil
+	}
+
+	err = f.run()
+	var m *Mismatch
+	assert.True(errors.As(err, &m), "expected a mismatch, got %v", err)
+	assert.NoError(m.Engine)
+	assert.Equal(errTooLarge, m.Solver)
+	assert.Equal(0, m.Witness["X"].(*big.Int).Cmp(big.NewInt(1001)), m.Error())
+	assert.Equal(0, m.Witness["Y"].(*big.Int).Sign(), m.Error())
+}
@@ -0,0 +1,94 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package test executes circuits on a witness without compiling them, to unit test circuits
+//
+// The test engine (frontend.Evaluate) runs Define with the values of the witness: each operation
+// computes its result in the scalar field of the curve, and each assertion is checked when it is
+// called. There is no R1CS and no key, so a test runs in milliseconds, and a failing assertion is
+// reported with its location in Define:
+//
+//	func TestCubic(t *testing.T) {
+//		assert := test.NewAssert(t)
+//		var witness Circuit
+//		witness.X.Assign(3)
+//		witness.Y.Assign(35)
+//		assert.SolvingSucceeded(&witness)
+//	}
+//
+// Fuzz cross-checks the test engine against the solver of the compiled circuit, on generated
+// witnesses.
+//
+// groth16.Assert checks the same properties on the compiled circuit, with the prover.
+package test
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gurvy"
+	"github.com/stretchr/testify/require"
+)
+
+// Curves are the curves of the Assert helpers, when none is given
+var Curves = []gurvy.ID{gurvy.BN256, gurvy.BLS377, gurvy.BLS381, gurvy.BW761}
+
+// IsSolved returns nil if the witness satisfies the assertions of its circuit on the scalar field of
+// the curve, or the first unsatisfied assertion; see frontend.Evaluate
+func IsSolved(witness frontend.Circuit, curveID gurvy.ID) error {
+	return frontend.Evaluate(curveID, witness)
+}
+
+// Assert is a helper to test circuits with the test engine
+type Assert struct {
+	*require.Assertions
+}
+
+// NewAssert returns an Assert helper
+func NewAssert(t *testing.T) *Assert {
+	return &Assert{require.New(t)}
+}
+
+// SolvingSucceeded checks that the witness satisfies its circuit, on the given curves (default: Curves)
+func (assert *Assert) SolvingSucceeded(witness frontend.Circuit, curves ...gurvy.ID) {
+	if len(curves) == 0 {
+		curves = Curves
+	}
+	for _, curveID := range curves {
+		assert.NoError(IsSolved(witness, curveID), "%s", curveID)
+	}
+}
+
+// SolvingFailed checks that the witness doesn't satisfy its circuit, on the given curves (default: Curves)
+func (assert *Assert) SolvingFailed(witness frontend.Circuit, curves ...gurvy.ID) {
+	if len(curves) == 0 {
+		curves = Curves
+	}
+	for _, curveID := range curves {
+		assert.Error(IsSolved(witness, curveID), "%s", curveID)
+	}
+}
+
+// Fuzz checks that the test engine and the solver of the compiled circuit accept the same generated
+// witnesses, on the given curves (default: Curves); see Fuzz
+func (assert *Assert) Fuzz(circuit frontend.Circuit, opts []FuzzOption, curves ...gurvy.ID) {
+	if len(curves) == 0 {
+		curves = Curves
+	}
+	for _, curveID := range curves {
+		assert.NoError(Fuzz(circuit, curveID, opts...), "%s", curveID)
+	}
+}
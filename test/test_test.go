@@ -0,0 +1,104 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/internal/backend/circuits"
+	"github.com/consensys/gurvy"
+)
+
+// the test engine agrees with the solver of the compiled circuits
+func TestCircuits(t *testing.T) {
+	for name, circuit := range circuits.Circuits {
+		for _, curveID := range Curves {
+			r1cs := circuit.R1CS.ToR1CS(curveID)
+			good, err := frontend.ParseWitness(circuit.Good)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := r1cs.IsSolved(good); err != nil {
+				t.Fatal(err)
+			}
+			if err := IsSolved(circuit.Good, curveID); err != nil {
+				t.Fatalf("%s (%s): %v", name, curveID, err)
+			}
+			if err := IsSolved(circuit.Bad, curveID); !errors.Is(err, backend.ErrUnsatisfiedConstraint) {
+				t.Fatalf("%s (%s): expected an unsatisfied constraint, got %v", name, curveID, err)
+			}
+		}
+	}
+}
+
+type cubicCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+// x**3 + x + 5 == y
+func (circuit *cubicCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	x3 := cs.Mul(circuit.X, circuit.X, circuit.X)
+	cs.AssertIsEqual(circuit.Y, cs.Add(x3, circuit.X, 5))
+	return nil
+}
+
+func TestAssert(t *testing.T) {
+	assert := NewAssert(t)
+
+	var good, bad, missing cubicCircuit
+	good.X.Assign(3)
+	good.Y.Assign(35)
+	assert.SolvingSucceeded(&good)
+
+	bad.X.Assign(3)
+	bad.Y.Assign(36)
+	assert.SolvingFailed(&bad)
+
+	// the error gives the values and the location of the assertion in Define
+	err := IsSolved(&bad, gurvy.BN256)
+	assert.True(errors.Is(err, backend.ErrUnsatisfiedConstraint))
+	assert.True(strings.Contains(err.Error(), "[36 != 35]"), err.Error())
+	assert.True(strings.Contains(err.Error(), "test_test.go"), err.Error())
+
+	missing.X.Assign(3)
+	assert.True(errors.Is(IsSolved(&missing, gurvy.BN256), backend.ErrInputNotSet))
+	assert.Error(IsSolved(&good, gurvy.UNKNOWN))
+}
+
+type squareCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+// Define overwrites X
+func (circuit *squareCircuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	circuit.X = cs.Mul(circuit.X, circuit.X)
+	cs.AssertIsEqual(circuit.X, circuit.Y)
+	return nil
+}
+
+func TestWitnessNotModified(t *testing.T) {
+	var witness squareCircuit
+	witness.X.Assign(3)
+	witness.Y.Assign(9)
+	NewAssert(t).SolvingSucceeded(&witness)
+}
@@ -0,0 +1,51 @@
+// Copyright © 2020 ConsenSys
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// gnark.js loads gnark.wasm (see main.go) and exposes the gnark Groth16 prover and verifier.
+//
+// wasm_exec.js, from the Go distribution that built gnark.wasm ($(go env GOROOT)/lib/wasm or misc/wasm),
+// must be loaded first: it defines the global Go class.
+//
+//	const gnark = await loadGnark(fetch("gnark.wasm"));
+//	const proof = await gnark.prove("bn256", r1cs, pk, JSON.stringify({ X: "3", Y: "35" }));
+//	await gnark.verify("bn256", proof, vk, JSON.stringify({ Y: "35" }));
+//
+// r1cs, pk, vk and proof are Uint8Array, serialized by the WriteTo methods of the Go objects.
+// Witnesses are JSON objects mapping the input names to decimal or hexadecimal ("0x") strings.
+// Every function returns a Promise, rejected with the Go error.
+"use strict";
+
+/**
+ * @param {Response | Promise<Response> | BufferSource} source content of gnark.wasm
+ * @returns {Promise<{
+ *   isSolved(curve: string, r1cs: Uint8Array, witness: string): Promise<boolean>,
+ *   prove(curve: string, r1cs: Uint8Array, pk: Uint8Array, witness: string): Promise<Uint8Array>,
+ *   verify(curve: string, proof: Uint8Array, vk: Uint8Array, publicWitness: string): Promise<boolean>,
+ * }>}
+ */
+async function loadGnark(source) {
+    const go = new Go();
+    source = await source;
+    const { instance } = (typeof Response !== "undefined" && source instanceof Response)
+        ? await WebAssembly.instantiateStreaming(source, go.importObject)
+        : await WebAssembly.instantiate(source, go.importObject);
+
+    // go.run resolves when the Go program exits, which it doesn't: main sets the global gnark object and blocks
+    go.run(instance);
+    return globalThis.gnark;
+}
+
+if (typeof module !== "undefined") {
+    module.exports = { loadGnark };
+}
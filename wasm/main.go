@@ -0,0 +1,149 @@
+//go:build js && wasm
+// +build js,wasm
+
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Command wasm exposes the gnark Groth16 prover and verifier to JavaScript (see gnark.js)
+//
+//	GOOS=js GOARCH=wasm go build -o gnark.wasm github.com/consensys/gnark/wasm
+//
+// The circuits are compiled in Go: the R1CS and the keys are serialized with WriteTo, and loaded
+// in the browser with the witness, as JSON (see gnarkio.ReadWitness).
+//
+// The prover runs on a single thread and the wasm memory is limited to 4GB (the proving key and the
+// prover buffers are held in memory): client-side proving is meant for small circuits.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"syscall/js"
+
+	"github.com/consensys/gnark/internal/bindings"
+)
+
+func main() {
+	js.Global().Set("gnark", js.ValueOf(map[string]interface{}{
+		"isSolved": promise(isSolved),
+		"prove":    promise(prove),
+		"verify":   promise(verify),
+	}))
+
+	// the functions are called from JavaScript as long as the page (or node process) lives
+	select {}
+}
+
+// isSolved(curve, r1cs, witness) checks the witness (JSON) solves the R1CS
+func isSolved(args []js.Value) (interface{}, error) {
+	if err := checkArgs(args, 3); err != nil {
+		return nil, err
+	}
+	r1cs, err := toBytes(args[1])
+	if err != nil {
+		return nil, err
+	}
+	if err := bindings.IsSolved(args[0].String(), r1cs, args[2].String()); err != nil {
+		return nil, err
+	}
+	return true, nil
+}
+
+// prove(curve, r1cs, pk, witness) returns the proof (compressed, see Proof.WriteTo) of the witness (JSON)
+func prove(args []js.Value) (interface{}, error) {
+	if err := checkArgs(args, 4); err != nil {
+		return nil, err
+	}
+	r1cs, err := toBytes(args[1])
+	if err != nil {
+		return nil, err
+	}
+	pk, err := toBytes(args[2])
+	if err != nil {
+		return nil, err
+	}
+	proof, err := bindings.Prove(args[0].String(), r1cs, pk, args[3].String())
+	if err != nil {
+		return nil, err
+	}
+	res := js.Global().Get("Uint8Array").New(len(proof))
+	js.CopyBytesToJS(res, proof)
+	return res, nil
+}
+
+// verify(curve, proof, vk, publicWitness) checks the proof against the public inputs (JSON)
+func verify(args []js.Value) (interface{}, error) {
+	if err := checkArgs(args, 4); err != nil {
+		return nil, err
+	}
+	proof, err := toBytes(args[1])
+	if err != nil {
+		return nil, err
+	}
+	vk, err := toBytes(args[2])
+	if err != nil {
+		return nil, err
+	}
+	if err := bindings.Verify(args[0].String(), proof, vk, args[3].String()); err != nil {
+		return nil, err
+	}
+	return true, nil
+}
+
+// promise wraps f in a JavaScript function returning a Promise
+//
+// f runs in its own goroutine: a blocking js.Func would block the JavaScript event loop
+func promise(f func(args []js.Value) (interface{}, error)) js.Func {
+	return js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		executor := js.FuncOf(func(this js.Value, p []js.Value) interface{} {
+			resolve, reject := p[0], p[1]
+			go func() {
+				defer func() {
+					if r := recover(); r != nil {
+						reject.Invoke(js.Global().Get("Error").New(fmt.Sprint(r)))
+					}
+				}()
+				res, err := f(args)
+				if err != nil {
+					reject.Invoke(js.Global().Get("Error").New(err.Error()))
+					return
+				}
+				resolve.Invoke(res)
+			}()
+			return nil
+		})
+		// the executor is called synchronously by the Promise constructor
+		defer executor.Release()
+		return js.Global().Get("Promise").New(executor)
+	})
+}
+
+func checkArgs(args []js.Value, nbArgs int) error {
+	if len(args) != nbArgs {
+		return fmt.Errorf("expected %d arguments, got %d", nbArgs, len(args))
+	}
+	return nil
+}
+
+// toBytes returns the content of a Uint8Array
+func toBytes(data js.Value) ([]byte, error) {
+	if !data.InstanceOf(js.Global().Get("Uint8Array")) {
+		return nil, errors.New("expected a Uint8Array")
+	}
+	res := make([]byte, data.Length())
+	js.CopyBytesToGo(res, data)
+	return res, nil
+}
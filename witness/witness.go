@@ -0,0 +1,165 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package witness provides a witness decoupled from the circuit struct: the values of the inputs are
+// assigned by name, and the circuit definition is not modified.
+//
+//	w, err := witness.New(&circuit)
+//	w.Assign("X", 3)
+//	w.Assign("Y", 35)
+//	full, err := w.Full() // groth16.Prove(r1cs, pk, full)
+//	public, err := w.Public() // groth16.Verify(proof, vk, public)
+package witness
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/internal/backend/ioutils"
+	gnarkio "github.com/consensys/gnark/io"
+)
+
+// ErrUnknownInput is returned when a name is not the name of an input of the circuit
+var ErrUnknownInput = errors.New("unknown input")
+
+// Witness is the assignment of the inputs of a circuit, keyed by their names (see frontend.Tag)
+type Witness struct {
+	public, secret []string // names of the inputs, in the order of the wires
+	isPublic       map[string]bool
+	values         map[string]big.Int
+}
+
+// New returns an empty witness for the inputs of circuit (see frontend.Inputs)
+func New(circuit frontend.Circuit) (*Witness, error) {
+	public, secret, err := frontend.Inputs(circuit)
+	if err != nil {
+		return nil, err
+	}
+	w := &Witness{
+		public:   public,
+		secret:   secret,
+		isPublic: make(map[string]bool, len(public)+len(secret)),
+		values:   make(map[string]big.Int, len(public)+len(secret)),
+	}
+	for _, name := range public {
+		w.isPublic[name] = true
+	}
+	for _, name := range secret {
+		w.isPublic[name] = false
+	}
+	return w, nil
+}
+
+// Assign sets the value of the input name; value is converted to a big.Int (see backend.ToBigInt)
+//
+// an input can be assigned again, the last value is kept
+func (w *Witness) Assign(name string, value interface{}) error {
+	if _, ok := w.isPublic[name]; !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownInput, name)
+	}
+	v, err := backend.ToBigInt(value)
+	if err != nil {
+		return fmt.Errorf("%q: %w", name, err)
+	}
+	w.values[name] = v
+	return nil
+}
+
+// Value returns the value of the input name, if it is assigned
+func (w *Witness) Value(name string) (big.Int, bool) {
+	v, ok := w.values[name]
+	return v, ok
+}
+
+// PublicInputs returns the names of the public inputs, in the order of the wires
+func (w *Witness) PublicInputs() []string {
+	return append([]string(nil), w.public...)
+}
+
+// SecretInputs returns the names of the secret inputs, in the order of the wires
+func (w *Witness) SecretInputs() []string {
+	return append([]string(nil), w.secret...)
+}
+
+// Full returns the values of all the inputs, as expected by r1cs.R1CS.IsSolved or groth16.Prove
+//
+// it returns an error wrapping backend.ErrInputNotSet if an input is not assigned
+func (w *Witness) Full() (map[string]interface{}, error) {
+	res := make(map[string]interface{}, len(w.public)+len(w.secret))
+	if err := w.collect(res, w.public); err != nil {
+		return nil, err
+	}
+	if err := w.collect(res, w.secret); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Public returns the values of the public inputs, as expected by groth16.Verify
+//
+// it returns an error wrapping backend.ErrInputNotSet if a public input is not assigned
+func (w *Witness) Public() (map[string]interface{}, error) {
+	res := make(map[string]interface{}, len(w.public))
+	if err := w.collect(res, w.public); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (w *Witness) collect(res map[string]interface{}, names []string) error {
+	for _, name := range names {
+		v, ok := w.values[name]
+		if !ok {
+			return fmt.Errorf("%q: %w", name, backend.ErrInputNotSet)
+		}
+		res[name] = v
+	}
+	return nil
+}
+
+// WriteTo writes the assigned values in JSON (see gnark/io.WriteWitness)
+func (w *Witness) WriteTo(writer io.Writer) (int64, error) {
+	values := make(map[string]interface{}, len(w.values))
+	for name, v := range w.values {
+		values[name] = v
+	}
+	var buf bytes.Buffer
+	if err := gnarkio.WriteWitness(&buf, values); err != nil {
+		return 0, err
+	}
+	return buf.WriteTo(writer)
+}
+
+// ReadFrom assigns the values read from JSON (see gnark/io.ReadWitness); the values which are not
+// the inputs of the circuit are rejected
+func (w *Witness) ReadFrom(reader io.Reader) (int64, error) {
+	values := make(map[string]interface{})
+	_r := ioutils.ReaderCounter{R: reader} // wraps reader to count the bytes read
+	if err := gnarkio.ReadWitness(&_r, values); err != nil {
+		return _r.N, err
+	}
+	for name, v := range values {
+		if err := w.Assign(name, v); err != nil {
+			return _r.N, err
+		}
+	}
+	return _r.N, nil
+}
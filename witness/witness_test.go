@@ -0,0 +1,104 @@
+package witness
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gurvy"
+)
+
+type circuit struct {
+	X   frontend.Variable
+	Y   frontend.Variable   `gnark:",public"`
+	Msg []frontend.Variable `gnark:",maxlen=2"`
+}
+
+func (c *circuit) Define(curveID gurvy.ID, cs *frontend.ConstraintSystem) error {
+	// y = x**3 + msg[0] + msg[1]
+	x3 := cs.Mul(c.X, c.X, c.X)
+	cs.AssertIsEqual(cs.Add(x3, c.Msg[0], c.Msg[1]), c.Y)
+	return nil
+}
+
+func TestWitness(t *testing.T) {
+
+	var c circuit
+	w, err := New(&c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Msg != nil {
+		t.Fatal("New must not modify the circuit")
+	}
+	if !reflect.DeepEqual(w.PublicInputs(), []string{"Y"}) {
+		t.Fatal("unexpected public inputs", w.PublicInputs())
+	}
+	if !reflect.DeepEqual(w.SecretInputs(), []string{"X", "Msg_0", "Msg_1", "Msg_len"}) {
+		t.Fatal("unexpected secret inputs", w.SecretInputs())
+	}
+
+	if err := w.Assign("Z", 1); !errors.Is(err, ErrUnknownInput) {
+		t.Fatal("expected ErrUnknownInput, got", err)
+	}
+	for name, value := range map[string]interface{}{"X": 3, "Y": 35, "Msg_0": 7, "Msg_1": 1} {
+		if err := w.Assign(name, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := w.Full(); !errors.Is(err, backend.ErrInputNotSet) {
+		t.Fatal("expected ErrInputNotSet, got", err)
+	}
+	if err := w.Assign("Msg_len", 2); err != nil {
+		t.Fatal(err)
+	}
+
+	// the circuit is compiled after New
+	r1cs, err := frontend.Compile(gurvy.BN256, &c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	full, err := w.Full()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r1cs.IsSolved(full); err != nil {
+		t.Fatal(err)
+	}
+
+	// an input can be assigned again
+	if err := w.Assign("Msg_1", 2); err != nil {
+		t.Fatal(err)
+	}
+	full, _ = w.Full()
+	if err := r1cs.IsSolved(full); err == nil {
+		t.Fatal("expected the witness not to solve the circuit")
+	}
+
+	// serialization
+	var buf bytes.Buffer
+	if _, err := w.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	read, err := New(&circuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := read.ReadFrom(&buf); err != nil {
+		t.Fatal(err)
+	}
+	public, err := read.Public()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if y := public["Y"].(big.Int); y.Int64() != 35 {
+		t.Fatal("expected Y = 35, got", y.String())
+	}
+	if v, _ := read.Value("Msg_1"); v.Int64() != 2 {
+		t.Fatal("expected Msg_1 = 2, got", v.String())
+	}
+}